@@ -0,0 +1,152 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package backupccl
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/sql/mutations"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+// TestBackupRestoreRandomMutatedSchemas creates a database from randomly
+// generated and mutated schemas, backs it up, restores it into a fresh cluster
+// and verifies that the schemas and the data of all tables survived the round
+// trip. It acts as a compatibility fuzzer between BACKUP/RESTORE and the schema
+// features produced by the mutators.
+func TestBackupRestoreRandomMutatedSchemas(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	rng, _ := randutil.NewPseudoRand()
+	dir, dirCleanupFn := testutils.TempDir(t)
+	defer dirCleanupFn()
+
+	params := base.TestClusterArgs{}
+	params.ServerArgs.UseDatabase = "rand"
+	ctx, _, sqlDB, cleanup := backupRestoreTestSetupEmpty(t, singleNode, dir, InitManualReplication, params)
+	defer cleanup()
+
+	sqlDB.Exec(t, `SET CLUSTER SETTING sql.defaults.interleaved_tables.enabled = true`)
+	sqlDB.Exec(t, `CREATE DATABASE rand`)
+
+	stmts := rowenc.RandCreateTables(rng, "table", rng.Intn(5)+1,
+		mutations.StatisticsMutator,
+		mutations.ForeignKeyMutator,
+		mutations.ColumnFamilyMutator,
+		mutations.IndexStoringMutator,
+		mutations.PartialIndexMutator,
+		mutations.CollatedStringMutator,
+		mutations.EnumMutator,
+	)
+	for _, stmt := range stmts {
+		// Mutated statements are not guaranteed to be valid, so failed
+		// statements are skipped; the tables that were created are still
+		// checked.
+		if _, err := sqlDB.DB.ExecContext(ctx, tree.SerializeForDisplay(stmt)); err != nil {
+			t.Logf("skipping statement %s: %v", tree.SerializeForDisplay(stmt), err)
+		}
+	}
+	for _, stmt := range stmts {
+		if create, ok := stmt.(*tree.CreateTable); ok {
+			insertRandomRows(ctx, t, rng, sqlDB, create)
+		}
+	}
+
+	tables := sqlDB.QueryStr(t, `SELECT table_name FROM [SHOW TABLES FROM rand] ORDER BY table_name`)
+	if len(tables) == 0 {
+		t.Skip("none of the random tables could be created")
+	}
+
+	sqlDB.Exec(t, `BACKUP DATABASE rand TO $1`, LocalFoo)
+
+	_, _, restoreDB, restoreCleanup := backupRestoreTestSetupEmpty(t, singleNode, dir, InitManualReplication, params)
+	defer restoreCleanup()
+	restoreDB.Exec(t, `RESTORE DATABASE rand FROM $1`, LocalFoo)
+
+	restoredTables := restoreDB.QueryStr(t, `SELECT table_name FROM [SHOW TABLES FROM rand] ORDER BY table_name`)
+	if a, e := fmt.Sprint(restoredTables), fmt.Sprint(tables); a != e {
+		t.Fatalf("expected tables %s after restore, found %s", e, a)
+	}
+
+	for _, row := range tables {
+		name := tree.NameString(row[0])
+
+		// Compare the parsed and reformatted SHOW CREATE output, so that only
+		// semantic differences are reported.
+		showCreate := fmt.Sprintf(`SELECT create_statement FROM [SHOW CREATE TABLE rand.%s]`, name)
+		expected := normalizeCreateStatement(t, sqlDB.QueryStr(t, showCreate)[0][0])
+		actual := normalizeCreateStatement(t, restoreDB.QueryStr(t, showCreate)[0][0])
+		if expected != actual {
+			t.Fatalf("schema of table %s changed after restore:\nbefore: %s\nafter:  %s",
+				name, expected, actual)
+		}
+
+		query := fmt.Sprintf(`SELECT * FROM rand.%[1]s ORDER BY PRIMARY KEY rand.%[1]s`, name)
+		sqlDB.CheckQueryResults(t, query, restoreDB.QueryStr(t, query))
+	}
+}
+
+// insertRandomRows inserts a few rows with random values into the table
+// created by create. Columns whose types are not statically known (like
+// enums) and computed columns are left to their defaults, and rows that
+// violate constraints are skipped.
+func insertRandomRows(
+	ctx context.Context,
+	t *testing.T,
+	rng *rand.Rand,
+	sqlDB *sqlutils.SQLRunner,
+	create *tree.CreateTable,
+) {
+	for i, n := 0, rng.Intn(20); i < n; i++ {
+		var names, values []string
+		for _, def := range create.Defs {
+			col, ok := def.(*tree.ColumnTableDef)
+			if !ok || col.IsComputed() {
+				continue
+			}
+			typ, ok := tree.GetStaticallyKnownType(col.Type)
+			if !ok {
+				continue
+			}
+			d := rowenc.RandDatum(rng, typ, col.Nullable.Nullability != tree.NotNull)
+			names = append(names, col.Name.String())
+			values = append(values, tree.Serialize(d))
+		}
+		insert := fmt.Sprintf("INSERT INTO %s DEFAULT VALUES", create.Table.String())
+		if len(names) > 0 {
+			insert = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+				create.Table.String(), strings.Join(names, ", "), strings.Join(values, ", "))
+		}
+		if _, err := sqlDB.DB.ExecContext(ctx, insert); err != nil {
+			t.Logf("skipping row: %v", err)
+		}
+	}
+}
+
+// normalizeCreateStatement parses and reformats a CREATE statement.
+func normalizeCreateStatement(t *testing.T, stmt string) string {
+	parsed, err := parser.ParseOne(stmt)
+	if err != nil {
+		t.Fatalf("error parsing %s: %v", stmt, err)
+	}
+	return tree.AsStringWithFlags(parsed.AST, tree.FmtParsable)
+}
@@ -30,6 +30,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"math"
 	"math/rand"
 	"regexp"
 	"strings"
@@ -247,6 +248,15 @@ var Inputs = []Input{
 		),
 		args: oneNum,
 	},
+	{
+		sql: fromSlices(
+			"SELECT $1::%s %s $2::%s",
+			overflowNumTyps,
+			overflowNumOps,
+			overflowNumTyps,
+		),
+		args: twoOverflowNum,
+	},
 }
 
 var (
@@ -255,6 +265,8 @@ var (
 	oneNum    = []func() interface{}{num}
 	threeNum  = []func() interface{}{num, num, num}
 
+	twoOverflowNum = []func() interface{}{overflowNum, overflowNum}
+
 	binaryNumOps = []string{
 		"-",
 		"+",
@@ -278,6 +290,21 @@ var (
 		"float8",
 		"decimal",
 	}
+	// overflowNumOps are the operators which can overflow with operands close
+	// to the boundaries generated by overflowNum.
+	overflowNumOps = []string{
+		"-",
+		"+",
+		"*",
+		"//",
+		"%",
+	}
+	// overflowNumTyps omits decimal because its limits differ between postgres
+	// and cockroach.
+	overflowNumTyps = []string{
+		"int8",
+		"float8",
+	}
 )
 
 func pass(s string) func() string {
@@ -316,6 +343,27 @@ func num() interface{} {
 	}
 }
 
+// overflowNum generates a random number (int64 or float64) close to a
+// boundary at which arithmetic on it overflows.
+func overflowNum() interface{} {
+	offset := rand.Int63n(4)
+	switch rand.Intn(6) {
+	case 1:
+		return int64(math.MaxInt64) - offset
+	case 2:
+		return int64(math.MinInt64) + offset
+	case 3:
+		// Close to the square root of math.MaxInt64.
+		return int64(3037000499) + offset
+	case 4:
+		return math.MaxFloat64 / (1 + float64(offset)*1e-15)
+	case 5:
+		return -math.MaxFloat64 / (1 + float64(offset)*1e-15)
+	default:
+		return offset - 2
+	}
+}
+
 func likeArg(n int) func() interface{} {
 	return func() interface{} {
 		p := make([]byte, rng.Intn(n))
@@ -44,82 +44,7 @@ func CompareVals(a, b []interface{}) error {
 
 var (
 	cmpOptions = []cmp.Option{
-		cmp.Transformer("", func(x []interface{}) []interface{} {
-			out := make([]interface{}, len(x))
-			for i, v := range x {
-				switch t := v.(type) {
-				case *pgtype.TextArray:
-					if t.Status == pgtype.Present && len(t.Elements) == 0 {
-						v = ""
-					}
-				case *pgtype.BPCharArray:
-					if t.Status == pgtype.Present && len(t.Elements) == 0 {
-						v = ""
-					}
-				case *pgtype.VarcharArray:
-					if t.Status == pgtype.Present && len(t.Elements) == 0 {
-						v = ""
-					}
-				case *pgtype.Int8Array:
-					if t.Status == pgtype.Present && len(t.Elements) == 0 {
-						v = &pgtype.Int8Array{}
-					}
-				case *pgtype.Float8Array:
-					if t.Status == pgtype.Present && len(t.Elements) == 0 {
-						v = &pgtype.Float8Array{}
-					}
-				case *pgtype.UUIDArray:
-					if t.Status == pgtype.Present && len(t.Elements) == 0 {
-						v = &pgtype.UUIDArray{}
-					}
-				case *pgtype.ByteaArray:
-					if t.Status == pgtype.Present && len(t.Elements) == 0 {
-						v = &pgtype.ByteaArray{}
-					}
-				case *pgtype.InetArray:
-					if t.Status == pgtype.Present && len(t.Elements) == 0 {
-						v = &pgtype.InetArray{}
-					}
-				case *pgtype.TimestampArray:
-					if t.Status == pgtype.Present && len(t.Elements) == 0 {
-						v = &pgtype.TimestampArray{}
-					}
-				case *pgtype.BoolArray:
-					if t.Status == pgtype.Present && len(t.Elements) == 0 {
-						v = &pgtype.BoolArray{}
-					}
-				case *pgtype.DateArray:
-					if t.Status == pgtype.Present && len(t.Elements) == 0 {
-						v = &pgtype.BoolArray{}
-					}
-				case *pgtype.Varbit:
-					if t.Status == pgtype.Present {
-						s, _ := t.EncodeText(nil, nil)
-						v = string(s)
-					}
-				case *pgtype.Bit:
-					vb := pgtype.Varbit(*t)
-					v = &vb
-				case *pgtype.Interval:
-					if t.Status == pgtype.Present {
-						v = duration.DecodeDuration(int64(t.Months), int64(t.Days), t.Microseconds*1000)
-					}
-				case string:
-					// Postgres sometimes adds spaces to the end of a string.
-					t = strings.TrimSpace(t)
-					v = strings.Replace(t, "T00:00:00+00:00", "T00:00:00Z", 1)
-					v = strings.Replace(t, ":00+00:00", ":00", 1)
-				case *pgtype.Numeric:
-					if t.Status == pgtype.Present {
-						v = apd.NewWithBigInt(t.Int, t.Exp)
-					}
-				case int64:
-					v = apd.New(t, 0)
-				}
-				out[i] = v
-			}
-			return out
-		}),
+		cmp.Transformer("", normalizeVals),
 
 		cmpopts.EquateEmpty(),
 		cmpopts.EquateNaNs(),
@@ -148,3 +73,83 @@ var (
 	}
 	decimalCloseness = apd.New(1, -6)
 )
+
+// normalizeVals returns a copy of x with the values of types whose
+// representation differs between postgres and cockroach, but which are
+// semantically equal, converted into a common representation.
+func normalizeVals(x []interface{}) []interface{} {
+	out := make([]interface{}, len(x))
+	for i, v := range x {
+		switch t := v.(type) {
+		case *pgtype.TextArray:
+			if t.Status == pgtype.Present && len(t.Elements) == 0 {
+				v = ""
+			}
+		case *pgtype.BPCharArray:
+			if t.Status == pgtype.Present && len(t.Elements) == 0 {
+				v = ""
+			}
+		case *pgtype.VarcharArray:
+			if t.Status == pgtype.Present && len(t.Elements) == 0 {
+				v = ""
+			}
+		case *pgtype.Int8Array:
+			if t.Status == pgtype.Present && len(t.Elements) == 0 {
+				v = &pgtype.Int8Array{}
+			}
+		case *pgtype.Float8Array:
+			if t.Status == pgtype.Present && len(t.Elements) == 0 {
+				v = &pgtype.Float8Array{}
+			}
+		case *pgtype.UUIDArray:
+			if t.Status == pgtype.Present && len(t.Elements) == 0 {
+				v = &pgtype.UUIDArray{}
+			}
+		case *pgtype.ByteaArray:
+			if t.Status == pgtype.Present && len(t.Elements) == 0 {
+				v = &pgtype.ByteaArray{}
+			}
+		case *pgtype.InetArray:
+			if t.Status == pgtype.Present && len(t.Elements) == 0 {
+				v = &pgtype.InetArray{}
+			}
+		case *pgtype.TimestampArray:
+			if t.Status == pgtype.Present && len(t.Elements) == 0 {
+				v = &pgtype.TimestampArray{}
+			}
+		case *pgtype.BoolArray:
+			if t.Status == pgtype.Present && len(t.Elements) == 0 {
+				v = &pgtype.BoolArray{}
+			}
+		case *pgtype.DateArray:
+			if t.Status == pgtype.Present && len(t.Elements) == 0 {
+				v = &pgtype.BoolArray{}
+			}
+		case *pgtype.Varbit:
+			if t.Status == pgtype.Present {
+				s, _ := t.EncodeText(nil, nil)
+				v = string(s)
+			}
+		case *pgtype.Bit:
+			vb := pgtype.Varbit(*t)
+			v = &vb
+		case *pgtype.Interval:
+			if t.Status == pgtype.Present {
+				v = duration.DecodeDuration(int64(t.Months), int64(t.Days), t.Microseconds*1000)
+			}
+		case string:
+			// Postgres sometimes adds spaces to the end of a string.
+			t = strings.TrimSpace(t)
+			v = strings.Replace(t, "T00:00:00+00:00", "T00:00:00Z", 1)
+			v = strings.Replace(t, ":00+00:00", ":00", 1)
+		case *pgtype.Numeric:
+			if t.Status == pgtype.Present {
+				v = apd.NewWithBigInt(t.Int, t.Exp)
+			}
+		case int64:
+			v = apd.New(t, 0)
+		}
+		out[i] = v
+	}
+	return out
+}
@@ -0,0 +1,246 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package cmpconn
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/cockroachdb/apd/v2"
+	"github.com/cockroachdb/cockroach/pkg/sql/mutations"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/errors"
+	"github.com/jackc/pgx"
+	"github.com/jackc/pgx/pgtype"
+)
+
+// hashSignificantDigits is the number of significant digits that floats and
+// decimals are rounded to before being hashed. It approximates the tolerance
+// used by CompareVals.
+const hashSignificantDigits = 6
+
+var hashDecimalCtx = apd.BaseContext.WithPrecision(hashSignificantDigits)
+
+// ResultHash is an order-insensitive hash over the rows of a result set. Rows
+// are added one at a time, so arbitrarily large result sets can be compared
+// without buffering them. Values are normalized in the same way as in
+// CompareVals before being hashed, so result sets that CompareVals considers
+// equal (e.g. an INT and an equal DECIMAL) have equal hashes, with one
+// caveat: floats and decimals are rounded to hashSignificantDigits, so values
+// which are within the tolerance of CompareVals but round differently hash
+// differently. A hash mismatch should therefore be confirmed with CompareConns
+// before being reported.
+//
+// The hash of each row is computed over a canonical encoding of its values
+// tagged by their type, and the row hashes are combined with addition, which
+// (unlike XOR) doesn't cancel out duplicate rows. Hashes of disjoint parts of
+// a result set can be combined with Merge.
+//
+// The zero value is the hash of an empty result set.
+type ResultHash struct {
+	rows uint64
+	sum  [2]uint64
+	buf  []byte
+}
+
+// Add adds a row to the hash.
+func (h *ResultHash) Add(vals []interface{}) error {
+	h.buf = h.buf[:0]
+	for _, v := range normalizeVals(vals) {
+		var err error
+		if h.buf, err = appendCanonicalVal(h.buf, v); err != nil {
+			return err
+		}
+	}
+	hasher := fnv.New128a()
+	_, _ = hasher.Write(h.buf)
+	var sum [16]byte
+	hasher.Sum(sum[:0])
+	h.sum[0] += binary.BigEndian.Uint64(sum[:8])
+	h.sum[1] += binary.BigEndian.Uint64(sum[8:])
+	h.rows++
+	return nil
+}
+
+// Merge adds all of the rows of other to the hash.
+func (h *ResultHash) Merge(other *ResultHash) {
+	h.sum[0] += other.sum[0]
+	h.sum[1] += other.sum[1]
+	h.rows += other.rows
+}
+
+// Rows returns the number of rows added to the hash.
+func (h *ResultHash) Rows() uint64 {
+	return h.rows
+}
+
+// Equal returns whether the two hashes are equal.
+func (h *ResultHash) Equal(other *ResultHash) bool {
+	return h.rows == other.rows && h.sum == other.sum
+}
+
+func (h *ResultHash) String() string {
+	return fmt.Sprintf("%016x%016x (%d rows)", h.sum[0], h.sum[1], h.rows)
+}
+
+// HashRows consumes rows and returns their hash.
+func HashRows(rows *pgx.Rows) (ResultHash, error) {
+	var h ResultHash
+	for rows.Next() {
+		vals, err := rows.Values()
+		if err != nil {
+			return ResultHash{}, err
+		}
+		if err := h.Add(vals); err != nil {
+			return ResultHash{}, err
+		}
+	}
+	return h, rows.Err()
+}
+
+// CompareConnsByHash is like CompareConns, but compares the hashes of the
+// results instead of the results themselves, so the order of the rows is
+// ignored and the results are never buffered.
+// NOTE: exec will be mutated for each connection of type connWithMutators.
+func CompareConnsByHash(
+	ctx context.Context,
+	timeout time.Duration,
+	conns map[string]Conn,
+	prep, exec string,
+	ignoreSQLErrors bool,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var first ResultHash
+	var firstName string
+	for name, conn := range conns {
+		connExec := exec
+		if cwm, withMutators := conn.(*connWithMutators); withMutators {
+			connExec, _ = mutations.ApplyString(cwm.rng, exec, cwm.sqlMutators...)
+		}
+		rows, err := conn.Values(ctx, prep, connExec)
+		if err != nil {
+			return nil //nolint:returnerrcheck
+		}
+		h, err := HashRows(rows)
+		rows.Close()
+		if err != nil {
+			if ignoreSQLErrors {
+				return nil //nolint:returnerrcheck
+			}
+			return errors.Wrapf(err, "%s", name)
+		}
+		if firstName == "" {
+			first, firstName = h, name
+		} else if !first.Equal(&h) {
+			return errors.Newf(
+				"%s result hash %s differs from %s result hash %s\n%s;",
+				name, &h, firstName, &first, connExec,
+			)
+		}
+	}
+	return nil
+}
+
+// Tags for the types of the values in the canonical row encoding.
+const (
+	hashTagNull byte = iota
+	hashTagNumeric
+	hashTagFloat
+	hashTagBool
+	hashTagString
+	hashTagBytes
+	hashTagTime
+	hashTagDuration
+	hashTagOther
+)
+
+// appendCanonicalVal appends the canonical encoding of the normalized value v
+// to buf.
+func appendCanonicalVal(buf []byte, v interface{}) ([]byte, error) {
+	switch t := v.(type) {
+	case nil:
+		return append(buf, hashTagNull), nil
+	case *apd.Decimal:
+		return appendDecimal(buf, t), nil
+	case int16:
+		return appendDecimal(buf, apd.New(int64(t), 0)), nil
+	case int32:
+		return appendDecimal(buf, apd.New(int64(t), 0)), nil
+	case float32:
+		return appendFloat(buf, float64(t)), nil
+	case float64:
+		return appendFloat(buf, t), nil
+	case bool:
+		if t {
+			return append(buf, hashTagBool, 1), nil
+		}
+		return append(buf, hashTagBool, 0), nil
+	case string:
+		return appendTagged(buf, hashTagString, []byte(t)), nil
+	case []byte:
+		return appendTagged(buf, hashTagBytes, t), nil
+	case time.Time:
+		return appendTagged(buf, hashTagTime, []byte(t.UTC().Format(time.RFC3339Nano))), nil
+	case duration.Duration:
+		var nanos big.Int
+		t.AsBigInt(&nanos)
+		return appendTagged(buf, hashTagDuration, []byte(nanos.String())), nil
+	case pgtype.TextEncoder:
+		text, err := t.EncodeText(nil /* ci */, nil /* buf */)
+		if err != nil {
+			return nil, err
+		}
+		if text == nil {
+			// The value is NULL.
+			return append(buf, hashTagNull), nil
+		}
+		buf = appendTagged(buf, hashTagOther, []byte(fmt.Sprintf("%T", t)))
+		return appendTagged(buf, hashTagOther, text), nil
+	default:
+		return nil, errors.AssertionFailedf("unsupported type %T", v)
+	}
+}
+
+func appendDecimal(buf []byte, d *apd.Decimal) []byte {
+	var rounded apd.Decimal
+	_, _ = hashDecimalCtx.Round(&rounded, d)
+	rounded.Reduce(&rounded)
+	return appendTagged(buf, hashTagNumeric, []byte(rounded.String()))
+}
+
+func appendFloat(buf []byte, f float64) []byte {
+	if f == 0 {
+		// Normalize -0.
+		f = 0
+	}
+	var s string
+	if math.IsNaN(f) {
+		s = "NaN"
+	} else {
+		s = strconv.FormatFloat(f, 'g', hashSignificantDigits, 64)
+	}
+	return appendTagged(buf, hashTagFloat, []byte(s))
+}
+
+func appendTagged(buf []byte, tag byte, b []byte) []byte {
+	var lenBuf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(lenBuf[:], uint64(len(b)))
+	buf = append(buf, tag)
+	buf = append(buf, lenBuf[:n]...)
+	return append(buf, b...)
+}
@@ -0,0 +1,105 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package cmpconn
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cockroachdb/apd/v2"
+)
+
+func TestResultHash(t *testing.T) {
+	hash := func(rows ...[]interface{}) ResultHash {
+		var h ResultHash
+		for _, row := range rows {
+			if err := h.Add(row); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return h
+	}
+
+	for i, tc := range []struct {
+		equal bool
+		a, b  [][]interface{}
+	}{
+		{
+			// Row order is ignored.
+			equal: true,
+			a:     [][]interface{}{{int64(1), "a"}, {int64(2), "b"}},
+			b:     [][]interface{}{{int64(2), "b"}, {int64(1), "a"}},
+		},
+		{
+			// Duplicate rows don't cancel out.
+			equal: false,
+			a:     [][]interface{}{{int64(1)}, {int64(1)}},
+			b:     [][]interface{}{},
+		},
+		{
+			equal: false,
+			a:     [][]interface{}{{int64(1)}, {int64(1)}},
+			b:     [][]interface{}{{int64(1)}},
+		},
+		{
+			// Values are not swapped across columns.
+			equal: false,
+			a:     [][]interface{}{{"a", "b"}},
+			b:     [][]interface{}{{"b", "a"}},
+		},
+		{
+			// Column boundaries are part of the encoding.
+			equal: false,
+			a:     [][]interface{}{{"ab", ""}},
+			b:     [][]interface{}{{"a", "b"}},
+		},
+		{
+			equal: true,
+			a:     [][]interface{}{{int64(2)}},
+			b:     [][]interface{}{{apd.New(2000, -3)}},
+		},
+		{
+			equal: false,
+			a:     [][]interface{}{{int64(2)}},
+			b:     [][]interface{}{{float64(2)}},
+		},
+		{
+			equal: false,
+			a:     [][]interface{}{{nil}},
+			b:     [][]interface{}{{""}},
+		},
+		{
+			equal: true,
+			a:     [][]interface{}{{math.Copysign(0, -1)}, {math.NaN()}},
+			b:     [][]interface{}{{float64(0)}, {math.NaN()}},
+		},
+		{
+			equal: true,
+			a:     [][]interface{}{{1.0000001}},
+			b:     [][]interface{}{{1.0}},
+		},
+	} {
+		a, b := hash(tc.a...), hash(tc.b...)
+		if equal := a.Equal(&b); equal != tc.equal {
+			t.Errorf("%d: expected equal=%t, got %s and %s", i, tc.equal, &a, &b)
+		}
+	}
+
+	// Merging the hashes of parts of a result set is the same as hashing it
+	// as a whole.
+	whole := hash([]interface{}{int64(1)}, []interface{}{int64(2)}, []interface{}{int64(3)})
+	merged := hash([]interface{}{int64(3)}, []interface{}{int64(1)})
+	part := hash([]interface{}{int64(2)})
+	merged.Merge(&part)
+	if !whole.Equal(&merged) {
+		t.Errorf("expected merged hash %s to equal %s", &merged, &whole)
+	}
+}
@@ -12,6 +12,7 @@ package coldata
 
 import (
 	"fmt"
+	"math"
 	"strings"
 	"unsafe"
 
@@ -19,13 +20,30 @@ import (
 )
 
 // Bytes is a wrapper type for a two-dimensional byte slice ([][]byte).
+//
+// All values are stored in a single logical buffer which is made up of one or
+// more chunks. The first chunk is data, and when it runs out of capacity,
+// new chunks are allocated in chunks instead of reallocating data and copying
+// all of the values set so far. A single value never spans multiple chunks,
+// so Get can always return a view into one of them. Reset consolidates the
+// chunks into a single one, so that Bytes which are reused across batches
+// settle on a single flat buffer.
 type Bytes struct {
-	// data is the slice of all bytes.
+	// data is the first chunk of the logical buffer, which starts at the
+	// logical offset 0.
 	data []byte
-	// offsets contains the offsets for each []byte slice in data. Note that the
+	// chunks contains the chunks of the logical buffer following data. It is
+	// empty unless data ran out of capacity.
+	chunks [][]byte
+	// chunkOffsets contains the logical offset of the first byte of each chunk
+	// in chunks. Every chunk starts at the logical offset at which the previous
+	// one ended, so the logical buffer has no gaps.
+	chunkOffsets []int32
+	// offsets contains the logical offsets for each []byte slice. Note that the
 	// last offset (similarly to Arrow format) will contain the full length of
-	// data. Note that we assume that offsets are non-decreasing, and with every
-	// access of this Bytes we will try to maintain this assumption.
+	// the logical buffer. Note that we assume that offsets are non-decreasing,
+	// and with every access of this Bytes we will try to maintain this
+	// assumption.
 	offsets []int32
 
 	// maxSetIndex specifies the last index set by the user of this struct. This
@@ -107,7 +125,152 @@ func (b *Bytes) maybeBackfillOffsets(i int) {
 // undefined.
 //gcassert:inline
 func (b *Bytes) Get(i int) []byte {
-	return b.data[b.offsets[i]:b.offsets[i+1]]
+	if len(b.chunks) == 0 {
+		return b.data[b.offsets[i]:b.offsets[i+1]]
+	}
+	return b.getChunked(i)
+}
+
+// getChunked is the slow path of Get for when the logical buffer consists of
+// multiple chunks.
+func (b *Bytes) getChunked(i int) []byte {
+	start, end := b.offsets[i], b.offsets[i+1]
+	chunk, chunkOffset := b.chunk(b.chunkIdx(start))
+	return chunk[start-chunkOffset : end-chunkOffset]
+}
+
+// chunkIdx returns the index into b.chunks of the chunk that contains the
+// logical offset, or -1 if the logical offset is in b.data. An offset at the
+// boundary of two chunks belongs to the latter one.
+func (b *Bytes) chunkIdx(offset int32) int {
+	k := len(b.chunks) - 1
+	for k >= 0 && b.chunkOffsets[k] > offset {
+		k--
+	}
+	return k
+}
+
+// chunk returns the chunk with the given index (as returned by chunkIdx) and
+// its logical offset. Note that the length of the chunk might be stale if the
+// receiver is a view into another Bytes, so the chunk should be sliced based
+// on the offsets rather than on its length.
+func (b *Bytes) chunk(k int) ([]byte, int32) {
+	if k < 0 {
+		return b.data, 0
+	}
+	return b.chunks[k], b.chunkOffsets[k]
+}
+
+// chunkEnd returns the logical offset at which the chunk with the given index
+// ends, or math.MaxInt32 if it is the last chunk.
+func (b *Bytes) chunkEnd(k int) int32 {
+	if k+1 < len(b.chunks) {
+		return b.chunkOffsets[k+1]
+	}
+	return math.MaxInt32
+}
+
+// dataLen returns the length of the logical buffer.
+func (b *Bytes) dataLen() int32 {
+	if len(b.chunks) == 0 {
+		return int32(len(b.data))
+	}
+	last := len(b.chunks) - 1
+	return b.chunkOffsets[last] + int32(len(b.chunks[last]))
+}
+
+// truncateData truncates the logical buffer to the given length. Chunks that
+// start at or after the length are released.
+func (b *Bytes) truncateData(length int32) {
+	k := b.chunkIdx(length)
+	if k >= 0 && b.chunkOffsets[k] == length {
+		// The chunk would become empty, so we release it and continue
+		// appending to the previous one.
+		k--
+	}
+	if k+1 < len(b.chunks) {
+		// Limit the capacity so that the slots of the released chunks are not
+		// overwritten when new chunks are added (they might be shared with
+		// windows into this Bytes).
+		b.chunks = b.chunks[: k+1 : k+1]
+		b.chunkOffsets = b.chunkOffsets[: k+1 : k+1]
+	}
+	if k < 0 {
+		b.data = b.data[:length]
+	} else {
+		b.chunks[k] = b.chunks[k][:length-b.chunkOffsets[k]]
+	}
+}
+
+// reserve makes sure that the last chunk of the logical buffer has enough
+// capacity for n more bytes, adding a new chunk if necessary.
+func (b *Bytes) reserve(n int32) {
+	last := len(b.chunks) - 1
+	chunk, _ := b.chunk(last)
+	if int32(cap(chunk)-len(chunk)) >= n {
+		return
+	}
+	newCap := 2 * cap(chunk)
+	if newCap < int(n) {
+		newCap = int(n)
+	}
+	if newCap < BytesInitialAllocationFactor {
+		newCap = BytesInitialAllocationFactor
+	}
+	if last < 0 && len(b.data) == 0 {
+		// There is nothing to preserve in data, so we can simply replace it.
+		b.data = make([]byte, 0, newCap)
+		return
+	}
+	b.chunkOffsets = append(b.chunkOffsets, b.dataLen())
+	b.chunks = append(b.chunks, make([]byte, 0, newCap))
+}
+
+// appendData appends v to the end of the logical buffer. The value is never
+// split across chunks.
+func (b *Bytes) appendData(v []byte) {
+	b.reserve(int32(len(v)))
+	if last := len(b.chunks) - 1; last >= 0 {
+		b.chunks[last] = append(b.chunks[last], v...)
+	} else {
+		b.data = append(b.data, v...)
+	}
+}
+
+// appendDataFrom appends the bytes in the logical range [start, end) of src to
+// the end of the logical buffer. The bytes are never split across chunks. src
+// can be a copy of the receiver made before the receiver was truncated, in
+// which case the range can refer to the bytes that were truncated away.
+func (b *Bytes) appendDataFrom(src *Bytes, start, end int32) {
+	k := src.chunkIdx(start)
+	if src.chunkEnd(k) < end {
+		// The range spans multiple chunks of the source, so we first gather it
+		// into a single slice. Note that we cannot append the pieces one at a
+		// time since the source might be the receiver, and appending one piece
+		// could overwrite another one.
+		b.appendData(src.appendDataTo(make([]byte, 0, end-start), start, end))
+		return
+	}
+	chunk, chunkOffset := src.chunk(k)
+	b.appendData(chunk[start-chunkOffset : end-chunkOffset])
+}
+
+// appendDataTo appends the bytes in the logical range [start, end) to dst and
+// returns the result. Note that since the chunks are sliced up to their
+// capacity, it is safe to call this on a copy of Bytes that was made before
+// the chunks were truncated.
+func (b *Bytes) appendDataTo(dst []byte, start, end int32) []byte {
+	for start < end {
+		k := b.chunkIdx(start)
+		chunk, chunkOffset := b.chunk(k)
+		pieceEnd := b.chunkEnd(k)
+		if pieceEnd > end {
+			pieceEnd = end
+		}
+		dst = append(dst, chunk[start-chunkOffset:pieceEnd-chunkOffset]...)
+		start = pieceEnd
+	}
+	return dst
 }
 
 // Set sets the ith []byte in Bytes. Overwriting a value that is not at the end
@@ -132,11 +295,31 @@ func (b *Bytes) Set(i int, v []byte) {
 	// NULL values that are stored separately. In order to maintain the
 	// assumption of non-decreasing offsets, we need to backfill them.
 	b.maybeBackfillOffsets(i)
-	b.data = append(b.data[:b.offsets[i]], v...)
-	b.offsets[i+1] = int32(len(b.data))
+	b.offsets[i+1] = b.setDataAt(b.offsets[i], v)
 	b.maxSetIndex = i
 }
 
+// setDataAt truncates the logical buffer to the given offset, appends v to it,
+// and returns the new length of the logical buffer.
+func (b *Bytes) setDataAt(offset int32, v []byte) int32 {
+	if len(b.chunks) == 0 {
+		if cap(b.data)-int(offset) >= len(v) {
+			// Fast path for when there is enough capacity in data.
+			b.data = append(b.data[:offset], v...)
+			return int32(len(b.data))
+		}
+	} else if last := len(b.chunks) - 1; offset == b.chunkOffsets[last]+int32(len(b.chunks[last])) &&
+		cap(b.chunks[last])-len(b.chunks[last]) >= len(v) {
+		// Fast path for when we're appending to the end of the last chunk, and
+		// there is enough capacity in it.
+		b.chunks[last] = append(b.chunks[last], v...)
+		return offset + int32(len(v))
+	}
+	b.truncateData(offset)
+	b.appendData(v)
+	return b.dataLen()
+}
+
 // Window creates a "window" into the receiver. It behaves similarly to
 // Golang's slice, but the returned object is *not* allowed to be modified - it
 // is read-only. Window is a lightweight operation that doesn't involve copying
@@ -151,12 +334,19 @@ func (b *Bytes) Window(start, end int) *Bytes {
 		)
 	}
 	b.maybeBackfillOffsets(end)
-	data := b.data[:b.offsets[end]]
+	data := b.data
+	if len(b.chunks) == 0 {
+		data = data[:b.offsets[end]]
+	}
 	if end == 0 {
 		data = b.data[:0]
 	}
 	return &Bytes{
 		data: data,
+		// The capacity is limited so that the window never shares the slots
+		// of chunks added to the receiver later.
+		chunks:       b.chunks[:len(b.chunks):len(b.chunks)],
+		chunkOffsets: b.chunkOffsets[:len(b.chunkOffsets):len(b.chunkOffsets)],
 		// We use 'end+1' because of the extra offset to know the length of the
 		// last element of the newly created window.
 		offsets: b.offsets[start : end+1],
@@ -215,16 +405,20 @@ func (b *Bytes) CopySlice(src *Bytes, destIdx, srcStartIdx, srcEndIdx int) {
 	// might remain zeroes. We want to be on the safe side, so we backfill the
 	// source offsets as well.
 	src.maybeBackfillOffsets(srcEndIdx)
-	srcDataToCopy := src.data[src.offsets[srcStartIdx]:src.offsets[srcEndIdx]]
+	// Keep a view of the source since it might be the receiver, whose chunks
+	// are about to be truncated.
+	srcView := *src
+	srcDataStart, srcDataEnd := src.offsets[srcStartIdx], src.offsets[srcEndIdx]
 
 	var leftoverDestBytes []byte
 	if destIdx+toCopy <= b.maxSetIndex {
 		// There will still be elements left over after the last element to copy. We
 		// copy those elements into leftoverDestBytes to append after all elements
 		// have been copied.
-		leftoverDestBytesToCopy := b.data[b.offsets[destIdx+toCopy]:]
-		leftoverDestBytes = make([]byte, len(leftoverDestBytesToCopy))
-		copy(leftoverDestBytes, leftoverDestBytesToCopy)
+		leftoverStart, leftoverEnd := b.offsets[destIdx+toCopy], b.dataLen()
+		leftoverDestBytes = b.appendDataTo(
+			make([]byte, 0, leftoverEnd-leftoverStart), leftoverStart, leftoverEnd,
+		)
 	}
 
 	newMaxIdx := destIdx + (toCopy - 1)
@@ -241,14 +435,11 @@ func (b *Bytes) CopySlice(src *Bytes, destIdx, srcStartIdx, srcEndIdx int) {
 	translateBy := destDataIdx - src.offsets[srcStartIdx]
 
 	// Append the actual bytes, we use an append instead of a copy to ensure that
-	// b.data has enough capacity. Note that the "capacity" was checked
-	// beforehand, but the number of elements to copy does not correlate with the
-	// number of bytes.
-	if destIdx != 0 {
-		b.data = append(b.data[:b.offsets[destIdx]], srcDataToCopy...)
-	} else {
-		b.data = append(b.data[:0], srcDataToCopy...)
-	}
+	// the logical buffer has enough capacity. Note that the "capacity" was
+	// checked beforehand, but the number of elements to copy does not correlate
+	// with the number of bytes.
+	b.truncateData(destDataIdx)
+	b.appendDataFrom(&srcView, srcDataStart, srcDataEnd)
 	copy(b.offsets[destIdx:], src.offsets[srcStartIdx:srcEndIdx])
 
 	if translateBy != 0 {
@@ -258,16 +449,15 @@ func (b *Bytes) CopySlice(src *Bytes, destIdx, srcStartIdx, srcEndIdx int) {
 		}
 	}
 
-	oldPrefixAndCopiedDataLen := b.offsets[destIdx] + int32(len(srcDataToCopy))
+	oldPrefixAndCopiedDataLen := b.offsets[destIdx] + (srcDataEnd - srcDataStart)
 	if leftoverDestBytes != nil {
-		dataToAppendTo := b.data[:oldPrefixAndCopiedDataLen]
 		// Append (to make sure we have the capacity for) the leftoverDestBytes to
 		// the end of the data we just copied.
-		b.data = append(dataToAppendTo, leftoverDestBytes...)
+		b.appendData(leftoverDestBytes)
 		// The lengths for these elements are correct (they weren't overwritten),
 		// but the offsets need updating.
 		destOffsets := b.offsets[destIdx+toCopy+1:]
-		translateBy := int32(len(dataToAppendTo)) - b.offsets[destIdx+toCopy]
+		translateBy := oldPrefixAndCopiedDataLen - b.offsets[destIdx+toCopy]
 		for i := range destOffsets {
 			destOffsets[i] += translateBy
 		}
@@ -312,7 +502,7 @@ func (b *Bytes) AppendSlice(src *Bytes, destIdx, srcStartIdx, srcEndIdx int) {
 		if destIdx == b.Len() {
 			return
 		}
-		b.data = b.data[:b.offsets[destIdx]]
+		b.truncateData(b.offsets[destIdx])
 		b.offsets = b.offsets[:destIdx+1]
 		return
 	}
@@ -322,7 +512,10 @@ func (b *Bytes) AppendSlice(src *Bytes, destIdx, srcStartIdx, srcEndIdx int) {
 	// might remain zeroes. We want to be on the safe side, so we backfill the
 	// source offsets as well.
 	src.maybeBackfillOffsets(srcEndIdx)
-	srcDataToAppend := src.data[src.offsets[srcStartIdx]:src.offsets[srcEndIdx]]
+	// Keep a view of the source since it might be the receiver, whose chunks
+	// are about to be truncated.
+	srcView := *src
+	srcDataStart, srcDataEnd := src.offsets[srcStartIdx], src.offsets[srcEndIdx]
 	destDataIdx := b.offsets[destIdx]
 	b.maxSetIndex = destIdx + (toAppend - 1)
 
@@ -330,7 +523,8 @@ func (b *Bytes) AppendSlice(src *Bytes, destIdx, srcStartIdx, srcEndIdx int) {
 	// destination offsets since we might be appending from the same Bytes (and
 	// might be overwriting information).
 	translateBy := destDataIdx - src.offsets[srcStartIdx]
-	b.data = append(b.data[:destDataIdx], srcDataToAppend...)
+	b.truncateData(destDataIdx)
+	b.appendDataFrom(&srcView, srcDataStart, srcDataEnd)
 	b.offsets = append(b.offsets[:destIdx], src.offsets[srcStartIdx:srcEndIdx+1]...)
 	if translateBy == 0 {
 		// No offset translation needed.
@@ -352,9 +546,9 @@ func (b *Bytes) AppendVal(v []byte) {
 		panic("AppendVal is called on a window into Bytes")
 	}
 	b.maybeBackfillOffsets(b.Len())
-	b.data = append(b.data[:b.offsets[b.Len()]], v...)
+	dataLen := b.setDataAt(b.offsets[b.Len()], v)
 	b.maxSetIndex = b.Len()
-	b.offsets = append(b.offsets, int32(len(b.data)))
+	b.offsets = append(b.offsets, dataLen)
 }
 
 // Len returns how many []byte values the receiver contains.
@@ -365,12 +559,17 @@ func (b *Bytes) Len() int {
 // FlatBytesOverhead is the overhead of Bytes in bytes.
 const FlatBytesOverhead = unsafe.Sizeof(Bytes{})
 const sizeOfInt32 = unsafe.Sizeof(int32(0))
+const sizeOfSlice = unsafe.Sizeof([]byte{})
 
 // Size returns the total size of the receiver in bytes.
 func (b *Bytes) Size() uintptr {
-	return FlatBytesOverhead +
+	size := FlatBytesOverhead +
 		uintptr(cap(b.data)) +
 		uintptr(cap(b.offsets))*sizeOfInt32
+	for _, chunk := range b.chunks {
+		size += uintptr(cap(chunk))
+	}
+	return size + uintptr(cap(b.chunks))*sizeOfSlice + uintptr(cap(b.chunkOffsets))*sizeOfInt32
 }
 
 // ProportionalSize returns the size of the receiver in bytes that is
@@ -381,7 +580,7 @@ func (b *Bytes) ProportionalSize(n int64) uintptr {
 	}
 	// It is possible that we have a "window" into the vector that doesn't start
 	// from the offset of 0, so we have to look at the first actual offset.
-	return FlatBytesOverhead + uintptr(len(b.data[b.offsets[0]:b.offsets[n]])) + uintptr(n)*sizeOfInt32
+	return FlatBytesOverhead + uintptr(b.offsets[n]-b.offsets[0]) + uintptr(n)*sizeOfInt32
 }
 
 var zeroInt32Slice = make([]int32, BytesInitialAllocationFactor*BatchSize())
@@ -389,13 +588,23 @@ var zeroInt32Slice = make([]int32, BytesInitialAllocationFactor*BatchSize())
 // Reset resets the underlying Bytes for reuse. Note that this zeroes out the
 // underlying bytes but doesn't change the length (see #42054 for the
 // discussion on why simply truncating b.data and setting b.maxSetIndex to 0 is
-// not sufficient).
+// not sufficient). If the logical buffer consists of multiple chunks, they
+// are consolidated into a single chunk with their combined capacity.
 // TODO(asubiotto): Move towards removing Set in favor of AppendVal. At that
 // point we can reset the length to 0.
 func (b *Bytes) Reset() {
 	if b.isWindow {
 		panic("Reset is called on a window into Bytes")
 	}
+	if len(b.chunks) > 0 {
+		newCap := cap(b.data)
+		for _, chunk := range b.chunks {
+			newCap += cap(chunk)
+		}
+		b.data = make([]byte, 0, newCap)
+		b.chunks = nil
+		b.chunkOffsets = nil
+	}
 	b.data = b.data[:0]
 	for n := 0; n < len(b.offsets); n += copy(b.offsets[n:], zeroInt32Slice) {
 	}
@@ -407,7 +616,7 @@ func (b *Bytes) String() string {
 	var builder strings.Builder
 	for i := range b.offsets[:b.maxSetIndex+1] {
 		builder.WriteString(
-			fmt.Sprintf("%d: %v\n", i, b.data[b.offsets[i]:b.offsets[i+1]]),
+			fmt.Sprintf("%d: %v\n", i, b.Get(i)),
 		)
 	}
 	return builder.String()
@@ -417,18 +626,27 @@ func (b *Bytes) String() string {
 // offsets and populates b.
 func BytesFromArrowSerializationFormat(b *Bytes, data []byte, offsets []int32) {
 	b.data = data
+	b.chunks = nil
+	b.chunkOffsets = nil
 	b.offsets = offsets
 	b.maxSetIndex = len(offsets) - 2
 }
 
 // ToArrowSerializationFormat returns a bytes slice and offsets that are
-// Arrow-compatible. n is the number of elements to serialize.
+// Arrow-compatible. n is the number of elements to serialize. Note that if the
+// logical buffer consists of multiple chunks, they are copied into a single
+// flat slice.
 func (b *Bytes) ToArrowSerializationFormat(n int) ([]byte, []int32) {
 	if n == 0 {
 		return []byte{}, []int32{0}
 	}
 	serializeLength := b.offsets[n]
-	data := b.data[:serializeLength]
+	var data []byte
+	if len(b.chunks) == 0 {
+		data = b.data[:serializeLength]
+	} else {
+		data = b.appendDataTo(make([]byte, 0, serializeLength), 0, serializeLength)
+	}
 	offsets := b.offsets[:n+1]
 	return data, offsets
 }
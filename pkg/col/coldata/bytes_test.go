@@ -201,11 +201,17 @@ func TestBytesRefImpl(t *testing.T) {
 
 	for nRun := 0; nRun < nRuns; nRun++ {
 		n := 1 + rng.Intn(maxLength)
+		// Use large values in some runs so that the initial capacity is
+		// exceeded and Bytes have to allocate multiple chunks.
+		maxValueLength := 16
+		if rng.Float64() < 0.5 {
+			maxValueLength = 4 * BytesInitialAllocationFactor
+		}
 
 		flat := NewBytes(n)
 		reference := make([][]byte, n)
 		for i := 0; i < n; i++ {
-			v := make([]byte, rng.Intn(16))
+			v := make([]byte, rng.Intn(maxValueLength))
 			rng.Read(v)
 			flat.Set(i, append([]byte(nil), v...))
 			reference[i] = append([]byte(nil), v...)
@@ -222,7 +228,7 @@ func TestBytesRefImpl(t *testing.T) {
 			flatSource = NewBytes(sourceN)
 			referenceSource = make([][]byte, sourceN)
 			for i := 0; i < sourceN; i++ {
-				v := make([]byte, rng.Intn(16))
+				v := make([]byte, rng.Intn(maxValueLength))
 				rng.Read(v)
 				flatSource.Set(i, append([]byte(nil), v...))
 				referenceSource[i] = append([]byte(nil), v...)
@@ -434,6 +440,63 @@ func TestBytes(t *testing.T) {
 	})
 }
 
+func TestBytesChunks(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	rng, _ := randutil.NewPseudoRand()
+	const n = 16
+	b := NewBytes(n)
+	reference := make([][]byte, n)
+	// The values exceed the initial capacity, so multiple chunks are needed.
+	for i := 0; i < n; i++ {
+		v := make([]byte, 1+rng.Intn(4*BytesInitialAllocationFactor))
+		rng.Read(v)
+		b.Set(i, v)
+		reference[i] = v
+	}
+	require.NotEqual(t, 0, len(b.chunks))
+	require.NoError(t, verifyEqual(b, reference))
+
+	t.Run("NoCopyOnGrowth", func(t *testing.T) {
+		// The values set before the growth must not have been moved.
+		first := b.Get(0)
+		b.AppendVal(make([]byte, 8*BytesInitialAllocationFactor))
+		require.Equal(t, unsafe.Pointer(&first[0]), unsafe.Pointer(&b.Get(0)[0]))
+		b.AppendSlice(b, n, 0, 0)
+		require.NoError(t, verifyEqual(b, reference))
+	})
+
+	t.Run("Window", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			start := rng.Intn(n)
+			end := start + rng.Intn(n-start+1)
+			require.NoError(t, verifyEqual(b.Window(start, end), reference[start:end]))
+		}
+	})
+
+	t.Run("Serialization", func(t *testing.T) {
+		data, offsets := b.ToArrowSerializationFormat(n)
+		var deserialized Bytes
+		BytesFromArrowSerializationFormat(&deserialized, data, offsets)
+		require.Equal(t, 0, len(deserialized.chunks))
+		require.NoError(t, verifyEqual(&deserialized, reference))
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		sizeBefore := b.Size()
+		b.Reset()
+		// The chunks are consolidated into a single one with their combined
+		// capacity.
+		require.Equal(t, 0, len(b.chunks))
+		require.True(t, b.Size() <= sizeBefore)
+		for i := 0; i < n; i++ {
+			b.Set(i, reference[i])
+		}
+		require.Equal(t, 0, len(b.chunks))
+		require.NoError(t, verifyEqual(b, reference))
+	})
+}
+
 func TestProportionalSize(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -461,3 +524,65 @@ func TestProportionalSize(t *testing.T) {
 	quarterSize := b.Window(fullCapacity/4, fullCapacity/2).ProportionalSize(int64(fullCapacity / 4))
 	require.Equal(t, int(fullSize-FlatBytesOverhead)/4, int(quarterSize-FlatBytesOverhead))
 }
+
+// randStringValues returns n random values with lengths in [0, maxLength).
+func randStringValues(rng *rand.Rand, n, maxLength int) [][]byte {
+	vals := make([][]byte, n)
+	for i := range vals {
+		vals[i] = make([]byte, rng.Intn(maxLength))
+		rng.Read(vals[i])
+	}
+	return vals
+}
+
+// BenchmarkBytesSet measures the cost of filling in a batch of Bytes with
+// random values of different lengths, which might exceed the initial capacity
+// of the Bytes (similarly to the output of string concatenation).
+func BenchmarkBytesSet(b *testing.B) {
+	rng, _ := randutil.NewPseudoRand()
+	for _, maxLength := range []int{16, BytesInitialAllocationFactor, 16 * BytesInitialAllocationFactor} {
+		vals := randStringValues(rng, BatchSize(), maxLength)
+		var totalLength int64
+		for _, v := range vals {
+			totalLength += int64(len(v))
+		}
+		for _, reuse := range []bool{false, true} {
+			b.Run(fmt.Sprintf("maxLength=%d/reuse=%t", maxLength, reuse), func(b *testing.B) {
+				b.SetBytes(totalLength)
+				bytes := NewBytes(BatchSize())
+				for i := 0; i < b.N; i++ {
+					if reuse {
+						bytes.Reset()
+					} else {
+						bytes = NewBytes(BatchSize())
+					}
+					for j, v := range vals {
+						bytes.Set(j, v)
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkBytesAppendVal is like BenchmarkBytesSet, but the Bytes start empty
+// and grow with every appended value.
+func BenchmarkBytesAppendVal(b *testing.B) {
+	rng, _ := randutil.NewPseudoRand()
+	for _, maxLength := range []int{16, BytesInitialAllocationFactor, 16 * BytesInitialAllocationFactor} {
+		vals := randStringValues(rng, BatchSize(), maxLength)
+		var totalLength int64
+		for _, v := range vals {
+			totalLength += int64(len(v))
+		}
+		b.Run(fmt.Sprintf("maxLength=%d", maxLength), func(b *testing.B) {
+			b.SetBytes(totalLength)
+			for i := 0; i < b.N; i++ {
+				bytes := NewBytes(0)
+				for _, v := range vals {
+					bytes.AppendVal(v)
+				}
+			}
+		})
+	}
+}
@@ -10,6 +10,11 @@
 
 package coldata
 
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
 // zeroedNulls is a zeroed out slice representing a bitmap of size MaxBatchSize.
 // This is copied to efficiently set all nulls.
 var zeroedNulls [(MaxBatchSize-1)/8 + 1]byte
@@ -363,6 +368,159 @@ func (n *Nulls) Or(n2 *Nulls) *Nulls {
 	}
 }
 
+// byteMask returns a byte with the bits in [start, end) set, 0 <= start < end
+// <= 8.
+func byteMask(start, end int) byte {
+	return onesMask << uint(start) & (onesMask >> uint(8-end))
+}
+
+// andBitmaps sets dst[i] &= src[i] for all i in [0, len(dst)) processing eight
+// bytes at a time. src must be at least as long as dst.
+func andBitmaps(dst, src []byte) {
+	src = src[:len(dst)]
+	for len(dst) >= 8 {
+		binary.LittleEndian.PutUint64(dst, binary.LittleEndian.Uint64(dst)&binary.LittleEndian.Uint64(src))
+		dst, src = dst[8:], src[8:]
+	}
+	for i := range dst {
+		dst[i] &= src[i]
+	}
+}
+
+// orBitmaps sets dst[i] |= src[i] for all i in [0, len(dst)) processing eight
+// bytes at a time. src must be at least as long as dst.
+func orBitmaps(dst, src []byte) {
+	src = src[:len(dst)]
+	for len(dst) >= 8 {
+		binary.LittleEndian.PutUint64(dst, binary.LittleEndian.Uint64(dst)|binary.LittleEndian.Uint64(src))
+		dst, src = dst[8:], src[8:]
+	}
+	for i := range dst {
+		dst[i] |= src[i]
+	}
+}
+
+// OrRange updates n in-place so that n.NullAt(i) iff n.NullAt(i) or
+// other.NullAt(i) for all i in [startIdx, endIdx). The values outside of the
+// range are not modified. Both n and other must be able to hold at least
+// endIdx values.
+func (n *Nulls) OrRange(other *Nulls, startIdx, endIdx int) {
+	if startIdx >= endIdx || !other.maybeHasNulls {
+		return
+	}
+	n.maybeHasNulls = true
+	// Note that the null is represented by an unset bit, so we need to
+	// perform the bitwise AND of the bitmaps.
+	sIdx, eIdx := startIdx/8, (endIdx-1)/8
+	if sIdx == eIdx {
+		n.nulls[sIdx] &= other.nulls[sIdx] | ^byteMask(startIdx%8, endIdx-sIdx*8)
+		return
+	}
+	if startIdx%8 != 0 {
+		n.nulls[sIdx] &= other.nulls[sIdx] | ^byteMask(startIdx%8, 8)
+		sIdx++
+	}
+	if endIdx%8 != 0 {
+		n.nulls[eIdx] &= other.nulls[eIdx] | ^byteMask(0, endIdx%8)
+		eIdx--
+	}
+	andBitmaps(n.nulls[sIdx:eIdx+1], other.nulls[sIdx:eIdx+1])
+}
+
+// AndRange updates n in-place so that n.NullAt(i) iff n.NullAt(i) and
+// other.NullAt(i) for all i in [startIdx, endIdx). The values outside of the
+// range are not modified. Both n and other must be able to hold at least
+// endIdx values.
+//
+// Note that, similar to UnsetNullRange, n might not contain any null values
+// after AndRange, but maybeHasNulls could still be true.
+func (n *Nulls) AndRange(other *Nulls, startIdx, endIdx int) {
+	if startIdx >= endIdx || !n.maybeHasNulls {
+		return
+	}
+	if !other.maybeHasNulls {
+		n.UnsetNullRange(startIdx, endIdx)
+		return
+	}
+	// Note that the null is represented by an unset bit, so we need to
+	// perform the bitwise OR of the bitmaps.
+	sIdx, eIdx := startIdx/8, (endIdx-1)/8
+	if sIdx == eIdx {
+		n.nulls[sIdx] |= other.nulls[sIdx] & byteMask(startIdx%8, endIdx-sIdx*8)
+		return
+	}
+	if startIdx%8 != 0 {
+		n.nulls[sIdx] |= other.nulls[sIdx] & byteMask(startIdx%8, 8)
+		sIdx++
+	}
+	if endIdx%8 != 0 {
+		n.nulls[eIdx] |= other.nulls[eIdx] & byteMask(0, endIdx%8)
+		eIdx--
+	}
+	orBitmaps(n.nulls[sIdx:eIdx+1], other.nulls[sIdx:eIdx+1])
+}
+
+// NullCount returns the number of null values in [startIdx, endIdx).
+func (n *Nulls) NullCount(startIdx, endIdx int) int {
+	if startIdx >= endIdx || !n.maybeHasNulls {
+		return 0
+	}
+	sIdx, eIdx := startIdx/8, (endIdx-1)/8
+	if sIdx == eIdx {
+		return bits.OnesCount8(^n.nulls[sIdx] & byteMask(startIdx%8, endIdx-sIdx*8))
+	}
+	count := 0
+	if startIdx%8 != 0 {
+		count += bits.OnesCount8(^n.nulls[sIdx] & byteMask(startIdx%8, 8))
+		sIdx++
+	}
+	if endIdx%8 != 0 {
+		count += bits.OnesCount8(^n.nulls[eIdx] & byteMask(0, endIdx%8))
+		eIdx--
+	}
+	bitmap := n.nulls[sIdx : eIdx+1]
+	for len(bitmap) >= 8 {
+		count += bits.OnesCount64(^binary.LittleEndian.Uint64(bitmap))
+		bitmap = bitmap[8:]
+	}
+	for _, b := range bitmap {
+		count += bits.OnesCount8(^b)
+	}
+	return count
+}
+
+// NextNull returns the smallest index i in [startIdx, endIdx) such that
+// NullAt(i) is true, or endIdx if there is no such index. It can be used to
+// iterate over all null values in a range:
+//
+//   for i := n.NextNull(startIdx, endIdx); i < endIdx; i = n.NextNull(i+1, endIdx) {
+//     ...
+//   }
+func (n *Nulls) NextNull(startIdx, endIdx int) int {
+	if !n.maybeHasNulls {
+		return endIdx
+	}
+	for i := startIdx; i < endIdx; {
+		if i%64 == 0 && endIdx-i >= 64 {
+			// The next 64 values are fully within the range, so we can
+			// process them at once.
+			if w := ^binary.LittleEndian.Uint64(n.nulls[i/8:]); w != 0 {
+				return i + bits.TrailingZeros64(w)
+			}
+			i += 64
+			continue
+		}
+		if b := ^n.nulls[i/8] >> uint(i%8); b != 0 {
+			if i += bits.TrailingZeros8(b); i < endIdx {
+				return i
+			}
+			return endIdx
+		}
+		i = (i/8 + 1) * 8
+	}
+	return endIdx
+}
+
 // makeCopy returns a copy of n which can be modified independently.
 func (n *Nulls) makeCopy() Nulls {
 	c := Nulls{
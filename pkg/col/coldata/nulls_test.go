@@ -12,9 +12,11 @@ package coldata
 
 import (
 	"fmt"
+	"math/rand"
 	"testing"
 
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -236,3 +238,54 @@ func TestNullsOr(t *testing.T) {
 		}
 	}
 }
+
+// randomNulls returns a nulls vector of the given length with a random subset
+// of values set to null.
+func randomNulls(rng *rand.Rand, length int) Nulls {
+	n := NewNulls(length)
+	nullProbability := rng.Float64()
+	for i := 0; i < length; i++ {
+		if rng.Float64() < nullProbability {
+			n.SetNull(i)
+		}
+	}
+	return n
+}
+
+func TestNullsRangeOperations(t *testing.T) {
+	rng, _ := randutil.NewPseudoRand()
+	const length = 300
+	for iter := 0; iter < 100; iter++ {
+		n1, n2 := randomNulls(rng, length), randomNulls(rng, length)
+		startIdx := rng.Intn(length)
+		endIdx := startIdx + rng.Intn(length-startIdx+1)
+		inRange := func(i int) bool {
+			return i >= startIdx && i < endIdx
+		}
+
+		or := n1.makeCopy()
+		or.OrRange(&n2, startIdx, endIdx)
+		and := n1.makeCopy()
+		and.AndRange(&n2, startIdx, endIdx)
+		expectedCount := 0
+		var expectedNulls, actualNulls []int
+		for i := 0; i < length; i++ {
+			expectedOr, expectedAnd := n1.NullAt(i), n1.NullAt(i)
+			if inRange(i) {
+				expectedOr = n1.NullAt(i) || n2.NullAt(i)
+				expectedAnd = n1.NullAt(i) && n2.NullAt(i)
+				if n1.NullAt(i) {
+					expectedCount++
+					expectedNulls = append(expectedNulls, i)
+				}
+			}
+			require.Equal(t, expectedOr, or.NullAt(i), "OrRange(%d, %d) at %d", startIdx, endIdx, i)
+			require.Equal(t, expectedAnd, and.NullAt(i), "AndRange(%d, %d) at %d", startIdx, endIdx, i)
+		}
+		require.Equal(t, expectedCount, n1.NullCount(startIdx, endIdx))
+		for i := n1.NextNull(startIdx, endIdx); i < endIdx; i = n1.NextNull(i+1, endIdx) {
+			actualNulls = append(actualNulls, i)
+		}
+		require.Equal(t, expectedNulls, actualNulls)
+	}
+}
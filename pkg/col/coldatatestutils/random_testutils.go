@@ -12,6 +12,7 @@ package coldatatestutils
 
 import (
 	"context"
+	"math"
 	"math/rand"
 	"time"
 
@@ -50,6 +51,32 @@ func init() {
 	}
 }
 
+// Distribution is the distribution of the values generated by RandomVec.
+type Distribution int
+
+const (
+	// DistributionUniform generates uniformly random values.
+	DistributionUniform Distribution = iota
+	// DistributionZipf generates values following a Zipf distribution, so
+	// that small values (relative to MinValue) are much more frequent than
+	// large ones.
+	DistributionZipf
+	// DistributionSequential generates consecutive values starting at
+	// MinValue, wrapping around at MaxValue if the values are bounded.
+	DistributionSequential
+	// DistributionRunLength generates uniformly random values in runs of
+	// duplicates whose lengths are random up to MaxRunLength.
+	DistributionRunLength
+)
+
+const (
+	// defaultZipfS is the default s parameter of DistributionZipf.
+	defaultZipfS = 1.1
+	// defaultMaxRunLength is the default maximum length of the runs of
+	// DistributionRunLength.
+	defaultMaxRunLength = 8
+)
+
 // RandomVecArgs is a utility struct that contains arguments to RandomVec call.
 type RandomVecArgs struct {
 	// Rand is the provided RNG.
@@ -72,16 +99,138 @@ type RandomVecArgs struct {
 	// ZeroProhibited determines whether numeric zero values are disallowed to
 	// be generated.
 	ZeroProhibited bool
+
+	// Distribution determines the distribution of the values of integer and
+	// float vectors (the values of the other types are always uniformly
+	// random). The values of float vectors are integral for all distributions
+	// other than DistributionUniform.
+	Distribution Distribution
+	// MinValue and MaxValue (when MaxValue is greater than MinValue) bound the
+	// values of integer and float vectors to [MinValue, MaxValue) interval.
+	// IntRange, if set, is applied on top of them.
+	MinValue, MaxValue int64
+	// ZipfS (when greater than 1) is the s parameter of DistributionZipf; the
+	// larger it is, the more skewed the values are.
+	ZipfS float64
+	// MaxRunLength (when greater than zero) is the maximum length of the runs
+	// of duplicates of DistributionRunLength.
+	MaxRunLength int
+
+	// CorrelatedWith, if set, is the vector (of the same type as Vec) with
+	// which the generated vector is correlated: each of the first N values
+	// (including NULLs) is copied from CorrelatedWith with the probability of
+	// Correlation, and it is generated otherwise.
+	CorrelatedWith coldata.Vec
+	// Correlation is the probability of a value being copied from
+	// CorrelatedWith.
+	Correlation float64
+}
+
+// intGenerator returns a function that generates integer values according to
+// the distribution and the bounds of args.
+func intGenerator(args RandomVecArgs) func() int64 {
+	lo, hi := args.MinValue, args.MaxValue
+	bounded := hi > lo
+	uniform := func() int64 {
+		if bounded {
+			return lo + args.Rand.Int63n(hi-lo)
+		}
+		return int64(args.Rand.Uint64())
+	}
+	switch args.Distribution {
+	case DistributionZipf:
+		s := args.ZipfS
+		if s <= 1 {
+			s = defaultZipfS
+		}
+		imax := uint64(math.MaxInt64)
+		if bounded {
+			imax = uint64(hi - lo - 1)
+		} else {
+			lo = 0
+		}
+		z := rand.NewZipf(args.Rand, s, 1 /* v */, imax)
+		return func() int64 {
+			return lo + int64(z.Uint64())
+		}
+	case DistributionSequential:
+		if !bounded {
+			lo = 0
+		}
+		next := lo
+		return func() int64 {
+			v := next
+			next++
+			if bounded && next >= hi {
+				next = lo
+			}
+			return v
+		}
+	case DistributionRunLength:
+		maxRunLength := args.MaxRunLength
+		if maxRunLength <= 0 {
+			maxRunLength = defaultMaxRunLength
+		}
+		var v int64
+		var remaining int
+		return func() int64 {
+			if remaining == 0 {
+				v = uniform()
+				remaining = 1 + args.Rand.Intn(maxRunLength)
+			}
+			remaining--
+			return v
+		}
+	}
+	return uniform
+}
+
+// floatGenerator is like intGenerator, but for float values. Unbounded
+// uniformly random values are in [0, 1) interval.
+func floatGenerator(args RandomVecArgs) func() float64 {
+	if args.Distribution == DistributionUniform {
+		lo, hi := args.MinValue, args.MaxValue
+		if hi > lo {
+			return func() float64 {
+				return float64(lo) + args.Rand.Float64()*float64(hi-lo)
+			}
+		}
+		return args.Rand.Float64
+	}
+	gen := intGenerator(args)
+	return func() float64 {
+		return float64(gen())
+	}
 }
 
 // RandomVec populates vector with random values, setting each value to null
 // with the given probability. It is assumed that N is in bounds of the given
 // vector.
 func RandomVec(args RandomVecArgs) {
+	var correlatedRows []bool
+	src := args.CorrelatedWith
+	if src != nil {
+		if !src.Type().Identical(args.Vec.Type()) {
+			colexecerror.InternalError(errors.AssertionFailedf(
+				"correlated vectors have different types %s and %s", src.Type(), args.Vec.Type(),
+			))
+		}
+		correlatedRows = make([]bool, args.N)
+		for i := range correlatedRows {
+			correlatedRows[i] = args.Rand.Float64() < args.Correlation
+		}
+	}
+	correlated := func(i int) bool {
+		return correlatedRows != nil && correlatedRows[i]
+	}
 	switch args.Vec.CanonicalTypeFamily() {
 	case types.BoolFamily:
 		bools := args.Vec.Bool()
 		for i := 0; i < args.N; i++ {
+			if correlated(i) {
+				bools[i] = src.Bool()[i]
+				continue
+			}
 			if args.Rand.Float64() < 0.5 {
 				bools[i] = true
 			} else {
@@ -91,6 +240,10 @@ func RandomVec(args RandomVecArgs) {
 	case types.BytesFamily:
 		bytes := args.Vec.Bytes()
 		for i := 0; i < args.N; i++ {
+			if correlated(i) {
+				bytes.Set(i, src.Bytes().Get(i))
+				continue
+			}
 			bytesLen := args.BytesFixedLength
 			if bytesLen <= 0 {
 				bytesLen = args.Rand.Intn(maxVarLen)
@@ -103,6 +256,10 @@ func RandomVec(args RandomVecArgs) {
 	case types.DecimalFamily:
 		decs := args.Vec.Decimal()
 		for i := 0; i < args.N; i++ {
+			if correlated(i) {
+				decs[i].Set(&src.Decimal()[i])
+				continue
+			}
 			// int64(args.Rand.Uint64()) to get negative numbers, too
 			decs[i].SetFinite(int64(args.Rand.Uint64()), int32(args.Rand.Intn(40)-20))
 			if args.ZeroProhibited {
@@ -112,11 +269,16 @@ func RandomVec(args RandomVecArgs) {
 			}
 		}
 	case types.IntFamily:
+		gen := intGenerator(args)
 		switch args.Vec.Type().Width() {
 		case 16:
 			ints := args.Vec.Int16()
 			for i := 0; i < args.N; i++ {
-				ints[i] = int16(args.Rand.Uint64())
+				if correlated(i) {
+					ints[i] = src.Int16()[i]
+					continue
+				}
+				ints[i] = int16(gen())
 				if args.IntRange != 0 {
 					ints[i] = ints[i] % int16(args.IntRange)
 				}
@@ -129,7 +291,11 @@ func RandomVec(args RandomVecArgs) {
 		case 32:
 			ints := args.Vec.Int32()
 			for i := 0; i < args.N; i++ {
-				ints[i] = int32(args.Rand.Uint64())
+				if correlated(i) {
+					ints[i] = src.Int32()[i]
+					continue
+				}
+				ints[i] = int32(gen())
 				if args.IntRange != 0 {
 					ints[i] = ints[i] % int32(args.IntRange)
 				}
@@ -142,7 +308,11 @@ func RandomVec(args RandomVecArgs) {
 		case 0, 64:
 			ints := args.Vec.Int64()
 			for i := 0; i < args.N; i++ {
-				ints[i] = int64(args.Rand.Uint64())
+				if correlated(i) {
+					ints[i] = src.Int64()[i]
+					continue
+				}
+				ints[i] = gen()
 				if args.IntRange != 0 {
 					ints[i] = ints[i] % int64(args.IntRange)
 				}
@@ -154,9 +324,14 @@ func RandomVec(args RandomVecArgs) {
 			}
 		}
 	case types.FloatFamily:
+		gen := floatGenerator(args)
 		floats := args.Vec.Float64()
 		for i := 0; i < args.N; i++ {
-			floats[i] = args.Rand.Float64()
+			if correlated(i) {
+				floats[i] = src.Float64()[i]
+				continue
+			}
+			floats[i] = gen()
 			if args.ZeroProhibited {
 				if floats[i] == 0 {
 					i--
@@ -166,6 +341,10 @@ func RandomVec(args RandomVecArgs) {
 	case types.TimestampTZFamily:
 		timestamps := args.Vec.Timestamp()
 		for i := 0; i < args.N; i++ {
+			if correlated(i) {
+				timestamps[i] = src.Timestamp()[i]
+				continue
+			}
 			timestamps[i] = timeutil.Unix(args.Rand.Int63n(1000000), args.Rand.Int63n(1000000))
 			loc := locations[args.Rand.Intn(len(locations))]
 			timestamps[i] = timestamps[i].In(loc)
@@ -173,21 +352,35 @@ func RandomVec(args RandomVecArgs) {
 	case types.IntervalFamily:
 		intervals := args.Vec.Interval()
 		for i := 0; i < args.N; i++ {
+			if correlated(i) {
+				intervals[i] = src.Interval()[i]
+				continue
+			}
 			intervals[i] = duration.FromFloat64(args.Rand.Float64())
 		}
 	default:
 		datums := args.Vec.Datum()
 		for i := 0; i < args.N; i++ {
+			if correlated(i) {
+				datums.Set(i, src.Datum().Get(i))
+				continue
+			}
 			datums.Set(i, rowenc.RandDatum(args.Rand, args.Vec.Type(), false /* nullOk */))
 		}
 	}
 	args.Vec.Nulls().UnsetNulls()
-	if args.NullProbability == 0 {
+	if args.NullProbability == 0 && correlatedRows == nil {
 		return
 	}
 
 	for i := 0; i < args.N; i++ {
-		if args.Rand.Float64() < args.NullProbability {
+		if correlated(i) {
+			if src.Nulls().NullAt(i) {
+				setNull(args.Rand, args.Vec, i)
+			}
+			continue
+		}
+		if args.NullProbability != 0 && args.Rand.Float64() < args.NullProbability {
 			setNull(args.Rand, args.Vec, i)
 		}
 	}
@@ -278,6 +471,79 @@ func RandomBatchWithSel(
 	return batch
 }
 
+// RandomBatchArgs are the arguments of RandomBatchWithArgs. All arguments other
+// than Rand and Typs are optional.
+type RandomBatchArgs struct {
+	// Rand is the provided RNG.
+	Rand *rand.Rand
+	// Typs are the types of the batch.
+	Typs []*types.T
+	// Capacity is the capacity of the batch, coldata.BatchSize() by default.
+	// All of the rows up to the capacity are generated.
+	Capacity int
+	// NullProbability determines the probability of a single value being NULL.
+	NullProbability float64
+	// SelProbability, if greater than zero, makes the batch have a selection
+	// vector where each row is selected with this probability. If it is 1, the
+	// selection vector selects all of the rows.
+	SelProbability float64
+	// DuplicateProbability determines the probability of a row being a
+	// duplicate (including the NULLs) of the previous row.
+	DuplicateProbability float64
+	// BytesFixedLength is the BytesFixedLength of RandomVecArgs.
+	BytesFixedLength int
+}
+
+// RandomBatchWithArgs returns a batch of random rows according to args. The
+// length of the batch is the length of its selection vector if it has one,
+// and its capacity otherwise.
+func RandomBatchWithArgs(allocator *colmem.Allocator, args RandomBatchArgs) coldata.Batch {
+	capacity := args.Capacity
+	if capacity == 0 {
+		capacity = coldata.BatchSize()
+	}
+	batch := allocator.NewMemBatchWithFixedCapacity(args.Typs, capacity)
+	for _, colVec := range batch.ColVecs() {
+		RandomVec(RandomVecArgs{
+			Rand:             args.Rand,
+			Vec:              colVec,
+			N:                capacity,
+			NullProbability:  args.NullProbability,
+			BytesFixedLength: args.BytesFixedLength,
+		})
+	}
+	if args.DuplicateProbability > 0 {
+		// Every row is copied from the row at the same index in rowIdxs, which
+		// is the index of the previous row for the duplicates.
+		rowIdxs := make([]int, capacity)
+		for i := 1; i < capacity; i++ {
+			rowIdxs[i] = i
+			if args.Rand.Float64() < args.DuplicateProbability {
+				rowIdxs[i] = rowIdxs[i-1]
+			}
+		}
+		withDuplicates := allocator.NewMemBatchWithFixedCapacity(args.Typs, capacity)
+		for colIdx, colVec := range withDuplicates.ColVecs() {
+			colVec.Copy(coldata.CopySliceArgs{
+				SliceArgs: coldata.SliceArgs{
+					Src:       batch.ColVec(colIdx),
+					Sel:       rowIdxs,
+					SrcEndIdx: capacity,
+				},
+			})
+		}
+		batch = withDuplicates
+	}
+	batch.SetLength(capacity)
+	if args.SelProbability > 0 {
+		sel := RandomSel(args.Rand, capacity, 1-args.SelProbability)
+		batch.SetSelection(true)
+		copy(batch.Selection(), sel)
+		batch.SetLength(len(sel))
+	}
+	return batch
+}
+
 const (
 	defaultMaxSchemaLength = 8
 	defaultNumBatches      = 4
@@ -0,0 +1,198 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colserde
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/bitutil"
+	"github.com/apache/arrow/go/arrow/ipc"
+	"github.com/apache/arrow/go/arrow/memory"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/typeconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// arrowType returns the arrow type of the data that ArrowBatchConverter
+// converts vectors of type t to.
+func arrowType(t *types.T) arrow.DataType {
+	switch typeconv.TypeFamilyToCanonicalTypeFamily(t.Family()) {
+	case types.BoolFamily:
+		return arrow.FixedWidthTypes.Boolean
+	case types.IntFamily:
+		switch t.Width() {
+		case 16:
+			return arrow.PrimitiveTypes.Int16
+		case 32:
+			return arrow.PrimitiveTypes.Int32
+		}
+		return arrow.PrimitiveTypes.Int64
+	case types.FloatFamily:
+		return arrow.PrimitiveTypes.Float64
+	}
+	// All other types are converted to binary data, either directly or by
+	// marshaling their values.
+	return arrow.BinaryTypes.Binary
+}
+
+// ArrowSchema returns the schema of the arrow record batches which batches of
+// the given types are exported to by ArrowIPCWriter. Since batches don't have
+// column names, the columns are named by their ordinals.
+func ArrowSchema(typs []*types.T) *arrow.Schema {
+	fields := make([]arrow.Field, len(typs))
+	for i, t := range typs {
+		fields[i] = arrow.Field{Name: fmt.Sprintf("col%d", i), Type: arrowType(t), Nullable: true}
+	}
+	return arrow.NewSchema(fields, nil /* metadata */)
+}
+
+// arrowNullCount returns the number of nulls among the first n values of the
+// arrow null bitmap. An empty bitmap has no nulls.
+func arrowNullCount(bitmap *memory.Buffer, n int) int {
+	if bitmap == nil || bitmap.Len() == 0 {
+		return 0
+	}
+	return n - bitutil.CountSetBits(bitmap.Bytes(), 0 /* offset */, n)
+}
+
+// ArrowIPCWriter exports batches as record batches in the Arrow IPC streaming
+// format. Unlike the formats of FileSerializer and RecordBatchSerializer, which
+// are only read by CockroachDB, the stream is written by the arrow library, so
+// it can be consumed by external tools (e.g. pandas) and compared with the
+// output of other engines.
+type ArrowIPCWriter struct {
+	schema *arrow.Schema
+	c      *ArrowBatchConverter
+	w      *ipc.Writer
+	cols   []array.Interface
+}
+
+// NewArrowIPCWriter creates an ArrowIPCWriter for batches of the given types.
+// The caller is responsible for closing the given writer.
+func NewArrowIPCWriter(w io.Writer, typs []*types.T) (*ArrowIPCWriter, error) {
+	c, err := NewArrowBatchConverter(typs)
+	if err != nil {
+		return nil, err
+	}
+	schema := ArrowSchema(typs)
+	return &ArrowIPCWriter{
+		schema: schema,
+		c:      c,
+		w:      ipc.NewWriter(w, ipc.WithSchema(schema)),
+		cols:   make([]array.Interface, len(typs)),
+	}, nil
+}
+
+// WriteBatch writes the batch as one record batch. The batch must not have a
+// selection vector.
+func (w *ArrowIPCWriter) WriteBatch(batch coldata.Batch) error {
+	if batch.Selection() != nil {
+		return errors.AssertionFailedf("unexpected selection vector on the exported batch")
+	}
+	data, err := w.c.BatchToArrow(batch)
+	if err != nil {
+		return err
+	}
+	n := batch.Length()
+	for i, d := range data {
+		// The data returned by the converter is untyped, so we add the types
+		// and the null counts which the IPC writer needs.
+		typed := array.NewData(
+			w.schema.Field(i).Type, n, d.Buffers(), nil, /* childData */
+			arrowNullCount(d.Buffers()[0], n), 0, /* offset */
+		)
+		w.cols[i] = array.MakeFromData(typed)
+		typed.Release()
+	}
+	rec := array.NewRecord(w.schema, w.cols, int64(n))
+	for _, col := range w.cols {
+		col.Release()
+	}
+	defer rec.Release()
+	return w.w.Write(rec)
+}
+
+// Close writes the end of the stream. Nothing can be called after Close.
+func (w *ArrowIPCWriter) Close() error {
+	return w.w.Close()
+}
+
+// ArrowIPCReader imports the record batches of an Arrow IPC stream, like the
+// ones written by ArrowIPCWriter, into batches.
+type ArrowIPCReader struct {
+	c    *ArrowBatchConverter
+	r    *ipc.Reader
+	data []*array.Data
+}
+
+// NewArrowIPCReader creates an ArrowIPCReader which reads the stream from r.
+// The columns of the stream must have the arrow types of the given types (see
+// ArrowSchema), but their names are ignored.
+func NewArrowIPCReader(r io.Reader, typs []*types.T) (*ArrowIPCReader, error) {
+	c, err := NewArrowBatchConverter(typs)
+	if err != nil {
+		return nil, err
+	}
+	ipcReader, err := ipc.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	fields := ipcReader.Schema().Fields()
+	if len(fields) != len(typs) {
+		ipcReader.Release()
+		return nil, errors.Errorf("mismatched stream and schema width: %d != %d", len(fields), len(typs))
+	}
+	for i, f := range fields {
+		if expected := arrowType(typs[i]); f.Type.ID() != expected.ID() {
+			ipcReader.Release()
+			return nil, errors.Errorf(
+				"column %d of the stream has type %s, expected %s for %s", i, f.Type, expected, typs[i],
+			)
+		}
+	}
+	return &ArrowIPCReader{
+		c:    c,
+		r:    ipcReader,
+		data: make([]*array.Data, 0, len(typs)),
+	}, nil
+}
+
+// Next fills in the given batch, which must have been allocated with the types
+// of the reader, with the next record batch of the stream. The record batch
+// must not have more than coldata.BatchSize() rows. false is returned once the
+// stream is exhausted. The batch is only valid until the next call to Next or
+// Release.
+func (r *ArrowIPCReader) Next(b coldata.Batch) (bool, error) {
+	if !r.r.Next() {
+		return false, r.r.Err()
+	}
+	rec := r.r.Record()
+	n := int(rec.NumRows())
+	if n > coldata.BatchSize() {
+		return false, errors.Errorf(
+			"record batch has %d rows, more than the batch size %d", n, coldata.BatchSize(),
+		)
+	}
+	r.data = r.data[:0]
+	for _, col := range rec.Columns() {
+		r.data = append(r.data, col.Data())
+	}
+	return true, r.c.ArrowToBatch(r.data, n, b)
+}
+
+// Release releases the memory held by the reader.
+func (r *ArrowIPCReader) Release() {
+	r.r.Release()
+}
@@ -0,0 +1,81 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colserde_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldatatestutils"
+	"github.com/cockroachdb/cockroach/pkg/col/colserde"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArrowIPCRoundTrip(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	rng, _ := randutil.NewPseudoRand()
+	typs, b := randomBatch(testAllocator)
+	// Make copies of the original batches because the converter modifies and
+	// casts data without copying for performance reasons.
+	expected := []coldata.Batch{coldatatestutils.CopyBatch(b, typs, testColumnFactory)}
+	batches := []coldata.Batch{b}
+	for i := rng.Intn(4); i > 0; i-- {
+		capacity := rng.Intn(coldata.BatchSize()) + 1
+		b := coldatatestutils.RandomBatch(testAllocator, rng, typs, capacity, rng.Intn(capacity), rng.Float64())
+		expected = append(expected, coldatatestutils.CopyBatch(b, typs, testColumnFactory))
+		batches = append(batches, b)
+	}
+
+	var buf bytes.Buffer
+	w, err := colserde.NewArrowIPCWriter(&buf, typs)
+	require.NoError(t, err)
+	for _, b := range batches {
+		require.NoError(t, w.WriteBatch(b))
+	}
+	require.NoError(t, w.Close())
+
+	r, err := colserde.NewArrowIPCReader(&buf, typs)
+	require.NoError(t, err)
+	defer r.Release()
+	actual := testAllocator.NewMemBatchWithMaxCapacity(typs)
+	for _, e := range expected {
+		ok, err := r.Next(actual)
+		require.NoError(t, err)
+		require.True(t, ok)
+		coldata.AssertEquivalentBatches(t, e, actual)
+	}
+	ok, err := r.Next(actual)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestArrowIPCReaderSchemaMismatch(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	var buf bytes.Buffer
+	w, err := colserde.NewArrowIPCWriter(&buf, []*types.T{types.Int, types.String})
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	data := buf.Bytes()
+
+	_, err = colserde.NewArrowIPCReader(bytes.NewReader(data), []*types.T{types.Int})
+	require.Error(t, err)
+	_, err = colserde.NewArrowIPCReader(bytes.NewReader(data), []*types.T{types.Float, types.String})
+	require.Error(t, err)
+	r, err := colserde.NewArrowIPCReader(bytes.NewReader(data), []*types.T{types.Int, types.Bytes})
+	require.NoError(t, err)
+	r.Release()
+}
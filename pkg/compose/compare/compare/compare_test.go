@@ -121,6 +121,11 @@ func TestCompare(t *testing.T) {
 		})
 	}
 
+	// Record which schema features the mutated schemas contained, so that
+	// gaps in the coverage of the mutators are visible.
+	var coverage mutations.SchemaCoverage
+	defer func() { t.Log(coverage.String()) }()
+
 	for confName, config := range configs {
 		t.Run(confName, func(t *testing.T) {
 			t.Logf("starting test: %s", confName)
@@ -146,6 +151,7 @@ func TestCompare(t *testing.T) {
 					}
 				}
 				connSetup, _ := mutations.ApplyString(rng, setup, testCn.mutators...)
+				coverage.RecordString(connSetup)
 				if err := conn.Exec(ctx, connSetup); err != nil {
 					t.Log(connSetup)
 					t.Fatalf("%s: %v", testCn.name, err)
@@ -355,3 +355,132 @@ func RandomGeographyWithLayout(rng *rand.Rand, srid geopb.SRID, layout geom.Layo
 	}
 	return ret
 }
+
+// MakeRandomGeomBoundsForXY creates a RandomGeomBounds struct with the bounds
+// of a geometry, except for the given bounds of the X and Y dimensions.
+func MakeRandomGeomBoundsForXY(minX, maxX, minY, maxY float64) RandomGeomBounds {
+	randomBounds := MakeRandomGeomBounds()
+	randomBounds.minX, randomBounds.maxX = minX, maxX
+	randomBounds.minY, randomBounds.maxY = minY, maxY
+	return randomBounds
+}
+
+// RandomShapeOptions controls the shapes generated by RandomShape.
+type RandomShapeOptions struct {
+	// ShapeTypes are the types of the generated shapes, which can be
+	// LineString, Polygon and MultiPolygon.
+	ShapeTypes []geopb.ShapeType
+	// Bounds are the bounds of the coordinates of the shapes.
+	Bounds RandomGeomBounds
+	// MaxExtent is the maximum width and height of a shape.
+	MaxExtent float64
+	// MinVertices and MaxVertices are the bounds of the number of vertices of
+	// LineStrings and of the rings of Polygons, excluding the vertex that
+	// closes the rings. MinVertices must be at least 3.
+	MinVertices, MaxVertices int
+	// MaxPolygons is the maximum number of Polygons of a MultiPolygon.
+	MaxPolygons int
+}
+
+// MakeRandomShapeOptions creates RandomShapeOptions for shapes within the given
+// bounds, whose extent is at most a hundredth of the bounds.
+func MakeRandomShapeOptions(randomBounds RandomGeomBounds) RandomShapeOptions {
+	return RandomShapeOptions{
+		ShapeTypes: []geopb.ShapeType{
+			geopb.ShapeType_LineString,
+			geopb.ShapeType_Polygon,
+			geopb.ShapeType_MultiPolygon,
+		},
+		Bounds: randomBounds,
+		MaxExtent: math.Min(
+			randomBounds.maxX-randomBounds.minX, randomBounds.maxY-randomBounds.minY,
+		) / 100,
+		MinVertices: 3,
+		MaxVertices: 20,
+		MaxPolygons: 5,
+	}
+}
+
+// RandomShape generates a random valid, non-empty LineString, Polygon or
+// MultiPolygon, as configured by opts. Unlike RandomGeomT, which generates
+// shapes spanning the whole bounds, the shapes have a controlled extent and
+// number of vertices, like the shapes of real data. The LineStrings don't
+// intersect themselves, and the Polygons of MultiPolygons don't overlap.
+func RandomShape(
+	rng *rand.Rand, opts RandomShapeOptions, srid geopb.SRID, layout geom.Layout,
+) geom.T {
+	if len(opts.ShapeTypes) == 0 || opts.MinVertices < 3 || opts.MaxVertices < opts.MinVertices ||
+		opts.MaxPolygons < 1 || opts.MaxExtent <= 0 {
+		panic(errors.Newf("invalid random shape options: %+v", opts))
+	}
+	layout = RandomLayout(rng, layout)
+	// The shape is in a random box with the maximum extent.
+	box := opts.Bounds
+	width := math.Min(RandomCoord(rng, 0, opts.MaxExtent), box.maxX-box.minX)
+	height := math.Min(RandomCoord(rng, 0, opts.MaxExtent), box.maxY-box.minY)
+	box.minX = RandomCoord(rng, box.minX, box.maxX-width)
+	box.maxX = box.minX + width
+	box.minY = RandomCoord(rng, box.minY, box.maxY-height)
+	box.maxY = box.minY + height
+
+	numVertices := func() int {
+		return opts.MinVertices + rng.Intn(opts.MaxVertices-opts.MinVertices+1)
+	}
+	switch shapeType := opts.ShapeTypes[rng.Intn(len(opts.ShapeTypes))]; shapeType {
+	case geopb.ShapeType_LineString:
+		// The coordinates of a ring without the closing vertex form a
+		// LineString that doesn't intersect itself.
+		n := numVertices()
+		coords := RandomValidLinearRingCoords(rng, n, box, layout)
+		return geom.NewLineString(layout).MustSetCoords(coords[:n]).SetSRID(int(srid))
+	case geopb.ShapeType_Polygon:
+		return geom.NewPolygon(layout).MustSetCoords([][]geom.Coord{
+			RandomValidLinearRingCoords(rng, numVertices(), box, layout),
+		}).SetSRID(int(srid))
+	case geopb.ShapeType_MultiPolygon:
+		// Every Polygon is in a column of the box of its own, with a gap
+		// between the columns, so that the Polygons don't touch.
+		ret := geom.NewMultiPolygon(layout).SetSRID(int(srid))
+		num := 1 + rng.Intn(opts.MaxPolygons)
+		columnWidth := width / float64(num)
+		for i := 0; i < num; i++ {
+			column := box
+			column.minX = box.minX + float64(i)*columnWidth
+			column.maxX = column.minX + columnWidth*0.9
+			polygon := geom.NewPolygon(layout).MustSetCoords([][]geom.Coord{
+				RandomValidLinearRingCoords(rng, numVertices(), column, layout),
+			}).SetSRID(int(srid))
+			if err := ret.Push(polygon); err != nil {
+				panic(err)
+			}
+		}
+		return ret
+	default:
+		panic(errors.Newf("unsupported shape type: %v", shapeType))
+	}
+}
+
+// RandomGeometryWithOptions generates a random Geometry with the given SRID,
+// whose shape is generated by RandomShape with the given options.
+func RandomGeometryWithOptions(
+	rng *rand.Rand, srid geopb.SRID, opts RandomShapeOptions,
+) geo.Geometry {
+	ret, err := geo.MakeGeometryFromGeomT(RandomShape(rng, opts, srid, geom.XY))
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
+
+// RandomGeographyWithOptions generates a random Geography with the given
+// SRID, whose shape is generated by RandomShape with the given options. The
+// bounds of the options must be within the bounds of a geography.
+func RandomGeographyWithOptions(
+	rng *rand.Rand, srid geopb.SRID, opts RandomShapeOptions,
+) geo.Geography {
+	ret, err := geo.MakeGeographyFromGeomT(RandomShape(rng, opts, srid, geom.XY))
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
@@ -69,3 +69,41 @@ func TestRandomGeomT(t *testing.T) {
 		})
 	}
 }
+
+func TestRandomShape(t *testing.T) {
+	rng, _ := randutil.NewPseudoRand()
+	opts := MakeRandomShapeOptions(MakeRandomGeomBoundsForGeography())
+	opts.MinVertices, opts.MaxVertices = 4, 6
+	opts.MaxExtent = 2
+	for run := 0; run < numRuns; run++ {
+		t.Run(strconv.Itoa(run), func(t *testing.T) {
+			g := RandomShape(rng, opts, geopb.DefaultGeographySRID, geom.NoLayout)
+			require.False(t, g.Empty())
+			require.Equal(t, int(geopb.DefaultGeographySRID), g.SRID())
+			bounds := g.Bounds()
+			require.True(t, bounds.Max(0)-bounds.Min(0) <= opts.MaxExtent)
+			require.True(t, bounds.Max(1)-bounds.Min(1) <= opts.MaxExtent)
+			require.True(t, -180 <= bounds.Min(0) && bounds.Max(0) <= 180)
+			require.True(t, -90 <= bounds.Min(1) && bounds.Max(1) <= 90)
+			var rings [][]geom.Coord
+			switch g := g.(type) {
+			case *geom.LineString:
+				require.True(t, g.NumCoords() >= opts.MinVertices && g.NumCoords() <= opts.MaxVertices)
+			case *geom.Polygon:
+				rings = g.Coords()
+			case *geom.MultiPolygon:
+				require.True(t, g.NumPolygons() >= 1 && g.NumPolygons() <= opts.MaxPolygons)
+				for _, polygon := range g.Coords() {
+					rings = append(rings, polygon...)
+				}
+			default:
+				t.Fatalf("unexpected shape %T", g)
+			}
+			for _, ring := range rings {
+				// The rings are closed by an additional vertex.
+				require.True(t, len(ring) >= opts.MinVertices+1 && len(ring) <= opts.MaxVertices+1)
+				require.Equal(t, ring[0], ring[len(ring)-1])
+			}
+		})
+	}
+}
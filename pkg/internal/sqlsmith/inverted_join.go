@@ -0,0 +1,203 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sqlsmith
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+// makeInvertedJoinExpr makes a join which the optimizer can plan as an inverted
+// join: the right side has an inverted index, and the ON condition is a
+// containment or geospatial predicate between a column of the left side and
+// the indexed column of the right side.
+func makeInvertedJoinExpr(s *Smither, _ colRefs, forJoin bool) (tree.TableExpr, colRefs, bool) {
+	join, refs, ok := s.makeInvertedJoin()
+	if !ok {
+		return nil, nil, false
+	}
+	return join, refs, true
+}
+
+// GenerateInvertedJoin returns a query which the optimizer should plan as an
+// inverted join, along with an equivalent reference query which cannot be
+// planned as an inverted join because the right side is scanned through its
+// primary index. Differential tests can compare the results of the two
+// queries. ok is false if the schema doesn't have any inverted indexes that
+// can be used in an inverted join.
+func (s *Smither) GenerateInvertedJoin() (query, reference string, ok bool) {
+	join, _, ok := s.makeInvertedJoin()
+	if !ok {
+		return "", "", false
+	}
+	refJoin := *join
+	refJoin.Hint = ""
+	refRight := *join.Right.(*tree.AliasedTableExpr)
+	refRight.IndexFlags = &tree.IndexFlags{Index: tree.UnrestrictedName("primary")}
+	refJoin.Right = &refRight
+
+	makeSelect := func(from tree.TableExpr) string {
+		return prettyCfg.Pretty(&tree.Select{
+			Select: &tree.SelectClause{
+				Exprs: tree.SelectExprs{tree.StarSelectExpr()},
+				From:  tree.From{Tables: tree.TableExprs{from}},
+			},
+		})
+	}
+	return makeSelect(join), makeSelect(&refJoin), true
+}
+
+// invertedJoinCandidate is a right side of an inverted join: a table and the
+// column of one of its inverted indexes.
+type invertedJoinCandidate struct {
+	table *tableRef
+	col   *tree.ColumnTableDef
+}
+
+// makeInvertedJoin makes a join for makeInvertedJoinExpr and
+// GenerateInvertedJoin.
+func (s *Smither) makeInvertedJoin() (*tree.JoinTableExpr, colRefs, bool) {
+	right, ok := s.getRandInvertedJoinCandidate()
+	if !ok {
+		return nil, nil, false
+	}
+	rightTyp := tree.MustBeStaticallyKnownType(right.col.Type)
+
+	// Find a column on the left side with a type that can be joined with the
+	// indexed column.
+	var leftTable *tableRef
+	var leftCol *tree.ColumnTableDef
+	func() {
+		s.lock.RLock()
+		defer s.lock.RUnlock()
+		type candidate struct {
+			table *tableRef
+			col   *tree.ColumnTableDef
+		}
+		var candidates []candidate
+		for _, table := range s.tables {
+			for _, col := range table.Columns {
+				typ := tree.MustBeStaticallyKnownType(col.Type)
+				if typ.Equivalent(rightTyp) {
+					candidates = append(candidates, candidate{table: table, col: col})
+				}
+			}
+		}
+		// There is always at least one candidate: the right column itself.
+		c := candidates[s.rnd.Intn(len(candidates))]
+		leftTable, leftCol = c.table, c.col
+	}()
+
+	leftAlias := s.name("tab")
+	rightAlias := s.name("tab")
+	leftName := tree.NewUnqualifiedTableName(leftAlias)
+	rightName := tree.NewUnqualifiedTableName(rightAlias)
+	leftExpr, leftRefs := s.tableExpr(leftTable, leftName)
+	rightExpr, rightRefs := s.tableExpr(right.table, rightName)
+
+	cond := s.makeInvertedJoinCond(
+		rightTyp,
+		typedParen(tree.NewColumnItem(leftName, leftCol.Name), rightTyp),
+		typedParen(tree.NewColumnItem(rightName, right.col.Name), rightTyp),
+	)
+
+	join := &tree.JoinTableExpr{
+		JoinType: tree.AstInner,
+		Left: &tree.AliasedTableExpr{
+			Expr: leftExpr,
+			As:   tree.AliasClause{Alias: leftAlias},
+		},
+		Right: &tree.AliasedTableExpr{
+			Expr: rightExpr,
+			As:   tree.AliasClause{Alias: rightAlias},
+		},
+		Cond: &tree.OnJoinCond{Expr: cond},
+	}
+	if s.coin() {
+		join.JoinType = tree.AstLeft
+	}
+	if s.coin() {
+		join.Hint = tree.AstInverted
+	}
+	return join, leftRefs.extend(rightRefs...), true
+}
+
+// getRandInvertedJoinCandidate returns a random table with a single-column
+// inverted index and the indexed column.
+func (s *Smither) getRandInvertedJoinCandidate() (invertedJoinCandidate, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	var candidates []invertedJoinCandidate
+	for _, table := range s.tables {
+		for _, idx := range s.indexes[*table.TableName] {
+			if !idx.Inverted {
+				continue
+			}
+			// Multi-column inverted indexes require constraints on the prefix
+			// columns, so only use indexes where the first column is the
+			// inverted column (the following ones are the implicit primary key
+			// columns).
+			col := s.columns[*table.TableName][idx.Columns[0].Column]
+			if col == nil ||
+				!colinfo.ColumnTypeIsInvertedIndexable(tree.MustBeStaticallyKnownType(col.Type)) {
+				continue
+			}
+			candidates = append(candidates, invertedJoinCandidate{table: table, col: col})
+		}
+	}
+	if len(candidates) == 0 {
+		return invertedJoinCandidate{}, false
+	}
+	return candidates[s.rnd.Intn(len(candidates))], true
+}
+
+var (
+	invertedJoinGeometryFns = []string{
+		"st_intersects",
+		"st_covers",
+		"st_coveredby",
+		"st_contains",
+		"st_within",
+	}
+	invertedJoinGeographyFns = []string{
+		"st_intersects",
+		"st_covers",
+		"st_coveredby",
+	}
+)
+
+// makeInvertedJoinCond returns a predicate between left and right, both of
+// type typ, which can be used to plan an inverted join with an inverted index
+// on right.
+func (s *Smither) makeInvertedJoinCond(typ *types.T, left, right tree.TypedExpr) tree.Expr {
+	var fns []string
+	switch typ.Family() {
+	case types.GeometryFamily:
+		fns = invertedJoinGeometryFns
+	case types.GeographyFamily:
+		fns = invertedJoinGeographyFns
+	default:
+		// JSON and arrays.
+		if s.coin() {
+			return tree.NewTypedComparisonExpr(tree.Contains, right, left)
+		}
+		return tree.NewTypedComparisonExpr(tree.ContainedBy, left, right)
+	}
+	args := tree.Exprs{left, right}
+	if s.coin() {
+		args[0], args[1] = args[1], args[0]
+	}
+	return &tree.FuncExpr{
+		Func:  tree.WrapFunction(fns[s.rnd.Intn(len(fns))]),
+		Exprs: args,
+	}
+}
@@ -118,6 +118,7 @@ var (
 		{40, makeEquiJoinExpr},
 		{20, makeSchemaTable},
 		{10, makeJoinExpr},
+		{5, makeInvertedJoinExpr},
 		{1, makeValuesTable},
 		{2, makeSelectTable},
 	}
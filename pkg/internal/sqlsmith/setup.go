@@ -35,6 +35,10 @@ var Setups = map[string]Setup{
 	// execution are used.
 	"seed-vec":    wrapCommonSetup(stringSetup(vecSeedTable)),
 	"rand-tables": wrapCommonSetup(randTables),
+	// inverted-tables creates tables with inverted indexes on JSON, array
+	// and geometry columns, with values from small domains so that inverted
+	// joins between them produce results.
+	"inverted-tables": wrapCommonSetup(invertedTables),
 }
 
 // wrapCommonSetup wraps setup steps common to all SQLSmith setups around the
@@ -108,6 +112,96 @@ func randTables(r *rand.Rand) string {
 	return sb.String()
 }
 
+func invertedTables(r *rand.Rand) string {
+	var sb strings.Builder
+	for _, name := range []string{"inv1", "inv2"} {
+		fmt.Fprintf(&sb, `CREATE TABLE %s (
+	k INT PRIMARY KEY,
+	j JSONB,
+	a INT[],
+	g GEOMETRY,
+	INVERTED INDEX (j),
+	INVERTED INDEX (a),
+	INVERTED INDEX (g)
+);
+`, name)
+		fmt.Fprintf(&sb, "INSERT INTO %s VALUES\n", name)
+		numRows := r.Intn(50) + 10
+		for i := 0; i < numRows; i++ {
+			if i > 0 {
+				sb.WriteString(",\n")
+			}
+			fmt.Fprintf(&sb, "\t(%d, %s, %s, %s)",
+				i, randInvertedJSON(r), randInvertedArray(r), randInvertedGeometry(r))
+		}
+		sb.WriteString(";\n")
+	}
+	return sb.String()
+}
+
+// randInvertedJSON returns a random JSON literal with keys and values from a
+// small domain, or NULL.
+func randInvertedJSON(r *rand.Rand) string {
+	switch r.Intn(6) {
+	case 0:
+		return "NULL"
+	case 1:
+		return fmt.Sprintf("'%d'", r.Intn(3))
+	case 2:
+		return fmt.Sprintf("'[%d, %d]'", r.Intn(3), r.Intn(3))
+	default:
+		var sb strings.Builder
+		sb.WriteString("'{")
+		for i, n := 0, r.Intn(3); i < n; i++ {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			key := string(rune('a' + i))
+			if r.Intn(4) == 0 {
+				fmt.Fprintf(&sb, `"%s": {"%s": %d}`, key, key, r.Intn(3))
+			} else {
+				fmt.Fprintf(&sb, `"%s": %d`, key, r.Intn(3))
+			}
+		}
+		sb.WriteString("}'")
+		return sb.String()
+	}
+}
+
+// randInvertedArray returns a random INT[] literal with values from a small
+// domain, or NULL.
+func randInvertedArray(r *rand.Rand) string {
+	if r.Intn(6) == 0 {
+		return "NULL"
+	}
+	vals := make([]string, r.Intn(4))
+	for i := range vals {
+		vals[i] = fmt.Sprint(r.Intn(5))
+	}
+	return fmt.Sprintf("ARRAY[%s]::INT[]", strings.Join(vals, ", "))
+}
+
+// randInvertedGeometry returns a random POINT, LINESTRING or POLYGON with
+// small integer coordinates, or NULL.
+func randInvertedGeometry(r *rand.Rand) string {
+	point := func() string {
+		return fmt.Sprintf("%d %d", r.Intn(5), r.Intn(5))
+	}
+	switch r.Intn(4) {
+	case 0:
+		return "NULL"
+	case 1:
+		return fmt.Sprintf("'POINT(%s)'", point())
+	case 2:
+		return fmt.Sprintf("'LINESTRING(%s, %s)'", point(), point())
+	default:
+		x, y := r.Intn(4), r.Intn(4)
+		w, h := r.Intn(3)+1, r.Intn(3)+1
+		return fmt.Sprintf("'POLYGON((%d %d, %d %d, %d %d, %d %d, %d %d))'",
+			x, y, x+w, y, x+w, y+h, x, y+h, x, y)
+	}
+}
+
 const (
 	seedTable = `
 CREATE TYPE greeting AS ENUM ('hello', 'howdy', 'hi', 'good day', 'morning');
@@ -434,6 +434,8 @@ func newSQLServer(ctx context.Context, cfg sqlServerArgs) (*SQLServer, error) {
 
 		RangeCache:     cfg.distSender.RangeDescriptorCache(),
 		HydratedTables: hydratedTablesCache,
+
+		MemoryUsageRegistry: execinfra.NewMemoryUsageRegistry(),
 	}
 	cfg.TempStorageConfig.Mon.SetMetrics(distSQLMetrics.CurDiskBytesCount, distSQLMetrics.MaxDiskBytesHist)
 	if distSQLTestingKnobs := cfg.TestingKnobs.DistSQL; distSQLTestingKnobs != nil {
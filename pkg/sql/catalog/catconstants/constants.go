@@ -81,6 +81,7 @@ const (
 	CrdbInternalClusterDatabasePrivilegesTableID
 	CrdbInternalInterleaved
 	CrdbInternalCrossDbRefrences
+	CrdbInternalVectorizedMemoryUsageTableID
 	InformationSchemaID
 	InformationSchemaAdministrableRoleAuthorizationsID
 	InformationSchemaApplicableRolesID
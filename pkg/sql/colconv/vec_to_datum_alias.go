@@ -0,0 +1,129 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colconv
+
+import (
+	"math"
+	"unsafe"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// canAliasVec returns whether the datums of values of typ can alias the
+// values of a vector, which is the case when the datum has the same memory
+// layout as the value.
+func canAliasVec(typ *types.T) bool {
+	switch typ.Family() {
+	case types.IntFamily:
+		// Only INT8 vectors store int64s, which have the layout of DInt.
+		return typ.Width() == 64
+	case types.FloatFamily:
+		return true
+	}
+	return false
+}
+
+// ColVecToAliasedDatumAndDeselect is like ColVecToDatumAndDeselect, but the
+// datums of INT8 and FLOAT8 (and FLOAT4) vectors point to the values of the
+// vector instead of being allocated, so they are only valid as long as the
+// vector isn't modified. It returns false without converting col if the datums
+// of its type can't alias it.
+func ColVecToAliasedDatumAndDeselect(
+	converted []tree.Datum, col coldata.Vec, length int, sel []int,
+) bool {
+	if !canAliasVec(col.Type()) {
+		return false
+	}
+	var nulls *coldata.Nulls
+	if col.MaybeHasNulls() {
+		nulls = col.Nulls()
+	}
+	switch col.Type().Family() {
+	case types.IntFamily:
+		vals := col.Int64()
+		for idx := 0; idx < length; idx++ {
+			srcIdx := idx
+			if sel != nil {
+				srcIdx = sel[idx]
+			}
+			if nulls != nil && nulls.NullAt(srcIdx) {
+				converted[idx] = tree.DNull
+				continue
+			}
+			converted[idx] = (*tree.DInt)(unsafe.Pointer(&vals[srcIdx]))
+		}
+	case types.FloatFamily:
+		vals := col.Float64()
+		for idx := 0; idx < length; idx++ {
+			srcIdx := idx
+			if sel != nil {
+				srcIdx = sel[idx]
+			}
+			if nulls != nil && nulls.NullAt(srcIdx) {
+				converted[idx] = tree.DNull
+				continue
+			}
+			converted[idx] = (*tree.DFloat)(unsafe.Pointer(&vals[srcIdx]))
+		}
+	}
+	return true
+}
+
+// aliasedDatum is a datum constructed by ColVecToAliasedDatumAndDeselect
+// together with the bits of its value at the time it was constructed.
+type aliasedDatum struct {
+	d    tree.Datum
+	bits uint64
+}
+
+// aliasedDatumBits returns the bits of the value of an INT8 or FLOAT datum.
+func aliasedDatumBits(d tree.Datum) uint64 {
+	switch t := d.(type) {
+	case *tree.DInt:
+		return uint64(*t)
+	case *tree.DFloat:
+		return math.Float64bits(float64(*t))
+	}
+	return 0
+}
+
+// appendAliasedDatums appends the non-NULL datums of converted, which were
+// constructed by ColVecToAliasedDatumAndDeselect, to aliased.
+func appendAliasedDatums(aliased []aliasedDatum, converted tree.Datums) []aliasedDatum {
+	for _, d := range converted {
+		if d != tree.DNull {
+			aliased = append(aliased, aliasedDatum{d: d, bits: aliasedDatumBits(d)})
+		}
+	}
+	return aliased
+}
+
+// AssertAliasedDatumsUnchanged panics with an assertion failure if one of the
+// datums that alias the vectors of the batches converted since the last call
+// has been modified. Modifying such a datum would also modify the vector it
+// points into, so the consumers of the datums must treat them as immutable,
+// which this checks in test builds. It must be called before the vectors are
+// reused for the next batch.
+func (c *VecToDatumConverter) AssertAliasedDatumsUnchanged() {
+	for _, a := range c.aliased {
+		if bits := aliasedDatumBits(a.d); bits != a.bits {
+			colexecerror.InternalError(errors.AssertionFailedf(
+				"datum %s that aliases a vector was modified, its bits changed from %x to %x",
+				a.d, a.bits, bits,
+			))
+		}
+	}
+	c.aliased = c.aliased[:0]
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util"
 	"github.com/lib/pq/oid"
 )
 
@@ -50,6 +51,12 @@ type VecToDatumConverter struct {
 	convertedVecs    []tree.Datums
 	vecIdxsToConvert []int
 	da               rowenc.DatumAlloc
+	// aliasVecs indicates whether ConvertBatchAndDeselect can construct
+	// datums that alias the vectors, see SetAliasVecs.
+	aliasVecs bool
+	// aliased are the datums that alias the vectors, which are only tracked
+	// in test builds, see AssertAliasedDatumsUnchanged.
+	aliased []aliasedDatum
 }
 
 var _ execinfra.Releasable = &VecToDatumConverter{}
@@ -94,11 +101,26 @@ func NewAllVecToDatumConverter(batchWidth int) *VecToDatumConverter {
 	return c
 }
 
+// SetAliasVecs sets whether ConvertBatchAndDeselect constructs the datums of
+// INT8 and FLOAT vectors so that they point to the values of the vectors
+// instead of allocating them (see ColVecToAliasedDatumAndDeselect). Such
+// datums are only valid until the vectors are modified, which is usually when
+// the next batch is produced, so this must only be enabled if the converted
+// datums aren't retained beyond that.
+func (c *VecToDatumConverter) SetAliasVecs(aliasVecs bool) {
+	c.aliasVecs = aliasVecs
+}
+
 // Release is part of the execinfra.Releasable interface.
 func (c *VecToDatumConverter) Release() {
 	*c = VecToDatumConverter{
 		convertedVecs:    c.convertedVecs[:0],
 		vecIdxsToConvert: c.vecIdxsToConvert[:0],
+		aliased:          c.aliased[:0],
+		// The datum alloc is kept so that the next user of the converter can
+		// use the rest of its allocated datums, since they haven't been
+		// handed out.
+		da: c.da,
 	}
 	vecToDatumConverterPool.Put(c)
 }
@@ -137,6 +159,14 @@ func (c *VecToDatumConverter) ConvertBatchAndDeselect(batch coldata.Batch) {
 	sel := batch.Selection()
 	vecs := batch.ColVecs()
 	for _, vecIdx := range c.vecIdxsToConvert {
+		if c.aliasVecs && ColVecToAliasedDatumAndDeselect(
+			c.convertedVecs[vecIdx], vecs[vecIdx], batchLength, sel,
+		) {
+			if util.CrdbTestBuild {
+				c.aliased = appendAliasedDatums(c.aliased, c.convertedVecs[vecIdx])
+			}
+			continue
+		}
 		ColVecToDatumAndDeselect(
 			c.convertedVecs[vecIdx], vecs[vecIdx], batchLength, sel, &c.da,
 		)
@@ -303,6 +303,7 @@ func UnmarshalColumnValueToCol(
 ) error {
 	if value.RawBytes == nil {
 		vec.Nulls().SetNull(idx)
+		return nil
 	}
 
 	var err error
@@ -357,3 +358,69 @@ func UnmarshalColumnValueToCol(
 	}
 	return err
 }
+
+// EncodeTableKeyFromCol encodes the idx'th value of the input coldata.Vec
+// using the key encoding and appends it to b. The value is encoded exactly the
+// same way as its datum would be.
+// See the analog, EncodeTableKey, in sqlbase/column_type_encoding.go.
+func EncodeTableKeyFromCol(
+	b []byte, vec coldata.Vec, idx int, valType *types.T, dir descpb.IndexDescriptor_Direction,
+) ([]byte, error) {
+	if (dir != descpb.IndexDescriptor_ASC) && (dir != descpb.IndexDescriptor_DESC) {
+		return nil, errors.AssertionFailedf("invalid direction: %d", log.Safe(dir))
+	}
+	if vec.Nulls().NullAt(idx) {
+		if dir == descpb.IndexDescriptor_ASC {
+			return encoding.EncodeNullAscending(b), nil
+		}
+		return encoding.EncodeNullDescending(b), nil
+	}
+	switch valType.Family() {
+	case types.BoolFamily:
+		var i int64
+		if vec.Bool()[idx] {
+			i = 1
+		}
+		if dir == descpb.IndexDescriptor_ASC {
+			return encoding.EncodeVarintAscending(b, i), nil
+		}
+		return encoding.EncodeVarintDescending(b, i), nil
+	case types.IntFamily, types.DateFamily:
+		i := intAt(vec, idx, valType)
+		if dir == descpb.IndexDescriptor_ASC {
+			return encoding.EncodeVarintAscending(b, i), nil
+		}
+		return encoding.EncodeVarintDescending(b, i), nil
+	case types.FloatFamily:
+		if dir == descpb.IndexDescriptor_ASC {
+			return encoding.EncodeFloatAscending(b, vec.Float64()[idx]), nil
+		}
+		return encoding.EncodeFloatDescending(b, vec.Float64()[idx]), nil
+	case types.DecimalFamily:
+		if dir == descpb.IndexDescriptor_ASC {
+			return encoding.EncodeDecimalAscending(b, &vec.Decimal()[idx]), nil
+		}
+		return encoding.EncodeDecimalDescending(b, &vec.Decimal()[idx]), nil
+	case types.BytesFamily, types.StringFamily, types.UuidFamily:
+		if dir == descpb.IndexDescriptor_ASC {
+			return encoding.EncodeBytesAscending(b, vec.Bytes().Get(idx)), nil
+		}
+		return encoding.EncodeBytesDescending(b, vec.Bytes().Get(idx)), nil
+	case types.TimestampFamily, types.TimestampTZFamily:
+		if dir == descpb.IndexDescriptor_ASC {
+			return encoding.EncodeTimeAscending(b, vec.Timestamp()[idx]), nil
+		}
+		return encoding.EncodeTimeDescending(b, vec.Timestamp()[idx]), nil
+	case types.IntervalFamily:
+		if dir == descpb.IndexDescriptor_ASC {
+			return encoding.EncodeDurationAscending(b, vec.Interval()[idx])
+		}
+		return encoding.EncodeDurationDescending(b, vec.Interval()[idx])
+	default:
+		encDir := encoding.Ascending
+		if dir == descpb.IndexDescriptor_DESC {
+			encDir = encoding.Descending
+		}
+		return rowenc.EncodeTableKey(b, datumAt(vec, idx), encDir)
+	}
+}
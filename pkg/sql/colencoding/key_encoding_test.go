@@ -0,0 +1,51 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colencoding
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+func TestEncodeTableKeyFromCol(t *testing.T) {
+	rng, _ := randutil.NewPseudoRand()
+	for i := 0; i < 1000; i++ {
+		typ := rowenc.RandSortingType(rng)
+		datum := rowenc.RandDatum(rng, typ, true /* nullOk */)
+		vec := coldata.NewMemColumn(typ, 1 /* n */, coldataext.NewExtendedColumnFactory(nil /* evalCtx */))
+		setDatumInVec(vec, typ, datum)
+		for _, dir := range []descpb.IndexDescriptor_Direction{
+			descpb.IndexDescriptor_ASC, descpb.IndexDescriptor_DESC,
+		} {
+			encDir, err := dir.ToEncodingDirection()
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected, err := rowenc.EncodeTableKey(nil /* b */, datum, encDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual, err := EncodeTableKeyFromCol(nil /* b */, vec, 0 /* idx */, typ, dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(expected, actual) {
+				t.Fatalf("%s of type %s (%s): expected %x, encoded %x", datum, typ.SQLString(), dir, expected, actual)
+			}
+		}
+	}
+}
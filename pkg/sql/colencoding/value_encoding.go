@@ -14,12 +14,15 @@ import (
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/duration"
 	"github.com/cockroachdb/cockroach/pkg/util/encoding"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/cockroachdb/errors"
 )
 
 // DecodeTableValueToCol decodes a value encoded by EncodeTableValue, writing
@@ -95,14 +98,14 @@ func decodeUntaggedDatumToCol(
 			vec.Int64()[idx] = i
 		}
 	case types.UuidFamily:
-		var data uuid.UUID
-		buf, data, err = encoding.DecodeUntaggedUUIDValue(buf)
-		// TODO(yuzefovich): we could peek inside the encoding package to skip a
-		// couple of conversions.
-		if err != nil {
-			return buf, err
+		// UUIDs are value encoded as their raw bytes, so we write them into the
+		// vector directly instead of going through uuid.UUID (see
+		// encoding.DecodeUntaggedUUIDValue).
+		if len(buf) < uuid.Size {
+			return buf, errors.Errorf("uuid: UUID must be exactly %d bytes long, got %d bytes", uuid.Size, len(buf))
 		}
-		vec.Bytes().Set(idx, data.GetBytes())
+		vec.Bytes().Set(idx, buf[:uuid.Size])
+		buf = buf[uuid.Size:]
 	case types.TimestampFamily, types.TimestampTZFamily:
 		var t time.Time
 		buf, t, err = encoding.DecodeUntaggedTimeValue(buf)
@@ -122,3 +125,63 @@ func decodeUntaggedDatumToCol(
 	}
 	return buf, err
 }
+
+// EncodeTableValueFromCol encodes the idx'th value of the input coldata.Vec
+// using the value encoding with the given column ID delta and appends it to
+// appendTo. The value is encoded exactly the same way as its datum would be.
+// See the analog, EncodeTableValue, in sqlbase/column_type_encoding.go.
+func EncodeTableValueFromCol(
+	appendTo []byte,
+	colID descpb.ColumnID,
+	vec coldata.Vec,
+	idx int,
+	valType *types.T,
+	scratch []byte,
+) ([]byte, error) {
+	if vec.Nulls().NullAt(idx) {
+		return encoding.EncodeNullValue(appendTo, uint32(colID)), nil
+	}
+	switch valType.Family() {
+	case types.BoolFamily:
+		return encoding.EncodeBoolValue(appendTo, uint32(colID), vec.Bool()[idx]), nil
+	case types.BytesFamily, types.StringFamily:
+		return encoding.EncodeBytesValue(appendTo, uint32(colID), vec.Bytes().Get(idx)), nil
+	case types.IntFamily, types.DateFamily:
+		return encoding.EncodeIntValue(appendTo, uint32(colID), intAt(vec, idx, valType)), nil
+	case types.DecimalFamily:
+		return encoding.EncodeDecimalValue(appendTo, uint32(colID), &vec.Decimal()[idx]), nil
+	case types.FloatFamily:
+		return encoding.EncodeFloatValue(appendTo, uint32(colID), vec.Float64()[idx]), nil
+	case types.UuidFamily:
+		u, err := uuid.FromBytes(vec.Bytes().Get(idx))
+		if err != nil {
+			return nil, err
+		}
+		return encoding.EncodeUUIDValue(appendTo, uint32(colID), u), nil
+	case types.TimestampFamily, types.TimestampTZFamily:
+		return encoding.EncodeTimeValue(appendTo, uint32(colID), vec.Timestamp()[idx]), nil
+	case types.IntervalFamily:
+		return encoding.EncodeDurationValue(appendTo, uint32(colID), vec.Interval()[idx]), nil
+	// Types backed by tree.Datums.
+	default:
+		return rowenc.EncodeTableValue(appendTo, colID, datumAt(vec, idx), scratch)
+	}
+}
+
+// intAt returns the idx'th value of the input vector of an integer-like type.
+func intAt(vec coldata.Vec, idx int, t *types.T) int64 {
+	switch t.Width() {
+	case 16:
+		return int64(vec.Int16()[idx])
+	case 32:
+		return int64(vec.Int32()[idx])
+	default:
+		return vec.Int64()[idx]
+	}
+}
+
+// datumAt returns the idx'th value of the input vector of a type backed by
+// tree.Datums.
+func datumAt(vec coldata.Vec, idx int) tree.Datum {
+	return vec.Datum().Get(idx).(*coldataext.Datum).Datum
+}
@@ -11,12 +11,16 @@
 package colencoding
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"testing"
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/colconv"
 	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
@@ -45,6 +49,9 @@ func TestDecodeTableValueToCol(t *testing.T) {
 			t.Fatal(err)
 		}
 	}
+	evalCtx := tree.NewTestingEvalContext(cluster.MakeTestingClusterSettings())
+	defer evalCtx.Stop(context.Background())
+	decoded := make([]tree.Datum, 1)
 	batch := coldata.NewMemBatchWithCapacity(typs, 1 /* capacity */, coldataext.NewExtendedColumnFactory(nil /*evalCtx */))
 	for i := 0; i < nCols; i++ {
 		typeOffset, dataOffset, _, typ, err := encoding.DecodeValueTag(buf)
@@ -58,11 +65,44 @@ func TestDecodeTableValueToCol(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		// TODO(jordan): should actually compare the outputs as well, but this is a
-		// decent enough smoke test.
+		colconv.ColVecToDatum(decoded, batch.ColVec(i), 1 /* length */, nil /* sel */, &da)
+		if decoded[0].Compare(evalCtx, datums[i]) != 0 {
+			t.Fatalf("expected %s of type %s, decoded %s", datums[i], typs[i].SQLString(), decoded[0])
+		}
 	}
 
 	if len(buf) != 0 {
 		t.Fatalf("leftover bytes %s", buf)
 	}
 }
+
+func TestEncodeTableValueFromCol(t *testing.T) {
+	rng, _ := randutil.NewPseudoRand()
+	var scratch []byte
+	for i := 0; i < 1000; i++ {
+		typ := rowenc.RandType(rng)
+		datum := rowenc.RandDatum(rng, typ, true /* nullOk */)
+		vec := coldata.NewMemColumn(typ, 1 /* n */, coldataext.NewExtendedColumnFactory(nil /* evalCtx */))
+		setDatumInVec(vec, typ, datum)
+		expected, err := rowenc.EncodeTableValue(nil /* appendTo */, descpb.ColumnID(i), datum, scratch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := EncodeTableValueFromCol(nil /* appendTo */, descpb.ColumnID(i), vec, 0 /* idx */, typ, scratch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(expected, actual) {
+			t.Fatalf("%s of type %s: expected %x, encoded %x", datum, typ.SQLString(), expected, actual)
+		}
+	}
+}
+
+// setDatumInVec writes the given datum into the first position of vec.
+func setDatumInVec(vec coldata.Vec, typ *types.T, datum tree.Datum) {
+	if datum == tree.DNull {
+		vec.Nulls().SetNull(0)
+		return
+	}
+	coldata.SetValueAt(vec, colconv.GetDatumToPhysicalFn(typ)(datum), 0 /* rowIdx */)
+}
@@ -252,9 +252,14 @@ func (h *filteringHashAggregatorHelper) performAggregation(
 // shared among all aggregation groups - the benefit of such approach is that
 // we only have a handful of map, but it turned out that such global map grows
 // a lot bigger and has worse performance.
+//
+// The memory used by the seen maps is accounted for with the allocator so that
+// the hash aggregator spills to disk when the number of distinct values is
+// large.
 type distinctAggregatorHelperBase struct {
 	*aggregatorHelperBase
 
+	allocator        *colmem.Allocator
 	inputTypes       []*types.T
 	aggColsConverter *colconv.VecToDatumConverter
 	arena            stringarena.Arena
@@ -273,6 +278,7 @@ func newDistinctAggregatorHelperBase(
 ) *distinctAggregatorHelperBase {
 	b := &distinctAggregatorHelperBase{
 		aggregatorHelperBase: newAggregatorHelperBase(args.Spec, maxBatchSize),
+		allocator:            args.Allocator,
 		inputTypes:           args.InputTypes,
 		arena:                stringarena.Make(args.MemAccount),
 		datumAlloc:           datumAlloc,
@@ -300,11 +306,13 @@ func newDistinctAggregatorHelperBase(
 	return b
 }
 
+// seenMapEntryOverhead is an estimate of the memory overhead of a single entry
+// in a seen map (the string header plus the bookkeeping of the map itself).
+// The encoded keys themselves are allocated in the arena which is accounted
+// for separately.
+const seenMapEntryOverhead = int64(unsafe.Sizeof("")) + 8
+
 func (b *distinctAggregatorHelperBase) makeSeenMaps() []map[string]struct{} {
-	// Note that we consciously don't account for the memory used under seen
-	// maps because that memory is likely noticeably smaller than the memory
-	// used (and accounted for) in other parts of the hash aggregation (the
-	// vectorized hash table and the aggregate functions).
 	seen := make([]map[string]struct{}, len(b.spec.Aggregations))
 	for i, aggFn := range b.spec.Aggregations {
 		if aggFn.Distinct {
@@ -333,6 +341,8 @@ func (b *distinctAggregatorHelperBase) selectDistinctTuples(
 		tupleIdx int
 		err      error
 		s        string
+		// numNewEntries tracks the change in the number of entries in seen.
+		numNewEntries int
 	)
 	for idx := 0; idx < inputLen; idx++ {
 		b.scratch.encoded = b.scratch.encoded[:0]
@@ -344,6 +354,7 @@ func (b *distinctAggregatorHelperBase) selectDistinctTuples(
 			// We have encountered a new group, so we need to clear the seen
 			// map. It turns out that it is faster to delete entries from the
 			// old map rather than allocating a new one.
+			numNewEntries -= len(seen)
 			for s := range seen {
 				delete(seen, s)
 			}
@@ -370,10 +381,12 @@ func (b *distinctAggregatorHelperBase) selectDistinctTuples(
 				colexecerror.InternalError(err)
 			}
 			seen[s] = struct{}{}
+			numNewEntries++
 			newSel[newLen] = tupleIdx
 			newLen++
 		}
 	}
+	b.allocator.AdjustMemoryUsage(int64(numNewEntries) * seenMapEntryOverhead)
 	return
 }
 
@@ -557,6 +570,19 @@ func (b *aggBucket) init(
 	b.seen = seen
 }
 
+// reset resets the bucket so that it can be reused for a new aggregation
+// group.
+func (b *aggBucket) reset() {
+	for _, fn := range b.fns {
+		fn.Reset()
+	}
+	for _, seen := range b.seen {
+		for k := range seen {
+			delete(seen, k)
+		}
+	}
+}
+
 const sizeOfAggBucket = int64(unsafe.Sizeof(aggBucket{}))
 const aggBucketSliceOverhead = int64(unsafe.Sizeof([]aggBucket{}))
 
@@ -0,0 +1,84 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"io"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/colserde"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+// ExportArrowIPC initializes and drains input, whose output types are typs,
+// and writes all of its batches to w as an Arrow IPC stream (see
+// colserde.ArrowIPCWriter), so that the output of the operators can be
+// consumed by external tools. The batches with selection vectors are
+// deselected first. The caller is responsible for closing the given writer.
+func ExportArrowIPC(
+	ctx context.Context,
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	typs []*types.T,
+	w io.Writer,
+) error {
+	writer, err := colserde.NewArrowIPCWriter(w, typs)
+	if err != nil {
+		return err
+	}
+	input = colexecutils.NewDeselectorOp(allocator, input, typs)
+	if panicErr := colexecerror.CatchVectorizedRuntimeError(func() {
+		input.Init()
+		for {
+			batch := input.Next(ctx)
+			if batch.Length() == 0 {
+				return
+			}
+			if err = writer.WriteBatch(batch); err != nil {
+				return
+			}
+		}
+	}); panicErr != nil {
+		return panicErr
+	}
+	if err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// ImportArrowIPC reads the Arrow IPC stream from r (see
+// colserde.ArrowIPCReader) and calls fn with every batch of the stream. The
+// batch passed to fn is only valid until fn returns.
+func ImportArrowIPC(
+	allocator *colmem.Allocator, r io.Reader, typs []*types.T, fn func(coldata.Batch) error,
+) error {
+	reader, err := colserde.NewArrowIPCReader(r, typs)
+	if err != nil {
+		return err
+	}
+	defer reader.Release()
+	batch := allocator.NewMemBatchWithFixedCapacity(typs, coldata.BatchSize())
+	for {
+		ok, err := reader.Next(batch)
+		if err != nil || !ok {
+			return err
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArrowIPCExportImport(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	typs := []*types.T{types.Int, types.String}
+	// The batch selects the even rows, which must be deselected by the
+	// export.
+	batch := testAllocator.NewMemBatchWithFixedCapacity(typs, coldata.BatchSize())
+	n := coldata.BatchSize()
+	for i := 0; i < n; i++ {
+		batch.ColVec(0).Int64()[i] = int64(i)
+		batch.ColVec(1).Bytes().Set(i, []byte{byte(i)})
+	}
+	batch.ColVec(1).Nulls().SetNull(0)
+	batch.SetSelection(true)
+	sel := batch.Selection()
+	var numSelected int
+	for i := 0; i < n; i += 2 {
+		sel[numSelected] = i
+		numSelected++
+	}
+	batch.SetLength(numSelected)
+	const numBatches = 3
+	source := colexecop.NewRepeatableBatchSource(testAllocator, batch, typs)
+	source.ResetBatchesToReturn(numBatches)
+
+	var buf bytes.Buffer
+	require.NoError(t, ExportArrowIPC(ctx, testAllocator, source, typs, &buf))
+
+	var numBatchesRead int
+	require.NoError(t, ImportArrowIPC(testAllocator, &buf, typs, func(b coldata.Batch) error {
+		numBatchesRead++
+		require.Nil(t, b.Selection())
+		require.Equal(t, numSelected, b.Length())
+		for i := 0; i < b.Length(); i++ {
+			require.Equal(t, int64(2*i), b.ColVec(0).Int64()[i])
+			if i == 0 {
+				require.True(t, b.ColVec(1).Nulls().NullAt(i))
+			} else {
+				require.Equal(t, []byte{byte(2 * i)}, b.ColVec(1).Bytes().Get(i))
+			}
+		}
+		return nil
+	}))
+	require.Equal(t, numBatches, numBatchesRead)
+}
@@ -118,6 +118,15 @@ func NewBuiltinFunctionOperator(
 	input colexecop.Operator,
 ) (colexecop.Operator, error) {
 	switch funcExpr.ResolvedOverload().SpecializedVecBuiltin {
+	case tree.DateTruncStringTimestamp, tree.DateTruncStringTimestampTZ:
+		withTZ := funcExpr.ResolvedOverload().SpecializedVecBuiltin == tree.DateTruncStringTimestampTZ
+		input = colexecutils.NewVectorTypeEnforcer(allocator, input, funcExpr.ResolvedType(), outputIdx)
+		return newDateTruncOperator(
+			allocator, evalCtx, funcExpr, argumentCols, outputIdx, input, withTZ,
+		), nil
+	case tree.ExtractStringTimestamp:
+		input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Float, outputIdx)
+		return newExtractOperator(allocator, funcExpr, argumentCols, outputIdx, input), nil
 	case tree.SubstringStringIntInt:
 		input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.String, outputIdx)
 		return newSubstringOperator(
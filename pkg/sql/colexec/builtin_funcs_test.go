@@ -14,6 +14,7 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"strings"
 	"testing"
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
@@ -24,11 +25,13 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/builtins"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -86,6 +89,122 @@ func TestBasicBuiltinFunctions(t *testing.T) {
 	}
 }
 
+// TestDateTimeBuiltins verifies that the specialized operators for date_trunc
+// and extract produce the same results as the row engine.
+func TestDateTimeBuiltins(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	// Trick to get the init() for the builtins package to run.
+	_ = builtins.AllBuiltinNames
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+	rng, _ := randutil.NewPseudoRand()
+
+	dateTruncTimeSpans := []string{
+		"millennium", "century", "decade", "year", "quarter", "month", "week",
+		"day", "hour", "minute", "second", "millisecond", "microsecond",
+	}
+	extractTimeSpans := append([]string{
+		"isoyear", "dow", "isodow", "doy", "julian", "epoch",
+	}, dateTruncTimeSpans...)
+	for _, tc := range []struct {
+		expr      string
+		typ       *types.T
+		timeSpans []string
+	}{
+		{expr: "date_trunc(@1, @2)", typ: types.Timestamp, timeSpans: dateTruncTimeSpans},
+		{expr: "date_trunc(@1, @2)", typ: types.TimestampTZ, timeSpans: dateTruncTimeSpans},
+		{expr: "extract(@1, @2)", typ: types.Timestamp, timeSpans: extractTimeSpans},
+	} {
+		t.Run(fmt.Sprintf("%s/%s", tc.expr, tc.typ), func(t *testing.T) {
+			typs := []*types.T{types.String, tc.typ}
+			expr, err := parser.ParseExpr(tc.expr)
+			require.NoError(t, err)
+			semaCtx := tree.MakeSemaContext()
+			semaCtx.IVarContainer = &colexectestutils.MockTypeContext{Typs: typs}
+			typedExpr, err := tree.TypeCheck(ctx, expr, &semaCtx, types.Any)
+			require.NoError(t, err)
+			fn := typedExpr.(*tree.FuncExpr).ResolvedOverload().Fn
+
+			var input, expected colexectestutils.Tuples
+			for i := 0; i < 100; i++ {
+				var timeSpan, ts interface{}
+				// Upper case time spans must be supported too.
+				timeSpanDatum := tree.NewDString(tc.timeSpans[rng.Intn(len(tc.timeSpans))])
+				if rng.Float64() < 0.5 {
+					timeSpanDatum = tree.NewDString(strings.ToUpper(string(*timeSpanDatum)))
+				}
+				timeDatum := rowenc.RandDatum(rng, tc.typ, false /* nullOk */)
+				if rng.Float64() < nullProbability {
+					timeSpanDatum = nil
+				} else {
+					timeSpan = string(*timeSpanDatum)
+				}
+				if rng.Float64() < nullProbability {
+					timeDatum = nil
+				} else {
+					switch d := timeDatum.(type) {
+					case *tree.DTimestamp:
+						ts = d.Time
+					case *tree.DTimestampTZ:
+						ts = d.Time
+					}
+				}
+				var res interface{}
+				if timeSpanDatum != nil && timeDatum != nil {
+					d, err := fn(&evalCtx, tree.Datums{timeSpanDatum, timeDatum})
+					if err != nil {
+						// The truncation might result in a timestamp outside of
+						// the supported bounds.
+						continue
+					}
+					switch d := d.(type) {
+					case *tree.DTimestamp:
+						res = d.Time
+					case *tree.DTimestampTZ:
+						res = d.Time
+					case *tree.DFloat:
+						res = float64(*d)
+					}
+				}
+				input = append(input, colexectestutils.Tuple{timeSpan, ts})
+				expected = append(expected, colexectestutils.Tuple{timeSpan, ts, res})
+			}
+			colexectestutils.RunTestsWithTyps(
+				t, testAllocator, []colexectestutils.Tuples{input}, [][]*types.T{typs},
+				expected, colexectestutils.OrderedVerifier,
+				func(input []colexecop.Operator) (colexecop.Operator, error) {
+					op, err := colexectestutils.CreateTestProjectingOperator(
+						ctx, flowCtx, input[0], typs,
+						tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+					)
+					if err != nil {
+						return nil, err
+					}
+					// Make sure that the specialized operator is planned.
+					var timeSpanOpFound bool
+					for n := execinfra.OpNode(op); n != nil && !timeSpanOpFound; {
+						_, timeSpanOpFound = n.(*timeSpanBuiltinOp)
+						if n.ChildCount(false /* verbose */) == 0 {
+							break
+						}
+						n = n.Child(0, false /* verbose */)
+					}
+					require.True(t, timeSpanOpFound)
+					return op, nil
+				})
+		})
+	}
+}
+
 func benchmarkBuiltinFunctions(b *testing.B, useSelectionVector bool, hasNulls bool) {
 	defer log.Scope(b).Close(b)
 	ctx := context.Background()
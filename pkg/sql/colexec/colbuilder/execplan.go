@@ -15,6 +15,7 @@ import (
 	"fmt"
 	"math"
 	"reflect"
+	"strings"
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
@@ -26,6 +27,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecagg"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecargs"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexeccmp"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecjoin"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecproj"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecsel"
@@ -71,14 +73,18 @@ func wrapRowSources(
 	var toWrapInputs []execinfra.RowSource
 	var releasables []execinfra.Releasable
 	for i, input := range inputs {
-		// Optimization: if the input is a Columnarizer, its input is
-		// necessarily a execinfra.RowSource, so remove the unnecessary
-		// conversion.
-		if c, ok := input.(*colexec.Columnarizer); ok {
+		// Optimization: if the input is a Columnarizer (which is the case when
+		// two adjacent processors are both wrapped), its input is necessarily
+		// a execinfra.RowSource, so remove the unnecessary conversion round
+		// trip.
+		if c := getColumnarizer(input); c != nil {
 			// Since this Columnarizer has been previously added to Closers and
 			// MetadataSources, this call ensures that all future calls are noops.
 			// Modifying the slices at this stage is difficult.
 			c.MarkAsRemovedFromFlow()
+			if log.V(2) {
+				log.Infof(ctx, "eliding the columnarizer-materializer pair on input %d of processor %d", i, args.Spec.ProcessorID)
+			}
 			toWrapInputs = append(toWrapInputs, c.Input())
 		} else {
 			var metadataSources execinfrapb.MetadataSources
@@ -97,11 +103,11 @@ func wrapRowSources(
 				args.Spec.ProcessorID,
 				input,
 				inputTypes[i],
-				nil, /* output */
-				nil, /* getStats */
-				metadataSources,
-				nil, /* toClose */
-				nil, /* cancelFlow */
+				colexec.WithMetadataSources(metadataSources),
+				// The materializer shares the processor ID with the wrapped
+				// processor, so it must not report its statistics which would
+				// be merged with the statistics of the wrapped processor.
+				colexec.WithoutStats(),
 			)
 			if err != nil {
 				return nil, releasables, err
@@ -131,6 +137,29 @@ func wrapRowSources(
 	return c, releasables, err
 }
 
+// getColumnarizer returns the Columnarizer that is the given operator or the
+// input to the InvariantsChecker that is the given operator. nil is returned
+// if there is no such Columnarizer.
+//
+// Looking through the InvariantsChecker only matters for the test builds (the
+// InvariantsChecker is not planned in the production builds), and it makes
+// them elide the same columnarizer-materializer pairs as the production
+// builds do. The InvariantsChecker explicitly supports having a removed
+// Columnarizer as its input. Any other operator is not looked through:
+// - a vectorized stats collector would no longer be part of the flow if the
+// Columnarizer were removed, so the statistics of the processor below it
+// would be lost (this is why the pairs are kept when the statistics are
+// collected, in all builds);
+// - a synchronizer merges several inputs, so there is no single Columnarizer
+// whose input could be passed to the wrapped processor.
+func getColumnarizer(op colexecop.Operator) *colexec.Columnarizer {
+	if ic, ok := op.(*colexec.InvariantsChecker); ok {
+		op = ic.Input
+	}
+	c, _ := op.(*colexec.Columnarizer)
+	return c
+}
+
 type opResult struct {
 	*colexecargs.NewColOperatorResult
 }
@@ -219,6 +248,14 @@ func supportedNatively(spec *execinfrapb.ProcessorSpec) error {
 		}
 		return nil
 
+	case spec.Core.JoinReader != nil:
+		// Only the index joins are supported natively (by ColIndexJoin), the
+		// lookup joins are still executed by the wrapped joinReader.
+		if len(spec.Core.JoinReader.LookupColumns) != 0 || !spec.Core.JoinReader.LookupExpr.Empty() {
+			return errLookupJoinUnsupported
+		}
+		return nil
+
 	case spec.Core.Filterer != nil:
 		return nil
 
@@ -288,6 +325,7 @@ func supportedNatively(spec *execinfrapb.ProcessorSpec) error {
 
 var (
 	errCoreUnsupportedNatively        = errors.New("unsupported processor core")
+	errLookupJoinUnsupported          = errors.New("lookup join reader is unsupported in vectorized")
 	errMetadataTestSenderWrap         = errors.New("core.MetadataTestSender is not supported")
 	errMetadataTestReceiverWrap       = errors.New("core.MetadataTestReceiver is not supported")
 	errChangeAggregatorWrap           = errors.New("core.ChangeAggregator is not supported")
@@ -391,6 +429,11 @@ func (r opResult) createDiskBackedSort(
 	var (
 		sorterMemMonitorName string
 		inMemorySorter       colexecop.Operator
+		// topK, if non-zero, is the number of tuples that the sorter needs
+		// to emit.
+		topK uint64
+		// inMemorySorterBudget is the workmem budget of the in-memory sorter.
+		inMemorySorterBudget *colmem.WorkMemBudget
 		err                  error
 	)
 	if len(ordering.Columns) == int(matchLen) {
@@ -409,9 +452,12 @@ func (r opResult) createDiskBackedSort(
 				ctx, flowCtx, sorterMemMonitorName,
 			)
 		}
+		var sortChunksAllocator *colmem.Allocator
+		sortChunksAllocator, inMemorySorterBudget = newSpillStrategyAllocator(
+			ctx, flowCtx, args, sortChunksMemAccount, factory,
+		)
 		inMemorySorter, err = colexec.NewSortChunks(
-			colmem.NewAllocator(ctx, sortChunksMemAccount, factory), input, inputTypes,
-			ordering.Columns, int(matchLen),
+			sortChunksAllocator, input, inputTypes, ordering.Columns, int(matchLen),
 		)
 	} else if post.Limit != 0 && post.Limit < math.MaxUint64-post.Offset {
 		// There is a limit specified, so we know exactly how many rows the
@@ -432,10 +478,13 @@ func (r opResult) createDiskBackedSort(
 				ctx, flowCtx, sorterMemMonitorName,
 			)
 		}
-		k := post.Limit + post.Offset
+		topK = post.Limit + post.Offset
+		var topKSorterAllocator *colmem.Allocator
+		topKSorterAllocator, inMemorySorterBudget = newSpillStrategyAllocator(
+			ctx, flowCtx, args, topKSorterMemAccount, factory,
+		)
 		inMemorySorter = colexec.NewTopKSorter(
-			colmem.NewAllocator(ctx, topKSorterMemAccount, factory), input, inputTypes,
-			ordering.Columns, k,
+			topKSorterAllocator, input, inputTypes, ordering.Columns, topK,
 		)
 	} else {
 		// No optimizations possible. Default to the standard sort operator.
@@ -448,8 +497,12 @@ func (r opResult) createDiskBackedSort(
 				ctx, flowCtx, sorterMemMonitorName,
 			)
 		}
+		var sorterAllocator *colmem.Allocator
+		sorterAllocator, inMemorySorterBudget = newSpillStrategyAllocator(
+			ctx, flowCtx, args, sorterMemAccount, factory,
+		)
 		inMemorySorter, err = colexec.NewSorter(
-			colmem.NewAllocator(ctx, sorterMemAccount, factory), input, inputTypes, ordering.Columns,
+			sorterAllocator, input, inputTypes, ordering.Columns,
 		)
 	}
 	if err != nil {
@@ -458,15 +511,18 @@ func (r opResult) createDiskBackedSort(
 	if inMemorySorter == nil {
 		return nil, errors.AssertionFailedf("unexpectedly inMemorySorter is nil")
 	}
-	// NOTE: when spilling to disk, we're using the same general external
-	// sorter regardless of which sorter variant we have instantiated (i.e.
-	// we don't take advantage of the limits and of partial ordering). We
-	// could improve this.
+	// NOTE: when spilling to disk, the top K sorter falls back to the external
+	// top K sorter, and the other sorter variants fall back to the same
+	// general external sorter (i.e. we don't take advantage of partial
+	// ordering). We could improve this.
 	return colexec.NewOneInputDiskSpiller(
 		input, inMemorySorter.(colexecop.BufferingInMemoryOperator),
 		sorterMemMonitorName,
 		func(input colexecop.Operator) colexecop.Operator {
 			monitorNamePrefix := fmt.Sprintf("%sexternal-sorter", memMonitorNamePrefix)
+			if topK > 0 {
+				monitorNamePrefix = fmt.Sprintf("%sexternal-topk-sorter", memMonitorNamePrefix)
+			}
 			// We are using unlimited memory monitors here because external
 			// sort itself is responsible for making sure that we stay within
 			// the memory limit.
@@ -483,6 +539,21 @@ func (r opResult) createDiskBackedSort(
 					ctx, flowCtx, monitorNamePrefix+"-output",
 				), factory)
 			diskAccount := r.createDiskAccount(ctx, flowCtx, monitorNamePrefix)
+			if topK > 0 {
+				es := colexec.NewExternalTopKSorter(
+					sortUnlimitedAllocator,
+					mergeUnlimitedAllocator,
+					outputUnlimitedAllocator,
+					input, inputTypes, ordering, topK,
+					execinfra.GetWorkMemLimit(flowCtx.Cfg),
+					args.TestingKnobs.DelegateFDAcquisitions,
+					args.DiskQueueCfg,
+					args.FDSemaphore,
+					diskAccount,
+				)
+				r.ToClose = append(r.ToClose, es.(colexecop.Closer))
+				return es
+			}
 			es := colexec.NewExternalSorter(
 				sortUnlimitedAllocator,
 				mergeUnlimitedAllocator,
@@ -500,6 +571,7 @@ func (r opResult) createDiskBackedSort(
 			return es
 		},
 		args.TestingKnobs.SpillingCallbackFn,
+		inMemorySorterBudget,
 	), nil
 }
 
@@ -522,6 +594,10 @@ func (r opResult) makeDiskBackedSorterConstructor(
 			))
 		}
 		sortArgs := *args
+		// The sorters created here are reused for several partitions, so they
+		// cannot donate their budget to the WorkMemPool once they are done with
+		// a single partition.
+		sortArgs.WorkMemPool = nil
 		if !args.TestingKnobs.DelegateFDAcquisitions {
 			// Set the FDSemaphore to nil. This indicates that no FDs should be
 			// acquired. The hash-based partitioner will do this up front.
@@ -603,6 +679,15 @@ func (r opResult) createAndWrapRowSource(
 					"processor %s is not an execinfra.RowSource", spec.Core.String(),
 				)
 			}
+			if execinfra.ShouldCollectStats(ctx, flowCtx) {
+				// The vectorized stats collector for this processor will
+				// report the execution statistics of the wrapped processor.
+				if d, ok := rs.(execinfra.ExecStatsForTraceDelegator); ok {
+					if stats := d.DelegateExecStatsForTrace(); stats != nil {
+						r.WrappedProcessorsStats = append(r.WrappedProcessorsStats, stats)
+					}
+				}
+			}
 			r.ColumnTypes = rs.OutputTypes()
 			return rs, nil
 		},
@@ -619,9 +704,19 @@ func (r opResult) createAndWrapRowSource(
 	r.MetadataSources = append(r.MetadataSources, r.Op.(execinfrapb.MetadataSource))
 	r.ToClose = append(r.ToClose, c)
 	r.Releasables = append(r.Releasables, releasables...)
+	r.WrappedProcessors = append(r.WrappedProcessors, colexecargs.WrappedProcessor{
+		Core:   coreName(&spec.Core),
+		Reason: causeToWrap,
+	})
 	return nil
 }
 
+// coreName returns the name of the processor core (for example,
+// "JoinReader").
+func coreName(core *execinfrapb.ProcessorCoreUnion) string {
+	return strings.TrimSuffix(reflect.TypeOf(core.GetValue()).Elem().Name(), "Spec")
+}
+
 // NOTE: throughout this file we do not append an output type of a projecting
 // operator to the passed-in type schema - we, instead, always allocate a new
 // type slice and copy over the old schema and set the output column of a
@@ -778,6 +873,25 @@ func NewColOperator(
 			result.ColumnTypes = scanOp.ResultTypes
 			result.ToClose = append(result.ToClose, scanOp)
 
+		case core.JoinReader != nil:
+			if err := checkNumIn(inputs, 1); err != nil {
+				return r, err
+			}
+
+			indexJoinOp, err := colfetcher.NewColIndexJoin(
+				ctx, streamingAllocator, flowCtx, evalCtx, inputs[0], spec.Input[0].ColumnTypes,
+				core.JoinReader, post, spec.EstimatedRowCount,
+			)
+			if err != nil {
+				return r, err
+			}
+			result.Op = indexJoinOp
+			result.KVReader = indexJoinOp
+			result.MetadataSources = append(result.MetadataSources, indexJoinOp)
+			result.Releasables = append(result.Releasables, indexJoinOp)
+			result.ColumnTypes = indexJoinOp.ResultTypes
+			result.ToClose = append(result.ToClose, indexJoinOp)
+
 		case core.Filterer != nil:
 			if err := checkNumIn(inputs, 1); err != nil {
 				return r, err
@@ -791,6 +905,14 @@ func NewColOperator(
 			); err != nil {
 				return r, err
 			}
+			if post.Limit == 0 {
+				// The filter might be highly selective, so we compact the
+				// sparse batches in order for the operators above not to walk
+				// the sparse selection vectors. We don't do so when there is a
+				// limit since the compactor might read more input batches than
+				// needed to satisfy the limit.
+				result.Op = colexecutils.NewCompactorOp(streamingAllocator, result.Op, result.ColumnTypes)
+			}
 
 		case core.Aggregator != nil:
 			if err := checkNumIn(inputs, 1); err != nil {
@@ -843,6 +965,14 @@ func NewColOperator(
 			result.ColumnTypes = newAggArgs.OutputTypes
 
 			if needHash {
+				newInMemoryHashAggregator := colexec.NewHashAggregator
+				if len(aggSpec.OrderedGroupCols) > 0 {
+					// The input is ordered on some of the grouping columns, so
+					// we can aggregate the chunks of tuples that are equal on
+					// those columns one at a time which bounds the memory
+					// usage by the number of groups within a single chunk.
+					newInMemoryHashAggregator = colexec.NewPartiallyOrderedAggregator
+				}
 				// We have separate unit tests that instantiate the in-memory
 				// hash aggregators, so we don't need to look at
 				// args.TestingKnobs.DiskSpillingDisabled and always instantiate
@@ -863,7 +993,7 @@ func NewColOperator(
 					evalCtx.SingleDatumAggMemAccount = hashAggregatorUnlimitedMemAccount
 					// The second argument is nil because we disable the
 					// tracking of the input tuples.
-					result.Op, err = colexec.NewHashAggregator(newAggArgs, nil /* newSpillingQueueArgs */)
+					result.Op, err = newInMemoryHashAggregator(newAggArgs, nil /* newSpillingQueueArgs */)
 				} else {
 					// We will divide the available memory equally between the
 					// two usages - the hash aggregation itself and the input
@@ -880,10 +1010,13 @@ func NewColOperator(
 					spillingQueueCfg := args.DiskQueueCfg
 					spillingQueueCfg.CacheMode = colcontainer.DiskQueueCacheModeReuseCache
 					spillingQueueCfg.SetDefaultBufferSizeBytesForCacheMode()
-					newAggArgs.Allocator = colmem.NewAllocator(ctx, hashAggregatorMemAccount, factory)
+					var inMemoryHashAggregatorBudget *colmem.WorkMemBudget
+					newAggArgs.Allocator, inMemoryHashAggregatorBudget = newSpillStrategyAllocator(
+						ctx, flowCtx, args, hashAggregatorMemAccount, factory,
+					)
 					newAggArgs.MemAccount = hashAggregatorMemAccount
 					var inMemoryHashAggregator colexecop.Operator
-					inMemoryHashAggregator, err = colexec.NewHashAggregator(
+					inMemoryHashAggregator, err = newInMemoryHashAggregator(
 						newAggArgs,
 						&colexecutils.NewSpillingQueueArgs{
 							UnlimitedAllocator: colmem.NewAllocator(ctx, spillingQueueMemAccount, factory),
@@ -927,6 +1060,7 @@ func NewColOperator(
 							)
 						},
 						args.TestingKnobs.SpillingCallbackFn,
+						inMemoryHashAggregatorBudget,
 					)
 				}
 			} else {
@@ -958,7 +1092,9 @@ func NewColOperator(
 				// ordered distinct, and we should plan it when we have
 				// non-empty ordered columns and we think that the probability
 				// of distinct tuples in the input is about 0.01 or less.
-				allocator := colmem.NewAllocator(ctx, distinctMemAccount, factory)
+				allocator, inMemoryUnorderedDistinctBudget := newSpillStrategyAllocator(
+					ctx, flowCtx, args, distinctMemAccount, factory,
+				)
 				inMemoryUnorderedDistinct := colexec.NewUnorderedDistinct(
 					allocator, inputs[0], core.Distinct.DistinctColumns, result.ColumnTypes,
 				)
@@ -983,6 +1119,7 @@ func NewColOperator(
 						)
 					},
 					args.TestingKnobs.SpillingCallbackFn,
+					inMemoryUnorderedDistinctBudget,
 				)
 				result.ToClose = append(result.ToClose, result.Op.(colexecop.Closer))
 			}
@@ -1038,18 +1175,45 @@ func NewColOperator(
 						ctx, result.createBufferingUnlimitedMemAccount(ctx, flowCtx, hashJoinerMemMonitorName), factory,
 					)
 				}
+				rightInput, hjRightTypes, rightEqCols := inputs[1], rightTypes, core.HashJoiner.RightEqColumns
+				if core.HashJoiner.Type == descpb.LeftSemiJoin || core.HashJoiner.Type == descpb.LeftAntiJoin {
+					// LEFT SEMI and LEFT ANTI joins don't output any of the
+					// right columns, so we project the right input to its
+					// equality columns in order to not store the other
+					// columns in the hash table (nor spill them to disk).
+					hjRightTypes = make([]*types.T, len(rightEqCols))
+					projectedEqCols := make([]uint32, len(rightEqCols))
+					for i, colIdx := range rightEqCols {
+						hjRightTypes[i] = rightTypes[colIdx]
+						projectedEqCols[i] = uint32(i)
+					}
+					rightInput = colexecbase.NewSimpleProjectOp(rightInput, len(rightTypes), rightEqCols)
+					rightEqCols = projectedEqCols
+				}
 				hjSpec := colexecjoin.MakeHashJoinerSpec(
 					core.HashJoiner.Type,
 					core.HashJoiner.LeftEqColumns,
-					core.HashJoiner.RightEqColumns,
+					rightEqCols,
 					leftTypes,
-					rightTypes,
+					hjRightTypes,
 					core.HashJoiner.RightEqColumnsAreKey,
 				)
 
+				leftInput := inputs[0]
+				if colexecjoin.RuntimeFilterSupported(core.HashJoiner.Type) &&
+					colexecjoin.RuntimeFilterEnabled.Get(&flowCtx.Cfg.Settings.SV) {
+					// The runtime filter is populated by the in-memory hash
+					// joiner only once the build side has been fully consumed,
+					// so it is safe to share the filtered left input with the
+					// disk-backed hash joiner (which can only be used if the
+					// in-memory one spills during the build phase).
+					leftInput = colexecjoin.NewRuntimeFilterOp(leftInput, core.HashJoiner.LeftEqColumns)
+				}
+				hashJoinerAllocator, inMemoryHashJoinerBudget := newSpillStrategyAllocator(
+					ctx, flowCtx, args, hashJoinerMemAccount, factory,
+				)
 				inMemoryHashJoiner := colexecjoin.NewHashJoiner(
-					colmem.NewAllocator(ctx, hashJoinerMemAccount, factory),
-					hashJoinerUnlimitedAllocator, hjSpec, inputs[0], inputs[1],
+					hashJoinerAllocator, hashJoinerUnlimitedAllocator, hjSpec, leftInput, rightInput,
 					colexecjoin.HashJoinerInitialNumBuckets, memoryLimit,
 				)
 				if args.TestingKnobs.DiskSpillingDisabled {
@@ -1060,7 +1224,7 @@ func NewColOperator(
 				} else {
 					diskAccount := result.createDiskAccount(ctx, flowCtx, hashJoinerMemMonitorName)
 					result.Op = colexec.NewTwoInputDiskSpiller(
-						inputs[0], inputs[1], inMemoryHashJoiner.(colexecop.BufferingInMemoryOperator),
+						leftInput, rightInput, inMemoryHashJoiner.(colexecop.BufferingInMemoryOperator),
 						hashJoinerMemMonitorName,
 						func(inputOne, inputTwo colexecop.Operator) colexecop.Operator {
 							monitorNamePrefix := fmt.Sprintf("external-hash-joiner-%d", spec.ProcessorID)
@@ -1080,6 +1244,7 @@ func NewColOperator(
 							return ehj
 						},
 						args.TestingKnobs.SpillingCallbackFn,
+						inMemoryHashJoinerBudget,
 					)
 				}
 			}
@@ -1345,7 +1510,7 @@ func NewColOperator(
 			castedIdx := len(r.ColumnTypes)
 			resultTypes := appendOneType(r.ColumnTypes, expected)
 			r.Op, err = colexecbase.GetCastOperator(
-				streamingAllocator, input, i, castedIdx, actual, expected,
+				streamingAllocator, input, i, castedIdx, actual, expected, flowCtx.EvalCtx,
 			)
 			if err != nil {
 				// We don't support a native vectorized cast between these
@@ -1565,6 +1730,31 @@ func (r opResult) createMemAccountForSpillStrategyWithLimit(
 	return &bufferingMemAccount
 }
 
+// newSpillStrategyAllocator creates an allocator for the in-memory buffering
+// operator that uses the memory account created via
+// createMemAccountForSpillStrategy. If the workmem rebalancing is enabled, the
+// monitor of the account is registered with the WorkMemPool of the flow so
+// that the allocator could borrow from the pool, and the budget of the monitor
+// is returned so that it could be donated to the pool once the in-memory
+// operator has emitted all of its output (and reclaimed if the operator is
+// reset).
+func newSpillStrategyAllocator(
+	ctx context.Context,
+	flowCtx *execinfra.FlowCtx,
+	args *colexecargs.NewColOperatorArgs,
+	acc *mon.BoundAccount,
+	factory coldata.ColumnFactory,
+) (*colmem.Allocator, *colmem.WorkMemBudget) {
+	pool := args.WorkMemPool
+	if pool == nil || args.TestingKnobs.UseStreamingMemAccountForBuffering ||
+		flowCtx.Cfg.TestingKnobs.ForceDiskSpill ||
+		!colexec.WorkMemRebalancingEnabled.Get(&flowCtx.Cfg.Settings.SV) {
+		return colmem.NewAllocator(ctx, acc, factory), nil
+	}
+	budget := pool.NewWorkMemBudget(acc.Monitor())
+	return colmem.NewAllocatorWithWorkMemPool(ctx, acc, factory, pool), budget
+}
+
 // createBufferingUnlimitedMemAccount instantiates an unlimited memory monitor
 // and a memory account to be used with a buffering disk-backed Operator. The
 // receiver is updated to have references to both objects. Note that the
@@ -1785,6 +1975,18 @@ func planSelectionOperators(
 				// FROM NULL.
 				negate := cmpOp == tree.IsDistinctFrom
 				op = colexec.NewIsNullSelOp(leftOp, leftIdx, negate, false /* isTupleNull */)
+			case tree.ILike, tree.NotILike, tree.SimilarTo, tree.NotSimilarTo,
+				tree.RegMatch, tree.NotRegMatch, tree.RegIMatch, tree.NotRegIMatch:
+				pattern, ok := tree.AsDString(constArg)
+				if !ok || lTyp.Family() != types.StringFamily {
+					// Some of these operators are also defined on non-string
+					// types, so we fallback to the default comparison
+					// operator.
+					break
+				}
+				op, err = colexecsel.GetRegexpSelectionConstOperator(
+					evalCtx, cmpOp, leftOp, leftIdx, string(pattern),
+				)
 			}
 			if op == nil || err != nil {
 				// op hasn't been created yet, so let's try the constructor for
@@ -1801,6 +2003,11 @@ func planSelectionOperators(
 		if err != nil {
 			return nil, resultIdx, ct, err
 		}
+		if colexeccmp.IsRegexpCmpOp(cmpOp) && lTyp.Family() == types.StringFamily &&
+			ct[rightIdx].Family() == types.StringFamily {
+			op = colexecsel.GetRegexpSelectionOperator(evalCtx, cmpOp, rightOp, leftIdx, rightIdx)
+			return op, resultIdx, ct, nil
+		}
 		op, err = colexecsel.GetSelectionOperator(
 			cmpOp, rightOp, ct, leftIdx, rightIdx, evalCtx, t,
 		)
@@ -1815,6 +2022,7 @@ func planSelectionOperators(
 // 'toType' that will be output at index 'resultIdx'.
 func planCastOperator(
 	ctx context.Context,
+	evalCtx *tree.EvalContext,
 	acc *mon.BoundAccount,
 	columnTypes []*types.T,
 	input colexecop.Operator,
@@ -1824,7 +2032,7 @@ func planCastOperator(
 	factory coldata.ColumnFactory,
 ) (op colexecop.Operator, resultIdx int, typs []*types.T, err error) {
 	outputIdx := len(columnTypes)
-	op, err = colexecbase.GetCastOperator(colmem.NewAllocator(ctx, acc, factory), input, inputIdx, outputIdx, fromType, toType)
+	op, err = colexecbase.GetCastOperator(colmem.NewAllocator(ctx, acc, factory), input, inputIdx, outputIdx, fromType, toType, evalCtx)
 	typs = appendOneType(columnTypes, toType)
 	return op, outputIdx, typs, err
 }
@@ -1885,7 +2093,7 @@ func planProjectionOperators(
 		if err != nil {
 			return nil, 0, nil, err
 		}
-		op, resultIdx, typs, err = planCastOperator(ctx, acc, typs, op, resultIdx, expr.ResolvedType(), t.ResolvedType(), factory)
+		op, resultIdx, typs, err = planCastOperator(ctx, evalCtx, acc, typs, op, resultIdx, expr.ResolvedType(), t.ResolvedType(), factory)
 		return op, resultIdx, typs, err
 	case *tree.FuncExpr:
 		var inputCols []int
@@ -2016,7 +2224,7 @@ func planProjectionOperators(
 				// is given). In such case, we need to plan a cast.
 				fromType, toType := typs[thenIdxs[i]], typs[caseOutputIdx]
 				caseOps[i], thenIdxs[i], typs, err = planCastOperator(
-					ctx, acc, typs, caseOps[i], thenIdxs[i], fromType, toType, factory,
+					ctx, evalCtx, acc, typs, caseOps[i], thenIdxs[i], fromType, toType, factory,
 				)
 				if err != nil {
 					return nil, resultIdx, typs, err
@@ -2042,7 +2250,7 @@ func planProjectionOperators(
 			elseIdx := thenIdxs[len(t.Whens)]
 			fromType, toType := typs[elseIdx], typs[caseOutputIdx]
 			elseOp, thenIdxs[len(t.Whens)], typs, err = planCastOperator(
-				ctx, acc, typs, elseOp, elseIdx, fromType, toType, factory,
+				ctx, evalCtx, acc, typs, elseOp, elseIdx, fromType, toType, factory,
 			)
 			if err != nil {
 				return nil, resultIdx, typs, err
@@ -2186,6 +2394,19 @@ func planProjectionExpr(
 				op = colexec.NewIsNullProjOp(
 					allocator, input, leftIdx, resultIdx, negate, false, /* isTupleNull */
 				)
+			case tree.ILike, tree.NotILike, tree.SimilarTo, tree.NotSimilarTo,
+				tree.RegMatch, tree.NotRegMatch, tree.RegIMatch, tree.NotRegIMatch:
+				pattern, ok := tree.AsDString(rConstArg)
+				if !ok || typs[leftIdx].Family() != types.StringFamily {
+					// Some of these operators are also defined on non-string
+					// types, so we fallback to the default comparison
+					// operator.
+					break
+				}
+				op, err = colexecproj.GetRegexpProjectionConstOperator(
+					allocator, evalCtx, projOp.(tree.ComparisonOperator), input,
+					leftIdx, resultIdx, string(pattern),
+				)
 			}
 			if op == nil || err != nil {
 				// op hasn't been created yet, so let's try the constructor for
@@ -2205,6 +2426,14 @@ func planProjectionExpr(
 				return nil, resultIdx, nil, err
 			}
 			resultIdx = len(typs)
+			if cmpOp, ok := projOp.(tree.ComparisonOperator); ok && colexeccmp.IsRegexpCmpOp(cmpOp) &&
+				typs[leftIdx].Family() == types.StringFamily && typs[rightIdx].Family() == types.StringFamily {
+				op = colexecproj.GetRegexpProjectionOperator(
+					allocator, evalCtx, cmpOp, input, leftIdx, rightIdx, resultIdx,
+				)
+				typs = appendOneType(typs, outputType)
+				return op, resultIdx, typs, nil
+			}
 			op, err = colexecproj.GetProjectionOperator(
 				allocator, typs, outputType, projOp, input, leftIdx, rightIdx,
 				resultIdx, evalCtx, binFn, cmpExpr,
@@ -12,16 +12,21 @@ package colbuilder
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/kv"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catalogkv"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecargs"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecjoin"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
@@ -31,6 +36,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
 	"github.com/stretchr/testify/require"
 )
 
@@ -125,11 +131,6 @@ func TestNewColOperatorExpectedTypeSchema(t *testing.T) {
 		0, /* processorID */
 		r.Op,
 		[]*types.T{types.Int},
-		nil, /* output */
-		nil, /* getStats */
-		nil, /* metadataSources */
-		nil, /* toClose */
-		nil, /* cancelFlow */
 	)
 	require.NoError(t, err)
 
@@ -148,3 +149,232 @@ func TestNewColOperatorExpectedTypeSchema(t *testing.T) {
 	}
 	require.Equal(t, numRows, rowIdx)
 }
+
+// TestWrappedProcessorsColumnarizerElision verifies that when the input to a
+// wrapped processor is a Columnarizer (possibly planned below an
+// InvariantsChecker), the Columnarizer and the Materializer that would be
+// planned on top of it are elided, so the row source is passed directly to the
+// wrapped processor. A Columnarizer planned below any other operator (like a
+// vectorized stats collector) is not elided.
+func TestWrappedProcessorsColumnarizerElision(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+	streamingMemAcc := evalCtx.Mon.MakeBoundAccount()
+	defer streamingMemAcc.Close(ctx)
+	allocator := colmem.NewAllocator(ctx, &streamingMemAcc, coldata.StandardColumnFactory)
+
+	typs := []*types.T{types.Int}
+	for _, tc := range []struct {
+		name   string
+		wrap   func(colexecop.Operator) colexecop.Operator
+		elided bool
+	}{
+		{
+			name:   "columnarizer",
+			wrap:   func(op colexecop.Operator) colexecop.Operator { return op },
+			elided: true,
+		},
+		{
+			name: "invariants-checker",
+			wrap: func(op colexecop.Operator) colexecop.Operator {
+				return colexec.NewInvariantsChecker(op)
+			},
+			elided: true,
+		},
+		{
+			name:   "other",
+			wrap:   func(op colexecop.Operator) colexecop.Operator { return colexecop.NewNoop(op) },
+			elided: false,
+		},
+	} {
+		source := execinfra.NewRepeatableRowSource(typs, rowenc.MakeIntRows(10, len(typs)))
+		c, err := colexec.NewBufferingColumnarizer(ctx, allocator, flowCtx, 0 /* processorID */, source)
+		require.NoError(t, err)
+		input := tc.wrap(c)
+		var wrappedInputs []execinfra.RowSource
+		errWrapped := errors.New("wrapped processor")
+		args := &colexecargs.NewColOperatorArgs{
+			Spec: &execinfrapb.ProcessorSpec{
+				Input: []execinfrapb.InputSyncSpec{{ColumnTypes: typs}},
+				Core: execinfrapb.ProcessorCoreUnion{
+					// Distinct with unique nulls is not supported natively, so
+					// the processor will be wrapped.
+					Distinct: &execinfrapb.DistinctSpec{DistinctColumns: []uint32{0}, NullsAreDistinct: true},
+				},
+				ResultTypes: typs,
+				ProcessorID: 1,
+			},
+			Inputs:              []colexecop.Operator{input},
+			StreamingMemAccount: &streamingMemAcc,
+			ProcessorConstructor: func(
+				_ context.Context,
+				_ *execinfra.FlowCtx,
+				_ int32,
+				_ *execinfrapb.ProcessorCoreUnion,
+				_ *execinfrapb.PostProcessSpec,
+				inputs []execinfra.RowSource,
+				_ []execinfra.RowReceiver,
+				_ []execinfra.LocalProcessor,
+			) (execinfra.Processor, error) {
+				wrappedInputs = inputs
+				// We're only interested in the inputs to the wrapped processor,
+				// so we don't bother creating it.
+				return nil, errWrapped
+			},
+		}
+		_, err = NewColOperator(ctx, flowCtx, args)
+		require.True(t, errors.Is(err, errWrapped))
+		require.Len(t, wrappedInputs, 1, tc.name)
+		if tc.elided {
+			require.Equal(t, execinfra.RowSource(source), wrappedInputs[0], tc.name)
+		} else {
+			require.IsType(t, &colexec.Materializer{}, wrappedInputs[0], tc.name)
+		}
+	}
+}
+
+// TestHashJoinerRuntimeFilter verifies that NewColOperator plans the runtime
+// filter on the probe side of the hash joins that support it (unless the
+// filter is disabled by the cluster setting), and that the results of the
+// joins are not affected by the filter.
+func TestHashJoinerRuntimeFilter(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	const numLeftRows = 100
+	typs := []*types.T{types.Int}
+	// Only two of the left rows have a match on the right side.
+	rightRows := rowenc.EncDatumRows{
+		{rowenc.IntEncDatum(1)}, {rowenc.IntEncDatum(3)}, {rowenc.IntEncDatum(numLeftRows)},
+	}
+	for _, tc := range []struct {
+		name     string
+		joinType descpb.JoinType
+		disabled bool
+		// expectedRows is the number of the output rows.
+		expectedRows int
+		// expectedFilter is whether the runtime filter should be planned.
+		expectedFilter bool
+	}{
+		{
+			name:           "inner",
+			joinType:       descpb.InnerJoin,
+			expectedRows:   2,
+			expectedFilter: true,
+		},
+		{
+			name:         "inner-disabled",
+			joinType:     descpb.InnerJoin,
+			disabled:     true,
+			expectedRows: 2,
+		},
+		{
+			// LEFT OUTER join emits the unmatched probe tuples, so the filter
+			// cannot be used.
+			name:         "left-outer",
+			joinType:     descpb.LeftOuterJoin,
+			expectedRows: numLeftRows,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			st := cluster.MakeTestingClusterSettings()
+			colexecjoin.RuntimeFilterEnabled.Override(&st.SV, !tc.disabled)
+			evalCtx := tree.MakeTestingEvalContext(st)
+			defer evalCtx.Stop(ctx)
+			flowCtx := &execinfra.FlowCtx{
+				EvalCtx: &evalCtx,
+				Cfg: &execinfra.ServerConfig{
+					Settings: st,
+				},
+			}
+			streamingMemAcc := evalCtx.Mon.MakeBoundAccount()
+			defer streamingMemAcc.Close(ctx)
+			allocator := colmem.NewAllocator(ctx, &streamingMemAcc, coldata.StandardColumnFactory)
+
+			var inputs []colexecop.Operator
+			for _, rows := range []rowenc.EncDatumRows{rowenc.MakeIntRows(numLeftRows, len(typs)), rightRows} {
+				c, err := colexec.NewBufferingColumnarizer(
+					ctx, allocator, flowCtx, 0 /* processorID */, execinfra.NewRepeatableRowSource(typs, rows),
+				)
+				require.NoError(t, err)
+				inputs = append(inputs, c)
+			}
+			args := &colexecargs.NewColOperatorArgs{
+				Spec: &execinfrapb.ProcessorSpec{
+					Input: []execinfrapb.InputSyncSpec{{ColumnTypes: typs}, {ColumnTypes: typs}},
+					Core: execinfrapb.ProcessorCoreUnion{
+						HashJoiner: &execinfrapb.HashJoinerSpec{
+							LeftEqColumns:  []uint32{0},
+							RightEqColumns: []uint32{0},
+							Type:           tc.joinType,
+						},
+					},
+					ResultTypes: []*types.T{types.Int, types.Int},
+					ProcessorID: 1,
+				},
+				Inputs:              inputs,
+				StreamingMemAccount: &streamingMemAcc,
+			}
+			args.TestingKnobs.DiskSpillingDisabled = true
+			r, err := NewColOperator(ctx, flowCtx, args)
+			require.NoError(t, err)
+			defer func() {
+				for _, acc := range r.OpAccounts {
+					acc.Close(ctx)
+				}
+				for _, m := range r.OpMonitors {
+					m.Stop(ctx)
+				}
+			}()
+			require.Equal(t, tc.expectedFilter, hasRuntimeFilter(r.Op))
+
+			m, err := colexec.NewMaterializer(
+				flowCtx,
+				1, /* processorID */
+				r.Op,
+				args.Spec.ResultTypes,
+			)
+			require.NoError(t, err)
+			m.Start(ctx)
+			var numRows int
+			for {
+				row, meta := m.Next()
+				require.Nil(t, meta)
+				if row == nil {
+					break
+				}
+				if tc.joinType == descpb.InnerJoin {
+					require.Equal(t, row[0].String(typs[0]), row[1].String(typs[0]))
+				}
+				numRows++
+			}
+			require.Equal(t, tc.expectedRows, numRows)
+		})
+	}
+}
+
+// hasRuntimeFilter returns whether the runtime filter of a hash joiner is
+// planned in the tree of operators rooted at op.
+func hasRuntimeFilter(op execinfra.OpNode) bool {
+	if fmt.Sprintf("%T", op) == "*colexecjoin.runtimeFilterOp" {
+		return true
+	}
+	for i := 0; i < op.ChildCount(true /* verbose */); i++ {
+		if hasRuntimeFilter(op.Child(i, true /* verbose */)) {
+			return true
+		}
+	}
+	return false
+}
@@ -141,6 +141,14 @@ func (o *orderedAggregateFuncBase) Reset() {
 	o.isFirstGroup = true
 }
 
+// hasNullsInRange returns whether there are any nulls among the first n tuples.
+// Even if MaybeHasNulls is true, there might be no nulls among the aggregated
+// tuples, so the ordered aggregate functions use this helper in order to
+// choose the faster loop without the null checks when possible.
+func hasNullsInRange(nulls *coldata.Nulls, n int) bool {
+	return nulls.MaybeHasNulls() && nulls.NextNull(0, n) < n
+}
+
 type hashAggregateFuncBase struct {
 	allocator *colmem.Allocator
 	// vec is the output vector of this function.
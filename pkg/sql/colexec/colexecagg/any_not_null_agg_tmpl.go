@@ -130,7 +130,7 @@ func (a *anyNotNull_TYPE_AGGKINDAgg) Compute(
 		if sel == nil {
 			_ = groups[inputLen-1]
 			_ = col.Get(inputLen - 1)
-			if nulls.MaybeHasNulls() {
+			if hasNullsInRange(nulls, inputLen) {
 				for i := 0; i < inputLen; i++ {
 					_FIND_ANY_NOT_NULL(a, groups, nulls, i, true, false)
 				}
@@ -98,7 +98,7 @@ func (a *bool_OP_TYPE_AGGKINDAgg) Compute(
 		if sel == nil {
 			_ = groups[inputLen-1]
 			_ = col.Get(inputLen - 1)
-			if nulls.MaybeHasNulls() {
+			if hasNullsInRange(nulls, inputLen) {
 				for i := 0; i < inputLen; i++ {
 					_ACCUMULATE_BOOLEAN(a, nulls, i, true, false)
 				}
@@ -76,7 +76,7 @@ func (a *concat_AGGKINDAgg) Compute(
 		// */}}
 		if sel == nil {
 			_ = groups[inputLen-1]
-			if nulls.MaybeHasNulls() {
+			if hasNullsInRange(nulls, inputLen) {
 				for i := 0; i < inputLen; i++ {
 					_ACCUMULATE_CONCAT(a, nulls, i, true, false)
 				}
@@ -83,7 +83,7 @@ func (a *count_COUNTKIND_AGGKINDAgg) Compute(
 		if sel == nil {
 			_ = groups[inputLen-1]
 			// {{if not (eq .CountKind "Rows")}}
-			if nulls.MaybeHasNulls() {
+			if hasNullsInRange(nulls, inputLen) {
 				for i := 0; i < inputLen; i++ {
 					_ACCUMULATE_COUNT(a, nulls, i, true, false)
 				}
@@ -174,7 +174,7 @@ func (a *_AGG_TYPE_AGGKINDAgg) Compute(
 		if sel == nil {
 			_ = groups[inputLen-1]
 			_ = col.Get(inputLen - 1)
-			if nulls.MaybeHasNulls() {
+			if hasNullsInRange(nulls, inputLen) {
 				for i := 0; i < inputLen; i++ {
 					_ACCUMULATE_MINMAX(a, nulls, i, true, false)
 				}
@@ -149,7 +149,7 @@ func (a *sum_SUMKIND_TYPE_AGGKINDAgg) Compute(
 		if sel == nil {
 			_ = groups[inputLen-1]
 			_ = col.Get(inputLen - 1)
-			if nulls.MaybeHasNulls() {
+			if hasNullsInRange(nulls, inputLen) {
 				for i := 0; i < inputLen; i++ {
 					_ACCUMULATE_SUM(a, nulls, i, true, false)
 				}
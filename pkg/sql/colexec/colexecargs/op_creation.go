@@ -17,6 +17,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/sql/colcontainer"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
@@ -48,7 +49,10 @@ type NewColOperatorArgs struct {
 	FDSemaphore     semaphore.Semaphore
 	ExprHelper      *ExprHelper
 	Factory         coldata.ColumnFactory
-	TestingKnobs    struct {
+	// WorkMemPool, if set, is used to redistribute the workmem budget between
+	// the buffering operators of the flow at runtime.
+	WorkMemPool  *colmem.WorkMemPool
+	TestingKnobs struct {
 		// SpillingCallbackFn will be called when the spilling from an in-memory
 		// to disk-backed operator occurs. It should only be set in tests.
 		SpillingCallbackFn func()
@@ -103,6 +107,27 @@ type NewColOperatorResult struct {
 	OpMonitors  []*mon.BytesMonitor
 	OpAccounts  []*mon.BoundAccount
 	Releasables []execinfra.Releasable
+	// WrappedProcessorsStats contains the execution statistics of the row
+	// execution processors that were wrapped into the vectorized flow. They
+	// are only collected if the statistics are collected for the flow, and
+	// the caller is responsible for reporting them.
+	WrappedProcessorsStats []*execinfra.DelegatedExecStats
+	// WrappedProcessors describes all row execution processors that were
+	// wrapped into the vectorized flow.
+	WrappedProcessors []WrappedProcessor
+}
+
+// WrappedProcessor describes a row execution processor that was wrapped into
+// the vectorized flow because the vectorized engine couldn't plan (a part of)
+// the processor spec natively.
+type WrappedProcessor struct {
+	// Core is the name of the core of the wrapped processor (for example,
+	// "JoinReader"). Note that the unsupported post-processing specs are
+	// planned by wrapping a "NoopCore" processor.
+	Core string
+	// Reason is the error that prompted the wrapping (for example, an
+	// unsupported expression or type).
+	Reason error
 }
 
 var _ execinfra.Releasable = &NewColOperatorResult{}
@@ -130,6 +155,9 @@ func (r *NewColOperatorResult) Release() {
 		OpMonitors:      r.OpMonitors[:0],
 		OpAccounts:      r.OpAccounts[:0],
 		Releasables:     r.Releasables[:0],
+
+		WrappedProcessorsStats: r.WrappedProcessorsStats[:0],
+		WrappedProcessors:      r.WrappedProcessors[:0],
 	}
 	newColOperatorResultPool.Put(r)
 }
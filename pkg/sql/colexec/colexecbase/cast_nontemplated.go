@@ -0,0 +1,284 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecbase
+
+import (
+	"context"
+	"math"
+
+	"github.com/cockroachdb/apd/v2"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/errors"
+)
+
+// getNonTemplatedCastOperator returns an operator that performs the cast for
+// which there is no execgen generated operator. These casts are
+// - from strings to timestamps and intervals
+// - from decimals to integers
+// - from any type to strings.
+// All of these casts need the eval context, so an error is returned if
+// evalCtx is nil.
+func getNonTemplatedCastOperator(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	colIdx int,
+	resultIdx int,
+	fromType *types.T,
+	toType *types.T,
+	evalCtx *tree.EvalContext,
+) (colexecop.Operator, error) {
+	if evalCtx == nil {
+		return nil, errors.Errorf("unhandled cast %s -> %s", fromType, toType)
+	}
+	if toType.Family() == types.StringFamily {
+		c := &castToStringOp{
+			OneInputCloserHelper: colexecop.MakeOneInputCloserHelper(input),
+			allocator:            allocator,
+			colIdx:               colIdx,
+			outputIdx:            resultIdx,
+			toType:               toType,
+			evalCtx:              evalCtx,
+			toDatumConverter:     colconv.NewVecToDatumConverter(colIdx+1 /* batchWidth */, []int{colIdx}),
+		}
+		// The converted datums are only used to perform the cast, so they
+		// don't need to outlive the input batch.
+		c.toDatumConverter.SetAliasVecs(true)
+		return c, nil
+	}
+	var castRow func(inputVec, outputVec coldata.Vec, i int)
+	switch fromType.Family() {
+	case types.StringFamily:
+		switch toType.Family() {
+		case types.TimestampFamily:
+			roundTo := tree.TimeFamilyPrecisionToRoundDuration(toType.Precision())
+			castRow = func(inputVec, outputVec coldata.Vec, i int) {
+				res, _, err := tree.ParseDTimestamp(evalCtx, string(inputVec.Bytes().Get(i)), roundTo)
+				if err != nil {
+					colexecerror.ExpectedError(err)
+				}
+				outputVec.Timestamp()[i] = res.Time
+			}
+		case types.TimestampTZFamily:
+			roundTo := tree.TimeFamilyPrecisionToRoundDuration(toType.Precision())
+			castRow = func(inputVec, outputVec coldata.Vec, i int) {
+				res, _, err := tree.ParseDTimestampTZ(evalCtx, string(inputVec.Bytes().Get(i)), roundTo)
+				if err != nil {
+					colexecerror.ExpectedError(err)
+				}
+				outputVec.Timestamp()[i] = res.Time
+			}
+		case types.IntervalFamily:
+			itm, err := toType.IntervalTypeMetadata()
+			if err != nil {
+				return nil, err
+			}
+			castRow = func(inputVec, outputVec coldata.Vec, i int) {
+				res, err := tree.ParseDIntervalWithTypeMetadata(string(inputVec.Bytes().Get(i)), itm)
+				if err != nil {
+					colexecerror.ExpectedError(err)
+				}
+				outputVec.Interval()[i] = res.Duration
+			}
+		}
+	case types.DecimalFamily:
+		if toType.Family() == types.IntFamily {
+			castRow = makeDecimalToIntCastRowFn(toType)
+		}
+	}
+	if castRow == nil {
+		return nil, errors.Errorf("unhandled cast %s -> %s", fromType, toType)
+	}
+	return &castRowOp{
+		OneInputCloserHelper: colexecop.MakeOneInputCloserHelper(input),
+		allocator:            allocator,
+		colIdx:               colIdx,
+		outputIdx:            resultIdx,
+		castRow:              castRow,
+	}, nil
+}
+
+// makeDecimalToIntCastRowFn returns a function that casts a decimal to an
+// integer of toType. The decimal is rounded to the nearest integer (with ties
+// rounded away from zero) the same way as the row engine does it.
+func makeDecimalToIntCastRowFn(toType *types.T) func(inputVec, outputVec coldata.Vec, i int) {
+	var tmpDec apd.Decimal
+	toInt := func(d *apd.Decimal) int64 {
+		if _, err := tree.DecimalCtx.RoundToIntegralValue(&tmpDec, d); err != nil {
+			colexecerror.ExpectedError(err)
+		}
+		v, err := tmpDec.Int64()
+		if err != nil {
+			colexecerror.ExpectedError(tree.ErrIntOutOfRange)
+		}
+		return v
+	}
+	outOfRangeErr := pgerror.Newf(pgcode.NumericValueOutOfRange,
+		"integer out of range for type %s", toType.Name(),
+	)
+	switch toType.Width() {
+	case 16:
+		return func(inputVec, outputVec coldata.Vec, i int) {
+			v := toInt(&inputVec.Decimal()[i])
+			if v < math.MinInt16 || v > math.MaxInt16 {
+				colexecerror.ExpectedError(outOfRangeErr)
+			}
+			outputVec.Int16()[i] = int16(v)
+		}
+	case 32:
+		return func(inputVec, outputVec coldata.Vec, i int) {
+			v := toInt(&inputVec.Decimal()[i])
+			if v < math.MinInt32 || v > math.MaxInt32 {
+				colexecerror.ExpectedError(outOfRangeErr)
+			}
+			outputVec.Int32()[i] = int32(v)
+		}
+	default:
+		return func(inputVec, outputVec coldata.Vec, i int) {
+			outputVec.Int64()[i] = toInt(&inputVec.Decimal()[i])
+		}
+	}
+}
+
+// castRowOp is a cast operator that performs the cast of each non-NULL value
+// using castRow function.
+type castRowOp struct {
+	colexecop.OneInputCloserHelper
+
+	allocator *colmem.Allocator
+	colIdx    int
+	outputIdx int
+	// castRow casts the value at position i of inputVec and sets the result
+	// at position i of outputVec. It is only called on non-NULL values.
+	castRow func(inputVec, outputVec coldata.Vec, i int)
+}
+
+var _ colexecop.ClosableOperator = &castRowOp{}
+
+func (c *castRowOp) Init() {
+	c.Input.Init()
+}
+
+func (c *castRowOp) Next(ctx context.Context) coldata.Batch {
+	batch := c.Input.Next(ctx)
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	inputVec := batch.ColVec(c.colIdx)
+	outputVec := batch.ColVec(c.outputIdx)
+	c.allocator.PerformOperation(
+		[]coldata.Vec{outputVec}, func() {
+			outputNulls := outputVec.Nulls()
+			hasNulls := inputVec.MaybeHasNulls()
+			inputNulls := inputVec.Nulls()
+			if hasNulls {
+				outputNulls.Copy(inputNulls)
+			} else {
+				// We need to make sure that there are no left over null values
+				// in the output vector.
+				outputNulls.UnsetNulls()
+			}
+			if sel != nil {
+				for _, i := range sel[:n] {
+					if !hasNulls || !inputNulls.NullAt(i) {
+						c.castRow(inputVec, outputVec, i)
+					}
+				}
+			} else {
+				for i := 0; i < n; i++ {
+					if !hasNulls || !inputNulls.NullAt(i) {
+						c.castRow(inputVec, outputVec, i)
+					}
+				}
+			}
+		},
+	)
+	return batch
+}
+
+// castToStringOp is a cast operator that casts values of any type to a string
+// type. It converts the values to datums in order to use the same formatting
+// as the row engine.
+type castToStringOp struct {
+	colexecop.OneInputCloserHelper
+
+	allocator        *colmem.Allocator
+	colIdx           int
+	outputIdx        int
+	toType           *types.T
+	evalCtx          *tree.EvalContext
+	toDatumConverter *colconv.VecToDatumConverter
+}
+
+var _ colexecop.ClosableOperator = &castToStringOp{}
+
+func (c *castToStringOp) Init() {
+	c.Input.Init()
+}
+
+func (c *castToStringOp) Next(ctx context.Context) coldata.Batch {
+	batch := c.Input.Next(ctx)
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	outputVec := batch.ColVec(c.outputIdx)
+	c.allocator.PerformOperation([]coldata.Vec{outputVec}, func() {
+		outputCol := outputVec.Bytes()
+		outputNulls := outputVec.Nulls()
+		if outputVec.MaybeHasNulls() {
+			// We need to make sure that there are no left over null values in
+			// the output vector.
+			outputNulls.UnsetNulls()
+		}
+		c.toDatumConverter.ConvertBatchAndDeselect(batch)
+		converted := c.toDatumConverter.GetDatumColumn(c.colIdx)
+		_ = converted[n-1]
+		for i := 0; i < n; i++ {
+			// Note that we performed a conversion with deselection, so there
+			// is no need to check whether sel is non-nil.
+			//gcassert:bce
+			d := converted[i]
+			rowIdx := i
+			if sel != nil {
+				rowIdx = sel[i]
+			}
+			if d == tree.DNull {
+				outputNulls.SetNull(rowIdx)
+				continue
+			}
+			res, err := tree.PerformCast(c.evalCtx, d, c.toType)
+			if err != nil {
+				colexecerror.ExpectedError(err)
+			}
+			s, ok := tree.AsDString(res)
+			if !ok {
+				colexecerror.InternalError(errors.AssertionFailedf("unexpected result of cast to %s: %v", c.toType, res))
+			}
+			outputCol.Set(rowIdx, encoding.UnsafeConvertStringToBytes(string(s)))
+		}
+	})
+	// Although we didn't change the length of the batch, it is necessary to set
+	// the length anyway (this helps maintaining the invariant of flat bytes).
+	batch.SetLength(n)
+	return batch
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/col/coldatatestutils"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
@@ -57,6 +58,18 @@ func TestRandomizedCast(t *testing.T) {
 	datumAsDecimal := func(d tree.Datum) interface{} {
 		return tree.MustBeDDecimal(d).Decimal
 	}
+	datumAsString := func(d tree.Datum) interface{} {
+		return string(tree.MustBeDString(d))
+	}
+	datumAsTimestamp := func(d tree.Datum) interface{} {
+		return tree.MustBeDTimestamp(d).Time
+	}
+	datumAsTimestampTZ := func(d tree.Datum) interface{} {
+		return tree.MustBeDTimestampTZ(d).Time
+	}
+	datumAsInterval := func(d tree.Datum) interface{} {
+		return tree.MustBeDInterval(d).Duration
+	}
 	datumAsColdataextDatum := func(datumVec coldata.DatumVec, d tree.Datum) interface{} {
 		datumVec.Set(0, d)
 		return datumVec.Get(0)
@@ -81,6 +94,22 @@ func TestRandomizedCast(t *testing.T) {
 		return res
 	}
 
+	// getStringsThatCanBeCastAs returns a function that generates a string
+	// representation of a random datum of typ which can be cast back to typ.
+	getStringsThatCanBeCastAs := func(typ *types.T) func() []tree.Datum {
+		return func() []tree.Datum {
+			for {
+				s, err := tree.PerformCast(&evalCtx, rowenc.RandDatum(rng, typ, false /* nullOk */), types.String)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err = tree.PerformCast(&evalCtx, s, typ); err == nil {
+					return []tree.Datum{s}
+				}
+			}
+		}
+	}
+
 	tc := []struct {
 		fromTyp      *types.T
 		fromPhysType func(tree.Datum) interface{}
@@ -102,16 +131,32 @@ func TestRandomizedCast(t *testing.T) {
 		{fromTyp: types.Bool, fromPhysType: datumAsBool, toTyp: types.Float, toPhysType: datumAsFloat},
 		// decimal -> t tests
 		{fromTyp: types.Decimal, fromPhysType: datumAsDecimal, toTyp: types.Bool, toPhysType: datumAsBool},
+		// We can sometimes generate a decimal outside of the range of the
+		// integers, so we want to retry with generation if that occurs.
+		{fromTyp: types.Decimal, fromPhysType: datumAsDecimal, toTyp: types.Int, toPhysType: datumAsInt, retryGeneration: true},
+		{fromTyp: types.Decimal, fromPhysType: datumAsDecimal, toTyp: types.Int2, toPhysType: datumAsInt, retryGeneration: true},
+		{fromTyp: types.Decimal, fromPhysType: datumAsDecimal, toTyp: types.String, toPhysType: datumAsString},
 		// int -> t tests
 		{fromTyp: types.Int, fromPhysType: datumAsInt, toTyp: types.Bool, toPhysType: datumAsBool},
 		{fromTyp: types.Int, fromPhysType: datumAsInt, toTyp: types.Float, toPhysType: datumAsFloat},
 		{fromTyp: types.Int, fromPhysType: datumAsInt, toTyp: types.Decimal, toPhysType: datumAsDecimal},
+		{fromTyp: types.Int, fromPhysType: datumAsInt, toTyp: types.String, toPhysType: datumAsString},
 		// float -> t tests
 		{fromTyp: types.Float, fromPhysType: datumAsFloat, toTyp: types.Bool, toPhysType: datumAsBool},
 		// We can sometimes generate a float outside of the range of the integers,
 		// so we want to retry with generation if that occurs.
 		{fromTyp: types.Float, fromPhysType: datumAsFloat, toTyp: types.Int, toPhysType: datumAsInt, retryGeneration: true},
 		{fromTyp: types.Float, fromPhysType: datumAsFloat, toTyp: types.Decimal, toPhysType: datumAsDecimal},
+		{fromTyp: types.Float, fromPhysType: datumAsFloat, toTyp: types.String, toPhysType: datumAsString},
+		// string -> t tests
+		{fromTyp: types.String, fromPhysType: datumAsString, toTyp: types.Timestamp, toPhysType: datumAsTimestamp, getValidSet: getStringsThatCanBeCastAs(types.Timestamp)},
+		{fromTyp: types.String, fromPhysType: datumAsString, toTyp: types.TimestampTZ, toPhysType: datumAsTimestampTZ, getValidSet: getStringsThatCanBeCastAs(types.TimestampTZ)},
+		{fromTyp: types.String, fromPhysType: datumAsString, toTyp: types.Interval, toPhysType: datumAsInterval, getValidSet: getStringsThatCanBeCastAs(types.Interval)},
+		{fromTyp: types.String, fromPhysType: datumAsString, toTyp: types.MakeVarChar(3), toPhysType: datumAsString},
+		// timestamp and interval -> t tests
+		{fromTyp: types.Timestamp, fromPhysType: datumAsTimestamp, toTyp: types.String, toPhysType: datumAsString},
+		{fromTyp: types.TimestampTZ, fromPhysType: datumAsTimestampTZ, toTyp: types.String, toPhysType: datumAsString},
+		{fromTyp: types.Interval, fromPhysType: datumAsInterval, toTyp: types.String, toPhysType: datumAsString},
 		// datum-backed type -> t tests
 		{fromTyp: collatedStringType, fromPhysType: makeDatumVecAdapter(collatedStringVec), toTyp: types.Bool, toPhysType: datumAsBool, getValidSet: getCollatedStringsThatCanBeCastAsBools},
 	}
@@ -157,6 +202,36 @@ func TestRandomizedCast(t *testing.T) {
 	}
 }
 
+// TestNonTemplatedCastsArePlanned verifies that the cast operators that aren't
+// generated by execgen are planned, i.e. that we don't fall back to the row
+// engine for them.
+func TestNonTemplatedCastsArePlanned(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	evalCtx := tree.MakeTestingEvalContext(cluster.MakeTestingClusterSettings())
+	defer evalCtx.Stop(context.Background())
+	for _, typePair := range [][]*types.T{
+		{types.String, types.Timestamp},
+		{types.String, types.TimestampTZ},
+		{types.String, types.Interval},
+		{types.Decimal, types.Int},
+		{types.Decimal, types.Int4},
+		{types.Int, types.String},
+		{types.Timestamp, types.String},
+		{types.Interval, types.String},
+		{types.Jsonb, types.String},
+	} {
+		typs := []*types.T{typePair[0]}
+		source := colexecop.NewRepeatableBatchSource(
+			testAllocator, testAllocator.NewMemBatchWithFixedCapacity(typs, 1 /* capacity */), typs,
+		)
+		_, err := colexecbase.GetCastOperator(
+			testAllocator, source, 0 /* colIdx */, 1 /* resultIdx */, typePair[0], typePair[1], &evalCtx,
+		)
+		require.NoError(t, err, "%s -> %s", typePair[0], typePair[1])
+	}
+}
+
 func BenchmarkCastOp(b *testing.B) {
 	defer log.Scope(b).Close(b)
 	ctx := context.Background()
@@ -79,6 +79,7 @@ func GetCastOperator(
 	resultIdx int,
 	fromType *types.T,
 	toType *types.T,
+	evalCtx *tree.EvalContext,
 ) (colexecop.Operator, error) {
 	input = colexecutils.NewVectorTypeEnforcer(allocator, input, toType, resultIdx)
 	if fromType.Family() == types.UnknownFamily {
@@ -117,7 +118,7 @@ func GetCastOperator(
 		}
 		// {{end}}
 	}
-	return nil, errors.Errorf("unhandled cast %s -> %s", fromType, toType)
+	return getNonTemplatedCastOperator(allocator, input, colIdx, resultIdx, fromType, toType, evalCtx)
 }
 
 type castOpNullAny struct {
@@ -24,8 +24,10 @@ type simpleProjectOp struct {
 	colexecop.OneInputCloserHelper
 	colexecop.NonExplainable
 
-	projection []uint32
-	batches    map[coldata.Batch]*projectingBatch
+	// numInputCols is the number of columns of the input batches.
+	numInputCols int
+	projection   []uint32
+	batches      map[coldata.Batch]*projectingBatch
 	// numBatchesLoggingThreshold is the threshold on the number of items in
 	// 'batches' map at which we will log a message when a new projectingBatch
 	// is created. It is growing exponentially.
@@ -107,6 +109,7 @@ func NewSimpleProjectOp(
 	}
 	s := &simpleProjectOp{
 		OneInputCloserHelper:       colexecop.MakeOneInputCloserHelper(input),
+		numInputCols:               numInputCols,
 		projection:                 make([]uint32, len(projection)),
 		batches:                    make(map[coldata.Batch]*projectingBatch),
 		numBatchesLoggingThreshold: 128,
@@ -116,6 +119,19 @@ func NewSimpleProjectOp(
 	return s
 }
 
+// UnwrapSimpleProjectOp returns the input of op, the number of columns of the
+// input, and the ordinals of the input columns that op projects if op is a
+// simple projection operator. ok is false otherwise.
+func UnwrapSimpleProjectOp(
+	op colexecop.Operator,
+) (input colexecop.Operator, numInputCols int, projection []uint32, ok bool) {
+	s, ok := op.(*simpleProjectOp)
+	if !ok {
+		return nil, 0, nil, false
+	}
+	return s.Input, s.numInputCols, s.projection, true
+}
+
 func (d *simpleProjectOp) Init() {
 	d.Input.Init()
 }
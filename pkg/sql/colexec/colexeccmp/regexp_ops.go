@@ -0,0 +1,108 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexeccmp
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+)
+
+// IsRegexpCmpOp returns whether op matches a string against a pattern that is
+// evaluated as a regular expression, namely whether op is one of LIKE, ILIKE,
+// SIMILAR TO, ~, ~* or one of their negations.
+func IsRegexpCmpOp(op tree.ComparisonOperator) bool {
+	switch op {
+	case tree.Like, tree.NotLike, tree.ILike, tree.NotILike,
+		tree.SimilarTo, tree.NotSimilarTo,
+		tree.RegMatch, tree.NotRegMatch, tree.RegIMatch, tree.NotRegIMatch:
+		return true
+	}
+	return false
+}
+
+// CompileRegexpCmpOpPattern compiles pattern as the regular expression
+// equivalent to the pattern of op, which must be such that IsRegexpCmpOp
+// returns true. It also returns whether the result of matching against the
+// regular expression must be negated. The compiled regular expressions are
+// cached in the regexp cache of evalCtx.
+func CompileRegexpCmpOpPattern(
+	evalCtx *tree.EvalContext, op tree.ComparisonOperator, pattern string,
+) (_ *regexp.Regexp, negate bool, _ error) {
+	op, _, _, _, negate = tree.FoldComparisonExpr(op, nil /* left */, nil /* right */)
+	var re *regexp.Regexp
+	var err error
+	switch op {
+	case tree.Like, tree.ILike:
+		re, err = tree.ConvertLikeToRegexp(evalCtx, pattern, op == tree.ILike, '\\')
+	case tree.SimilarTo:
+		re, err = tree.ConvertSimilarToRegexp(evalCtx, pattern)
+	case tree.RegMatch, tree.RegIMatch:
+		re, err = tree.CompileRegexp(evalCtx, pattern, op == tree.RegIMatch)
+	default:
+		return nil, false, errors.AssertionFailedf("unexpected regexp comparison operator %s", op)
+	}
+	return re, negate, err
+}
+
+// RegexpMatcher evaluates a comparison operator for which IsRegexpCmpOp
+// returns true when the pattern is not constant. The compiled patterns are
+// looked up in the regexp cache of the eval context, so they are shared across
+// batches, and the last used pattern is remembered to avoid the lookup when
+// consecutive rows use the same pattern, which is the common case.
+type RegexpMatcher struct {
+	evalCtx *tree.EvalContext
+	op      tree.ComparisonOperator
+	// isLike indicates whether op is one of the LIKE variants which need a
+	// special handling of empty strings.
+	isLike bool
+
+	lastPattern []byte
+	lastRe      *regexp.Regexp
+	lastNegate  bool
+}
+
+// MakeRegexpMatcher returns a RegexpMatcher for op.
+func MakeRegexpMatcher(evalCtx *tree.EvalContext, op tree.ComparisonOperator) RegexpMatcher {
+	return RegexpMatcher{
+		evalCtx: evalCtx,
+		op:      op,
+		isLike:  op == tree.Like || op == tree.NotLike || op == tree.ILike || op == tree.NotILike,
+	}
+}
+
+// Match returns the result of matching s against pattern. Both arguments must
+// be non-NULL.
+func (m *RegexpMatcher) Match(s, pattern []byte) (bool, error) {
+	if m.isLike && len(s) == 0 {
+		// An empty string only matches with an empty pattern or a pattern
+		// consisting only of '%'. To match PostgreSQL's behavior (and the row
+		// engine), we have a special handling of this case.
+		negate := m.op == tree.NotLike || m.op == tree.NotILike
+		for _, c := range pattern {
+			if c != '%' {
+				return negate, nil
+			}
+		}
+		return !negate, nil
+	}
+	if m.lastRe == nil || !bytes.Equal(pattern, m.lastPattern) {
+		re, negate, err := CompileRegexpCmpOpPattern(m.evalCtx, m.op, string(pattern))
+		if err != nil {
+			return false, err
+		}
+		m.lastPattern = append(m.lastPattern[:0], pattern...)
+		m.lastRe, m.lastNegate = re, negate
+	}
+	return m.lastRe.Match(s) != m.lastNegate, nil
+}
@@ -0,0 +1,168 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexechash
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execgen"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+)
+
+const (
+	// runtimeFilterBitsPerKey is the number of bits of the bloom filter per
+	// key in the hash table. Together with runtimeFilterNumProbes, it gives a
+	// false positive rate of about 3%.
+	runtimeFilterBitsPerKey = 8
+	// runtimeFilterNumProbes is the number of bits that are set in the bloom
+	// filter for every key.
+	runtimeFilterNumProbes = 3
+	// runtimeFilterMaxBits limits the size of the bloom filter to 8MiB. The
+	// false positive rate of the filter degrades when the hash table has more
+	// than runtimeFilterMaxBits/runtimeFilterBitsPerKey keys, but the filter
+	// stays correct.
+	runtimeFilterMaxBits = 1 << 26
+)
+
+// RuntimeFilter is a bloom filter over the equality columns of the tuples
+// stored in a HashTable. It is used to discard the tuples on the probe side of
+// a hash join that definitely don't have a match in the hash table before they
+// reach the hash joiner. The filter can have false positives but never has
+// false negatives.
+type RuntimeFilter struct {
+	// bits is the bit array of the bloom filter. The number of bits is a power
+	// of two.
+	bits []uint64
+	// mask is the number of bits minus one.
+	mask uint64
+
+	// keys and hashes are scratch space for hashing the equality columns.
+	keys   []coldata.Vec
+	hashes []uint64
+
+	overloadHelper execgen.OverloadHelper
+	datumAlloc     rowenc.DatumAlloc
+	cancelChecker  colexecutils.CancelChecker
+}
+
+// NewRuntimeFilter returns a RuntimeFilter that contains all the keys stored
+// in ht. The memory used by the filter is accounted for with allocator.
+func NewRuntimeFilter(
+	ctx context.Context, allocator *colmem.Allocator, ht *HashTable,
+) *RuntimeFilter {
+	numTuples := ht.Vals.Length()
+	numBits := uint64(64)
+	for numBits < uint64(numTuples)*runtimeFilterBitsPerKey && numBits < runtimeFilterMaxBits {
+		numBits *= 2
+	}
+	allocator.AdjustMemoryUsage(int64(numBits / 8))
+	f := &RuntimeFilter{
+		bits: make([]uint64, numBits/64),
+		mask: numBits - 1,
+		keys: make([]coldata.Vec, len(ht.keyCols)),
+	}
+	for start := 0; start < numTuples; start += coldata.BatchSize() {
+		end := start + coldata.BatchSize()
+		if end > numTuples {
+			end = numTuples
+		}
+		for i, keyCol := range ht.keyCols {
+			f.keys[i] = ht.Vals.ColVec(int(keyCol)).Window(start, end)
+		}
+		hashes := f.computeHashes(ctx, f.keys, end-start, nil /* sel */)
+		for _, hash := range hashes {
+			f.insert(hash)
+		}
+	}
+	return f
+}
+
+// computeHashes computes the hash values of the first nKeys keys (according to
+// sel) and returns them. The returned slice is only valid until the next call.
+func (f *RuntimeFilter) computeHashes(
+	ctx context.Context, keys []coldata.Vec, nKeys int, sel []int,
+) []uint64 {
+	f.hashes = colexecutils.MaybeAllocateLimitedUint64Array(f.hashes, nKeys)
+	if nKeys == 0 {
+		return f.hashes
+	}
+	initHash(f.hashes, nKeys, DefaultInitHashValue)
+	for _, key := range keys {
+		rehash(ctx, f.hashes, key, nKeys, sel, f.cancelChecker, f.overloadHelper, &f.datumAlloc)
+	}
+	return f.hashes
+}
+
+// probes returns the positions of the first two bits for hash. The remaining
+// positions are derived from those with double hashing.
+func (f *RuntimeFilter) probes(hash uint64) (uint64, uint64) {
+	// The delta is made odd so that all probes are distinct.
+	return hash, (hash>>32 | hash<<32) | 1
+}
+
+func (f *RuntimeFilter) insert(hash uint64) {
+	pos, delta := f.probes(hash)
+	for i := 0; i < runtimeFilterNumProbes; i++ {
+		bit := pos & f.mask
+		f.bits[bit/64] |= 1 << (bit % 64)
+		pos += delta
+	}
+}
+
+func (f *RuntimeFilter) mayContain(hash uint64) bool {
+	pos, delta := f.probes(hash)
+	for i := 0; i < runtimeFilterNumProbes; i++ {
+		bit := pos & f.mask
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+		pos += delta
+	}
+	return true
+}
+
+// Filter updates the selection vector of batch to only include the tuples
+// whose equality columns (specified by eqCols) might be present in the hash
+// table the filter was built from.
+func (f *RuntimeFilter) Filter(ctx context.Context, batch coldata.Batch, eqCols []uint32) {
+	n := batch.Length()
+	if n == 0 {
+		return
+	}
+	vecs := batch.ColVecs()
+	for i, eqCol := range eqCols {
+		f.keys[i] = vecs[eqCol]
+	}
+	sel := batch.Selection()
+	hashes := f.computeHashes(ctx, f.keys, n, sel)
+	idx := 0
+	if sel != nil {
+		for i, hash := range hashes[:n] {
+			if f.mayContain(hash) {
+				sel[idx] = sel[i]
+				idx++
+			}
+		}
+	} else {
+		batch.SetSelection(true)
+		sel = batch.Selection()
+		for i, hash := range hashes[:n] {
+			if f.mayContain(hash) {
+				sel[idx] = i
+				idx++
+			}
+		}
+	}
+	batch.SetLength(idx)
+}
@@ -0,0 +1,106 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexechash
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+// TestRuntimeFilter verifies that the runtime filter never discards tuples
+// that are present in the hash table and that it discards most of the tuples
+// that are not.
+func TestRuntimeFilter(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	rng, _ := randutil.NewPseudoRand()
+	typs := []*types.T{types.Int, types.Bytes}
+	eqCols := []uint32{0, 1}
+	ht := NewHashTable(
+		testAllocator, 1.0 /* loadFactor */, 1, /* initialNumHashBuckets */
+		typs, eqCols, false /* allowNullEquality */, HashTableFullBuildMode, HashTableDefaultProbeMode,
+	)
+
+	// Populate the hash table with even keys only.
+	numBuildTuples := 1000 + rng.Intn(4000)
+	for added := 0; added < numBuildTuples; {
+		n := coldata.BatchSize()
+		if n > numBuildTuples-added {
+			n = numBuildTuples - added
+		}
+		batch := testAllocator.NewMemBatchWithFixedCapacity(typs, n)
+		for i := 0; i < n; i++ {
+			batch.ColVec(0).Int64()[i] = int64(2 * (added + i))
+			batch.ColVec(1).Bytes().Set(i, []byte{byte(added + i)})
+		}
+		batch.SetLength(n)
+		testAllocator.PerformOperation(ht.Vals.ColVecs(), func() {
+			ht.Vals.AppendTuples(batch, 0 /* startIdx */, n)
+		})
+		added += n
+	}
+	filter := NewRuntimeFilter(ctx, testAllocator, ht)
+
+	// Probe with all keys in [0, 2*numBuildTuples), using a selection vector
+	// for some of the batches.
+	falsePositives, numProbeTuples := 0, 0
+	for start := 0; start < 2*numBuildTuples; start += coldata.BatchSize() {
+		n := coldata.BatchSize()
+		if n > 2*numBuildTuples-start {
+			n = 2*numBuildTuples - start
+		}
+		batch := testAllocator.NewMemBatchWithFixedCapacity(typs, n)
+		for i := 0; i < n; i++ {
+			batch.ColVec(0).Int64()[i] = int64(start + i)
+			batch.ColVec(1).Bytes().Set(i, []byte{byte((start + i) / 2)})
+		}
+		batch.SetLength(n)
+		if rng.Intn(2) == 0 {
+			batch.SetSelection(true)
+			for i := 0; i < n; i++ {
+				batch.Selection()[i] = i
+			}
+		}
+		filter.Filter(ctx, batch, eqCols)
+
+		passed := make(map[int64]bool)
+		sel := batch.Selection()
+		for i := 0; i < batch.Length(); i++ {
+			passed[batch.ColVec(0).Int64()[sel[i]]] = true
+		}
+		for i := 0; i < n; i++ {
+			key := int64(start + i)
+			if key%2 == 0 {
+				if !passed[key] {
+					t.Fatalf("key %d is present in the hash table but was filtered out", key)
+				}
+			} else {
+				numProbeTuples++
+				if passed[key] {
+					falsePositives++
+				}
+			}
+		}
+	}
+	// The expected false positive rate is about 3%, so we allow for some
+	// variance.
+	if falsePositives*10 > numProbeTuples {
+		t.Fatalf("too many false positives: %d out of %d", falsePositives, numProbeTuples)
+	}
+}
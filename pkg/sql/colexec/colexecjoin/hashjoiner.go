@@ -69,6 +69,13 @@ type HashJoinerSpec struct {
 	// rightDistinct indicates whether or not the build table equality column
 	// tuples are distinct. If they are distinct, performance can be optimized.
 	rightDistinct bool
+
+	// leftStreaming indicates whether the output consists only of the probe
+	// tuples with each probe tuple emitted at most once in the order of the
+	// probe input (this is the case with LEFT SEMI and LEFT ANTI joins). If so,
+	// the probe batches are emitted directly with an updated selection vector
+	// instead of copying the matched tuples into the output batch.
+	leftStreaming bool
 }
 
 type hashJoinerSourceSpec struct {
@@ -210,8 +217,10 @@ type hashJoiner struct {
 		// buildRowMatched is used in the case that spec.trackBuildMatches is true. This
 		// means that an outer join is performed on the build side and buildRowMatched
 		// marks all the build table rows that have been matched already. The rows
-		// that were unmatched are emitted during the hjEmittingRight phase.
-		buildRowMatched []bool
+		// that were unmatched are emitted during the hjEmittingRight phase. The
+		// matches are recorded once per output batch in congregate (or once per
+		// probe batch in collectRightSemiAnti).
+		buildRowMatched matchedBitmap
 
 		// buckets is used to store the computed hash value of each key in a single
 		// probe batch.
@@ -335,13 +344,15 @@ func (hj *hashJoiner) build(ctx context.Context) {
 	}
 
 	if hj.spec.trackBuildMatches {
-		if cap(hj.probeState.buildRowMatched) < hj.ht.Vals.Length() {
-			hj.probeState.buildRowMatched = make([]bool, hj.ht.Vals.Length())
-		} else {
-			hj.probeState.buildRowMatched = hj.probeState.buildRowMatched[:hj.ht.Vals.Length()]
-			for n := 0; n < hj.ht.Vals.Length(); n += copy(hj.probeState.buildRowMatched[n:], colexecutils.ZeroBoolColumn) {
-			}
-		}
+		// The bitmap is accounted for by the build side allocator since its
+		// size is proportional to the number of build tuples.
+		hj.buildSideAllocator.AdjustMemoryUsage(hj.probeState.buildRowMatched.reset(hj.ht.Vals.Length()))
+	}
+
+	if f, ok := hj.inputOne.(*runtimeFilterOp); ok {
+		// The left input can use the keys in the hash table to discard the
+		// probe tuples that don't have a match.
+		f.setFilter(colexechash.NewRuntimeFilter(ctx, hj.buildSideAllocator, hj.ht))
 	}
 
 	hj.state = hjProbing
@@ -366,11 +377,14 @@ func (hj *hashJoiner) emitRight(matched bool) {
 	// Find the next batch of tuples that have the requested 'matched' value.
 	nResults := 0
 	for nResults < coldata.BatchSize() && hj.emittingRightState.rowIdx < hj.ht.Vals.Length() {
-		if hj.probeState.buildRowMatched[hj.emittingRightState.rowIdx] == matched {
-			hj.probeState.buildIdx[nResults] = hj.emittingRightState.rowIdx
-			nResults++
+		rowIdx := hj.probeState.buildRowMatched.next(hj.emittingRightState.rowIdx, matched)
+		if rowIdx == hj.ht.Vals.Length() {
+			hj.emittingRightState.rowIdx = rowIdx
+			break
 		}
-		hj.emittingRightState.rowIdx++
+		hj.probeState.buildIdx[nResults] = rowIdx
+		nResults++
+		hj.emittingRightState.rowIdx = rowIdx + 1
 	}
 	hj.resetOutput(nResults)
 
@@ -468,8 +482,7 @@ func (hj *hashJoiner) exec(ctx context.Context) coldata.Batch {
 		hj.prepareForCollecting(coldata.BatchSize())
 		nResults := hj.collect(batch, batchSize, sel)
 		if nResults > 0 {
-			hj.congregate(nResults, batch)
-			return hj.output
+			return hj.emitProbed(nResults, batch)
 		}
 		// There were no matches in that batch, so we move on to the next one.
 	}
@@ -563,10 +576,25 @@ func (hj *hashJoiner) exec(ctx context.Context) coldata.Batch {
 		}
 
 		if nResults > 0 {
-			hj.congregate(nResults, batch)
-			break
+			return hj.emitProbed(nResults, batch)
 		}
 	}
+}
+
+// emitProbed returns the output batch containing the nResults joined tuples
+// collected from the probe batch.
+func (hj *hashJoiner) emitProbed(nResults int, batch coldata.Batch) coldata.Batch {
+	if hj.spec.leftStreaming {
+		// All output columns come from the probe side and the collected probe
+		// indices are increasing, so we can simply narrow down the selection
+		// vector of the probe batch and emit it as is. Note that probeIdx
+		// already takes the original selection vector into account.
+		batch.SetSelection(true)
+		copy(batch.Selection()[:nResults], hj.probeState.probeIdx[:nResults])
+		batch.SetLength(nResults)
+		return batch
+	}
+	hj.congregate(nResults, batch)
 	return hj.output
 }
 
@@ -648,14 +676,14 @@ func (hj *hashJoiner) congregate(nResults int, batch coldata.Batch) {
 					if !probeRowUnmatched[i] {
 						//gcassert:bce
 						bIdx := buildIdx[i]
-						hj.probeState.buildRowMatched[bIdx] = true
+						hj.probeState.buildRowMatched.set(bIdx)
 					}
 				}
 			} else {
 				for i := 0; i < nResults; i++ {
 					//gcassert:bce
 					bIdx := buildIdx[i]
-					hj.probeState.buildRowMatched[bIdx] = true
+					hj.probeState.buildRowMatched.set(bIdx)
 				}
 			}
 		}
@@ -775,6 +803,7 @@ func MakeHashJoinerSpec(
 		descpb.RightSemiJoin, descpb.RightAntiJoin:
 		trackBuildMatches = true
 	}
+	leftStreaming := joinType == descpb.LeftSemiJoin || joinType == descpb.LeftAntiJoin
 
 	left := hashJoinerSourceSpec{
 		EqCols:      leftEqCols,
@@ -790,6 +819,7 @@ func MakeHashJoinerSpec(
 		Right:             right,
 		trackBuildMatches: trackBuildMatches,
 		rightDistinct:     rightDistinct,
+		leftStreaming:     leftStreaming,
 	}
 }
 
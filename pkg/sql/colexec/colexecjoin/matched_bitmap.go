@@ -0,0 +1,77 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecjoin
+
+import (
+	"math/bits"
+	"unsafe"
+)
+
+// matchedBitmap tracks which of the n tuples have had a match, using a single
+// bit per tuple. It is used by the hash joiner to track the build tuples that
+// got a match during the probing phase, and it takes eight times less memory
+// than a []bool of the same length. Additionally, the tuples with the
+// requested value can be found by looking at 64 tuples at once, which makes
+// emitting the unmatched build tuples of right/full outer joins cheap when
+// most of the tuples had a match.
+type matchedBitmap struct {
+	words []uint64
+	n     int
+}
+
+const sizeOfUint64 = int64(unsafe.Sizeof(uint64(0)))
+
+// reset resizes the bitmap to track n tuples and marks all of them as
+// unmatched. It returns the change in the memory footprint of the bitmap.
+func (m *matchedBitmap) reset(n int) (memDelta int64) {
+	numWords := (n + 63) / 64
+	if cap(m.words) < numWords {
+		memDelta = int64(numWords-cap(m.words)) * sizeOfUint64
+		m.words = make([]uint64, numWords)
+	} else {
+		m.words = m.words[:numWords]
+		for i := range m.words {
+			m.words[i] = 0
+		}
+	}
+	m.n = n
+	return memDelta
+}
+
+// set marks the i'th tuple as matched.
+func (m *matchedBitmap) set(i int) {
+	m.words[i>>6] |= 1 << uint(i&63)
+}
+
+// next returns the index of the first tuple starting from i that has been
+// marked as matched if matched is true or that hasn't been marked if matched
+// is false. n is returned if there is no such tuple.
+func (m *matchedBitmap) next(i int, matched bool) int {
+	for i < m.n {
+		word := m.words[i>>6]
+		if !matched {
+			word = ^word
+		}
+		// Ignore the tuples before i in the current word.
+		word &= ^uint64(0) << uint(i&63)
+		if word != 0 {
+			i = i&^63 + bits.TrailingZeros64(word)
+			if i >= m.n {
+				// The bits past the last tuple are never set, so they can
+				// only be found when looking for the unmatched tuples.
+				return m.n
+			}
+			return i
+		}
+		i = (i + 64) &^ 63
+	}
+	return m.n
+}
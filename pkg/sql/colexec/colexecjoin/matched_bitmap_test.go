@@ -0,0 +1,56 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecjoin
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchedBitmap(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	rng, _ := randutil.NewPseudoRand()
+	var m matchedBitmap
+	for run := 0; run < 10; run++ {
+		n := rng.Intn(1000)
+		memDelta := m.reset(n)
+		require.GreaterOrEqual(t, memDelta, int64(0))
+		// Use different densities of the matches to have both fully set and
+		// fully unset words.
+		matchProbability := rng.Float64()
+		expected := make([]bool, n)
+		for i := range expected {
+			if rng.Float64() < matchProbability {
+				expected[i] = true
+				m.set(i)
+			}
+		}
+		for _, matched := range []bool{false, true} {
+			var actual []int
+			for i := m.next(0, matched); i < n; i = m.next(i+1, matched) {
+				actual = append(actual, i)
+			}
+			var expectedIdxs []int
+			for i := range expected {
+				if expected[i] == matched {
+					expectedIdxs = append(expectedIdxs, i)
+				}
+			}
+			require.Equal(t, expectedIdxs, actual)
+		}
+	}
+}
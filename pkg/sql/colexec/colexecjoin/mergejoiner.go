@@ -271,9 +271,11 @@ func NewMergeJoinOp(
 			case types.FloatFamily:
 				castLeftToRight = rightType.Family() == types.DecimalFamily
 			}
+			// Note that the casts between numeric types don't need the eval
+			// context.
 			if castLeftToRight {
 				castColumnIdx := len(actualLeftTypes)
-				left, err = colexecbase.GetCastOperator(unlimitedAllocator, left, int(leftColIdx), castColumnIdx, leftType, rightType)
+				left, err = colexecbase.GetCastOperator(unlimitedAllocator, left, int(leftColIdx), castColumnIdx, leftType, rightType, nil /* evalCtx */)
 				if err != nil {
 					return nil, err
 				}
@@ -281,7 +283,7 @@ func NewMergeJoinOp(
 				actualLeftOrdering[i].ColIdx = uint32(castColumnIdx)
 			} else {
 				castColumnIdx := len(actualRightTypes)
-				right, err = colexecbase.GetCastOperator(unlimitedAllocator, right, int(rightColIdx), castColumnIdx, rightType, leftType)
+				right, err = colexecbase.GetCastOperator(unlimitedAllocator, right, int(rightColIdx), castColumnIdx, rightType, leftType, nil /* evalCtx */)
 				if err != nil {
 					return nil, err
 				}
@@ -0,0 +1,123 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecjoin
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexechash"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+)
+
+// RuntimeFilterEnabled is a cluster setting that allows to disable the runtime
+// filters that the hash joiner pushes into its probe side.
+var RuntimeFilterEnabled = settings.RegisterBoolSetting(
+	"sql.distsql.vectorize_runtime_filter.enabled",
+	"set to false to disable the bloom filters built from the build side of "+
+		"vectorized hash joins and applied to their probe side",
+	true,
+)
+
+const (
+	// runtimeFilterMinTuplesToDisable is the number of probe tuples that the
+	// runtime filter needs to see before it can decide whether it is
+	// selective enough.
+	runtimeFilterMinTuplesToDisable = 4096
+	// runtimeFilterMaxPassRatio is the fraction of the probe tuples passing
+	// the runtime filter above which the filter is disabled because it costs
+	// more than it saves.
+	runtimeFilterMaxPassRatio = 0.9
+)
+
+// RuntimeFilterSupported returns whether the runtime filter can be used on the
+// probe (left) side of a hash join with the given join type. This is the case
+// for all join types that never emit the probe tuples that don't have a match
+// on the build side.
+func RuntimeFilterSupported(joinType descpb.JoinType) bool {
+	switch joinType {
+	case descpb.InnerJoin, descpb.LeftSemiJoin, descpb.RightOuterJoin,
+		descpb.RightSemiJoin, descpb.RightAntiJoin, descpb.IntersectAllJoin:
+		return true
+	default:
+		return false
+	}
+}
+
+// runtimeFilterOp is an operator that discards the tuples from its input that
+// definitely don't have a match on the build side of a hash join. It is
+// planned as the left input of the hash joiner which sets the filter once the
+// hash table is built. Until then (and when the filter turns out not to be
+// selective) all batches are passed through unchanged.
+type runtimeFilterOp struct {
+	colexecop.OneInputNode
+
+	eqCols []uint32
+	filter *colexechash.RuntimeFilter
+	// disabled is set when the filter discards too few tuples to be useful.
+	disabled bool
+	// numSeen and numPassed track the selectivity of the filter.
+	numSeen, numPassed int
+}
+
+var _ colexecop.ResettableOperator = &runtimeFilterOp{}
+
+// NewRuntimeFilterOp returns an operator that is to be used as the left input
+// of a hash joiner in order to filter out the tuples that don't have a match
+// in the hash table. eqCols are the equality columns of the left side of the
+// join. The hash joiner must be of a type for which RuntimeFilterSupported
+// returns true.
+func NewRuntimeFilterOp(input colexecop.Operator, eqCols []uint32) colexecop.ResettableOperator {
+	return &runtimeFilterOp{
+		OneInputNode: colexecop.NewOneInputNode(input),
+		eqCols:       eqCols,
+	}
+}
+
+func (f *runtimeFilterOp) Init() {
+	f.Input.Init()
+}
+
+// setFilter sets the filter built from the hash table of the hash joiner.
+func (f *runtimeFilterOp) setFilter(filter *colexechash.RuntimeFilter) {
+	f.filter = filter
+}
+
+func (f *runtimeFilterOp) Next(ctx context.Context) coldata.Batch {
+	for {
+		batch := f.Input.Next(ctx)
+		n := batch.Length()
+		if f.filter == nil || f.disabled || n == 0 {
+			return batch
+		}
+		f.filter.Filter(ctx, batch, f.eqCols)
+		f.numSeen += n
+		f.numPassed += batch.Length()
+		if f.numSeen >= runtimeFilterMinTuplesToDisable &&
+			float64(f.numPassed) > runtimeFilterMaxPassRatio*float64(f.numSeen) {
+			f.disabled = true
+		}
+		if batch.Length() > 0 {
+			return batch
+		}
+	}
+}
+
+func (f *runtimeFilterOp) Reset(ctx context.Context) {
+	if r, ok := f.Input.(colexecop.Resetter); ok {
+		r.Reset(ctx)
+	}
+	f.filter = nil
+	f.disabled = false
+	f.numSeen, f.numPassed = 0, 0
+}
@@ -147,7 +147,13 @@ func _SET_PROJECTION(_HAS_NULLS bool) {
 	// {{$hasNulls := $.HasNulls}}
 	// {{with $.Overload}}
 	// {{if _HAS_NULLS}}
-	colNulls := vec.Nulls()
+	// Union the input nulls into _outNulls upfront. Since the selection vector
+	// is increasing, all selected tuples are within [startIdx, endIdx).
+	startIdx, endIdx := 0, n
+	if sel := batch.Selection(); sel != nil {
+		startIdx, endIdx = sel[0], sel[n-1]+1
+	}
+	_outNulls.OrRange(vec.Nulls(), startIdx, endIdx)
 	// {{end}}
 	if sel := batch.Selection(); sel != nil {
 		sel = sel[:n]
@@ -161,14 +167,6 @@ func _SET_PROJECTION(_HAS_NULLS bool) {
 			_SET_SINGLE_TUPLE_PROJECTION(_HAS_NULLS, false)
 		}
 	}
-	// _outNulls has been updated from within the _ASSIGN function to include
-	// any NULLs that resulted from the projection.
-	// If _HAS_NULLS is true, union _outNulls with the set of input Nulls.
-	// If _HAS_NULLS is false, then there are no input Nulls. _outNulls is
-	// projVec.Nulls() so there is no need to call projVec.SetNulls().
-	// {{if _HAS_NULLS}}
-	projVec.SetNulls(_outNulls.Or(colNulls))
-	// {{end}}
 	// {{end}}
 	// {{end}}
 	// {{/*
@@ -183,7 +181,7 @@ func _SET_SINGLE_TUPLE_PROJECTION(_HAS_NULLS bool, _HAS_SEL bool) { // */}}
 	// {{$hasSel := $.HasSel}}
 	// {{with $.Overload}}
 	// {{if _HAS_NULLS}}
-	if !colNulls.NullAt(i) {
+	if !_outNulls.NullAt(i) {
 		// We only want to perform the projection operation if the value is not null.
 		// {{end}}
 		// {{if _IS_CONST_LEFT}}
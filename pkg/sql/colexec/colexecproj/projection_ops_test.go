@@ -22,8 +22,10 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/colconv"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execgen"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
@@ -260,6 +262,81 @@ func TestRandomComparisons(t *testing.T) {
 	}
 }
 
+// TestRandomOverflowBoundaryArithmetic checks that the vectorized arithmetic
+// projection operators agree with the row engine on operands which are close
+// to overflow boundaries, both in the results and in the errors returned.
+// Every tuple is evaluated by a separate operator so that an error on one
+// tuple doesn't hide the results of the others.
+func TestRandomOverflowBoundaryArithmetic(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+	const numTuples = 64
+	rng, _ := randutil.NewPseudoRand()
+
+	var da rowenc.DatumAlloc
+	vecRes := make([]tree.Datum, 1)
+	for _, typ := range []*types.T{types.Int, types.Float, types.Decimal} {
+		typs := []*types.T{typ, typ, typ}
+		for _, binOp := range []tree.BinaryOperator{tree.Plus, tree.Minus, tree.Mult, tree.FloorDiv, tree.Mod} {
+			for i := 0; i < numTuples; i++ {
+				l := rowenc.RandOverflowBoundaryDatum(rng, typ)
+				r := rowenc.RandOverflowBoundaryDatum(rng, typ)
+				rowRes, rowErr := tree.NewTypedBinaryExpr(binOp, l, r, typ).Eval(&evalCtx)
+
+				b := testAllocator.NewMemBatchWithFixedCapacity(typs, 1 /* capacity */)
+				for j, d := range []tree.Datum{l, r} {
+					vec := b.ColVec(j)
+					switch typ.Family() {
+					case types.IntFamily:
+						vec.Int64()[0] = int64(tree.MustBeDInt(d))
+					case types.FloatFamily:
+						vec.Float64()[0] = float64(*d.(*tree.DFloat))
+					case types.DecimalFamily:
+						vec.Decimal()[0].Set(&d.(*tree.DDecimal).Decimal)
+					}
+				}
+				input := colexectestutils.NewChunkingBatchSource(
+					testAllocator, typs, []coldata.Vec{b.ColVec(0), b.ColVec(1), b.ColVec(2)}, 1, /* length */
+				)
+				op, err := colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input, []*types.T{typ, typ},
+					fmt.Sprintf("@1 %s @2", binOp), false /* canFallbackToRowexec */, testMemAcc,
+				)
+				require.NoError(t, err)
+				vecErr := colexecerror.CatchVectorizedRuntimeError(func() {
+					op.Init()
+					batch := op.Next(ctx)
+					colconv.ColVecToDatumAndDeselect(vecRes, batch.ColVec(2), batch.Length(), batch.Selection(), &da)
+				})
+
+				repro := fmt.Sprintf("%s %s %s (%s)", l, binOp, r, typ)
+				switch {
+				case rowErr != nil && vecErr != nil:
+					assert.Equal(t, pgerror.GetPGCode(rowErr), pgerror.GetPGCode(vecErr),
+						"%s: row engine error %v, vectorized error %v", repro, rowErr, vecErr)
+				case rowErr != nil:
+					t.Errorf("%s: row engine error %v, vectorized result %s", repro, rowErr, vecRes[0])
+				case vecErr != nil:
+					t.Errorf("%s: row engine result %s, vectorized error %v", repro, rowRes, vecErr)
+				default:
+					assert.Equal(t, 0, rowRes.Compare(&evalCtx, vecRes[0]),
+						"%s: row engine result %s, vectorized result %s", repro, rowRes, vecRes[0])
+				}
+			}
+		}
+	}
+}
+
 func TestGetProjectionOperator(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
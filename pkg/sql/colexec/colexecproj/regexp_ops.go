@@ -0,0 +1,137 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecproj
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexeccmp"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+// GetRegexpProjectionConstOperator returns a projection operator which
+// projects the result of matching the strings in the column at colIdx against
+// the constant pattern using cmpOp which must be one of the operators for
+// which colexeccmp.IsRegexpCmpOp returns true. The pattern is compiled once as
+// a regular expression.
+func GetRegexpProjectionConstOperator(
+	allocator *colmem.Allocator,
+	evalCtx *tree.EvalContext,
+	cmpOp tree.ComparisonOperator,
+	input colexecop.Operator,
+	colIdx int,
+	resultIdx int,
+	pattern string,
+) (colexecop.Operator, error) {
+	re, negate, err := colexeccmp.CompileRegexpCmpOpPattern(evalCtx, cmpOp, pattern)
+	if err != nil {
+		return nil, err
+	}
+	input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Bool, resultIdx)
+	base := projConstOpBase{
+		OneInputNode: colexecop.NewOneInputNode(input),
+		allocator:    allocator,
+		colIdx:       colIdx,
+		outputIdx:    resultIdx,
+	}
+	if negate {
+		return &projNotRegexpBytesBytesConstOp{projConstOpBase: base, constArg: re}, nil
+	}
+	return &projRegexpBytesBytesConstOp{projConstOpBase: base, constArg: re}, nil
+}
+
+// GetRegexpProjectionOperator returns a projection operator which projects
+// the result of matching the strings in the column at col1Idx against the
+// patterns in the column at col2Idx using cmpOp which must be one of the
+// operators for which colexeccmp.IsRegexpCmpOp returns true.
+func GetRegexpProjectionOperator(
+	allocator *colmem.Allocator,
+	evalCtx *tree.EvalContext,
+	cmpOp tree.ComparisonOperator,
+	input colexecop.Operator,
+	col1Idx int,
+	col2Idx int,
+	resultIdx int,
+) colexecop.Operator {
+	input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Bool, resultIdx)
+	return &projRegexpBytesBytesOp{
+		projOpBase: projOpBase{
+			OneInputNode: colexecop.NewOneInputNode(input),
+			allocator:    allocator,
+			col1Idx:      col1Idx,
+			col2Idx:      col2Idx,
+			outputIdx:    resultIdx,
+		},
+		matcher: colexeccmp.MakeRegexpMatcher(evalCtx, cmpOp),
+	}
+}
+
+// projRegexpBytesBytesOp is a projection operator that matches the strings in
+// one column against the patterns in another column.
+type projRegexpBytesBytesOp struct {
+	projOpBase
+	matcher colexeccmp.RegexpMatcher
+}
+
+var _ colexecop.Operator = &projRegexpBytesBytesOp{}
+
+func (p *projRegexpBytesBytesOp) Init() {
+	p.Input.Init()
+}
+
+func (p *projRegexpBytesBytesOp) Next(ctx context.Context) coldata.Batch {
+	batch := p.Input.Next(ctx)
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	vec1, vec2 := batch.ColVec(p.col1Idx), batch.ColVec(p.col2Idx)
+	col1, col2 := vec1.Bytes(), vec2.Bytes()
+	nulls1, nulls2 := vec1.Nulls(), vec2.Nulls()
+	hasNulls := vec1.MaybeHasNulls() || vec2.MaybeHasNulls()
+	projVec := batch.ColVec(p.outputIdx)
+	p.allocator.PerformOperation([]coldata.Vec{projVec}, func() {
+		if projVec.MaybeHasNulls() {
+			// We need to make sure that there are no left over null values in
+			// the output vector.
+			projVec.Nulls().UnsetNulls()
+		}
+		projCol := projVec.Bool()
+		projNulls := projVec.Nulls()
+		project := func(i int) {
+			if hasNulls && (nulls1.NullAt(i) || nulls2.NullAt(i)) {
+				projNulls.SetNull(i)
+				return
+			}
+			cmp, err := p.matcher.Match(col1.Get(i), col2.Get(i))
+			if err != nil {
+				colexecerror.ExpectedError(err)
+			}
+			projCol[i] = cmp
+		}
+		if sel := batch.Selection(); sel != nil {
+			for _, i := range sel[:n] {
+				project(i)
+			}
+		} else {
+			for i := 0; i < n; i++ {
+				project(i)
+			}
+		}
+	})
+	return batch
+}
@@ -0,0 +1,131 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecsel
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexeccmp"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+)
+
+// GetRegexpSelectionConstOperator returns a selection operator which matches
+// the strings in the column at colIdx against the constant pattern using cmpOp
+// which must be one of the operators for which colexeccmp.IsRegexpCmpOp
+// returns true. The pattern is compiled once as a regular expression.
+func GetRegexpSelectionConstOperator(
+	evalCtx *tree.EvalContext,
+	cmpOp tree.ComparisonOperator,
+	input colexecop.Operator,
+	colIdx int,
+	pattern string,
+) (colexecop.Operator, error) {
+	re, negate, err := colexeccmp.CompileRegexpCmpOpPattern(evalCtx, cmpOp, pattern)
+	if err != nil {
+		return nil, err
+	}
+	base := selConstOpBase{
+		OneInputNode: colexecop.NewOneInputNode(input),
+		colIdx:       colIdx,
+	}
+	if negate {
+		return &selNotRegexpBytesBytesConstOp{selConstOpBase: base, constArg: re}, nil
+	}
+	return &selRegexpBytesBytesConstOp{selConstOpBase: base, constArg: re}, nil
+}
+
+// GetRegexpSelectionOperator returns a selection operator which matches the
+// strings in the column at col1Idx against the patterns in the column at
+// col2Idx using cmpOp which must be one of the operators for which
+// colexeccmp.IsRegexpCmpOp returns true.
+func GetRegexpSelectionOperator(
+	evalCtx *tree.EvalContext,
+	cmpOp tree.ComparisonOperator,
+	input colexecop.Operator,
+	col1Idx int,
+	col2Idx int,
+) colexecop.Operator {
+	return &selRegexpBytesBytesOp{
+		selOpBase: selOpBase{
+			OneInputNode: colexecop.NewOneInputNode(input),
+			col1Idx:      col1Idx,
+			col2Idx:      col2Idx,
+		},
+		matcher: colexeccmp.MakeRegexpMatcher(evalCtx, cmpOp),
+	}
+}
+
+// selRegexpBytesBytesOp is a selection operator that matches the strings in
+// one column against the patterns in another column.
+type selRegexpBytesBytesOp struct {
+	selOpBase
+	matcher colexeccmp.RegexpMatcher
+}
+
+var _ colexecop.Operator = &selRegexpBytesBytesOp{}
+
+func (p *selRegexpBytesBytesOp) Init() {
+	p.Input.Init()
+}
+
+func (p *selRegexpBytesBytesOp) Next(ctx context.Context) coldata.Batch {
+	for {
+		batch := p.Input.Next(ctx)
+		n := batch.Length()
+		if n == 0 {
+			return batch
+		}
+		vec1, vec2 := batch.ColVec(p.col1Idx), batch.ColVec(p.col2Idx)
+		col1, col2 := vec1.Bytes(), vec2.Bytes()
+		nulls1, nulls2 := vec1.Nulls(), vec2.Nulls()
+		hasNulls := vec1.MaybeHasNulls() || vec2.MaybeHasNulls()
+		var idx int
+		if sel := batch.Selection(); sel != nil {
+			sel = sel[:n]
+			for _, i := range sel {
+				if p.match(col1, col2, nulls1, nulls2, hasNulls, i) {
+					sel[idx] = i
+					idx++
+				}
+			}
+		} else {
+			batch.SetSelection(true)
+			sel := batch.Selection()
+			for i := 0; i < n; i++ {
+				if p.match(col1, col2, nulls1, nulls2, hasNulls, i) {
+					sel[idx] = i
+					idx++
+				}
+			}
+		}
+		if idx > 0 {
+			batch.SetLength(idx)
+			return batch
+		}
+	}
+}
+
+// match returns whether the row at index i must be selected.
+func (p *selRegexpBytesBytesOp) match(
+	col1, col2 *coldata.Bytes, nulls1, nulls2 *coldata.Nulls, hasNulls bool, i int,
+) bool {
+	if hasNulls && (nulls1.NullAt(i) || nulls2.NullAt(i)) {
+		return false
+	}
+	cmp, err := p.matcher.Match(col1.Get(i), col2.Get(i))
+	if err != nil {
+		colexecerror.ExpectedError(err)
+	}
+	return cmp
+}
@@ -0,0 +1,92 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecsel
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// TestRegexpOperators verifies that the selection operators for the regexp
+// comparisons produce the same results as the row engine, both with constant
+// and non-constant patterns.
+func TestRegexpOperators(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	evalCtx := tree.MakeTestingEvalContext(cluster.MakeTestingClusterSettings())
+	strs := []interface{}{"abc", "ABC", "", "a%c", "a_c", "abcabc", "xyz", nil}
+	patterns := []interface{}{"abc", "a.c", "^a", "A%", "%", "", "_b_", `a\%c`, "(abc)+", "%c", nil}
+	ops := []tree.ComparisonOperator{
+		tree.Like, tree.NotLike, tree.ILike, tree.NotILike, tree.SimilarTo, tree.NotSimilarTo,
+		tree.RegMatch, tree.NotRegMatch, tree.RegIMatch, tree.NotRegIMatch,
+	}
+	// matches returns whether the row engine evaluates the comparison to true.
+	matches := func(op tree.ComparisonOperator, s, pattern interface{}) bool {
+		if s == nil || pattern == nil {
+			return false
+		}
+		expr := tree.NewTypedComparisonExpr(
+			op, tree.NewDString(s.(string)), tree.NewDString(pattern.(string)),
+		)
+		res, err := expr.Eval(&evalCtx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res == tree.DBoolTrue
+	}
+	for _, op := range ops {
+		t.Run(fmt.Sprintf("%s/const", op), func(t *testing.T) {
+			for _, pattern := range patterns {
+				if pattern == nil {
+					continue
+				}
+				var tups, expected colexectestutils.Tuples
+				for _, s := range strs {
+					tups = append(tups, colexectestutils.Tuple{s})
+					if matches(op, s, pattern) {
+						expected = append(expected, colexectestutils.Tuple{s})
+					}
+				}
+				colexectestutils.RunTestsWithTyps(
+					t, testAllocator, []colexectestutils.Tuples{tups}, [][]*types.T{{types.String}},
+					expected, colexectestutils.OrderedVerifier,
+					func(input []colexecop.Operator) (colexecop.Operator, error) {
+						return GetRegexpSelectionConstOperator(&evalCtx, op, input[0], 0, pattern.(string))
+					})
+			}
+		})
+		t.Run(fmt.Sprintf("%s/non-const", op), func(t *testing.T) {
+			var tups, expected colexectestutils.Tuples
+			for _, s := range strs {
+				for _, pattern := range patterns {
+					tups = append(tups, colexectestutils.Tuple{s, pattern})
+					if matches(op, s, pattern) {
+						expected = append(expected, colexectestutils.Tuple{s, pattern})
+					}
+				}
+			}
+			colexectestutils.RunTestsWithTyps(
+				t, testAllocator, []colexectestutils.Tuples{tups}, [][]*types.T{{types.String, types.String}},
+				expected, colexectestutils.OrderedVerifier,
+				func(input []colexecop.Operator) (colexecop.Operator, error) {
+					return GetRegexpSelectionOperator(&evalCtx, op, input[0], 0, 1), nil
+				})
+		})
+	}
+}
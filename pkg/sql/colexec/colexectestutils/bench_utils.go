@@ -0,0 +1,146 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexectestutils
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldatatestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/envutil"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+// benchmarkProfileDir is the directory to which RunBenchmark writes the
+// profiles of every benchmark. No profiles are written if it is empty.
+var benchmarkProfileDir = envutil.EnvOrDefaultString("COCKROACH_COLEXEC_BENCHMARK_PROFILE_DIR", "")
+
+// RunBenchmark runs fn b.N times after setting the number of bytes processed
+// by every call to fn. Everything done before RunBenchmark is excluded from the
+// timing.
+//
+// If the COCKROACH_COLEXEC_BENCHMARK_PROFILE_DIR environment variable is set,
+// the CPU profile of the loop and the allocations profile after it are written
+// to that directory in files named after the benchmark. Unlike -cpuprofile and
+// -memprofile, this allows for profiling every (sub)benchmark separately. Note
+// that the CPU profile cannot be captured when -cpuprofile is also used.
+func RunBenchmark(b *testing.B, bytes int64, fn func()) {
+	b.SetBytes(bytes)
+	var cpuProfile *os.File
+	if benchmarkProfileDir != "" {
+		var err error
+		cpuProfile, err = os.Create(benchmarkProfilePath(b, "cpu"))
+		if err != nil {
+			b.Fatal(err)
+		}
+		defer cpuProfile.Close()
+		if err := pprof.StartCPUProfile(cpuProfile); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fn()
+	}
+	b.StopTimer()
+	if cpuProfile == nil {
+		return
+	}
+	pprof.StopCPUProfile()
+	memProfile, err := os.Create(benchmarkProfilePath(b, "mem"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer memProfile.Close()
+	// Get up-to-date statistics, like testing does for -memprofile.
+	runtime.GC()
+	if err := pprof.Lookup("allocs").WriteTo(memProfile, 0 /* debug */); err != nil {
+		b.Fatal(err)
+	}
+}
+
+// benchmarkProfilePath returns the path of the profile of the given kind for
+// the benchmark.
+func benchmarkProfilePath(b *testing.B, kind string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-':
+			return r
+		}
+		return '_'
+	}, b.Name())
+	return filepath.Join(benchmarkProfileDir, name+"."+kind+".pprof")
+}
+
+// OperatorBenchmarkArgs are the arguments of RunOperatorBenchmark.
+type OperatorBenchmarkArgs struct {
+	// Typs are the types of the input.
+	Typs []*types.T
+	// NumBatches is the number of batches that the input returns on every
+	// iteration.
+	NumBatches int
+	// NullProbability, SelProbability and BytesFixedLength describe the random
+	// input batch (see coldatatestutils.RandomBatchArgs).
+	NullProbability  float64
+	SelProbability   float64
+	BytesFixedLength int
+	// MakeOperator creates the benchmarked operator on top of the input. It is
+	// called on every iteration.
+	MakeOperator func(input colexecop.Operator) (colexecop.Operator, error)
+}
+
+// RunOperatorBenchmark benchmarks the operator created by args.MakeOperator
+// on the input with the same random batch repeated args.NumBatches times.
+// Every iteration fully drains the operator (and closes it if it is a
+// colexecop.Closer). The number of bytes processed by every iteration is
+// computed as 8 bytes per input value regardless of the types, like in most
+// of the benchmarks of the vectorized operators, so that the results are
+// comparable across operators.
+func RunOperatorBenchmark(
+	ctx context.Context, b *testing.B, allocator *colmem.Allocator, args OperatorBenchmarkArgs,
+) {
+	rng, _ := randutil.NewPseudoRand()
+	batch := coldatatestutils.RandomBatchWithArgs(allocator, coldatatestutils.RandomBatchArgs{
+		Rand:             rng,
+		Typs:             args.Typs,
+		NullProbability:  args.NullProbability,
+		SelProbability:   args.SelProbability,
+		BytesFixedLength: args.BytesFixedLength,
+	})
+	input := NewFiniteBatchSource(allocator, batch, args.Typs, args.NumBatches)
+	bytes := int64(args.NumBatches * batch.Length() * len(args.Typs) * 8)
+	RunBenchmark(b, bytes, func() {
+		input.Reset(args.NumBatches)
+		op, err := args.MakeOperator(input)
+		if err != nil {
+			b.Fatal(err)
+		}
+		op.Init()
+		for {
+			if op.Next(ctx).Length() == 0 {
+				break
+			}
+		}
+		if c, ok := op.(colexecop.Closer); ok {
+			if err := c.Close(ctx); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -1319,6 +1319,107 @@ func (f *FiniteBatchSource) Reset(usableCount int) {
 	f.usableCount = usableCount
 }
 
+// RandomLengthBatchSource is an Operator that, like FiniteBatchSource, returns
+// the rows of the same batch a specified number of times, but every returned
+// batch has a random length and, randomly, a selection vector. It is meant to
+// catch the operators that assume that their input batches are full.
+//
+// Note that since a zero-length batch signals the end of the input, only the
+// last batch returned by RandomLengthBatchSource has zero length.
+type RandomLengthBatchSource struct {
+	colexecop.ZeroInputNode
+
+	allocator      *colmem.Allocator
+	rng            *rand.Rand
+	typs           []*types.T
+	input          coldata.Batch
+	output         coldata.Batch
+	selProbability float64
+
+	usableCount int
+}
+
+var _ colexecop.Operator = &RandomLengthBatchSource{}
+
+// NewRandomLengthBatchSource returns a new Operator initialized to return the
+// rows of the input batch, which must not have a selection vector, a specified
+// number of times. Every returned batch has a random non-zero length and
+// selects a random subset of the rows of the input batch with probability
+// selProbability, and a prefix of them otherwise.
+func NewRandomLengthBatchSource(
+	allocator *colmem.Allocator,
+	rng *rand.Rand,
+	batch coldata.Batch,
+	typs []*types.T,
+	usableCount int,
+	selProbability float64,
+) *RandomLengthBatchSource {
+	if batch.Selection() != nil {
+		colexecerror.InternalError(errors.AssertionFailedf("unexpected selection vector on the input batch"))
+	}
+	return &RandomLengthBatchSource{
+		allocator:      allocator,
+		rng:            rng,
+		typs:           typs,
+		input:          batch,
+		selProbability: selProbability,
+		usableCount:    usableCount,
+	}
+}
+
+// Init implements the Operator interface.
+func (s *RandomLengthBatchSource) Init() {
+	capacity := s.input.Length()
+	if capacity == 0 {
+		capacity = 1
+	}
+	s.output = s.allocator.NewMemBatchWithFixedCapacity(s.typs, capacity)
+}
+
+// Next implements the Operator interface.
+func (s *RandomLengthBatchSource) Next(context.Context) coldata.Batch {
+	n := s.input.Length()
+	if s.usableCount == 0 || n == 0 {
+		return coldata.ZeroBatch
+	}
+	s.usableCount--
+	// The output batch is reset and the rows are copied every time since the
+	// batch could have been modified by the downstream operators.
+	s.output.ResetInternalBatch()
+	length := 1 + s.rng.Intn(n)
+	useSel := s.rng.Float64() < s.selProbability
+	srcEndIdx := length
+	if useSel {
+		srcEndIdx = n
+	}
+	s.allocator.PerformOperation(s.output.ColVecs(), func() {
+		for i, vec := range s.output.ColVecs() {
+			vec.Copy(coldata.CopySliceArgs{
+				SliceArgs: coldata.SliceArgs{
+					Src:       s.input.ColVec(i),
+					SrcEndIdx: srcEndIdx,
+				},
+			})
+		}
+	})
+	if useSel {
+		// The selected rows are a random subset of the rows in their original
+		// order.
+		selected := s.rng.Perm(n)[:length]
+		sort.Ints(selected)
+		s.output.SetSelection(true)
+		copy(s.output.Selection(), selected)
+	}
+	s.output.SetLength(length)
+	return s.output
+}
+
+// Reset resets RandomLengthBatchSource to return the rows of its input batch
+// usableCount number of times.
+func (s *RandomLengthBatchSource) Reset(usableCount int) {
+	s.usableCount = usableCount
+}
+
 // finiteChunksSource is an Operator that returns a batch specified number of
 // times. The first matchLen columns of the batch are incremented every time
 // (except for the first) the batch is returned to emulate source that is
@@ -21,6 +21,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
 )
 
 func TestOpTestInputOutput(t *testing.T) {
@@ -121,3 +122,38 @@ func TestRepeatableBatchSourceWithFixedSel(t *testing.T) {
 		}
 	}
 }
+
+func TestRandomLengthBatchSource(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	typs := []*types.T{types.Int}
+	rng, _ := randutil.NewPseudoRand()
+	batch := testAllocator.NewMemBatchWithMaxCapacity(typs)
+	col := batch.ColVec(0).Int64()
+	for i := 0; i < coldata.BatchSize(); i++ {
+		col[i] = int64(i)
+	}
+	batch.SetLength(coldata.BatchSize())
+	const usableCount = 16
+	input := NewRandomLengthBatchSource(testAllocator, rng, batch, typs, usableCount, 0.5 /* selProbability */)
+	input.Init()
+	for i := 0; i < usableCount; i++ {
+		b := input.Next(context.Background())
+		require.True(t, b.Length() > 0 && b.Length() <= coldata.BatchSize(), "unexpected length %d", b.Length())
+		// The values are the ordinals of the rows, so they must be increasing.
+		prev := int64(-1)
+		for j := 0; j < b.Length(); j++ {
+			rowIdx := j
+			if sel := b.Selection(); sel != nil {
+				rowIdx = sel[j]
+			}
+			v := b.ColVec(0).Int64()[rowIdx]
+			require.Equal(t, int64(rowIdx), v)
+			require.Less(t, prev, v)
+			prev = v
+		}
+		// Modify the batch to check that the next one isn't affected.
+		b.ColVec(0).Int64()[0] = -1
+	}
+	require.Zero(t, input.Next(context.Background()).Length())
+}
@@ -0,0 +1,130 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecutils
+
+import (
+	"context"
+	"math"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+// compactionSelectivityThreshold determines which batches are considered
+// sparse by the compactor operator: a batch with a selection vector is sparse
+// if it contains less than this fraction of coldata.BatchSize() tuples.
+const compactionSelectivityThreshold = 0.25
+
+// compactorOp consumes the input operator and physically compacts the sparse
+// batches (the batches with selection vectors that retain only a small
+// fraction of tuples, which is the case after highly selective filters) by
+// copying the selected tuples of several such batches into a single dense
+// output batch. This way the operators above the compactor don't have to walk
+// the sparse selection vectors, and they process fewer but fuller batches.
+// Batches that are not sparse are passed through as is (after the compacted
+// tuples that precede them have been emitted, so the order of the tuples is
+// preserved).
+//
+// Note that the compactor might need to read several batches from the input
+// before emitting a batch, so it shouldn't be planned when only a few tuples
+// are needed from it (e.g. below a limit).
+type compactorOp struct {
+	colexecop.OneInputNode
+	colexecop.NonExplainable
+	allocator  *colmem.Allocator
+	inputTypes []*types.T
+
+	output coldata.Batch
+	// pending, if non-nil, is the batch that has been read from the input but
+	// hasn't been processed because the output batch had to be emitted first.
+	pending coldata.Batch
+	done    bool
+}
+
+var _ colexecop.Operator = &compactorOp{}
+
+// NewCompactorOp creates a new compactor operator on the given input operator
+// with the given column types.
+func NewCompactorOp(
+	allocator *colmem.Allocator, input colexecop.Operator, typs []*types.T,
+) colexecop.Operator {
+	return &compactorOp{
+		OneInputNode: colexecop.NewOneInputNode(input),
+		allocator:    allocator,
+		inputTypes:   typs,
+	}
+}
+
+func (c *compactorOp) Init() {
+	c.Input.Init()
+}
+
+// isSparse returns whether the batch should be compacted.
+func isSparse(batch coldata.Batch) bool {
+	return batch.Selection() != nil &&
+		float64(batch.Length()) < compactionSelectivityThreshold*float64(coldata.BatchSize())
+}
+
+func (c *compactorOp) Next(ctx context.Context) coldata.Batch {
+	if c.pending != nil && !isSparse(c.pending) {
+		// The pending batch doesn't need to be compacted, and all of the
+		// tuples that preceded it have already been emitted.
+		batch := c.pending
+		c.pending = nil
+		return batch
+	}
+	if c.done {
+		return coldata.ZeroBatch
+	}
+	// The compactor should *not* limit the capacities of the output batches
+	// since it only buffers a single batch. It is up to the input to limit the
+	// size of batches based on the memory footprint.
+	const maxBatchMemSize = math.MaxInt64
+	c.output, _ = c.allocator.ResetMaybeReallocate(
+		c.inputTypes, c.output, coldata.BatchSize(), maxBatchMemSize,
+	)
+	outputLen := 0
+	for {
+		batch := c.pending
+		c.pending = nil
+		if batch == nil {
+			batch = c.Input.Next(ctx)
+		}
+		n := batch.Length()
+		if n == 0 {
+			c.done = true
+			if outputLen > 0 {
+				break
+			}
+			return coldata.ZeroBatch
+		}
+		if !isSparse(batch) {
+			if outputLen == 0 {
+				return batch
+			}
+			// Emit the compacted tuples first.
+			c.pending = batch
+			break
+		}
+		if outputLen+n > c.output.Capacity() {
+			// The tuples of this batch don't fit, so we will process the
+			// batch on the next call.
+			c.pending = batch
+			break
+		}
+		c.allocator.CopyTuples(c.output, outputLen, batch)
+		outputLen += n
+	}
+	c.output.SetLength(outputLen)
+	return c.output
+}
@@ -0,0 +1,149 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecutils
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompactor(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	rng, _ := randutil.NewPseudoRand()
+	typs := []*types.T{types.Int, types.Bytes}
+
+	// addBatches adds numBatches batches with at most maxSelected tuples
+	// selected in each (if useSel is true) to source and returns the values of
+	// the first column of all selected tuples.
+	addBatches := func(
+		source *colexecop.BatchBuffer, numBatches int, maxSelected int, useSel bool,
+	) []int64 {
+		var expected []int64
+		var val int64
+		for i := 0; i < numBatches; i++ {
+			batch := testAllocator.NewMemBatchWithMaxCapacity(typs)
+			intCol, bytesCol := batch.ColVec(0).Int64(), batch.ColVec(1).Bytes()
+			for j := 0; j < coldata.BatchSize(); j++ {
+				intCol[j] = val
+				bytesCol.Set(j, []byte(fmt.Sprint(val)))
+				val++
+			}
+			n := coldata.BatchSize()
+			if useSel {
+				n = rng.Intn(maxSelected + 1)
+				batch.SetSelection(true)
+				sel := batch.Selection()
+				// Select n tuples in increasing order.
+				idx := 0
+				for j := 0; j < n; j++ {
+					idx += rng.Intn((coldata.BatchSize()-idx)/(n-j)) + 1
+					sel[j] = idx - 1
+				}
+				for _, j := range sel[:n] {
+					expected = append(expected, intCol[j])
+				}
+			} else {
+				expected = append(expected, intCol[:n]...)
+			}
+			batch.SetLength(n)
+			if n == 0 {
+				// Zero-length batch would indicate the end of the input.
+				continue
+			}
+			source.Add(batch, typs)
+		}
+		return expected
+	}
+
+	// run returns the values of the first column of the output as well as the
+	// number of output batches.
+	run := func(op colexecop.Operator) ([]int64, int) {
+		op.Init()
+		var actual []int64
+		numBatches := 0
+		for b := op.Next(ctx); b.Length() > 0; b = op.Next(ctx) {
+			numBatches++
+			intCol, bytesCol := b.ColVec(0).Int64(), b.ColVec(1).Bytes()
+			for i := 0; i < b.Length(); i++ {
+				idx := i
+				if sel := b.Selection(); sel != nil {
+					idx = sel[i]
+				}
+				require.Equal(t, fmt.Sprint(intCol[idx]), string(bytesCol.Get(idx)))
+				actual = append(actual, intCol[idx])
+			}
+		}
+		return actual, numBatches
+	}
+
+	t.Run("sparse", func(t *testing.T) {
+		// All batches have exactly one tuple, so they should be compacted into
+		// as few batches as possible.
+		numBatches := 1 + rng.Intn(3*coldata.BatchSize())
+		source := colexecop.NewBatchBuffer()
+		var expected []int64
+		for i := 0; i < numBatches; i++ {
+			idx := rng.Intn(coldata.BatchSize())
+			batch := testAllocator.NewMemBatchWithFixedCapacity(typs, idx+1)
+			batch.ColVec(0).Int64()[idx] = int64(i)
+			batch.ColVec(1).Bytes().Set(idx, []byte(fmt.Sprint(i)))
+			batch.SetSelection(true)
+			batch.Selection()[0] = idx
+			batch.SetLength(1)
+			source.Add(batch, typs)
+			expected = append(expected, int64(i))
+		}
+		source.Add(coldata.ZeroBatch, typs)
+		actual, numOutputBatches := run(NewCompactorOp(testAllocator, source, typs))
+		require.Equal(t, expected, actual)
+		expectedNumOutputBatches := (numBatches + coldata.BatchSize() - 1) / coldata.BatchSize()
+		if 1 >= compactionSelectivityThreshold*float64(coldata.BatchSize()) {
+			// The batch size is so small that the batches are not sparse.
+			expectedNumOutputBatches = numBatches
+		}
+		require.Equal(t, expectedNumOutputBatches, numOutputBatches)
+	})
+
+	t.Run("random", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			source := colexecop.NewBatchBuffer()
+			var expected []int64
+			// Mix sparse, dense, and not selected batches.
+			for j := 0; j < 5; j++ {
+				var maxSelected int
+				useSel := true
+				switch rng.Intn(3) {
+				case 0:
+					maxSelected = int(compactionSelectivityThreshold * float64(coldata.BatchSize()))
+				case 1:
+					maxSelected = coldata.BatchSize()
+				default:
+					useSel = false
+				}
+				expected = append(expected, addBatches(source, 1+rng.Intn(5), maxSelected, useSel)...)
+			}
+			source.Add(coldata.ZeroBatch, typs)
+			actual, _ := run(NewCompactorOp(testAllocator, source, typs))
+			require.Equal(t, expected, actual)
+		}
+	})
+}
@@ -14,6 +14,7 @@ import (
 	"context"
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
 	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
@@ -61,6 +62,22 @@ type Columnarizer struct {
 	ctx             context.Context
 	typs            []*types.T
 
+	// maxBatchCapacity is the maximum capacity of the output batches in the
+	// buffering mode. It is at most coldata.BatchSize().
+	maxBatchCapacity int
+	// autoTuneBatchCapacity indicates whether the capacity of the output
+	// batches in the buffering mode is derived from the average width of the
+	// input rows, see batchCapacity.
+	autoTuneBatchCapacity bool
+	// numSampledRows and sampledRowsSize are the number of input rows whose
+	// width was sampled for auto-tuning and their total size.
+	numSampledRows  int64
+	sampledRowsSize int64
+
+	// cancelChecker is used to periodically check whether the query has been
+	// canceled while the input rows are buffered.
+	cancelChecker colexecutils.CancelChecker
+
 	// removedFromFlow marks this Columnarizer as having been removed from the
 	// flow. This renders all future calls to Init, Next, Close, and DrainMeta
 	// noops.
@@ -69,6 +86,38 @@ type Columnarizer struct {
 
 var _ colexecop.Operator = &Columnarizer{}
 
+// columnarizerRowWidthSamples is the number of input rows whose width is
+// sampled by a Columnarizer that auto-tunes the capacity of its batches.
+const columnarizerRowWidthSamples = 1024
+
+// ColumnarizerOption is an option on NewBufferingColumnarizer.
+type ColumnarizerOption func(*Columnarizer)
+
+// WithMaxBatchCapacity limits the capacity of the output batches of the
+// Columnarizer to maxBatchCapacity, which is clamped to be between 1 and
+// coldata.BatchSize().
+func WithMaxBatchCapacity(maxBatchCapacity int) ColumnarizerOption {
+	return func(c *Columnarizer) {
+		if maxBatchCapacity < 1 {
+			maxBatchCapacity = 1
+		}
+		if maxBatchCapacity < c.maxBatchCapacity {
+			c.maxBatchCapacity = maxBatchCapacity
+		}
+	}
+}
+
+// WithBatchCapacityAutoTuning makes the Columnarizer derive the capacity of
+// its output batches from the average width of its input rows: batches of
+// narrow rows are allocated with the maximum capacity right away, instead of
+// growing it gradually, and batches of wide rows are limited to the number of
+// rows that fit in the memory limit of the batches.
+func WithBatchCapacityAutoTuning() ColumnarizerOption {
+	return func(c *Columnarizer) {
+		c.autoTuneBatchCapacity = true
+	}
+}
+
 // NewBufferingColumnarizer returns a new Columnarizer that will be buffering up
 // rows before emitting them as output batches.
 func NewBufferingColumnarizer(
@@ -77,8 +126,16 @@ func NewBufferingColumnarizer(
 	flowCtx *execinfra.FlowCtx,
 	processorID int32,
 	input execinfra.RowSource,
+	opts ...ColumnarizerOption,
 ) (*Columnarizer, error) {
-	return newColumnarizer(ctx, allocator, flowCtx, processorID, input, columnarizerBufferingMode)
+	c, err := newColumnarizer(ctx, allocator, flowCtx, processorID, input, columnarizerBufferingMode)
+	if err != nil {
+		return nil, err
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
 }
 
 // NewStreamingColumnarizer returns a new Columnarizer that emits every input
@@ -109,11 +166,12 @@ func newColumnarizer(
 		return nil, errors.AssertionFailedf("unexpected columnarizerMode %d", mode)
 	}
 	c := &Columnarizer{
-		allocator:       allocator,
-		input:           input,
-		maxBatchMemSize: execinfra.GetWorkMemLimit(flowCtx.Cfg),
-		ctx:             ctx,
-		mode:            mode,
+		allocator:        allocator,
+		input:            input,
+		maxBatchMemSize:  execinfra.GetWorkMemLimit(flowCtx.Cfg),
+		ctx:              ctx,
+		mode:             mode,
+		maxBatchCapacity: coldata.BatchSize(),
 	}
 	if err = c.ProcessorBase.Init(
 		nil,
@@ -164,10 +222,22 @@ func (c *Columnarizer) Next(context.Context) coldata.Batch {
 		return coldata.ZeroBatch
 	}
 	var reallocated bool
+	batchCapacity := 1
 	switch c.mode {
 	case columnarizerBufferingMode:
+		batchCapacity = c.batchCapacity()
+		if c.batch != nil && c.batch.Capacity() >= batchCapacity {
+			c.batch.ResetInternalBatch()
+			break
+		}
+		minCapacity := 1
+		if c.autoTuneBatchCapacity && c.numSampledRows > 0 {
+			// The batch doesn't need to grow gradually since we know how
+			// many rows fit in it.
+			minCapacity = batchCapacity
+		}
 		c.batch, reallocated = c.allocator.ResetMaybeReallocate(
-			c.typs, c.batch, 1 /* minCapacity */, c.maxBatchMemSize,
+			c.typs, c.batch, minCapacity, c.maxBatchMemSize,
 		)
 	case columnarizerStreamingMode:
 		// Note that we're not using ResetMaybeReallocate because we will
@@ -194,8 +264,12 @@ func (c *Columnarizer) Next(context.Context) coldata.Batch {
 		c.buffered = newRows
 	}
 	// Buffer up rows up to the capacity of the batch.
+	if batchCapacity > c.batch.Capacity() {
+		batchCapacity = c.batch.Capacity()
+	}
 	nRows := 0
-	for ; nRows < c.batch.Capacity(); nRows++ {
+	for ; nRows < batchCapacity; nRows++ {
+		c.cancelChecker.Check(c.ctx)
 		row, meta := c.input.Next()
 		if meta != nil {
 			nRows--
@@ -210,6 +284,10 @@ func (c *Columnarizer) Next(context.Context) coldata.Batch {
 			break
 		}
 		copy(c.buffered[nRows], row)
+		if c.autoTuneBatchCapacity && c.numSampledRows < columnarizerRowWidthSamples {
+			c.numSampledRows++
+			c.sampledRowsSize += int64(row.Size())
+		}
 	}
 
 	// Check if we have buffered more rows than the current allocation size
@@ -230,6 +308,26 @@ func (c *Columnarizer) Next(context.Context) coldata.Batch {
 	return c.batch
 }
 
+// batchCapacity returns the capacity of the next output batch in the
+// buffering mode. If the capacity is auto-tuned, it is the number of rows of
+// the average width that fit in the memory limit of the batches.
+func (c *Columnarizer) batchCapacity() int {
+	capacity := c.maxBatchCapacity
+	if c.autoTuneBatchCapacity && c.numSampledRows > 0 {
+		avgRowSize := c.sampledRowsSize / c.numSampledRows
+		if avgRowSize < 1 {
+			avgRowSize = 1
+		}
+		if n := c.maxBatchMemSize / avgRowSize; n < int64(capacity) {
+			capacity = int(n)
+		}
+		if capacity < 1 {
+			capacity = 1
+		}
+	}
+	return capacity
+}
+
 // Run is part of the execinfra.Processor interface.
 //
 // Columnarizers are not expected to be Run, so we prohibit calling this method
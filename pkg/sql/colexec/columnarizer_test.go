@@ -17,6 +17,7 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
@@ -25,6 +26,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/distsqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/cancelchecker"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/errors"
@@ -71,6 +73,105 @@ func TestColumnarizerResetsInternalBatch(t *testing.T) {
 	require.Equal(t, nRows, foundRows)
 }
 
+func TestColumnarizerBatchCapacity(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	const memLimit = 1 << 20
+	flowCtx := &execinfra.FlowCtx{
+		Cfg: &execinfra.ServerConfig{
+			Settings:     st,
+			TestingKnobs: execinfra.TestingKnobs{MemoryLimitBytes: memLimit},
+		},
+		EvalCtx: &evalCtx,
+	}
+	// batchLengths returns the lengths of the batches of the Columnarizer of
+	// rows.
+	batchLengths := func(typs []*types.T, rows rowenc.EncDatumRows, opts ...ColumnarizerOption) []int {
+		input := execinfra.NewRepeatableRowSource(typs, rows)
+		c, err := NewBufferingColumnarizer(ctx, testAllocator, flowCtx, 0, input, opts...)
+		require.NoError(t, err)
+		c.Init()
+		var lengths []int
+		var numRows int
+		for {
+			batch := c.Next(ctx)
+			if batch.Length() == 0 {
+				break
+			}
+			lengths = append(lengths, batch.Length())
+			numRows += batch.Length()
+		}
+		require.Equal(t, len(rows), numRows)
+		return lengths
+	}
+
+	intTyps := []*types.T{types.Int}
+	intRows := rowenc.MakeIntRows(3*coldata.BatchSize(), len(intTyps))
+	t.Run("max", func(t *testing.T) {
+		for _, l := range batchLengths(intTyps, intRows, WithMaxBatchCapacity(7)) {
+			require.LessOrEqual(t, l, 7)
+		}
+	})
+	t.Run("narrow", func(t *testing.T) {
+		// The first batch is used to sample the rows, and then the batches
+		// are allocated with the maximum capacity right away.
+		lengths := batchLengths(intTyps, intRows, WithBatchCapacityAutoTuning())
+		require.Equal(t, coldata.BatchSize(), lengths[1])
+	})
+	t.Run("wide", func(t *testing.T) {
+		bytesTyps := []*types.T{types.Bytes}
+		bytesRows := make(rowenc.EncDatumRows, 16)
+		for i := range bytesRows {
+			d := tree.NewDBytes(tree.DBytes(make([]byte, 256<<10)))
+			bytesRows[i] = rowenc.EncDatumRow{rowenc.DatumToEncDatum(types.Bytes, d)}
+		}
+		// Only a few rows fit in the memory limit.
+		for _, l := range batchLengths(bytesTyps, bytesRows, WithBatchCapacityAutoTuning()) {
+			require.LessOrEqual(t, l, memLimit/(256<<10))
+		}
+	})
+}
+
+func TestColumnarizerCancellation(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(context.Background())
+	flowCtx := &execinfra.FlowCtx{
+		Cfg:     &execinfra.ServerConfig{Settings: st},
+		EvalCtx: &evalCtx,
+	}
+	typs := []*types.T{types.Int}
+	// There are enough rows for the cancellation to be checked several times
+	// after the first batch, regardless of the batch size.
+	rows := rowenc.MakeIntRows(coldata.BatchSize()+4096, len(typs))
+	input := execinfra.NewRepeatableRowSource(typs, rows)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c, err := NewBufferingColumnarizer(ctx, testAllocator, flowCtx, 0, input)
+	require.NoError(t, err)
+	c.Init()
+	require.NotZero(t, c.Next(ctx).Length())
+	cancel()
+	// The cancellation must be noticed before the input is exhausted.
+	err = colexecerror.CatchVectorizedRuntimeError(func() {
+		for {
+			if c.Next(ctx).Length() == 0 {
+				return
+			}
+		}
+	})
+	require.True(t, errors.Is(err, cancelchecker.QueryCanceledError), "unexpected error %v", err)
+}
+
 func TestColumnarizerDrainsAndClosesInput(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
@@ -146,14 +247,12 @@ func BenchmarkColumnarize(b *testing.B) {
 		EvalCtx: &evalCtx,
 	}
 
-	b.SetBytes(int64(nRows * nCols * int(unsafe.Sizeof(int64(0)))))
-
 	c, err := NewBufferingColumnarizer(ctx, testAllocator, flowCtx, 0, input)
 	if err != nil {
 		b.Fatal(err)
 	}
 	c.Init()
-	for i := 0; i < b.N; i++ {
+	colexectestutils.RunBenchmark(b, int64(nRows*nCols*int(unsafe.Sizeof(int64(0)))), func() {
 		foundRows := 0
 		for {
 			batch := c.Next(ctx)
@@ -166,5 +265,5 @@ func BenchmarkColumnarize(b *testing.B) {
 			b.Fatalf("found %d rows, expected %d", foundRows, nRows)
 		}
 		input.Reset()
-	}
+	})
 }
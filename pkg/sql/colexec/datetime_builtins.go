@@ -0,0 +1,168 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+)
+
+// newDateTruncOperator returns an operator that evaluates the date_trunc
+// builtin on timestamps (or timestamps with time zone if withTZ is true)
+// directly on the vectors, without converting the values to datums.
+func newDateTruncOperator(
+	allocator *colmem.Allocator,
+	evalCtx *tree.EvalContext,
+	funcExpr *tree.FuncExpr,
+	argumentCols []int,
+	outputIdx int,
+	input colexecop.Operator,
+	withTZ bool,
+) colexecop.Operator {
+	return &timeSpanBuiltinOp{
+		OneInputNode: colexecop.NewOneInputNode(input),
+		allocator:    allocator,
+		funcExpr:     funcExpr,
+		argumentCols: argumentCols,
+		outputIdx:    outputIdx,
+		projectRow: func(fromTime time.Time, timeSpan string, outputVec coldata.Vec, i int) error {
+			if withTZ {
+				fromTime = fromTime.In(evalCtx.GetLocation())
+			}
+			toTime, err := tree.TruncateTimestamp(fromTime, timeSpan)
+			if err != nil {
+				return err
+			}
+			// Construct the result datum in order to perform the same
+			// rounding and bounds check as the row engine does.
+			if withTZ {
+				d, err := tree.MakeDTimestampTZ(toTime, time.Microsecond)
+				if err != nil {
+					return err
+				}
+				outputVec.Timestamp()[i] = d.Time
+			} else {
+				d, err := tree.MakeDTimestamp(toTime, time.Microsecond)
+				if err != nil {
+					return err
+				}
+				outputVec.Timestamp()[i] = d.Time
+			}
+			return nil
+		},
+	}
+}
+
+// newExtractOperator returns an operator that evaluates the extract builtin on
+// timestamps directly on the vectors, without converting the values to
+// datums.
+func newExtractOperator(
+	allocator *colmem.Allocator,
+	funcExpr *tree.FuncExpr,
+	argumentCols []int,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	return &timeSpanBuiltinOp{
+		OneInputNode: colexecop.NewOneInputNode(input),
+		allocator:    allocator,
+		funcExpr:     funcExpr,
+		argumentCols: argumentCols,
+		outputIdx:    outputIdx,
+		projectRow: func(fromTime time.Time, timeSpan string, outputVec coldata.Vec, i int) error {
+			res, err := tree.ExtractTimeSpanFromTimestamp(fromTime, timeSpan)
+			if err != nil {
+				return err
+			}
+			outputVec.Float64()[i] = res
+			return nil
+		},
+	}
+}
+
+// timeSpanBuiltinOp is an operator that evaluates a builtin which takes in a
+// time span string (like 'hour' or 'day') as the first argument and a
+// timestamp as the second. The time span is usually the same for all rows, so
+// its lowercased version is cached until a different one is seen.
+type timeSpanBuiltinOp struct {
+	colexecop.OneInputNode
+	allocator    *colmem.Allocator
+	funcExpr     *tree.FuncExpr
+	argumentCols []int
+	outputIdx    int
+	// projectRow evaluates the builtin on a single non-NULL row and sets the
+	// result at position i of outputVec.
+	projectRow func(fromTime time.Time, timeSpan string, outputVec coldata.Vec, i int) error
+
+	// lastTimeSpan is the last seen value of the time span argument, and
+	// timeSpan is its lowercased version.
+	lastTimeSpan []byte
+	timeSpan     string
+}
+
+var _ colexecop.Operator = &timeSpanBuiltinOp{}
+
+func (o *timeSpanBuiltinOp) Init() {
+	o.Input.Init()
+}
+
+func (o *timeSpanBuiltinOp) Next(ctx context.Context) coldata.Batch {
+	batch := o.Input.Next(ctx)
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	timeSpanVec := batch.ColVec(o.argumentCols[0])
+	timeVec := batch.ColVec(o.argumentCols[1])
+	timeSpanCol, timeCol := timeSpanVec.Bytes(), timeVec.Timestamp()
+	timeSpanNulls, timeNulls := timeSpanVec.Nulls(), timeVec.Nulls()
+	hasNulls := timeSpanVec.MaybeHasNulls() || timeVec.MaybeHasNulls()
+	outputVec := batch.ColVec(o.outputIdx)
+	o.allocator.PerformOperation([]coldata.Vec{outputVec}, func() {
+		if outputVec.MaybeHasNulls() {
+			// We need to make sure that there are no left over null values in
+			// the output vector.
+			outputVec.Nulls().UnsetNulls()
+		}
+		outputNulls := outputVec.Nulls()
+		project := func(i int) {
+			if hasNulls && (timeSpanNulls.NullAt(i) || timeNulls.NullAt(i)) {
+				outputNulls.SetNull(i)
+				return
+			}
+			if timeSpan := timeSpanCol.Get(i); o.lastTimeSpan == nil || !bytes.Equal(timeSpan, o.lastTimeSpan) {
+				o.lastTimeSpan = append(o.lastTimeSpan[:0], timeSpan...)
+				o.timeSpan = strings.ToLower(string(timeSpan))
+			}
+			if err := o.projectRow(timeCol[i], o.timeSpan, outputVec, i); err != nil {
+				colexecerror.ExpectedError(o.funcExpr.MaybeWrapError(err))
+			}
+		}
+		if sel := batch.Selection(); sel != nil {
+			for _, i := range sel[:n] {
+				project(i)
+			}
+		} else {
+			for i := 0; i < n; i++ {
+				project(i)
+			}
+		}
+	})
+	return batch
+}
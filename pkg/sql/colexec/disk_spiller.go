@@ -15,13 +15,25 @@ import (
 	"strings"
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlerrors"
 	"github.com/cockroachdb/errors"
 )
 
+// WorkMemRebalancingEnabled is a cluster setting that allows to disable the
+// redistribution of the workmem budget between the buffering operators of a
+// flow at runtime.
+var WorkMemRebalancingEnabled = settings.RegisterBoolSetting(
+	"sql.distsql.temp_storage.workmem_rebalancing.enabled",
+	"set to false to disable the redistribution of the unused memory budget "+
+		"of the finished buffering operators to the operators that still run",
+	true,
+)
+
 // oneInputDiskSpiller is an Operator that manages the fallback from a one
 // input in-memory buffering operator to a disk-backed one when the former hits
 // the memory limit.
@@ -71,12 +83,17 @@ import (
 //   exporting operator that serves as the input to the disk-backed operator.
 // - spillingCallbackFn will be called when the spilling from in-memory to disk
 //   backed operator occurs. It should only be set in tests.
+// - workMemBudget, if set, is the budget of the memory monitor of the
+//   in-memory operator. Its unused part is donated once the in-memory operator
+//   has emitted all of its output without spilling to disk, and the donation
+//   is reclaimed when the disk spiller is reset.
 func NewOneInputDiskSpiller(
 	input colexecop.Operator,
 	inMemoryOp colexecop.BufferingInMemoryOperator,
 	inMemoryMemMonitorName string,
 	diskBackedOpConstructor func(input colexecop.Operator) colexecop.Operator,
 	spillingCallbackFn func(),
+	workMemBudget *colmem.WorkMemBudget,
 ) colexecop.Operator {
 	diskBackedOpInput := newBufferExportingOperator(inMemoryOp, input)
 	return &diskSpillerBase{
@@ -85,6 +102,7 @@ func NewOneInputDiskSpiller(
 		inMemoryMemMonitorName: inMemoryMemMonitorName,
 		diskBackedOp:           diskBackedOpConstructor(diskBackedOpInput),
 		spillingCallbackFn:     spillingCallbackFn,
+		workMemBudget:          workMemBudget,
 	}
 }
 
@@ -138,12 +156,17 @@ func NewOneInputDiskSpiller(
 //   exporting operators that serves as inputs to the disk-backed operator.
 // - spillingCallbackFn will be called when the spilling from in-memory to disk
 //   backed operator occurs. It should only be set in tests.
+// - workMemBudget, if set, is the budget of the memory monitor of the
+//   in-memory operator. Its unused part is donated once the in-memory operator
+//   has emitted all of its output without spilling to disk, and the donation
+//   is reclaimed when the disk spiller is reset.
 func NewTwoInputDiskSpiller(
 	inputOne, inputTwo colexecop.Operator,
 	inMemoryOp colexecop.BufferingInMemoryOperator,
 	inMemoryMemMonitorName string,
 	diskBackedOpConstructor func(inputOne, inputTwo colexecop.Operator) colexecop.Operator,
 	spillingCallbackFn func(),
+	workMemBudget *colmem.WorkMemBudget,
 ) colexecop.Operator {
 	diskBackedOpInputOne := newBufferExportingOperator(inMemoryOp, inputOne)
 	diskBackedOpInputTwo := newBufferExportingOperator(inMemoryOp, inputTwo)
@@ -155,6 +178,7 @@ func NewTwoInputDiskSpiller(
 		diskBackedOp:           diskBackedOpConstructor(diskBackedOpInputOne, diskBackedOpInputTwo),
 		distBackedOpInitStatus: colexecop.OperatorNotInitialized,
 		spillingCallbackFn:     spillingCallbackFn,
+		workMemBudget:          workMemBudget,
 	}
 }
 
@@ -174,6 +198,8 @@ type diskSpillerBase struct {
 	diskBackedOp           colexecop.Operator
 	distBackedOpInitStatus colexecop.OperatorInitStatus
 	spillingCallbackFn     func()
+	workMemBudget          *colmem.WorkMemBudget
+	inMemoryOpDone         bool
 }
 
 var _ colexecop.ResettableOperator = &diskSpillerBase{}
@@ -220,6 +246,10 @@ func (d *diskSpillerBase) Next(ctx context.Context) coldata.Batch {
 		// different operator, so we propagate it further.
 		colexecerror.InternalError(err)
 	}
+	if batch.Length() == 0 && !d.inMemoryOpDone {
+		d.inMemoryOpDone = true
+		d.workMemBudget.Donate()
+	}
 	return batch
 }
 
@@ -240,6 +270,12 @@ func (d *diskSpillerBase) Reset(ctx context.Context) {
 		}
 	}
 	d.spilled = false
+	if d.inMemoryOpDone {
+		// The in-memory operator will be used again, so it needs its full
+		// budget back.
+		d.workMemBudget.Reclaim()
+		d.inMemoryOpDone = false
+	}
 }
 
 // Close implements the Closer interface.
@@ -41,6 +41,15 @@ var binaryOpFloatMethod = map[tree.BinaryOperator]string{
 	tree.Pow:      "math.Pow",
 }
 
+// binaryOpDecFastPathFn contains the functions that perform the exact
+// arithmetic on decimals with the fast path for the decimals with small
+// coefficients.
+var binaryOpDecFastPathFn = map[tree.BinaryOperator]string{
+	tree.Plus:  "execgen.DecimalAdd",
+	tree.Minus: "execgen.DecimalSub",
+	tree.Mult:  "execgen.DecimalMul",
+}
+
 var binaryOpDecCtx = map[tree.BinaryOperator]string{
 	tree.Plus:     "ExactCtx",
 	tree.Minus:    "ExactCtx",
@@ -302,6 +311,7 @@ func (decimalCustomizer) getBinOpAssignFunc() assignFunc {
 		args := map[string]interface{}{
 			"Ctx":              binaryOpDecCtx[binOp],
 			"Op":               binaryOpDecMethod[binOp],
+			"FastPathFn":       binaryOpDecFastPathFn[binOp],
 			"CheckRightIsZero": checkRightIsZero(binOp),
 			"Target":           targetElem,
 			"Left":             leftElem,
@@ -315,7 +325,11 @@ func (decimalCustomizer) getBinOpAssignFunc() assignFunc {
 					colexecerror.ExpectedError(tree.ErrDivByZero)
 				}
 				{{end}}
+				{{if .FastPathFn}}
+				_, err := {{.FastPathFn}}(&{{.Target}}, &{{.Left}}, &{{.Right}})
+				{{else}}
 				_, err := tree.{{.Ctx}}.{{.Op}}(&{{.Target}}, &{{.Left}}, &{{.Right}})
+				{{end}}
 				if err != nil {
 					colexecerror.ExpectedError(err)
 				}
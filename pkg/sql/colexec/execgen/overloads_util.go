@@ -13,6 +13,7 @@ package execgen
 import (
 	"github.com/cockroachdb/apd/v2"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/arith"
 )
 
 // OverloadHelper is a utility struct used for templates that helps us avoid
@@ -27,3 +28,146 @@ type OverloadHelper struct {
 	BinFn            tree.TwoArgFn
 	EvalCtx          *tree.EvalContext
 }
+
+// The functions below implement the fast path for the exact arithmetic on
+// decimals. Most decimals that the vectorized engine operates on (e.g. the
+// values of DECIMAL(p, s) columns with small precision) have coefficients that
+// fit into int64, so such a decimal can be represented as a "scaled int64",
+// i.e. an int64 coefficient together with the exponent. The arithmetic on the
+// scaled int64s is much cheaper than the one of apd (which operates on
+// big.Ints), and if the result overflows int64, we escape to apd.
+//
+// The results (including the exponents and the signs of zeros) are the same
+// as of the corresponding operations of tree.ExactCtx.
+//
+// Note that only the arithmetic uses the scaled int64s: the decimal vectors
+// still store apd.Decimals (the decimal physical type is shared with the
+// serialization and the conversion code), so the coefficients are converted
+// to and from int64 on every operation. Storing the decimals with small
+// precision as scaled int64s in the vectors themselves is not implemented.
+
+// maxInt64Digits is the number of decimal digits of math.MaxInt64.
+const maxInt64Digits = 19
+
+// powersOf10 contains the powers of 10 which fit into int64.
+var powersOf10 = func() [maxInt64Digits]int64 {
+	var res [maxInt64Digits]int64
+	res[0] = 1
+	for i := 1; i < maxInt64Digits; i++ {
+		res[i] = res[i-1] * 10
+	}
+	return res
+}()
+
+// decimalAsScaledInt64 returns the coefficient of d as int64 (with the sign
+// of d applied) if d is finite and the coefficient fits into int64. Negative
+// zeros are not supported since the sign would be lost.
+func decimalAsScaledInt64(d *apd.Decimal) (int64, bool) {
+	if d.Form != apd.Finite || !d.Coeff.IsInt64() {
+		return 0, false
+	}
+	c := d.Coeff.Int64()
+	if d.Negative {
+		if c == 0 {
+			return 0, false
+		}
+		c = -c
+	}
+	return c, true
+}
+
+// alignScaledInt64s returns a and b with the same exponent (the smaller of
+// the two).
+func alignScaledInt64s(
+	a int64, aExp int32, b int64, bExp int32,
+) (_ int64, _ int64, exp int32, ok bool) {
+	if aExp < bExp {
+		b, exp, ok = upscaleScaledInt64(b, bExp, aExp)
+		return a, b, exp, ok
+	}
+	a, exp, ok = upscaleScaledInt64(a, aExp, bExp)
+	return a, b, exp, ok
+}
+
+// upscaleScaledInt64 returns the coefficient of the decimal x*10^exp when
+// represented with the exponent newExp which must not be greater than exp.
+func upscaleScaledInt64(x int64, exp int32, newExp int32) (int64, int32, bool) {
+	diff := exp - newExp
+	if diff == 0 {
+		return x, exp, true
+	}
+	if diff >= maxInt64Digits {
+		return 0, 0, false
+	}
+	x, ok := arith.MulHalfPositiveWithOverflow(x, powersOf10[diff])
+	return x, newExp, ok
+}
+
+// DecimalAdd sets res to a+b. It is equivalent to tree.ExactCtx.Add, but it
+// performs the addition on int64s when possible.
+func DecimalAdd(res, a, b *apd.Decimal) (apd.Condition, error) {
+	if x, y, exp, ok := alignDecimalsAsScaledInt64s(a, b); ok {
+		if r, ok := arith.AddWithOverflow(x, y); ok {
+			res.SetFinite(r, exp)
+			return 0, nil
+		}
+	}
+	return tree.ExactCtx.Add(res, a, b)
+}
+
+// DecimalSub sets res to a-b. It is equivalent to tree.ExactCtx.Sub, but it
+// performs the subtraction on int64s when possible.
+func DecimalSub(res, a, b *apd.Decimal) (apd.Condition, error) {
+	if x, y, exp, ok := alignDecimalsAsScaledInt64s(a, b); ok {
+		if r, ok := arith.SubWithOverflow(x, y); ok {
+			res.SetFinite(r, exp)
+			return 0, nil
+		}
+	}
+	return tree.ExactCtx.Sub(res, a, b)
+}
+
+// DecimalMul sets res to a*b. It is equivalent to tree.ExactCtx.Mul, but it
+// performs the multiplication on int64s when possible.
+func DecimalMul(res, a, b *apd.Decimal) (apd.Condition, error) {
+	if x, ok := decimalAsScaledInt64(a); ok {
+		if y, ok := decimalAsScaledInt64(b); ok {
+			exp := int64(a.Exponent) + int64(b.Exponent)
+			if isFastPathExponent(exp) {
+				if r, ok := arith.MulWithOverflow(x, y); ok {
+					res.SetFinite(r, int32(exp))
+					if r == 0 {
+						// apd preserves the sign of zero when multiplying.
+						res.Negative = a.Negative != b.Negative
+					}
+					return 0, nil
+				}
+			}
+		}
+	}
+	return tree.ExactCtx.Mul(res, a, b)
+}
+
+// alignDecimalsAsScaledInt64s returns the scaled int64s of a and b with the
+// same exponent, if possible.
+func alignDecimalsAsScaledInt64s(a, b *apd.Decimal) (_ int64, _ int64, exp int32, ok bool) {
+	x, ok := decimalAsScaledInt64(a)
+	if !ok {
+		return 0, 0, 0, false
+	}
+	y, ok := decimalAsScaledInt64(b)
+	if !ok {
+		return 0, 0, 0, false
+	}
+	x, y, exp, ok = alignScaledInt64s(x, a.Exponent, y, b.Exponent)
+	return x, y, exp, ok && isFastPathExponent(int64(exp))
+}
+
+// isFastPathExponent returns whether the result with the given exponent can
+// be computed on the fast path. The adjusted exponent of the result (which
+// depends on the number of digits of the coefficient) must be within the
+// limits of tree.ExactCtx, so we don't take the fast path when the exponent is
+// close to them.
+func isFastPathExponent(exp int64) bool {
+	return exp >= int64(tree.ExactCtx.MinExponent) && exp <= int64(tree.ExactCtx.MaxExponent)-maxInt64Digits
+}
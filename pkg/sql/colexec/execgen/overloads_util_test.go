@@ -0,0 +1,100 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package execgen
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/cockroachdb/apd/v2"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDecimalArithmetic verifies that the fast path for the arithmetic on
+// decimals produces exactly the same results as tree.ExactCtx.
+func TestDecimalArithmetic(t *testing.T) {
+	rng, _ := randutil.NewPseudoRand()
+	coeffs := []int64{0, 1, 7, 10, 12345, math.MaxInt32, math.MaxInt64 / 10, math.MaxInt64, math.MinInt64}
+	exponents := []int32{0, -1, -2, -10, -18, -19, 1, 5, -2000, 1990}
+	randDecimal := func() *apd.Decimal {
+		var d apd.Decimal
+		if rng.Float64() < 0.5 {
+			d.SetFinite(coeffs[rng.Intn(len(coeffs))], exponents[rng.Intn(len(exponents))])
+		} else {
+			d.SetFinite(rng.Int63n(1000000)-500000, int32(-rng.Intn(6)))
+		}
+		switch rng.Intn(10) {
+		case 0:
+			d.Negative = !d.Negative
+		case 1:
+			// Make the coefficient not fit into int64.
+			d.Coeff.Mul(&d.Coeff, &d.Coeff)
+		}
+		return &d
+	}
+	for _, tc := range []struct {
+		name     string
+		fastPath func(res, a, b *apd.Decimal) (apd.Condition, error)
+		apdOp    func(res, a, b *apd.Decimal) (apd.Condition, error)
+	}{
+		{name: "Add", fastPath: DecimalAdd, apdOp: tree.ExactCtx.Add},
+		{name: "Sub", fastPath: DecimalSub, apdOp: tree.ExactCtx.Sub},
+		{name: "Mul", fastPath: DecimalMul, apdOp: tree.ExactCtx.Mul},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for i := 0; i < 10000; i++ {
+				a, b := randDecimal(), randDecimal()
+				var expected, actual apd.Decimal
+				_, expectedErr := tc.apdOp(&expected, a, b)
+				_, actualErr := tc.fastPath(&actual, a, b)
+				msg := fmt.Sprintf("%s and %s", a, b)
+				if expectedErr != nil {
+					require.Error(t, actualErr, msg)
+					continue
+				}
+				require.NoError(t, actualErr, msg)
+				require.Equal(t, expected.String(), actual.String(), msg)
+				require.Equal(t, expected.Negative, actual.Negative, msg)
+				// The result might alias one of the arguments.
+				_, err := tc.fastPath(a, a, b)
+				require.NoError(t, err, msg)
+				require.Equal(t, expected.String(), a.String(), msg)
+			}
+		})
+	}
+}
+
+func BenchmarkDecimalArithmetic(b *testing.B) {
+	var x, y apd.Decimal
+	x.SetFinite(1234567, -2)
+	y.SetFinite(89, -4)
+	for _, tc := range []struct {
+		name string
+		op   func(res, a, b *apd.Decimal) (apd.Condition, error)
+	}{
+		{name: "fast-path/Add", op: DecimalAdd},
+		{name: "apd/Add", op: tree.ExactCtx.Add},
+		{name: "fast-path/Mul", op: DecimalMul},
+		{name: "apd/Mul", op: tree.ExactCtx.Mul},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			var res apd.Decimal
+			for i := 0; i < b.N; i++ {
+				if _, err := tc.op(&res, &x, &y); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
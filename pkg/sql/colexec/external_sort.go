@@ -636,9 +636,13 @@ type inputPartitioningOperator struct {
 	memoryLimit int64
 	// alreadyUsedMemory tracks the size of the current partition so far.
 	alreadyUsedMemory int64
+	// inputExhausted indicates whether the input has returned a zero-length
+	// batch. It is used by the externalTopKSorter to find out whether the
+	// current partition is the last one.
+	inputExhausted bool
 	// interceptReset determines whether the reset method will be called on
 	// the input to this operator when the latter is being reset. This field is
-	// managed by externalSorter.
+	// managed by externalSorter and externalTopKSorter.
 	// NOTE: this field itself is set to 'false' when inputPartitioningOperator
 	// is being reset, regardless of the original value.
 	//
@@ -668,6 +672,7 @@ func (o *inputPartitioningOperator) Next(ctx context.Context) coldata.Batch {
 	}
 	b := o.Input.Next(ctx)
 	if b.Length() == 0 {
+		o.inputExhausted = true
 		return b
 	}
 	// This operator is an input to sortOp which will spool all the tuples and
@@ -685,6 +690,7 @@ func (o *inputPartitioningOperator) Reset(ctx context.Context) {
 		if r, ok := o.Input.(colexecop.Resetter); ok {
 			r.Reset(ctx)
 		}
+		o.inputExhausted = false
 	}
 	o.interceptReset = false
 	o.alreadyUsedMemory = 0
@@ -181,7 +181,13 @@ func TestExternalSortRandomized(t *testing.T) {
 					namePrefix = "ForceDiskSpill=true"
 				}
 				delegateFDAcquisition := rng.Float64() < 0.5
-				name := fmt.Sprintf("%s/nCols=%d/nOrderingCols=%d/delegateFDAcquisition=%t", namePrefix, nCols, nOrderingCols, delegateFDAcquisition)
+				// Randomly choose whether the top K sort is planned in order
+				// to exercise the external top K sorter too.
+				var k uint64
+				if rng.Float64() < 0.5 {
+					k = uint64(1 + rng.Intn(nTups))
+				}
+				name := fmt.Sprintf("%s/nCols=%d/nOrderingCols=%d/delegateFDAcquisition=%t/k=%d", namePrefix, nCols, nOrderingCols, delegateFDAcquisition, k)
 				log.Infof(ctx, "%s", name)
 				// Unfortunately, there is currently no better way to check that a
 				// sorter does not have leftover file descriptors other than appending
@@ -197,6 +203,9 @@ func TestExternalSortRandomized(t *testing.T) {
 				//  flow tracking open FDs and releasing any leftovers.
 				var semsToCheck []semaphore.Semaphore
 				tups, expected, ordCols := generateRandomDataForTestSort(rng, nTups, nCols, nOrderingCols)
+				if k > 0 {
+					expected = expected[:k]
+				}
 				colexectestutils.RunTests(
 					t,
 					testAllocator,
@@ -208,7 +217,7 @@ func TestExternalSortRandomized(t *testing.T) {
 						semsToCheck = append(semsToCheck, sem)
 						sorter, newAccounts, newMonitors, closers, err := createDiskBackedSorter(
 							ctx, flowCtx, input, typs[:nCols], ordCols,
-							0 /* matchLen */, 0 /* k */, func() {},
+							0 /* matchLen */, k, func() {},
 							numForcedRepartitions, delegateFDAcquisition, queueCfg, sem)
 						// TODO(asubiotto): Explicitly Close when testing.T is passed into
 						//  this constructor and we do a substring match.
@@ -0,0 +1,396 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
+	"github.com/cockroachdb/cockroach/pkg/sql/colcontainer"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/errors"
+	"github.com/marusama/semaphore"
+)
+
+// externalTopKSorterState indicates the current state of the external top K
+// sorter.
+type externalTopKSorterState int
+
+const (
+	// externalTopKSorterNewChunk indicates that the next chunk of the input
+	// should be sorted in memory and merged with the top K tuples spilled to
+	// disk so far. If the input has not been exhausted by the chunk, the first
+	// K merged tuples are spilled to disk and replace the ones spilled before,
+	// and the sorter stays in this state. Otherwise, the sorter transitions to
+	// externalTopKSorterEmitting.
+	externalTopKSorterNewChunk externalTopKSorterState = iota
+	// externalTopKSorterEmitting indicates that the input has been fully
+	// consumed and the first K tuples of the last merge are being emitted. A
+	// zero-length batch in this state indicates that we have emitted all
+	// tuples and should transition to externalTopKSorterFinished state.
+	externalTopKSorterEmitting
+	// externalTopKSorterFinished indicates that all tuples have been emitted
+	// and from now on only a zero-length batch will be emitted by the external
+	// top K sorter. This state is also responsible for closing the partitions.
+	externalTopKSorterFinished
+)
+
+// externalTopKSorterNumFDs is the number of file descriptors that the external
+// top K sorter uses at once: one for reading the top K tuples spilled so far
+// and another for writing the new ones.
+const externalTopKSorterNumFDs = 2
+
+// externalTopKSorter is an Operator that returns the first K tuples of its
+// input in sorted order, and it is used once the top K sorter exceeds its
+// memory limit (e.g. when K is large). It works as follows:
+// 1. it divides up all batches from the input into chunks that fit in memory
+// (using an input partitioner) and finds the top K tuples of each chunk using
+// the in-memory top K sorter (which keeps a heap over the buffered batches);
+// 2. it merges the top K tuples of the chunk with the top K tuples of all
+// previous chunks, which are stored in a single partition on disk, and spills
+// the first K merged tuples into a new partition that replaces the old one.
+// The merge of the last chunk is emitted directly instead of being spilled.
+//
+// The (simplified) diagram of the components involved is as follows:
+//
+//                      input
+//                        |
+//                        ↓
+//                 input partitioner
+//                        |
+//                        ↓
+//                 in-memory top K       top K tuples of the
+//                 sorter (a chunk)      previous chunks (on disk)
+//                        |                      |
+//                        ↓                      ↓
+//                   merger (ordered synchronizer) --→ new top K partition
+//                        |                          (if not the last chunk)
+//                        ↓
+//                      output
+//
+// As opposed to the external sorter, which spills the whole input to disk and
+// only then merges the partitions, the external top K sorter never keeps more
+// than 2K tuples on disk and never needs more than externalTopKSorterNumFDs
+// file descriptors.
+type externalTopKSorter struct {
+	colexecop.OneInputNode
+	colexecop.NonExplainable
+	colexecop.CloserHelper
+
+	// mergeUnlimitedAllocator is used to track the memory under the batches
+	// dequeued from the spilled top K tuples during the merge operation.
+	mergeUnlimitedAllocator *colmem.Allocator
+	// outputUnlimitedAllocator is used to track the memory under the output
+	// batch in the merge operation.
+	outputUnlimitedAllocator *colmem.Allocator
+	// mergeMemoryLimit determines the amount of RAM available for the output
+	// batch of the merge operation.
+	mergeMemoryLimit int64
+
+	state          externalTopKSorterState
+	inputTypes     []*types.T
+	columnOrdering colinfo.ColumnOrdering
+	k              uint64
+	inMemSorter    colexecop.ResettableOperator
+	// inMemSorterOutput is the output of inMemSorter which is passed to the
+	// mergers (the in-memory sorter must not be initialized by every merger).
+	inMemSorterOutput  *alreadyInitializedOperator
+	inMemSorterInput   *inputPartitioningOperator
+	partitioner        colcontainer.PartitionedQueue
+	partitionerCreator func() colcontainer.PartitionedQueue
+	// numPartitions is the number of partitions spilled so far. The last one
+	// contains the top K tuples of all chunks consumed so far.
+	numPartitions int
+	// spilled reads the tuples of the last partition. It is reused by all
+	// mergers.
+	spilled *partitionerToOperator
+
+	// emitter is the merger of the last chunk.
+	emitter colexecop.Operator
+	// numMerged is the number of tuples that the current merger has output so
+	// far.
+	numMerged uint64
+
+	// fdState is used to acquire file descriptors up front.
+	fdState struct {
+		fdSemaphore semaphore.Semaphore
+		acquiredFDs int
+	}
+
+	testingKnobs struct {
+		// delegateFDAcquisitions if true, means that a test wants to force the
+		// PartitionedDiskQueues to track the number of file descriptors the
+		// external top K sorter will open/close. This disables the default
+		// behavior of acquiring all file descriptors up front.
+		delegateFDAcquisitions bool
+	}
+}
+
+var _ colexecop.ResettableOperator = &externalTopKSorter{}
+var _ colexecop.ClosableOperator = &externalTopKSorter{}
+
+// NewExternalTopKSorter returns a disk-backed top K sort operator, which sorts
+// its input on the columns given in ordering and returns the first k tuples.
+// - unlimitedAllocators must have been created with a memory account derived
+// from an unlimited memory monitor. They will be used by several internal
+// components of the external top K sort which is responsible for making sure
+// that the components stay within the memory limit.
+// - delegateFDAcquisitions specifies whether the external top K sorter should
+// let the partitioned disk queue acquire file descriptors instead of acquiring
+// them up front. This should only be true in tests.
+func NewExternalTopKSorter(
+	sortUnlimitedAllocator *colmem.Allocator,
+	mergeUnlimitedAllocator *colmem.Allocator,
+	outputUnlimitedAllocator *colmem.Allocator,
+	input colexecop.Operator,
+	inputTypes []*types.T,
+	ordering execinfrapb.Ordering,
+	k uint64,
+	memoryLimit int64,
+	delegateFDAcquisitions bool,
+	diskQueueCfg colcontainer.DiskQueueCfg,
+	fdSemaphore semaphore.Semaphore,
+	diskAcc *mon.BoundAccount,
+) colexecop.Operator {
+	// The cache mode is chosen to reuse the cache to have a smaller cache per
+	// partition without affecting performance.
+	diskQueueCfg.CacheMode = colcontainer.DiskQueueCacheModeReuseCache
+	diskQueueCfg.SetDefaultBufferSizeBytesForCacheMode()
+	if memoryLimit == 1 {
+		// If memory limit is 1, we're likely in a "force disk spill"
+		// scenario, but we don't want to artificially limit batches when we
+		// have already spilled, so we'll use a larger limit.
+		memoryLimit = colexecop.DefaultMemoryLimit
+	}
+	// Each disk queue will use up to BufferSizeBytes of RAM, so we reduce the
+	// memoryLimit of the chunks to sort in memory by those cache sizes.
+	memoryLimit -= int64(externalTopKSorterNumFDs * diskQueueCfg.BufferSizeBytes)
+	// We give half of the available RAM to the in-memory top K sorter and
+	// another half to the merge operation.
+	inMemSortMemoryLimit := memoryLimit / 2
+	mergeMemoryLimit := memoryLimit / 2
+	if inMemSortMemoryLimit < 1 {
+		// If the memory limit is 0, the input partitioning operator will return
+		// a zero-length batch, so make it at least 1.
+		inMemSortMemoryLimit = 1
+		mergeMemoryLimit = 1
+	}
+	inputPartitioner := newInputPartitioningOperator(input, inMemSortMemoryLimit)
+	inMemSorter := NewTopKSorter(sortUnlimitedAllocator, inputPartitioner, inputTypes, ordering.Columns, k)
+	partitionedDiskQueueSemaphore := fdSemaphore
+	if !delegateFDAcquisitions {
+		// To avoid deadlocks with other disk queues, we manually attempt to
+		// acquire the maximum number of descriptors all at once in Next.
+		// Passing in a nil semaphore indicates that the caller will do the
+		// acquiring.
+		partitionedDiskQueueSemaphore = nil
+	}
+	s := &externalTopKSorter{
+		OneInputNode:             colexecop.NewOneInputNode(inMemSorter),
+		mergeUnlimitedAllocator:  mergeUnlimitedAllocator,
+		outputUnlimitedAllocator: outputUnlimitedAllocator,
+		mergeMemoryLimit:         mergeMemoryLimit,
+		inputTypes:               inputTypes,
+		columnOrdering:           execinfrapb.ConvertToColumnOrdering(ordering),
+		k:                        k,
+		inMemSorter:              inMemSorter,
+		inMemSorterOutput:        &alreadyInitializedOperator{OneInputNode: colexecop.NewOneInputNode(inMemSorter)},
+		inMemSorterInput:         inputPartitioner.(*inputPartitioningOperator),
+		partitionerCreator: func() colcontainer.PartitionedQueue {
+			return colcontainer.NewPartitionedDiskQueue(inputTypes, diskQueueCfg, partitionedDiskQueueSemaphore, colcontainer.PartitionerStrategyCloseOnNewPartition, diskAcc)
+		},
+	}
+	s.fdState.fdSemaphore = fdSemaphore
+	s.testingKnobs.delegateFDAcquisitions = delegateFDAcquisitions
+	return s
+}
+
+func (s *externalTopKSorter) Init() {
+	s.Input.Init()
+	s.state = externalTopKSorterNewChunk
+}
+
+func (s *externalTopKSorter) Next(ctx context.Context) coldata.Batch {
+	for {
+		switch s.state {
+		case externalTopKSorterNewChunk:
+			merger := s.createMerger()
+			s.numMerged = 0
+			// Note that the in-memory sorter consumes the whole chunk on the
+			// first call to Next, so after the first call to the merger we
+			// know whether the chunk is the last one.
+			b := s.limit(merger.Next(ctx))
+			if s.inMemSorterInput.inputExhausted {
+				s.emitter = merger
+				s.state = externalTopKSorterEmitting
+				if b.Length() == 0 {
+					s.state = externalTopKSorterFinished
+					continue
+				}
+				return b
+			}
+			s.spill(ctx, merger, b)
+
+		case externalTopKSorterEmitting:
+			b := s.limit(s.emitter.Next(ctx))
+			if b.Length() == 0 {
+				s.state = externalTopKSorterFinished
+				continue
+			}
+			return b
+
+		case externalTopKSorterFinished:
+			if err := s.Close(ctx); err != nil {
+				colexecerror.InternalError(err)
+			}
+			return coldata.ZeroBatch
+
+		default:
+			colexecerror.InternalError(errors.AssertionFailedf("unexpected externalTopKSorterState %d", s.state))
+		}
+	}
+}
+
+// createMerger returns the operator that merges the top K tuples of the
+// current chunk with the top K tuples spilled to disk so far (if any).
+func (s *externalTopKSorter) createMerger() colexecop.Operator {
+	if s.numPartitions == 0 {
+		return s.inMemSorterOutput
+	}
+	merger, err := NewOrderedSynchronizer(
+		s.outputUnlimitedAllocator, s.mergeMemoryLimit,
+		[]SynchronizerInput{{Op: s.spilled}, {Op: s.inMemSorterOutput}},
+		s.inputTypes, s.columnOrdering,
+	)
+	if err != nil {
+		colexecerror.InternalError(err)
+	}
+	merger.Init()
+	return merger
+}
+
+// limit truncates b so that the current merger doesn't output more than k
+// tuples in total.
+func (s *externalTopKSorter) limit(b coldata.Batch) coldata.Batch {
+	remaining := s.k - s.numMerged
+	if remaining == 0 {
+		return coldata.ZeroBatch
+	}
+	if uint64(b.Length()) > remaining {
+		b.SetLength(int(remaining))
+	}
+	s.numMerged += uint64(b.Length())
+	return b
+}
+
+// spill spills b followed by the rest of the output of merger into a new
+// partition which then replaces the previous one, and it resets the in-memory
+// sorter so that it could sort the next chunk.
+func (s *externalTopKSorter) spill(ctx context.Context, merger colexecop.Operator, b coldata.Batch) {
+	if s.partitioner == nil {
+		s.partitioner = s.partitionerCreator()
+		if !s.testingKnobs.delegateFDAcquisitions && s.fdState.fdSemaphore != nil {
+			toAcquire := externalTopKSorterNumFDs
+			if err := s.fdState.fdSemaphore.Acquire(ctx, toAcquire); err != nil {
+				colexecerror.InternalError(err)
+			}
+			s.fdState.acquiredFDs = toAcquire
+		}
+	}
+	partitionIdx := s.numPartitions
+	for ; ; b = s.limit(merger.Next(ctx)) {
+		// Note that b never has a selection vector set because neither the
+		// top K sorter nor the ordered synchronizer set it.
+		if err := s.partitioner.Enqueue(ctx, partitionIdx, b); err != nil {
+			colexecutils.HandleErrorFromDiskQueue(err)
+		}
+		if b.Length() == 0 {
+			break
+		}
+	}
+	s.numPartitions++
+	// We are now done with the merger, so we can release the memory used for
+	// the output batches (all of which have been enqueued into the new
+	// partition).
+	s.outputUnlimitedAllocator.ReleaseMemory(s.outputUnlimitedAllocator.Used())
+	// The merger might have not exhausted the previous partition, so we close
+	// its read file descriptor, and then we reclaim its disk space.
+	if err := s.partitioner.CloseAllOpenReadFileDescriptors(); err != nil {
+		colexecerror.InternalError(err)
+	}
+	if err := s.partitioner.CloseInactiveReadPartitions(ctx); err != nil {
+		colexecerror.InternalError(err)
+	}
+	if s.spilled == nil {
+		s.spilled = newPartitionerToOperator(s.mergeUnlimitedAllocator, s.inputTypes, s.partitioner)
+	}
+	s.spilled.partitioner = s.partitioner
+	s.spilled.partitionIdx = partitionIdx
+	// The chunk has been merged, so we reset the in-memory sorter (which will
+	// do the "shallow" reset of inputPartitioningOperator).
+	s.inMemSorterInput.interceptReset = true
+	s.inMemSorter.Reset(ctx)
+}
+
+func (s *externalTopKSorter) Reset(ctx context.Context) {
+	if r, ok := s.Input.(colexecop.Resetter); ok {
+		r.Reset(ctx)
+	}
+	s.state = externalTopKSorterNewChunk
+	if err := s.Close(ctx); err != nil {
+		colexecerror.InternalError(err)
+	}
+	// Reset closed so that the sorter may be closed again.
+	s.Closed = false
+	s.numPartitions = 0
+	s.emitter = nil
+}
+
+func (s *externalTopKSorter) Close(ctx context.Context) error {
+	if !s.CloserHelper.Close() {
+		return nil
+	}
+	var lastErr error
+	if s.partitioner != nil {
+		lastErr = s.partitioner.Close(ctx)
+		s.partitioner = nil
+	}
+	if err := s.inMemSorterInput.Close(ctx); err != nil {
+		lastErr = err
+	}
+	if !s.testingKnobs.delegateFDAcquisitions && s.fdState.fdSemaphore != nil && s.fdState.acquiredFDs > 0 {
+		s.fdState.fdSemaphore.Release(s.fdState.acquiredFDs)
+		s.fdState.acquiredFDs = 0
+	}
+	return lastErr
+}
+
+// alreadyInitializedOperator is a pass-through operator whose input has
+// already been initialized, so Init is a noop.
+type alreadyInitializedOperator struct {
+	colexecop.OneInputNode
+	colexecop.NonExplainable
+}
+
+var _ colexecop.Operator = &alreadyInitializedOperator{}
+
+func (o *alreadyInitializedOperator) Init() {}
+
+func (o *alreadyInitializedOperator) Next(ctx context.Context) coldata.Batch {
+	return o.Input.Next(ctx)
+}
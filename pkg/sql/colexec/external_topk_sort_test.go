@@ -0,0 +1,122 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/testutils/colcontainerutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/marusama/semaphore"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExternalTopKSort verifies that the top K sorter which spills to disk
+// returns the first K tuples in sorted order when the input is divided into
+// many chunks, and that it never uses more than externalTopKSorterNumFDs file
+// descriptors at once.
+func TestExternalTopKSort(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+		DiskMonitor: testDiskMonitor,
+	}
+	rng, _ := randutil.NewPseudoRand()
+	nTups := coldata.BatchSize()*4 + 1
+	typs := []*types.T{types.Int, types.Int}
+	queueCfg, cleanup := colcontainerutils.NewTestingDiskQueueCfg(t, true /* inMem */)
+	defer cleanup()
+
+	var (
+		accounts []*mon.BoundAccount
+		monitors []*mon.BytesMonitor
+	)
+	// The lowest possible memory limit forces the spilling and results in the
+	// chunks consisting of a single batch, and the larger one allows the
+	// in-memory top K sorter to spool several batches of every chunk (it
+	// spills only if K is large enough).
+	const forcedSpillMemoryLimit = 2
+	memoryToSort := (nTups / coldata.BatchSize()) * colmem.EstimateBatchSizeBytes(typs, coldata.BatchSize())
+	chunkSize := int64(memoryToSort/4) + int64(externalTopKSorterNumFDs*queueCfg.BufferSizeBytes)
+	for _, memoryLimit := range []int64{forcedSpillMemoryLimit, chunkSize} {
+		flowCtx.Cfg.TestingKnobs.MemoryLimitBytes = memoryLimit
+		for _, k := range []uint64{1, uint64(coldata.BatchSize()), uint64(nTups - 1), uint64(nTups), uint64(nTups + 1)} {
+			delegateFDAcquisition := rng.Float64() < 0.5
+			log.Infof(ctx, "memoryLimit=%d/k=%d/delegateFDAcquisition=%t", memoryLimit, k, delegateFDAcquisition)
+			tups, expected, ordCols := generateRandomDataForTestSort(rng, nTups, len(typs), 1+rng.Intn(len(typs)))
+			if k < uint64(len(expected)) {
+				expected = expected[:k]
+			}
+			var (
+				semsToCheck []semaphore.Semaphore
+				toClose     []colexecop.Closer
+				spilled     bool
+			)
+			colexectestutils.RunTests(
+				t,
+				testAllocator,
+				[]colexectestutils.Tuples{tups},
+				expected,
+				colexectestutils.OrderedVerifier,
+				func(input []colexecop.Operator) (colexecop.Operator, error) {
+					sem := colexecop.NewTestingSemaphore(externalTopKSorterNumFDs)
+					semsToCheck = append(semsToCheck, sem)
+					sorter, newAccounts, newMonitors, closers, err := createDiskBackedSorter(
+						ctx, flowCtx, input, typs, ordCols, 0 /* matchLen */, k,
+						func() { spilled = true }, 0 /* numForcedRepartitions */, delegateFDAcquisition,
+						queueCfg, sem,
+					)
+					require.Equal(t, 1, len(closers))
+					toClose = append(toClose, closers...)
+					accounts = append(accounts, newAccounts...)
+					monitors = append(monitors, newMonitors...)
+					return sorter, err
+				})
+			if memoryLimit == forcedSpillMemoryLimit {
+				require.True(t, spilled)
+			}
+			// The sorter is not drained when the limit is satisfied, so we
+			// close it explicitly (which a flow does on cleanup).
+			for _, c := range toClose {
+				require.NoError(t, c.Close(ctx))
+			}
+			for i, sem := range semsToCheck {
+				require.Equal(t, 0, sem.GetCount(), "sem still reports open FDs at index %d", i)
+			}
+		}
+	}
+	for _, acc := range accounts {
+		acc.Close(ctx)
+	}
+	for _, m := range monitors {
+		m.Stop(ctx)
+	}
+}
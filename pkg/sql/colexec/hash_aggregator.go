@@ -89,9 +89,17 @@ type hashAggregator struct {
 	}
 
 	// buckets contains all aggregation groups that we have so far. There is
-	// 1-to-1 mapping between buckets[i] and ht.Vals[i]. Once the output from
-	// the buckets has been flushed, buckets will be sliced up accordingly.
+	// 1-to-1 mapping between buckets[i] and ht.Vals[i].
 	buckets []*aggBucket
+	// curOutputBucketIdx is the index of the first bucket in buckets that
+	// hasn't been flushed yet in hashAggregatorOutputting state.
+	curOutputBucketIdx int
+	// numPreviouslyCreatedBuckets is the number of buckets that have been
+	// created before the last Reset(). The buckets in
+	// buckets[len(buckets):numPreviouslyCreatedBuckets] are no longer used,
+	// and they are reset and reused for new groups instead of allocating new
+	// buckets.
+	numPreviouslyCreatedBuckets int
 	// ht stores tuples that are "heads" of the corresponding aggregation
 	// groups ("head" here means the tuple that was first seen from the group).
 	ht *colexechash.HashTable
@@ -272,29 +280,30 @@ func (op *hashAggregator) Next(ctx context.Context) coldata.Batch {
 
 		case hashAggregatorOutputting:
 			// Note that ResetMaybeReallocate truncates the requested capacity
-			// at coldata.BatchSize(), so we can just try asking for
-			// len(op.buckets) capacity. Note that in hashAggregatorOutputting
-			// state we always have at least 1 bucket.
+			// at coldata.BatchSize(), so we can just try asking for the number
+			// of remaining buckets capacity. Note that in
+			// hashAggregatorOutputting state we always have at least 1 bucket
+			// to flush.
 			//
 			// For now, we don't enforce any footprint-based memory limit.
 			// TODO(yuzefovich): refactor this.
 			const maxBatchMemSize = math.MaxInt64
 			op.output, _ = op.allocator.ResetMaybeReallocate(
-				op.outputTypes, op.output, len(op.buckets), maxBatchMemSize,
+				op.outputTypes, op.output, len(op.buckets)-op.curOutputBucketIdx, maxBatchMemSize,
 			)
 			curOutputIdx := 0
 			op.allocator.PerformOperation(op.output.ColVecs(), func() {
-				for curOutputIdx < op.output.Capacity() && curOutputIdx < len(op.buckets) {
-					bucket := op.buckets[curOutputIdx]
+				for curOutputIdx < op.output.Capacity() && op.curOutputBucketIdx < len(op.buckets) {
+					bucket := op.buckets[op.curOutputBucketIdx]
 					for fnIdx, fn := range bucket.fns {
 						fn.SetOutput(op.output.ColVec(fnIdx))
 						fn.Flush(curOutputIdx)
 					}
 					curOutputIdx++
+					op.curOutputBucketIdx++
 				}
 			})
-			op.buckets = op.buckets[curOutputIdx:]
-			if len(op.buckets) == 0 {
+			if op.curOutputBucketIdx == len(op.buckets) {
 				op.state = hashAggregatorDone
 			}
 			op.output.SetLength(curOutputIdx)
@@ -440,19 +449,25 @@ func (op *hashAggregator) onlineAgg(ctx context.Context, b coldata.Batch) {
 			// so we'll create a new bucket and make sure that the head of this
 			// equality chain is appended to the hash table in the
 			// corresponding position.
-			// TODO(yuzefovich): we could change the behavior so that we don't
-			// lose the references to the old buckets in the outputting stage,
-			// and then we could reset an old bucket if we still have some
-			// unused ones instead of always allocating a new bucket here. This
-			// will be beneficial for the external hash aggregator (but probably
-			// the difference won't be that big).
-			bucket := op.hashAlloc.newAggBucket()
-			op.buckets = append(op.buckets, bucket)
-			// We know that all selected tuples belong to the same single
-			// group, so we can pass 'nil' for the 'groups' argument.
-			bucket.init(
-				op.aggFnsAlloc.MakeAggregateFuncs(), op.aggHelper.makeSeenMaps(), nil, /* groups */
-			)
+			var bucket *aggBucket
+			if numBuckets := len(op.buckets); numBuckets < op.numPreviouslyCreatedBuckets {
+				// We still have a bucket created before the last Reset()
+				// that is no longer used, so we reuse it instead of
+				// allocating a new one. This is beneficial for the external
+				// hash aggregator and the partially ordered aggregator which
+				// reset the hash aggregator many times.
+				op.buckets = op.buckets[:numBuckets+1]
+				bucket = op.buckets[numBuckets]
+				bucket.reset()
+			} else {
+				bucket = op.hashAlloc.newAggBucket()
+				op.buckets = append(op.buckets, bucket)
+				// We know that all selected tuples belong to the same single
+				// group, so we can pass 'nil' for the 'groups' argument.
+				bucket.init(
+					op.aggFnsAlloc.MakeAggregateFuncs(), op.aggHelper.makeSeenMaps(), nil, /* groups */
+				)
+			}
 			op.aggHelper.performAggregation(
 				ctx, inputVecs, len(eqChain), eqChain, bucket, nil, /* groups */
 			)
@@ -495,12 +510,17 @@ func (op *hashAggregator) Reset(ctx context.Context) {
 	op.bufferingState.tuples.ResetInternalBatch()
 	op.bufferingState.pendingBatch = nil
 	op.bufferingState.unprocessedIdx = 0
+	if numBuckets := len(op.buckets); numBuckets > op.numPreviouslyCreatedBuckets {
+		op.numPreviouslyCreatedBuckets = numBuckets
+	}
 	op.buckets = op.buckets[:0]
+	op.curOutputBucketIdx = 0
 	op.ht.Reset(ctx)
 	if op.inputTrackingState.tuples != nil {
-		if err := op.inputTrackingState.tuples.Close(ctx); err != nil {
-			colexecerror.InternalError(err)
-		}
+		// Note that we reset rather than close the spilling queue since we
+		// might be reused (e.g. by the partially ordered aggregator) and need
+		// to track the input tuples again.
+		op.inputTrackingState.tuples.Reset(ctx)
 		op.inputTrackingState.zeroBatchEnqueued = false
 	}
 	op.state = hashAggregatorBuffering
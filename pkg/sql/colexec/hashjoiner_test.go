@@ -1183,3 +1183,61 @@ func TestHashJoinerProjection(t *testing.T) {
 		b.ColVec(5).Bytes()
 	}
 }
+
+// TestHashJoinerLeftSemiAntiStreaming verifies that LEFT SEMI and LEFT ANTI
+// joins emit the probe batches themselves, with the selection vector narrowed
+// down to the output tuples, instead of copying the probe tuples into a
+// separate output batch.
+func TestHashJoinerLeftSemiAntiStreaming(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	typs := []*types.T{types.Int}
+	const n = 8
+	// The right side contains all even numbers in [0, 2*n).
+	rightBatch := testAllocator.NewMemBatchWithMaxCapacity(typs)
+	rightCol := rightBatch.ColVec(0).Int64()
+	for i := 0; i < n; i++ {
+		rightCol[i] = int64(2 * i)
+	}
+	rightBatch.SetLength(n)
+
+	for _, tc := range []struct {
+		joinType    descpb.JoinType
+		expectedSel []int
+	}{
+		{joinType: descpb.LeftSemiJoin, expectedSel: []int{2, 4}},
+		{joinType: descpb.LeftAntiJoin, expectedSel: []int{1, 3}},
+	} {
+		t.Run(tc.joinType.String(), func(t *testing.T) {
+			// The left side contains all numbers in [0, n), but only the
+			// numbers in [1, 4] are selected.
+			leftBatch := testAllocator.NewMemBatchWithMaxCapacity(typs)
+			leftCol := leftBatch.ColVec(0).Int64()
+			for i := 0; i < n; i++ {
+				leftCol[i] = int64(i)
+			}
+			leftBatch.SetSelection(true)
+			copy(leftBatch.Selection(), []int{1, 2, 3, 4})
+			leftBatch.SetLength(4)
+			leftSource := colexecop.NewBatchBuffer()
+			leftSource.Add(leftBatch, typs)
+			leftSource.Add(coldata.ZeroBatch, typs)
+			rightSource := colexectestutils.NewFiniteBatchSource(testAllocator, rightBatch, typs, 1 /* usableCount */)
+
+			hjSpec := colexecjoin.MakeHashJoinerSpec(
+				tc.joinType, []uint32{0}, []uint32{0}, typs, typs, true, /* rightDistinct */
+			)
+			hj := colexecjoin.NewHashJoiner(
+				testAllocator, testAllocator, hjSpec, leftSource, rightSource,
+				colexecjoin.HashJoinerInitialNumBuckets, colexecop.DefaultMemoryLimit,
+			)
+			hj.Init()
+			b := hj.Next(ctx)
+			require.Same(t, leftBatch, b)
+			require.Equal(t, tc.expectedSel, b.Selection()[:b.Length()])
+			require.Zero(t, hj.Next(ctx).Length())
+		})
+	}
+}
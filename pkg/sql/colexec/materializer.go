@@ -13,26 +13,51 @@ package colexec
 import (
 	"context"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/server/telemetry"
 	"github.com/cockroachdb/cockroach/pkg/sql/colconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqltelemetry"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
 	"github.com/cockroachdb/errors"
+	"github.com/petermattis/goid"
 )
 
 // Materializer converts an Operator input into a execinfra.RowSource.
+//
+// The Materializer (as well as its input tree) is not safe for concurrent use,
+// and it is confined to the goroutine which first uses it. It can be handed off
+// to a different goroutine (e.g. started and partially consumed by a worker,
+// and then drained by the connection goroutine) by calling HandOff on the
+// current goroutine and then passing the Materializer to the new goroutine
+// with a synchronization primitive that establishes a happens-before edge
+// (e.g. a channel). If the CheckMaterializerGoroutineConfinement testing knob
+// is set, the confinement is enforced, and using the Materializer on a
+// goroutine that doesn't own it results in an assertion failure that is
+// returned as metadata.
 type Materializer struct {
 	execinfra.ProcessorBase
 	colexecop.NonExplainable
 
 	input colexecop.Operator
-	typs  []*types.T
+	// typs are the types of the output rows.
+	typs []*types.T
+	// outputCols, if non-nil, contains the ordinals of the input columns that
+	// make up the output rows. If nil, all input columns are output.
+	outputCols  []uint32
+	processorID int32
 
 	drainHelper *drainHelper
 
@@ -55,6 +80,17 @@ type Materializer struct {
 	// adapter.
 	outputRow rowenc.EncDatumRow
 
+	// rows are the rows returned by NextRows, and rowsAlloc contains their
+	// EncDatums.
+	rows      rowenc.EncDatumRows
+	rowsAlloc []rowenc.EncDatum
+	// outputRows stores the returned results of nextRows() to be passed
+	// through an adapter.
+	outputRows rowenc.EncDatumRows
+	// outputBatch stores the returned results of nextInputBatch() to be passed
+	// through an adapter.
+	outputBatch coldata.Batch
+
 	// cancelFlow will return a function to cancel the context of the flow. It is
 	// a function in order to be lazily evaluated, since the context cancellation
 	// function is only available when Starting. This function differs from
@@ -64,20 +100,75 @@ type Materializer struct {
 
 	// closers is a slice of Closers that should be Closed on termination.
 	closers colexecop.Closers
+
+	// cancelChecker is used to periodically check whether the query has been
+	// canceled while the rows are emitted, so that the Materializer responds
+	// promptly even if the input produces huge batches.
+	cancelChecker colexecutils.CancelChecker
+
+	// checkConfinement indicates whether the goroutine confinement of the
+	// Materializer is enforced.
+	checkConfinement bool
+	// owner is the ID of the goroutine which is currently allowed to use the
+	// Materializer, or 0 if no goroutine has used it since it was created or
+	// last handed off. It is only maintained if checkConfinement is true and
+	// must be accessed atomically.
+	owner int64
+
+	// collectStats indicates whether the Materializer collects the execution
+	// statistics below, which are reported by getStats.
+	collectStats bool
+	// numBatches is the number of non-empty batches consumed from the input.
+	numBatches uint64
+	// numRows is the number of rows materialized from the batches.
+	numRows uint64
+	// conversionTime is the time spent converting the batches to rows.
+	conversionTime time.Duration
+	// maxBatchMemSize is the largest memory footprint of a consumed batch.
+	maxBatchMemSize int64
 }
 
+// MetadataDrainPhase determines when the Materializer drains a metadata
+// source relative to its other metadata sources and inputs to drain.
+type MetadataDrainPhase int
+
+const (
+	// MetadataDrainPhaseFirst sources are drained as soon as the Materializer
+	// starts draining, before any other metadata sources and inputs to drain.
+	// It should be used for sources whose metadata (e.g. errors) should be
+	// forwarded promptly.
+	MetadataDrainPhaseFirst MetadataDrainPhase = iota
+	// MetadataDrainPhaseDefault sources are drained right after the sources
+	// of MetadataDrainPhaseFirst. The metadata sources passed to
+	// NewMaterializer are in this phase.
+	MetadataDrainPhaseDefault
+	// MetadataDrainPhaseLast sources are drained after all other metadata
+	// sources and inputs to drain of the Materializer. It should be used for
+	// sources whose metadata (e.g. execution statistics) is complete only once
+	// everything else has been drained.
+	MetadataDrainPhaseLast
+
+	numMetadataDrainPhases
+)
+
 // drainHelper is a utility struct that wraps MetadataSources in a RowSource
 // interface. This is done so that the Materializer can drain MetadataSources
 // in the vectorized input tree as inputs, rather than draining them in the
 // trailing metadata state, which is meant only for internal metadata
 // generation.
+//
+// The drainHelper itself only drains the sources up to
+// MetadataDrainPhaseDefault, and the sources of MetadataDrainPhaseLast are
+// drained by lastPhaseDrainHelper.
 type drainHelper struct {
 	// If unset, the drainHelper wasn't Start()'ed, so all operations on it
 	// are noops.
 	ctx context.Context
 
 	getStats func() []*execinfrapb.ComponentStats
-	sources  execinfrapb.MetadataSources
+	sources  [numMetadataDrainPhases]execinfrapb.MetadataSources
+	// nextPhase is the first phase whose sources haven't been drained yet.
+	nextPhase MetadataDrainPhase
 
 	bufferedMeta []execinfrapb.ProducerMetadata
 }
@@ -96,10 +187,18 @@ func newDrainHelper(
 ) *drainHelper {
 	d := drainHelperPool.Get().(*drainHelper)
 	d.getStats = getStats
-	d.sources = sources
+	d.sources[MetadataDrainPhaseDefault] = sources
 	return d
 }
 
+// addSource adds a metadata source to be drained in the given phase.
+func (d *drainHelper) addSource(src execinfrapb.MetadataSource, phase MetadataDrainPhase) {
+	sources := d.sources[phase]
+	// Limit the capacity so that we don't modify the slice of sources passed
+	// to newDrainHelper.
+	d.sources[phase] = append(sources[:len(sources):len(sources)], src)
+}
+
 // OutputTypes implements the RowSource interface.
 func (d *drainHelper) OutputTypes() []*types.T {
 	colexecerror.InternalError(errors.AssertionFailedf("unimplemented"))
@@ -114,23 +213,28 @@ func (d *drainHelper) Start(ctx context.Context) {
 
 // Next implements the RowSource interface.
 func (d *drainHelper) Next() (rowenc.EncDatumRow, *execinfrapb.ProducerMetadata) {
+	return nil, d.nextMeta(MetadataDrainPhaseDefault)
+}
+
+// nextMeta returns the next metadata object from the sources of all phases up
+// to and including lastPhase, draining the sources of each phase only once
+// all of the metadata of the previous phases has been returned. nil is
+// returned once all of these sources have been drained.
+func (d *drainHelper) nextMeta(lastPhase MetadataDrainPhase) *execinfrapb.ProducerMetadata {
 	if d.ctx == nil {
 		// The drainHelper wasn't Start()'ed, so this operation is a noop.
-		return nil, nil
+		return nil
 	}
-	if d.bufferedMeta == nil {
-		d.bufferedMeta = d.sources.DrainMeta(d.ctx)
-		if d.bufferedMeta == nil {
-			// Still nil, avoid more calls to DrainMeta.
-			d.bufferedMeta = []execinfrapb.ProducerMetadata{}
+	for len(d.bufferedMeta) == 0 {
+		if d.nextPhase > lastPhase {
+			return nil
 		}
-	}
-	if len(d.bufferedMeta) == 0 {
-		return nil, nil
+		d.bufferedMeta = d.sources[d.nextPhase].DrainMeta(d.ctx)
+		d.nextPhase++
 	}
 	meta := d.bufferedMeta[0]
 	d.bufferedMeta = d.bufferedMeta[1:]
-	return nil, &meta
+	return &meta
 }
 
 // ConsumerDone implements the RowSource interface.
@@ -163,6 +267,34 @@ func (d *drainHelper) Release() {
 	drainHelperPool.Put(d)
 }
 
+// lastPhaseDrainHelper is a RowSource that drains the sources of
+// MetadataDrainPhaseLast of the wrapped drainHelper. It is added as the last
+// input to drain of the Materializer.
+type lastPhaseDrainHelper struct {
+	d *drainHelper
+}
+
+var _ execinfra.RowSource = lastPhaseDrainHelper{}
+
+// OutputTypes implements the RowSource interface.
+func (l lastPhaseDrainHelper) OutputTypes() []*types.T {
+	return l.d.OutputTypes()
+}
+
+// Start implements the RowSource interface.
+func (l lastPhaseDrainHelper) Start(context.Context) {}
+
+// Next implements the RowSource interface.
+func (l lastPhaseDrainHelper) Next() (rowenc.EncDatumRow, *execinfrapb.ProducerMetadata) {
+	return nil, l.d.nextMeta(MetadataDrainPhaseLast)
+}
+
+// ConsumerDone implements the RowSource interface.
+func (l lastPhaseDrainHelper) ConsumerDone() {}
+
+// ConsumerClosed implements the RowSource interface.
+func (l lastPhaseDrainHelper) ConsumerClosed() {}
+
 const materializerProcName = "materializer"
 
 var materializerPool = sync.Pool{
@@ -178,18 +310,110 @@ var materializerPool = sync.Pool{
 // post-processing spec object itself), so it is thread-safe.
 var materializerEmptyPostProcessSpec = &execinfrapb.PostProcessSpec{}
 
+// materializerOptions are the optional arguments of NewMaterializer.
+type materializerOptions struct {
+	output          execinfra.RowReceiver
+	getStats        func() []*execinfrapb.ComponentStats
+	noStats         bool
+	metadataSources []execinfrapb.MetadataSource
+	toClose         []colexecop.Closer
+	cancelFlow      func() context.CancelFunc
+	aliasDatums     bool
+	numInputCols    int
+	outputCols      []uint32
+}
+
+// MaterializerOption is an option on NewMaterializer.
+type MaterializerOption func(*materializerOptions)
+
+// WithOutput makes the Materializer push its rows to output. Without it, the
+// rows have to be pulled with Next.
+func WithOutput(output execinfra.RowReceiver) MaterializerOption {
+	return func(o *materializerOptions) {
+		o.output = output
+	}
+}
+
+// WithStats makes the Materializer emit the execution statistics returned by
+// getStats (when tracing is enabled) of the operators which the Materializer
+// is responsible for, merged with its own execution statistics if the flow
+// collects them (see Materializer.mergeStats). Without it, the Materializer
+// only emits its own execution statistics, see Materializer.getStats.
+func WithStats(getStats func() []*execinfrapb.ComponentStats) MaterializerOption {
+	return func(o *materializerOptions) {
+		o.getStats = getStats
+	}
+}
+
+// WithoutStats makes the Materializer not emit any execution statistics, even
+// if the flow collects them. It is ignored if WithStats is also passed.
+func WithoutStats() MaterializerOption {
+	return func(o *materializerOptions) {
+		o.noStats = true
+	}
+}
+
+// WithMetadataSources makes the Materializer drain metadataSources, which are
+// all of the metadata sources that are planned on the same node as the
+// Materializer. They are drained in MetadataDrainPhaseDefault (see
+// AddMetadataSource for the other phases).
+func WithMetadataSources(metadataSources []execinfrapb.MetadataSource) MaterializerOption {
+	return func(o *materializerOptions) {
+		o.metadataSources = metadataSources
+	}
+}
+
+// WithClosers makes the Materializer close toClose when it is closed.
+func WithClosers(toClose []colexecop.Closer) MaterializerOption {
+	return func(o *materializerOptions) {
+		o.toClose = toClose
+	}
+}
+
+// WithCancelFlow makes the Materializer cancel the context of the flow with
+// the function returned by cancelFlow (i.e. it is Flow.ctxCancel) when it is
+// closed. It should only be used for a root Materializer (i.e. not when we're
+// wrapping a row source).
+func WithCancelFlow(cancelFlow func() context.CancelFunc) MaterializerOption {
+	return func(o *materializerOptions) {
+		o.cancelFlow = cancelFlow
+	}
+}
+
+// WithDatumAliasing makes the Materializer construct the INT8 and FLOAT datums
+// of its rows so that they point into the vectors of the input batches instead
+// of allocating them. Since the input can modify the vectors once it produces
+// its next batch, the datums are only valid until the next call to Next or
+// NextRows, so the consumer must copy the datums (and not only the rows) that
+// it retains. The consumer must also not modify the datums, since that would
+// modify the vectors of the input, which is checked in test builds. It is
+// ignored if the Materializer pushes its rows to an output, since
+// RowReceivers can retain the datums.
+func WithDatumAliasing() MaterializerOption {
+	return func(o *materializerOptions) {
+		o.aliasDatums = true
+	}
+}
+
+// WithOutputColumns makes the Materializer output only the columns with the
+// given ordinals (in the given order) of the input batches, which have
+// numInputCols columns, so that only the needed vectors are converted to
+// datums when the consumer doesn't need the full width of the batches (for
+// example, instead of a simple projection at the root of the input). The
+// ordinals can repeat.
+func WithOutputColumns(numInputCols int, outputCols []uint32) MaterializerOption {
+	return func(o *materializerOptions) {
+		o.numInputCols = numInputCols
+		o.outputCols = outputCols
+	}
+}
+
 // NewMaterializer creates a new Materializer processor which processes the
-// columnar data coming from input to return it as rows.
-// Arguments:
-// - typs is the output types scheme.
-// - getStats (when tracing is enabled) returns all of the execution statistics
-// of operators which the materializer is responsible for.
-// - metadataSources are all of the metadata sources that are planned on the
-// same node as the Materializer and that need to be drained.
-// - cancelFlow should return the context cancellation function that cancels
-// the context of the flow (i.e. it is Flow.ctxCancel). It should only be
-// non-nil in case of a root Materializer (i.e. not when we're wrapping a row
-// source).
+// columnar data coming from input to return it as rows. typs is the output
+// types scheme. The other arguments are optional, see MaterializerOption.
+// The Materializer, its row buffer and the DatumAlloc of its conversions are
+// taken from a pool, and they are returned to it by Release, so short flows
+// don't have to allocate them.
 // NOTE: the constructor does *not* take in an execinfrapb.PostProcessSpec
 // because we expect input to handle that for us.
 func NewMaterializer(
@@ -197,22 +421,71 @@ func NewMaterializer(
 	processorID int32,
 	input colexecop.Operator,
 	typs []*types.T,
-	output execinfra.RowReceiver,
-	getStats func() []*execinfrapb.ComponentStats,
-	metadataSources []execinfrapb.MetadataSource,
-	toClose []colexecop.Closer,
-	cancelFlow func() context.CancelFunc,
+	opts ...MaterializerOption,
 ) (*Materializer, error) {
+	var o materializerOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	var converter *colconv.VecToDatumConverter
+	if o.outputCols != nil {
+		if len(o.outputCols) != len(typs) {
+			return nil, errors.AssertionFailedf(
+				"%d output columns are requested, but %d output types are given", len(o.outputCols), len(typs),
+			)
+		}
+		// Only convert the vectors that are needed, each of them once.
+		vecIdxsToConvert := make([]int, 0, len(o.outputCols))
+		var seen util.FastIntSet
+		for _, colIdx := range o.outputCols {
+			if int(colIdx) >= o.numInputCols {
+				return nil, errors.AssertionFailedf(
+					"output column %d is out of range for %d input columns", colIdx, o.numInputCols,
+				)
+			}
+			if !seen.Contains(int(colIdx)) {
+				seen.Add(int(colIdx))
+				vecIdxsToConvert = append(vecIdxsToConvert, int(colIdx))
+			}
+		}
+		converter = colconv.NewVecToDatumConverter(o.numInputCols, vecIdxsToConvert)
+	} else {
+		converter = colconv.NewAllVecToDatumConverter(len(typs))
+	}
 	m := materializerPool.Get().(*Materializer)
+	row := m.row
+	if cap(row) < len(typs) {
+		row = make(rowenc.EncDatumRow, len(typs))
+	} else {
+		row = row[:len(typs)]
+		for i := range row {
+			row[i] = rowenc.EncDatum{}
+		}
+	}
 	*m = Materializer{
 		ProcessorBase: m.ProcessorBase,
 		input:         input,
 		typs:          typs,
-		drainHelper:   newDrainHelper(getStats, metadataSources),
-		converter:     colconv.NewAllVecToDatumConverter(len(typs)),
-		row:           make(rowenc.EncDatumRow, len(typs)),
-		closers:       toClose,
+		outputCols:    o.outputCols,
+		processorID:   processorID,
+		converter:     converter,
+		row:           row,
+		closers:       o.toClose,
+	}
+	getStats := o.getStats
+	if flowCtx.CollectStats && (getStats != nil || !o.noStats) {
+		m.collectStats = true
+		if operatorStats := getStats; operatorStats != nil {
+			getStats = func() []*execinfrapb.ComponentStats {
+				return m.mergeStats(operatorStats())
+			}
+		} else {
+			getStats = m.getStats
+		}
 	}
+	m.drainHelper = newDrainHelper(getStats, o.metadataSources)
+
+	m.converter.SetAliasVecs(o.aliasDatums && o.output == nil)
 
 	if err := m.ProcessorBase.InitWithEvalCtx(
 		m,
@@ -225,7 +498,7 @@ func NewMaterializer(
 		// the one from the flow context.
 		flowCtx.EvalCtx,
 		processorID,
-		output,
+		o.output,
 		nil, /* memMonitor */
 		execinfra.ProcStateOpts{
 			// We append drainHelper to inputs to drain below in order to reuse
@@ -241,13 +514,17 @@ func NewMaterializer(
 		return nil, err
 	}
 	m.AddInputToDrain(m.drainHelper)
-	m.cancelFlow = cancelFlow
+	m.cancelFlow = o.cancelFlow
+	if flowCtx.Cfg != nil {
+		m.checkConfinement = flowCtx.Cfg.TestingKnobs.CheckMaterializerGoroutineConfinement
+	}
 	return m, nil
 }
 
 var _ execinfra.OpNode = &Materializer{}
 var _ execinfra.Processor = &Materializer{}
 var _ execinfra.Releasable = &Materializer{}
+var _ execinfra.HandOffer = &Materializer{}
 
 // ChildCount is part of the exec.OpNode interface.
 func (m *Materializer) ChildCount(verbose bool) int {
@@ -264,13 +541,81 @@ func (m *Materializer) Child(nth int, verbose bool) execinfra.OpNode {
 	return nil
 }
 
+// AddMetadataSource registers an additional metadata source which is drained
+// in the given phase. It must be called before the Materializer is started.
+func (m *Materializer) AddMetadataSource(
+	src execinfrapb.MetadataSource, phase MetadataDrainPhase,
+) {
+	m.drainHelper.addSource(src, phase)
+}
+
+// HandOff is part of the execinfra.HandOffer interface.
+func (m *Materializer) HandOff() {
+	if m.checkConfinement {
+		m.enforceConfinement()
+		atomic.StoreInt64(&m.owner, 0)
+	}
+}
+
+// checkOwner returns an error if the Materializer is used by a goroutine other
+// than the one which owns it, claiming the ownership if the Materializer is not
+// currently owned. The ownership is claimed by the current goroutine also when
+// the error is returned, so that the same violation is reported only once
+// (otherwise, draining the Materializer would never finish). It is a noop
+// unless checkConfinement is true.
+func (m *Materializer) checkOwner() error {
+	if !m.checkConfinement {
+		return nil
+	}
+	id := goid.Get()
+	if atomic.CompareAndSwapInt64(&m.owner, 0, id) {
+		return nil
+	}
+	if owner := atomic.SwapInt64(&m.owner, id); owner != id {
+		return errors.AssertionFailedf(
+			"materializer used on goroutine %d while owned by goroutine %d, HandOff must be called first",
+			id, owner,
+		)
+	}
+	return nil
+}
+
+// enforceConfinement checks the goroutine confinement of the Materializer. Note
+// that it is called outside of the panic-catcher, so the violation is reported
+// to the consumer as metadata: the Materializer is moved to draining with the
+// error if it is still running, and the error is appended to the trailing
+// metadata otherwise.
+func (m *Materializer) enforceConfinement() {
+	if err := m.checkOwner(); err != nil {
+		if m.State == execinfra.StateRunning {
+			m.MoveToDraining(err)
+		} else {
+			m.AppendTrailingMeta(execinfrapb.ProducerMetadata{Err: err})
+		}
+	}
+}
+
 // Start is part of the execinfra.RowSource interface.
 func (m *Materializer) Start(ctx context.Context) {
+	ownerErr := m.checkOwner()
+	if len(m.drainHelper.sources[MetadataDrainPhaseLast]) > 0 {
+		// The sources of the last phase are drained after all other inputs to
+		// drain, which might have been added after the construction of the
+		// Materializer.
+		m.AddInputToDrain(lastPhaseDrainHelper{d: m.drainHelper})
+	}
 	ctx = m.ProcessorBase.StartInternal(ctx, materializerProcName)
+	if ownerErr != nil {
+		// Neither the input nor the drain helper are initialized, same as
+		// when the initialization fails below.
+		m.MoveToDraining(ownerErr)
+		return
+	}
 	// We can encounter an expected error during Init (e.g. an operator
 	// attempts to allocate a batch, but the memory budget limit has been
 	// reached), so we need to wrap it with a catcher.
 	if err := colexecerror.CatchVectorizedRuntimeError(m.input.Init); err != nil {
+		countVectorizedError(err)
 		m.MoveToDraining(err)
 	} else {
 		// Note that we intentionally only start the drain helper if
@@ -281,27 +626,73 @@ func (m *Materializer) Start(ctx context.Context) {
 	}
 }
 
+// countVectorizedError increments the telemetry counter corresponding to the
+// category of the error caught by the Materializer (the expected errors are not
+// counted).
+func countVectorizedError(err error) {
+	switch colexecerror.Classify(err) {
+	case colexecerror.InternalErrorCategory:
+		telemetry.Inc(sqltelemetry.VecInternalErrorCounter)
+	case colexecerror.OutOfMemoryErrorCategory:
+		telemetry.Inc(sqltelemetry.VecOutOfMemoryErrorCounter)
+	}
+}
+
+// nextBatch gets a fresh batch from the input and converts it. false is
+// returned when a zero-length batch is encountered.
+func (m *Materializer) nextBatch() bool {
+	if util.CrdbTestBuild {
+		// The input can reuse the vectors of the previous batch, which the
+		// datums of the previous rows might alias.
+		m.converter.AssertAliasedDatumsUnchanged()
+	}
+	m.batch = m.input.Next(m.Ctx)
+	if m.batch.Length() == 0 {
+		return false
+	}
+	m.curIdx = 0
+	if !m.collectStats {
+		m.converter.ConvertBatchAndDeselect(m.batch)
+		return true
+	}
+	m.numBatches++
+	if memSize := colmem.GetBatchMemSize(m.batch); memSize > m.maxBatchMemSize {
+		m.maxBatchMemSize = memSize
+	}
+	start := timeutil.Now()
+	m.converter.ConvertBatchAndDeselect(m.batch)
+	m.conversionTime += timeutil.Since(start)
+	return true
+}
+
+// inputColIdx returns the ordinal of the input column that is output at
+// position outputIdx of the rows.
+func (m *Materializer) inputColIdx(outputIdx int) int {
+	if m.outputCols == nil {
+		return outputIdx
+	}
+	return int(m.outputCols[outputIdx])
+}
+
 // next is the logic of Next() extracted in a separate method to be used by an
 // adapter to be able to wrap the latter with a catcher. nil is returned when a
 // zero-length batch is encountered.
 func (m *Materializer) next() rowenc.EncDatumRow {
+	m.cancelChecker.Check(m.Ctx)
 	if m.batch == nil || m.curIdx >= m.batch.Length() {
-		// Get a fresh batch.
-		m.batch = m.input.Next(m.Ctx)
-		if m.batch.Length() == 0 {
+		if !m.nextBatch() {
 			return nil
 		}
-		m.curIdx = 0
-		m.converter.ConvertBatchAndDeselect(m.batch)
 	}
 
-	for colIdx := range m.typs {
+	for outputIdx := range m.typs {
 		// Note that we don't need to apply the selection vector of the
 		// batch to index m.curIdx because vecToDatumConverter returns a
 		// "dense" datum column.
-		m.row[colIdx].Datum = m.converter.GetDatumColumn(colIdx)[m.curIdx]
+		m.row[outputIdx].Datum = m.converter.GetDatumColumn(m.inputColIdx(outputIdx))[m.curIdx]
 	}
 	m.curIdx++
+	m.numRows++
 	// Note that there is no post-processing to be done in the
 	// materializer, so we do not use ProcessRowHelper and emit the row
 	// directly.
@@ -317,8 +708,10 @@ func (m *Materializer) nextAdapter() {
 
 // Next is part of the execinfra.RowSource interface.
 func (m *Materializer) Next() (rowenc.EncDatumRow, *execinfrapb.ProducerMetadata) {
+	m.enforceConfinement()
 	for m.State == execinfra.StateRunning {
 		if err := colexecerror.CatchVectorizedRuntimeError(m.nextAdapter); err != nil {
+			countVectorizedError(err)
 			m.MoveToDraining(err)
 			continue
 		}
@@ -333,6 +726,175 @@ func (m *Materializer) Next() (rowenc.EncDatumRow, *execinfrapb.ProducerMetadata
 	return nil, m.DrainHelper()
 }
 
+// nextRows is the logic of NextRows() extracted in a separate method to be
+// used by an adapter to be able to wrap the latter with a catcher. nil is
+// returned when a zero-length batch is encountered.
+func (m *Materializer) nextRows() rowenc.EncDatumRows {
+	// Every call returns up to a whole batch of rows, so we check for the
+	// cancellation on every call.
+	m.cancelChecker.CheckEveryCall(m.Ctx)
+	if m.batch == nil || m.curIdx >= m.batch.Length() {
+		if !m.nextBatch() {
+			return nil
+		}
+	}
+
+	numRows, width := m.batch.Length()-m.curIdx, len(m.typs)
+	if cap(m.rows) < numRows {
+		m.rows = make(rowenc.EncDatumRows, numRows)
+	} else {
+		m.rows = m.rows[:numRows]
+	}
+	if cap(m.rowsAlloc) < numRows*width {
+		m.rowsAlloc = make([]rowenc.EncDatum, numRows*width)
+	} else {
+		m.rowsAlloc = m.rowsAlloc[:numRows*width]
+	}
+	for outputIdx := range m.typs {
+		// Note that we don't need to apply the selection vector of the batch
+		// because vecToDatumConverter returns a "dense" datum column.
+		col := m.converter.GetDatumColumn(m.inputColIdx(outputIdx))[m.curIdx:]
+		for rowIdx := range m.rows {
+			m.rowsAlloc[rowIdx*width+outputIdx] = rowenc.EncDatum{Datum: col[rowIdx]}
+		}
+	}
+	for rowIdx := range m.rows {
+		m.rows[rowIdx] = m.rowsAlloc[rowIdx*width : (rowIdx+1)*width : (rowIdx+1)*width]
+	}
+	m.curIdx += numRows
+	m.numRows += uint64(numRows)
+	return m.rows
+}
+
+// nextRowsAdapter calls nextRows() and saves the returned results in m. For
+// internal use only. The purpose of having this function is to not create an
+// anonymous function on every call to NextRows().
+func (m *Materializer) nextRowsAdapter() {
+	m.outputRows = m.nextRows()
+}
+
+// NextRows is like Next, but it returns all of the remaining rows of the
+// current batch of the input at once, getting a new batch if necessary, which
+// saves the per-row overhead of Next for consumers that can process the rows
+// in bulk. It returns no rows and no metadata once the Materializer is
+// exhausted. The returned rows are only valid until the next call to Next or
+// NextRows.
+func (m *Materializer) NextRows() (rowenc.EncDatumRows, *execinfrapb.ProducerMetadata) {
+	m.enforceConfinement()
+	for m.State == execinfra.StateRunning {
+		if err := colexecerror.CatchVectorizedRuntimeError(m.nextRowsAdapter); err != nil {
+			countVectorizedError(err)
+			m.MoveToDraining(err)
+			continue
+		}
+		if m.outputRows == nil {
+			// Zero-length batch was encountered, move to draining.
+			m.MoveToDraining(nil /* err */)
+			continue
+		}
+		return m.outputRows, nil
+	}
+	// Forward any metadata.
+	return nil, m.DrainHelper()
+}
+
+// nextInputBatch is the logic of NextBatch() extracted in a separate method to
+// be used by an adapter to be able to wrap the latter with a catcher. nil is
+// returned when a zero-length batch is encountered.
+func (m *Materializer) nextInputBatch() coldata.Batch {
+	m.cancelChecker.CheckEveryCall(m.Ctx)
+	batch := m.input.Next(m.Ctx)
+	if batch.Length() == 0 {
+		return nil
+	}
+	if m.collectStats {
+		m.numBatches++
+		if memSize := colmem.GetBatchMemSize(batch); memSize > m.maxBatchMemSize {
+			m.maxBatchMemSize = memSize
+		}
+	}
+	m.numRows += uint64(batch.Length())
+	return batch
+}
+
+// nextBatchAdapter calls nextInputBatch() and saves the returned results in m.
+// For internal use only. The purpose of having this function is to not create
+// an anonymous function on every call to NextBatch().
+func (m *Materializer) nextBatchAdapter() {
+	m.outputBatch = m.nextInputBatch()
+}
+
+// NextBatch returns the next batch of the input as is (i.e. without converting
+// it to rows), for the consumers that can process the columnar data directly.
+// Note that the batch can have a selection vector. It returns no batch and no
+// metadata once the Materializer is exhausted. The returned batch is only
+// valid until the next call to NextBatch, and NextBatch cannot be mixed with
+// Next and NextRows. It cannot be used if WithOutputColumns was passed.
+func (m *Materializer) NextBatch() (coldata.Batch, *execinfrapb.ProducerMetadata) {
+	m.enforceConfinement()
+	if m.State == execinfra.StateRunning && m.outputCols != nil {
+		m.MoveToDraining(errors.AssertionFailedf(
+			"NextBatch is not supported by a Materializer with output columns",
+		))
+	}
+	for m.State == execinfra.StateRunning {
+		if err := colexecerror.CatchVectorizedRuntimeError(m.nextBatchAdapter); err != nil {
+			countVectorizedError(err)
+			m.MoveToDraining(err)
+			continue
+		}
+		if m.outputBatch == nil {
+			// Zero-length batch was encountered, move to draining.
+			m.MoveToDraining(nil /* err */)
+			continue
+		}
+		return m.outputBatch, nil
+	}
+	// Forward any metadata.
+	return nil, m.DrainHelper()
+}
+
+// getStats is the getStats function of the Materializer if none was passed
+// with WithStats. It reports the costs of the boundary between the vectorized
+// input and the row-by-row consumers: the number of batches consumed and rows
+// materialized as the output stats, and the time spent converting the batches
+// and the largest memory footprint of a batch as the execution stats.
+func (m *Materializer) getStats() []*execinfrapb.ComponentStats {
+	s := &execinfrapb.ComponentStats{Component: m.FlowCtx.ProcessorComponentID(m.processorID)}
+	s.Output.NumBatches.Set(m.numBatches)
+	s.Output.NumTuples.Set(m.numRows)
+	s.Exec.ExecTime.Set(m.conversionTime)
+	s.Exec.MaxAllocatedMem.Set(uint64(m.maxBatchMemSize))
+	return []*execinfrapb.ComponentStats{s}
+}
+
+// mergeStats merges the execution statistics of the Materializer into stats,
+// the statistics of the operators which the Materializer is responsible for.
+// The Materializer shares its processor ID with the operator whose output it
+// materializes, and statistics with the same component are merged keeping
+// only one of the values of every statistic, so the time spent converting
+// the batches is added to the execution time of that operator instead of
+// being reported separately. The output statistics of the Materializer are
+// the same as the ones of the operator, so they are only reported if the
+// operator doesn't report them.
+func (m *Materializer) mergeStats(
+	stats []*execinfrapb.ComponentStats,
+) []*execinfrapb.ComponentStats {
+	component := m.FlowCtx.ProcessorComponentID(m.processorID)
+	for _, s := range stats {
+		if s.Component != component {
+			continue
+		}
+		s.Exec.ExecTime.Set(s.Exec.ExecTime.Value() + m.conversionTime)
+		if !s.Output.NumTuples.HasValue() {
+			s.Output.NumBatches.Set(m.numBatches)
+			s.Output.NumTuples.Set(m.numRows)
+		}
+		return stats
+	}
+	return append(stats, m.getStats()...)
+}
+
 func (m *Materializer) close() {
 	if m.ProcessorBase.InternalClose() {
 		if m.cancelFlow != nil {
@@ -342,8 +904,15 @@ func (m *Materializer) close() {
 	}
 }
 
+// ConsumerDone is part of the execinfra.RowSource interface.
+func (m *Materializer) ConsumerDone() {
+	m.enforceConfinement()
+	m.ProcessorBase.ConsumerDone()
+}
+
 // ConsumerClosed is part of the execinfra.RowSource interface.
 func (m *Materializer) ConsumerClosed() {
+	m.enforceConfinement()
 	m.close()
 }
 
@@ -357,6 +926,9 @@ func (m *Materializer) Release() {
 		// allows us to reuse some of the slices as well as ProcOutputHelper
 		// struct.
 		ProcessorBase: m.ProcessorBase,
+		// The row is reused by the next Materializer, which is fine since the
+		// consumers can't hold on to the rows returned by Next anyway.
+		row: m.row[:0],
 	}
 	materializerPool.Put(m)
 }
@@ -14,20 +14,30 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 	"unsafe"
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/col/coldatatestutils"
+	"github.com/cockroachdb/cockroach/pkg/server/telemetry"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colconv"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqltelemetry"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/distsqlutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/skip"
+	"github.com/cockroachdb/cockroach/pkg/util"
+	"github.com/cockroachdb/cockroach/pkg/util/cancelchecker"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/randutil"
@@ -67,11 +77,6 @@ func TestColumnarizeMaterialize(t *testing.T) {
 		1, /* processorID */
 		c,
 		typs,
-		nil, /* output */
-		nil, /* getStats */
-		nil, /* metadataSources */
-		nil, /* toClose */
-		nil, /* cancelFlow */
 	)
 	if err != nil {
 		t.Fatal(err)
@@ -101,6 +106,561 @@ func TestColumnarizeMaterialize(t *testing.T) {
 	}
 }
 
+// TestColumnarizeMaterializeMetadata checks that the metadata interleaved with
+// the rows of the input of a columnarizer is forwarded by the materializer.
+func TestColumnarizeMaterializeMetadata(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	rng, _ := randutil.NewPseudoRand()
+	typs := []*types.T{types.Int}
+	nRows := rng.Intn(2 * coldata.BatchSize())
+	rows := rowenc.MakeIntRows(nRows, len(typs))
+	nMeta := 1 + rng.Intn(8)
+	inputMeta := make([]execinfra.RepeatableRowSourceMeta, nMeta)
+	for i := range inputMeta {
+		inputMeta[i] = execinfra.RepeatableRowSourceMeta{
+			RowIdx: rng.Intn(nRows + 1),
+			Meta: &execinfrapb.ProducerMetadata{
+				Metrics: &execinfrapb.RemoteProducerMetadata_Metrics{RowsRead: int64(i)},
+			},
+		}
+	}
+	input := execinfra.NewRepeatableRowSourceWithMeta(typs, rows, inputMeta)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		Cfg:     &execinfra.ServerConfig{Settings: st},
+		EvalCtx: &evalCtx,
+	}
+	c, err := NewBufferingColumnarizer(ctx, testAllocator, flowCtx, 0, input)
+	require.NoError(t, err)
+	m, err := NewMaterializer(flowCtx, 1 /* processorID */, c, typs)
+	require.NoError(t, err)
+	m.Start(ctx)
+
+	var numRows int
+	seenMeta := make(map[int64]struct{})
+	for {
+		row, meta := m.Next()
+		if row == nil && meta == nil {
+			break
+		}
+		if row != nil {
+			require.Equal(t, rows[numRows].String(typs), row.String(typs))
+			numRows++
+			continue
+		}
+		require.NotNil(t, meta.Metrics, "unexpected meta %+v", meta)
+		seenMeta[meta.Metrics.RowsRead] = struct{}{}
+	}
+	require.Equal(t, nRows, numRows)
+	require.Len(t, seenMeta, nMeta)
+}
+
+// TestMaterializerReuse checks that Materializers that are released and
+// reused for inputs of different widths produce the correct rows.
+func TestMaterializerReuse(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	rng, _ := randutil.NewPseudoRand()
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		Cfg:     &execinfra.ServerConfig{Settings: st},
+		EvalCtx: &evalCtx,
+	}
+
+	for run := 0; run < 5; run++ {
+		nCols := 1 + rng.Intn(4)
+		var typs []*types.T
+		for len(typs) < nCols {
+			typs = append(typs, rowenc.RandType(rng))
+		}
+		nRows := rng.Intn(2 * coldata.BatchSize())
+		rows := rowenc.RandEncDatumRowsOfTypes(rng, nRows, typs)
+		input := execinfra.NewRepeatableRowSource(typs, rows)
+		c, err := NewBufferingColumnarizer(ctx, testAllocator, flowCtx, 0, input)
+		require.NoError(t, err)
+		m, err := NewMaterializer(
+			flowCtx,
+			1, /* processorID */
+			c,
+			typs,
+		)
+		require.NoError(t, err)
+		m.Start(ctx)
+
+		for i := 0; i < nRows; i++ {
+			row, meta := m.Next()
+			require.Nil(t, meta)
+			require.Len(t, row, len(typs))
+			for j := range typs {
+				if row[j].Datum.Compare(&evalCtx, rows[i][j].Datum) != 0 {
+					t.Fatal("unequal rows", row, rows[i])
+				}
+			}
+		}
+		row, meta := m.Next()
+		require.Nil(t, meta)
+		require.Nil(t, row)
+		m.Release()
+	}
+}
+
+func TestMaterializerNextRows(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	rng, _ := randutil.NewPseudoRand()
+	nCols := 1 + rng.Intn(4)
+	var typs []*types.T
+	for len(typs) < nCols {
+		typs = append(typs, rowenc.RandType(rng))
+	}
+	nRows := 10000
+	rows := rowenc.RandEncDatumRowsOfTypes(rng, nRows, typs)
+	input := execinfra.NewRepeatableRowSource(typs, rows)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		Cfg:     &execinfra.ServerConfig{Settings: st},
+		EvalCtx: &evalCtx,
+	}
+	c, err := NewBufferingColumnarizer(ctx, testAllocator, flowCtx, 0, input)
+	require.NoError(t, err)
+	m, err := NewMaterializer(
+		flowCtx,
+		1, /* processorID */
+		c,
+		typs,
+	)
+	require.NoError(t, err)
+	m.Start(ctx)
+
+	// Mix calls to Next and NextRows, which must return the rows in order.
+	var i int
+	for {
+		var got rowenc.EncDatumRows
+		if rng.Intn(4) == 0 {
+			row, meta := m.Next()
+			require.Nil(t, meta)
+			if row != nil {
+				got = rowenc.EncDatumRows{row}
+			}
+		} else {
+			var meta *execinfrapb.ProducerMetadata
+			got, meta = m.NextRows()
+			require.Nil(t, meta)
+		}
+		if got == nil {
+			break
+		}
+		for _, row := range got {
+			require.Less(t, i, nRows)
+			require.Len(t, row, len(typs))
+			for j := range typs {
+				if row[j].Datum.Compare(&evalCtx, rows[i][j].Datum) != 0 {
+					t.Fatal("unequal rows", row, rows[i])
+				}
+			}
+			i++
+		}
+	}
+	require.Equal(t, nRows, i)
+}
+
+// TestMaterializerNextBatch verifies that NextBatch returns the input batches
+// as is.
+func TestMaterializerNextBatch(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	rng, _ := randutil.NewPseudoRand()
+	nCols := 1 + rng.Intn(4)
+	var typs []*types.T
+	for len(typs) < nCols {
+		typs = append(typs, rowenc.RandType(rng))
+	}
+	nRows := 10000
+	rows := rowenc.RandEncDatumRowsOfTypes(rng, nRows, typs)
+	input := execinfra.NewRepeatableRowSource(typs, rows)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		Cfg:     &execinfra.ServerConfig{Settings: st},
+		EvalCtx: &evalCtx,
+	}
+	c, err := NewBufferingColumnarizer(ctx, testAllocator, flowCtx, 0, input)
+	require.NoError(t, err)
+	m, err := NewMaterializer(
+		flowCtx,
+		1, /* processorID */
+		c,
+		typs,
+	)
+	require.NoError(t, err)
+	m.Start(ctx)
+
+	var (
+		i         int
+		da        rowenc.DatumAlloc
+		converted = make([]tree.Datum, coldata.BatchSize())
+	)
+	for {
+		batch, meta := m.NextBatch()
+		require.Nil(t, meta)
+		if batch == nil {
+			break
+		}
+		require.Less(t, i+batch.Length()-1, nRows)
+		for j := range typs {
+			colconv.ColVecToDatumAndDeselect(converted, batch.ColVec(j), batch.Length(), batch.Selection(), &da)
+			for rowIdx := 0; rowIdx < batch.Length(); rowIdx++ {
+				if converted[rowIdx].Compare(&evalCtx, rows[i+rowIdx][j].Datum) != 0 {
+					t.Fatal("unequal values", converted[rowIdx], rows[i+rowIdx][j].Datum)
+				}
+			}
+		}
+		i += batch.Length()
+	}
+	require.Equal(t, nRows, i)
+}
+
+// TestMaterializerOutputColumns verifies that the Materializer outputs only the
+// requested columns of the input batches when WithOutputColumns is passed.
+func TestMaterializerOutputColumns(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	rng, _ := randutil.NewPseudoRand()
+	nCols := 1 + rng.Intn(4)
+	var typs []*types.T
+	for len(typs) < nCols {
+		typs = append(typs, rowenc.RandType(rng))
+	}
+	// Choose random output columns, possibly repeating some of them and
+	// omitting the others.
+	outputCols := make([]uint32, 1+rng.Intn(2*nCols))
+	outputTypes := make([]*types.T, len(outputCols))
+	for i := range outputCols {
+		outputCols[i] = uint32(rng.Intn(nCols))
+		outputTypes[i] = typs[outputCols[i]]
+	}
+	nRows := 10000
+	rows := rowenc.RandEncDatumRowsOfTypes(rng, nRows, typs)
+	input := execinfra.NewRepeatableRowSource(typs, rows)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		Cfg:     &execinfra.ServerConfig{Settings: st},
+		EvalCtx: &evalCtx,
+	}
+	c, err := NewBufferingColumnarizer(ctx, testAllocator, flowCtx, 0, input)
+	require.NoError(t, err)
+	m, err := NewMaterializer(
+		flowCtx,
+		1, /* processorID */
+		c,
+		outputTypes,
+		WithOutputColumns(nCols, outputCols),
+	)
+	require.NoError(t, err)
+	m.Start(ctx)
+
+	// Mix calls to Next and NextRows.
+	var i int
+	for {
+		var got rowenc.EncDatumRows
+		if rng.Intn(4) == 0 {
+			row, meta := m.Next()
+			require.Nil(t, meta)
+			if row != nil {
+				got = rowenc.EncDatumRows{row}
+			}
+		} else {
+			var meta *execinfrapb.ProducerMetadata
+			got, meta = m.NextRows()
+			require.Nil(t, meta)
+		}
+		if got == nil {
+			break
+		}
+		for _, row := range got {
+			require.Less(t, i, nRows)
+			require.Len(t, row, len(outputCols))
+			for j, colIdx := range outputCols {
+				if row[j].Datum.Compare(&evalCtx, rows[i][colIdx].Datum) != 0 {
+					t.Fatal("unequal rows", row, rows[i])
+				}
+			}
+			i++
+		}
+	}
+	require.Equal(t, nRows, i)
+
+	// Out of range output columns are rejected.
+	_, err = NewMaterializer(
+		flowCtx,
+		1, /* processorID */
+		c,
+		typs[:1],
+		WithOutputColumns(nCols, []uint32{uint32(nCols)}),
+	)
+	require.Error(t, err)
+}
+
+func TestMaterializerStats(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	rng, _ := randutil.NewPseudoRand()
+	typs := []*types.T{types.Int, types.String}
+	nRows := 10000
+	rows := rowenc.RandEncDatumRowsOfTypes(rng, nRows, typs)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		Cfg:          &execinfra.ServerConfig{Settings: st},
+		EvalCtx:      &evalCtx,
+		NodeID:       evalCtx.NodeID,
+		CollectStats: true,
+	}
+
+	t.Run("default", func(t *testing.T) {
+		input := execinfra.NewRepeatableRowSource(typs, rows)
+		c, err := NewBufferingColumnarizer(ctx, testAllocator, flowCtx, 0, input)
+		require.NoError(t, err)
+		m, err := NewMaterializer(flowCtx, 1 /* processorID */, c, typs)
+		require.NoError(t, err)
+		m.Start(ctx)
+		for i := 0; ; i++ {
+			if i%2 == 0 {
+				row, meta := m.Next()
+				require.Nil(t, meta)
+				if row == nil {
+					break
+				}
+			} else {
+				got, meta := m.NextRows()
+				require.Nil(t, meta)
+				if got == nil {
+					break
+				}
+			}
+		}
+		stats := m.getStats()
+		require.Len(t, stats, 1)
+		s := stats[0]
+		require.Equal(t, flowCtx.ProcessorComponentID(1), s.Component)
+		require.Equal(t, uint64(nRows), s.Output.NumTuples.Value())
+		minBatches := (nRows + coldata.BatchSize() - 1) / coldata.BatchSize()
+		require.GreaterOrEqual(t, s.Output.NumBatches.Value(), uint64(minBatches))
+		require.True(t, s.Exec.ExecTime.HasValue())
+		require.Greater(t, s.Exec.MaxAllocatedMem.Value(), uint64(0))
+	})
+
+	t.Run("with-stats", func(t *testing.T) {
+		// The statistics of the Materializer are merged into the ones of the
+		// operator whose output it materializes.
+		input := execinfra.NewRepeatableRowSource(typs, rows)
+		c, err := NewBufferingColumnarizer(ctx, testAllocator, flowCtx, 0, input)
+		require.NoError(t, err)
+		operatorStats := func() []*execinfrapb.ComponentStats {
+			s1 := &execinfrapb.ComponentStats{Component: flowCtx.ProcessorComponentID(1)}
+			s1.Exec.ExecTime.Set(time.Second)
+			s1.Output.NumTuples.Set(uint64(nRows))
+			s2 := &execinfrapb.ComponentStats{Component: flowCtx.ProcessorComponentID(2)}
+			s2.Exec.ExecTime.Set(time.Second)
+			return []*execinfrapb.ComponentStats{s1, s2}
+		}
+		m, err := NewMaterializer(
+			flowCtx,
+			1, /* processorID */
+			c,
+			typs,
+			WithStats(operatorStats),
+		)
+		require.NoError(t, err)
+		require.True(t, m.collectStats)
+		m.Start(ctx)
+		// Don't exhaust the Materializer, since its drainHelper gives up on
+		// the statistics once it is drained.
+		for i := 0; i < nRows; i++ {
+			row, meta := m.Next()
+			require.Nil(t, meta)
+			require.NotNil(t, row)
+		}
+		stats := m.drainHelper.getStats()
+		require.Len(t, stats, 2)
+		require.Equal(t, time.Second+m.conversionTime, stats[0].Exec.ExecTime.Value())
+		require.Equal(t, uint64(nRows), stats[0].Output.NumTuples.Value())
+		require.False(t, stats[0].Output.NumBatches.HasValue())
+		require.Equal(t, time.Second, stats[1].Exec.ExecTime.Value())
+	})
+}
+
+func TestMaterializerCancellation(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(context.Background())
+	flowCtx := &execinfra.FlowCtx{
+		Cfg:     &execinfra.ServerConfig{Settings: st},
+		EvalCtx: &evalCtx,
+	}
+	typs := []*types.T{types.Int}
+	// There are enough rows for the cancellation to be checked several times
+	// after the first row, regardless of the batch size.
+	nRows := coldata.BatchSize() + 4096
+	batch := testAllocator.NewMemBatchWithFixedCapacity(typs, coldata.BatchSize())
+	batch.SetLength(coldata.BatchSize())
+	source := colexecop.NewRepeatableBatchSource(testAllocator, batch, typs)
+	source.ResetBatchesToReturn((nRows + coldata.BatchSize() - 1) / coldata.BatchSize())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m, err := NewMaterializer(flowCtx, 0 /* processorID */, source, typs)
+	require.NoError(t, err)
+	m.Start(ctx)
+	row, meta := m.Next()
+	require.NotNil(t, row)
+	require.Nil(t, meta)
+	cancel()
+	// The cancellation must be noticed before the input is exhausted.
+	for {
+		row, meta = m.Next()
+		if meta != nil {
+			break
+		}
+		require.NotNil(t, row, "the input was exhausted without noticing the cancellation")
+	}
+	require.True(t, errors.Is(meta.Err, cancelchecker.QueryCanceledError), "unexpected error %v", meta.Err)
+}
+
+func TestMaterializerDatumAliasing(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	rng, _ := randutil.NewPseudoRand()
+	// INT4 and STRING datums can't alias the vectors, so they are allocated.
+	typs := []*types.T{types.Int, types.Float, types.Int4, types.String}
+	nRows := 10000
+	rows := rowenc.RandEncDatumRowsOfTypes(rng, nRows, typs)
+	input := execinfra.NewRepeatableRowSource(typs, rows)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		Cfg:     &execinfra.ServerConfig{Settings: st},
+		EvalCtx: &evalCtx,
+	}
+	c, err := NewBufferingColumnarizer(ctx, testAllocator, flowCtx, 0, input)
+	require.NoError(t, err)
+	m, err := NewMaterializer(
+		flowCtx,
+		1, /* processorID */
+		c,
+		typs,
+		WithDatumAliasing(),
+	)
+	require.NoError(t, err)
+	m.Start(ctx)
+
+	for i := 0; i < nRows; i++ {
+		row, meta := m.Next()
+		require.Nil(t, meta)
+		require.NotNil(t, row)
+		for j := range typs {
+			if row[j].Datum.Compare(&evalCtx, rows[i][j].Datum) != 0 {
+				t.Fatal("unequal rows", row, rows[i])
+			}
+		}
+	}
+	row, meta := m.Next()
+	require.Nil(t, meta)
+	require.Nil(t, row)
+}
+
+// TestMaterializerDatumAliasingMutation checks that the Materializer detects
+// consumers that modify datums that alias the vectors of its input in test
+// builds.
+func TestMaterializerDatumAliasingMutation(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	if !util.CrdbTestBuild {
+		skip.IgnoreLint(t, "aliased datums are only checked in test builds")
+	}
+
+	typs := []*types.T{types.Int}
+	nRows := 2 * coldata.BatchSize()
+	rows := make(rowenc.EncDatumRows, nRows)
+	for i := range rows {
+		rows[i] = rowenc.EncDatumRow{rowenc.DatumToEncDatum(types.Int, tree.NewDInt(tree.DInt(i)))}
+	}
+	input := execinfra.NewRepeatableRowSource(typs, rows)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		Cfg:     &execinfra.ServerConfig{Settings: st},
+		EvalCtx: &evalCtx,
+	}
+	c, err := NewBufferingColumnarizer(ctx, testAllocator, flowCtx, 0, input)
+	require.NoError(t, err)
+	m, err := NewMaterializer(
+		flowCtx,
+		1, /* processorID */
+		c,
+		typs,
+		WithDatumAliasing(),
+	)
+	require.NoError(t, err)
+	m.Start(ctx)
+
+	row, meta := m.Next()
+	require.Nil(t, meta)
+	require.NotNil(t, row)
+	// Modify the datum, which also modifies the vector of the input.
+	*row[0].Datum.(*tree.DInt) = -1
+
+	for {
+		row, meta = m.Next()
+		if meta != nil {
+			break
+		}
+		require.NotNil(t, row, "expected the modified datum to be detected")
+	}
+	require.Error(t, meta.Err)
+	require.True(t, errors.HasAssertionFailure(meta.Err), "unexpected error %v", meta.Err)
+	require.Contains(t, meta.Err.Error(), "aliases a vector was modified")
+}
+
 func BenchmarkMaterializer(b *testing.B) {
 	defer log.Scope(b).Close(b)
 	ctx := context.Background()
@@ -120,66 +680,77 @@ func BenchmarkMaterializer(b *testing.B) {
 		nCols := len(typs)
 		for _, hasNulls := range []bool{false, true} {
 			for _, useSelectionVector := range []bool{false, true} {
-				b.Run(fmt.Sprintf("%s/hasNulls=%t/useSel=%t", typ, hasNulls, useSelectionVector), func(b *testing.B) {
-					nullProb := 0.0
-					if hasNulls {
-						nullProb = nullProbability
-					}
-					batch := testAllocator.NewMemBatchWithMaxCapacity(typs)
-					for _, colVec := range batch.ColVecs() {
-						coldatatestutils.RandomVec(coldatatestutils.RandomVecArgs{
-							Rand:             rng,
-							Vec:              colVec,
-							N:                coldata.BatchSize(),
-							NullProbability:  nullProb,
-							BytesFixedLength: 8,
-						})
-					}
-					batch.SetLength(coldata.BatchSize())
-					if useSelectionVector {
-						batch.SetSelection(true)
-						sel := batch.Selection()
-						for i := 0; i < coldata.BatchSize(); i++ {
-							sel[i] = i
-						}
-					}
-					input := colexectestutils.NewFiniteBatchSource(testAllocator, batch, typs, nBatches)
-
-					b.SetBytes(int64(nRows * nCols * int(unsafe.Sizeof(int64(0)))))
-					for i := 0; i < b.N; i++ {
-						m, err := NewMaterializer(
-							flowCtx,
-							0, /* processorID */
-							input,
-							typs,
-							nil, /* output */
-							nil, /* getStats */
-							nil, /* metadataSources */
-							nil, /* toClose */
-							nil, /* cancelFlow */
-						)
-						if err != nil {
-							b.Fatal(err)
-						}
-						m.Start(ctx)
-
-						foundRows := 0
-						for {
-							row, meta := m.Next()
-							if meta != nil {
-								b.Fatalf("unexpected metadata %v", meta)
+				for _, nextRows := range []bool{false, true} {
+					for _, aliasDatums := range []bool{false, true} {
+						b.Run(fmt.Sprintf("%s/hasNulls=%t/useSel=%t/nextRows=%t/aliasDatums=%t", typ, hasNulls, useSelectionVector, nextRows, aliasDatums), func(b *testing.B) {
+							nullProb := 0.0
+							if hasNulls {
+								nullProb = nullProbability
 							}
-							if row == nil {
-								break
+							selProb := 0.0
+							if useSelectionVector {
+								// All of the rows are selected.
+								selProb = 1
 							}
-							foundRows++
-						}
-						if foundRows != nRows {
-							b.Fatalf("expected %d rows, found %d", nRows, foundRows)
-						}
-						input.Reset(nBatches)
+							batch := coldatatestutils.RandomBatchWithArgs(testAllocator, coldatatestutils.RandomBatchArgs{
+								Rand:             rng,
+								Typs:             typs,
+								NullProbability:  nullProb,
+								SelProbability:   selProb,
+								BytesFixedLength: 8,
+							})
+							input := colexectestutils.NewFiniteBatchSource(testAllocator, batch, typs, nBatches)
+
+							b.SetBytes(int64(nRows * nCols * int(unsafe.Sizeof(int64(0)))))
+							for i := 0; i < b.N; i++ {
+								var opts []MaterializerOption
+								if aliasDatums {
+									opts = append(opts, WithDatumAliasing())
+								}
+								m, err := NewMaterializer(
+									flowCtx,
+									0, /* processorID */
+									input,
+									typs,
+									opts...,
+								)
+								if err != nil {
+									b.Fatal(err)
+								}
+								m.Start(ctx)
+
+								foundRows := 0
+								for {
+									var numRows int
+									var meta *execinfrapb.ProducerMetadata
+									if nextRows {
+										var rows rowenc.EncDatumRows
+										rows, meta = m.NextRows()
+										numRows = len(rows)
+									} else {
+										var row rowenc.EncDatumRow
+										row, meta = m.Next()
+										if row != nil {
+											numRows = 1
+										}
+									}
+									if meta != nil {
+										b.Fatalf("unexpected metadata %v", meta)
+									}
+									if numRows == 0 {
+										break
+									}
+									foundRows += numRows
+								}
+								if foundRows != nRows {
+									b.Fatalf("expected %d rows, found %d", nRows, foundRows)
+								}
+								m.Release()
+								input.Reset(nBatches)
+							}
+						})
 					}
-				})
+				}
 			}
 		}
 	}
@@ -205,13 +776,9 @@ func TestMaterializerNextErrorAfterConsumerDone(t *testing.T) {
 	m, err := NewMaterializer(
 		flowCtx,
 		0, /* processorID */
-		&colexecop.CallbackOperator{},
+		&colexecop.ScriptedOperator{},
 		nil, /* typ */
-		nil, /* output */
-		nil, /* getStats */
-		[]execinfrapb.MetadataSource{metadataSource},
-		nil, /* toClose */
-		nil, /* cancelFlow */
+		WithMetadataSources([]execinfrapb.MetadataSource{metadataSource}),
 	)
 	require.NoError(t, err)
 
@@ -229,6 +796,383 @@ func TestMaterializerNextErrorAfterConsumerDone(t *testing.T) {
 	)
 }
 
+// TestMaterializerCountsErrors verifies that the Materializer increments the
+// telemetry counter corresponding to the category of the caught error.
+func TestMaterializerCountsErrors(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+	}
+
+	for _, tc := range []struct {
+		name                string
+		panicFn             func()
+		expectedInternal    int32
+		expectedOutOfMemory int32
+	}{
+		{
+			name: "internal",
+			panicFn: func() {
+				colexecerror.InternalError(errors.AssertionFailedf("test-induced internal error"))
+			},
+			expectedInternal: 1,
+		},
+		{
+			name: "out-of-memory",
+			panicFn: func() {
+				colexecerror.InternalError(pgerror.New(pgcode.OutOfMemory, "test-induced oom error"))
+			},
+			expectedOutOfMemory: 1,
+		},
+		{
+			name: "expected",
+			panicFn: func() {
+				colexecerror.ExpectedError(errors.New("test-induced expected error"))
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			internalBefore := telemetry.Read(sqltelemetry.VecInternalErrorCounter)
+			oomBefore := telemetry.Read(sqltelemetry.VecOutOfMemoryErrorCounter)
+			input := &colexecop.CallbackOperator{NextCb: func(context.Context) coldata.Batch {
+				tc.panicFn()
+				// Unreachable
+				return nil
+			}}
+			m, err := NewMaterializer(
+				flowCtx,
+				0, /* processorID */
+				input,
+				nil, /* typ */
+			)
+			require.NoError(t, err)
+
+			m.Start(ctx)
+			row, meta := m.Next()
+			require.Nil(t, row)
+			require.NotNil(t, meta)
+			require.Error(t, meta.Err)
+			require.Equal(t, tc.expectedInternal, telemetry.Read(sqltelemetry.VecInternalErrorCounter)-internalBefore)
+			require.Equal(t, tc.expectedOutOfMemory, telemetry.Read(sqltelemetry.VecOutOfMemoryErrorCounter)-oomBefore)
+		})
+	}
+}
+
+// TestMaterializerMetadataDrainPhases verifies that the metadata sources of
+// the Materializer are drained in the order of their phases relative to each
+// other and to the other inputs to drain.
+func TestMaterializerMetadataDrainPhases(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+	}
+
+	var drained []string
+	makeSource := func(name string) execinfrapb.MetadataSource {
+		return execinfrapb.CallbackMetadataSource{DrainMetaCb: func(context.Context) []execinfrapb.ProducerMetadata {
+			drained = append(drained, name)
+			return []execinfrapb.ProducerMetadata{{Err: errors.New(name)}}
+		}}
+	}
+
+	m, err := NewMaterializer(
+		flowCtx,
+		0, /* processorID */
+		&colexecop.ScriptedOperator{},
+		nil, /* typ */
+		WithMetadataSources([]execinfrapb.MetadataSource{makeSource("default")}),
+	)
+	require.NoError(t, err)
+	m.AddMetadataSource(makeSource("last"), MetadataDrainPhaseLast)
+	m.AddMetadataSource(makeSource("first1"), MetadataDrainPhaseFirst)
+	m.AddMetadataSource(makeSource("first2"), MetadataDrainPhaseFirst)
+	// An input to drain which is added after the construction of the
+	// Materializer should still be drained before the sources of the last
+	// phase.
+	input := distsqlutils.NewRowBuffer(nil /* types */, nil /* rows */, distsqlutils.RowBufferArgs{})
+	input.Push(nil /* row */, &execinfrapb.ProducerMetadata{Err: errors.New("input")})
+	input.ProducerDone()
+	m.AddInputToDrain(input)
+
+	m.Start(ctx)
+	m.ConsumerDone()
+	var metas []string
+	for {
+		row, meta := m.Next()
+		require.Nil(t, row)
+		if meta == nil {
+			break
+		}
+		if meta.Err != nil {
+			metas = append(metas, meta.Err.Error())
+			if meta.Err.Error() == "input" {
+				// The sources of the last phase must not be drained before the
+				// other inputs to drain.
+				require.Equal(t, []string{"first1", "first2", "default"}, drained)
+			}
+		}
+	}
+	require.Equal(t, []string{"first1", "first2", "default", "input", "last"}, metas)
+}
+
+// TestMaterializerLifecycle verifies the sequence of calls that the
+// Materializer makes to its input when the input is exhausted, returns an
+// error, is canceled, or when the consumer closes the Materializer early.
+func TestMaterializerLifecycle(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	typs := []*types.T{types.Int}
+	batch := testAllocator.NewMemBatchWithFixedCapacity(typs, 1 /* capacity */)
+	batch.SetLength(1)
+
+	const (
+		initCall  = colexecop.ScriptedInit
+		nextCall  = colexecop.ScriptedNext
+		drainCall = colexecop.ScriptedDrainMeta
+		closeCall = colexecop.ScriptedClose
+	)
+	for _, tc := range []struct {
+		name  string
+		input func() *colexecop.ScriptedOperator
+		// cancel, if set, cancels the context before the Materializer is
+		// started.
+		cancel bool
+		// consumerClosedAfter, if positive, is the number of rows after which
+		// the consumer closes the Materializer.
+		consumerClosedAfter int
+		expectedRows        int
+		expectedErrs        []string
+		expectedCalls       []colexecop.ScriptedOperatorCall
+	}{
+		{
+			name:          "batches then done",
+			input:         func() *colexecop.ScriptedOperator { return colexecop.NewBatchesThenDoneOperator(batch, 2) },
+			expectedRows:  2,
+			expectedCalls: []colexecop.ScriptedOperatorCall{initCall, nextCall, nextCall, nextCall, drainCall, closeCall},
+		},
+		{
+			name: "error on next",
+			input: func() *colexecop.ScriptedOperator {
+				op := colexecop.NewBatchesThenDoneOperator(batch, 1)
+				op.NextErr = errors.New("next")
+				return op
+			},
+			expectedRows:  1,
+			expectedErrs:  []string{"next"},
+			expectedCalls: []colexecop.ScriptedOperatorCall{initCall, nextCall, nextCall, drainCall, closeCall},
+		},
+		{
+			// Errors returned by Close are only logged.
+			name:          "error on close",
+			input:         func() *colexecop.ScriptedOperator { return colexecop.NewErrorOnCloseOperator(errors.New("close")) },
+			expectedCalls: []colexecop.ScriptedOperatorCall{initCall, nextCall, drainCall, closeCall},
+		},
+		{
+			name: "metadata only",
+			input: func() *colexecop.ScriptedOperator {
+				return colexecop.NewMetadataOnlyOperator(execinfrapb.ProducerMetadata{Err: errors.New("meta")})
+			},
+			expectedErrs:  []string{"meta"},
+			expectedCalls: []colexecop.ScriptedOperatorCall{initCall, nextCall, drainCall, closeCall},
+		},
+		{
+			name:          "canceled",
+			input:         func() *colexecop.ScriptedOperator { return colexecop.NewSlowOperator(batch, 1, time.Hour) },
+			cancel:        true,
+			expectedErrs:  []string{context.Canceled.Error()},
+			expectedCalls: []colexecop.ScriptedOperatorCall{initCall, nextCall, drainCall, closeCall},
+		},
+		{
+			// The input must not be drained or used after the consumer closes
+			// the Materializer.
+			name:                "consumer closed",
+			input:               func() *colexecop.ScriptedOperator { return colexecop.NewBatchesThenDoneOperator(batch, 2) },
+			consumerClosedAfter: 1,
+			expectedRows:        1,
+			expectedCalls:       []colexecop.ScriptedOperatorCall{initCall, nextCall, closeCall},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			st := cluster.MakeTestingClusterSettings()
+			evalCtx := tree.MakeTestingEvalContext(st)
+			defer evalCtx.Stop(ctx)
+			flowCtx := &execinfra.FlowCtx{
+				EvalCtx: &evalCtx,
+			}
+
+			input := tc.input()
+			m, err := NewMaterializer(
+				flowCtx,
+				0, /* processorID */
+				input,
+				typs,
+				WithMetadataSources([]execinfrapb.MetadataSource{input}),
+				WithClosers([]colexecop.Closer{input}),
+			)
+			require.NoError(t, err)
+
+			if tc.cancel {
+				cancel()
+			}
+			m.Start(ctx)
+			var numRows int
+			var errs []string
+			for {
+				row, meta := m.Next()
+				if row == nil && meta == nil {
+					break
+				}
+				if row != nil {
+					numRows++
+					if numRows == tc.consumerClosedAfter {
+						m.ConsumerClosed()
+						break
+					}
+				}
+				if meta != nil && meta.Err != nil {
+					errs = append(errs, meta.Err.Error())
+				}
+			}
+			require.Equal(t, tc.expectedRows, numRows)
+			require.Equal(t, tc.expectedErrs, errs)
+			require.Equal(t, tc.expectedCalls, input.Calls())
+		})
+	}
+}
+
+// newConfinedTestMaterializer returns a materializer over nRows rows with a
+// single INT column which enforces its goroutine confinement.
+func newConfinedTestMaterializer(
+	t *testing.T, ctx context.Context, evalCtx *tree.EvalContext, nRows int,
+) *Materializer {
+	typs := []*types.T{types.Int}
+	input := execinfra.NewRepeatableRowSource(typs, rowenc.MakeIntRows(nRows, len(typs)))
+	flowCtx := &execinfra.FlowCtx{
+		Cfg: &execinfra.ServerConfig{
+			Settings: evalCtx.Settings,
+			TestingKnobs: execinfra.TestingKnobs{
+				CheckMaterializerGoroutineConfinement: true,
+			},
+		},
+		EvalCtx: evalCtx,
+	}
+	c, err := NewBufferingColumnarizer(ctx, testAllocator, flowCtx, 0, input)
+	require.NoError(t, err)
+	m, err := NewMaterializer(
+		flowCtx,
+		1, /* processorID */
+		c,
+		typs,
+	)
+	require.NoError(t, err)
+	return m
+}
+
+// TestMaterializerHandOff verifies that the materializer can be started and
+// partially consumed on one goroutine, and then handed off to a different
+// goroutine which consumes the rest of the rows. Run under the race detector,
+// the test also verifies that HandOff doesn't leave any unsynchronized state
+// behind.
+func TestMaterializerHandOff(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+
+	const nRows = 100
+	m := newConfinedTestMaterializer(t, ctx, &evalCtx, nRows)
+
+	handedOff := make(chan *Materializer)
+	errCh := make(chan error, 1)
+	go func() {
+		m.Start(ctx)
+		for i := 0; i < nRows/2; i++ {
+			if row, meta := m.Next(); row == nil || meta != nil {
+				errCh <- errors.Newf("unexpected row %v and meta %v", row, meta)
+				return
+			}
+		}
+		m.HandOff()
+		errCh <- nil
+		handedOff <- m
+	}()
+	require.NoError(t, <-errCh)
+
+	m = <-handedOff
+	for i := nRows / 2; i < nRows; i++ {
+		row, meta := m.Next()
+		require.Nil(t, meta)
+		require.NotNil(t, row)
+		require.Equal(t, tree.NewDInt(tree.DInt(i)), row[0].Datum)
+	}
+	row, meta := m.Next()
+	require.Nil(t, row)
+	require.Nil(t, meta)
+	m.ConsumerClosed()
+}
+
+// TestMaterializerGoroutineConfinement verifies that using the materializer on
+// a different goroutine without handing it off first is caught and reported as
+// metadata, once.
+func TestMaterializerGoroutineConfinement(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+
+	m := newConfinedTestMaterializer(t, ctx, &evalCtx, 1 /* nRows */)
+	m.Start(ctx)
+
+	errCh := make(chan error, 1)
+	go func() {
+		// Note that the violation must be reported without panicking, so
+		// there is no catcher here.
+		var errs []error
+		for {
+			row, meta := m.Next()
+			if row != nil {
+				errCh <- errors.Newf("unexpected row %v", row)
+				return
+			}
+			if meta == nil {
+				break
+			}
+			if meta.Err != nil {
+				errs = append(errs, meta.Err)
+			}
+		}
+		if len(errs) != 1 {
+			errCh <- errors.Newf("expected exactly one error, got %v", errs)
+			return
+		}
+		errCh <- errs[0]
+	}()
+	err := <-errCh
+	require.True(t, testutils.IsError(err, "HandOff must be called first"), err)
+	m.ConsumerClosed()
+}
+
 func BenchmarkColumnarizeMaterialize(b *testing.B) {
 	defer log.Scope(b).Close(b)
 	types := []*types.T{types.Int, types.Int}
@@ -250,18 +1194,12 @@ func BenchmarkColumnarizeMaterialize(b *testing.B) {
 		b.Fatal(err)
 	}
 
-	b.SetBytes(int64(nRows * nCols * int(unsafe.Sizeof(int64(0)))))
-	for i := 0; i < b.N; i++ {
+	colexectestutils.RunBenchmark(b, int64(nRows*nCols*int(unsafe.Sizeof(int64(0)))), func() {
 		m, err := NewMaterializer(
 			flowCtx,
 			1, /* processorID */
 			c,
 			types,
-			nil, /* output */
-			nil, /* getStats */
-			nil, /* metadataSources */
-			nil, /* toClose */
-			nil, /* cancelFlow */
 		)
 		if err != nil {
 			b.Fatal(err)
@@ -283,5 +1221,5 @@ func BenchmarkColumnarizeMaterialize(b *testing.B) {
 			b.Fatalf("expected %d rows, found %d", nRows, foundRows)
 		}
 		input.Reset()
-	}
+	})
 }
@@ -14,7 +14,6 @@ import (
 	"context"
 	"testing"
 
-	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
@@ -70,16 +69,13 @@ func BenchmarkOffset(b *testing.B) {
 	defer log.Scope(b).Close(b)
 	ctx := context.Background()
 	typs := []*types.T{types.Int, types.Int, types.Int}
-	batch := testAllocator.NewMemBatchWithMaxCapacity(typs)
-	batch.SetLength(coldata.BatchSize())
-	source := colexecop.NewRepeatableBatchSource(testAllocator, batch, typs)
-	source.Init()
-
-	o := NewOffsetOp(source, 1)
-	// Set throughput proportional to size of the selection vector.
-	b.SetBytes(int64(2 * coldata.BatchSize()))
-	for i := 0; i < b.N; i++ {
-		o.(*offsetOp).Reset()
-		o.Next(ctx)
-	}
+	colexectestutils.RunOperatorBenchmark(ctx, b, testAllocator, colexectestutils.OperatorBenchmarkArgs{
+		Typs:       typs,
+		NumBatches: 64,
+		MakeOperator: func(input colexecop.Operator) (colexecop.Operator, error) {
+			// Skip a single row, so that most of the work is done in the
+			// batches that are passed through.
+			return NewOffsetOp(input, 1 /* offset */), nil
+		},
+	})
 }
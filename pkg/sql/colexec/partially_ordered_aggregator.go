@@ -0,0 +1,378 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecagg"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// NewPartiallyOrderedAggregator creates an aggregator for the case when the
+// input is ordered on a non-empty proper subset of the grouping columns (the
+// ordered grouping columns of the spec). The input is split into "chunks" of
+// tuples that are equal on the ordered columns, and every chunk is aggregated
+// by an in-memory hash aggregator on the remaining grouping columns which is
+// reset once the chunk has been fully processed. As a result, only the groups
+// of a single chunk are kept in memory at any point, and the groups of every
+// chunk are emitted as soon as the ordered columns advance. The output row
+// ordering within a chunk is arbitrary.
+//
+// The arguments are the same as of NewHashAggregator. If newSpillingQueueArgs
+// is non-nil, the input tuples of the current chunk are tracked so that the
+// operator could fall back to the disk-backed aggregator (the groups of the
+// previous chunks have already been emitted, so they don't need to be
+// exported).
+func NewPartiallyOrderedAggregator(
+	args *colexecagg.NewAggregatorArgs, newSpillingQueueArgs *colexecutils.NewSpillingQueueArgs,
+) (colexecop.ResettableOperator, error) {
+	orderedCols, groupCols := args.Spec.OrderedGroupCols, args.Spec.GroupCols
+	if len(orderedCols) == 0 || len(orderedCols) == len(groupCols) {
+		return nil, errors.AssertionFailedf(
+			"partially ordered aggregator wrongfully planned: numGroupCols=%d "+
+				"numOrderedCols=%d", len(groupCols), len(orderedCols))
+	}
+	chunks, err := newOrderedChunksSplitter(args.Allocator, args.Input, args.InputTypes, orderedCols)
+	if err != nil {
+		return nil, err
+	}
+	// unorderedGroupCols will contain grouping columns that are not present
+	// among orderedCols. The hash aggregator will use only these columns to
+	// find the groups within the chunks since all tuples of a chunk are equal
+	// on the ordered columns.
+	unorderedGroupCols := make([]uint32, 0, len(groupCols)-len(orderedCols))
+	for _, groupCol := range groupCols {
+		isOrdered := false
+		for _, orderedCol := range orderedCols {
+			if orderedCol == groupCol {
+				isOrdered = true
+				break
+			}
+		}
+		if !isOrdered {
+			unorderedGroupCols = append(unorderedGroupCols, groupCol)
+		}
+	}
+	hashAggSpec := *args.Spec
+	hashAggSpec.GroupCols = unorderedGroupCols
+	hashAggArgs := *args
+	hashAggArgs.Input = chunks
+	hashAggArgs.Spec = &hashAggSpec
+	hashAgg, err := NewHashAggregator(&hashAggArgs, newSpillingQueueArgs)
+	if err != nil {
+		return nil, err
+	}
+	return &partiallyOrderedAggregator{
+		OneInputNode: colexecop.NewOneInputNode(args.Input),
+		chunks:       chunks,
+		hashAgg:      hashAgg.(*hashAggregator),
+	}, nil
+}
+
+// partiallyOrderedAggregator implements the aggregation using a combination
+// of orderedChunksSplitter and hashAggregator. Its only job is to check
+// whether the input has been fully processed and, if not, to move to the next
+// chunk once the hash aggregator has emitted all groups of the current one.
+type partiallyOrderedAggregator struct {
+	colexecop.OneInputNode
+
+	chunks  *orderedChunksSplitter
+	hashAgg *hashAggregator
+
+	// hashAggExported indicates whether the tuples tracked by the hash
+	// aggregator have been fully exported in ExportBuffered.
+	hashAggExported bool
+}
+
+var _ colexecop.ResettableOperator = &partiallyOrderedAggregator{}
+var _ colexecop.BufferingInMemoryOperator = &partiallyOrderedAggregator{}
+var _ colexecop.ClosableOperator = &partiallyOrderedAggregator{}
+
+func (p *partiallyOrderedAggregator) Init() {
+	// Note that p.Input is the input to p.chunks which is the input to
+	// p.hashAgg, so calling Init() only on the latter is sufficient.
+	p.hashAgg.Init()
+}
+
+func (p *partiallyOrderedAggregator) Next(ctx context.Context) coldata.Batch {
+	for {
+		batch := p.hashAgg.Next(ctx)
+		if batch.Length() > 0 {
+			return batch
+		}
+		if p.chunks.done() {
+			return coldata.ZeroBatch
+		}
+		// All groups of the current chunk have been emitted, so we reset the
+		// hash aggregator which will reset p.chunks to proceed to the next
+		// chunk.
+		p.hashAgg.Reset(ctx)
+	}
+}
+
+// ExportBuffered exports the input tuples of the current chunk that have
+// been consumed by the hash aggregator followed by the tuples that have been
+// read from the input but not emitted by p.chunks yet.
+func (p *partiallyOrderedAggregator) ExportBuffered(
+	ctx context.Context, input colexecop.Operator,
+) coldata.Batch {
+	if !p.hashAggExported {
+		if batch := p.hashAgg.ExportBuffered(ctx, input); batch.Length() > 0 {
+			return batch
+		}
+		p.hashAggExported = true
+	}
+	return p.chunks.exportRemaining()
+}
+
+func (p *partiallyOrderedAggregator) Reset(ctx context.Context) {
+	if r, ok := p.Input.(colexecop.Resetter); ok {
+		r.Reset(ctx)
+	}
+	p.chunks.resetInput()
+	p.hashAgg.Reset(ctx)
+	p.hashAggExported = false
+}
+
+func (p *partiallyOrderedAggregator) Close(ctx context.Context) error {
+	return p.hashAgg.Close(ctx)
+}
+
+// orderedChunksSplitter is an operator that splits its input into chunks of
+// tuples that are equal on the ordered columns (the input must be ordered on
+// those). It emits the tuples of a single chunk followed by zero-length
+// batches until it is reset, and then it proceeds to emitting the next chunk.
+// Unlike the chunker, it doesn't buffer the chunks, and the emitted batches
+// are windows into (or have the selection vector on top of) the input
+// batches.
+//
+// It will have emitted all tuples from all of the chunks only when it returns
+// a zero-length batch *and* done() returns true.
+type orderedChunksSplitter struct {
+	colexecop.OneInputNode
+	colexecop.NonExplainable
+
+	allocator   *colmem.Allocator
+	inputTypes  []*types.T
+	orderedCols []uint32
+	// partitioners contains one partitioner for each of the ordered columns.
+	partitioners []partitioner
+	// partitionCol indicates for every tuple of batch (in the order of the
+	// selection vector, if present) whether a new chunk begins at it.
+	partitionCol []bool
+
+	// batch is the last batch read from the input, and batchIdx is the index
+	// (within the selection vector, if present) of the first tuple of batch
+	// that hasn't been emitted yet.
+	batch    coldata.Batch
+	batchIdx int
+	// lastTuple contains the values of the ordered columns of the last tuple
+	// of the previous input batch. It is used to check whether the first tuple
+	// of the next batch begins a new chunk.
+	lastTuple    coldata.Batch
+	lastTupleSet bool
+	// chunkStarted indicates whether some tuples of the current chunk have
+	// been emitted.
+	chunkStarted bool
+	// chunkDone indicates whether all tuples of the current chunk have been
+	// emitted, so zero-length batches must be returned until reset.
+	chunkDone bool
+	inputDone bool
+
+	output coldata.Batch
+}
+
+var _ colexecop.ResettableOperator = &orderedChunksSplitter{}
+
+func newOrderedChunksSplitter(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	inputTypes []*types.T,
+	orderedCols []uint32,
+) (*orderedChunksSplitter, error) {
+	partitioners := make([]partitioner, len(orderedCols))
+	for i, col := range orderedCols {
+		var err error
+		partitioners[i], err = newPartitioner(inputTypes[col])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &orderedChunksSplitter{
+		OneInputNode: colexecop.NewOneInputNode(input),
+		allocator:    allocator,
+		inputTypes:   inputTypes,
+		orderedCols:  orderedCols,
+		partitioners: partitioners,
+	}, nil
+}
+
+func (s *orderedChunksSplitter) Init() {
+	s.Input.Init()
+	s.partitionCol = make([]bool, coldata.BatchSize())
+	s.output = s.allocator.NewMemBatchNoCols(s.inputTypes, coldata.BatchSize())
+}
+
+func (s *orderedChunksSplitter) Next(ctx context.Context) coldata.Batch {
+	if s.chunkDone || s.inputDone {
+		return coldata.ZeroBatch
+	}
+	if s.batch == nil || s.batchIdx == s.batch.Length() {
+		s.batch, s.batchIdx = s.Input.Next(ctx), 0
+		if s.batch.Length() == 0 {
+			s.inputDone = true
+			return coldata.ZeroBatch
+		}
+		s.partition()
+	}
+	if s.chunkStarted && s.partitionCol[s.batchIdx] {
+		// The next tuple begins a new chunk, so the current one is done.
+		s.chunkDone = true
+		return coldata.ZeroBatch
+	}
+	n := s.batch.Length()
+	startIdx, endIdx := s.batchIdx, s.batchIdx+1
+	for endIdx < n && !s.partitionCol[endIdx] {
+		endIdx++
+	}
+	if endIdx == n {
+		// Note that we need to remember the last tuple before advancing
+		// batchIdx so that the tuples are not lost if we hit a memory error
+		// (they will be exported by exportRemaining).
+		s.setLastTuple(n - 1)
+	}
+	s.batchIdx = endIdx
+	s.chunkStarted = true
+	return s.emit(startIdx, endIdx)
+}
+
+// partition finds the boundaries of the chunks within s.batch.
+func (s *orderedChunksSplitter) partition() {
+	n := s.batch.Length()
+	copy(s.partitionCol[:n], colexecutils.ZeroBoolColumn)
+	sel := s.batch.Selection()
+	for i, col := range s.orderedCols {
+		vec := s.batch.ColVec(int(col))
+		if sel != nil {
+			s.partitioners[i].partitionWithOrder(vec, sel, s.partitionCol, n)
+		} else {
+			s.partitioners[i].partition(vec, s.partitionCol, n)
+		}
+	}
+	// The partitioners always mark the first tuple as the beginning of a new
+	// chunk, but it might belong to the same chunk as the last tuple of the
+	// previous batch.
+	s.partitionCol[0] = s.lastTupleSet && s.differsFromLastTuple(s.tupleIdx(0))
+}
+
+// tupleIdx returns the index in the vectors of s.batch of the tuple at the
+// given position.
+func (s *orderedChunksSplitter) tupleIdx(i int) int {
+	if sel := s.batch.Selection(); sel != nil {
+		return sel[i]
+	}
+	return i
+}
+
+// differsFromLastTuple returns whether the tuple at index idx of s.batch
+// differs from s.lastTuple on any of the ordered columns.
+func (s *orderedChunksSplitter) differsFromLastTuple(idx int) bool {
+	for i, col := range s.orderedCols {
+		if valuesDiffer(s.lastTuple.ColVec(i), 0 /* aValueIdx */, s.batch.ColVec(int(col)), idx) {
+			return true
+		}
+	}
+	return false
+}
+
+// setLastTuple copies the ordered columns of the tuple at the given position
+// of s.batch into s.lastTuple.
+func (s *orderedChunksSplitter) setLastTuple(pos int) {
+	idx := s.tupleIdx(pos)
+	if s.lastTuple == nil {
+		// Note that we allocate the batch lazily (rather than in Init()) so
+		// that the memory error, if any, occurs when the tuples can be
+		// exported.
+		orderedTypes := make([]*types.T, len(s.orderedCols))
+		for i, col := range s.orderedCols {
+			orderedTypes[i] = s.inputTypes[col]
+		}
+		s.lastTuple = s.allocator.NewMemBatchWithFixedCapacity(orderedTypes, 1 /* capacity */)
+	}
+	s.lastTuple.ResetInternalBatch()
+	s.allocator.PerformOperation(s.lastTuple.ColVecs(), func() {
+		for i, col := range s.orderedCols {
+			s.lastTuple.ColVec(i).Copy(coldata.CopySliceArgs{
+				SliceArgs: coldata.SliceArgs{
+					Src:         s.batch.ColVec(int(col)),
+					SrcStartIdx: idx,
+					SrcEndIdx:   idx + 1,
+				},
+			})
+		}
+	})
+	s.lastTupleSet = true
+}
+
+// emit returns the tuples of s.batch at positions [startIdx, endIdx).
+func (s *orderedChunksSplitter) emit(startIdx, endIdx int) coldata.Batch {
+	if sel := s.batch.Selection(); sel != nil {
+		for i := range s.inputTypes {
+			s.output.ReplaceCol(s.batch.ColVec(i), i)
+		}
+		s.output.SetSelection(true)
+		copy(s.output.Selection(), sel[startIdx:endIdx])
+	} else {
+		for i := range s.inputTypes {
+			s.output.ReplaceCol(s.batch.ColVec(i).Window(startIdx, endIdx), i)
+		}
+		s.output.SetSelection(false)
+	}
+	s.output.SetLength(endIdx - startIdx)
+	return s.output
+}
+
+// exportRemaining returns all tuples that have been read from the input but
+// haven't been emitted yet. A zero-length batch is returned once all such
+// tuples have been exported.
+func (s *orderedChunksSplitter) exportRemaining() coldata.Batch {
+	if s.batch == nil || s.batchIdx == s.batch.Length() {
+		return coldata.ZeroBatch
+	}
+	startIdx, endIdx := s.batchIdx, s.batch.Length()
+	s.batchIdx = endIdx
+	return s.emit(startIdx, endIdx)
+}
+
+// done returns whether the input has been fully consumed.
+func (s *orderedChunksSplitter) done() bool {
+	return s.inputDone
+}
+
+// Reset makes the splitter proceed to emitting the next chunk.
+func (s *orderedChunksSplitter) Reset(context.Context) {
+	s.chunkStarted = false
+	s.chunkDone = false
+}
+
+// resetInput resets the splitter to the state before any tuples have been
+// read from the input.
+func (s *orderedChunksSplitter) resetInput() {
+	s.batch, s.batchIdx = nil, 0
+	s.lastTupleSet = false
+	s.inputDone = false
+}
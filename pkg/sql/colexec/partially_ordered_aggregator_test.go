@@ -0,0 +1,207 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecagg"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/testutils/colcontainerutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+// makePartiallyOrderedAggregatorTestCase returns a random input of three int
+// columns (a, b, c) which is ordered on a, as well as the expected output of
+// the aggregation of c grouped by (a, b).
+func makePartiallyOrderedAggregatorTestCase(
+	rng randutil.PseudoRand,
+) (input, expected colexectestutils.Tuples) {
+	type groupKey struct {
+		a, b interface{}
+	}
+	type groupResult struct {
+		sum, count int
+	}
+	var groups []groupKey
+	results := make(map[groupKey]*groupResult)
+	numRows := 1 + rng.Intn(4*coldata.BatchSize())
+	// The tuples with NULL in the ordered column come first.
+	numNullRows := 0
+	if rng.Float64() < 0.5 {
+		numNullRows = rng.Intn(numRows)
+	}
+	a := 0
+	for i := 0; i < numRows; i++ {
+		var key groupKey
+		if i >= numNullRows {
+			if rng.Float64() < 0.05 {
+				a++
+			}
+			key.a = a
+		}
+		if rng.Float64() < 0.9 {
+			key.b = rng.Intn(3)
+		}
+		c := rng.Intn(100)
+		input = append(input, colexectestutils.Tuple{key.a, key.b, c})
+		res, ok := results[key]
+		if !ok {
+			res = &groupResult{}
+			results[key] = res
+			groups = append(groups, key)
+		}
+		res.sum += c
+		res.count++
+	}
+	for _, key := range groups {
+		res := results[key]
+		expected = append(expected, colexectestutils.Tuple{key.a, key.b, res.sum, res.count})
+	}
+	return input, expected
+}
+
+var partiallyOrderedAggregatorTestSpec = &execinfrapb.AggregatorSpec{
+	GroupCols:        []uint32{0, 1},
+	OrderedGroupCols: []uint32{0},
+	Aggregations: []execinfrapb.AggregatorSpec_Aggregation{
+		{Func: execinfrapb.AggregatorSpec_ANY_NOT_NULL, ColIdx: []uint32{0}},
+		{Func: execinfrapb.AggregatorSpec_ANY_NOT_NULL, ColIdx: []uint32{1}},
+		{Func: execinfrapb.AggregatorSpec_SUM_INT, ColIdx: []uint32{2}},
+		{Func: execinfrapb.AggregatorSpec_COUNT_ROWS},
+	},
+}
+
+func TestPartiallyOrderedAggregator(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	evalCtx := tree.MakeTestingEvalContext(cluster.MakeTestingClusterSettings())
+	defer evalCtx.Stop(context.Background())
+	rng, _ := randutil.NewPseudoRand()
+	typs := []*types.T{types.Int, types.Int, types.Int}
+	spec := partiallyOrderedAggregatorTestSpec
+	constructors, constArguments, outputTypes, err := colexecagg.ProcessAggregations(
+		&evalCtx, nil /* semaCtx */, spec.Aggregations, typs,
+	)
+	require.NoError(t, err)
+	input, expected := makePartiallyOrderedAggregatorTestCase(rng)
+	colexectestutils.RunTestsWithTyps(
+		t, testAllocator, []colexectestutils.Tuples{input}, [][]*types.T{typs},
+		expected, colexectestutils.UnorderedVerifier,
+		func(sources []colexecop.Operator) (colexecop.Operator, error) {
+			return NewPartiallyOrderedAggregator(&colexecagg.NewAggregatorArgs{
+				Allocator:      testAllocator,
+				MemAccount:     testMemAcc,
+				Input:          sources[0],
+				InputTypes:     typs,
+				Spec:           spec,
+				EvalCtx:        &evalCtx,
+				Constructors:   constructors,
+				ConstArguments: constArguments,
+				OutputTypes:    outputTypes,
+			},
+				nil, /* newSpillingQueueArgs */
+			)
+		})
+}
+
+// TestPartiallyOrderedAggregatorSpilling verifies that the partially ordered
+// aggregator is planned when the input is ordered on some of the grouping
+// columns and that it correctly falls back to the external hash aggregator.
+func TestPartiallyOrderedAggregatorSpilling(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+		DiskMonitor: testDiskMonitor,
+	}
+
+	queueCfg, cleanup := colcontainerutils.NewTestingDiskQueueCfg(t, true /* inMem */)
+	defer cleanup()
+
+	var (
+		accounts []*mon.BoundAccount
+		monitors []*mon.BytesMonitor
+	)
+	rng, _ := randutil.NewPseudoRand()
+	typs := []*types.T{types.Int, types.Int, types.Int}
+	spec := partiallyOrderedAggregatorTestSpec
+	constructors, constArguments, outputTypes, err := colexecagg.ProcessAggregations(
+		&evalCtx, nil /* semaCtx */, spec.Aggregations, typs,
+	)
+	require.NoError(t, err)
+	input, expected := makePartiallyOrderedAggregatorTestCase(rng)
+	for _, diskSpillingEnabled := range []bool{true, false} {
+		HashAggregationDiskSpillingEnabled.Override(&flowCtx.Cfg.Settings.SV, diskSpillingEnabled)
+		for _, spillForced := range []bool{false, true} {
+			if !diskSpillingEnabled && spillForced {
+				continue
+			}
+			flowCtx.Cfg.TestingKnobs.ForceDiskSpill = spillForced
+			t.Run(fmt.Sprintf("diskSpillingEnabled=%t/spillForced=%t", diskSpillingEnabled, spillForced), func(t *testing.T) {
+				colexectestutils.RunTestsWithTyps(
+					t, testAllocator, []colexectestutils.Tuples{input}, [][]*types.T{typs},
+					expected, colexectestutils.UnorderedVerifier,
+					func(sources []colexecop.Operator) (colexecop.Operator, error) {
+						sem := colexecop.NewTestingSemaphore(ehaNumRequiredFDs)
+						op, accs, mons, _, err := createExternalHashAggregator(
+							ctx, flowCtx, &colexecagg.NewAggregatorArgs{
+								Allocator:      testAllocator,
+								MemAccount:     testMemAcc,
+								Input:          sources[0],
+								InputTypes:     typs,
+								Spec:           spec,
+								EvalCtx:        &evalCtx,
+								Constructors:   constructors,
+								ConstArguments: constArguments,
+								OutputTypes:    outputTypes,
+							},
+							queueCfg, sem, 0, /* numForcedRepartitions */
+						)
+						accounts = append(accounts, accs...)
+						monitors = append(monitors, mons...)
+						if !diskSpillingEnabled {
+							_, isPartiallyOrderedAgg := op.(*partiallyOrderedAggregator)
+							require.True(t, isPartiallyOrderedAgg)
+						}
+						return op, err
+					})
+			})
+		}
+	}
+	for _, acc := range accounts {
+		acc.Close(ctx)
+	}
+	for _, mon := range monitors {
+		mon.Stop(ctx)
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+)
+
+// canDecodeEncDatumsToColVec returns whether the encoded bytes of EncDatums
+// of type t can be decoded directly into a column vector, without creating a
+// tree.Datum first. This is the case for the types whose key and value
+// encodings map directly onto the physical representation of their vectors.
+func canDecodeEncDatumsToColVec(t *types.T) bool {
+	switch t.Family() {
+	case types.BoolFamily, types.IntFamily, types.FloatFamily, types.BytesFamily, types.StringFamily:
+		return true
+	}
+	return false
+}
+
+// decodeEncDatumRowsToColVec is the fast path of EncDatumRowsToColVec. If all
+// EncDatums of the column are only available in their encoded form and the
+// type is supported by canDecodeEncDatumsToColVec, it decodes them directly
+// into vec and returns true. The EncDatums are not modified, so their datums
+// are not cached. Otherwise, it returns false without touching vec.
+//
+// It must be called from within allocator.PerformOperation.
+func decodeEncDatumRowsToColVec(
+	rows rowenc.EncDatumRows, vec coldata.Vec, columnIdx int, t *types.T,
+) (bool, error) {
+	if !canDecodeEncDatumsToColVec(t) {
+		return false, nil
+	}
+	for i := range rows {
+		if ed := &rows[i][columnIdx]; ed.Datum != nil || ed.EncodedBytes() == nil {
+			return false, nil
+		}
+	}
+	var scratch []byte
+	for i := range rows {
+		ed := &rows[i][columnIdx]
+		enc, _ := ed.Encoding()
+		encoded := ed.EncodedBytes()
+		var rem []byte
+		var err error
+		switch enc {
+		case descpb.DatumEncoding_ASCENDING_KEY:
+			rem, scratch, err = decodeKeyToColVec(vec, i, t, encoded, encoding.Ascending, scratch)
+		case descpb.DatumEncoding_DESCENDING_KEY:
+			rem, scratch, err = decodeKeyToColVec(vec, i, t, encoded, encoding.Descending, scratch)
+		case descpb.DatumEncoding_VALUE:
+			rem, err = decodeValueToColVec(vec, i, t, encoded)
+		default:
+			return false, errors.AssertionFailedf("unknown encoding %d", log.Safe(enc))
+		}
+		if err != nil {
+			return false, errors.Wrapf(err, "error decoding %d bytes", log.Safe(len(encoded)))
+		}
+		if len(rem) != 0 {
+			return false, errors.AssertionFailedf(
+				"%d trailing bytes in encoded value: %+v", log.Safe(len(rem)), rem)
+		}
+	}
+	return true, nil
+}
+
+// decodeKeyToColVec decodes the key encoded value of type t from key into the
+// i-th element of vec. scratch is used as the buffer for the unescaped bytes
+// of bytes-like values and is returned to be reused by the next call.
+func decodeKeyToColVec(
+	vec coldata.Vec, i int, t *types.T, key []byte, dir encoding.Direction, scratch []byte,
+) (rem []byte, _ []byte, err error) {
+	var isNull bool
+	if rem, isNull = encoding.DecodeIfNull(key); isNull {
+		vec.Nulls().SetNull(i)
+		return rem, scratch, nil
+	}
+	switch t.Family() {
+	case types.BoolFamily, types.IntFamily:
+		var v int64
+		if dir == encoding.Ascending {
+			rem, v, err = encoding.DecodeVarintAscending(key)
+		} else {
+			rem, v, err = encoding.DecodeVarintDescending(key)
+		}
+		if err == nil {
+			if t.Family() == types.BoolFamily {
+				vec.Bool()[i] = v != 0
+			} else {
+				setIntColVec(vec, i, t, v)
+			}
+		}
+		return rem, scratch, err
+	case types.FloatFamily:
+		var v float64
+		if dir == encoding.Ascending {
+			rem, v, err = encoding.DecodeFloatAscending(key)
+		} else {
+			rem, v, err = encoding.DecodeFloatDescending(key)
+		}
+		if err == nil {
+			vec.Float64()[i] = v
+		}
+		return rem, scratch, err
+	case types.BytesFamily, types.StringFamily:
+		// Strings are key encoded in the same way as bytes.
+		if dir == encoding.Ascending {
+			rem, scratch, err = encoding.DecodeBytesAscending(key, scratch[:0])
+		} else {
+			rem, scratch, err = encoding.DecodeBytesDescending(key, scratch[:0])
+		}
+		if err == nil {
+			vec.Bytes().Set(i, scratch)
+		}
+		return rem, scratch, err
+	}
+	return key, scratch, errors.AssertionFailedf("unsupported type %s", t)
+}
+
+// decodeValueToColVec decodes the value encoded value of type t from b into
+// the i-th element of vec.
+func decodeValueToColVec(vec coldata.Vec, i int, t *types.T, b []byte) ([]byte, error) {
+	_, dataOffset, _, typ, err := encoding.DecodeValueTag(b)
+	if err != nil {
+		return b, err
+	}
+	// NULL is special because it is a valid value for any type.
+	if typ == encoding.Null {
+		vec.Nulls().SetNull(i)
+		return b[dataOffset:], nil
+	}
+	switch t.Family() {
+	case types.BoolFamily:
+		// Bool is special because the value is stored in the value tag.
+		rem, v, err := encoding.DecodeBoolValue(b)
+		if err == nil {
+			vec.Bool()[i] = v
+		}
+		return rem, err
+	case types.IntFamily:
+		rem, v, err := encoding.DecodeUntaggedIntValue(b[dataOffset:])
+		if err == nil {
+			setIntColVec(vec, i, t, v)
+		}
+		return rem, err
+	case types.FloatFamily:
+		rem, v, err := encoding.DecodeUntaggedFloatValue(b[dataOffset:])
+		if err == nil {
+			vec.Float64()[i] = v
+		}
+		return rem, err
+	case types.BytesFamily, types.StringFamily:
+		rem, v, err := encoding.DecodeUntaggedBytesValue(b[dataOffset:])
+		if err == nil {
+			vec.Bytes().Set(i, v)
+		}
+		return rem, err
+	}
+	return b, errors.AssertionFailedf("unsupported type %s", t)
+}
+
+// setIntColVec sets the i-th element of the integer vector vec of type t to v.
+func setIntColVec(vec coldata.Vec, i int, t *types.T, v int64) {
+	switch t.Width() {
+	case 16:
+		vec.Int16()[i] = int16(v)
+	case 32:
+		vec.Int32()[i] = int32(v)
+	default:
+		vec.Int64()[i] = v
+	}
+}
@@ -11,14 +11,20 @@
 package colexec
 
 import (
+	"fmt"
+	"math"
 	"reflect"
 	"testing"
 
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
 )
 
 var alloc = rowenc.DatumAlloc{}
@@ -128,3 +134,53 @@ func TestEncDatumRowsToColVecDecimal(t *testing.T) {
 		t.Errorf("expected vector %+v, got %+v", expected, vec)
 	}
 }
+
+func TestEncDatumRowsToColVecEncoded(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	rng, _ := randutil.NewPseudoRand()
+	const numRows = 100
+	for _, typ := range []*types.T{
+		types.Bool, types.Int2, types.Int4, types.Int, types.Float, types.Bytes, types.String,
+	} {
+		for _, enc := range []descpb.DatumEncoding{
+			descpb.DatumEncoding_ASCENDING_KEY,
+			descpb.DatumEncoding_DESCENDING_KEY,
+			descpb.DatumEncoding_VALUE,
+		} {
+			t.Run(fmt.Sprintf("%s/%s", typ, enc), func(t *testing.T) {
+				require.True(t, canDecodeEncDatumsToColVec(typ))
+				decodedRows := make(rowenc.EncDatumRows, numRows)
+				encodedRows := make(rowenc.EncDatumRows, numRows)
+				for i := range encodedRows {
+					d := rowenc.RandDatum(rng, typ, true /* nullOk */)
+					if f, ok := d.(*tree.DFloat); ok && math.IsNaN(float64(*f)) {
+						// NaNs are never equal to each other, so the vectors
+						// below couldn't be compared.
+						d = tree.NewDFloat(0)
+					}
+					ed := rowenc.DatumToEncDatum(typ, d)
+					encoded, err := ed.Encode(typ, &alloc, enc, nil /* appendTo */)
+					require.NoError(t, err)
+					decodedRows[i] = rowenc.EncDatumRow{ed}
+					encodedRows[i] = rowenc.EncDatumRow{rowenc.EncDatumFromEncoded(enc, encoded)}
+				}
+				expected := testAllocator.NewMemBatchWithFixedCapacity([]*types.T{typ}, numRows)
+				require.NoError(t, EncDatumRowsToColVec(
+					testAllocator, decodedRows, expected.ColVec(0), 0 /* columnIdx */, typ, &alloc,
+				))
+				expected.SetLength(numRows)
+				actual := testAllocator.NewMemBatchWithFixedCapacity([]*types.T{typ}, numRows)
+				require.NoError(t, EncDatumRowsToColVec(
+					testAllocator, encodedRows, actual.ColVec(0), 0 /* columnIdx */, typ, &alloc,
+				))
+				actual.SetLength(numRows)
+				// The fast path must not have decoded the datums.
+				for i := range encodedRows {
+					require.Nil(t, encodedRows[i][0].Datum)
+				}
+				coldata.AssertEquivalentBatches(t, expected, actual)
+			})
+		}
+	}
+}
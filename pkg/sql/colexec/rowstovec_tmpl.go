@@ -86,6 +86,9 @@ func _ROWS_TO_COL_VEC(
 
 // EncDatumRowsToColVec converts one column from EncDatumRows to a column
 // vector. columnIdx is the 0-based index of the column in the EncDatumRows.
+// If the column is only available in its encoded form and its type permits it,
+// the encoded bytes are decoded directly into the vector without going through
+// tree.Datums, see decodeEncDatumRowsToColVec.
 func EncDatumRowsToColVec(
 	allocator *colmem.Allocator,
 	rows rowenc.EncDatumRows,
@@ -98,6 +101,10 @@ func EncDatumRowsToColVec(
 	allocator.PerformOperation(
 		[]coldata.Vec{vec},
 		func() {
+			var decoded bool
+			if decoded, err = decodeEncDatumRowsToColVec(rows, vec, columnIdx, t); decoded || err != nil {
+				return
+			}
 			switch t.Family() {
 			// {{range .}}
 			case _TYPE_FAMILY:
@@ -39,7 +39,7 @@ func NewTopKSorter(
 	inputTypes []*types.T,
 	orderingCols []execinfrapb.Ordering_Column,
 	k uint64,
-) colexecop.Operator {
+) colexecop.ResettableOperator {
 	return &topKSorter{
 		allocator:    allocator,
 		OneInputNode: colexecop.NewOneInputNode(input),
@@ -50,6 +50,7 @@ func NewTopKSorter(
 }
 
 var _ colexecop.BufferingInMemoryOperator = &topKSorter{}
+var _ colexecop.Resetter = &topKSorter{}
 
 // topKSortState represents the state of the sort operator.
 type topKSortState int
@@ -296,6 +297,19 @@ func (t *topKSorter) ExportBuffered(context.Context, colexecop.Operator) coldata
 	return coldata.ZeroBatch
 }
 
+func (t *topKSorter) Reset(ctx context.Context) {
+	if r, ok := t.Input.(colexecop.Resetter); ok {
+		r.Reset(ctx)
+	}
+	t.state = topKSortSpooling
+	t.inputBatch = nil
+	t.firstUnprocessedTupleIdx = 0
+	t.topK.ResetInternalBatch()
+	t.emitted = 0
+	t.exportedFromTopK = 0
+	t.exportedFromBatch = 0
+}
+
 // Len is part of heap.Interface and is only meant to be used internally.
 func (t *topKSorter) Len() int {
 	return len(t.heap)
@@ -91,11 +91,7 @@ func TestSQLTypesIntegration(t *testing.T) {
 				1, /* processorID */
 				arrowOp,
 				typs,
-				output,
-				nil, /* getStats */
-				nil, /* metadataSources */
-				nil, /* toClose */
-				nil, /* cancelFlow */
+				WithOutput(output),
 			)
 			require.NoError(t, err)
 
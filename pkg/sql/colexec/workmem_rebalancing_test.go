@@ -0,0 +1,199 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecargs"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/testutils/colcontainerutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWorkMemRebalancing verifies that a sort planned above a hash join whose
+// build side is another hash join can use the budget donated by the latter
+// once it has emitted all of its output, so the sort doesn't spill to disk
+// even though its input doesn't fit under the workmem limit.
+func TestWorkMemRebalancing(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	queueCfg, cleanup := colcontainerutils.NewTestingDiskQueueCfg(t, true /* inMem */)
+	defer cleanup()
+
+	// The input of the sort consists of a single INT column, so it takes up
+	// roughly 16 bytes per tuple (8 bytes for the value and 8 bytes for the
+	// ordering), or about 1.2 times the workmem limit in total.
+	const memoryLimit = 128 << 10
+	const numRows = 10000
+
+	for _, rebalancingEnabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("rebalancing=%t", rebalancingEnabled), func(t *testing.T) {
+			st := cluster.MakeTestingClusterSettings()
+			WorkMemRebalancingEnabled.Override(&st.SV, rebalancingEnabled)
+			evalCtx := tree.MakeTestingEvalContext(st)
+			defer evalCtx.Stop(ctx)
+			flowCtx := &execinfra.FlowCtx{
+				EvalCtx: &evalCtx,
+				Cfg: &execinfra.ServerConfig{
+					Settings: st,
+				},
+				DiskMonitor: testDiskMonitor,
+			}
+			flowCtx.Cfg.TestingKnobs.MemoryLimitBytes = memoryLimit
+
+			var (
+				accounts []*mon.BoundAccount
+				monitors []*mon.BytesMonitor
+			)
+			defer func() {
+				for _, acc := range accounts {
+					acc.Close(ctx)
+				}
+				for _, m := range monitors {
+					m.Stop(ctx)
+				}
+			}()
+			pool := colmem.NewWorkMemPool()
+			newOp := func(
+				spec *execinfrapb.ProcessorSpec, inputs []colexecop.Operator, spillingCallbackFn func(),
+			) colexecop.Operator {
+				args := &colexecargs.NewColOperatorArgs{
+					Spec:                spec,
+					Inputs:              inputs,
+					StreamingMemAccount: testMemAcc,
+					DiskQueueCfg:        queueCfg,
+					FDSemaphore:         colexecop.NewTestingSemaphore(0 /* limit */),
+					WorkMemPool:         pool,
+				}
+				args.TestingKnobs.SpillingCallbackFn = spillingCallbackFn
+				result, err := colexecargs.TestNewColOperator(ctx, flowCtx, args)
+				require.NoError(t, err)
+				accounts = append(accounts, result.OpAccounts...)
+				monitors = append(monitors, result.OpMonitors...)
+				return result.Op
+			}
+			hashJoinerSpilled := func() { t.Fatal("unexpectedly a hash joiner spilled to disk") }
+
+			// The first hash join outputs a single row that is used as the
+			// build side of the second hash join.
+			intType := []*types.T{types.Int}
+			firstHashJoin := newOp(
+				&execinfrapb.ProcessorSpec{
+					Input: []execinfrapb.InputSyncSpec{{ColumnTypes: intType}, {ColumnTypes: intType}},
+					Core: execinfrapb.ProcessorCoreUnion{
+						HashJoiner: &execinfrapb.HashJoinerSpec{
+							LeftEqColumns:  []uint32{0},
+							RightEqColumns: []uint32{0},
+							Type:           descpb.InnerJoin,
+						},
+					},
+					ResultTypes: []*types.T{types.Int, types.Int},
+				},
+				[]colexecop.Operator{
+					colexectestutils.NewOpTestInput(testAllocator, 1 /* batchSize */, colexectestutils.Tuples{{1}}, intType),
+					colexectestutils.NewOpTestInput(testAllocator, 1 /* batchSize */, colexectestutils.Tuples{{1}}, intType),
+				},
+				hashJoinerSpilled,
+			)
+
+			// All rows of the probe side of the second hash join have a match,
+			// and only the second column of the probe side is output.
+			probeTuples := make(colexectestutils.Tuples, numRows)
+			for i := range probeTuples {
+				probeTuples[i] = colexectestutils.Tuple{1, numRows - i}
+			}
+			secondHashJoin := newOp(
+				&execinfrapb.ProcessorSpec{
+					Input: []execinfrapb.InputSyncSpec{
+						{ColumnTypes: []*types.T{types.Int, types.Int}},
+						{ColumnTypes: []*types.T{types.Int, types.Int}},
+					},
+					Core: execinfrapb.ProcessorCoreUnion{
+						HashJoiner: &execinfrapb.HashJoinerSpec{
+							LeftEqColumns:  []uint32{0},
+							RightEqColumns: []uint32{0},
+							Type:           descpb.InnerJoin,
+						},
+					},
+					Post: execinfrapb.PostProcessSpec{
+						Projection:    true,
+						OutputColumns: []uint32{1},
+					},
+					ResultTypes: intType,
+				},
+				[]colexecop.Operator{
+					colexectestutils.NewOpTestInput(
+						testAllocator, coldata.BatchSize(), probeTuples, []*types.T{types.Int, types.Int},
+					),
+					firstHashJoin,
+				},
+				hashJoinerSpilled,
+			)
+
+			var sortSpilled bool
+			sort := newOp(
+				&execinfrapb.ProcessorSpec{
+					Input: []execinfrapb.InputSyncSpec{{ColumnTypes: intType}},
+					Core: execinfrapb.ProcessorCoreUnion{
+						Sorter: &execinfrapb.SorterSpec{
+							OutputOrdering: execinfrapb.Ordering{
+								Columns: []execinfrapb.Ordering_Column{{ColIdx: 0, Direction: execinfrapb.Ordering_Column_ASC}},
+							},
+						},
+					},
+					ResultTypes: intType,
+				},
+				[]colexecop.Operator{secondHashJoin},
+				func() { sortSpilled = true },
+			)
+
+			sort.Init()
+			var numOutputRows int
+			for {
+				b := sort.Next(ctx)
+				if b.Length() == 0 {
+					break
+				}
+				col := b.ColVec(0).Int64()
+				for i := 0; i < b.Length(); i++ {
+					numOutputRows++
+					require.Equal(t, int64(numOutputRows), col[i])
+				}
+			}
+			require.Equal(t, numRows, numOutputRows)
+			// Without the rebalancing, the sort has only its own budget, so it
+			// has to spill.
+			require.Equal(t, !rebalancingEnabled, sortSpilled)
+			for _, c := range []colexecop.Operator{sort, secondHashJoin, firstHashJoin} {
+				if closer, ok := c.(colexecop.Closer); ok {
+					require.NoError(t, closer.Close(ctx))
+				}
+			}
+		})
+	}
+}
@@ -87,11 +87,90 @@ func CatchVectorizedRuntimeError(operation func()) (retErr error) {
 			// unexpected.
 			retErr = errors.NewAssertionErrorWithWrappedErrf(err, "unexpected error from the vectorized engine")
 		}
+		retErr = maybeAddHint(retErr)
 	}()
 	operation()
 	return retErr
 }
 
+// ErrorCategory describes the kind of an error returned by
+// CatchVectorizedRuntimeError.
+type ErrorCategory int
+
+const (
+	// InternalErrorCategory is the category of the errors that resulted in
+	// the vectorized engine being in an unexpected state. Such errors are
+	// returned to the client as internal errors with the stack trace.
+	InternalErrorCategory ErrorCategory = iota
+	// ExpectedErrorCategory is the category of the errors that the vectorized
+	// engine expects to occur (for example, a division by zero) as well as of
+	// the errors that were caused by a component outside of the vectorized
+	// engine (like StorageErrors).
+	ExpectedErrorCategory
+	// OutOfMemoryErrorCategory is the category of the errors that occur when
+	// a memory budget is exceeded.
+	OutOfMemoryErrorCategory
+)
+
+// Classify returns the category of err which must have been returned by
+// CatchVectorizedRuntimeError.
+func Classify(err error) ErrorCategory {
+	if pgerror.GetPGCode(err) == pgcode.OutOfMemory {
+		return OutOfMemoryErrorCategory
+	}
+	if errors.HasAssertionFailure(err) {
+		return InternalErrorCategory
+	}
+	return ExpectedErrorCategory
+}
+
+// errWorkMemLimitExceeded is the mark of the out of memory errors that occur
+// when an operator that can spill to disk exceeds the limit of its memory
+// monitor, which is derived from the sql.distsql.temp_storage.workmem setting.
+var errWorkMemLimitExceeded = errors.New("workmem limit exceeded")
+
+// MarkWorkMemLimitExceeded marks err (which must be an out of memory error) as
+// having occurred because an operator that can spill to disk exceeded its
+// workmem limit. Such errors get a different hint than the ones that occur
+// when the memory budget of the node is exceeded.
+func MarkWorkMemLimitExceeded(err error) error {
+	return errors.Mark(err, errWorkMemLimitExceeded)
+}
+
+const (
+	outOfMemoryHint = "the query exceeded the memory budget of the node; " +
+		"consider increasing the --max-sql-memory limit"
+	workMemHint = "an operator that spills to disk exceeded its memory limit; " +
+		"consider increasing the sql.distsql.temp_storage.workmem cluster setting"
+	diskFullHint = "the query exceeded the temporary storage budget for the " +
+		"operators that spilled to disk; consider increasing the " +
+		"--max-disk-temp-storage limit"
+)
+
+// maybeAddHint attaches a hint on how to avoid the error to the errors that
+// occur when a memory or a disk budget is exceeded. The hint is attached only
+// once, even if the error is caught by several catchers.
+func maybeAddHint(err error) error {
+	var hint string
+	switch pgerror.GetPGCode(err) {
+	case pgcode.OutOfMemory:
+		hint = outOfMemoryHint
+		if errors.Is(err, errWorkMemLimitExceeded) {
+			hint = workMemHint
+		}
+	case pgcode.DiskFull:
+		hint = diskFullHint
+	default:
+		return err
+	}
+	for _, h := range errors.GetAllHints(err) {
+		if h == hint {
+			return err
+		}
+	}
+	return errors.WithHint(err, hint)
+}
+
 // We use the approach of allow-listing the packages the panics from which are
 // safe to catch (which is the case when the code doesn't update shared state
 // and doesn't manipulate locks).
@@ -16,6 +16,8 @@ import (
 	"testing"
 
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/stretchr/testify/require"
@@ -62,6 +64,60 @@ func TestCatchVectorizedRuntimeError(t *testing.T) {
 	require.False(t, strings.Contains(notAnnotatedErr.Error(), annotationText))
 }
 
+// TestClassify verifies that the errors returned by the panic-catcher are
+// classified correctly and that the out-of-memory errors get a hint.
+func TestClassify(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	err := colexecerror.CatchVectorizedRuntimeError(func() {
+		colexecerror.InternalError(errors.New("internal"))
+	})
+	require.Equal(t, colexecerror.InternalErrorCategory, colexecerror.Classify(err))
+
+	err = colexecerror.CatchVectorizedRuntimeError(func() {
+		colexecerror.ExpectedError(pgerror.New(pgcode.DivisionByZero, "division by zero"))
+	})
+	require.Equal(t, colexecerror.ExpectedErrorCategory, colexecerror.Classify(err))
+
+	err = colexecerror.CatchVectorizedRuntimeError(func() {
+		colexecerror.InternalError(colexecerror.NewStorageError(errors.New("storage")))
+	})
+	require.Equal(t, colexecerror.ExpectedErrorCategory, colexecerror.Classify(err))
+
+	memErr := pgerror.New(pgcode.OutOfMemory, "memory budget exceeded")
+	err = colexecerror.CatchVectorizedRuntimeError(func() {
+		colexecerror.InternalError(memErr)
+	})
+	require.Equal(t, colexecerror.OutOfMemoryErrorCategory, colexecerror.Classify(err))
+	require.Equal(t, pgcode.OutOfMemory, pgerror.GetPGCode(err))
+	hint := pgerror.Flatten(err).Hint
+	require.NotEmpty(t, hint)
+
+	// Setup multiple levels of catchers to ensure that the hint is attached
+	// only once.
+	err = colexecerror.CatchVectorizedRuntimeError(func() {
+		colexecerror.InternalError(colexecerror.CatchVectorizedRuntimeError(func() {
+			colexecerror.InternalError(memErr)
+		}))
+	})
+	require.Equal(t, colexecerror.OutOfMemoryErrorCategory, colexecerror.Classify(err))
+	require.Equal(t, hint, pgerror.Flatten(err).Hint)
+	require.Contains(t, hint, "--max-sql-memory")
+	require.NotContains(t, hint, "workmem")
+
+	// The errors of the operators that can spill to disk get a different
+	// hint.
+	err = colexecerror.CatchVectorizedRuntimeError(func() {
+		colexecerror.InternalError(colexecerror.MarkWorkMemLimitExceeded(memErr))
+	})
+	require.Equal(t, colexecerror.OutOfMemoryErrorCategory, colexecerror.Classify(err))
+	require.Equal(t, pgcode.OutOfMemory, pgerror.GetPGCode(err))
+	workMemHint := pgerror.Flatten(err).Hint
+	require.Contains(t, workMemHint, "sql.distsql.temp_storage.workmem")
+	require.NotContains(t, workMemHint, "--max-sql-memory")
+}
+
 // TestNonVectorizedTestPanicIsNotCaught verifies that panics emitted via
 // NonVectorizedTestPanic() method are not caught by the catcher.
 func TestNonVectorizedTestPanicIsNotCaught(t *testing.T) {
@@ -0,0 +1,183 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecop
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/errors"
+)
+
+// ScriptedOperatorCall is a method call recorded by a ScriptedOperator.
+type ScriptedOperatorCall string
+
+const (
+	// ScriptedInit is recorded when Init is called.
+	ScriptedInit ScriptedOperatorCall = "Init"
+	// ScriptedNext is recorded when Next is called.
+	ScriptedNext ScriptedOperatorCall = "Next"
+	// ScriptedDrainMeta is recorded when DrainMeta is called.
+	ScriptedDrainMeta ScriptedOperatorCall = "DrainMeta"
+	// ScriptedClose is recorded when Close is called.
+	ScriptedClose ScriptedOperatorCall = "Close"
+)
+
+// ScriptedOperator is a testing utility Operator whose behavior is configured
+// by its fields. It records the calls to its methods, so that tests can verify
+// the order in which the operator tree calls Init, Next, DrainMeta and Close,
+// and it panics with an assertion failure if Next is called before Init or
+// after Close. The constructors below cover the most common scripts.
+type ScriptedOperator struct {
+	ZeroInputNode
+
+	// Batches are returned by Next in order. Once all of them are returned,
+	// Next returns a zero-length batch, unless NextErr is set.
+	Batches []coldata.Batch
+	// NextErr, if set, is thrown as an expected error by Next once all of the
+	// Batches are returned.
+	NextErr error
+	// NextDelay, if set, is the time each call to Next waits for before
+	// returning. If the context is canceled while waiting, Next throws the
+	// context error as an expected error.
+	NextDelay time.Duration
+	// Meta is returned by the first call to DrainMeta.
+	Meta []execinfrapb.ProducerMetadata
+	// CloseErr is returned by Close.
+	CloseErr error
+
+	mu struct {
+		syncutil.Mutex
+		calls       []ScriptedOperatorCall
+		initialized bool
+		closed      bool
+	}
+}
+
+var _ DrainableOperator = &ScriptedOperator{}
+var _ ClosableOperator = &ScriptedOperator{}
+
+// NewBatchesThenDoneOperator returns a ScriptedOperator that returns batch n
+// times and then a zero-length batch.
+func NewBatchesThenDoneOperator(batch coldata.Batch, n int) *ScriptedOperator {
+	batches := make([]coldata.Batch, n)
+	for i := range batches {
+		batches[i] = batch
+	}
+	return &ScriptedOperator{Batches: batches}
+}
+
+// NewErrorOnCloseOperator returns a ScriptedOperator that returns a
+// zero-length batch and whose Close returns err.
+func NewErrorOnCloseOperator(err error) *ScriptedOperator {
+	return &ScriptedOperator{CloseErr: err}
+}
+
+// NewSlowOperator returns a ScriptedOperator that returns batch n times and
+// then a zero-length batch, waiting for delay on every call to Next. It can be
+// used to verify that context cancellation is propagated to an operator that
+// is blocked in Next.
+func NewSlowOperator(batch coldata.Batch, n int, delay time.Duration) *ScriptedOperator {
+	op := NewBatchesThenDoneOperator(batch, n)
+	op.NextDelay = delay
+	return op
+}
+
+// NewMetadataOnlyOperator returns a ScriptedOperator that returns a
+// zero-length batch and the given metadata when drained.
+func NewMetadataOnlyOperator(meta ...execinfrapb.ProducerMetadata) *ScriptedOperator {
+	return &ScriptedOperator{Meta: meta}
+}
+
+// record records a call to the given method and returns whether Init and
+// Close have been called, including by this call.
+func (o *ScriptedOperator) record(call ScriptedOperatorCall) (initialized, closed bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.mu.calls = append(o.mu.calls, call)
+	switch call {
+	case ScriptedInit:
+		o.mu.initialized = true
+	case ScriptedClose:
+		o.mu.closed = true
+	}
+	return o.mu.initialized, o.mu.closed
+}
+
+// Init is part of the Operator interface.
+func (o *ScriptedOperator) Init() {
+	o.record(ScriptedInit)
+}
+
+// Next is part of the Operator interface.
+func (o *ScriptedOperator) Next(ctx context.Context) coldata.Batch {
+	initialized, closed := o.record(ScriptedNext)
+	if !initialized {
+		colexecerror.InternalError(errors.AssertionFailedf("Next is called before Init"))
+	}
+	if closed {
+		colexecerror.InternalError(errors.AssertionFailedf("Next is called after Close"))
+	}
+	if o.NextDelay > 0 {
+		select {
+		case <-ctx.Done():
+			colexecerror.ExpectedError(ctx.Err())
+		case <-time.After(o.NextDelay):
+		}
+	}
+	if len(o.Batches) > 0 {
+		batch := o.Batches[0]
+		o.Batches = o.Batches[1:]
+		return batch
+	}
+	if o.NextErr != nil {
+		colexecerror.ExpectedError(o.NextErr)
+	}
+	return coldata.ZeroBatch
+}
+
+// DrainMeta is part of the execinfrapb.MetadataSource interface.
+func (o *ScriptedOperator) DrainMeta(context.Context) []execinfrapb.ProducerMetadata {
+	o.record(ScriptedDrainMeta)
+	meta := o.Meta
+	o.Meta = nil
+	return meta
+}
+
+// Close is part of the Closer interface.
+func (o *ScriptedOperator) Close(context.Context) error {
+	o.record(ScriptedClose)
+	return o.CloseErr
+}
+
+// Calls returns the method calls recorded so far, in order.
+func (o *ScriptedOperator) Calls() []ScriptedOperatorCall {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]ScriptedOperatorCall(nil), o.mu.calls...)
+}
+
+// NumCalls returns the number of recorded calls to the given method.
+func (o *ScriptedOperator) NumCalls(call ScriptedOperatorCall) int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	n := 0
+	for _, c := range o.mu.calls {
+		if c == call {
+			n++
+		}
+	}
+	return n
+}
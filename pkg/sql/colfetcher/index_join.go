@@ -0,0 +1,424 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colfetcher
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/colconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/span"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/cockroachdb/errors"
+)
+
+// indexJoinBatchSizeBytes is the default target size of the spans that
+// ColIndexJoin looks up at once. It matches the batch size of the input rows of
+// the index joins in the row execution engine.
+const indexJoinBatchSizeBytes = 4 << 20 /* 4 MB */
+
+type indexJoinState int
+
+const (
+	indexJoinConstructingSpans indexJoinState = iota
+	indexJoinFetchingRows
+	indexJoinDone
+)
+
+// ColIndexJoin is the vectorized implementation of the index join, i.e. of
+// the JoinReader without lookup columns and a lookup expression. The first
+// columns of its input are the primary key columns of the table. ColIndexJoin
+// reads the input in chunks, generates the spans of the primary index for
+// them, and outputs the looked up rows as they are returned by a cFetcher
+// (meaning that the output contains all columns of the table, and the
+// columns of the input are not a part of it).
+//
+// Lookup joins (i.e. the JoinReaders with lookup columns or lookup
+// expressions) are not supported natively, so their JoinReaders are still
+// wrapped.
+type ColIndexJoin struct {
+	colexecop.OneInputNode
+
+	state   indexJoinState
+	flowCtx *execinfra.FlowCtx
+	rf      *cFetcher
+
+	// spanBuilder generates the spans of the primary index from the first
+	// numKeyCols columns of the input.
+	spanBuilder *span.Builder
+	numKeyCols  int
+	keyTypes    []*types.T
+	keyRow      rowenc.EncDatumRow
+	// converter converts the primary key columns of the input batches to
+	// datums.
+	converter *colconv.VecToDatumConverter
+	spans     roachpb.Spans
+	inputDone bool
+	// batchSizeBytes is the target size of the spans that are looked up at
+	// once. It doesn't exceed the workmem limit.
+	batchSizeBytes int64
+	// spansBytes is the size of the spans of the current lookup, which is
+	// registered with the allocator until the next lookup.
+	spansBytes int64
+	allocator  *colmem.Allocator
+	// maintainOrdering indicates whether the rows must be looked up in the
+	// order of the input. If it is false, the spans are sorted to make the
+	// lookups more efficient.
+	maintainOrdering bool
+
+	// tracingSpan is created when the stats should be collected for the query
+	// execution, and it will be finished when closing the operator.
+	tracingSpan *tracing.Span
+	mu          struct {
+		syncutil.Mutex
+		ctx context.Context
+		// init is true after Init() has been called.
+		init bool
+		// rowsRead contains the number of total rows this ColIndexJoin has
+		// returned so far.
+		rowsRead int64
+		// bytesRead contains the number of bytes read by the fetchers of the
+		// previous lookups. The fetcher is replaced on every lookup.
+		bytesRead int64
+	}
+	// ResultTypes is the slice of resulting column types from this operator.
+	ResultTypes []*types.T
+}
+
+var _ colexecop.KVReader = &ColIndexJoin{}
+var _ execinfra.Releasable = &ColIndexJoin{}
+var _ colexecop.Closer = &ColIndexJoin{}
+var _ colexecop.Operator = &ColIndexJoin{}
+
+// Init initializes a ColIndexJoin.
+func (s *ColIndexJoin) Init() {
+	s.Input.Init()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mu.init = true
+}
+
+// Next is part of the Operator interface.
+func (s *ColIndexJoin) Next(ctx context.Context) coldata.Batch {
+	s.mu.Lock()
+	if s.mu.ctx == nil {
+		// This is the first call to Next(), so we will capture the context and
+		// possibly replace it with a child below.
+		s.mu.ctx = ctx
+		if execinfra.ShouldCollectStats(s.mu.ctx, s.flowCtx) {
+			// We need to start a child span so that the only contention events
+			// present in the recording would be because of this cFetcher.
+			s.mu.ctx, s.tracingSpan = execinfra.ProcessorSpan(s.mu.ctx, "colindexjoin")
+		}
+	}
+	ctx = s.mu.ctx
+	s.mu.Unlock()
+	for {
+		switch s.state {
+		case indexJoinConstructingSpans:
+			s.spans = s.spans[:0]
+			s.allocator.ReleaseMemory(s.spansBytes)
+			s.spansBytes = 0
+			for !s.inputDone && s.spansBytes < s.batchSizeBytes {
+				batch := s.Input.Next(ctx)
+				if batch.Length() == 0 {
+					s.inputDone = true
+					break
+				}
+				spansBytes := s.generateSpans(batch)
+				s.allocator.AdjustMemoryUsage(spansBytes)
+				s.spansBytes += spansBytes
+			}
+			if len(s.spans) == 0 {
+				if s.inputDone {
+					s.state = indexJoinDone
+				}
+				// Otherwise, all of the input rows had NULL keys, so we
+				// proceed to the next chunk of the input.
+				continue
+			}
+			if !s.maintainOrdering {
+				// Sort the spans so that lower layers could optimize iteration
+				// over the data. It is not safe to do so when the ordering has
+				// to be maintained since the looked up rows are output as they
+				// are retrieved.
+				sort.Sort(s.spans)
+			}
+			s.mu.Lock()
+			// The fetcher is about to be replaced, so we need to remember the
+			// number of bytes it has read.
+			s.mu.bytesRead += s.rf.fetcher.GetBytesRead()
+			err := s.rf.StartScan(
+				s.flowCtx.Txn, s.spans, false /* limitBatches */, 0, /* limitHint */
+				s.flowCtx.TraceKV, s.flowCtx.EvalCtx.TestingKnobs.ForceProductionBatchSizes,
+			)
+			s.mu.Unlock()
+			if err != nil {
+				colexecerror.InternalError(err)
+			}
+			s.state = indexJoinFetchingRows
+		case indexJoinFetchingRows:
+			batch, err := s.rf.NextBatch(ctx)
+			if err != nil {
+				colexecerror.InternalError(err)
+			}
+			if batch.Length() == 0 {
+				s.state = indexJoinConstructingSpans
+				continue
+			}
+			s.mu.Lock()
+			s.mu.rowsRead += int64(batch.Length())
+			s.mu.Unlock()
+			return batch
+		case indexJoinDone:
+			return coldata.ZeroBatch
+		default:
+			colexecerror.InternalError(errors.AssertionFailedf("unexpected indexJoinState %d", s.state))
+		}
+	}
+}
+
+// generateSpans appends the spans of the primary index for the rows of the
+// input batch to s.spans and returns their size in bytes. The rows with NULL
+// keys are skipped since they cannot have matches.
+func (s *ColIndexJoin) generateSpans(batch coldata.Batch) int64 {
+	s.converter.ConvertBatchAndDeselect(batch)
+	var size int64
+	for i, n := 0, batch.Length(); i < n; i++ {
+		hasNull := false
+		for j := 0; j < s.numKeyCols; j++ {
+			d := s.converter.GetDatumColumn(j)[i]
+			if d == tree.DNull {
+				hasNull = true
+				break
+			}
+			s.keyRow[j] = rowenc.DatumToEncDatum(s.keyTypes[j], d)
+		}
+		if hasNull {
+			continue
+		}
+		keySpan, containsNull, err := s.spanBuilder.SpanFromEncDatums(s.keyRow, s.numKeyCols)
+		if err != nil {
+			colexecerror.InternalError(err)
+		}
+		s.spans = s.spanBuilder.MaybeSplitSpanIntoSeparateFamilies(
+			s.spans, keySpan, s.numKeyCols, containsNull,
+		)
+		size += int64(len(keySpan.Key) + len(keySpan.EndKey))
+	}
+	return size
+}
+
+// DrainMeta is part of the MetadataSource interface.
+func (s *ColIndexJoin) DrainMeta(ctx context.Context) []execinfrapb.ProducerMetadata {
+	s.mu.Lock()
+	initialized := s.mu.init
+	s.mu.Unlock()
+	if !initialized {
+		return nil
+	}
+	var trailingMeta []execinfrapb.ProducerMetadata
+	if tfs := execinfra.GetLeafTxnFinalState(ctx, s.flowCtx.Txn); tfs != nil {
+		trailingMeta = append(trailingMeta, execinfrapb.ProducerMetadata{LeafTxnFinalState: tfs})
+	}
+	meta := execinfrapb.GetProducerMeta()
+	meta.Metrics = execinfrapb.GetMetricsMeta()
+	meta.Metrics.BytesRead = s.GetBytesRead()
+	meta.Metrics.RowsRead = s.GetRowsRead()
+	trailingMeta = append(trailingMeta, *meta)
+	if s.tracingSpan != nil {
+		// See the comment in ColBatchScan.DrainMeta.
+		s.mu.Lock()
+		traceCtx := s.mu.ctx
+		s.mu.Unlock()
+		if trace := execinfra.GetTraceData(traceCtx); trace != nil {
+			trailingMeta = append(trailingMeta, execinfrapb.ProducerMetadata{TraceData: trace})
+		}
+	}
+	return trailingMeta
+}
+
+// SetBatchSizeBytes sets the desired size of the spans that are looked up at
+// once. It should only be used in tests.
+func (s *ColIndexJoin) SetBatchSizeBytes(batchSize int64) {
+	s.batchSizeBytes = batchSize
+}
+
+// GetBytesRead is part of the colexecop.KVReader interface.
+func (s *ColIndexJoin) GetBytesRead() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Note that if no lookups have been performed yet, s.rf.fetcher is nil,
+	// and GetBytesRead() returns 0 for it.
+	return s.mu.bytesRead + s.rf.fetcher.GetBytesRead()
+}
+
+// GetRowsRead is part of the colexecop.KVReader interface.
+func (s *ColIndexJoin) GetRowsRead() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mu.rowsRead
+}
+
+// GetCumulativeContentionTime is part of the colexecop.KVReader interface.
+func (s *ColIndexJoin) GetCumulativeContentionTime() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.mu.ctx == nil {
+		// Next was never called, so there was no contention events.
+		return 0
+	}
+	return execinfra.GetCumulativeContentionTime(s.mu.ctx)
+}
+
+// NewColIndexJoin creates a new ColIndexJoin operator. inputTypes are the
+// types of the input, the first columns of which must be the primary key
+// columns of the table.
+func NewColIndexJoin(
+	ctx context.Context,
+	allocator *colmem.Allocator,
+	flowCtx *execinfra.FlowCtx,
+	evalCtx *tree.EvalContext,
+	input colexecop.Operator,
+	inputTypes []*types.T,
+	spec *execinfrapb.JoinReaderSpec,
+	post *execinfrapb.PostProcessSpec,
+	estimatedRowCount uint64,
+) (*ColIndexJoin, error) {
+	// NB: we hit this with a zero NodeID (but !ok) with multi-tenancy.
+	if nodeID, ok := flowCtx.NodeID.OptionalNodeID(); nodeID == 0 && ok {
+		return nil, errors.Errorf("attempting to create a ColIndexJoin with uninitialized NodeID")
+	}
+	if len(spec.LookupColumns) != 0 || !spec.LookupExpr.Empty() {
+		return nil, errors.AssertionFailedf("lookup joins are not supported by ColIndexJoin")
+	}
+	if spec.IndexIdx != 0 {
+		return nil, errors.AssertionFailedf("index join must be against primary index")
+	}
+	if spec.Type != descpb.InnerJoin {
+		return nil, errors.AssertionFailedf("only inner index joins are supported, %s requested", spec.Type)
+	}
+	if !spec.OnExpr.Empty() {
+		return nil, errors.AssertionFailedf("non-empty ON expressions are not supported for index joins")
+	}
+
+	table := spec.BuildTableDescriptor()
+	cols := table.PublicColumns()
+	if spec.Visibility == execinfra.ScanVisibilityPublicAndNotPublic {
+		cols = table.DeletableColumns()
+	}
+	columnIdxMap := catalog.ColumnIDToOrdinalMap(cols)
+	typs := catalog.ColumnTypes(cols)
+
+	// Add all requested system columns to the output.
+	if spec.HasSystemColumns {
+		for _, sysCol := range table.SystemColumns() {
+			typs = append(typs, sysCol.GetType())
+			columnIdxMap.Set(sysCol.GetID(), columnIdxMap.Len())
+		}
+	}
+
+	// See the comment in NewColBatchScan on why the types need to be hydrated.
+	resolver := flowCtx.TypeResolverFactory.NewTypeResolver(evalCtx.Txn)
+	if err := resolver.HydrateTypeSlice(ctx, typs); err != nil {
+		return nil, err
+	}
+
+	// The output of the index join before the post-processing consists of all
+	// columns of the table, so only the columns needed by the post-processing
+	// have to be fetched.
+	var neededColumns util.FastIntSet
+	if post.Projection && len(post.RenderExprs) == 0 {
+		for _, neededColumn := range post.OutputColumns {
+			neededColumns.Add(int(neededColumn))
+		}
+	} else {
+		neededColumns.AddRange(0, len(typs)-1)
+	}
+
+	fetcher := cFetcherPool.Get().(*cFetcher)
+	fetcher.estimatedRowCount = estimatedRowCount
+	index, _, err := initCRowFetcher(
+		flowCtx.Codec(), allocator, execinfra.GetWorkMemLimit(flowCtx.Cfg), fetcher, table,
+		columnIdxMap, neededColumns, &execinfrapb.TableReaderSpec{
+			Visibility:        spec.Visibility,
+			LockingStrength:   spec.LockingStrength,
+			LockingWaitPolicy: spec.LockingWaitPolicy,
+		}, spec.HasSystemColumns,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	numKeyCols := len(index.ColumnIDs)
+	if len(inputTypes) < numKeyCols {
+		return nil, errors.AssertionFailedf(
+			"the input has %d columns, expected at least %d primary key columns", len(inputTypes), numKeyCols,
+		)
+	}
+	keyVecIdxs := make([]int, numKeyCols)
+	for i := range keyVecIdxs {
+		keyVecIdxs[i] = i
+	}
+	spanBuilder := span.MakeBuilder(evalCtx, flowCtx.Codec(), table, index)
+	spanBuilder.SetNeededColumns(neededColumns)
+
+	batchSizeBytes := int64(indexJoinBatchSizeBytes)
+	if memoryLimit := execinfra.GetWorkMemLimit(flowCtx.Cfg); memoryLimit < batchSizeBytes {
+		batchSizeBytes = memoryLimit
+	}
+
+	return &ColIndexJoin{
+		OneInputNode:     colexecop.NewOneInputNode(input),
+		flowCtx:          flowCtx,
+		rf:               fetcher,
+		spanBuilder:      spanBuilder,
+		numKeyCols:       numKeyCols,
+		keyTypes:         inputTypes[:numKeyCols],
+		keyRow:           make(rowenc.EncDatumRow, numKeyCols),
+		converter:        colconv.NewVecToDatumConverter(len(inputTypes), keyVecIdxs),
+		batchSizeBytes:   batchSizeBytes,
+		allocator:        allocator,
+		maintainOrdering: spec.MaintainOrdering,
+		ResultTypes:      typs,
+	}, nil
+}
+
+// Release implements the execinfra.Releasable interface.
+func (s *ColIndexJoin) Release() {
+	s.rf.Release()
+	s.converter.Release()
+	*s = ColIndexJoin{}
+}
+
+// Close implements the colexecop.Closer interface.
+func (s *ColIndexJoin) Close(context.Context) error {
+	if s.tracingSpan != nil {
+		s.tracingSpan.Finish()
+		s.tracingSpan = nil
+	}
+	return nil
+}
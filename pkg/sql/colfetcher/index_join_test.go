@@ -0,0 +1,176 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colfetcher_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catalogkv"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colfetcher"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/skip"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+// countingOp counts the number of batches requested from its input.
+type countingOp struct {
+	colexecop.OneInputNode
+	numNextCalls int
+}
+
+var _ colexecop.Operator = &countingOp{}
+
+func (c *countingOp) Init() {
+	c.Input.Init()
+}
+
+func (c *countingOp) Next(ctx context.Context) coldata.Batch {
+	c.numNextCalls++
+	return c.Input.Next(ctx)
+}
+
+// TestColIndexJoinBatching verifies that ColIndexJoin looks up the spans
+// generated from as many input batches as fit into its batch size, which is
+// limited by the workmem limit, and that all of the rows are looked up
+// regardless of the batching.
+func TestColIndexJoinBatching(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	skip.UnderMetamorphic(t, "This test doesn't work with metamorphic batch sizes.")
+
+	ctx := context.Background()
+	s, sqlDB, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+
+	const numRows = 10
+	sqlutils.CreateTable(t, sqlDB, "t",
+		"a INT PRIMARY KEY, b INT",
+		numRows,
+		sqlutils.ToRowFn(sqlutils.RowIdxFn, sqlutils.RowModuloFn(3)))
+	td := catalogkv.TestingGetTableDescriptor(kvDB, keys.SystemSQLCodec, "test", "t")
+
+	st := s.ClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	streamingMemAcc := evalCtx.Mon.MakeBoundAccount()
+	defer streamingMemAcc.Close(ctx)
+	allocator := colmem.NewAllocator(ctx, &streamingMemAcc, coldata.StandardColumnFactory)
+
+	// The input contains the primary keys of all rows, two per batch.
+	const inputBatchSize = 2
+	var input colexectestutils.Tuples
+	for i := 1; i <= numRows; i++ {
+		input = append(input, colexectestutils.Tuple{i})
+	}
+	inputTypes := []*types.T{types.Int}
+
+	for _, tc := range []struct {
+		name           string
+		memoryLimit    int64
+		batchSizeBytes int64
+		// expectedFirstLookup is the number of rows returned by the first
+		// lookup.
+		expectedFirstLookup int
+		// expectedNextCalls is the number of batches requested from the input
+		// by the first lookup.
+		expectedNextCalls int
+	}{
+		{
+			// All spans are looked up at once, and the input is exhausted.
+			name:                "default",
+			expectedFirstLookup: numRows,
+			expectedNextCalls:   numRows/inputBatchSize + 1,
+		},
+		{
+			name:                "batch-size",
+			batchSizeBytes:      1,
+			expectedFirstLookup: inputBatchSize,
+			expectedNextCalls:   1,
+		},
+		{
+			// The spans of a single lookup must not exceed the workmem limit.
+			name:                "memory-limit",
+			memoryLimit:         1,
+			expectedFirstLookup: inputBatchSize,
+			expectedNextCalls:   1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			flowCtx := execinfra.FlowCtx{
+				EvalCtx: &evalCtx,
+				Cfg: &execinfra.ServerConfig{
+					Settings: st,
+					TestingKnobs: execinfra.TestingKnobs{
+						MemoryLimitBytes: tc.memoryLimit,
+					},
+				},
+				Txn:    kv.NewTxn(ctx, s.DB(), s.NodeID()),
+				Local:  true,
+				NodeID: evalCtx.NodeID,
+			}
+			source := &countingOp{
+				OneInputNode: colexecop.NewOneInputNode(
+					colexectestutils.NewOpTestInput(allocator, inputBatchSize, input, inputTypes),
+				),
+			}
+			// The estimated row count makes the fetcher allocate a batch that
+			// fits all rows of the table.
+			indexJoin, err := colfetcher.NewColIndexJoin(
+				ctx, allocator, &flowCtx, &evalCtx, source, inputTypes,
+				&execinfrapb.JoinReaderSpec{Table: *td.TableDesc(), MaintainOrdering: true},
+				&execinfrapb.PostProcessSpec{}, numRows, /* estimatedRowCount */
+			)
+			require.NoError(t, err)
+			defer indexJoin.Release()
+			if tc.batchSizeBytes != 0 {
+				indexJoin.SetBatchSizeBytes(tc.batchSizeBytes)
+			}
+
+			indexJoin.Init()
+			var numLookedUp int
+			for {
+				b := indexJoin.Next(ctx)
+				if numLookedUp == 0 {
+					require.Equal(t, tc.expectedFirstLookup, b.Length())
+					require.Equal(t, tc.expectedNextCalls, source.numNextCalls)
+				}
+				if b.Length() == 0 {
+					break
+				}
+				a, bCol := b.ColVec(0).Int64(), b.ColVec(1).Int64()
+				for i := 0; i < b.Length(); i++ {
+					numLookedUp++
+					// The rows are looked up in the order of the input.
+					require.Equal(t, int64(numLookedUp), a[i])
+					require.Equal(t, int64(numLookedUp%3), bCol[i])
+				}
+			}
+			require.Equal(t, numRows, numLookedUp)
+			require.Equal(t, int64(numRows), indexJoin.GetRowsRead())
+			require.NoError(t, indexJoin.Close(ctx))
+		})
+	}
+}
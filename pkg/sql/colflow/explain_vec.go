@@ -12,6 +12,7 @@ package colflow
 
 import (
 	"context"
+	"encoding/json"
 	"math"
 	"reflect"
 	"sort"
@@ -32,24 +33,25 @@ import (
 // returning a list of the leap operators or an error if the flow vectorization
 // is not supported. Note that it does so by setting up the full flow without
 // running the components asynchronously, so it is pretty expensive.
-// It also returns a non-nil cleanup function that releases all
-// execinfra.Releasable objects which can *only* be performed once leaves are
-// no longer needed.
+// It also returns the flow creator that was used to set up the flow as well as
+// a non-nil cleanup function that releases all execinfra.Releasable objects
+// (including the creator) which can *only* be performed once leaves and the
+// creator are no longer needed.
 func convertToVecTree(
 	ctx context.Context,
 	flowCtx *execinfra.FlowCtx,
 	flow *execinfrapb.FlowSpec,
 	localProcessors []execinfra.LocalProcessor,
 	isPlanLocal bool,
-) (leaves []execinfra.OpNode, cleanup func(), err error) {
+) (leaves []execinfra.OpNode, creator *vectorizedFlowCreator, cleanup func(), err error) {
 	if !isPlanLocal && len(localProcessors) > 0 {
-		return nil, func() {}, errors.AssertionFailedf("unexpectedly non-empty LocalProcessors when plan is not local")
+		return nil, nil, func() {}, errors.AssertionFailedf("unexpectedly non-empty LocalProcessors when plan is not local")
 	}
 	fuseOpt := flowinfra.FuseNormally
 	if isPlanLocal {
 		fuseOpt = flowinfra.FuseAggressively
 	}
-	creator := newVectorizedFlowCreator(
+	creator = newVectorizedFlowCreator(
 		newNoopFlowCreatorHelper(), vectorizedRemoteComponentCreator{}, false, false,
 		nil, &execinfra.RowChannel{}, nil, execinfrapb.FlowID{}, colcontainer.DiskQueueCfg{},
 		flowCtx.Cfg.VecFDSemaphore, flowCtx.TypeResolverFactory.NewTypeResolver(flowCtx.EvalCtx.Txn),
@@ -71,7 +73,7 @@ func convertToVecTree(
 	defer memoryMonitor.Stop(ctx)
 	defer creator.cleanup(ctx)
 	leaves, err = creator.setupFlow(ctx, flowCtx, flow.Processors, localProcessors, fuseOpt)
-	return leaves, creator.Release, err
+	return leaves, creator, creator.Release, err
 }
 
 type flowWithNode struct {
@@ -79,17 +81,17 @@ type flowWithNode struct {
 	flow   *execinfrapb.FlowSpec
 }
 
-// ExplainVec converts the flows (that are assumed to be vectorizable) into the
-// corresponding string representation.
-func ExplainVec(
+// convertSortedFlows converts each of the flows into a tree of vectorized
+// operators, in the order of node IDs, and calls visit on each of them. The
+// leaves and the creator passed to visit are only valid during the call.
+func convertSortedFlows(
 	ctx context.Context,
 	flowCtx *execinfra.FlowCtx,
 	flows map[roachpb.NodeID]*execinfrapb.FlowSpec,
 	localProcessors []execinfra.LocalProcessor,
-	verbose bool,
 	distributed bool,
-) ([]string, error) {
-	tp := treeprinter.NewWithStyle(treeprinter.CompactStyle)
+	visit func(nodeID roachpb.NodeID, leaves []execinfra.OpNode, creator *vectorizedFlowCreator),
+) error {
 	var conversionErr error
 	// It is possible that when iterating over execinfra.OpNodes we will hit a
 	// panic (an input that doesn't implement OpNode interface), so we're
@@ -102,28 +104,98 @@ func ExplainVec(
 		// Sort backward, since the first thing you add to a treeprinter will come
 		// last.
 		sort.Slice(sortedFlows, func(i, j int) bool { return sortedFlows[i].nodeID < sortedFlows[j].nodeID })
-		root := tp.Child("│")
 		for _, flow := range sortedFlows {
-			node := root.Childf("Node %d", flow.nodeID)
-			opChains, cleanup, err := convertToVecTree(ctx, flowCtx, flow.flow, localProcessors, !distributed)
+			leaves, creator, cleanup, err := convertToVecTree(ctx, flowCtx, flow.flow, localProcessors, !distributed)
 			defer cleanup()
 			if err != nil {
 				conversionErr = err
 				return
 			}
+			visit(flow.nodeID, leaves, creator)
+		}
+	}); err != nil {
+		return err
+	}
+	return conversionErr
+}
+
+// ExplainVec converts the flows (that are assumed to be vectorizable) into the
+// corresponding string representation. If verbose is true, the row execution
+// processors that were wrapped into the flows are listed together with the
+// reasons for the wrapping.
+func ExplainVec(
+	ctx context.Context,
+	flowCtx *execinfra.FlowCtx,
+	flows map[roachpb.NodeID]*execinfrapb.FlowSpec,
+	localProcessors []execinfra.LocalProcessor,
+	verbose bool,
+	distributed bool,
+) ([]string, error) {
+	tp := treeprinter.NewWithStyle(treeprinter.CompactStyle)
+	root := tp.Child("│")
+	if err := convertSortedFlows(
+		ctx, flowCtx, flows, localProcessors, distributed,
+		func(nodeID roachpb.NodeID, opChains []execinfra.OpNode, creator *vectorizedFlowCreator) {
+			node := root.Childf("Node %d", nodeID)
 			for _, op := range opChains {
 				formatOpChain(op, node, verbose)
 			}
-		}
-	}); err != nil {
+			if verbose {
+				for _, w := range creator.wrappedProcessors {
+					node.Childf("wrapped %s processor %d: %v", w.Core, w.processorID, w.Reason)
+				}
+			}
+		},
+	); err != nil {
 		return nil, err
 	}
-	if conversionErr != nil {
-		return nil, conversionErr
-	}
 	return tp.FormattedRows(), nil
 }
 
+// ExplainVecJSON is similar to ExplainVec, but it returns the JSON
+// representation of the vectorized operator trees. In addition to the
+// operators, the representation includes the row execution processors that
+// were wrapped into the flows, the boundaries between the row and the columnar
+// execution, as well as the batch size and the memory limits of the operators,
+// so that the plans can be visualized by external tools.
+func ExplainVecJSON(
+	ctx context.Context,
+	flowCtx *execinfra.FlowCtx,
+	flows map[roachpb.NodeID]*execinfrapb.FlowSpec,
+	localProcessors []execinfra.LocalProcessor,
+	verbose bool,
+	distributed bool,
+) (string, error) {
+	plan, err := makeVecPlan(ctx, flowCtx, flows, localProcessors, verbose, distributed)
+	if err != nil {
+		return "", err
+	}
+	res, err := json.Marshal(plan)
+	if err != nil {
+		return "", err
+	}
+	return string(res), nil
+}
+
+// ExplainVecDOT is similar to ExplainVecJSON, but it returns the
+// representation of the vectorized operator trees in the DOT language of
+// Graphviz, one line per string. The edges point in the direction of the data
+// flow.
+func ExplainVecDOT(
+	ctx context.Context,
+	flowCtx *execinfra.FlowCtx,
+	flows map[roachpb.NodeID]*execinfrapb.FlowSpec,
+	localProcessors []execinfra.LocalProcessor,
+	verbose bool,
+	distributed bool,
+) ([]string, error) {
+	plan, err := makeVecPlan(ctx, flowCtx, flows, localProcessors, verbose, distributed)
+	if err != nil {
+		return nil, err
+	}
+	return plan.formatDOT(), nil
+}
+
 func shouldOutput(operator execinfra.OpNode, verbose bool) bool {
 	_, nonExplainable := operator.(colexecop.NonExplainable)
 	return !nonExplainable || verbose
@@ -0,0 +1,252 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colflow
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
+)
+
+// vecPlan is the structured representation of the vectorized operator trees
+// of all flows of a query that is used by EXPLAIN (VEC, JSON) and EXPLAIN
+// (VEC, DOT).
+type vecPlan struct {
+	// BatchSize is the maximum number of tuples in a batch.
+	BatchSize int       `json:"batchSize"`
+	Flows     []vecFlow `json:"flows"`
+}
+
+// vecFlow describes the vectorized operator tree of a single flow.
+type vecFlow struct {
+	NodeID roachpb.NodeID `json:"nodeID"`
+	// Operators contains all operators of the flow with the operator ID being
+	// the position in this slice. Note that an operator can be an input to
+	// several operators, so the operators form a DAG.
+	Operators []*vecOperator `json:"operators"`
+	// Roots contains the IDs of the operators that are the roots of the trees
+	// (for example, outboxes and materializers).
+	Roots             []int                 `json:"roots"`
+	WrappedProcessors []vecWrappedProcessor `json:"wrappedProcessors,omitempty"`
+}
+
+// vecOperator describes a single vectorized operator.
+type vecOperator struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// Boundary, if set, indicates that the operator is a boundary between the
+	// row and the columnar execution (either "materializer" or
+	// "columnarizer").
+	Boundary string `json:"boundary,omitempty"`
+	// ProcessorID, if set, indicates that the operator is the root of the
+	// operator chain created for the processor with the given ID (or, if the
+	// root is not outputted, the first outputted operator below it).
+	ProcessorID *int32 `json:"processorID,omitempty"`
+	// EstimatedBatchSize is the estimated number of tuples in a batch produced
+	// by the operator. It is the batch size capped at the number of rows that
+	// the optimizer expects the processor to emit, and it is only set if
+	// ProcessorID is set. The operators without the processor ID are expected
+	// to produce full batches.
+	EstimatedBatchSize int `json:"estimatedBatchSize,omitempty"`
+	// MemoryLimits contains the memory limits of the monitors used by the
+	// operator chain of the processor. It is only set if ProcessorID is set.
+	MemoryLimits []vecMemoryLimit `json:"memoryLimits,omitempty"`
+	// Inputs contains the IDs of the input operators.
+	Inputs []int `json:"inputs,omitempty"`
+}
+
+// vecMemoryLimit describes the memory limit of a single memory monitor.
+type vecMemoryLimit struct {
+	Monitor string `json:"monitor"`
+	// Limit is the limit in bytes. It is omitted if the monitor doesn't have
+	// a local limit.
+	Limit int64 `json:"limit,omitempty"`
+}
+
+// vecWrappedProcessor describes a row execution processor that was wrapped
+// into the flow.
+type vecWrappedProcessor struct {
+	ProcessorID int32  `json:"processorID"`
+	Core        string `json:"core"`
+	Reason      string `json:"reason"`
+}
+
+// makeVecPlan converts the flows into their structured representation.
+func makeVecPlan(
+	ctx context.Context,
+	flowCtx *execinfra.FlowCtx,
+	flows map[roachpb.NodeID]*execinfrapb.FlowSpec,
+	localProcessors []execinfra.LocalProcessor,
+	verbose bool,
+	distributed bool,
+) (*vecPlan, error) {
+	plan := &vecPlan{BatchSize: coldata.BatchSize()}
+	if err := convertSortedFlows(
+		ctx, flowCtx, flows, localProcessors, distributed,
+		func(nodeID roachpb.NodeID, leaves []execinfra.OpNode, creator *vectorizedFlowCreator) {
+			plan.Flows = append(plan.Flows, makeVecFlow(nodeID, leaves, creator, verbose))
+		},
+	); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func makeVecFlow(
+	nodeID roachpb.NodeID,
+	leaves []execinfra.OpNode,
+	creator *vectorizedFlowCreator,
+	verbose bool,
+) vecFlow {
+	f := vecFlow{NodeID: nodeID}
+	processorOps := make(map[reflect.Value]processorOp, len(creator.processorOps))
+	for _, p := range creator.processorOps {
+		processorOps[reflect.ValueOf(p.op)] = p
+	}
+	annotate := func(o *vecOperator, p processorOp) {
+		processorID := p.processorID
+		o.ProcessorID = &processorID
+		o.EstimatedBatchSize = coldata.BatchSize()
+		if p.estimatedRowCount != 0 && p.estimatedRowCount < uint64(o.EstimatedBatchSize) {
+			o.EstimatedBatchSize = int(p.estimatedRowCount)
+		}
+		for _, m := range creator.processorMemMonitors {
+			if m.processorID != processorID {
+				continue
+			}
+			limit := m.monitor.Limit()
+			if limit == math.MaxInt64 {
+				limit = 0
+			}
+			o.MemoryLimits = append(o.MemoryLimits, vecMemoryLimit{
+				Monitor: m.monitor.Name(),
+				Limit:   limit,
+			})
+		}
+	}
+	// seenOps maps each already visited operator to the IDs of the operators
+	// that represent it in the plan (either the operator itself or, if it is
+	// not outputted, its outputted inputs).
+	seenOps := make(map[reflect.Value][]int)
+	var visit func(op execinfra.OpNode) []int
+	visit = func(op execinfra.OpNode) []int {
+		opValue := reflect.ValueOf(op)
+		if ids, seenOp := seenOps[opValue]; seenOp {
+			return ids
+		}
+		var inputs []int
+		for i := 0; i < op.ChildCount(verbose); i++ {
+			inputs = append(inputs, visit(op.Child(i, verbose))...)
+		}
+		p, isProcessorOp := processorOps[opValue]
+		if !shouldOutput(op, verbose) {
+			// The root of the operator chain of the processor is not
+			// outputted (for example, it is an invariants checker), so we
+			// annotate the first outputted operator below it instead, unless
+			// that operator belongs to another processor.
+			if isProcessorOp && len(inputs) > 0 && f.Operators[inputs[0]].ProcessorID == nil {
+				annotate(f.Operators[inputs[0]], p)
+			}
+			seenOps[opValue] = inputs
+			return inputs
+		}
+		o := &vecOperator{
+			ID:     len(f.Operators),
+			Name:   reflect.TypeOf(op).String(),
+			Inputs: inputs,
+		}
+		switch op.(type) {
+		case *colexec.Materializer:
+			o.Boundary = "materializer"
+		case *colexec.Columnarizer:
+			o.Boundary = "columnarizer"
+		}
+		if isProcessorOp {
+			annotate(o, p)
+		}
+		f.Operators = append(f.Operators, o)
+		ids := []int{o.ID}
+		seenOps[opValue] = ids
+		return ids
+	}
+	for _, leaf := range leaves {
+		f.Roots = append(f.Roots, visit(leaf)...)
+	}
+	for _, w := range creator.wrappedProcessors {
+		f.WrappedProcessors = append(f.WrappedProcessors, vecWrappedProcessor{
+			ProcessorID: w.processorID,
+			Core:        w.Core,
+			Reason:      w.Reason.Error(),
+		})
+	}
+	return f
+}
+
+// formatDOT returns the representation of the plan in the DOT language, one
+// line per string. Each flow is a separate cluster, the boundaries between the
+// row and the columnar execution are highlighted, and the row execution
+// processors that were wrapped into the flow are listed in the label of the
+// cluster.
+func (p *vecPlan) formatDOT() []string {
+	lines := []string{
+		"digraph vectorized {",
+		fmt.Sprintf("  label=%s;", strconv.Quote(fmt.Sprintf("batch size: %d", p.BatchSize))),
+		"  node [shape=box];",
+	}
+	for _, f := range p.Flows {
+		label := fmt.Sprintf("Node %d", f.NodeID)
+		for _, w := range f.WrappedProcessors {
+			label += fmt.Sprintf("\nwrapped %s processor %d: %s", w.Core, w.ProcessorID, w.Reason)
+		}
+		lines = append(lines,
+			fmt.Sprintf("  subgraph cluster_%d {", f.NodeID),
+			fmt.Sprintf("    label=%s;", strconv.Quote(label)),
+		)
+		opName := func(id int) string {
+			return fmt.Sprintf("n%d_op%d", f.NodeID, id)
+		}
+		for _, o := range f.Operators {
+			var attrs strings.Builder
+			opLabel := o.Name
+			if o.ProcessorID != nil {
+				opLabel += fmt.Sprintf("\nprocessor %d", *o.ProcessorID)
+			}
+			if o.EstimatedBatchSize != 0 && o.EstimatedBatchSize != p.BatchSize {
+				opLabel += fmt.Sprintf("\nestimated batch size: %d", o.EstimatedBatchSize)
+			}
+			for _, l := range o.MemoryLimits {
+				if l.Limit != 0 {
+					opLabel += fmt.Sprintf("\n%s: %s", l.Monitor, humanizeutil.IBytes(l.Limit))
+				}
+			}
+			fmt.Fprintf(&attrs, "label=%s", strconv.Quote(opLabel))
+			if o.Boundary != "" {
+				attrs.WriteString(", style=filled, fillcolor=lightgrey")
+			}
+			lines = append(lines, fmt.Sprintf("    %s [%s];", opName(o.ID), attrs.String()))
+			for _, input := range o.Inputs {
+				lines = append(lines, fmt.Sprintf("    %s -> %s;", opName(input), opName(o.ID)))
+			}
+		}
+		lines = append(lines, "  }")
+	}
+	return append(lines, "}")
+}
@@ -0,0 +1,138 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colflow
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/stretchr/testify/require"
+)
+
+// nonExplainableOp is a pass-through operator that is not outputted by EXPLAIN
+// (VEC) in the non-verbose mode.
+type nonExplainableOp struct {
+	colexecop.OneInputNode
+	colexecop.NonExplainable
+}
+
+var _ colexecop.Operator = &nonExplainableOp{}
+
+func (o *nonExplainableOp) Init() {
+	o.Input.Init()
+}
+
+func (o *nonExplainableOp) Next(ctx context.Context) coldata.Batch {
+	return o.Input.Next(ctx)
+}
+
+// TestMakeVecFlowProcessorAnnotations verifies that the processor ID, the
+// estimated batch size, and the memory limits are attached to the first
+// outputted operator of the chain created for the processor.
+func TestMakeVecFlowProcessorAnnotations(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	st := cluster.MakeTestingClusterSettings()
+	const memoryLimit = 1 << 10
+	memMon := mon.NewMonitorWithLimit(
+		"test-mem", mon.MemoryResource, memoryLimit, nil, nil, 0, math.MaxInt64, st,
+	)
+
+	for _, tc := range []struct {
+		name    string
+		verbose bool
+		// leafIsProcessorOp, if set, indicates that the leaf is the root of
+		// the chain of another processor.
+		leafIsProcessorOp bool
+		// expectedOps is the number of outputted operators.
+		expectedOps int
+		// annotatedOp is the ID of the operator annotated with the processor.
+		annotatedOp int
+	}{
+		{
+			name:        "non-verbose",
+			expectedOps: 1,
+			annotatedOp: 0,
+		},
+		{
+			name:        "verbose",
+			verbose:     true,
+			expectedOps: 2,
+			annotatedOp: 1,
+		},
+		{
+			name:              "leaf-of-other-processor",
+			leafIsProcessorOp: true,
+			expectedOps:       1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			const processorID, estimatedRowCount = 1, 3
+			leaf := &colexecop.CallbackOperator{}
+			root := &nonExplainableOp{OneInputNode: colexecop.NewOneInputNode(leaf)}
+			creator := &vectorizedFlowCreator{
+				processorOps: []processorOp{{
+					processorID:       processorID,
+					op:                root,
+					estimatedRowCount: estimatedRowCount,
+				}},
+				processorMemMonitors: []processorMemMonitor{{
+					processorID: processorID,
+					monitor:     memMon,
+				}},
+			}
+			if tc.leafIsProcessorOp {
+				creator.processorOps = append(creator.processorOps, processorOp{
+					processorID: processorID - 1,
+					op:          leaf,
+				})
+			}
+
+			f := makeVecFlow(1 /* nodeID */, []execinfra.OpNode{root}, creator, tc.verbose)
+			require.Len(t, f.Operators, tc.expectedOps)
+			if tc.leafIsProcessorOp {
+				// The annotations of the other processor must not be
+				// overwritten.
+				o := f.Operators[0]
+				require.NotNil(t, o.ProcessorID)
+				require.Equal(t, int32(processorID-1), *o.ProcessorID)
+				require.Equal(t, coldata.BatchSize(), o.EstimatedBatchSize)
+				require.Empty(t, o.MemoryLimits)
+				return
+			}
+			for _, o := range f.Operators {
+				if o.ID != tc.annotatedOp {
+					require.Nil(t, o.ProcessorID)
+					require.Zero(t, o.EstimatedBatchSize)
+					require.Empty(t, o.MemoryLimits)
+					continue
+				}
+				require.NotNil(t, o.ProcessorID)
+				require.Equal(t, int32(processorID), *o.ProcessorID)
+				expectedBatchSize := estimatedRowCount
+				if coldata.BatchSize() < expectedBatchSize {
+					expectedBatchSize = coldata.BatchSize()
+				}
+				require.Equal(t, expectedBatchSize, o.EstimatedBatchSize)
+				require.Equal(t, []vecMemoryLimit{{Monitor: "test-mem", Limit: memoryLimit}}, o.MemoryLimits)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
 	"github.com/cockroachdb/cockroach/pkg/sql/colflow/colrpc"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/util/mon"
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
@@ -118,6 +119,9 @@ func (bic *batchInfoCollector) getElapsedTime() time.Duration {
 // StreamID 'id' (with 'idTagKey' distinguishing between the two). 'kvReader' is
 // a component (either an operator or a wrapped processor) that performs KV
 // reads that is present in the chain of operators rooted at 'op'.
+// 'wrappedProcessorsStats' are the statistics of the row-execution processors
+// that were wrapped into the chain of operators rooted at 'op' (and that have
+// the same ProcessorID).
 func newVectorizedStatsCollector(
 	op colexecop.Operator,
 	kvReader colexecop.KVReader,
@@ -126,14 +130,16 @@ func newVectorizedStatsCollector(
 	memMonitors []*mon.BytesMonitor,
 	diskMonitors []*mon.BytesMonitor,
 	inputStatsCollectors []childStatsCollector,
+	wrappedProcessorsStats []*execinfra.DelegatedExecStats,
 ) colexec.VectorizedStatsCollector {
 	// TODO(cathymw): Refactor to have specialized stats collectors for
 	// memory/disk stats and IO operators.
 	return &vectorizedStatsCollectorImpl{
-		batchInfoCollector: makeBatchInfoCollector(op, id, inputWatch, inputStatsCollectors),
-		kvReader:           kvReader,
-		memMonitors:        memMonitors,
-		diskMonitors:       diskMonitors,
+		batchInfoCollector:     makeBatchInfoCollector(op, id, inputWatch, inputStatsCollectors),
+		kvReader:               kvReader,
+		memMonitors:            memMonitors,
+		diskMonitors:           diskMonitors,
+		wrappedProcessorsStats: wrappedProcessorsStats,
 	}
 }
 
@@ -142,9 +148,10 @@ func newVectorizedStatsCollector(
 type vectorizedStatsCollectorImpl struct {
 	batchInfoCollector
 
-	kvReader     colexecop.KVReader
-	memMonitors  []*mon.BytesMonitor
-	diskMonitors []*mon.BytesMonitor
+	kvReader               colexecop.KVReader
+	memMonitors            []*mon.BytesMonitor
+	diskMonitors           []*mon.BytesMonitor
+	wrappedProcessorsStats []*execinfra.DelegatedExecStats
 }
 
 // GetStats is part of the colexec.VectorizedStatsCollector interface.
@@ -179,6 +186,23 @@ func (vsc *vectorizedStatsCollectorImpl) GetStats() *execinfrapb.ComponentStats
 
 	s.Output.NumBatches.Set(numBatches)
 	s.Output.NumTuples.Set(numTuples)
+
+	for _, stats := range vsc.wrappedProcessorsStats {
+		ps := stats.Get()
+		if ps == nil {
+			// The wrapped processor hasn't finished yet (e.g. because of a
+			// limit), so it will record its statistics to its span itself.
+			continue
+		}
+		// The memory and the disk usage of the wrapped processor isn't tracked
+		// by the monitors above, so we add it. All other statistics that are
+		// set by the wrapped processor are either not set above (like the KV
+		// statistics and the input statistics) or describe the same thing
+		// (like the output statistics).
+		s.Exec.MaxAllocatedMem.MaybeAdd(ps.Exec.MaxAllocatedMem)
+		s.Exec.MaxAllocatedDisk.MaybeAdd(ps.Exec.MaxAllocatedDisk)
+		s = s.Union(ps)
+	}
 	return s
 }
 
@@ -42,7 +42,7 @@ func TestNumBatches(t *testing.T) {
 	vsc := newVectorizedStatsCollector(
 		noop, nil /* kvReader */, execinfrapb.ComponentID{},
 		timeutil.NewStopWatch(), nil /* memMonitors */, nil, /* diskMonitors */
-		nil, /* inputStatsCollectors */
+		nil /* inputStatsCollectors */, nil, /* wrappedProcessorsStats */
 	)
 	vsc.Init()
 	for {
@@ -68,7 +68,7 @@ func TestNumTuples(t *testing.T) {
 		vsc := newVectorizedStatsCollector(
 			noop, nil /* kvReader */, execinfrapb.ComponentID{},
 			timeutil.NewStopWatch(), nil /* memMonitors */, nil, /* diskMonitors */
-			nil, /* inputStatsCollectors */
+			nil /* inputStatsCollectors */, nil, /* wrappedProcessorsStats */
 		)
 		vsc.Init()
 		for {
@@ -104,7 +104,7 @@ func TestVectorizedStatsCollector(t *testing.T) {
 		leftInput := newVectorizedStatsCollector(
 			leftSource, nil /* kvReader */, execinfrapb.ComponentID{ID: 0},
 			timeutil.NewTestStopWatch(timeSource.Now), nil /* memMonitors */, nil, /* diskMonitors */
-			nil, /* inputStatsCollectors */
+			nil /* inputStatsCollectors */, nil, /* wrappedProcessorsStats */
 		)
 		rightSource := &timeAdvancingOperator{
 			OneInputNode: colexecop.NewOneInputNode(makeFiniteChunksSourceWithBatchSize(tu.testAllocator, nBatches, coldata.BatchSize())),
@@ -113,7 +113,7 @@ func TestVectorizedStatsCollector(t *testing.T) {
 		rightInput := newVectorizedStatsCollector(
 			rightSource, nil /* kvReader */, execinfrapb.ComponentID{ID: 1},
 			timeutil.NewTestStopWatch(timeSource.Now), nil /* memMonitors */, nil, /* diskMonitors */
-			nil, /* inputStatsCollectors */
+			nil /* inputStatsCollectors */, nil, /* wrappedProcessorsStats */
 		)
 		mergeJoiner, err := colexecjoin.NewMergeJoinOp(
 			tu.testAllocator, colexecop.DefaultMemoryLimit, queueCfg,
@@ -135,6 +135,7 @@ func TestVectorizedStatsCollector(t *testing.T) {
 			timeAdvancingMergeJoiner, nil /* kvReader */, execinfrapb.ComponentID{ID: 2},
 			mjInputWatch, nil /* memMonitors */, nil, /* diskMonitors */
 			[]childStatsCollector{leftInput.(childStatsCollector), rightInput.(childStatsCollector)},
+			nil, /* wrappedProcessorsStats */
 		)
 
 		// The inputs are identical, so the merge joiner should output
@@ -23,11 +23,13 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
 	"github.com/cockroachdb/cockroach/pkg/rpc/nodedialer"
+	"github.com/cockroachdb/cockroach/pkg/server/telemetry"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descs"
 	"github.com/cockroachdb/cockroach/pkg/sql/colcontainer"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colbuilder"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecargs"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
 	"github.com/cockroachdb/cockroach/pkg/sql/colflow/colrpc"
@@ -37,6 +39,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/flowinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/rowexec"
 	"github.com/cockroachdb/cockroach/pkg/sql/sessiondatapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqltelemetry"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
@@ -150,6 +153,7 @@ type vectorizedFlow struct {
 
 var _ flowinfra.Flow = &vectorizedFlow{}
 var _ execinfra.Releasable = &vectorizedFlow{}
+var _ execinfra.MemoryUsageReporter = &vectorizedFlow{}
 
 var vectorizedFlowPool = sync.Pool{
 	New: func() interface{} {
@@ -212,6 +216,12 @@ func (f *vectorizedFlow) Setup(
 	if err == nil {
 		f.testingInfo.numClosers = f.creator.numClosers
 		f.testingInfo.numClosed = &f.creator.numClosed
+		if r := f.Cfg.MemoryUsageRegistry; r != nil {
+			r.Register(f)
+		}
+		for _, w := range f.creator.wrappedProcessors {
+			telemetry.Inc(sqltelemetry.VecWrappedProcessorCounter(w.Core))
+		}
 		if log.V(1) {
 			log.Info(ctx, "vectorized flow setup succeeded")
 		}
@@ -274,8 +284,28 @@ func (f *vectorizedFlow) Release() {
 	vectorizedFlowPool.Put(f)
 }
 
+// AppendMemoryUsage is part of the execinfra.MemoryUsageReporter interface.
+func (f *vectorizedFlow) AppendMemoryUsage(
+	usage []execinfra.OperatorMemoryUsage,
+) []execinfra.OperatorMemoryUsage {
+	for _, m := range f.creator.processorMemMonitors {
+		usage = append(usage, execinfra.OperatorMemoryUsage{
+			FlowID:       f.GetID(),
+			ProcessorID:  m.processorID,
+			Operator:     m.monitor.Name(),
+			CurrentBytes: m.monitor.AllocBytes(),
+			PeakBytes:    m.monitor.MaximumBytes(),
+		})
+	}
+	return usage
+}
+
 // Cleanup is part of the flowinfra.Flow interface.
 func (f *vectorizedFlow) Cleanup(ctx context.Context) {
+	if r := f.Cfg.MemoryUsageRegistry; r != nil {
+		// The flow must be unregistered before its monitors are released.
+		r.Unregister(f)
+	}
 	// This cleans up all the memory and disk monitoring of the vectorized flow.
 	f.creator.cleanup(ctx)
 
@@ -309,16 +339,41 @@ func (f *vectorizedFlow) Cleanup(ctx context.Context) {
 	f.Release()
 }
 
+// materializerInput returns the operator that a Materializer materializing the
+// output of op should consume, along with the options it should be created
+// with. If op only projects the columns of its input (possibly below an
+// InvariantsChecker), the projection is removed, and the Materializer outputs
+// the projected columns itself, converting only the needed vectors to datums.
+func materializerInput(op colexecop.Operator) (colexecop.Operator, []colexec.MaterializerOption) {
+	ic, isInvariantsChecker := op.(*colexec.InvariantsChecker)
+	if isInvariantsChecker {
+		op = ic.Input
+	}
+	input, numInputCols, projection, ok := colexecbase.UnwrapSimpleProjectOp(op)
+	if !ok {
+		if isInvariantsChecker {
+			return ic, nil
+		}
+		return op, nil
+	}
+	if isInvariantsChecker {
+		input = colexec.NewInvariantsChecker(input)
+	}
+	return input, []colexec.MaterializerOption{colexec.WithOutputColumns(numInputCols, projection)}
+}
+
 // wrapWithVectorizedStatsCollectorBase creates a new
 // colexec.VectorizedStatsCollectorBase that wraps op and connects the newly
 // created wrapper with those corresponding to operators in inputs (the latter
-// must have already been wrapped).
+// must have already been wrapped). The statistics of the wrapped processors
+// are reported as part of the statistics of the component.
 func (s *vectorizedFlowCreator) wrapWithVectorizedStatsCollectorBase(
 	op colexecop.Operator,
 	kvReader colexecop.KVReader,
 	inputs []colexecop.Operator,
 	component execinfrapb.ComponentID,
 	monitors []*mon.BytesMonitor,
+	wrappedProcessorsStats []*execinfra.DelegatedExecStats,
 ) (colexec.VectorizedStatsCollector, error) {
 	inputWatch := timeutil.NewStopWatch()
 	var memMonitors, diskMonitors []*mon.BytesMonitor
@@ -339,7 +394,7 @@ func (s *vectorizedFlowCreator) wrapWithVectorizedStatsCollectorBase(
 	}
 	return newVectorizedStatsCollector(
 		op, kvReader, component, inputWatch,
-		memMonitors, diskMonitors, inputStatsCollectors,
+		memMonitors, diskMonitors, inputStatsCollectors, wrappedProcessorsStats,
 	), nil
 }
 
@@ -478,12 +533,26 @@ type vectorizedFlowCreator struct {
 	// accounts contains all monitors (for both memory and disk usage) of the
 	// components in the vectorized flow.
 	accounts []*mon.BoundAccount
+	// processorMemMonitors contains the memory monitors of the operators
+	// created for the processors. It is used to report the memory usage of
+	// the operators.
+	processorMemMonitors []processorMemMonitor
+	// wrappedProcessors contains all row execution processors that were
+	// wrapped into the flow.
+	wrappedProcessors []wrappedProcessor
+	// processorOps contains the root operators of the operator chains created
+	// for the processors. It is used by EXPLAIN (VEC) to annotate the
+	// operators with the information about the processors.
+	processorOps []processorOp
 	// releasables contains all components that should be released back to their
 	// pools during the flow cleanup.
 	releasables []execinfra.Releasable
 
 	diskQueueCfg colcontainer.DiskQueueCfg
 	fdSemaphore  semaphore.Semaphore
+	// workMemPool is used to redistribute the workmem budget between the
+	// buffering operators of the flow at runtime.
+	workMemPool *colmem.WorkMemPool
 
 	// numClosers and numClosed are used to assert during testing that the
 	// expected number of components are closed.
@@ -495,6 +564,30 @@ type vectorizedFlowCreator struct {
 
 var _ execinfra.Releasable = &vectorizedFlowCreator{}
 
+// processorMemMonitor is a memory monitor of an operator created for the
+// processor with the given ID.
+type processorMemMonitor struct {
+	processorID int32
+	monitor     *mon.BytesMonitor
+}
+
+// wrappedProcessor describes a row execution processor that was wrapped into
+// the flow in order to plan the processor spec with the given ID.
+type wrappedProcessor struct {
+	processorID int32
+	colexecargs.WrappedProcessor
+}
+
+// processorOp is the root operator of the operator chain created for the
+// processor spec with the given ID.
+type processorOp struct {
+	processorID int32
+	op          colexecop.Operator
+	// estimatedRowCount is the number of rows that the optimizer expects the
+	// processor to emit (0 if the estimate wasn't populated).
+	estimatedRowCount uint64
+}
+
 var vectorizedFlowCreatorPool = sync.Pool{
 	New: func() interface{} {
 		return &vectorizedFlowCreator{
@@ -536,9 +629,13 @@ func newVectorizedFlowCreator(
 		leaves:                 creator.leaves,
 		monitors:               creator.monitors,
 		accounts:               creator.accounts,
+		processorMemMonitors:   creator.processorMemMonitors,
+		wrappedProcessors:      creator.wrappedProcessors,
+		processorOps:           creator.processorOps,
 		releasables:            creator.releasables,
 		diskQueueCfg:           diskQueueCfg,
 		fdSemaphore:            fdSemaphore,
+		workMemPool:            colmem.NewWorkMemPool(),
 		inputsScratch:          creator.inputsScratch,
 	}
 	return creator
@@ -566,15 +663,18 @@ func (s *vectorizedFlowCreator) Release() {
 		r.Release()
 	}
 	*s = vectorizedFlowCreator{
-		streamIDToInputOp: s.streamIDToInputOp,
-		streamIDToSpecIdx: s.streamIDToSpecIdx,
-		exprHelper:        s.exprHelper,
-		procIdxQueue:      s.procIdxQueue[:0],
-		leaves:            s.leaves[:0],
-		monitors:          s.monitors[:0],
-		accounts:          s.accounts[:0],
-		releasables:       s.releasables[:0],
-		inputsScratch:     s.inputsScratch[:0],
+		streamIDToInputOp:    s.streamIDToInputOp,
+		streamIDToSpecIdx:    s.streamIDToSpecIdx,
+		exprHelper:           s.exprHelper,
+		procIdxQueue:         s.procIdxQueue[:0],
+		leaves:               s.leaves[:0],
+		monitors:             s.monitors[:0],
+		accounts:             s.accounts[:0],
+		processorMemMonitors: s.processorMemMonitors[:0],
+		wrappedProcessors:    s.wrappedProcessors[:0],
+		processorOps:         s.processorOps[:0],
+		releasables:          s.releasables[:0],
+		inputsScratch:        s.inputsScratch[:0],
 	}
 	vectorizedFlowCreatorPool.Put(s)
 }
@@ -759,6 +859,7 @@ func (s *vectorizedFlowCreator) setupRouter(
 				vsc, err := s.wrapWithVectorizedStatsCollectorBase(
 					op, nil /* kvReader */, nil, /* inputs */
 					flowCtx.StreamComponentID(stream.StreamID), mons,
+					nil, /* wrappedProcessorsStats */
 				)
 				if err != nil {
 					return err
@@ -926,7 +1027,8 @@ func (s *vectorizedFlowCreator) setupInput(
 			// TODO(asubiotto): Once we have IDs for synchronizers, plumb them into
 			// this stats collector to display stats.
 			vsc, err := s.wrapWithVectorizedStatsCollectorBase(
-				op, nil /* kvReader */, statsInputsAsOps, execinfrapb.ComponentID{}, nil, /* monitors */
+				op, nil /* kvReader */, statsInputsAsOps, execinfrapb.ComponentID{},
+				nil /* monitors */, nil, /* wrappedProcessorsStats */
 			)
 			if err != nil {
 				return nil, nil, nil, nil, err
@@ -1031,16 +1133,19 @@ func (s *vectorizedFlowCreator) setupOutput(
 				return finishVectorizedStatsCollectors(statsCollectors)
 			}
 		}
+		input, opts := materializerInput(op)
 		proc, err := colexec.NewMaterializer(
 			flowCtx,
 			pspec.ProcessorID,
-			op,
+			input,
 			opOutputTypes,
-			s.syncFlowConsumer,
-			getStats,
-			metadataSources,
-			toClose,
-			s.getCancelFlowFn,
+			append(opts,
+				colexec.WithOutput(s.syncFlowConsumer),
+				colexec.WithStats(getStats),
+				colexec.WithMetadataSources(metadataSources),
+				colexec.WithClosers(toClose),
+				colexec.WithCancelFlow(s.getCancelFlowFn),
+			)...,
 		)
 		if err != nil {
 			return err
@@ -1152,6 +1257,7 @@ func (s *vectorizedFlowCreator) setupFlow(
 				FDSemaphore:          s.fdSemaphore,
 				ExprHelper:           s.exprHelper,
 				Factory:              factory,
+				WorkMemPool:          s.workMemPool,
 			}
 			args.TestingKnobs.PlanInvariantsCheckers = util.CrdbTestBuild
 			var result *colexecargs.NewColOperatorResult
@@ -1162,12 +1268,27 @@ func (s *vectorizedFlowCreator) setupFlow(
 				// them for a proper cleanup.
 				s.monitors = append(s.monitors, result.OpMonitors...)
 				s.accounts = append(s.accounts, result.OpAccounts...)
+				for _, m := range result.OpMonitors {
+					if m.Resource() == mon.MemoryResource {
+						s.processorMemMonitors = append(s.processorMemMonitors, processorMemMonitor{
+							processorID: pspec.ProcessorID,
+							monitor:     m,
+						})
+					}
+				}
 				s.releasables = append(s.releasables, result)
 			}
 			if err != nil {
 				err = errors.Wrapf(err, "unable to vectorize execution plan")
 				return
 			}
+			for _, w := range result.WrappedProcessors {
+				log.VEventf(ctx, 1, "wrapped %s processor %d: %v", w.Core, pspec.ProcessorID, w.Reason)
+				s.wrappedProcessors = append(s.wrappedProcessors, wrappedProcessor{
+					processorID:      pspec.ProcessorID,
+					WrappedProcessor: w,
+				})
+			}
 			if flowCtx.EvalCtx.SessionData.TestingVectorizeInjectPanics {
 				result.Op = newPanicInjector(result.Op)
 			}
@@ -1192,13 +1313,13 @@ func (s *vectorizedFlowCreator) setupFlow(
 			op := result.Op
 			var statsCollectors []colexec.VectorizedStatsCollector
 			if s.recordingStats {
-				// Note: if the original op is a Columnarizer, this will result in two
-				// sets of stats for the same processor. The code that processes stats
-				// is prepared to union the stats.
-				// TODO(radu): find a way to clean this up.
+				// Note: if the original op is a Columnarizer, the execution
+				// statistics of the wrapped processor are reported by this
+				// stats collector (rather than by the processor itself), so
+				// that there is a single set of stats for the processor.
 				vsc, err := s.wrapWithVectorizedStatsCollectorBase(
 					op, result.KVReader, inputs, flowCtx.ProcessorComponentID(pspec.ProcessorID),
-					result.OpMonitors,
+					result.OpMonitors, result.WrappedProcessorsStats,
 				)
 				if err != nil {
 					return
@@ -1209,6 +1330,11 @@ func (s *vectorizedFlowCreator) setupFlow(
 				}
 				statsCollectors = append(statsCollectors, vsc)
 			}
+			s.processorOps = append(s.processorOps, processorOp{
+				processorID:       pspec.ProcessorID,
+				op:                op,
+				estimatedRowCount: pspec.EstimatedRowCount,
+			})
 
 			if err = s.setupOutput(
 				ctx, flowCtx, pspec, op, result.ColumnTypes, statsCollectors, result.MetadataSources, toClose, factory,
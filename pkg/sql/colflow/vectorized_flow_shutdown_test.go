@@ -359,14 +359,12 @@ func TestVectorizedFlowShutdown(t *testing.T) {
 					1, /* processorID */
 					materializerInput,
 					typs,
-					nil, /* output */
-					nil, /* getStats */
-					[]execinfrapb.MetadataSource{materializerMetadataSource},
-					[]colexecop.Closer{callbackCloser{closeCb: func() error {
+					colexec.WithMetadataSources([]execinfrapb.MetadataSource{materializerMetadataSource}),
+					colexec.WithClosers([]colexecop.Closer{callbackCloser{closeCb: func() error {
 						materializerCalledClose = true
 						return nil
-					}}}, /* toClose */
-					func() context.CancelFunc { return cancelLocal },
+					}}}),
+					colexec.WithCancelFlow(func() context.CancelFunc { return cancelLocal }),
 				)
 				require.NoError(t, err)
 				materializer.Start(ctxLocal)
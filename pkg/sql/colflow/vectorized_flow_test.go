@@ -21,6 +21,8 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descs"
 	"github.com/cockroachdb/cockroach/pkg/sql/colcontainer"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
 	"github.com/cockroachdb/cockroach/pkg/sql/colflow/colrpc"
 	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
@@ -346,3 +348,96 @@ func TestVectorizedFlowTempDirectory(t *testing.T) {
 		checkDirs(t, 0)
 	})
 }
+
+// TestMaterializerInputUnwrapsProjection verifies that a simple projection at
+// the root of the flow is performed by the Materializer instead of the
+// projection operator.
+func TestMaterializerInputUnwrapsProjection(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		Cfg:     &execinfra.ServerConfig{Settings: st},
+		EvalCtx: &evalCtx,
+	}
+
+	inputTypes := []*types.T{types.Int, types.String, types.Int}
+	tuples := colexectestutils.Tuples{{1, "a", 10}, {2, "b", 20}}
+	projection := []uint32{2, 0}
+	outputTypes := []*types.T{types.Int, types.Int}
+	expected := []tree.Datums{
+		{tree.NewDInt(10), tree.NewDInt(1)},
+		{tree.NewDInt(20), tree.NewDInt(2)},
+	}
+
+	for _, tc := range []struct {
+		name string
+		// root returns the root of the flow given its source.
+		root func(source colexecop.Operator) colexecop.Operator
+		// unwrapped is whether the projection is expected to be performed by
+		// the Materializer.
+		unwrapped bool
+	}{
+		{
+			name: "simple-project",
+			root: func(source colexecop.Operator) colexecop.Operator {
+				return colexecbase.NewSimpleProjectOp(source, len(inputTypes), projection)
+			},
+			unwrapped: true,
+		},
+		{
+			name: "invariants-checker",
+			root: func(source colexecop.Operator) colexecop.Operator {
+				return colexec.NewInvariantsChecker(
+					colexecbase.NewSimpleProjectOp(source, len(inputTypes), projection),
+				)
+			},
+			unwrapped: true,
+		},
+		{
+			name: "other",
+			root: func(source colexecop.Operator) colexecop.Operator {
+				return colexecop.NewNoop(
+					colexecbase.NewSimpleProjectOp(source, len(inputTypes), projection),
+				)
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			source := colexectestutils.NewOpTestInput(testAllocator, 1 /* batchSize */, tuples, inputTypes)
+			root := tc.root(source)
+			input, opts := materializerInput(root)
+			if tc.unwrapped {
+				require.Len(t, opts, 1)
+				if ic, ok := input.(*colexec.InvariantsChecker); ok {
+					input = ic.Input
+				}
+				require.Equal(t, source, input)
+			} else {
+				require.Empty(t, opts)
+				require.Equal(t, root, input)
+			}
+
+			m, err := colexec.NewMaterializer(
+				flowCtx, 0 /* processorID */, input, outputTypes, opts...,
+			)
+			require.NoError(t, err)
+			m.Start(ctx)
+			for _, expectedRow := range expected {
+				row, meta := m.Next()
+				require.Nil(t, meta)
+				require.Len(t, row, len(expectedRow))
+				for i := range row {
+					require.NoError(t, row[i].EnsureDecoded(outputTypes[i], nil /* a */))
+					require.Equal(t, expectedRow[i], row[i].Datum)
+				}
+			}
+			row, meta := m.Next()
+			require.Nil(t, row)
+			require.Nil(t, meta)
+		})
+	}
+}
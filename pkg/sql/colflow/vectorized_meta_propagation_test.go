@@ -77,11 +77,7 @@ func TestVectorizedMetaPropagation(t *testing.T) {
 		2, /* processorID */
 		noop,
 		types,
-		nil, /* output */
-		nil, /* getStats */
-		[]execinfrapb.MetadataSource{col},
-		nil, /* toClose */
-		nil, /* cancelFlow */
+		colexec.WithMetadataSources([]execinfrapb.MetadataSource{col}),
 	)
 	if err != nil {
 		t.Fatal(err)
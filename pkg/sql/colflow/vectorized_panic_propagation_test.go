@@ -59,11 +59,6 @@ func TestVectorizedInternalPanic(t *testing.T) {
 		1, /* processorID */
 		vee,
 		types,
-		nil, /* output */
-		nil, /* getStats */
-		nil, /* metadataSourceQueue */
-		nil, /* toClose */
-		nil, /* cancelFlow */
 	)
 	if err != nil {
 		t.Fatal(err)
@@ -106,11 +101,6 @@ func TestNonVectorizedPanicPropagation(t *testing.T) {
 		1, /* processorID */
 		nvee,
 		types,
-		nil, /* output */
-		nil, /* getStats */
-		nil, /* metadataSourceQueue */
-		nil, /* toClose */
-		nil, /* cancelFlow */
 	)
 	if err != nil {
 		t.Fatal(err)
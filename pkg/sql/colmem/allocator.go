@@ -38,6 +38,8 @@ type Allocator struct {
 	ctx     context.Context
 	acc     *mon.BoundAccount
 	factory coldata.ColumnFactory
+	// pool, if set, is consulted when the account's monitor reaches its limit.
+	pool *WorkMemPool
 }
 
 func selVectorSize(capacity int) int64 {
@@ -118,15 +120,50 @@ func NewAllocator(
 	}
 }
 
+// NewAllocatorWithWorkMemPool constructs a new Allocator instance that, once
+// the monitor of acc reaches its limit, attempts to borrow the budget from the
+// given pool before giving up. The monitor must be registered with the pool
+// for the borrowing to take place.
+func NewAllocatorWithWorkMemPool(
+	ctx context.Context, acc *mon.BoundAccount, factory coldata.ColumnFactory, pool *WorkMemPool,
+) *Allocator {
+	a := NewAllocator(ctx, acc, factory)
+	a.pool = pool
+	return a
+}
+
+// grow grows the memory account of the allocator by delta bytes. If the
+// allocation is denied because of the local limit of the account's monitor, an
+// attempt to borrow from the WorkMemPool (if there is one) is made before
+// panicking with the error.
+func (a *Allocator) grow(delta int64) {
+	err := a.acc.Grow(a.ctx, delta)
+	if err == nil {
+		return
+	}
+	// The account reserves the bytes from its monitor in chunks, so we need to
+	// borrow a bit more than delta.
+	n := delta + mon.DefaultPoolAllocationSize
+	if a.pool != nil && a.pool.borrow(a.acc.Monitor(), n) {
+		err = a.acc.Grow(a.ctx, delta)
+	}
+	if err != nil {
+		if deniedByLocalLimit(a.acc.Monitor(), n) {
+			// Only the monitors of the operators that can spill to disk have
+			// local limits, so raising the workmem limit would help.
+			err = colexecerror.MarkWorkMemLimitExceeded(err)
+		}
+		colexecerror.InternalError(err)
+	}
+}
+
 // NewMemBatchWithFixedCapacity allocates a new in-memory coldata.Batch with the
 // given vector capacity.
 // Note: consider whether you want the dynamic batch size behavior (in which
 // case you should be using ResetMaybeReallocate).
 func (a *Allocator) NewMemBatchWithFixedCapacity(typs []*types.T, capacity int) coldata.Batch {
 	estimatedMemoryUsage := selVectorSize(capacity) + int64(EstimateBatchSizeBytes(typs, capacity))
-	if err := a.acc.Grow(a.ctx, estimatedMemoryUsage); err != nil {
-		colexecerror.InternalError(err)
-	}
+	a.grow(estimatedMemoryUsage)
 	return coldata.NewMemBatchWithCapacity(typs, capacity, a.factory)
 }
 
@@ -142,9 +179,7 @@ func (a *Allocator) NewMemBatchWithMaxCapacity(typs []*types.T) coldata.Batch {
 // for the column vectors - those will have to be added separately.
 func (a *Allocator) NewMemBatchNoCols(typs []*types.T, capacity int) coldata.Batch {
 	estimatedMemoryUsage := selVectorSize(capacity)
-	if err := a.acc.Grow(a.ctx, estimatedMemoryUsage); err != nil {
-		colexecerror.InternalError(err)
-	}
+	a.grow(estimatedMemoryUsage)
 	return coldata.NewMemBatchNoCols(typs, capacity)
 }
 
@@ -209,9 +244,7 @@ func (a *Allocator) ResetMaybeReallocate(
 // NewMemBatchWith*, or ResetMaybeReallocate methods.
 func (a *Allocator) NewMemColumn(t *types.T, capacity int) coldata.Vec {
 	estimatedMemoryUsage := int64(EstimateBatchSizeBytes([]*types.T{t}, capacity))
-	if err := a.acc.Grow(a.ctx, estimatedMemoryUsage); err != nil {
-		colexecerror.InternalError(err)
-	}
+	a.grow(estimatedMemoryUsage)
 	return coldata.NewMemColumn(t, capacity, a.factory)
 }
 
@@ -254,9 +287,7 @@ func (a *Allocator) MaybeAppendColumn(b coldata.Batch, t *types.T, colIdx int) {
 				// capacity, so we need to replace it.
 				oldMemUsage := getVecMemoryFootprint(presentVec)
 				newEstimatedMemoryUsage := int64(EstimateBatchSizeBytes([]*types.T{t}, desiredCapacity))
-				if err := a.acc.Grow(a.ctx, newEstimatedMemoryUsage-oldMemUsage); err != nil {
-					colexecerror.InternalError(err)
-				}
+				a.grow(newEstimatedMemoryUsage - oldMemUsage)
 				b.ReplaceCol(a.NewMemColumn(t, desiredCapacity), colIdx)
 				return
 			}
@@ -281,9 +312,7 @@ func (a *Allocator) MaybeAppendColumn(b coldata.Batch, t *types.T, colIdx int) {
 		))
 	}
 	estimatedMemoryUsage := int64(EstimateBatchSizeBytes([]*types.T{t}, desiredCapacity))
-	if err := a.acc.Grow(a.ctx, estimatedMemoryUsage); err != nil {
-		colexecerror.InternalError(err)
-	}
+	a.grow(estimatedMemoryUsage)
 	b.AppendCol(a.NewMemColumn(t, desiredCapacity))
 }
 
@@ -302,6 +331,28 @@ func (a *Allocator) PerformOperation(destVecs []coldata.Vec, operation func()) {
 	a.AdjustMemoryUsage(after - before)
 }
 
+// CopyTuples copies all tuples of src (paying attention to its selection
+// vector) into dest starting at position destIdx and updates the memory
+// account accordingly. dest must have enough capacity to store the tuples, and
+// the length of dest is not updated.
+func (a *Allocator) CopyTuples(dest coldata.Batch, destIdx int, src coldata.Batch) {
+	destVecs := dest.ColVecs()
+	a.PerformOperation(destVecs, func() {
+		for i, vec := range destVecs {
+			vec.Copy(
+				coldata.CopySliceArgs{
+					SliceArgs: coldata.SliceArgs{
+						Src:       src.ColVec(i),
+						Sel:       src.Selection(),
+						DestIdx:   destIdx,
+						SrcEndIdx: src.Length(),
+					},
+				},
+			)
+		}
+	})
+}
+
 // Used returns the number of bytes currently allocated through this allocator.
 func (a *Allocator) Used() int64 {
 	return a.acc.Used()
@@ -311,9 +362,7 @@ func (a *Allocator) Used() int64 {
 // this allocator by delta bytes (which can be both positive or negative).
 func (a *Allocator) AdjustMemoryUsage(delta int64) {
 	if delta > 0 {
-		if err := a.acc.Grow(a.ctx, delta); err != nil {
-			colexecerror.InternalError(err)
-		}
+		a.grow(delta)
 	} else {
 		a.ReleaseMemory(-delta)
 	}
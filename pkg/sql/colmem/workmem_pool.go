@@ -0,0 +1,184 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colmem
+
+import (
+	"math"
+
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// WorkMemPool redistributes the workmem budget between the buffering operators
+// of a single flow at runtime.
+//
+// Every buffering operator that can spill to disk gets its own memory monitor
+// with a local limit (the workmem limit), and the operator spills once that
+// limit is reached. However, the operators often don't need their budgets at
+// the same time: for example, a hash joiner might have already emitted all of
+// its output while the sort on top of it is still buffering its input. The
+// pool allows the operators that are done to donate the unused part of their
+// budgets and the allocators of the operators that are about to exceed their
+// limits to borrow it, so that the latter don't spill to disk prematurely.
+//
+// Note that only the budget that hasn't been allocated is donated, so the
+// total memory usage of the flow is still bounded by the sum of the original
+// limits.
+//
+// WorkMemPool is safe for concurrent use.
+type WorkMemPool struct {
+	mu struct {
+		syncutil.Mutex
+		// available is the number of donated bytes that haven't been borrowed
+		// yet.
+		available int64
+		// monitors contains all monitors that can borrow from the pool.
+		monitors map[*mon.BytesMonitor]struct{}
+		// donated contains the number of bytes donated by each monitor that
+		// can reclaim its donation.
+		donated map[*mon.BytesMonitor]int64
+	}
+}
+
+// NewWorkMemPool creates a new empty WorkMemPool.
+func NewWorkMemPool() *WorkMemPool {
+	p := &WorkMemPool{}
+	p.mu.monitors = make(map[*mon.BytesMonitor]struct{})
+	p.mu.donated = make(map[*mon.BytesMonitor]int64)
+	return p
+}
+
+// Register registers the given monitor with the pool so that the allocators
+// using the accounts bound to the monitor could borrow from the pool. Only the
+// monitors with a local limit can be registered; the call is a noop for all
+// other monitors.
+func (p *WorkMemPool) Register(m *mon.BytesMonitor) {
+	if m.Limit() == math.MaxInt64 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.mu.monitors[m] = struct{}{}
+}
+
+// Donate lowers the limit of the given monitor to the number of bytes it
+// currently has allocated and adds the difference to the pool. It must only be
+// called once the operator using the monitor won't allocate any more memory.
+// The monitor is unregistered from the pool, and the call is a noop if the
+// monitor is not registered.
+func (p *WorkMemPool) Donate(m *mon.BytesMonitor) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.mu.monitors[m]; !ok {
+		return
+	}
+	delete(p.mu.monitors, m)
+	allocated, limit := m.AllocBytes(), m.Limit()
+	if allocated >= limit {
+		return
+	}
+	// Note that the limit cannot be lowered to zero because that would remove
+	// it altogether.
+	m.SetLimit(allocated + 1)
+	p.mu.available += limit - allocated - 1
+	p.mu.donated[m] = limit - allocated - 1
+}
+
+// Reclaim registers the given monitor with the pool again and raises its limit
+// by the number of bytes it has donated. It must be called when the operator
+// using the monitor is reset for reuse after Donate was called. If some of the
+// donated budget has been borrowed by other monitors in the meantime, only the
+// rest is returned right away, and the monitor can borrow the remainder once
+// it is donated back.
+func (p *WorkMemPool) Reclaim(m *mon.BytesMonitor) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if m.Limit() != math.MaxInt64 {
+		p.mu.monitors[m] = struct{}{}
+	}
+	donated, ok := p.mu.donated[m]
+	if !ok {
+		return
+	}
+	delete(p.mu.donated, m)
+	if donated > p.mu.available {
+		donated = p.mu.available
+	}
+	m.SetLimit(m.Limit() + donated)
+	p.mu.available -= donated
+}
+
+// Available returns the number of bytes that can currently be borrowed from
+// the pool.
+func (p *WorkMemPool) Available() int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.mu.available
+}
+
+// borrow attempts to raise the limit of the given monitor by n bytes using the
+// budget donated to the pool. It returns whether the limit has been raised.
+// Nothing is borrowed if the monitor is not registered, if the pool doesn't
+// have enough budget, or if the local limit of the monitor is not what's
+// preventing the allocation of n bytes.
+func (p *WorkMemPool) borrow(m *mon.BytesMonitor, n int64) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.mu.monitors[m]; !ok || n <= 0 || p.mu.available < n {
+		return false
+	}
+	if !deniedByLocalLimit(m, n) {
+		// The allocation must have been denied by one of the ancestor
+		// monitors, so raising the local limit won't help.
+		return false
+	}
+	m.SetLimit(m.Limit() + n)
+	p.mu.available -= n
+	return true
+}
+
+// deniedByLocalLimit returns whether an allocation of n bytes from m would be
+// denied because of the local limit of m (as opposed to the limit of one of
+// its ancestors).
+func deniedByLocalLimit(m *mon.BytesMonitor, n int64) bool {
+	return m.AllocBytes() > m.Limit()-n
+}
+
+// WorkMemBudget is the budget of the memory monitor of a single buffering
+// operator that is registered with a WorkMemPool. A nil WorkMemBudget is valid
+// and all of its methods are noops.
+type WorkMemBudget struct {
+	pool    *WorkMemPool
+	monitor *mon.BytesMonitor
+}
+
+// NewWorkMemBudget registers the given monitor with the pool and returns its
+// budget.
+func (p *WorkMemPool) NewWorkMemBudget(m *mon.BytesMonitor) *WorkMemBudget {
+	p.Register(m)
+	return &WorkMemBudget{pool: p, monitor: m}
+}
+
+// Donate donates the unused part of the budget to the pool. It must only be
+// called once the operator won't allocate any more memory (until it is reset).
+func (b *WorkMemBudget) Donate() {
+	if b != nil {
+		b.pool.Donate(b.monitor)
+	}
+}
+
+// Reclaim reclaims the budget donated to the pool. It must be called when the
+// operator is reset for reuse.
+func (b *WorkMemBudget) Reclaim() {
+	if b != nil {
+		b.pool.Reclaim(b.monitor)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colmem_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkMemPool(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	testMemMonitor := execinfra.NewTestMemMonitor(ctx, st)
+	defer testMemMonitor.Stop(ctx)
+	evalCtx := tree.MakeTestingEvalContext(st)
+	testColumnFactory := coldataext.NewExtendedColumnFactory(&evalCtx)
+
+	const limit = 1 << 20
+	pool := colmem.NewWorkMemPool()
+	newAllocator := func(name string, register bool) (*colmem.Allocator, *mon.BytesMonitor, func()) {
+		m := mon.NewMonitorInheritWithLimit(name, limit, testMemMonitor)
+		m.Start(ctx, testMemMonitor, mon.BoundAccount{})
+		if register {
+			pool.Register(m)
+		}
+		acc := m.MakeBoundAccount()
+		return colmem.NewAllocatorWithWorkMemPool(ctx, &acc, testColumnFactory, pool), m, func() {
+			acc.Close(ctx)
+			m.Stop(ctx)
+		}
+	}
+	grow := func(a *colmem.Allocator, delta int64) error {
+		return colexecerror.CatchVectorizedRuntimeError(func() {
+			a.AdjustMemoryUsage(delta)
+		})
+	}
+
+	done, doneMon, cleanupDone := newAllocator("done", true /* register */)
+	defer cleanupDone()
+	running, runningMon, cleanupRunning := newAllocator("running", true /* register */)
+	defer cleanupRunning()
+	unregistered, _, cleanupUnregistered := newAllocator("unregistered", false /* register */)
+	defer cleanupUnregistered()
+
+	// Nothing has been donated yet, so no allocator can exceed its limit.
+	require.NoError(t, grow(done, 1<<10))
+	require.NoError(t, grow(running, limit/2))
+	require.Error(t, grow(running, limit))
+	require.Zero(t, pool.Available())
+
+	// Once the operator using the first allocator is done, the unused part of
+	// its budget can be borrowed.
+	pool.Donate(doneMon)
+	donated := pool.Available()
+	require.Equal(t, limit-doneMon.AllocBytes()-1, donated)
+	require.Error(t, grow(done, limit/2))
+	require.NoError(t, grow(running, limit/2+limit/4))
+	require.Greater(t, runningMon.Limit(), int64(limit))
+	require.Equal(t, donated-(runningMon.Limit()-limit), pool.Available())
+
+	// The monitors that haven't been registered cannot borrow.
+	// Such an error is hinted to be avoided by raising the workmem limit.
+	err := grow(unregistered, limit+1)
+	require.Error(t, err)
+	require.Contains(t, pgerror.Flatten(err).Hint, "sql.distsql.temp_storage.workmem")
+
+	// Donating twice is a noop.
+	pool.Donate(doneMon)
+	require.Equal(t, donated-(runningMon.Limit()-limit), pool.Available())
+
+	// Once an operator that has donated its budget is reset, it reclaims its
+	// donation and can use its original limit again.
+	pool = colmem.NewWorkMemPool()
+	reset, resetMon, cleanupReset := newAllocator("reset", true /* register */)
+	defer cleanupReset()
+	require.NoError(t, grow(reset, limit/2))
+	pool.Donate(resetMon)
+	require.Error(t, grow(reset, limit/4))
+	pool.Reclaim(resetMon)
+	require.Zero(t, pool.Available())
+	require.Equal(t, int64(limit), resetMon.Limit())
+	require.NoError(t, grow(reset, limit/4))
+
+	// If a part of the donation has been borrowed by another operator, only
+	// the rest is reclaimed.
+	other, otherMon, cleanupOther := newAllocator("other", true /* register */)
+	defer cleanupOther()
+	pool.Donate(resetMon)
+	donated = pool.Available()
+	require.NoError(t, grow(other, limit-limit/16))
+	require.NoError(t, grow(other, limit/8))
+	borrowed := otherMon.Limit() - limit
+	require.Greater(t, borrowed, int64(0))
+	require.Less(t, borrowed, donated)
+	resetLimit := resetMon.Limit()
+	pool.Reclaim(resetMon)
+	require.Zero(t, pool.Available())
+	require.Equal(t, resetLimit+donated-borrowed, resetMon.Limit())
+}
@@ -134,6 +134,7 @@ var crdbInternal = virtualSchema{
 		catconstants.CrdbInternalClusterDatabasePrivilegesTableID: crdbInternalClusterDatabasePrivilegesTable,
 		catconstants.CrdbInternalInterleaved:                      crdbInternalInterleaved,
 		catconstants.CrdbInternalCrossDbRefrences:                 crdbInternalCrossDbReferences,
+		catconstants.CrdbInternalVectorizedMemoryUsageTableID:     crdbInternalVectorizedMemoryUsageTable,
 	},
 	validWithNoDatabaseContext: true,
 }
@@ -1212,6 +1213,46 @@ CREATE TABLE crdb_internal.session_trace (
 	},
 }
 
+// crdbInternalVectorizedMemoryUsageTable exposes the memory usage of the
+// operators of the vectorized flows running on this node.
+var crdbInternalVectorizedMemoryUsageTable = virtualSchemaTable{
+	comment: `memory usage of vectorized operators of running flows (RAM; local node only)`,
+	schema: `
+CREATE TABLE crdb_internal.node_vectorized_memory_usage (
+  flow_id       UUID NOT NULL,    -- The ID of the flow the operator belongs to.
+  processor_id  INT NOT NULL,     -- The ID of the processor the operator was created for.
+  operator      STRING NOT NULL,  -- The name of the memory monitor of the operator.
+  current_bytes INT NOT NULL,     -- The number of bytes currently allocated by the operator.
+  peak_bytes    INT NOT NULL      -- The maximum number of bytes allocated by the operator.
+)`,
+	populate: func(ctx context.Context, p *planner, _ *dbdesc.Immutable, addRow func(...tree.Datum) error) error {
+		hasAdmin, err := p.HasAdminRole(ctx)
+		if err != nil {
+			return err
+		}
+		if !hasAdmin {
+			return pgerror.Newf(pgcode.InsufficientPrivilege,
+				"only users with the admin role are allowed to read crdb_internal.node_vectorized_memory_usage")
+		}
+		registry := p.ExecCfg().DistSQLSrv.MemoryUsageRegistry
+		if registry == nil {
+			return nil
+		}
+		for _, u := range registry.GetMemoryUsage() {
+			if err := addRow(
+				tree.NewDUuid(tree.DUuid{UUID: u.FlowID.UUID}),
+				tree.NewDInt(tree.DInt(u.ProcessorID)),
+				tree.NewDString(u.Operator),
+				tree.NewDInt(tree.DInt(u.CurrentBytes)),
+				tree.NewDInt(tree.DInt(u.PeakBytes)),
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
 // crdbInternalInflightTraceSpanTable exposes the node-local registry of in-flight spans.
 var crdbInternalInflightTraceSpanTable = virtualSchemaTable{
 	comment: `in-flight spans (RAM; local node only)`,
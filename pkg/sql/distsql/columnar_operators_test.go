@@ -252,7 +252,8 @@ func TestAggregatorAgainstProcessor(t *testing.T) {
 
 						// Update the specifications of aggregate functions to
 						// possibly include DISTINCT and/or FILTER clauses.
-						for _, aggFn := range aggregations {
+						for i := range aggregations {
+							aggFn := &aggregations[i]
 							distinctProb := 0.5
 							if hasJSONColumn {
 								// We currently cannot encode json columns, so we
@@ -333,6 +334,91 @@ func TestAggregatorAgainstProcessor(t *testing.T) {
 	}
 }
 
+// TestDistinctAggregationAgainstProcessor verifies that DISTINCT aggregation
+// (like count(DISTINCT x) and array_agg(DISTINCT x)) in the vectorized engine
+// returns the same results as the row-by-row aggregator. The aggregated values
+// are chosen from a small domain so that there are many duplicates within each
+// group.
+func TestDistinctAggregationAgainstProcessor(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	evalCtx := tree.MakeTestingEvalContext(cluster.MakeTestingClusterSettings())
+	defer evalCtx.Stop(context.Background())
+
+	rng, seed := randutil.NewPseudoRand()
+	const (
+		nRuns   = 10
+		nRows   = 200
+		nGroups = 5
+		maxNum  = 4
+	)
+	inputTypes := []*types.T{types.Int, types.Int}
+	for _, spillForced := range []bool{false, true} {
+		for _, hashAgg := range []bool{false, true} {
+			if !hashAgg && spillForced {
+				// There is no point in making the ordered aggregation spill to
+				// disk.
+				continue
+			}
+			aggFns := []execinfrapb.AggregatorSpec_Func{execinfrapb.AggregatorSpec_COUNT}
+			if !spillForced {
+				// The fallback strategy of the external hash aggregator sorts
+				// the input by the grouping columns which doesn't preserve the
+				// order of the values within the groups, so we only check
+				// array_agg when spilling doesn't occur.
+				aggFns = append(aggFns, execinfrapb.AggregatorSpec_ARRAY_AGG)
+			}
+			for run := 0; run < nRuns; run++ {
+				values := rowenc.MakeRandIntRowsInRange(rng, nRows, 1 /* numCols */, maxNum, nullProbability)
+				rows := make(rowenc.EncDatumRows, nRows)
+				for i := range rows {
+					rows[i] = rowenc.EncDatumRow{rowenc.IntEncDatum(rng.Intn(nGroups)), values[i][0]}
+				}
+				aggregatorSpec := &execinfrapb.AggregatorSpec{
+					Type:      execinfrapb.AggregatorSpec_NON_SCALAR,
+					GroupCols: []uint32{0},
+				}
+				outputTypes := make([]*types.T, len(aggFns))
+				for i, aggFn := range aggFns {
+					aggregatorSpec.Aggregations = append(aggregatorSpec.Aggregations, execinfrapb.AggregatorSpec_Aggregation{
+						Func:     aggFn,
+						Distinct: true,
+						ColIdx:   []uint32{1},
+					})
+					_, outputType, err := execinfrapb.GetAggregateInfo(aggFn, types.Int)
+					require.NoError(t, err)
+					outputTypes[i] = outputType
+				}
+				if !hashAgg {
+					aggregatorSpec.OrderedGroupCols = []uint32{0}
+					sort.SliceStable(rows, func(i, j int) bool {
+						return tree.MustBeDInt(rows[i][0].Datum) < tree.MustBeDInt(rows[j][0].Datum)
+					})
+				}
+				pspec := &execinfrapb.ProcessorSpec{
+					Input:       []execinfrapb.InputSyncSpec{{ColumnTypes: inputTypes}},
+					Core:        execinfrapb.ProcessorCoreUnion{Aggregator: aggregatorSpec},
+					ResultTypes: outputTypes,
+				}
+				args := verifyColOperatorArgs{
+					anyOrder:       hashAgg,
+					inputTypes:     [][]*types.T{inputTypes},
+					inputs:         []rowenc.EncDatumRows{rows},
+					pspec:          pspec,
+					forceDiskSpill: spillForced,
+				}
+				if err := verifyColOperator(t, args); err != nil {
+					fmt.Printf("--- seed = %d run = %d hash = %t spill = %t ---\n",
+						seed, run, hashAgg, spillForced)
+					prettyPrintTypes(inputTypes, "t" /* tableName */)
+					prettyPrintInput(rows, inputTypes, "t" /* tableName */)
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+}
+
 func TestDistinctAgainstProcessor(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	var da rowenc.DatumAlloc
@@ -174,11 +174,8 @@ func verifyColOperator(t *testing.T, args verifyColOperatorArgs) error {
 		int32(len(args.inputs))+2,
 		result.Op,
 		args.pspec.ResultTypes,
-		nil, /* output */
-		nil, /* getStats */
-		result.MetadataSources,
-		result.ToClose,
-		nil, /* cancelFlow */
+		colexec.WithMetadataSources(result.MetadataSources),
+		colexec.WithClosers(result.ToClose),
 	)
 	if err != nil {
 		return err
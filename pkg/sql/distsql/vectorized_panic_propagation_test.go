@@ -59,11 +59,7 @@ func TestNonVectorizedPanicDoesntHangServer(t *testing.T) {
 			},
 		},
 		nil, /* typs */
-		&distsqlutils.RowBuffer{},
-		nil, /* getStats */
-		nil, /* metadataSourceQueue */
-		nil, /* toClose */
-		nil, /* cancelFlow */
+		colexec.WithOutput(&distsqlutils.RowBuffer{}),
 	)
 	if err != nil {
 		t.Fatal(err)
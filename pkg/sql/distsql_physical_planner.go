@@ -35,6 +35,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/sql/execstats"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/cat"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/physicalplan"
@@ -1324,7 +1325,7 @@ func (dsp *DistSQLPlanner) planTableReaders(
 		}
 
 		tr.Parallelize = info.parallelize
-		p.TotalEstimatedScannedRows += info.estimatedRowCount
+		p.TotalEstimatedScannedRows = cat.AddStatCounts(p.TotalEstimatedScannedRows, info.estimatedRowCount)
 
 		corePlacement[i].NodeID = sp.Node
 		corePlacement[i].EstimatedRowCount = info.estimatedRowCount
@@ -159,6 +159,7 @@ func (dsp *DistSQLPlanner) setupFlows(
 		for _, spec := range flows {
 			if err := colflow.IsSupported(vectorizeMode, spec); err != nil {
 				log.VEventf(ctx, 1, "failed to vectorize: %s", err)
+				telemetry.Inc(sqltelemetry.VecFlowNotSupportedCounter)
 				if vectorizeMode == sessiondatapb.VectorizeExperimentalAlways {
 					return nil, nil, err
 				}
@@ -963,7 +964,10 @@ func (dsp *DistSQLPlanner) PlanAndRun(
 		return func() {}
 	}
 	dsp.FinalizePlan(planCtx, physPlan)
-	recv.expectedRowsRead = int64(physPlan.TotalEstimatedScannedRows)
+	recv.expectedRowsRead = math.MaxInt64
+	if physPlan.TotalEstimatedScannedRows < math.MaxInt64 {
+		recv.expectedRowsRead = int64(physPlan.TotalEstimatedScannedRows)
+	}
 	return dsp.Run(planCtx, txn, physPlan, recv, evalCtx, nil /* finishedSetupFn */)
 }
 
@@ -281,7 +281,7 @@ func (e *distSQLSpecExecFactory) ConstructScan(
 			reverse:               params.Reverse,
 			scanVisibility:        colCfg.visibility,
 			parallelize:           params.Parallelize,
-			estimatedRowCount:     uint64(params.EstimatedRowCount),
+			estimatedRowCount:     cat.EstimatedCount(params.EstimatedRowCount),
 			reqOrdering:           ReqOrdering(reqOrdering),
 			cols:                  cols,
 			colsToTableOrdinalMap: colsToTableOrdinalMap,
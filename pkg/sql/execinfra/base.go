@@ -156,6 +156,15 @@ type RowSource interface {
 	ConsumerClosed()
 }
 
+// HandOffer is implemented by RowSources which are confined to the goroutine
+// that uses them, but which can be handed off to a different goroutine.
+type HandOffer interface {
+	// HandOff must be called on the goroutine which currently uses the
+	// RowSource before the RowSource is passed to a different goroutine. The
+	// RowSource must not be used by the current goroutine afterwards.
+	HandOff()
+}
+
 // RowSourcedProcessor is the union of RowSource and Processor.
 type RowSourcedProcessor interface {
 	RowSource
@@ -310,6 +319,9 @@ func DrainAndClose(
 	if len(srcs) > 0 {
 		var wg sync.WaitGroup
 		for _, input := range srcs[1:] {
+			if h, ok := input.(HandOffer); ok {
+				h.HandOff()
+			}
 			wg.Add(1)
 			go func(input RowSource) {
 				DrainAndForwardMetadata(ctx, input, dst)
@@ -0,0 +1,90 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package execinfra
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// OperatorMemoryUsage describes the memory usage of a single operator of a
+// flow running on this node.
+//
+// Note that the usage is tracked by the memory monitors, not by the operators
+// themselves. Every buffering operator (like a sorter or a hash joiner) gets
+// its own monitor, so its usage is reported separately, but the streaming
+// operators share a single account of the flow and are not reported at all.
+type OperatorMemoryUsage struct {
+	FlowID      execinfrapb.FlowID
+	ProcessorID int32
+	// Operator is the name of the memory monitor used by the operator.
+	Operator string
+	// CurrentBytes is the number of bytes currently allocated by the operator.
+	CurrentBytes int64
+	// PeakBytes is the maximum number of bytes allocated by the operator at
+	// any point.
+	PeakBytes int64
+}
+
+// MemoryUsageReporter is an interface to the components of flows (like the
+// vectorized flows) that can report the memory usage of their operators. It is
+// implemented by the whole flow rather than by every operator, and the current
+// usage is only available through the registry: EXPLAIN ANALYZE reports the
+// peak usage collected from the same monitors once the flow finishes.
+type MemoryUsageReporter interface {
+	// AppendMemoryUsage appends the memory usage of all operators to usage
+	// and returns the result.
+	AppendMemoryUsage(usage []OperatorMemoryUsage) []OperatorMemoryUsage
+}
+
+// MemoryUsageRegistry keeps track of the MemoryUsageReporters of all flows
+// running on this node.
+type MemoryUsageRegistry struct {
+	mu struct {
+		syncutil.Mutex
+		reporters map[MemoryUsageReporter]struct{}
+	}
+}
+
+// NewMemoryUsageRegistry returns a new MemoryUsageRegistry.
+func NewMemoryUsageRegistry() *MemoryUsageRegistry {
+	r := &MemoryUsageRegistry{}
+	r.mu.reporters = make(map[MemoryUsageReporter]struct{})
+	return r
+}
+
+// Register adds the reporter to the registry. The reporter must be
+// unregistered before it becomes invalid.
+func (r *MemoryUsageRegistry) Register(reporter MemoryUsageReporter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mu.reporters[reporter] = struct{}{}
+}
+
+// Unregister removes the reporter from the registry. It is a noop if the
+// reporter hasn't been registered.
+func (r *MemoryUsageRegistry) Unregister(reporter MemoryUsageReporter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.mu.reporters, reporter)
+}
+
+// GetMemoryUsage returns the memory usage of the operators of all registered
+// reporters.
+func (r *MemoryUsageRegistry) GetMemoryUsage() []OperatorMemoryUsage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var usage []OperatorMemoryUsage
+	for reporter := range r.mu.reporters {
+		usage = reporter.AppendMemoryUsage(usage)
+	}
+	return usage
+}
@@ -474,6 +474,10 @@ type ProcessorBase struct {
 	//
 	// Can return nil.
 	ExecStatsForTrace func() *execinfrapb.ComponentStats
+	// delegatedExecStats, if set, receives the statistics returned by
+	// ExecStatsForTrace instead of the span (unless they have already been
+	// read), see DelegateExecStatsForTrace.
+	delegatedExecStats *DelegatedExecStats
 	// trailingMetaCallback, if set, will be called by moveToTrailingMeta(). The
 	// callback is expected to close all inputs, do other cleanup on the processor
 	// (including calling InternalClose()) and generate the trailing meta that
@@ -505,6 +509,16 @@ type ProcessorBase struct {
 	curInputToDrain int
 }
 
+// DelegateExecStatsForTrace is part of the ExecStatsForTraceDelegator
+// interface.
+func (pb *ProcessorBase) DelegateExecStatsForTrace() *DelegatedExecStats {
+	if pb.ExecStatsForTrace == nil {
+		return nil
+	}
+	pb.delegatedExecStats = &DelegatedExecStats{}
+	return pb.delegatedExecStats
+}
+
 // Reset resets this ProcessorBase, retaining allocated memory in slices.
 func (pb *ProcessorBase) Reset() {
 	pb.Out.Reset()
@@ -704,7 +718,9 @@ func (pb *ProcessorBase) moveToTrailingMeta() {
 		if pb.ExecStatsForTrace != nil {
 			if stats := pb.ExecStatsForTrace(); stats != nil {
 				stats.Component = pb.FlowCtx.ProcessorComponentID(pb.processorID)
-				pb.span.RecordStructured(stats)
+				if pb.delegatedExecStats == nil || !pb.delegatedExecStats.set(stats) {
+					pb.span.RecordStructured(stats)
+				}
 			}
 		}
 		if trace := pb.span.GetRecording(); trace != nil {
@@ -156,6 +156,10 @@ type ServerConfig struct {
 	// SQLStatsResetter is an interface used to reset SQL stats without the need to
 	// introduce dependency on the sql package.
 	SQLStatsResetter tree.SQLStatsResetter
+
+	// MemoryUsageRegistry keeps track of the memory usage of the operators of
+	// the flows running on this node. It can be nil.
+	MemoryUsageRegistry *MemoryUsageRegistry
 }
 
 // RuntimeStats is an interface through which the rowexec layer can get
@@ -226,6 +230,11 @@ type TestingKnobs struct {
 	// were closed explicitly in flow.Cleanup.
 	CheckVectorizedFlowIsClosedCorrectly bool
 
+	// CheckMaterializerGoroutineConfinement checks that materializers are only
+	// used by a single goroutine at a time, and that they are explicitly handed
+	// off (see HandOffer) when passed to a different goroutine.
+	CheckMaterializerGoroutineConfinement bool
+
 	// Changefeed contains testing knobs specific to the changefeed system.
 	Changefeed base.ModuleTestingKnobs
 
@@ -15,6 +15,8 @@ import (
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
 	pbtypes "github.com/gogo/protobuf/types"
 )
@@ -50,3 +52,55 @@ func GetCumulativeContentionTime(ctx context.Context) time.Duration {
 	}
 	return cumulativeContentionTime
 }
+
+// ExecStatsForTraceDelegator is implemented by the processors that embed
+// ProcessorBase. It is used by the vectorized engine when wrapping a processor
+// in order to report the execution statistics of the processor together with
+// the statistics of the vectorized operators planned for the same processor.
+type ExecStatsForTraceDelegator interface {
+	// DelegateExecStatsForTrace makes the processor store the statistics
+	// returned by ExecStatsForTrace in the returned DelegatedExecStats instead
+	// of recording them to its span. If the statistics are read before the
+	// processor collects them (e.g. because the flow is drained early), the
+	// processor still records them to its span. It returns nil if the
+	// processor doesn't collect the statistics.
+	DelegateExecStatsForTrace() *DelegatedExecStats
+}
+
+// DelegatedExecStats contains the execution statistics of a processor that
+// delegated them with DelegateExecStatsForTrace.
+type DelegatedExecStats struct {
+	// The statistics are set by the goroutine that runs the processor, and
+	// they can be read from another one.
+	mu struct {
+		syncutil.Mutex
+		stats *execinfrapb.ComponentStats
+		// read is set once the statistics have been read. The statistics set
+		// after that would never be reported.
+		read bool
+	}
+}
+
+// set stores the statistics of the processor. It returns false if the
+// statistics have already been read, in which case the processor must report
+// them itself.
+func (d *DelegatedExecStats) set(stats *execinfrapb.ComponentStats) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.mu.read {
+		return false
+	}
+	d.mu.stats = stats
+	return true
+}
+
+// Get returns the statistics of the processor. It returns nil if the processor
+// hasn't moved to draining its trailing metadata yet (which is when the
+// statistics are collected), and then the processor records the statistics to
+// its span once it collects them.
+func (d *DelegatedExecStats) Get() *execinfrapb.ComponentStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.mu.read = true
+	return d.mu.stats
+}
@@ -12,7 +12,9 @@ package execinfra
 
 import (
 	"context"
+	"fmt"
 	"math"
+	"sort"
 
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
@@ -35,6 +37,19 @@ type RepeatableRowSource struct {
 	rows       rowenc.EncDatumRows
 	// Schema of rows.
 	types []*types.T
+	// The index of the next metadata object to emit.
+	nextMetaIdx int
+	// meta is ordered by RowIdx.
+	meta []RepeatableRowSourceMeta
+}
+
+// RepeatableRowSourceMeta is a metadata object emitted by a
+// RepeatableRowSource.
+type RepeatableRowSourceMeta struct {
+	// RowIdx is the index of the row before which Meta is emitted. If it is
+	// len(rows), Meta is emitted after all of the rows.
+	RowIdx int
+	Meta   *execinfrapb.ProducerMetadata
 }
 
 var _ RowSource = &RepeatableRowSource{}
@@ -48,6 +63,27 @@ func NewRepeatableRowSource(types []*types.T, rows rowenc.EncDatumRows) *Repeata
 	return &RepeatableRowSource{rows: rows, types: types}
 }
 
+// NewRepeatableRowSourceWithMeta is like NewRepeatableRowSource, but the
+// RepeatableRowSource also interleaves the given metadata with the rows. The
+// metadata objects with the same RowIdx are emitted in the order in which
+// they are given.
+func NewRepeatableRowSourceWithMeta(
+	types []*types.T, rows rowenc.EncDatumRows, meta []RepeatableRowSourceMeta,
+) *RepeatableRowSource {
+	r := NewRepeatableRowSource(types, rows)
+	r.meta = make([]RepeatableRowSourceMeta, len(meta))
+	copy(r.meta, meta)
+	sort.SliceStable(r.meta, func(i, j int) bool {
+		return r.meta[i].RowIdx < r.meta[j].RowIdx
+	})
+	for _, m := range r.meta {
+		if m.RowIdx < 0 || m.RowIdx > len(rows) {
+			panic(fmt.Sprintf("metadata row index %d is out of range [0, %d]", m.RowIdx, len(rows)))
+		}
+	}
+	return r
+}
+
 // OutputTypes is part of the RowSource interface.
 func (r *RepeatableRowSource) OutputTypes() []*types.T {
 	return r.types
@@ -58,6 +94,12 @@ func (r *RepeatableRowSource) Start(ctx context.Context) {}
 
 // Next is part of the RowSource interface.
 func (r *RepeatableRowSource) Next() (rowenc.EncDatumRow, *execinfrapb.ProducerMetadata) {
+	// The metadata scheduled before the next row is emitted first.
+	if r.nextMetaIdx < len(r.meta) && r.meta[r.nextMetaIdx].RowIdx <= r.nextRowIdx {
+		meta := r.meta[r.nextMetaIdx].Meta
+		r.nextMetaIdx++
+		return nil, meta
+	}
 	// If we've emitted all rows, signal that we have reached the end.
 	if r.nextRowIdx >= len(r.rows) {
 		return nil, nil
@@ -68,9 +110,10 @@ func (r *RepeatableRowSource) Next() (rowenc.EncDatumRow, *execinfrapb.ProducerM
 }
 
 // Reset resets the RepeatableRowSource such that a subsequent call to Next()
-// returns the first row.
+// returns the first row (or the metadata scheduled before it).
 func (r *RepeatableRowSource) Reset() {
 	r.nextRowIdx = 0
+	r.nextMetaIdx = 0
 }
 
 // ConsumerDone is part of the RowSource interface.
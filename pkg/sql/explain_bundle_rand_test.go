@@ -0,0 +1,216 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"context"
+	gosql "database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/sql/mutations"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/stats"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/cockroachdb/errors"
+	"github.com/lib/pq"
+)
+
+// TestExplainAnalyzeDebugRandomSchemas runs EXPLAIN ANALYZE (DEBUG) on queries
+// against randomly generated and mutated schemas, and validates that the
+// resulting statement bundles contain parseable statements, schemas and
+// statistics that are consistent with the tables they were generated from.
+func TestExplainAnalyzeDebugRandomSchemas(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	rng, _ := randutil.NewPseudoRand()
+	srv, godb, _ := serverutils.StartServer(t, base.TestServerArgs{Insecure: true})
+	defer srv.Stopper().Stop(ctx)
+	r := sqlutils.MakeSQLRunner(godb)
+	// Automatic statistics would race with the comparison of the statistics
+	// in the bundles with the table statistics.
+	r.Exec(t, `SET CLUSTER SETTING sql.stats.automatic_collection.enabled = false`)
+
+	stmts := rowenc.RandCreateTables(rng, "table", rng.Intn(3)+1,
+		mutations.StatisticsMutator,
+		mutations.ColumnFamilyMutator,
+		mutations.IndexStoringMutator,
+		mutations.PartialIndexMutator,
+		mutations.CollatedStringMutator,
+		mutations.EnumMutator,
+	)
+	for _, stmt := range stmts {
+		// Mutated statements are not guaranteed to be valid, so failed
+		// statements are skipped.
+		if _, err := godb.ExecContext(ctx, tree.SerializeForDisplay(stmt)); err != nil {
+			t.Logf("skipping statement %s: %v", tree.SerializeForDisplay(stmt), err)
+		}
+	}
+
+	tables := r.QueryStr(t, `SELECT table_name FROM [SHOW TABLES] ORDER BY table_name`)
+	if len(tables) == 0 {
+		t.Skip("none of the random tables could be created")
+	}
+	for _, row := range tables {
+		table := tree.NameString(row[0])
+		cols := r.QueryStr(t, fmt.Sprintf(`SELECT column_name FROM [SHOW COLUMNS FROM %s]`, table))
+		col := tree.NameString(cols[rng.Intn(len(cols))][0])
+		queries := []string{
+			fmt.Sprintf(`SELECT * FROM %s`, table),
+			fmt.Sprintf(`SELECT count(*) FROM %s WHERE %s IS NOT NULL`, table, col),
+			fmt.Sprintf(`SELECT %[2]s, count(*) FROM %[1]s GROUP BY %[2]s`, table, col),
+			fmt.Sprintf(`SELECT * FROM %[1]s ORDER BY %[2]s LIMIT 10`, table, col),
+		}
+		var liveStats []stats.JSONStatistic
+		statsJSON := r.QueryStr(t, fmt.Sprintf(`SHOW STATISTICS USING JSON FOR TABLE %s`, table))[0][0]
+		if err := json.Unmarshal([]byte(statsJSON), &liveStats); err != nil {
+			t.Fatal(err)
+		}
+		for _, query := range queries {
+			t.Run(query, func(t *testing.T) {
+				files := fetchBundle(t, explainAnalyzeDebug(ctx, t, godb, query))
+				validateBundle(t, files, row[0], liveStats)
+			})
+		}
+	}
+}
+
+// explainAnalyzeDebug runs the query with EXPLAIN ANALYZE (DEBUG) and returns
+// the text that contains the bundle URL. Queries against random schemas can
+// fail, in which case the URL is in the error detail.
+func explainAnalyzeDebug(ctx context.Context, t *testing.T, db *gosql.DB, query string) string {
+	rows, err := db.QueryContext(ctx, "EXPLAIN ANALYZE (DEBUG) "+query)
+	if err != nil {
+		var pqErr *pq.Error
+		if !errors.As(err, &pqErr) {
+			t.Fatal(err)
+		}
+		t.Logf("query failed: %v", err)
+		return pqErr.Detail
+	}
+	defer rows.Close()
+	var sb strings.Builder
+	for rows.Next() {
+		var row string
+		if err := rows.Scan(&row); err != nil {
+			t.Fatal(err)
+		}
+		sb.WriteString(row)
+		sb.WriteByte('\n')
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return sb.String()
+}
+
+// validateBundle verifies that the files of a statement bundle for a query
+// against the given table can be parsed, and that the schema and statistics
+// in the bundle match the table and its statistics in liveStats.
+func validateBundle(
+	t *testing.T, files map[string]string, table string, liveStats []stats.JSONStatistic,
+) {
+	t.Helper()
+	for _, name := range []string{"statement.txt", "env.sql"} {
+		if _, err := parser.Parse(files[name]); err != nil {
+			t.Fatalf("error parsing %s: %v\n%s", name, err, files[name])
+		}
+	}
+
+	schema, ok := files["schema.sql"]
+	if !ok {
+		// The query failed before it was planned.
+		return
+	}
+	for _, name := range []string{"opt.txt", "opt-v.txt", "opt-vv.txt", "plan.txt"} {
+		if _, ok := files[name]; !ok {
+			t.Fatalf("expected %s in the bundle", name)
+		}
+	}
+	parsed, err := parser.Parse(schema)
+	if err != nil {
+		t.Fatalf("error parsing schema.sql: %v\n%s", err, schema)
+	}
+	var create *tree.CreateTable
+	for _, stmt := range parsed {
+		if c, ok := stmt.AST.(*tree.CreateTable); ok && c.Table.Table() == table {
+			create = c
+		}
+	}
+	if create == nil {
+		t.Fatalf("expected CREATE TABLE %s in schema.sql:\n%s", table, schema)
+	}
+	columns := map[string]bool{}
+	for _, def := range create.Defs {
+		if col, ok := def.(*tree.ColumnTableDef); ok {
+			columns[string(col.Name)] = true
+		}
+	}
+
+	statsFile := fmt.Sprintf("stats-defaultdb.public.%s.sql", tree.NameString(table))
+	stmt, err := parser.ParseOne(files[statsFile])
+	if err != nil {
+		t.Fatalf("error parsing %s: %v\n%s", statsFile, err, files[statsFile])
+	}
+	alter, ok := stmt.AST.(*tree.AlterTable)
+	if !ok || len(alter.Cmds) != 1 {
+		t.Fatalf("expected ALTER TABLE INJECT STATISTICS in %s:\n%s", statsFile, files[statsFile])
+	}
+	inject, ok := alter.Cmds[0].(*tree.AlterTableInjectStats)
+	if !ok {
+		t.Fatalf("expected ALTER TABLE INJECT STATISTICS in %s:\n%s", statsFile, files[statsFile])
+	}
+	statsStr, ok := inject.Stats.(*tree.StrVal)
+	if !ok {
+		t.Fatalf("expected a string literal in %s:\n%s", statsFile, files[statsFile])
+	}
+	var bundleStats []stats.JSONStatistic
+	if err := json.Unmarshal([]byte(statsStr.RawString()), &bundleStats); err != nil {
+		t.Fatalf("error decoding statistics in %s: %v", statsFile, err)
+	}
+	for _, stat := range bundleStats {
+		for _, col := range stat.Columns {
+			if !columns[col] {
+				t.Fatalf("statistic on unknown column %s in %s", col, statsFile)
+			}
+		}
+	}
+	if a, e := summarizeStats(bundleStats), summarizeStats(liveStats); a != e {
+		t.Fatalf("statistics in %s differ from the table statistics:\n%s\nexpected:\n%s", statsFile, a, e)
+	}
+}
+
+// summarizeStats returns a sorted summary of the counts of the given
+// statistics.
+func summarizeStats(jsonStats []stats.JSONStatistic) string {
+	lines := make([]string, len(jsonStats))
+	for i, s := range jsonStats {
+		lines[i] = fmt.Sprintf(
+			"%s %s rows=%d distinct=%d nulls=%d buckets=%d",
+			strings.Join(s.Columns, ","), s.CreatedAt, s.RowCount, s.DistinctCount, s.NullCount,
+			len(s.HistogramBuckets),
+		)
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
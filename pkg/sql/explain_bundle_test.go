@@ -126,6 +126,37 @@ CREATE TABLE s.a (a INT PRIMARY KEY);`)
 // arbitrary number of strings; each string contains one or more filenames
 // separated by a space.
 func checkBundle(t *testing.T, text, tableName string, expectedFiles ...string) {
+	t.Helper()
+	contents := fetchBundle(t, text)
+
+	// Make sure the bundle contains the expected list of files.
+	var files []string
+	for name := range contents {
+		files = append(files, name)
+	}
+	if schema, ok := contents["schema.sql"]; ok && !strings.Contains(schema, tableName) {
+		t.Errorf(
+			"expected table name to appear in schema.sql. tableName: %s\nfile contents:\n%s",
+			tableName,
+			schema,
+		)
+	}
+
+	var expList []string
+	for _, s := range expectedFiles {
+		expList = append(expList, strings.Split(s, " ")...)
+	}
+	sort.Strings(files)
+	sort.Strings(expList)
+	if fmt.Sprint(files) != fmt.Sprint(expList) {
+		t.Errorf("unexpected list of files:\n  %v\nexpected:\n  %v", files, expList)
+	}
+}
+
+// fetchBundle searches text strings for a bundle URL, downloads the bundle and
+// returns the contents of its files, keyed by file name. It fails the test if
+// any file in the bundle is empty.
+func fetchBundle(t *testing.T, text string) map[string]string {
 	t.Helper()
 	reg := regexp.MustCompile("http://[a-zA-Z0-9.:]*/_admin/v1/stmtbundle/[0-9]*")
 	url := reg.FindString(text)
@@ -147,41 +178,21 @@ func checkBundle(t *testing.T, text, tableName string, expectedFiles ...string)
 		t.Fatal(err)
 	}
 
-	// Make sure the bundle contains the expected list of files.
-	var files []string
+	contents := make(map[string]string, len(unzip.File))
 	for _, f := range unzip.File {
 		if f.UncompressedSize64 == 0 {
 			t.Fatalf("file %s is empty", f.Name)
 		}
-		files = append(files, f.Name)
-
-		if f.Name == "schema.sql" {
-			r, err := f.Open()
-			if err != nil {
-				t.Fatal(err)
-			}
-			defer r.Close()
-			contents, err := ioutil.ReadAll(r)
-			if err != nil {
-				t.Fatal(err)
-			}
-			if !strings.Contains(string(contents), tableName) {
-				t.Errorf(
-					"expected table name to appear in schema.sql. tableName: %s\nfile contents:\n%s",
-					tableName,
-					string(contents),
-				)
-			}
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
 		}
+		b, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents[f.Name] = string(b)
 	}
-
-	var expList []string
-	for _, s := range expectedFiles {
-		expList = append(expList, strings.Split(s, " ")...)
-	}
-	sort.Strings(files)
-	sort.Strings(expList)
-	if fmt.Sprint(files) != fmt.Sprint(expList) {
-		t.Errorf("unexpected list of files:\n  %v\nexpected:\n  %v", files, expList)
-	}
+	return contents
 }
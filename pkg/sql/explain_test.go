@@ -12,8 +12,13 @@ package sql_test
 
 import (
 	"bytes"
+	"compress/zlib"
 	"context"
+	"encoding/base64"
+	gojson "encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net/url"
 	"regexp"
 	"strings"
 	"testing"
@@ -317,3 +322,104 @@ func TestExplainStatsCollected(t *testing.T) {
 		}
 	}
 }
+
+// explainAnalyzeDiagram runs EXPLAIN ANALYZE (DISTSQL) of the query and
+// returns the JSON of the diagram of the physical plan.
+func explainAnalyzeDiagram(t *testing.T, r *sqlutils.SQLRunner, query string) []byte {
+	var diagram string
+	for _, row := range r.QueryStr(t, "EXPLAIN ANALYZE (DISTSQL) "+query) {
+		if strings.HasPrefix(row[0], "Diagram: ") {
+			diagram = strings.TrimPrefix(row[0], "Diagram: ")
+			break
+		}
+	}
+	if diagram == "" {
+		t.Fatal("could not find diagram row in explain output")
+	}
+	u, err := url.Parse(diagram)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decompressor, err := zlib.NewReader(
+		base64.NewDecoder(base64.URLEncoding, strings.NewReader(u.Fragment)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	json, err := ioutil.ReadAll(decompressor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return json
+}
+
+// TestExplainAnalyzeMaterializerStats verifies that the execution statistics
+// of the root materializer of a vectorized flow are reported by EXPLAIN
+// ANALYZE. The ColBatchScan only reports the KV time, so the execution time
+// of the table reader comes from the materializer.
+func TestExplainAnalyzeMaterializerStats(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	srv, godb, _ := serverutils.StartServer(t, base.TestServerArgs{Insecure: true})
+	defer srv.Stopper().Stop(ctx)
+	r := sqlutils.MakeSQLRunner(godb)
+	r.Exec(t, "CREATE TABLE t (a INT PRIMARY KEY)")
+	r.Exec(t, "INSERT INTO t SELECT generate_series(1, 100)")
+	r.Exec(t, "SET vectorize = on")
+
+	json := explainAnalyzeDiagram(t, r, "SELECT * FROM t")
+	for _, expected := range []string{"KV time", "execution time"} {
+		if !bytes.Contains(json, []byte(expected)) {
+			t.Errorf("expected %q in the diagram, got %s", expected, json)
+		}
+	}
+}
+
+// TestExplainAnalyzeWrappedProcessorStatsWithLimit verifies that the execution
+// statistics of a row-execution processor wrapped into a vectorized flow are
+// reported even if the flow is drained before the processor finishes because
+// of a limit.
+func TestExplainAnalyzeWrappedProcessorStatsWithLimit(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	srv, godb, _ := serverutils.StartServer(t, base.TestServerArgs{Insecure: true})
+	defer srv.Stopper().Stop(ctx)
+	r := sqlutils.MakeSQLRunner(godb)
+	r.Exec(t, "CREATE TABLE l (a INT PRIMARY KEY)")
+	r.Exec(t, "CREATE TABLE r (a INT PRIMARY KEY)")
+	r.Exec(t, "INSERT INTO l SELECT generate_series(1, 1000)")
+	r.Exec(t, "INSERT INTO r SELECT generate_series(1, 1000)")
+	r.Exec(t, "SET vectorize = on")
+
+	// The lookup join is executed by the wrapped joinReader, and only the
+	// joinReader itself reports the number of its input rows.
+	var diagram struct {
+		Processors []struct {
+			Core struct {
+				Title   string   `json:"title"`
+				Details []string `json:"details"`
+			} `json:"core"`
+		} `json:"processors"`
+	}
+	json := explainAnalyzeDiagram(t, r, "SELECT * FROM l INNER LOOKUP JOIN r ON l.a = r.a LIMIT 1")
+	if err := gojson.Unmarshal(json, &diagram); err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, p := range diagram.Processors {
+		if !strings.HasPrefix(p.Core.Title, "JoinReader") {
+			continue
+		}
+		found = true
+		if details := strings.Join(p.Core.Details, "\n"); !strings.Contains(details, "input rows") {
+			t.Errorf("expected the input rows in the statistics of %s, got:\n%s", p.Core.Title, details)
+		}
+	}
+	if !found {
+		t.Fatalf("could not find the JoinReader in the diagram %s", json)
+	}
+}
@@ -75,9 +75,24 @@ func (n *explainVecNode) startExec(params runParams) error {
 		return errors.New("vectorize is set to 'off'")
 	}
 	verbose := n.options.Flags[tree.ExplainFlagVerbose]
-	n.run.lines, err = colflow.ExplainVec(
-		params.ctx, flowCtx, flows, physPlan.LocalProcessors, verbose, willDistribute,
-	)
+	switch {
+	case n.options.Flags[tree.ExplainFlagJSON]:
+		// For the JSON flag, we only want to emit the JSON representation of
+		// the plan as a single row.
+		var planJSON string
+		planJSON, err = colflow.ExplainVecJSON(
+			params.ctx, flowCtx, flows, physPlan.LocalProcessors, verbose, willDistribute,
+		)
+		n.run.lines = []string{planJSON}
+	case n.options.Flags[tree.ExplainFlagDOT]:
+		n.run.lines, err = colflow.ExplainVecDOT(
+			params.ctx, flowCtx, flows, physPlan.LocalProcessors, verbose, willDistribute,
+		)
+	default:
+		n.run.lines, err = colflow.ExplainVec(
+			params.ctx, flowCtx, flows, physPlan.LocalProcessors, verbose, willDistribute,
+		)
+	}
 	if err != nil {
 		return err
 	}
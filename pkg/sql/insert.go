@@ -81,6 +81,10 @@ type insertRun struct {
 
 	// traceKV caches the current KV tracing flag.
 	traceKV bool
+
+	// columnar, if set, is used to insert the source rows instead of
+	// processSourceRow.
+	columnar *columnarInserter
 }
 
 func (r *insertRun) initRowContainer(params runParams, columns colinfo.ResultColumns) {
@@ -187,7 +191,11 @@ func (n *insertNode) startExec(params runParams) error {
 
 	n.run.initRowContainer(params, n.columns)
 
-	return n.run.ti.init(params.ctx, params.p.txn, params.EvalContext())
+	if err := n.run.ti.init(params.ctx, params.p.txn, params.EvalContext()); err != nil {
+		return err
+	}
+	n.run.columnar = newColumnarInserter(params, &n.run, n.source)
+	return nil
 }
 
 // Next is required because batchedPlanNode inherits from planNode, but
@@ -210,7 +218,45 @@ func (n *insertNode) BatchedNext(params runParams) (bool, error) {
 	n.run.ti.clearLastBatch(params.ctx)
 
 	// Now consume/accumulate the rows for this batch.
-	lastBatch := false
+	var lastBatch bool
+	var err error
+	if n.run.columnar != nil {
+		lastBatch, err = n.run.columnar.accumulate(params, &n.run.ti, n.run.traceKV)
+	} else {
+		lastBatch, err = n.processSourceRows(params)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if n.run.ti.currentBatchSize > 0 {
+		if !lastBatch {
+			// We only run/commit the batch if there were some rows processed
+			// in this batch.
+			if err := n.run.ti.flushAndStartNewBatch(params.ctx); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	if lastBatch {
+		if err := n.run.ti.finalize(params.ctx); err != nil {
+			return false, err
+		}
+		// Remember we're done for the next call to BatchedNext().
+		n.run.done = true
+	}
+
+	// Possibly initiate a run of CREATE STATISTICS.
+	params.ExecCfg().StatsRefresher.NotifyMutation(n.run.ti.tableDesc().GetID(), n.run.ti.lastBatchSize)
+
+	return n.run.ti.lastBatchSize > 0, nil
+}
+
+// processSourceRows processes the source rows one at a time until either the
+// current KV batch is full or the source is exhausted, in which case lastBatch
+// is true.
+func (n *insertNode) processSourceRows(params runParams) (lastBatch bool, _ error) {
 	for {
 		if err := params.p.cancelChecker.Check(); err != nil {
 			return false, err
@@ -218,7 +264,6 @@ func (n *insertNode) BatchedNext(params runParams) (bool, error) {
 
 		// Advance one individual row.
 		if next, err := n.source.Next(params); !next {
-			lastBatch = true
 			if err != nil {
 				// TODO(richardjcai): Don't like this, not sure how to check if the
 				// parse error is specifically from the column undergoing the
@@ -228,7 +273,7 @@ func (n *insertNode) BatchedNext(params runParams) (bool, error) {
 				err = interceptAlterColumnTypeParseError(n.run.insertCols, -1, err)
 				return false, err
 			}
-			break
+			return true, nil
 		}
 
 		// Process the insertion for the current source row, potentially
@@ -239,32 +284,9 @@ func (n *insertNode) BatchedNext(params runParams) (bool, error) {
 
 		// Are we done yet with the current batch?
 		if n.run.ti.currentBatchSize >= n.run.ti.maxBatchSize {
-			break
-		}
-	}
-
-	if n.run.ti.currentBatchSize > 0 {
-		if !lastBatch {
-			// We only run/commit the batch if there were some rows processed
-			// in this batch.
-			if err := n.run.ti.flushAndStartNewBatch(params.ctx); err != nil {
-				return false, err
-			}
+			return false, nil
 		}
 	}
-
-	if lastBatch {
-		if err := n.run.ti.finalize(params.ctx); err != nil {
-			return false, err
-		}
-		// Remember we're done for the next call to BatchedNext().
-		n.run.done = true
-	}
-
-	// Possibly initiate a run of CREATE STATISTICS.
-	params.ExecCfg().StatsRefresher.NotifyMutation(n.run.ti.tableDesc().GetID(), n.run.ti.lastBatchSize)
-
-	return n.run.ti.lastBatchSize > 0, nil
 }
 
 // BatchedCount implements the batchedPlanNode interface.
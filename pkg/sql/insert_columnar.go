@@ -0,0 +1,335 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/colencoding"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlerrors"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+	"github.com/lib/pq/oid"
+)
+
+// columnarInsertEnabled controls whether INSERT statements can use the
+// columnarInserter.
+var columnarInsertEnabled = settings.RegisterBoolSetting(
+	"sql.insert.columnar.enabled",
+	"if set, INSERT statements whose input is planned in the vectorized engine "+
+		"produce the KVs directly from the columnar batches when possible",
+	true,
+)
+
+// batchRowSource is a RowSource that can also return its output as
+// coldata.Batches, without converting them to rows.
+type batchRowSource interface {
+	execinfra.RowSource
+	// NextBatch returns the next batch of the output. It returns no batch and
+	// no metadata once the source is exhausted.
+	NextBatch() (coldata.Batch, *execinfrapb.ProducerMetadata)
+}
+
+var _ batchRowSource = &colexec.Materializer{}
+
+// columnarInserter queues the KVs of the source rows of an INSERT directly
+// from the batches of a batchRowSource, without materializing each row into
+// tree.Datums and going through row.Inserter. It only supports the cases in
+// which every row is written as a single KV of the primary index, see
+// newColumnarInserter.
+type columnarInserter struct {
+	source *rowSourceToPlanNode
+	// insertCols are the columns being inserted into, and typs are the types
+	// of the corresponding source columns.
+	insertCols []descpb.ColumnDescriptor
+	typs       []*types.T
+	// intWidths contains, for every source column, the width that the values
+	// of the column must fit into (see tree.AdjustValueToType), or zero if no
+	// range check is needed.
+	intWidths []int32
+
+	// keyPrefix is the prefix of the keys of the primary index.
+	keyPrefix []byte
+	// keyColOrds are the ordinals of the source columns that make up the
+	// primary key, and keyColDirs are their directions in the primary index.
+	keyColOrds []int
+	keyColDirs []descpb.IndexDescriptor_Direction
+	// valueColOrds are the ordinals of the source columns that are stored in
+	// the value of the primary index KV, and valueColIDs are their IDs. The
+	// columns are ordered by ID.
+	valueColOrds []int
+	valueColIDs  []descpb.ColumnID
+
+	// batch is the current batch of the source, and batchIdx is the position
+	// of its next row to be inserted.
+	batch    coldata.Batch
+	batchIdx int
+	valueBuf []byte
+}
+
+// newColumnarInserter returns a columnarInserter for the given insertRun, or
+// nil if the columnar insertion is not supported. It is supported only if the
+// source is a batchRowSource and each source row maps to a single KV: the
+// table must have a single column family, it must not be interleaved or have
+// any writable secondary indexes, and the insert must not have a RETURNING
+// clause or any CHECK constraints. Additionally, the primary key must not
+// have composite key encoding, and the values of the source columns must not
+// need any adjustments to the types of the columns.
+func newColumnarInserter(params runParams, r *insertRun, source planNode) *columnarInserter {
+	if !columnarInsertEnabled.Get(&params.ExecCfg().Settings.SV) ||
+		r.rowsNeeded || !r.checkOrds.Empty() {
+		return nil
+	}
+	rs, ok := source.(*rowSourceToPlanNode)
+	if !ok {
+		return nil
+	}
+	if _, ok := rs.source.(batchRowSource); !ok {
+		return nil
+	}
+	desc := r.ti.tableDesc()
+	if desc.NumFamilies() != 1 || desc.IsInterleaved() || len(desc.WritableNonPrimaryIndexes()) > 0 {
+		return nil
+	}
+	typs := rs.source.OutputTypes()
+	if len(typs) != len(r.insertCols) {
+		return nil
+	}
+	ci := &columnarInserter{
+		source:     rs,
+		insertCols: r.insertCols,
+		typs:       typs,
+		intWidths:  make([]int32, len(typs)),
+		keyPrefix:  rowenc.MakeIndexKeyPrefix(params.ExecCfg().Codec, desc, desc.GetPrimaryIndexID()),
+	}
+	var colIDToOrd catalog.TableColMap
+	for i := range r.insertCols {
+		colTyp := r.insertCols[i].Type
+		if !columnarInsertSupportsType(colTyp, typs[i]) {
+			return nil
+		}
+		if colTyp.Family() == types.IntFamily && intWidth(colTyp) < intWidth(typs[i]) {
+			ci.intWidths[i] = intWidth(colTyp)
+		}
+		colIDToOrd.Set(r.insertCols[i].ID, i)
+	}
+
+	primaryIndex := desc.GetPrimaryIndex()
+	var pkCols catalog.TableColSet
+	for i := 0; i < primaryIndex.NumColumns(); i++ {
+		colID := primaryIndex.GetColumnID(i)
+		ord, ok := colIDToOrd.Get(colID)
+		if !ok || colinfo.HasCompositeKeyEncoding(r.insertCols[ord].Type) {
+			return nil
+		}
+		pkCols.Add(colID)
+		ci.keyColOrds = append(ci.keyColOrds, ord)
+		ci.keyColDirs = append(ci.keyColDirs, primaryIndex.GetColumnDirection(i))
+	}
+
+	// Column family 0 is always encoded as a tuple of its non-NULL columns
+	// ordered by ID, with the primary key columns omitted (see
+	// row.prepareInsertOrUpdateBatch).
+	familyColIDs := append([]descpb.ColumnID(nil), desc.GetFamilies()[0].ColumnIDs...)
+	sort.Slice(familyColIDs, func(i, j int) bool { return familyColIDs[i] < familyColIDs[j] })
+	for _, colID := range familyColIDs {
+		ord, ok := colIDToOrd.Get(colID)
+		if !ok || pkCols.Contains(colID) {
+			continue
+		}
+		ci.valueColOrds = append(ci.valueColOrds, ord)
+		ci.valueColIDs = append(ci.valueColIDs, colID)
+	}
+	return ci
+}
+
+// columnarInsertSupportsType returns whether the values of a source column of
+// type srcTyp can be written into a column of type colTyp without any
+// adjustments, i.e. whether tree.AdjustValueToType is a noop for them. The
+// range checks of integers and timestamps are the only exceptions since the
+// columnarInserter performs them itself (see checkRow).
+func columnarInsertSupportsType(colTyp, srcTyp *types.T) bool {
+	if colTyp.Family() != srcTyp.Family() {
+		return false
+	}
+	switch colTyp.Family() {
+	case types.BoolFamily, types.IntFamily, types.FloatFamily, types.BytesFamily,
+		types.UuidFamily, types.DateFamily, types.JsonFamily, types.INetFamily:
+		return true
+	case types.StringFamily:
+		return colTyp.Width() == 0 && colTyp.Oid() != oid.T_bpchar
+	case types.DecimalFamily:
+		return colTyp.Precision() == 0
+	case types.TimestampFamily, types.TimestampTZFamily:
+		// The timestamps always have microsecond precision, so rounding them
+		// to the default precision is a noop.
+		return colTyp.Precision() == 6
+	default:
+		return false
+	}
+}
+
+// intWidth returns the width in bits of the given integer type.
+func intWidth(t *types.T) int32 {
+	if t.Width() == 0 {
+		return 64
+	}
+	return t.Width()
+}
+
+// accumulate queues the KVs of the source rows into the current KV batch of ti
+// until either the KV batch is full or the source is exhausted, in which case
+// lastBatch is true.
+func (ci *columnarInserter) accumulate(
+	params runParams, ti *tableInserter, traceKV bool,
+) (lastBatch bool, _ error) {
+	for ti.currentBatchSize < ti.maxBatchSize {
+		if err := params.p.cancelChecker.Check(); err != nil {
+			return false, err
+		}
+		if ci.batch == nil || ci.batchIdx >= ci.batch.Length() {
+			batch, err := ci.source.nextBatch()
+			if err != nil {
+				// Intercept parse error due to ALTER COLUMN TYPE schema change.
+				return false, interceptAlterColumnTypeParseError(ci.insertCols, -1, err)
+			}
+			if batch == nil {
+				return true, nil
+			}
+			log.VEventf(params.ctx, 2, "inserting %d rows from a columnar batch", batch.Length())
+			ci.batch, ci.batchIdx = batch, 0
+		}
+		n, err := ci.insertRows(params.ctx, ti.b, ti.maxBatchSize-ti.currentBatchSize, traceKV)
+		if err != nil {
+			return false, err
+		}
+		ti.currentBatchSize += n
+	}
+	return false, nil
+}
+
+// insertRows queues the KVs of up to maxRows rows of the current batch
+// starting from batchIdx into b. It returns the number of rows queued.
+func (ci *columnarInserter) insertRows(
+	ctx context.Context, b *kv.Batch, maxRows int, traceKV bool,
+) (int, error) {
+	var n int
+	sel := ci.batch.Selection()
+	for ; n < maxRows && ci.batchIdx < ci.batch.Length(); ci.batchIdx++ {
+		rowIdx := ci.batchIdx
+		if sel != nil {
+			rowIdx = sel[rowIdx]
+		}
+		if err := ci.checkRow(rowIdx); err != nil {
+			return n, err
+		}
+
+		key := append([]byte(nil), ci.keyPrefix...)
+		var err error
+		for i, ord := range ci.keyColOrds {
+			key, err = colencoding.EncodeTableKeyFromCol(
+				key, ci.batch.ColVec(ord), rowIdx, ci.typs[ord], ci.keyColDirs[i],
+			)
+			if err != nil {
+				return n, err
+			}
+		}
+		kvKey := roachpb.Key(keys.MakeFamilyKey(key, 0 /* famID */))
+
+		ci.valueBuf = ci.valueBuf[:0]
+		var lastColID descpb.ColumnID
+		for i, ord := range ci.valueColOrds {
+			vec := ci.batch.ColVec(ord)
+			if vec.Nulls().NullAt(rowIdx) {
+				continue
+			}
+			colID := ci.valueColIDs[i]
+			ci.valueBuf, err = colencoding.EncodeTableValueFromCol(
+				ci.valueBuf, colID-lastColID, vec, rowIdx, ci.typs[ord], nil /* scratch */)
+			if err != nil {
+				return n, err
+			}
+			lastColID = colID
+		}
+		// SetTuple makes a deep copy, so valueBuf can be reused for the next
+		// row.
+		var kvValue roachpb.Value
+		kvValue.SetTuple(ci.valueBuf)
+
+		if traceKV {
+			log.VEventfDepth(ctx, 1, 2, "CPut %s -> %s", kvKey, kvValue.PrettyPrint())
+		}
+		b.CPut(kvKey, &kvValue, nil /* expValue */)
+		n++
+	}
+	return n, nil
+}
+
+// checkRow performs the checks of enforceLocalColumnConstraints as well as the
+// range checks of integers and timestamps for the row at position rowIdx of the
+// current batch.
+func (ci *columnarInserter) checkRow(rowIdx int) error {
+	for i := range ci.insertCols {
+		vec := ci.batch.ColVec(i)
+		if vec.Nulls().NullAt(rowIdx) {
+			if !ci.insertCols[i].Nullable {
+				return sqlerrors.NewNonNullViolationError(ci.insertCols[i].Name)
+			}
+			continue
+		}
+		if width := ci.intWidths[i]; width != 0 {
+			var v int64
+			switch ci.typs[i].Width() {
+			case 16:
+				v = int64(vec.Int16()[rowIdx])
+			case 32:
+				v = int64(vec.Int32()[rowIdx])
+			default:
+				v = vec.Int64()[rowIdx]
+			}
+			if shifted := v >> uint(width-1); (v >= 0 && shifted > 0) || (v < 0 && shifted < -1) {
+				return pgerror.Newf(pgcode.NumericValueOutOfRange,
+					"integer out of range for type %s",
+					ci.insertCols[i].Type.Name(),
+				)
+			}
+		}
+		switch ci.typs[i].Family() {
+		case types.TimestampFamily, types.TimestampTZFamily:
+			// The vectorized engine doesn't always keep the timestamps within
+			// the supported bounds, which tree.MakeDTimestamp enforces on the
+			// row path.
+			t := vec.Timestamp()[rowIdx]
+			if t.After(tree.MaxSupportedTime) || t.Before(tree.MinSupportedTime) {
+				return errors.Newf("timestamp %q exceeds supported timestamp bounds", t.Format(time.RFC3339))
+			}
+		}
+	}
+	return nil
+}
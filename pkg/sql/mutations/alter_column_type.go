@@ -0,0 +1,210 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"context"
+	"math/rand"
+
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/sql/lex"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachange"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+// AlterColumnTypeMutator appends ALTER TABLE ... ALTER COLUMN TYPE statements
+// that change the type of random columns of the tables created by the
+// statements. Both trivial conversions, like widening an INT4 column to INT8,
+// and general conversions, which rewrite the column, like converting an INT8
+// column to STRING, are added. General conversions are preceded by the
+// session setting that enables them. Only columns that are not referenced by
+// indexes, constraints, computed columns, views or other schema changes are
+// altered, since general conversions of those are not supported, and the
+// conversions are chosen so that they succeed for all values of the column.
+var AlterColumnTypeMutator = VersionedMutator{MultiStatementMutation(alterColumnTypeMutator), clusterversion.V20_2}
+
+// alterColumnTypeGeneralSetting is the session setting that enables general
+// ALTER COLUMN TYPE conversions.
+const alterColumnTypeGeneralSetting = "enable_experimental_alter_column_type_general"
+
+func alterColumnTypeMutator(
+	rng *rand.Rand, stmts []tree.Statement,
+) (mutated []tree.Statement, changed bool) {
+	// ddlIdents contains the identifiers used by every schema change,
+	// keyed by the index of its statement.
+	ddlIdents := map[int]map[string]int{}
+	// dropped contains the tables that are dropped or renamed, and viewed
+	// the identifiers used by views, which can depend on all columns of the
+	// tables they select from.
+	dropped := map[tableKey]bool{}
+	viewed := map[string]bool{}
+	for i, stmt := range stmts {
+		if stmt.StatementType() != tree.DDL {
+			continue
+		}
+		ddlIdents[i] = identifierCounts(stmt)
+		switch stmt := stmt.(type) {
+		case *tree.CreateView:
+			for ident := range ddlIdents[i] {
+				viewed[ident] = true
+			}
+		case *tree.DropTable:
+			for j := range stmt.Names {
+				dropped[makeTableKey(&stmt.Names[j])] = true
+			}
+		case *tree.RenameTable:
+			tn := stmt.Name.ToTableName()
+			dropped[makeTableKey(&tn)] = true
+		}
+	}
+
+	var enabled bool
+	mutated = append([]tree.Statement(nil), stmts...)
+	for i, stmt := range stmts {
+		create, ok := stmt.(*tree.CreateTable)
+		if !ok || dropped[makeTableKey(&create.Table)] || viewed[string(create.Table.ObjectName)] ||
+			rng.Intn(2) == 0 {
+			continue
+		}
+		var candidates []*tree.ColumnTableDef
+		for _, def := range create.Defs {
+			col, ok := def.(*tree.ColumnTableDef)
+			if !ok || !canAlterColumnType(col) || ddlIdents[i][string(col.Name)] != 1 {
+				continue
+			}
+			referenced := false
+			for j, idents := range ddlIdents {
+				if j != i && idents[string(col.Name)] > 0 {
+					referenced = true
+					break
+				}
+			}
+			if !referenced {
+				candidates = append(candidates, col)
+			}
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+		col := candidates[rng.Intn(len(candidates))]
+		from, _ := tree.GetStaticallyKnownType(col.Type)
+		to, using, ok := randAlterColumnType(rng, from, col.Name)
+		if !ok {
+			continue
+		}
+		kind := schemachange.ColumnConversionGeneral
+		if using == nil {
+			var err error
+			if kind, err = schemachange.ClassifyConversion(context.Background(), from, to); err != nil {
+				continue
+			}
+		}
+		if kind != schemachange.ColumnConversionTrivial && !enabled {
+			mutated = append(mutated, &tree.SetVar{
+				Name:   alterColumnTypeGeneralSetting,
+				Values: tree.Exprs{tree.DBoolTrue},
+			})
+			enabled = true
+		}
+		mutated = append(mutated, &tree.AlterTable{
+			Table: create.Table.ToUnresolvedObjectName(),
+			Cmds: tree.AlterTableCmds{
+				&tree.AlterTableAlterColumnType{Column: col.Name, ToType: to, Using: using},
+			},
+		})
+		changed = true
+	}
+	return mutated, changed
+}
+
+// canAlterColumnType returns whether the type of col can be altered, as far
+// as its definition is concerned.
+func canAlterColumnType(col *tree.ColumnTableDef) bool {
+	if col.PrimaryKey.IsPrimaryKey || col.Unique.IsUnique || col.IsComputed() ||
+		col.HasFKConstraint() || len(col.CheckExprs) > 0 || col.Hidden {
+		return false
+	}
+	_, ok := tree.GetStaticallyKnownType(col.Type)
+	return ok
+}
+
+// randAlterColumnType returns a random type that the values of a column of
+// type from and named name can be converted to, and the USING expression of
+// the conversion, if it needs one. It returns false if there is no such type.
+func randAlterColumnType(
+	rng *rand.Rand, from *types.T, name tree.Name,
+) (to *types.T, using tree.Expr, ok bool) {
+	var targets []*types.T
+	switch from.Family() {
+	case types.IntFamily:
+		switch from.Width() {
+		case 16:
+			targets = []*types.T{types.Int4, types.Int}
+		case 32:
+			targets = []*types.T{types.Int}
+		}
+		targets = append(targets, types.String, types.Float, types.Decimal)
+	case types.FloatFamily:
+		if from.Width() == 32 {
+			targets = append(targets, types.Float)
+		}
+		targets = append(targets, types.String)
+	case types.DecimalFamily:
+		if from.Precision() > 0 {
+			targets = append(targets, types.Decimal)
+		}
+		targets = append(targets, types.String)
+	case types.StringFamily:
+		if from.Width() > 0 {
+			targets = append(targets, types.String)
+		} else {
+			// Convert with an explicit expression, which rewrites the
+			// column, like the general conversions.
+			if rng.Intn(2) == 0 {
+				return types.Int, &tree.FuncExpr{
+					Func:  tree.WrapFunction("length"),
+					Exprs: tree.Exprs{&tree.UnresolvedName{NumParts: 1, Parts: tree.NameParts{string(name)}}},
+				}, true
+			}
+		}
+		targets = append(targets, types.Bytes)
+	case types.BytesFamily:
+		targets = []*types.T{types.String}
+	case types.BoolFamily:
+		targets = []*types.T{types.Int, types.String}
+	case types.TimestampFamily:
+		targets = []*types.T{types.TimestampTZ, types.String}
+	case types.TimestampTZFamily:
+		targets = []*types.T{types.Timestamp, types.String}
+	case types.DateFamily:
+		targets = []*types.T{types.Timestamp, types.String}
+	case types.UuidFamily, types.INetFamily, types.JsonFamily, types.TimeFamily, types.IntervalFamily:
+		targets = []*types.T{types.String}
+	default:
+		return nil, nil, false
+	}
+	return targets[rng.Intn(len(targets))], nil, true
+}
+
+// identifierCounts returns the number of times every identifier is used by
+// stmt.
+func identifierCounts(stmt tree.Statement) map[string]int {
+	counts := map[string]int{}
+	tokens, _ := parser.Tokens(tree.Serialize(stmt))
+	for _, tok := range tokens {
+		if tok.TokenID == lex.IDENT {
+			counts[tok.Str]++
+		}
+	}
+	return counts
+}
@@ -0,0 +1,81 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+func TestAlterColumnTypeMutator(t *testing.T) {
+	q := `
+		CREATE TABLE t (
+			k INT8 PRIMARY KEY,
+			i INT2,
+			s STRING,
+			c INT8 AS (k + 1) STORED,
+			x INT8 CHECK (x > 0),
+			y INT8,
+			INDEX (y)
+		);
+		CREATE TABLE u (k INT8 PRIMARY KEY, v STRING);
+		CREATE VIEW w AS SELECT * FROM u;
+		INSERT INTO t (k, i, s) VALUES (1, 2, 'a');
+	`
+	rng, _ := randutil.NewPseudoRand()
+	found := map[string]bool{}
+	for i := 0; i < 1000; i++ {
+		mutated, changed := ApplyString(rng, q, AlterColumnTypeMutator)
+		if !changed {
+			continue
+		}
+		parsed, err := parser.Parse(mutated)
+		if err != nil {
+			t.Fatalf("error parsing %s: %v", mutated, err)
+		}
+		// The statements are appended.
+		if _, ok := parsed[3].AST.(*tree.Insert); !ok {
+			t.Fatalf("expected the statements to be appended: %s", mutated)
+		}
+		enabled := false
+		for _, p := range parsed[4:] {
+			switch stmt := p.AST.(type) {
+			case *tree.SetVar:
+				enabled = true
+			case *tree.AlterTable:
+				alter := stmt.Cmds[0].(*tree.AlterTableAlterColumnType)
+				// Only i and s can be altered: u is used by a view, and the
+				// other columns are part of indexes or constraints.
+				if stmt.Table.String() != "t" || (alter.Column != "i" && alter.Column != "s") {
+					t.Fatalf("unexpected ALTER COLUMN TYPE: %s", mutated)
+				}
+				general := alter.Using != nil || !strings.HasPrefix(alter.ToType.SQLString(), "INT")
+				if general && !enabled {
+					t.Fatalf("expected general conversion to be enabled: %s", mutated)
+				}
+				if general {
+					found["general"] = true
+				} else {
+					found["trivial"] = true
+				}
+			default:
+				t.Fatalf("unexpected statement %s: %s", p.SQL, mutated)
+			}
+		}
+	}
+	if len(found) != 2 {
+		t.Fatalf("expected trivial and general conversions, found %v", found)
+	}
+}
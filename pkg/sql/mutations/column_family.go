@@ -0,0 +1,206 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+// StorageColumnFamilyMutator complements ColumnFamilyMutator. Instead of
+// assigning columns to families at random, it groups the columns of random
+// CREATE TABLE statements without families by how they are stored: the
+// fixed-size columns share a family, and the columns that can hold large
+// values, like STRING, BYTES, JSONB and array columns, are either grouped
+// together in a family of their own or get a family each. The STORING
+// columns of the indexes of the mutated tables are then validated: columns
+// that cannot be stored are removed, and large columns are added to random
+// indexes, so that their entries span several families.
+var StorageColumnFamilyMutator = VersionedMutator{MultiStatementMutation(storageColumnFamilyMutator), clusterversion.V20_2}
+
+const (
+	// fixedFamilyName is the name of the family of the fixed-size columns.
+	fixedFamilyName = "fam_fixed"
+	// largeFamilyName is the name of the family of the large columns, or
+	// the prefix of their names if they get a family each.
+	largeFamilyName = "fam_large"
+)
+
+func storageColumnFamilyMutator(
+	rng *rand.Rand, stmts []tree.Statement,
+) (mutated []tree.Statement, changed bool) {
+	// grouped contains the tables whose columns were grouped into families.
+	grouped := map[tableKey]*tree.CreateTable{}
+	for _, stmt := range stmts {
+		create, ok := stmt.(*tree.CreateTable)
+		if !ok || rng.Intn(2) == 0 {
+			continue
+		}
+		if groupColumnFamilies(rng, create) {
+			grouped[makeTableKey(&create.Table)] = create
+			changed = true
+		}
+	}
+	if !changed {
+		return stmts, false
+	}
+
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *tree.CreateTable:
+			if grouped[makeTableKey(&stmt.Table)] != stmt {
+				continue
+			}
+			for _, def := range stmt.Defs {
+				switch def := def.(type) {
+				case *tree.IndexTableDef:
+					def.Storing = fixIndexStoring(rng, stmt, def.Columns, def.Storing, def.Inverted)
+				case *tree.UniqueConstraintTableDef:
+					if !def.PrimaryKey && !def.WithoutIndex {
+						def.Storing = fixIndexStoring(rng, stmt, def.Columns, def.Storing, def.Inverted)
+					}
+				}
+			}
+		case *tree.CreateIndex:
+			if create, ok := grouped[makeTableKey(&stmt.Table)]; ok {
+				stmt.Storing = fixIndexStoring(rng, create, stmt.Columns, stmt.Storing, stmt.Inverted)
+			}
+		}
+	}
+	return stmts, true
+}
+
+// groupColumnFamilies adds families to create that group its columns by how
+// they are stored. It returns false if create already has families, or if
+// the columns cannot be split into several families.
+func groupColumnFamilies(rng *rand.Rand, create *tree.CreateTable) bool {
+	var fixed, large tree.NameList
+	for _, def := range create.Defs {
+		switch def := def.(type) {
+		case *tree.FamilyTableDef:
+			return false
+		case *tree.ColumnTableDef:
+			if def.HasColumnFamily() {
+				return false
+			}
+			if def.Computed.Virtual {
+				continue
+			}
+			if isLargeColumn(def) {
+				large = append(large, def.Name)
+			} else {
+				fixed = append(fixed, def.Name)
+			}
+		}
+	}
+	if len(large) == 0 {
+		return false
+	}
+
+	var families []*tree.FamilyTableDef
+	if len(fixed) > 0 {
+		families = append(families, &tree.FamilyTableDef{Name: fixedFamilyName, Columns: fixed})
+	}
+	if rng.Intn(2) == 0 {
+		families = append(families, &tree.FamilyTableDef{Name: largeFamilyName, Columns: large})
+	} else {
+		for i, col := range large {
+			families = append(families, &tree.FamilyTableDef{
+				Name:    tree.Name(fmt.Sprintf("%s_%d", largeFamilyName, i)),
+				Columns: tree.NameList{col},
+			})
+		}
+	}
+	if len(families) < 2 {
+		return false
+	}
+	for _, fam := range families {
+		create.Defs = append(create.Defs, fam)
+	}
+	return true
+}
+
+// isLargeColumn returns whether col has a variable-length type whose values
+// can be large.
+func isLargeColumn(col *tree.ColumnTableDef) bool {
+	typ, ok := tree.GetStaticallyKnownType(col.Type)
+	if !ok {
+		// User-defined types are enums, whose values are small.
+		return false
+	}
+	switch typ.Family() {
+	case types.StringFamily, types.CollatedStringFamily, types.BytesFamily, types.JsonFamily,
+		types.ArrayFamily, types.GeometryFamily, types.GeographyFamily:
+		return true
+	}
+	return false
+}
+
+// fixIndexStoring returns the columns of storing that can be stored by an
+// index of create on the given columns: columns that don't exist, are
+// virtual, or are part of the primary key or of the index are removed, and
+// inverted indexes store no columns. A random large column is added with a
+// probability of 50%.
+func fixIndexStoring(
+	rng *rand.Rand,
+	create *tree.CreateTable,
+	columns tree.IndexElemList,
+	storing tree.NameList,
+	inverted bool,
+) tree.NameList {
+	if inverted {
+		return nil
+	}
+	cols := map[tree.Name]*tree.ColumnTableDef{}
+	excluded := map[tree.Name]bool{}
+	for _, def := range create.Defs {
+		switch def := def.(type) {
+		case *tree.ColumnTableDef:
+			cols[def.Name] = def
+			if def.PrimaryKey.IsPrimaryKey || def.Computed.Virtual {
+				excluded[def.Name] = true
+			}
+		case *tree.UniqueConstraintTableDef:
+			if def.PrimaryKey {
+				for _, elem := range def.Columns {
+					excluded[elem.Column] = true
+				}
+			}
+		}
+	}
+	for _, elem := range columns {
+		excluded[elem.Column] = true
+	}
+
+	var res tree.NameList
+	for _, name := range storing {
+		if _, ok := cols[name]; ok && !excluded[name] {
+			res = append(res, name)
+			excluded[name] = true
+		}
+	}
+	if rng.Intn(2) == 0 {
+		var candidates tree.NameList
+		for _, def := range create.Defs {
+			if col, ok := def.(*tree.ColumnTableDef); ok && !excluded[col.Name] && isLargeColumn(col) {
+				candidates = append(candidates, col.Name)
+			}
+		}
+		if len(candidates) > 0 {
+			res = append(res, candidates[rng.Intn(len(candidates))])
+		}
+	}
+	return res
+}
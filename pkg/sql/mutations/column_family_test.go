@@ -0,0 +1,70 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+func TestStorageColumnFamilyMutator(t *testing.T) {
+	q := `
+		CREATE TABLE t (
+			k INT8 PRIMARY KEY,
+			i INT8,
+			s STRING,
+			b BYTES,
+			j JSONB,
+			INDEX (i) STORING (k, s, unknown),
+			INVERTED INDEX (j)
+		);
+		CREATE INDEX ON t (s) STORING (i, s);
+		CREATE TABLE u (k INT8 PRIMARY KEY, i INT8);
+	`
+	rng, _ := randutil.NewPseudoRand()
+	found := map[string]bool{}
+	for i := 0; i < 1000 && len(found) < 3; i++ {
+		mutated, changed := ApplyString(rng, q, StorageColumnFamilyMutator)
+		if !changed {
+			continue
+		}
+		if _, err := parser.Parse(mutated); err != nil {
+			t.Fatalf("error parsing %s: %v", mutated, err)
+		}
+		// u has no large columns.
+		if !strings.Contains(mutated, "CREATE TABLE u (k INT8 PRIMARY KEY, i INT8);") {
+			t.Fatalf("expected u to be unchanged: %s", mutated)
+		}
+		// The primary key, indexed and unknown columns are removed from
+		// STORING.
+		for _, e := range []string{"STORING (k", "unknown", "STORING (i, s"} {
+			if strings.Contains(mutated, e) {
+				t.Fatalf("unexpected %s: %s", e, mutated)
+			}
+		}
+		if strings.Contains(mutated, "FAMILY fam_fixed (k, i), FAMILY fam_large (s, b, j)") {
+			found["grouped"] = true
+		}
+		if strings.Contains(mutated, "FAMILY fam_fixed (k, i), FAMILY fam_large_0 (s), FAMILY fam_large_1 (b), FAMILY fam_large_2 (j)") {
+			found["separate"] = true
+		}
+		if strings.Contains(mutated, "INDEX ON t (s) STORING (i, b)") ||
+			strings.Contains(mutated, "INDEX ON t (s) STORING (i, j)") {
+			found["added"] = true
+		}
+	}
+	if len(found) != 3 {
+		t.Fatalf("expected grouped and separate families and added STORING columns, found %v", found)
+	}
+}
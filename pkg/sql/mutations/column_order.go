@@ -0,0 +1,188 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+// ColumnOrderMutator shuffles the columns of random CREATE TABLE statements
+// and adds pairs of ALTER TABLE ADD COLUMN and ALTER TABLE DROP COLUMN
+// statements after random CREATE TABLE statements, so that the IDs of the
+// columns differ from their ordinal positions. INSERT statements without a
+// column list are given the column list of the original column order. The
+// order of the columns returned by SELECT * changes, so the mutator should
+// only be used by tests that don't depend on it.
+var ColumnOrderMutator = VersionedMutator{MultiStatementMutation(columnOrderMutator), clusterversion.V20_2}
+
+// columnChurnPrefix is the prefix of the names of the columns that are added
+// and dropped by ColumnOrderMutator.
+const columnChurnPrefix = "mut_dropped_"
+
+func columnOrderMutator(
+	rng *rand.Rand, stmts []tree.Statement,
+) (mutated []tree.Statement, changed bool) {
+	// The original order of the columns has to be known for the INSERT
+	// statements without a column list, which is only the case if their rows
+	// come from a VALUES clause, and if no columns are added to the table.
+	pinned := map[tableKey]bool{}
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *tree.Insert:
+			if stmt.Columns != nil || stmt.Rows == nil || stmt.Rows.Select == nil {
+				continue
+			}
+			if _, ok := stmt.Rows.Select.(*tree.ValuesClause); ok {
+				continue
+			}
+			if tn, ok := insertTableName(stmt); ok {
+				pinned[makeTableKey(tn)] = true
+			}
+		case *tree.AlterTable:
+			for _, cmd := range stmt.Cmds {
+				if _, ok := cmd.(*tree.AlterTableAddColumn); ok {
+					tn := stmt.Table.ToTableName()
+					pinned[makeTableKey(&tn)] = true
+				}
+			}
+		}
+	}
+
+	// visibleCols contains the names of the visible columns of the shuffled
+	// tables in their original order.
+	visibleCols := map[tableKey]tree.NameList{}
+	mutated = make([]tree.Statement, 0, len(stmts))
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *tree.CreateTable:
+			mutated = append(mutated, stmt)
+			if stmt.As() {
+				continue
+			}
+			key := makeTableKey(&stmt.Table)
+			if !pinned[key] && rng.Intn(2) == 0 {
+				if cols, ok := shuffleColumns(rng, stmt); ok {
+					visibleCols[key] = cols
+					changed = true
+				}
+			}
+			if rng.Intn(2) == 0 {
+				mutated = append(mutated, columnChurn(rng, stmt)...)
+				changed = true
+			}
+
+		case *tree.Insert:
+			mutated = append(mutated, stmt)
+			if stmt.Columns != nil || stmt.Rows == nil {
+				continue
+			}
+			values, ok := stmt.Rows.Select.(*tree.ValuesClause)
+			if !ok || len(values.Rows) == 0 {
+				continue
+			}
+			tn, ok := insertTableName(stmt)
+			if !ok {
+				continue
+			}
+			cols, ok := visibleCols[makeTableKey(tn)]
+			if !ok {
+				continue
+			}
+			// Rows with fewer values than columns insert into the first
+			// columns of the table.
+			if n := len(values.Rows[0]); n < len(cols) {
+				cols = cols[:n]
+			}
+			stmt.Columns = append(tree.NameList(nil), cols...)
+
+		default:
+			mutated = append(mutated, stmt)
+		}
+	}
+	return mutated, changed
+}
+
+// insertTableName returns the name of the table of ins, if it is a table name
+// with an optional alias.
+func insertTableName(ins *tree.Insert) (*tree.TableName, bool) {
+	table := ins.Table
+	if aliased, ok := table.(*tree.AliasedTableExpr); ok {
+		table = aliased.Expr
+	}
+	tn, ok := table.(*tree.TableName)
+	return tn, ok
+}
+
+// shuffleColumns shuffles the column definitions of create among their
+// positions in the table definitions. It returns the names of the visible
+// columns in their original order, and false if there are fewer than two
+// columns.
+func shuffleColumns(rng *rand.Rand, create *tree.CreateTable) (tree.NameList, bool) {
+	var positions []int
+	var cols []*tree.ColumnTableDef
+	var visible tree.NameList
+	for i, def := range create.Defs {
+		if col, ok := def.(*tree.ColumnTableDef); ok {
+			positions = append(positions, i)
+			cols = append(cols, col)
+			if !col.Hidden {
+				visible = append(visible, col.Name)
+			}
+		}
+	}
+	if len(cols) < 2 {
+		return nil, false
+	}
+	rng.Shuffle(len(cols), func(i, j int) {
+		cols[i], cols[j] = cols[j], cols[i]
+	})
+	for i, pos := range positions {
+		create.Defs[pos] = cols[i]
+	}
+	return visible, true
+}
+
+// columnChurn returns between one and three pairs of statements that add a
+// column to the table created by create and drop it again.
+func columnChurn(rng *rand.Rand, create *tree.CreateTable) []tree.Statement {
+	names := map[tree.Name]bool{}
+	for _, def := range create.Defs {
+		if col, ok := def.(*tree.ColumnTableDef); ok {
+			names[col.Name] = true
+		}
+	}
+	var stmts []tree.Statement
+	for i, n := 0, 1+rng.Intn(3); len(stmts) < 2*n; i++ {
+		name := tree.Name(fmt.Sprintf("%s%d", columnChurnPrefix, i))
+		if names[name] {
+			continue
+		}
+		table := create.Table.ToUnresolvedObjectName()
+		stmts = append(stmts,
+			&tree.AlterTable{
+				Table: table,
+				Cmds: tree.AlterTableCmds{&tree.AlterTableAddColumn{
+					ColumnDef: &tree.ColumnTableDef{Name: name, Type: types.Int},
+				}},
+			},
+			&tree.AlterTable{
+				Table: table,
+				Cmds:  tree.AlterTableCmds{&tree.AlterTableDropColumn{Column: name}},
+			},
+		)
+	}
+	return stmts
+}
@@ -0,0 +1,65 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+func TestColumnOrderMutator(t *testing.T) {
+	q := `
+		CREATE TABLE t (a INT8, b STRING, c FLOAT8, d INT8 NOT VISIBLE, PRIMARY KEY (a));
+		INSERT INTO t VALUES (1, 'b', 1.5);
+		INSERT INTO t VALUES (2);
+		CREATE TABLE u (x INT8, y INT8);
+		INSERT INTO u SELECT a, a FROM t;
+	`
+	rng, _ := randutil.NewPseudoRand()
+	var shuffled, churned bool
+	for i := 0; i < 1000 && !(shuffled && churned); i++ {
+		mutated, changed := ApplyString(rng, q, ColumnOrderMutator)
+		if !changed {
+			continue
+		}
+		if _, err := parser.Parse(mutated); err != nil {
+			t.Fatalf("error parsing %s: %v", mutated, err)
+		}
+		// The INSERT statements into a shuffled t insert into the columns of
+		// the original order, and u is not shuffled because of the INSERT ...
+		// SELECT.
+		expected := []string{
+			"CREATE TABLE u (x INT8, y INT8)",
+			"INSERT INTO u SELECT a, a FROM t",
+		}
+		if !strings.Contains(mutated, "CREATE TABLE t (a INT8, b STRING, c FLOAT8, d INT8 NOT VISIBLE,") {
+			shuffled = true
+			expected = append(expected,
+				"INSERT INTO t(a, b, c) VALUES (1, 'b', 1.5)",
+				"INSERT INTO t(a) VALUES (2)",
+			)
+		}
+		for _, e := range expected {
+			if !strings.Contains(mutated, e) {
+				t.Fatalf("expected %s: %s", e, mutated)
+			}
+		}
+		if strings.Contains(mutated, "ALTER TABLE t ADD COLUMN mut_dropped_0 INT8;\nALTER TABLE t DROP COLUMN mut_dropped_0;") {
+			churned = true
+		}
+	}
+	if !shuffled || !churned {
+		t.Fatalf("expected shuffled and churned tables, found shuffled=%t churned=%t", shuffled, churned)
+	}
+}
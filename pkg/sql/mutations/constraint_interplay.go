@@ -0,0 +1,157 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"math/rand"
+
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+)
+
+// ConstraintInterplayMutator adds interacting constraints on the same
+// columns: a random column of a random CREATE TABLE statement gets a foreign
+// key reference to a table created before it, or to its own table, together
+// with a combination of a CHECK constraint, a UNIQUE constraint and a NOT
+// NULL constraint. The actions of the foreign key are chosen among the ones
+// that are valid for the column, see isValidReferenceAction, so that the
+// statements can be executed, but the constraints can still conflict when
+// the actions run, for example when ON UPDATE CASCADE changes a UNIQUE
+// column.
+var ConstraintInterplayMutator = VersionedMutator{SchemaAwareMutation(constraintInterplayMutator), clusterversion.V20_2}
+
+func constraintInterplayMutator(
+	rng *rand.Rand, schema *Schema, stmts []tree.Statement,
+) (mutated []tree.Statement, changed bool) {
+	tables := schema.Tables()
+	for i, table := range tables {
+		if rng.Intn(2) == 0 {
+			continue
+		}
+		// The columns must be defined by the CREATE TABLE statements, so
+		// that the constraints can be added to them.
+		cols := createTableColumns(table.Create)
+		rng.Shuffle(len(cols), func(a, b int) {
+			cols[a], cols[b] = cols[b], cols[a]
+		})
+		fkCols := foreignKeyColumns(table.Create)
+		var col, refCol *tree.ColumnTableDef
+		var ref *SchemaTable
+	LoopCol:
+		for _, c := range cols {
+			if c.PrimaryKey.IsPrimaryKey || c.IsComputed() || fkCols[c.Name] {
+				continue
+			}
+			// The referenced table must have been created before the
+			// table, or be the table itself.
+			for _, r := range tables[:i+1] {
+				for _, rc := range createTableColumns(r.Create) {
+					if rc != c && !rc.Computed.Virtual && isEquivalentIndexableColumn(c, rc) {
+						col, refCol, ref = c, rc, r
+						break LoopCol
+					}
+				}
+			}
+		}
+		if col == nil {
+			continue
+		}
+
+		if len(uniqueColumnSets(ref.Create, []*tree.ColumnTableDef{refCol})) == 0 {
+			ref.Create.Defs = append(ref.Create.Defs, &tree.UniqueConstraintTableDef{
+				IndexTableDef: tree.IndexTableDef{Columns: tree.IndexElemList{{Column: refCol.Name}}},
+			})
+		}
+		check, unique, notNull := rng.Intn(2) == 0, rng.Intn(2) == 0, rng.Intn(2) == 0
+		if !check && !unique && !notNull {
+			check = true
+		}
+		if notNull {
+			col.Nullable.Nullability = tree.NotNull
+		}
+		if unique && len(uniqueColumnSets(table.Create, []*tree.ColumnTableDef{col})) == 0 {
+			table.Create.Defs = append(table.Create.Defs, &tree.UniqueConstraintTableDef{
+				IndexTableDef: tree.IndexTableDef{Columns: tree.IndexElemList{{Column: col.Name}}},
+			})
+		}
+		if check {
+			// The check is satisfied by all values, but it is evaluated when
+			// the actions of the foreign key change the column.
+			colRef := &tree.UnresolvedName{NumParts: 1, Parts: tree.NameParts{string(col.Name)}}
+			table.Create.Defs = append(table.Create.Defs, &tree.CheckConstraintTableDef{
+				Expr: &tree.OrExpr{
+					Left:  &tree.IsNullExpr{Expr: colRef},
+					Right: &tree.ComparisonExpr{Operator: tree.EQ, Left: colRef, Right: colRef},
+				},
+			})
+		}
+		fk := &tree.ForeignKeyConstraintTableDef{
+			Table:    ref.Create.Table,
+			FromCols: tree.NameList{col.Name},
+			ToCols:   tree.NameList{refCol.Name},
+		}
+		fkFrom := []*tree.ColumnTableDef{col}
+		if rng.Intn(2) == 0 {
+			fk.Actions.Delete = randAction(rng, table.Create, fkFrom)
+		}
+		if rng.Intn(2) == 0 {
+			fk.Actions.Update = randAction(rng, table.Create, fkFrom)
+		}
+		table.Create.Defs = append(table.Create.Defs, fk)
+		changed = true
+	}
+	return stmts, changed
+}
+
+// createTableColumns returns the columns defined by create.
+func createTableColumns(create *tree.CreateTable) []*tree.ColumnTableDef {
+	var cols []*tree.ColumnTableDef
+	for _, def := range create.Defs {
+		if col, ok := def.(*tree.ColumnTableDef); ok {
+			cols = append(cols, col)
+		}
+	}
+	return cols
+}
+
+// foreignKeyColumns returns the names of the columns of create that reference
+// other columns.
+func foreignKeyColumns(create *tree.CreateTable) map[tree.Name]bool {
+	cols := map[tree.Name]bool{}
+	for _, def := range create.Defs {
+		switch def := def.(type) {
+		case *tree.ColumnTableDef:
+			if def.HasFKConstraint() {
+				cols[def.Name] = true
+			}
+		case *tree.ForeignKeyConstraintTableDef:
+			for _, name := range def.FromCols {
+				cols[name] = true
+			}
+		}
+	}
+	return cols
+}
+
+// isEquivalentIndexableColumn returns whether a and b have equivalent types
+// that are indexable, so that a can reference b.
+func isEquivalentIndexableColumn(a, b *tree.ColumnTableDef) bool {
+	aType, ok := tree.GetStaticallyKnownType(a.Type)
+	if !ok {
+		return false
+	}
+	bType, ok := tree.GetStaticallyKnownType(b.Type)
+	if !ok {
+		return false
+	}
+	return aType.Equivalent(bType) && colinfo.ColumnTypeIsIndexable(bType)
+}
@@ -0,0 +1,123 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+func TestIsValidReferenceAction(t *testing.T) {
+	stmt, err := parser.ParseOne(`
+		CREATE TABLE t (
+			a INT8, b INT8 NOT NULL, c INT8 NOT NULL DEFAULT 1, d INT8 NOT NULL DEFAULT NULL,
+			e INT8 AS (a + 1) STORED, f INT8,
+			PRIMARY KEY (f)
+		)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	create := stmt.AST.(*tree.CreateTable)
+	cols := map[tree.Name]*tree.ColumnTableDef{}
+	for _, col := range createTableColumns(create) {
+		cols[col.Name] = col
+	}
+	for _, tc := range []struct {
+		col    tree.Name
+		action tree.ReferenceAction
+		valid  bool
+	}{
+		{"a", tree.SetNull, true},
+		{"a", tree.SetDefault, true},
+		{"b", tree.SetNull, false},
+		{"b", tree.SetDefault, false},
+		{"b", tree.Cascade, true},
+		{"c", tree.SetNull, false},
+		{"c", tree.SetDefault, true},
+		{"d", tree.SetDefault, false},
+		{"e", tree.Cascade, false},
+		{"e", tree.Restrict, true},
+		// f is not nullable since it is the primary key.
+		{"f", tree.SetNull, false},
+		{"f", tree.NoAction, true},
+	} {
+		valid := isValidReferenceAction(tc.action, create, []*tree.ColumnTableDef{cols[tc.col]})
+		if valid != tc.valid {
+			t.Errorf("%s %s: expected %t, found %t", tc.col, tc.action, tc.valid, valid)
+		}
+	}
+}
+
+func TestConstraintInterplayMutator(t *testing.T) {
+	q := `
+		CREATE TABLE p (k INT8 PRIMARY KEY, v INT8);
+		CREATE TABLE c (k INT8 PRIMARY KEY, p INT8, s STRING);
+		INSERT INTO p VALUES (1, 1);
+	`
+	rng, _ := randutil.NewPseudoRand()
+	found := map[string]bool{}
+	for i := 0; i < 1000; i++ {
+		mutated, changed := ApplyString(rng, q, ConstraintInterplayMutator)
+		if !changed {
+			continue
+		}
+		parsed, err := parser.Parse(mutated)
+		if err != nil {
+			t.Fatalf("error parsing %s: %v", mutated, err)
+		}
+		for _, p := range parsed {
+			create, ok := p.AST.(*tree.CreateTable)
+			if !ok {
+				continue
+			}
+			var fk *tree.ForeignKeyConstraintTableDef
+			checks := 0
+			for _, def := range create.Defs {
+				switch def := def.(type) {
+				case *tree.ForeignKeyConstraintTableDef:
+					fk = def
+				case *tree.CheckConstraintTableDef:
+					checks++
+				}
+			}
+			if fk == nil {
+				continue
+			}
+			found["fk"] = true
+			if checks > 0 {
+				found["check"] = true
+			}
+			var col *tree.ColumnTableDef
+			for _, c := range createTableColumns(create) {
+				if c.Name == fk.FromCols[0] {
+					col = c
+				}
+			}
+			if col == nil || col.PrimaryKey.IsPrimaryKey {
+				t.Fatalf("unexpected foreign key column: %s", mutated)
+			}
+			if col.Nullable.Nullability == tree.NotNull {
+				found["not null"] = true
+			}
+			for _, action := range []tree.ReferenceAction{fk.Actions.Delete, fk.Actions.Update} {
+				if !isValidReferenceAction(action, create, []*tree.ColumnTableDef{col}) {
+					t.Fatalf("invalid action %s: %s", action, mutated)
+				}
+			}
+		}
+	}
+	if len(found) != 3 {
+		t.Fatalf("expected foreign keys with checks and NOT NULL columns, found %v", found)
+	}
+}
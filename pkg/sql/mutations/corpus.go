@@ -0,0 +1,98 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"hash/fnv"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+)
+
+// NormalizeStatement returns the normalized SQL string of the statement sql.
+// Statements that only differ in formatting, like whitespace, the case of
+// keywords and unquoted identifiers, redundant quotes of identifiers, or
+// trailing semicolons, have the same normalized string. An error is returned
+// if sql is not a single statement.
+func NormalizeStatement(sql string) (string, error) {
+	stmt, err := parser.ParseOne(sql)
+	if err != nil {
+		return "", err
+	}
+	return tree.Serialize(stmt.AST), nil
+}
+
+// normalizeCorpusStatement is like NormalizeStatement, but it returns sql
+// itself if it can't be normalized, so that statements that don't parse are
+// only identical to themselves.
+func normalizeCorpusStatement(sql string) string {
+	if normalized, err := NormalizeStatement(sql); err == nil {
+		return normalized
+	}
+	return sql
+}
+
+// DedupStatements returns sqls without the statements whose normalized SQL
+// strings, see NormalizeStatement, are the same as the ones of earlier
+// statements. The first occurrences are kept, in their original order and
+// formatting.
+func DedupStatements(sqls []string) []string {
+	seen := make(map[string]bool, len(sqls))
+	res := make([]string, 0, len(sqls))
+	for _, sql := range sqls {
+		normalized := normalizeCorpusStatement(sql)
+		if !seen[normalized] {
+			seen[normalized] = true
+			res = append(res, sql)
+		}
+	}
+	return res
+}
+
+// StatementShard returns the shard in [0, numShards) that the statement sql is
+// assigned to. The shard only depends on the normalized SQL string of the
+// statement, so a statement stays in its shard when the corpus changes, and
+// identical statements are assigned to the same shard. An error is returned if
+// numShards is not positive.
+func StatementShard(sql string, numShards int) (int, error) {
+	if numShards <= 0 {
+		return 0, errors.Newf("invalid number of shards: %d", numShards)
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(normalizeCorpusStatement(sql)))
+	return int(h.Sum64() % uint64(numShards)), nil
+}
+
+// ShardStatements returns the statements of sqls that are assigned to shard
+// out of numShards by StatementShard, in their original order. Test workers
+// that run the same corpus with the same numShards and different shards run
+// every statement exactly once between them. The shards are only balanced
+// for large corpora.
+func ShardStatements(sqls []string, shard, numShards int) ([]string, error) {
+	if numShards <= 0 {
+		return nil, errors.Newf("invalid number of shards: %d", numShards)
+	}
+	if shard < 0 || shard >= numShards {
+		return nil, errors.Newf("shard %d is not between 0 and %d", shard, numShards-1)
+	}
+	var res []string
+	for _, sql := range sqls {
+		s, err := StatementShard(sql, numShards)
+		if err != nil {
+			return nil, err
+		}
+		if s == shard {
+			res = append(res, sql)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestDedupStatements(t *testing.T) {
+	sqls := []string{
+		"SELECT a FROM t WHERE b = 1",
+		"select a   from T where b = 1;",
+		`SELECT "a" FROM t WHERE b = 1`,
+		"SELECT a FROM t WHERE b = 2",
+		"NOT A STATEMENT",
+		"NOT A STATEMENT",
+		"not a statement",
+	}
+	expected := []string{
+		"SELECT a FROM t WHERE b = 1",
+		"SELECT a FROM t WHERE b = 2",
+		"NOT A STATEMENT",
+		"not a statement",
+	}
+	if actual := DedupStatements(sqls); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %q, found %q", expected, actual)
+	}
+}
+
+func TestShardStatements(t *testing.T) {
+	var sqls []string
+	for i := 0; i < 100; i++ {
+		sqls = append(sqls, fmt.Sprintf("SELECT %d", i))
+	}
+	const numShards = 4
+	var all []string
+	for shard := 0; shard < numShards; shard++ {
+		res, err := ShardStatements(sqls, shard, numShards)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Formatting doesn't change the shard of a statement.
+		for _, sql := range res {
+			s, err := StatementShard("  select "+sql[len("SELECT "):]+";", numShards)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s != shard {
+				t.Errorf("expected %s in shard %d, found %d", sql, shard, s)
+			}
+		}
+		all = append(all, res...)
+	}
+	if len(all) != len(sqls) {
+		t.Errorf("expected %d statements in all shards, found %d", len(sqls), len(all))
+	}
+	seen := map[string]bool{}
+	for _, sql := range all {
+		if seen[sql] {
+			t.Errorf("%s is in several shards", sql)
+		}
+		seen[sql] = true
+	}
+
+	if _, err := ShardStatements(sqls, numShards, numShards); err == nil {
+		t.Error("expected error for invalid shard")
+	}
+	if _, err := ShardStatements(sqls, 0, 0); err == nil {
+		t.Error("expected error for invalid number of shards")
+	}
+	if _, err := StatementShard(sqls[0], 0); err == nil {
+		t.Error("expected error for invalid number of shards")
+	}
+}
@@ -0,0 +1,295 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// SchemaFeature is a SQL schema feature which randomly generated and mutated
+// schemas are expected to cover.
+type SchemaFeature int
+
+// The values for SchemaFeature.
+const (
+	FeaturePartialIndex SchemaFeature = iota
+	FeatureInvertedIndex
+	FeatureHashShardedIndex
+	FeatureStoringIndex
+	FeatureUniqueConstraint
+	FeatureUniqueWithoutIndex
+	FeatureCheckConstraint
+	FeatureForeignKey
+	FeatureForeignKeyAction
+	FeatureForeignKeyMatch
+	FeatureStoredComputedColumn
+	FeatureVirtualComputedColumn
+	FeatureColumnFamilies
+	FeatureInterleave
+	FeaturePartitioning
+	FeatureCollatedString
+	FeatureEnum
+	FeatureMultiRegion
+	FeatureStatistics
+
+	// NumSchemaFeatures is the number of SchemaFeature values.
+	NumSchemaFeatures
+)
+
+var schemaFeatureNames = [NumSchemaFeatures]string{
+	FeaturePartialIndex:          "partial index",
+	FeatureInvertedIndex:         "inverted index",
+	FeatureHashShardedIndex:      "hash-sharded index",
+	FeatureStoringIndex:          "storing index",
+	FeatureUniqueConstraint:      "unique constraint",
+	FeatureUniqueWithoutIndex:    "unique without index",
+	FeatureCheckConstraint:       "check constraint",
+	FeatureForeignKey:            "foreign key",
+	FeatureForeignKeyAction:      "foreign key action",
+	FeatureForeignKeyMatch:       "foreign key match method",
+	FeatureStoredComputedColumn:  "stored computed column",
+	FeatureVirtualComputedColumn: "virtual computed column",
+	FeatureColumnFamilies:        "column families",
+	FeatureInterleave:            "interleave",
+	FeaturePartitioning:          "partitioning",
+	FeatureCollatedString:        "collated string",
+	FeatureEnum:                  "enum",
+	FeatureMultiRegion:           "multi-region",
+	FeatureStatistics:            "injected statistics",
+}
+
+func (f SchemaFeature) String() string {
+	if f < 0 || f >= NumSchemaFeatures {
+		return fmt.Sprintf("SchemaFeature(%d)", int(f))
+	}
+	return schemaFeatureNames[f]
+}
+
+// SchemaFeatures is a set of SchemaFeatures.
+type SchemaFeatures [NumSchemaFeatures]bool
+
+// DetectSchemaFeatures returns the set of SchemaFeatures that the given
+// statements contain.
+func DetectSchemaFeatures(stmts []tree.Statement) SchemaFeatures {
+	var f SchemaFeatures
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *tree.CreateTable:
+			f.detectCreateTable(stmt)
+		case *tree.CreateIndex:
+			f.detectIndex(&tree.IndexTableDef{
+				Sharded:          stmt.Sharded,
+				Storing:          stmt.Storing,
+				Interleave:       stmt.Interleave,
+				Inverted:         stmt.Inverted,
+				PartitionByIndex: stmt.PartitionByIndex,
+				Predicate:        stmt.Predicate,
+			})
+			if stmt.Unique {
+				f[FeatureUniqueConstraint] = true
+			}
+		case *tree.CreateType:
+			if stmt.Variety == tree.Enum {
+				f[FeatureEnum] = true
+			}
+		case *tree.AlterDatabasePrimaryRegion, *tree.AlterTableLocality:
+			f[FeatureMultiRegion] = true
+		case *tree.AlterTable:
+			for _, cmd := range stmt.Cmds {
+				switch cmd := cmd.(type) {
+				case *tree.AlterTableAddConstraint:
+					f.detectTableDef(cmd.ConstraintDef)
+				case *tree.AlterTableInjectStats:
+					f[FeatureStatistics] = true
+				}
+			}
+		}
+	}
+	return f
+}
+
+func (f *SchemaFeatures) detectCreateTable(create *tree.CreateTable) {
+	if create.Interleave != nil {
+		f[FeatureInterleave] = true
+	}
+	if create.PartitionByTable != nil {
+		f[FeaturePartitioning] = true
+	}
+	if create.Locality != nil {
+		f[FeatureMultiRegion] = true
+	}
+	for _, def := range create.Defs {
+		f.detectTableDef(def)
+	}
+}
+
+func (f *SchemaFeatures) detectTableDef(def tree.TableDef) {
+	switch def := def.(type) {
+	case *tree.ColumnTableDef:
+		if def.Computed.Computed {
+			if def.Computed.Virtual {
+				f[FeatureVirtualComputedColumn] = true
+			} else {
+				f[FeatureStoredComputedColumn] = true
+			}
+		}
+		if def.PrimaryKey.Sharded {
+			f[FeatureHashShardedIndex] = true
+		}
+		if def.Unique.IsUnique {
+			f[FeatureUniqueConstraint] = true
+			if def.Unique.WithoutIndex {
+				f[FeatureUniqueWithoutIndex] = true
+			}
+		}
+		if len(def.CheckExprs) > 0 {
+			f[FeatureCheckConstraint] = true
+		}
+		if def.References.Table != nil {
+			f.detectForeignKey(def.References.Actions, def.References.Match)
+		}
+		if def.Family.Name != "" || def.Family.Create {
+			f[FeatureColumnFamilies] = true
+		}
+		if typ, ok := tree.GetStaticallyKnownType(def.Type); !ok {
+			// Columns of user-defined types reference the type by name. The only
+			// user-defined types are enums.
+			f[FeatureEnum] = true
+		} else if typ.Family() == types.CollatedStringFamily {
+			f[FeatureCollatedString] = true
+		}
+	case *tree.UniqueConstraintTableDef:
+		f.detectIndex(&def.IndexTableDef)
+		if !def.PrimaryKey {
+			f[FeatureUniqueConstraint] = true
+			if def.WithoutIndex {
+				f[FeatureUniqueWithoutIndex] = true
+			}
+		}
+	case *tree.IndexTableDef:
+		f.detectIndex(def)
+	case *tree.CheckConstraintTableDef:
+		f[FeatureCheckConstraint] = true
+	case *tree.ForeignKeyConstraintTableDef:
+		f.detectForeignKey(def.Actions, def.Match)
+	case *tree.FamilyTableDef:
+		f[FeatureColumnFamilies] = true
+	}
+}
+
+func (f *SchemaFeatures) detectIndex(def *tree.IndexTableDef) {
+	if def.Predicate != nil {
+		f[FeaturePartialIndex] = true
+	}
+	if def.Inverted {
+		f[FeatureInvertedIndex] = true
+	}
+	if def.Sharded != nil {
+		f[FeatureHashShardedIndex] = true
+	}
+	if len(def.Storing) > 0 {
+		f[FeatureStoringIndex] = true
+	}
+	if def.Interleave != nil {
+		f[FeatureInterleave] = true
+	}
+	if def.PartitionByIndex != nil {
+		f[FeaturePartitioning] = true
+	}
+}
+
+func (f *SchemaFeatures) detectForeignKey(
+	actions tree.ReferenceActions, match tree.CompositeKeyMatchMethod,
+) {
+	f[FeatureForeignKey] = true
+	if actions.Delete != tree.NoAction || actions.Update != tree.NoAction {
+		f[FeatureForeignKeyAction] = true
+	}
+	if match != tree.MatchSimple {
+		f[FeatureForeignKeyMatch] = true
+	}
+}
+
+// SchemaCoverage aggregates the SchemaFeatures contained in the final schemas
+// of many randomized test runs, so that features which the generators and
+// mutators never (or rarely) produce are visible. It is safe for concurrent
+// use.
+type SchemaCoverage struct {
+	mu struct {
+		syncutil.Mutex
+		runs   int
+		counts [NumSchemaFeatures]int
+	}
+}
+
+// Record records the features contained in the final schema of a single run.
+func (c *SchemaCoverage) Record(stmts []tree.Statement) {
+	f := DetectSchemaFeatures(stmts)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mu.runs++
+	for i, ok := range f {
+		if ok {
+			c.mu.counts[i]++
+		}
+	}
+}
+
+// RecordString is like Record, but takes the schema as a string of SQL
+// statements. Schemas which fail to parse are not recorded.
+func (c *SchemaCoverage) RecordString(sql string) {
+	parsed, err := parser.Parse(sql)
+	if err != nil {
+		return
+	}
+	stmts := make([]tree.Statement, len(parsed))
+	for i, p := range parsed {
+		stmts[i] = p.AST
+	}
+	c.Record(stmts)
+}
+
+// Uncovered returns the features which were not contained in any recorded
+// schema.
+func (c *SchemaCoverage) Uncovered() []SchemaFeature {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var res []SchemaFeature
+	for i, n := range c.mu.counts {
+		if n == 0 {
+			res = append(res, SchemaFeature(i))
+		}
+	}
+	return res
+}
+
+// String returns a report with the number of runs which contained each
+// feature, one feature per line.
+func (c *SchemaCoverage) String() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "schema feature coverage over %d runs:\n", c.mu.runs)
+	for i, n := range c.mu.counts {
+		var pct float64
+		if c.mu.runs > 0 {
+			pct = 100 * float64(n) / float64(c.mu.runs)
+		}
+		fmt.Fprintf(&sb, "  %-25s %6d (%5.1f%%)\n", SchemaFeature(i), n, pct)
+	}
+	return sb.String()
+}
@@ -0,0 +1,64 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSchemaCoverage(t *testing.T) {
+	var c SchemaCoverage
+	c.RecordString(`
+CREATE TYPE e AS ENUM ('a', 'b');
+CREATE TABLE p (i INT PRIMARY KEY, s STRING COLLATE en, INDEX (s) WHERE i > 0);
+CREATE TABLE c (
+	i INT PRIMARY KEY,
+	j INT AS (i + 1) VIRTUAL,
+	k e,
+	FAMILY (i, j),
+	FAMILY (k),
+	FOREIGN KEY (i) REFERENCES p (i) ON DELETE CASCADE
+);`)
+	c.RecordString(`CREATE TABLE t (i INT PRIMARY KEY, j INT, INDEX (j) STORING (i))`)
+
+	uncovered := map[SchemaFeature]bool{}
+	for _, f := range c.Uncovered() {
+		uncovered[f] = true
+	}
+	for _, f := range []SchemaFeature{
+		FeatureEnum,
+		FeatureCollatedString,
+		FeaturePartialIndex,
+		FeatureVirtualComputedColumn,
+		FeatureColumnFamilies,
+		FeatureForeignKey,
+		FeatureForeignKeyAction,
+		FeatureStoringIndex,
+	} {
+		if uncovered[f] {
+			t.Errorf("expected %s to be covered", f)
+		}
+	}
+	for _, f := range []SchemaFeature{FeatureInterleave, FeatureMultiRegion, FeatureStatistics} {
+		if !uncovered[f] {
+			t.Errorf("expected %s to be uncovered", f)
+		}
+	}
+
+	report := c.String()
+	if !strings.HasPrefix(report, "schema feature coverage over 2 runs:") {
+		t.Fatalf("unexpected report:\n%s", report)
+	}
+	if !strings.Contains(report, "  foreign key                    1 ( 50.0%)\n") {
+		t.Fatalf("unexpected report:\n%s", report)
+	}
+}
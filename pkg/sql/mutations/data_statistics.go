@@ -0,0 +1,395 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/stats"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/errors"
+)
+
+const (
+	// dataStatsIndexColHistogramBuckets and dataStatsNonIndexColHistogramBuckets
+	// are the maximum numbers of histogram buckets of indexed and non-indexed
+	// columns, which match the ones used by CREATE STATISTICS.
+	dataStatsIndexColHistogramBuckets    = 200
+	dataStatsNonIndexColHistogramBuckets = 2
+)
+
+// MakeDataStatisticsMutator returns a MultiStatementMutation which adds ALTER
+// TABLE INJECT STATISTICS statements with statistics that are computed from
+// the data of the tables rather than generated randomly. The data of a table
+// consists of the given rows, keyed by table name, followed by the rows
+// inserted by the INSERT ... VALUES statements in the mutated statements. The
+// datums of the given rows are in the order of the column definitions of the
+// table.
+//
+// Tables whose data cannot be determined, because they are modified by other
+// statements like UPSERT, UPDATE or DELETE, do not get statistics. Columns
+// whose values cannot be determined, like computed columns or columns whose
+// values are omitted and have a default expression, are left out of the
+// statistics. The statistics assume that all INSERT statements succeed.
+func MakeDataStatisticsMutator(rows map[string][]tree.Datums) MultiStatementMutation {
+	return func(rng *rand.Rand, stmts []tree.Statement) (mutated []tree.Statement, changed bool) {
+		return dataStatisticsMutator(stmts, rows)
+	}
+}
+
+// tableData contains the rows of a table that are known to
+// dataStatisticsMutator.
+type tableData struct {
+	create *tree.CreateTable
+	cols   []*tree.ColumnTableDef
+	// types contains the type of each column, or nil if the type is not
+	// statically known.
+	types []*types.T
+	// unknown is true for the columns whose values are not known for all
+	// rows. The values of unknown columns in rows are nil.
+	unknown []bool
+	rows    []tree.Datums
+	// modified is true if the table is modified by statements other than
+	// INSERT ... VALUES, in which case its data is not known.
+	modified bool
+}
+
+func newTableData(create *tree.CreateTable) *tableData {
+	td := &tableData{create: create}
+	for _, def := range create.Defs {
+		if col, ok := def.(*tree.ColumnTableDef); ok {
+			typ, _ := tree.GetStaticallyKnownType(col.Type)
+			td.cols = append(td.cols, col)
+			td.types = append(td.types, typ)
+			td.unknown = append(td.unknown, typ == nil || col.IsComputed())
+		}
+	}
+	return td
+}
+
+// addRow adds a row with the given values for the given columns of the table.
+// The values of the other columns are NULL unless they have a default
+// expression, in which case they are unknown.
+func (td *tableData) addRow(
+	ctx context.Context,
+	evalCtx *tree.EvalContext,
+	semaCtx *tree.SemaContext,
+	targets []int,
+	exprs tree.Exprs,
+) {
+	row := make(tree.Datums, len(td.cols))
+	for i, col := range td.cols {
+		if col.HasDefaultExpr() || tree.IsReferenceSerialType(col.Type) {
+			td.unknown[i] = true
+		} else {
+			row[i] = tree.DNull
+		}
+	}
+	for j, expr := range exprs {
+		if j >= len(targets) {
+			break
+		}
+		i := targets[j]
+		switch expr.(type) {
+		case tree.DefaultVal, *tree.DefaultVal:
+			continue
+		}
+		row[i] = nil
+		if td.unknown[i] {
+			continue
+		}
+		typed, err := tree.TypeCheck(ctx, expr, semaCtx, td.types[i])
+		if err != nil {
+			td.unknown[i] = true
+			continue
+		}
+		d, err := typed.Eval(evalCtx)
+		if err != nil || (d != tree.DNull && !d.ResolvedType().Equivalent(td.types[i])) {
+			td.unknown[i] = true
+			continue
+		}
+		row[i] = d
+	}
+	td.rows = append(td.rows, row)
+}
+
+// insertTargets returns the ordinals of the columns that are targeted by the
+// given INSERT statement, or ok=false if one of them does not exist.
+func (td *tableData) insertTargets(ins *tree.Insert) (targets []int, ok bool) {
+	if len(ins.Columns) == 0 {
+		for i, col := range td.cols {
+			if !col.IsComputed() {
+				targets = append(targets, i)
+			}
+		}
+		return targets, true
+	}
+	for _, name := range ins.Columns {
+		found := false
+		for i, col := range td.cols {
+			if col.Name == name {
+				targets = append(targets, i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	return targets, true
+}
+
+// mutatedTableName returns the name of the table modified by a DML statement
+// with the given table expression.
+func mutatedTableName(expr tree.TableExpr) (string, bool) {
+	if aliased, ok := expr.(*tree.AliasedTableExpr); ok {
+		expr = aliased.Expr
+	}
+	tn, ok := expr.(*tree.TableName)
+	if !ok {
+		return "", false
+	}
+	return tn.Table(), true
+}
+
+func dataStatisticsMutator(
+	stmts []tree.Statement, rows map[string][]tree.Datums,
+) (mutated []tree.Statement, changed bool) {
+	ctx := context.Background()
+	evalCtx := tree.NewTestingEvalContext(cluster.MakeTestingClusterSettings())
+	defer evalCtx.Stop(ctx)
+	semaCtx := tree.MakeSemaContext()
+
+	tables := map[string]*tableData{}
+	var order []*tableData
+	markModified := func(expr tree.TableExpr) {
+		if name, ok := mutatedTableName(expr); ok && tables[name] != nil {
+			tables[name].modified = true
+		}
+	}
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *tree.CreateTable:
+			td := newTableData(stmt)
+			for _, row := range rows[stmt.Table.Table()] {
+				if len(row) != len(td.cols) {
+					panic(errors.AssertionFailedf(
+						"expected %d datums for table %s, found %d", len(td.cols), stmt.Table.Table(), len(row),
+					))
+				}
+				td.rows = append(td.rows, row)
+			}
+			tables[stmt.Table.Table()] = td
+			order = append(order, td)
+
+		case *tree.Insert:
+			name, ok := mutatedTableName(stmt.Table)
+			if !ok || tables[name] == nil {
+				continue
+			}
+			td := tables[name]
+			targets, ok := td.insertTargets(stmt)
+			if !ok || stmt.OnConflict != nil || stmt.With != nil {
+				td.modified = true
+				continue
+			}
+			if stmt.Rows == nil || stmt.Rows.Select == nil {
+				// INSERT ... DEFAULT VALUES.
+				td.addRow(ctx, evalCtx, &semaCtx, nil /* targets */, nil /* exprs */)
+				continue
+			}
+			values, ok := stmt.Rows.Select.(*tree.ValuesClause)
+			if !ok || stmt.Rows.With != nil || stmt.Rows.OrderBy != nil || stmt.Rows.Limit != nil {
+				td.modified = true
+				continue
+			}
+			for _, exprs := range values.Rows {
+				td.addRow(ctx, evalCtx, &semaCtx, targets, exprs)
+			}
+
+		case *tree.Update:
+			markModified(stmt.Table)
+
+		case *tree.Delete:
+			markModified(stmt.Table)
+
+		case *tree.Truncate:
+			for i := range stmt.Tables {
+				markModified(&stmt.Tables[i])
+			}
+		}
+	}
+
+	for _, td := range order {
+		if td.modified {
+			continue
+		}
+		allStats := td.computeStats(evalCtx)
+		if len(allStats) == 0 {
+			continue
+		}
+		alter, err := stats.MakeAlterTableInjectStats(
+			td.create.Table.ToUnresolvedObjectName(), allStats,
+		)
+		if err != nil {
+			// Should not happen.
+			panic(err)
+		}
+		stmts = append(stmts, alter)
+		changed = true
+	}
+	return stmts, changed
+}
+
+// computeStats returns the single-column statistics of all known columns and
+// the multi-column statistics of the prefixes of the indexes of the table.
+func (td *tableData) computeStats(evalCtx *tree.EvalContext) []stats.JSONStatistic {
+	ordinals := map[tree.Name]int{}
+	for i, col := range td.cols {
+		ordinals[col.Name] = i
+	}
+	// indexes contains the key columns of each index, up to the first element
+	// that is not a known column.
+	var indexes [][]int
+	indexed := map[int]bool{}
+	addIndex := func(elems tree.IndexElemList) {
+		var index []int
+		for _, elem := range elems {
+			i, ok := ordinals[elem.Column]
+			if elem.Column == "" || !ok || td.unknown[i] {
+				break
+			}
+			index = append(index, i)
+			indexed[i] = true
+		}
+		indexes = append(indexes, index)
+	}
+	for _, def := range td.create.Defs {
+		switch def := def.(type) {
+		case *tree.ColumnTableDef:
+			if (def.Unique.IsUnique && !def.Unique.WithoutIndex) || def.PrimaryKey.IsPrimaryKey {
+				indexed[ordinals[def.Name]] = true
+			}
+		case *tree.IndexTableDef:
+			addIndex(def.Columns)
+		case *tree.UniqueConstraintTableDef:
+			if !def.WithoutIndex {
+				addIndex(def.Columns)
+			}
+		}
+	}
+
+	rowCount := uint64(len(td.rows))
+	makeStat := func(ordinals []int) stats.JSONStatistic {
+		names := make([]string, len(ordinals))
+		for i, ord := range ordinals {
+			names[i] = td.cols[ord].Name.String()
+		}
+		var nullCount uint64
+		distinct := map[string]struct{}{}
+		var key []byte
+		for _, row := range td.rows {
+			key = key[:0]
+			allNull := true
+			for _, ord := range ordinals {
+				key = appendDatumKey(key, row[ord])
+				allNull = allNull && row[ord] == tree.DNull
+			}
+			if allNull {
+				nullCount++
+			}
+			distinct[string(key)] = struct{}{}
+		}
+		// Like the statistics collected by CREATE STATISTICS, the distinct
+		// count includes NULL, and the null count of multiple columns is the
+		// number of rows where all the columns are NULL.
+		return stats.JSONStatistic{
+			Name:          "__auto__",
+			CreatedAt:     "2000-01-01 00:00:00+00:00",
+			RowCount:      rowCount,
+			Columns:       names,
+			DistinctCount: uint64(len(distinct)),
+			NullCount:     nullCount,
+		}
+	}
+
+	var res []stats.JSONStatistic
+	for i := range td.cols {
+		if td.unknown[i] {
+			continue
+		}
+		stat := makeStat([]int{i})
+		typ := td.types[i]
+		if colinfo.ColumnTypeIsIndexable(typ) && !colinfo.ColumnTypeIsInvertedIndexable(typ) &&
+			typ.Family() != types.CollatedStringFamily {
+			// The histograms of inverted indexable columns describe the
+			// inverted index entries rather than the values, so they are not
+			// computed. The upper bounds of histograms on collated strings
+			// cannot be decoded from their key encoding by SetHistogram.
+			values := make(tree.Datums, 0, len(td.rows))
+			for _, row := range td.rows {
+				if row[i] != tree.DNull {
+					values = append(values, row[i])
+				}
+			}
+			distinctCount := int64(stat.DistinctCount)
+			if stat.NullCount > 0 {
+				distinctCount--
+			}
+			maxBuckets := dataStatsNonIndexColHistogramBuckets
+			if indexed[i] {
+				maxBuckets = dataStatsIndexColHistogramBuckets
+			}
+			h, err := stats.EquiDepthHistogram(
+				evalCtx, typ, values, int64(len(values)), distinctCount, maxBuckets,
+			)
+			if err != nil {
+				panic(err)
+			}
+			if err := stat.SetHistogram(&h); err != nil {
+				panic(err)
+			}
+		}
+		res = append(res, stat)
+	}
+	seen := map[string]bool{}
+	for _, index := range indexes {
+		for n := 2; n <= len(index); n++ {
+			stat := makeStat(index[:n])
+			key := strings.Join(stat.Columns, ",")
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			res = append(res, stat)
+		}
+	}
+	return res
+}
+
+// appendDatumKey appends a key to b that is equal for two datums if and only
+// if the datums are equal.
+func appendDatumKey(b []byte, d tree.Datum) []byte {
+	if enc, err := rowenc.EncodeTableKey(b, d, encoding.Ascending); err == nil {
+		return enc
+	}
+	// Datums that cannot be key-encoded are compared by their serialized
+	// form. The terminator separates the datums of multiple columns.
+	return append(append(b, tree.Serialize(d)...), 0)
+}
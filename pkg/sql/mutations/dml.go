@@ -0,0 +1,230 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"math/rand"
+
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+)
+
+var (
+	// UpsertMutator changes random INSERT statements without an ON CONFLICT
+	// clause into UPSERT, INSERT ... ON CONFLICT DO NOTHING, or INSERT ... ON
+	// CONFLICT DO UPDATE statements. The mutated statements don't fail on
+	// conflicts with existing rows, so they should only be used by tests
+	// that don't depend on such failures.
+	UpsertMutator = VersionedMutator{MultiStatementMutation(upsertMutator), clusterversion.V20_2}
+
+	// ReturningMutator adds RETURNING * or RETURNING NOTHING clauses to random
+	// INSERT, UPSERT, UPDATE and DELETE statements without a RETURNING clause.
+	ReturningMutator = VersionedMutator{StatementMutator(returningMutator), clusterversion.V20_2}
+
+	// InsertBatchMutator splits random multi-row INSERT ... VALUES statements
+	// into single-row statements, and merges random consecutive INSERT ...
+	// VALUES statements into the same table and columns into multi-row
+	// statements. The mutated statements insert the same rows, but a failing
+	// row only fails the statement that inserts it.
+	InsertBatchMutator = VersionedMutator{MultiStatementMutation(insertBatchMutator), clusterversion.V20_2}
+)
+
+// excludedTableName is the name of the data source of an ON CONFLICT DO UPDATE
+// clause that contains the row that could not be inserted.
+var excludedTableName = tree.MakeUnqualifiedTableName("excluded")
+
+func upsertMutator(
+	rng *rand.Rand, stmts []tree.Statement,
+) (mutated []tree.Statement, changed bool) {
+	tables := map[tableKey]*tree.CreateTable{}
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *tree.CreateTable:
+			tables[makeTableKey(&stmt.Table)] = stmt
+
+		case *tree.Insert:
+			if stmt.OnConflict != nil || rng.Intn(2) == 0 {
+				continue
+			}
+			switch rng.Intn(3) {
+			case 0:
+				stmt.OnConflict = &tree.OnConflict{}
+			case 1:
+				stmt.OnConflict = &tree.OnConflict{DoNothing: true}
+			case 2:
+				stmt.OnConflict = randOnConflictDoUpdate(rng, stmt, tables)
+			}
+			changed = true
+		}
+	}
+	return stmts, changed
+}
+
+// randOnConflictDoUpdate returns an ON CONFLICT DO UPDATE clause for ins that
+// uses a random unique index of the table as the arbiter and updates the
+// other inserted columns to the values of the conflicting row. If the table or
+// its unique indexes are not known, or if all inserted columns are part of the
+// arbiter, an ON CONFLICT DO NOTHING clause is returned instead.
+func randOnConflictDoUpdate(
+	rng *rand.Rand, ins *tree.Insert, tables map[tableKey]*tree.CreateTable,
+) *tree.OnConflict {
+	doNothing := &tree.OnConflict{DoNothing: true}
+	tn, ok := ins.Table.(*tree.TableName)
+	if !ok {
+		return doNothing
+	}
+	create, ok := tables[makeTableKey(tn)]
+	if !ok {
+		return doNothing
+	}
+	var cols []*tree.ColumnTableDef
+	for _, def := range create.Defs {
+		if col, ok := def.(*tree.ColumnTableDef); ok {
+			cols = append(cols, col)
+		}
+	}
+	uniques := uniqueColumnSets(create, cols)
+	if len(uniques) == 0 {
+		return doNothing
+	}
+	arbiter := uniques[rng.Intn(len(uniques))]
+
+	inserted := ins.Columns
+	if inserted == nil {
+		for _, col := range cols {
+			if !col.IsComputed() {
+				inserted = append(inserted, col.Name)
+			}
+		}
+	}
+	onConflict := &tree.OnConflict{}
+Loop:
+	for _, name := range inserted {
+		for _, col := range arbiter {
+			if col.Name == name {
+				continue Loop
+			}
+		}
+		onConflict.Exprs = append(onConflict.Exprs, &tree.UpdateExpr{
+			Names: tree.NameList{name},
+			Expr:  tree.NewColumnItem(&excludedTableName, name),
+		})
+	}
+	if len(onConflict.Exprs) == 0 {
+		return doNothing
+	}
+	for _, col := range arbiter {
+		onConflict.Columns = append(onConflict.Columns, col.Name)
+	}
+	return onConflict
+}
+
+func returningMutator(rng *rand.Rand, stmt tree.Statement) (changed bool) {
+	var returning *tree.ReturningClause
+	switch stmt := stmt.(type) {
+	case *tree.Insert:
+		returning = &stmt.Returning
+	case *tree.Update:
+		returning = &stmt.Returning
+	case *tree.Delete:
+		returning = &stmt.Returning
+	default:
+		return false
+	}
+	if tree.HasReturningClause(*returning) || rng.Intn(2) == 0 {
+		return false
+	}
+	if rng.Intn(4) == 0 {
+		*returning = tree.ReturningNothingClause
+	} else {
+		*returning = &tree.ReturningExprs{tree.StarSelectExpr()}
+	}
+	return true
+}
+
+// valuesInsert returns the VALUES clause of an INSERT statement that only
+// consists of a table, columns and a VALUES clause, which is safe to split
+// and merge with other such statements.
+func valuesInsert(stmt tree.Statement) (*tree.Insert, *tree.ValuesClause, bool) {
+	ins, ok := stmt.(*tree.Insert)
+	if !ok || ins.With != nil || ins.OnConflict != nil || tree.HasReturningClause(ins.Returning) {
+		return nil, nil, false
+	}
+	if ins.Rows == nil || ins.Rows.With != nil || ins.Rows.OrderBy != nil || ins.Rows.Limit != nil {
+		return nil, nil, false
+	}
+	values, ok := ins.Rows.Select.(*tree.ValuesClause)
+	return ins, values, ok
+}
+
+// sameInsertTarget returns whether a and b insert into the same columns of the
+// same table.
+func sameInsertTarget(a, b *tree.Insert) bool {
+	if tree.Serialize(a.Table) != tree.Serialize(b.Table) || len(a.Columns) != len(b.Columns) {
+		return false
+	}
+	for i := range a.Columns {
+		if a.Columns[i] != b.Columns[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// valuesArity returns the number of expressions in each row of values, or -1
+// if the rows have different lengths.
+func valuesArity(values *tree.ValuesClause) int {
+	arity := -1
+	for i, row := range values.Rows {
+		if i == 0 {
+			arity = len(row)
+		} else if len(row) != arity {
+			return -1
+		}
+	}
+	return arity
+}
+
+func insertBatchMutator(
+	rng *rand.Rand, stmts []tree.Statement,
+) (mutated []tree.Statement, changed bool) {
+	mutated = make([]tree.Statement, 0, len(stmts))
+	for _, stmt := range stmts {
+		ins, values, ok := valuesInsert(stmt)
+		if !ok || rng.Intn(2) == 0 {
+			mutated = append(mutated, stmt)
+			continue
+		}
+		if len(mutated) > 0 {
+			if prev, prevValues, ok := valuesInsert(mutated[len(mutated)-1]); ok && sameInsertTarget(prev, ins) &&
+				valuesArity(prevValues) >= 0 && valuesArity(prevValues) == valuesArity(values) {
+				// Merge the statement into the previous one. The rows of a
+				// VALUES clause must all have the same length, which is not
+				// implied by the same target if no columns are specified.
+				prevValues.Rows = append(prevValues.Rows, values.Rows...)
+				changed = true
+				continue
+			}
+		}
+		if len(values.Rows) < 2 {
+			mutated = append(mutated, stmt)
+			continue
+		}
+		// Split the statement into one statement per row.
+		for _, row := range values.Rows {
+			split := *ins
+			split.Rows = &tree.Select{Select: &tree.ValuesClause{Rows: []tree.Exprs{row}}}
+			mutated = append(mutated, &split)
+		}
+		changed = true
+	}
+	return mutated, changed
+}
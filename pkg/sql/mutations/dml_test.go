@@ -0,0 +1,127 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+func TestUpsertMutator(t *testing.T) {
+	q := `
+		CREATE TABLE t (a INT8 PRIMARY KEY, b INT8, c INT8);
+		INSERT INTO t (a, b) VALUES (1, 2);
+		INSERT INTO t VALUES (3, 4, 5) ON CONFLICT DO NOTHING;
+	`
+	rng, _ := randutil.NewPseudoRand()
+	found := map[string]bool{}
+	for i := 0; i < 1000 && len(found) < 3; i++ {
+		mutated, changed := ApplyString(rng, q, UpsertMutator)
+		if !changed {
+			continue
+		}
+		if _, err := parser.Parse(mutated); err != nil {
+			t.Fatalf("error parsing %s: %v", mutated, err)
+		}
+		if !strings.Contains(mutated, "INSERT INTO t VALUES (3, 4, 5) ON CONFLICT DO NOTHING") {
+			t.Fatalf("expected statement with ON CONFLICT to be unchanged: %s", mutated)
+		}
+		for _, e := range []string{
+			"UPSERT INTO t(a, b) VALUES (1, 2)",
+			"INSERT INTO t(a, b) VALUES (1, 2) ON CONFLICT DO NOTHING",
+			"INSERT INTO t(a, b) VALUES (1, 2) ON CONFLICT (a) DO UPDATE SET b = excluded.b",
+		} {
+			if strings.Contains(mutated, e) {
+				found[e] = true
+			}
+		}
+	}
+	if len(found) != 3 {
+		t.Fatalf("expected all kinds of upserts, found %v", found)
+	}
+}
+
+func TestReturningMutator(t *testing.T) {
+	q := `
+		INSERT INTO t VALUES (1);
+		UPDATE t SET a = 2 RETURNING a;
+		DELETE FROM t WHERE a = 1;
+	`
+	rng, _ := randutil.NewPseudoRand()
+	for i := 0; i < 100; i++ {
+		mutated, changed := ApplyString(rng, q, ReturningMutator)
+		if !changed {
+			continue
+		}
+		if !strings.Contains(mutated, "UPDATE t SET a = 2 RETURNING a;") {
+			t.Fatalf("expected existing RETURNING clause to be unchanged: %s", mutated)
+		}
+		if !strings.Contains(mutated, "RETURNING *") && !strings.Contains(mutated, "RETURNING NOTHING") {
+			t.Fatalf("expected a RETURNING clause: %s", mutated)
+		}
+		return
+	}
+	t.Fatal("expected a change")
+}
+
+func TestInsertBatchMutator(t *testing.T) {
+	q := `
+		INSERT INTO t VALUES (1), (2);
+		INSERT INTO t VALUES (3);
+		INSERT INTO u VALUES (4);
+		INSERT INTO u VALUES (5) RETURNING *;
+		INSERT INTO v VALUES (6);
+		INSERT INTO v VALUES (7, 8);
+	`
+	rng, _ := randutil.NewPseudoRand()
+	var merged, split bool
+	for i := 0; i < 1000 && !(merged && split); i++ {
+		mutated, changed := ApplyString(rng, q, InsertBatchMutator)
+		if !changed {
+			continue
+		}
+		if _, err := parser.Parse(mutated); err != nil {
+			t.Fatalf("error parsing %s: %v", mutated, err)
+		}
+		// The same rows have to be inserted in the same order.
+		digits := strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, mutated)
+		if digits != "12345678" {
+			t.Fatalf("expected rows 1 to 8 to be inserted in order: %s", mutated)
+		}
+		if !strings.Contains(mutated, "INSERT INTO u VALUES (5) RETURNING *") {
+			t.Fatalf("expected statement with RETURNING to be unchanged: %s", mutated)
+		}
+		if strings.Contains(mutated, "INSERT INTO t VALUES (1), (2), (3)") ||
+			strings.Contains(mutated, "INSERT INTO t VALUES (2), (3)") {
+			merged = true
+		}
+		if strings.Contains(mutated, "INSERT INTO t VALUES (1);\nINSERT INTO t VALUES (2)") {
+			split = true
+		}
+		if strings.Contains(mutated, "u VALUES (4), (5)") {
+			t.Fatalf("unexpected merge of statement with RETURNING: %s", mutated)
+		}
+		if strings.Contains(mutated, "v VALUES (6), (7, 8)") {
+			t.Fatalf("unexpected merge of rows with different lengths: %s", mutated)
+		}
+	}
+	if !merged || !split {
+		t.Fatalf("expected merged and split statements, found merged=%t split=%t", merged, split)
+	}
+}
@@ -0,0 +1,366 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+// fkDataMaxAttempts is the number of times RandForeignKeyInserts tries to
+// generate a row that satisfies the unique constraints of its table before the
+// row is skipped.
+const fkDataMaxAttempts = 10
+
+// RandForeignKeyInserts returns INSERT statements that populate the tables
+// created by the CREATE TABLE statements in stmts with up to maxRows random
+// rows each. The tables are populated in the order returned by
+// ForeignKeyInsertOrder, and the values of the referencing columns of the
+// foreign keys defined by stmts are sampled from the rows generated for the
+// referenced tables (or from earlier rows of the same table for self
+// references), so that the statements succeed without foreign key violations.
+// Rows that would violate a primary key or unique constraint are not
+// generated either.
+//
+// Values are only generated for columns with statically known types and for
+// columns of enum types created by stmts; computed columns and all other
+// columns are left to their defaults. No rows are generated for tables with
+// NOT NULL columns that have neither generated values nor default expressions.
+// Referenced columns without generated values cannot be sampled, so their
+// referencing columns are set to NULL, and no rows are generated for a table
+// whose referencing columns are NOT NULL. CHECK constraints and partial index
+// predicates are not taken into account.
+func RandForeignKeyInserts(rng *rand.Rand, stmts []tree.Statement, maxRows int) []tree.Statement {
+	typeCatalog := collectEnumTypes(stmts)
+	tables := map[tableKey]*fkTableData{}
+	for _, stmt := range stmts {
+		if create, ok := stmt.(*tree.CreateTable); ok {
+			key := makeTableKey(&create.Table)
+			if tables[key] == nil {
+				tables[key] = newFKTableData(create, typeCatalog)
+			}
+		}
+	}
+	for _, fk := range collectForeignKeys(stmts) {
+		if from := tables[fk.fromTable]; from != nil {
+			from.addForeignKey(fk, tables[fk.toTable])
+		}
+	}
+
+	var inserts []tree.Statement
+	for _, hint := range ForeignKeyInsertOrder(stmts) {
+		td := tables[makeTableKey(&hint.Table)]
+		for i := 0; i < maxRows; i++ {
+			if row, ok := td.randRow(rng); ok {
+				inserts = append(inserts, td.makeInsert(row))
+			}
+		}
+	}
+	return inserts
+}
+
+// fkTableData contains the rows generated by RandForeignKeyInserts for a
+// table.
+type fkTableData struct {
+	create *tree.CreateTable
+	cols   []*tree.ColumnTableDef
+	// types contains the type of each column whose values are generated
+	// randomly, or nil otherwise.
+	types   []*types.T
+	notNull []bool
+	// pk contains the ordinals of the primary key columns, if any.
+	pk []int
+	// uniqueSets contains the ordinals of the columns of every unique
+	// constraint, including the primary key.
+	uniqueSets [][]int
+	// seen contains the keys of the values of every unique constraint.
+	seen []map[string]bool
+	fks  []fkData
+	// noRows is true if no rows can be inserted into the table, because a
+	// NOT NULL column has neither generated values nor a default expression.
+	noRows bool
+	// rows are the generated rows. The datums of the columns that are left to
+	// their defaults are nil.
+	rows []tree.Datums
+}
+
+// fkData is a foreign key from the columns of a table with the ordinals
+// fromCols to the columns of the table ref with the ordinals toCols. ref is
+// nil if the referenced table or columns are not known.
+type fkData struct {
+	fromCols []int
+	ref      *fkTableData
+	toCols   []int
+}
+
+func newFKTableData(create *tree.CreateTable, typeCatalog *rowenc.RandTypeCatalog) *fkTableData {
+	td := &fkTableData{create: create}
+	for _, def := range create.Defs {
+		if col, ok := def.(*tree.ColumnTableDef); ok {
+			typ, err := tree.ResolveType(context.Background(), col.Type, typeCatalog)
+			if err != nil || col.IsComputed() ||
+				(typ.Family() == types.EnumFamily && len(typ.TypeMeta.EnumData.LogicalRepresentations) == 0) {
+				typ = nil
+			}
+			td.cols = append(td.cols, col)
+			td.types = append(td.types, typ)
+			td.notNull = append(td.notNull, col.Nullable.Nullability == tree.NotNull)
+		}
+	}
+	for _, def := range create.Defs {
+		switch def := def.(type) {
+		case *tree.ColumnTableDef:
+			ord, _ := td.colOrdinal(def.Name)
+			if def.PrimaryKey.IsPrimaryKey {
+				td.pk = []int{ord}
+			}
+			if def.PrimaryKey.IsPrimaryKey || def.Unique.IsUnique {
+				td.uniqueSets = append(td.uniqueSets, []int{ord})
+			}
+		case *tree.UniqueConstraintTableDef:
+			// Partial unique constraints are treated like regular ones,
+			// which is conservative.
+			set := make([]int, 0, len(def.Columns))
+			for _, elem := range def.Columns {
+				ord, ok := td.colOrdinal(elem.Column)
+				if elem.Expr != nil || !ok {
+					break
+				}
+				set = append(set, ord)
+			}
+			if len(set) != len(def.Columns) {
+				continue
+			}
+			if def.PrimaryKey {
+				td.pk = set
+			}
+			td.uniqueSets = append(td.uniqueSets, set)
+		}
+	}
+	for _, ord := range td.pk {
+		// Primary key columns are implicitly NOT NULL.
+		td.notNull[ord] = true
+	}
+	for i, col := range td.cols {
+		if td.notNull[i] && td.types[i] == nil && !col.IsComputed() && col.DefaultExpr.Expr == nil {
+			td.noRows = true
+		}
+	}
+	td.seen = make([]map[string]bool, len(td.uniqueSets))
+	for i := range td.seen {
+		td.seen[i] = map[string]bool{}
+	}
+	return td
+}
+
+// colOrdinal returns the ordinal of the column with the given name.
+func (td *fkTableData) colOrdinal(name tree.Name) (int, bool) {
+	for i, col := range td.cols {
+		if col.Name == name {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// addForeignKey adds the foreign key fk from td to the table ref, which is nil
+// if the referenced table is not created by the statements.
+func (td *fkTableData) addForeignKey(fk foreignKeyRef, ref *fkTableData) {
+	var data fkData
+	for _, name := range fk.fromCols {
+		ord, ok := td.colOrdinal(name)
+		if !ok {
+			return
+		}
+		data.fromCols = append(data.fromCols, ord)
+	}
+	if ref != nil {
+		toCols := ref.pk
+		if len(fk.toCols) > 0 {
+			toCols = nil
+			for _, name := range fk.toCols {
+				if ord, ok := ref.colOrdinal(name); ok {
+					toCols = append(toCols, ord)
+				}
+			}
+		}
+		if len(toCols) == len(data.fromCols) {
+			data.ref, data.toCols = ref, toCols
+		}
+	}
+	td.fks = append(td.fks, data)
+}
+
+// randRow generates a random row that satisfies the foreign keys and the
+// unique constraints of the table and adds it to the rows of the table. It
+// returns false if no such row was found.
+func (td *fkTableData) randRow(rng *rand.Rand) (tree.Datums, bool) {
+	if td.noRows {
+		return nil, false
+	}
+	isFKCol := make([]bool, len(td.cols))
+	for _, fk := range td.fks {
+		for _, ord := range fk.fromCols {
+			isFKCol[ord] = true
+		}
+	}
+Attempt:
+	for attempt := 0; attempt < fkDataMaxAttempts; attempt++ {
+		row := make(tree.Datums, len(td.cols))
+		for i, typ := range td.types {
+			if typ == nil || isFKCol[i] {
+				continue
+			}
+			row[i] = rowenc.RandDatum(rng, typ, !td.notNull[i])
+		}
+		for _, fk := range td.fks {
+			if !td.fillForeignKey(rng, row, fk) {
+				continue Attempt
+			}
+		}
+		if td.addUnique(row) {
+			td.rows = append(td.rows, row)
+			return row, true
+		}
+	}
+	return nil, false
+}
+
+// fillForeignKey sets the referencing columns of fk in row to the values of a
+// random referenced row. If there is no suitable referenced row, the
+// referencing columns are set to NULL. It returns false if that is not
+// possible either.
+func (td *fkTableData) fillForeignKey(rng *rand.Rand, row tree.Datums, fk fkData) bool {
+	var candidates []tree.Datums
+	if fk.ref != nil {
+		candidates = fk.ref.rows
+		if fk.ref == td {
+			// A row can reference itself.
+			candidates = append(candidates[:len(candidates):len(candidates)], row)
+		}
+	}
+	var valid [][]tree.Datum
+	for _, ref := range candidates {
+		if values, ok := td.referencedValues(row, fk, ref); ok {
+			valid = append(valid, values)
+		}
+	}
+	if len(valid) > 0 {
+		values := valid[rng.Intn(len(valid))]
+		for i, ord := range fk.fromCols {
+			row[ord] = values[i]
+		}
+		return true
+	}
+	for _, ord := range fk.fromCols {
+		if td.notNull[ord] || (row[ord] != nil && row[ord] != tree.DNull) {
+			return false
+		}
+	}
+	for _, ord := range fk.fromCols {
+		row[ord] = tree.DNull
+	}
+	return true
+}
+
+// referencedValues returns the values of the referenced columns of fk in the
+// referenced row ref, adjusted to the types of the referencing columns. It
+// returns false if the values are not known, are NULL, don't fit the
+// referencing columns, or differ from the values already set in row by
+// another foreign key.
+func (td *fkTableData) referencedValues(
+	row tree.Datums, fk fkData, ref tree.Datums,
+) ([]tree.Datum, bool) {
+	values := make([]tree.Datum, len(fk.toCols))
+	for i, ord := range fk.toCols {
+		d := ref[ord]
+		if d == nil || d == tree.DNull {
+			return nil, false
+		}
+		from := fk.fromCols[i]
+		if typ := td.types[from]; typ != nil && typ.Family() != types.EnumFamily {
+			var err error
+			if d, err = tree.AdjustValueToType(typ, d); err != nil {
+				return nil, false
+			}
+		}
+		if row[from] != nil && !bytes.Equal(appendDatumKey(nil, row[from]), appendDatumKey(nil, d)) {
+			return nil, false
+		}
+		values[i] = d
+	}
+	return values, true
+}
+
+// addUnique returns whether row satisfies the unique constraints of the table
+// and, if it does, marks the values of row as used.
+func (td *fkTableData) addUnique(row tree.Datums) bool {
+	keys := make([]string, len(td.uniqueSets))
+	for i, set := range td.uniqueSets {
+		var key []byte
+		for _, ord := range set {
+			if row[ord] == nil || row[ord] == tree.DNull {
+				// NULLs never conflict, and unknown values cannot
+				// be checked.
+				key = nil
+				break
+			}
+			key = appendDatumKey(key, row[ord])
+		}
+		if key == nil {
+			continue
+		}
+		keys[i] = string(key)
+		if td.seen[i][keys[i]] {
+			return false
+		}
+	}
+	for i, key := range keys {
+		if key != "" {
+			td.seen[i][key] = true
+		}
+	}
+	return true
+}
+
+// makeInsert returns an INSERT statement that inserts row into the table.
+func (td *fkTableData) makeInsert(row tree.Datums) *tree.Insert {
+	var names tree.NameList
+	var exprs tree.Exprs
+	for i, d := range row {
+		if d == nil {
+			continue
+		}
+		names = append(names, td.cols[i].Name)
+		if e, ok := d.(*tree.DEnum); ok {
+			// The values of enums are inserted as untyped string literals,
+			// since the types are only known by name.
+			exprs = append(exprs, tree.NewStrVal(e.LogicalRep))
+		} else {
+			exprs = append(exprs, d)
+		}
+	}
+	ins := &tree.Insert{
+		Table:     &td.create.Table,
+		Rows:      &tree.Select{},
+		Returning: tree.AbsentReturningClause,
+	}
+	if len(names) > 0 {
+		ins.Columns = names
+		ins.Rows.Select = &tree.ValuesClause{Rows: []tree.Exprs{exprs}}
+	}
+	return ins
+}
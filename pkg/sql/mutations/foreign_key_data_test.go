@@ -0,0 +1,119 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+func TestRandForeignKeyInserts(t *testing.T) {
+	q := `
+		CREATE TABLE c (
+			x INT8 PRIMARY KEY,
+			pa INT8 NOT NULL,
+			pb STRING REFERENCES p (b),
+			parent INT8 REFERENCES c (x)
+		);
+		CREATE TABLE p (a INT8 PRIMARY KEY, b STRING NOT NULL, UNIQUE (b));
+		ALTER TABLE c ADD CONSTRAINT fk FOREIGN KEY (pa) REFERENCES p;
+		CREATE TABLE u (k INT8 PRIMARY KEY, r INT8 NOT NULL REFERENCES unknown (r));
+	`
+	parsed, err := parser.Parse(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rng, _ := randutil.NewPseudoRand()
+	inserts := RandForeignKeyInserts(rng, stmtsFromParsed(parsed), 20)
+	if len(inserts) == 0 {
+		t.Fatal("expected inserts")
+	}
+
+	// values contains the inserted values of every column, keyed by table
+	// and column name.
+	values := map[string]map[string]bool{}
+	contains := func(table, col string, d tree.Expr) bool {
+		return d == tree.DNull || values[table+"."+col][tree.Serialize(d)]
+	}
+	for _, stmt := range inserts {
+		// The statements must round-trip through the parser.
+		if _, err := parser.ParseOne(tree.Serialize(stmt)); err != nil {
+			t.Fatalf("error parsing %s: %v", tree.Serialize(stmt), err)
+		}
+		ins := stmt.(*tree.Insert)
+		table := ins.Table.(*tree.TableName).Table()
+		if table == "u" {
+			t.Fatalf("unexpected insert into u, whose NOT NULL FK column cannot be satisfied: %s",
+				tree.Serialize(stmt))
+		}
+		row := map[string]tree.Expr{}
+		for i, e := range ins.Rows.Select.(*tree.ValuesClause).Rows[0] {
+			row[string(ins.Columns[i])] = e
+			key := table + "." + string(ins.Columns[i])
+			if values[key] == nil {
+				values[key] = map[string]bool{}
+			}
+			if ins.Columns[i] == "a" || ins.Columns[i] == "b" || ins.Columns[i] == "x" {
+				if values[key][tree.Serialize(e)] {
+					t.Fatalf("duplicate value in unique column: %s", tree.Serialize(stmt))
+				}
+			}
+			values[key][tree.Serialize(e)] = true
+		}
+		if table != "c" {
+			continue
+		}
+		if !contains("p", "a", row["pa"]) || row["pa"] == tree.DNull {
+			t.Fatalf("pa does not reference p: %s", tree.Serialize(stmt))
+		}
+		if !contains("p", "b", row["pb"]) {
+			t.Fatalf("pb does not reference p: %s", tree.Serialize(stmt))
+		}
+		// The row can reference itself or an earlier row, whose values are
+		// already added to values.
+		if !contains("c", "x", row["parent"]) {
+			t.Fatalf("parent does not reference c: %s", tree.Serialize(stmt))
+		}
+	}
+}
+
+func TestRandForeignKeyInsertsEnum(t *testing.T) {
+	q := `
+		CREATE TYPE e AS ENUM ('x', 'y');
+		CREATE TABLE p (k e PRIMARY KEY);
+		CREATE TABLE c (k INT8 PRIMARY KEY, v e NOT NULL REFERENCES p (k));
+	`
+	parsed, err := parser.Parse(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rng, _ := randutil.NewPseudoRand()
+	inserts := RandForeignKeyInserts(rng, stmtsFromParsed(parsed), 20)
+	if len(inserts) == 0 {
+		t.Fatal("expected inserts")
+	}
+	for _, stmt := range inserts {
+		ins := stmt.(*tree.Insert)
+		for i, e := range ins.Rows.Select.(*tree.ValuesClause).Rows[0] {
+			if e == tree.DNull || (ins.Table.(*tree.TableName).Table() == "c" && ins.Columns[i] == "k") {
+				continue
+			}
+			// The values of enums are inserted as string literals of their
+			// labels.
+			if s, ok := e.(*tree.StrVal); !ok || (s.RawString() != "x" && s.RawString() != "y") {
+				t.Fatalf("expected label, found %s in %s", tree.Serialize(e), tree.Serialize(stmt))
+			}
+		}
+	}
+}
@@ -0,0 +1,279 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strings"
+	"unicode"
+
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/sql/lex"
+	"github.com/cockroachdb/cockroach/pkg/sql/lexbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/stats"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+// IdentifierMutator renames random tables, columns, indexes, views and
+// sequences created by the statements to mixed-case, reserved-word and
+// unicode identifiers, which have to be quoted. All references to the renamed
+// objects are renamed as well, including the columns of injected statistics.
+// Names that are referred to in other string literals, like the arguments of
+// some builtins, are not renamed.
+var IdentifierMutator = VersionedMutator{MultiStatementMutation(identifierMutator), clusterversion.V20_2}
+
+// reservedIdentifiers are reserved keywords used as identifiers by
+// IdentifierMutator.
+var reservedIdentifiers = []string{
+	"select", "table", "order", "group", "user", "check", "column", "default", "primary", "references",
+}
+
+// unicodeIdentifierSuffixes are appended to identifiers by IdentifierMutator.
+// They are in Unicode Normalization Form C, so that they are not changed when
+// the identifiers are normalized.
+var unicodeIdentifierSuffixes = []string{"_ñ", "_über", "_ελληνικά", "_名前", "_🐛", " ◊ "}
+
+func identifierMutator(
+	rng *rand.Rand, stmts []tree.Statement,
+) (mutated []tree.Statement, changed bool) {
+	renames := randIdentifierRenames(rng, stmts)
+	if len(renames) == 0 {
+		return stmts, false
+	}
+	mutated = make([]tree.Statement, len(stmts))
+	for i, stmt := range stmts {
+		sql := tree.Serialize(stmt)
+		renamed := renameIdentifiers(sql, renames)
+		if renamed == sql {
+			mutated[i] = stmt
+			continue
+		}
+		parsed, err := parser.ParseOne(renamed)
+		if err != nil {
+			// Should not happen, the renamed identifiers are quoted.
+			panic(err)
+		}
+		if alter, ok := parsed.AST.(*tree.AlterTable); ok {
+			for _, cmd := range alter.Cmds {
+				if inject, ok := cmd.(*tree.AlterTableInjectStats); ok {
+					renameStatisticsColumns(inject, renames)
+				}
+			}
+		}
+		mutated[i] = parsed.AST
+		changed = true
+	}
+	return mutated, changed
+}
+
+// randIdentifierRenames returns new names for random objects created by
+// stmts, keyed by their old names. Names that cannot be renamed consistently
+// by renameIdentifiers are excluded, see isRenamableIdentifier.
+func randIdentifierRenames(rng *rand.Rand, stmts []tree.Statement) map[string]string {
+	var names []tree.Name
+	excluded := map[string]bool{}
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *tree.CreateTable:
+			names = append(names, stmt.Table.ObjectName)
+			for _, def := range stmt.Defs {
+				switch def := def.(type) {
+				case *tree.ColumnTableDef:
+					names = append(names, def.Name)
+				case *tree.IndexTableDef:
+					names = append(names, def.Name)
+				case *tree.UniqueConstraintTableDef:
+					names = append(names, def.Name)
+				}
+			}
+		case *tree.CreateIndex:
+			names = append(names, stmt.Name)
+		case *tree.CreateView:
+			names = append(names, stmt.Name.ObjectName)
+		case *tree.CreateSequence:
+			names = append(names, stmt.Name.ObjectName)
+		case *tree.CreateType:
+			excluded[stmt.TypeName.Object()] = true
+		}
+	}
+
+	used := map[string]bool{}
+	for _, name := range names {
+		used[string(name)] = true
+	}
+	renames := map[string]string{}
+	for _, name := range names {
+		s := string(name)
+		if s == "" || excluded[s] || renames[s] != "" || rng.Intn(2) == 0 {
+			continue
+		}
+		if !isRenamableIdentifier(s) {
+			continue
+		}
+		renamed := randIdentifier(rng, s)
+		for i := 1; used[renamed]; i++ {
+			renamed = fmt.Sprintf("%s_%d", randIdentifier(rng, s), i)
+		}
+		used[renamed] = true
+		renames[s] = renamed
+	}
+	return renames
+}
+
+// isRenamableIdentifier returns whether the references to the object named
+// name can be renamed by renameIdentifiers. Names that are keywords are not
+// renamable, because their references are not scanned as identifiers, and
+// neither are names of types and functions, because they can share the
+// namespace of the references.
+func isRenamableIdentifier(name string) bool {
+	if lex.GetKeywordID(name) != lex.IDENT {
+		return false
+	}
+	if _, ok := tree.FunDefs[name]; ok {
+		return false
+	}
+	if _, ok, _ := types.TypeForNonKeywordTypeName(name); ok {
+		return false
+	}
+	return true
+}
+
+// randIdentifier returns a mixed-case or unicode variant of name, or a random
+// reserved keyword.
+func randIdentifier(rng *rand.Rand, name string) string {
+	switch rng.Intn(3) {
+	case 0:
+		runes := []rune(name)
+		upper := false
+		for i, r := range runes {
+			if unicode.IsLower(r) && (rng.Intn(2) == 0 || (!upper && i == len(runes)-1)) {
+				runes[i] = unicode.ToUpper(r)
+				upper = true
+			}
+		}
+		if !upper {
+			return "Mut" + name
+		}
+		return string(runes)
+	case 1:
+		return reservedIdentifiers[rng.Intn(len(reservedIdentifiers))]
+	default:
+		return name + unicodeIdentifierSuffixes[rng.Intn(len(unicodeIdentifierSuffixes))]
+	}
+}
+
+// renameIdentifiers replaces the identifiers in sql that have a new name in
+// renames by the quoted new name. Keywords and string literals are not
+// replaced.
+func renameIdentifiers(sql string, renames map[string]string) string {
+	tokens, ok := parser.Tokens(sql)
+	if !ok {
+		return sql
+	}
+	var sb strings.Builder
+	last := 0
+	for _, tok := range tokens {
+		if tok.TokenID != lex.IDENT {
+			continue
+		}
+		renamed, ok := renames[tok.Str]
+		if !ok {
+			continue
+		}
+		start := int(tok.Pos)
+		end := identifierEnd(sql, start)
+		if end < 0 {
+			continue
+		}
+		sb.WriteString(sql[last:start])
+		sb.WriteString(tree.NameString(renamed))
+		last = end
+	}
+	sb.WriteString(sql[last:])
+	return sb.String()
+}
+
+// identifierEnd returns the end of the bare or double-quoted identifier that
+// starts at start in sql, or -1 if there is no such identifier.
+func identifierEnd(sql string, start int) int {
+	if sql[start] == '"' {
+		for i := start + 1; i < len(sql); i++ {
+			if sql[i] != '"' {
+				continue
+			}
+			if i+1 < len(sql) && sql[i+1] == '"' {
+				// An escaped quote.
+				i++
+				continue
+			}
+			return i + 1
+		}
+		return -1
+	}
+	if !lexbase.IsIdentStart(int(sql[start])) {
+		return -1
+	}
+	end := start + 1
+	for end < len(sql) && lexbase.IsIdentMiddle(int(sql[end])) {
+		end++
+	}
+	return end
+}
+
+// renameStatisticsColumns renames the columns of the statistics injected by
+// inject.
+func renameStatisticsColumns(inject *tree.AlterTableInjectStats, renames map[string]string) {
+	jsonStats, ok := injectedStatistics(inject)
+	if !ok {
+		return
+	}
+	for i := range jsonStats {
+		for j, col := range jsonStats[i].Columns {
+			if renamed, ok := renames[col]; ok {
+				jsonStats[i].Columns[j] = renamed
+			}
+		}
+	}
+	b, err := json.Marshal(jsonStats)
+	if err != nil {
+		panic(err)
+	}
+	inject.Stats = tree.NewStrVal(string(b))
+}
+
+// injectedStatistics returns the statistics injected by inject, and false if
+// they are not a JSON constant.
+func injectedStatistics(inject *tree.AlterTableInjectStats) ([]stats.JSONStatistic, bool) {
+	var s string
+	switch e := inject.Stats.(type) {
+	case *tree.StrVal:
+		s = e.RawString()
+	case *tree.AnnotateTypeExpr:
+		str, ok := e.Expr.(*tree.StrVal)
+		if !ok {
+			return nil, false
+		}
+		s = str.RawString()
+	case *tree.DJSON:
+		s = e.JSON.String()
+	default:
+		return nil, false
+	}
+	var jsonStats []stats.JSONStatistic
+	if err := json.Unmarshal([]byte(s), &jsonStats); err != nil {
+		return nil, false
+	}
+	return jsonStats, true
+}
@@ -0,0 +1,87 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+func TestIdentifierMutator(t *testing.T) {
+	q := `
+		CREATE TABLE tab (col1 INT8 PRIMARY KEY, col2 STRING, name STRING, INDEX idx (col2));
+		INSERT INTO tab (col1, col2) VALUES (1, 'col1');
+		SELECT tab.col1, count(col2) FROM tab@idx WHERE col2 > 'a' GROUP BY tab.col1;
+	`
+	rng, _ := randutil.NewPseudoRand()
+	for i := 0; i < 100; i++ {
+		mutated, changed := ApplyString(rng, q, StatisticsMutator, IdentifierMutator)
+		if !changed {
+			continue
+		}
+		parsed, err := parser.Parse(mutated)
+		if err != nil {
+			t.Fatalf("error parsing %s: %v", mutated, err)
+		}
+		// String literals, keywords and function names are not renamed.
+		for _, e := range []string{"'col1'", " name STRING", "count("} {
+			if !strings.Contains(mutated, e) {
+				t.Fatalf("expected %s: %s", e, mutated)
+			}
+		}
+
+		// The CREATE TABLE statement contains the new names, which are used
+		// consistently by the other statements.
+		create := parsed[0].AST.(*tree.CreateTable)
+		table := tree.NameString(string(create.Table.ObjectName))
+		col1 := tree.NameString(string(create.Defs[0].(*tree.ColumnTableDef).Name))
+		col2 := tree.NameString(string(create.Defs[1].(*tree.ColumnTableDef).Name))
+		idx := tree.NameString(string(create.Defs[3].(*tree.IndexTableDef).Name))
+		for _, e := range []string{
+			"INSERT INTO " + table + "(" + col1 + ", " + col2 + ")",
+			"FROM " + table + "@" + idx + " WHERE " + col2 + " > 'a'",
+			"GROUP BY " + table + "." + col1,
+		} {
+			if !strings.Contains(mutated, e) {
+				t.Fatalf("expected %s: %s", e, mutated)
+			}
+		}
+		cols := map[string]bool{}
+		for _, def := range create.Defs {
+			if col, ok := def.(*tree.ColumnTableDef); ok {
+				cols[string(col.Name)] = true
+			}
+		}
+		for _, p := range parsed {
+			alter, ok := p.AST.(*tree.AlterTable)
+			if !ok {
+				continue
+			}
+			jsonStats, ok := injectedStatistics(alter.Cmds[0].(*tree.AlterTableInjectStats))
+			if !ok {
+				t.Fatalf("expected statistics: %s", mutated)
+			}
+			for _, s := range jsonStats {
+				for _, col := range s.Columns {
+					if !cols[col] {
+						t.Fatalf("unexpected statistics on column %s: %s", col, mutated)
+					}
+				}
+			}
+		}
+		return
+	}
+	t.Fatal("expected a change")
+}
@@ -0,0 +1,138 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// MutatorMetrics records how often a mutator changed the statements it ran on.
+type MutatorMetrics struct {
+	// Runs is the number of times the mutator ran.
+	Runs int
+	// Unchanged is the number of runs that did not change the statements,
+	// because the mutator found nothing to mutate or bailed out early. A
+	// mutator whose runs are all unchanged is effectively dead.
+	Unchanged int
+	// StatementsChanged is the number of new or mutated statements returned
+	// by the mutator.
+	StatementsChanged int
+}
+
+// Metrics collects MutatorMetrics from ApplyWithMetrics, keyed by the names
+// of the mutators. It is safe for concurrent use.
+type Metrics struct {
+	mu struct {
+		syncutil.Mutex
+		byMutator map[string]*MutatorMetrics
+	}
+}
+
+// record adds a run of the mutator with the given name.
+func (m *Metrics) record(name string, changed bool, statementsChanged int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.mu.byMutator == nil {
+		m.mu.byMutator = map[string]*MutatorMetrics{}
+	}
+	mm, ok := m.mu.byMutator[name]
+	if !ok {
+		mm = &MutatorMetrics{}
+		m.mu.byMutator[name] = mm
+	}
+	mm.Runs++
+	if !changed {
+		mm.Unchanged++
+	}
+	mm.StatementsChanged += statementsChanged
+}
+
+// Snapshot returns a copy of the metrics, keyed by the names of the mutators.
+func (m *Metrics) Snapshot() map[string]MutatorMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	res := make(map[string]MutatorMetrics, len(m.mu.byMutator))
+	for name, mm := range m.mu.byMutator {
+		res[name] = *mm
+	}
+	return res
+}
+
+// String returns a report of the metrics, with one line per mutator sorted by
+// name.
+func (m *Metrics) String() string {
+	snapshot := m.Snapshot()
+	names := make([]string, 0, len(snapshot))
+	for name := range snapshot {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	for _, name := range names {
+		mm := snapshot[name]
+		fmt.Fprintf(&sb, "%s: runs=%d unchanged=%d statements_changed=%d\n",
+			name, mm.Runs, mm.Unchanged, mm.StatementsChanged)
+	}
+	return sb.String()
+}
+
+// mutatorName returns the name of m used by Metrics. Mutators implemented by
+// funcs are named after the func, and other mutators after their type.
+func mutatorName(m rowenc.Mutator) string {
+	if vm, ok := m.(VersionedMutator); ok {
+		m = vm.Mutator
+	}
+	v := reflect.ValueOf(m)
+	if v.Kind() == reflect.Func {
+		if fn := runtime.FuncForPC(v.Pointer()); fn != nil {
+			// Strip the package path.
+			name := fn.Name()
+			return name[strings.LastIndexByte(name, '/')+1:]
+		}
+	}
+	return fmt.Sprintf("%T", m)
+}
+
+// serializeStatements returns the SQL strings of stmts.
+func serializeStatements(stmts []tree.Statement) []string {
+	res := make([]string, len(stmts))
+	for i, stmt := range stmts {
+		res[i] = tree.Serialize(stmt)
+	}
+	return res
+}
+
+// countChangedStatements returns the number of statements of after whose SQL
+// strings are not in before.
+func countChangedStatements(before []string, after []tree.Statement) int {
+	remaining := map[string]int{}
+	for _, s := range before {
+		remaining[s]++
+	}
+	n := 0
+	for _, stmt := range after {
+		s := tree.Serialize(stmt)
+		if remaining[s] > 0 {
+			remaining[s]--
+		} else {
+			n++
+		}
+	}
+	return n
+}
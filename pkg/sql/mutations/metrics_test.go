@@ -0,0 +1,70 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+func TestApplyWithMetrics(t *testing.T) {
+	q := `
+		INSERT INTO t VALUES (1);
+		SELECT 1;
+		DELETE FROM t;
+	`
+	parsed, err := parser.Parse(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// addReturning adds RETURNING * to all mutation statements, and dead
+	// never changes anything.
+	addReturning := StatementMutator(func(rng *rand.Rand, stmt tree.Statement) (changed bool) {
+		switch stmt := stmt.(type) {
+		case *tree.Insert:
+			stmt.Returning = &tree.ReturningExprs{tree.StarSelectExpr()}
+		case *tree.Delete:
+			stmt.Returning = &tree.ReturningExprs{tree.StarSelectExpr()}
+		default:
+			return false
+		}
+		return true
+	})
+	dead := MultiStatementMutation(func(
+		rng *rand.Rand, stmts []tree.Statement,
+	) (mutated []tree.Statement, changed bool) {
+		return stmts, false
+	})
+
+	rng, _ := randutil.NewPseudoRand()
+	var metrics Metrics
+	for i := 0; i < 3; i++ {
+		ApplyWithMetrics(rng, &metrics, stmtsFromParsed(parsed), dead, VersionedMutator{Mutator: addReturning})
+	}
+	snapshot := metrics.Snapshot()
+	// The statements are only changed by the first run of addReturning,
+	// since they were parsed once.
+	if m := snapshot[mutatorName(addReturning)]; m != (MutatorMetrics{Runs: 3, Unchanged: 0, StatementsChanged: 2}) {
+		t.Fatalf("unexpected metrics of addReturning: %+v", m)
+	}
+	if m := snapshot[mutatorName(dead)]; m != (MutatorMetrics{Runs: 3, Unchanged: 3}) {
+		t.Fatalf("unexpected metrics of dead: %+v", m)
+	}
+	if s := metrics.String(); !strings.Contains(s, "runs=3 unchanged=3 statements_changed=0") {
+		t.Fatalf("unexpected report: %s", s)
+	}
+}
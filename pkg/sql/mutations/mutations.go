@@ -12,13 +12,17 @@ package mutations
 
 import (
 	"bytes"
-	"encoding/json"
+	"fmt"
+	"math"
 	"math/rand"
-	"regexp"
 	"sort"
 	"strings"
+	"time"
 
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/geo/geogen"
 	"github.com/cockroachdb/cockroach/pkg/geo/geoindex"
+	"github.com/cockroachdb/cockroach/pkg/geo/geopb"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
@@ -31,34 +35,84 @@ import (
 
 var (
 	// StatisticsMutator adds ALTER TABLE INJECT STATISTICS statements.
-	StatisticsMutator MultiStatementMutation = statisticsMutator
+	StatisticsMutator = VersionedMutator{MultiStatementMutation(randomStatisticsMutator), clusterversion.V20_2}
+
+	// ExtremeStatisticsMutator adds ALTER TABLE INJECT STATISTICS statements
+	// with row counts and histogram bucket counts close to the maximum int64
+	// value. It is used to test that the consumers of statistics handle such
+	// counts without overflowing.
+	ExtremeStatisticsMutator = VersionedMutator{MultiStatementMutation(extremeStatisticsMutator), clusterversion.V20_2}
+
+	// ConsistentStatisticsMutator adds ALTER TABLE INJECT STATISTICS
+	// statements whose counts are consistent with each other and with the
+	// foreign keys between the tables, so that they resemble statistics
+	// collected on actual data.
+	ConsistentStatisticsMutator = VersionedMutator{MultiStatementMutation(consistentStatisticsMutator), clusterversion.V20_2}
+
+	// DataStatisticsMutator adds ALTER TABLE INJECT STATISTICS statements
+	// with statistics computed from the rows inserted by INSERT ... VALUES
+	// statements. It can be used by metamorphic tests that expect the plans
+	// to be close to optimal.
+	DataStatisticsMutator = VersionedMutator{MakeDataStatisticsMutator(nil), clusterversion.V20_2}
+
+	// StatisticsHistoryMutator adds ALTER TABLE INJECT STATISTICS statements
+	// with several collections of statistics per table. The older collections
+	// have earlier creation times and drifting row counts, like the statistics
+	// of a table that changes over time.
+	StatisticsHistoryMutator = VersionedMutator{MultiStatementMutation(statisticsHistoryMutator), clusterversion.V20_2}
 
 	// ForeignKeyMutator adds ALTER TABLE ADD FOREIGN KEY statements.
-	ForeignKeyMutator MultiStatementMutation = foreignKeyMutator
+	ForeignKeyMutator = VersionedMutator{MakeForeignKeyMutator(ForeignKeyMutatorOptions{}), clusterversion.V20_2}
 
 	// ColumnFamilyMutator modifies a CREATE TABLE statement without any FAMILY
 	// definitions to have random FAMILY definitions.
-	ColumnFamilyMutator StatementMutator = rowenc.ColumnFamilyMutator
+	ColumnFamilyMutator = VersionedMutator{StatementMutator(rowenc.ColumnFamilyMutator), clusterversion.V20_2}
 
 	// IndexStoringMutator modifies the STORING clause of CREATE INDEX and
 	// indexes in CREATE TABLE.
-	IndexStoringMutator MultiStatementMutation = rowenc.IndexStoringMutator
+	IndexStoringMutator = VersionedMutator{MultiStatementMutation(rowenc.IndexStoringMutator), clusterversion.V20_2}
 
 	// PartialIndexMutator adds random partial index predicate expressions to
-	// indexes.
-	PartialIndexMutator MultiStatementMutation = rowenc.PartialIndexMutator
+	// indexes. Partial indexes are experimental before 21.1.
+	PartialIndexMutator = VersionedMutator{MultiStatementMutation(rowenc.PartialIndexMutator), clusterversion.Start21_1}
+
+	// CollatedStringMutator changes the type of random STRING columns to
+	// collated strings with random locales.
+	CollatedStringMutator = VersionedMutator{MultiStatementMutation(collatedStringMutator), clusterversion.V20_2}
+
+	// EnumMutator adds random CREATE TYPE ... AS ENUM statements and changes
+	// the type of random STRING columns to those enums.
+	EnumMutator = VersionedMutator{MultiStatementMutation(enumMutator), clusterversion.Enums}
 
 	// PostgresMutator modifies strings such that they execute identically
-	// in both Postgres and Cockroach (however this mutator does not remove
-	// features not supported by Postgres; use PostgresCreateTableMutator
-	// for those).
+	// in both Postgres and Cockroach by serializing them in the Postgres
+	// dialect (however this mutator does not remove features not supported
+	// by Postgres; use PostgresCreateTableMutator for those).
 	PostgresMutator StatementStringMutator = postgresMutator
 
 	// PostgresCreateTableMutator modifies CREATE TABLE statements to
 	// remove any features not supported by Postgres that would change
 	// results (like descending primary keys). This should be used on the
 	// output of sqlbase.RandCreateTable.
-	PostgresCreateTableMutator MultiStatementMutation = postgresCreateTableMutator
+	PostgresCreateTableMutator = VersionedMutator{MultiStatementMutation(postgresCreateTableMutator), clusterversion.V20_2}
+
+	// ChangefeedExportMutator adds EXPORT INTO CSV statements and sinkless
+	// changefeeds with random options for random tables. Sinkless changefeeds
+	// emit rows until they are canceled, so the resulting statements should
+	// be run with a statement timeout.
+	ChangefeedExportMutator = VersionedMutator{MultiStatementMutation(changefeedExportMutator), clusterversion.V20_2}
+
+	// SessionSettingMutator prepends SET statements that change random
+	// session settings of the execution engine and the optimizer to the
+	// statements. The settings don't change the results of the statements.
+	// PostgresMutator removes the statements again.
+	SessionSettingMutator = VersionedMutator{MultiStatementMutation(sessionSettingMutator), clusterversion.V20_2}
+
+	// MySQLMutator modifies strings such that they execute in MySQL. It
+	// removes features not supported by MySQL (like STORING columns and partial
+	// and inverted indexes) and serializes the statements in the MySQL dialect
+	// (see tree.FmtMySQL).
+	MySQLMutator StatementStringMutator = mysqlMutator
 )
 
 var (
@@ -94,13 +148,48 @@ func (msm MultiStatementMutation) Mutate(
 
 // Apply executes all mutators on stmts. It returns the (possibly mutated and
 // changed in place) statements and a boolean indicating whether any changes
-// were made.
+// were made. The schema passed to SchemaAwareMutators is rebuilt whenever a
+// mutator changes the statements.
 func Apply(
 	rng *rand.Rand, stmts []tree.Statement, mutators ...rowenc.Mutator,
+) (mutated []tree.Statement, changed bool) {
+	return ApplyWithMetrics(rng, nil /* metrics */, stmts, mutators...)
+}
+
+// ApplyWithMetrics is like Apply, but it also records the runs of the
+// mutators in metrics, if it is not nil.
+func ApplyWithMetrics(
+	rng *rand.Rand, metrics *Metrics, stmts []tree.Statement, mutators ...rowenc.Mutator,
 ) (mutated []tree.Statement, changed bool) {
 	var mc bool
+	var schema *Schema
 	for _, m := range mutators {
-		stmts, mc = m.Mutate(rng, stmts)
+		var before []string
+		if metrics != nil {
+			before = serializeStatements(stmts)
+		}
+		mutator := m
+		if vm, ok := mutator.(VersionedMutator); ok {
+			mutator = vm.Mutator
+		}
+		if sm, ok := mutator.(SchemaAwareMutator); ok {
+			if schema == nil {
+				schema = MakeSchema(stmts)
+			}
+			stmts, mc = sm.MutateWithSchema(rng, schema, stmts)
+		} else {
+			stmts, mc = mutator.Mutate(rng, stmts)
+		}
+		if mc {
+			schema = nil
+		}
+		if metrics != nil {
+			var n int
+			if mc {
+				n = countChangedStatements(before, stmts)
+			}
+			metrics.record(mutatorName(m), mc, n)
+		}
 		changed = changed || mc
 	}
 	return stmts, changed
@@ -130,14 +219,46 @@ func (sm StatementStringMutator) MutateString(
 }
 
 // ApplyString executes all mutators on input. A mutator can also be a
-// StringMutator which will operate after all other mutators.
+// StringMutator which will operate after all other mutators. If input cannot
+// be parsed, it is returned unchanged; use ApplyStringStrict to get the parse
+// error.
 func ApplyString(
 	rng *rand.Rand, input string, mutators ...rowenc.Mutator,
 ) (output string, changed bool) {
-	parsed, err := parser.Parse(input)
+	output, changed, err := ApplyStringStrict(rng, input, mutators...)
 	if err != nil {
 		return input, false
 	}
+	return output, changed
+}
+
+// StatementParseError is returned by ApplyStringStrict when a statement of
+// the input cannot be parsed.
+type StatementParseError struct {
+	// Index is the index of the statement in the input, not counting empty
+	// statements.
+	Index int
+	// SQL is the text of the statement.
+	SQL   string
+	cause error
+}
+
+func (e *StatementParseError) Error() string {
+	return fmt.Sprintf("statement %d: %s: %v", e.Index, e.SQL, e.cause)
+}
+
+func (e *StatementParseError) Unwrap() error { return e.cause }
+
+// ApplyStringStrict is like ApplyString, but it returns an error if input
+// cannot be parsed. The error is a *StatementParseError if the offending
+// statement can be found.
+func ApplyStringStrict(
+	rng *rand.Rand, input string, mutators ...rowenc.Mutator,
+) (output string, changed bool, err error) {
+	parsed, err := parser.Parse(input)
+	if err != nil {
+		return "", false, makeStatementParseError(input, err)
+	}
 
 	stmts := make([]tree.Statement, len(parsed))
 	for i, p := range parsed {
@@ -169,7 +290,27 @@ func ApplyString(
 			changed = true
 		}
 	}
-	return input, changed
+	return input, changed, nil
+}
+
+// makeStatementParseError returns a *StatementParseError for the first
+// statement of input that cannot be parsed by itself. If there is no such
+// statement, err, the error of parsing all of input, is returned.
+func makeStatementParseError(input string, err error) error {
+	idx := 0
+	for rest := input; rest != ""; {
+		stmt := rest
+		if pos, ok := parser.SplitFirstStatement(rest); ok {
+			stmt = rest[:pos]
+		}
+		parsed, stmtErr := parser.Parse(stmt)
+		if stmtErr != nil {
+			return &StatementParseError{Index: idx, SQL: strings.TrimSpace(stmt), cause: stmtErr}
+		}
+		idx += len(parsed)
+		rest = rest[len(stmt):]
+	}
+	return err
 }
 
 // randNonNegInt returns a random non-negative integer. It attempts to
@@ -189,36 +330,142 @@ func randNonNegInt(rng *rand.Rand) int64 {
 	return v
 }
 
-func statisticsMutator(
+// statsMode determines the counts generated by statisticsMutator.
+type statsMode int
+
+const (
+	// randomStats generates counts that are distributed over powers of 10.
+	randomStats statsMode = iota
+	// extremeStats generates counts close to math.MaxInt64.
+	extremeStats
+	// consistentStats generates counts that are consistent with each other:
+	// the null and distinct counts of a column add up to at most the row
+	// count, the histogram buckets add up to the number of non-null rows and
+	// to the distinct count, and the distinct counts of the referencing
+	// columns of foreign keys are at most the row counts of the referenced
+	// tables.
+	consistentStats
+)
+
+// randCount returns a random non-negative count according to the mode.
+func (m statsMode) randCount(rng *rand.Rand) int64 {
+	if m == extremeStats {
+		return math.MaxInt64 - rng.Int63n(1000)
+	}
+	return randNonNegInt(rng)
+}
+
+func randomStatisticsMutator(
+	rng *rand.Rand, stmts []tree.Statement,
+) (mutated []tree.Statement, changed bool) {
+	return statisticsMutator(rng, stmts, randomStats, false /* history */)
+}
+
+func extremeStatisticsMutator(
 	rng *rand.Rand, stmts []tree.Statement,
 ) (mutated []tree.Statement, changed bool) {
+	return statisticsMutator(rng, stmts, extremeStats, false /* history */)
+}
+
+func consistentStatisticsMutator(
+	rng *rand.Rand, stmts []tree.Statement,
+) (mutated []tree.Statement, changed bool) {
+	return statisticsMutator(rng, stmts, consistentStats, false /* history */)
+}
+
+func statisticsHistoryMutator(
+	rng *rand.Rand, stmts []tree.Statement,
+) (mutated []tree.Statement, changed bool) {
+	return statisticsMutator(rng, stmts, randomStats, true /* history */)
+}
+
+// statisticsMutator adds ALTER TABLE INJECT STATISTICS statements for the
+// tables created by stmts, with counts generated according to mode. If history
+// is true, several collections of statistics with different creation times and
+// row counts are injected for each table.
+func statisticsMutator(
+	rng *rand.Rand, stmts []tree.Statement, mode statsMode, history bool,
+) (mutated []tree.Statement, changed bool) {
+	// The row counts of all tables are generated up front so that the
+	// consistent mode can bound the distinct counts of foreign key columns by
+	// the row counts of the referenced tables.
+	rowCounts := map[tableKey]int64{}
+	for _, stmt := range stmts {
+		if create, ok := stmt.(*tree.CreateTable); ok {
+			rowCounts[makeTableKey(&create.Table)] = mode.randCount(rng)
+		}
+	}
+	var fks []foreignKeyRef
+	if mode == consistentStats {
+		fks = collectForeignKeys(stmts)
+	}
+	enumLabels := collectEnumLabels(stmts)
 	for _, stmt := range stmts {
 		create, ok := stmt.(*tree.CreateTable)
 		if !ok {
 			continue
 		}
-		alter := &tree.AlterTable{
-			Table: create.Table.ToUnresolvedObjectName(),
-		}
-		rowCount := randNonNegInt(rng)
+		rowCount := rowCounts[makeTableKey(&create.Table)]
 		cols := map[tree.Name]*tree.ColumnTableDef{}
 		colStats := map[tree.Name]*stats.JSONStatistic{}
+		// indexedCols contains the columns that appear in any index or unique
+		// constraint, in order of appearance.
+		var indexedCols []tree.Name
+		// indexes contains the key columns of each index and unique
+		// constraint, up to the first element that is not a column.
+		var indexes [][]tree.Name
+		// uniqueCols contains the columns that are unique on their own.
+		uniqueCols := map[tree.Name]bool{}
+		addIndexedCols := func(elems tree.IndexElemList, unique bool) {
+			var index []tree.Name
+			for _, elem := range elems {
+				if elem.Column == "" {
+					break
+				}
+				indexedCols = append(indexedCols, elem.Column)
+				index = append(index, elem.Column)
+			}
+			indexes = append(indexes, index)
+			if unique && len(elems) == 1 && len(index) == 1 {
+				uniqueCols[index[0]] = true
+			}
+		}
+		histograms := map[tree.Name]bool{}
 		makeHistogram := func(col *tree.ColumnTableDef) {
-			// If an index appeared before a column definition, col
-			// can be nil.
-			if col == nil {
+			// Indexes can refer to columns that are not defined, and each
+			// column only gets one histogram.
+			if col == nil || histograms[col.Name] {
 				return
 			}
+			histograms[col.Name] = true
 			// Do not create a histogram 20% of the time.
 			if rng.Intn(5) == 0 {
 				return
 			}
-			colType := tree.MustBeStaticallyKnownType(col.Type)
-			h := randHistogram(rng, colType)
+			var h stats.HistogramData
+			var upperBounds []string
+			var colTypeName string
+			inverted := false
+			if labels, ok := enumLabels[col.Type.SQLString()]; ok {
+				h, upperBounds = randEnumHistogram(rng, labels, mode)
+				colTypeName = col.Type.SQLString()
+			} else if colType, ok := tree.GetStaticallyKnownType(col.Type); ok {
+				h, upperBounds = randHistogram(rng, colType, mode)
+				colTypeName = h.ColumnType.SQLString()
+				inverted = colinfo.ColumnTypeIsInvertedIndexable(colType)
+			} else {
+				// The type is not known.
+				return
+			}
 			stat := colStats[col.Name]
-			if err := stat.SetHistogram(&h); err != nil {
-				panic(err)
+			if mode == consistentStats && !inverted {
+				// The histograms of inverted indexable columns describe the
+				// inverted index entries rather than the rows.
+				stat.DistinctCount = uint64(makeHistogramConsistent(
+					rng, &h, int64(stat.RowCount-stat.NullCount), int64(stat.DistinctCount),
+				))
 			}
+			setHistogram(stat, colTypeName, &h, upperBounds)
 		}
 		for _, def := range create.Defs {
 			switch def := def.(type) {
@@ -240,37 +487,51 @@ func statisticsMutator(
 					NullCount:     nullCount,
 				}
 				if (def.Unique.IsUnique && !def.Unique.WithoutIndex) || def.PrimaryKey.IsPrimaryKey {
-					makeHistogram(def)
+					indexedCols = append(indexedCols, def.Name)
+				}
+				if def.Unique.IsUnique || def.PrimaryKey.IsPrimaryKey {
+					uniqueCols[def.Name] = true
 				}
 			case *tree.IndexTableDef:
-				// TODO(mgartner): We should make a histogram for each indexed
-				// column.
-				makeHistogram(cols[def.Columns[0].Column])
+				addIndexedCols(def.Columns, false /* unique */)
 			case *tree.UniqueConstraintTableDef:
 				if !def.WithoutIndex {
-					// TODO(mgartner): We should make a histogram for each
-					// column in the unique constraint.
-					makeHistogram(cols[def.Columns[0].Column])
+					addIndexedCols(def.Columns, true /* unique */)
+				} else if len(def.Columns) == 1 {
+					uniqueCols[def.Columns[0].Column] = true
 				}
 			}
 		}
+		if mode == consistentStats {
+			makeColumnStatsConsistent(
+				rng, makeTableKey(&create.Table), rowCount, cols, colStats, uniqueCols, fks, rowCounts,
+			)
+		}
+		// Indexes can appear before the definitions of their columns, so the
+		// histograms are made after all columns are known.
+		for _, name := range indexedCols {
+			makeHistogram(cols[name])
+		}
 		if len(colStats) > 0 {
-			var allStats []*stats.JSONStatistic
-			for _, cs := range colStats {
-				allStats = append(allStats, cs)
+			// The statistics are added in a deterministic order, so that
+			// the mutations can be replayed.
+			var allStats []stats.JSONStatistic
+			for _, name := range sortedColumnNames(cols) {
+				if cs, ok := colStats[name]; ok {
+					allStats = append(allStats, *cs)
+				}
 			}
-			b, err := json.Marshal(allStats)
-			if err != nil {
-				// Should not happen.
-				panic(err)
+			allStats = append(allStats, randMultiColumnStats(rng, rowCount, cols, colStats, indexes, mode)...)
+			if history {
+				allStats = append(allStats, randStatisticsHistory(rng, allStats)...)
 			}
-			j, err := tree.ParseDJSON(string(b))
+			alter, err := stats.MakeAlterTableInjectStats(
+				create.Table.ToUnresolvedObjectName(), allStats,
+			)
 			if err != nil {
+				// Should not happen.
 				panic(err)
 			}
-			alter.Cmds = append(alter.Cmds, &tree.AlterTableInjectStats{
-				Stats: j,
-			})
 			stmts = append(stmts, alter)
 			changed = true
 		}
@@ -278,194 +539,963 @@ func statisticsMutator(
 	return stmts, changed
 }
 
-// randHistogram generates a histogram for the given type with random histogram
-// buckets. If colType is inverted indexable then the histogram bucket upper
-// bounds are byte-encoded inverted index keys.
-func randHistogram(rng *rand.Rand, colType *types.T) stats.HistogramData {
-	histogramColType := colType
-	if colinfo.ColumnTypeIsInvertedIndexable(colType) {
-		histogramColType = types.Bytes
+const (
+	// statsCreatedAtFormat is the format of the creation times of the
+	// statistics generated by statisticsMutator.
+	statsCreatedAtFormat = "2006-01-02 15:04:05-07:00"
+	// maxStatsHistory is the maximum number of older collections of statistics
+	// generated by randStatisticsHistory.
+	maxStatsHistory = 5
+)
+
+// randStatisticsHistory returns up to maxStatsHistory older collections of the
+// given statistics. Each collection is created between an hour and a week
+// before the next one, and its counts are scaled by a random factor that
+// drifts from one collection to the next.
+func randStatisticsHistory(rng *rand.Rand, latest []stats.JSONStatistic) []stats.JSONStatistic {
+	var res []stats.JSONStatistic
+	createdAt := make([]time.Time, len(latest))
+	for i := range latest {
+		t, err := time.Parse(statsCreatedAtFormat, latest[i].CreatedAt)
+		if err != nil {
+			// Should not happen.
+			panic(err)
+		}
+		createdAt[i] = t
 	}
-	h := stats.HistogramData{
-		ColumnType: histogramColType,
+	factor := 1.0
+	var age time.Duration
+	for n := rng.Intn(maxStatsHistory + 1); n > 0; n-- {
+		age += time.Hour + time.Duration(rng.Int63n(int64(7*24*time.Hour)))
+		// Tables usually grow over time, so older row counts tend to be lower.
+		factor *= 0.5 + 0.6*rng.Float64()
+		for i := range latest {
+			res = append(res, scaleStatistic(latest[i], factor, createdAt[i].Add(-age)))
+		}
 	}
+	return res
+}
 
-	// Generate random values for histogram bucket upper bounds.
-	var encodedUpperBounds [][]byte
-	for i, numDatums := 0, rng.Intn(10); i < numDatums; i++ {
-		upper := rowenc.RandDatum(rng, colType, false /* nullOk */)
-		if colinfo.ColumnTypeIsInvertedIndexable(colType) {
-			encs := encodeInvertedIndexHistogramUpperBounds(colType, upper)
-			encodedUpperBounds = append(encodedUpperBounds, encs...)
-		} else {
-			enc, err := rowenc.EncodeTableKey(nil, upper, encoding.Ascending)
-			if err != nil {
-				panic(err)
-			}
-			encodedUpperBounds = append(encodedUpperBounds, enc)
+// scaleStatistic returns a copy of stat with the given creation time and its
+// counts scaled by factor. The counts saturate instead of overflowing.
+func scaleStatistic(stat stats.JSONStatistic, factor float64, createdAt time.Time) stats.JSONStatistic {
+	scaleUint64 := func(c uint64) uint64 {
+		if v := float64(c) * factor; v < math.MaxUint64 {
+			return uint64(v)
 		}
+		return math.MaxUint64
 	}
-
-	// Return early if there are no upper-bounds.
-	if len(encodedUpperBounds) == 0 {
-		return h
+	scaleInt64 := func(c int64) int64 {
+		if v := float64(c) * factor; v < math.MaxInt64 {
+			return int64(v)
+		}
+		return math.MaxInt64
 	}
-
-	// Sort the encoded upper-bounds.
-	sort.Slice(encodedUpperBounds, func(i, j int) bool {
-		return bytes.Compare(encodedUpperBounds[i], encodedUpperBounds[j]) < 0
-	})
-
-	// Remove duplicates.
-	dedupIdx := 1
-	for i := 1; i < len(encodedUpperBounds); i++ {
-		if !bytes.Equal(encodedUpperBounds[i], encodedUpperBounds[i-1]) {
-			encodedUpperBounds[dedupIdx] = encodedUpperBounds[i]
-			dedupIdx++
+	stat.CreatedAt = createdAt.Format(statsCreatedAtFormat)
+	stat.RowCount = scaleUint64(stat.RowCount)
+	stat.DistinctCount = scaleUint64(stat.DistinctCount)
+	stat.NullCount = scaleUint64(stat.NullCount)
+	if stat.HistogramBuckets != nil {
+		buckets := make([]stats.JSONHistoBucket, len(stat.HistogramBuckets))
+		for i, b := range stat.HistogramBuckets {
+			b.NumEq = scaleInt64(b.NumEq)
+			b.NumRange = scaleInt64(b.NumRange)
+			b.DistinctRange *= factor
+			if b.DistinctRange > float64(b.NumRange) {
+				b.DistinctRange = float64(b.NumRange)
+			}
+			buckets[i] = b
 		}
+		stat.HistogramBuckets = buckets
 	}
-	encodedUpperBounds = encodedUpperBounds[:dedupIdx]
+	stat.Columns = append([]string(nil), stat.Columns...)
+	return stat
+}
 
-	// Create a histogram bucket for each encoded upper-bound.
-	for i := range encodedUpperBounds {
-		// The first bucket must have NumRange = 0, and thus DistinctRange = 0
-		// as well.
-		var numRange int64
-		var distinctRange float64
-		if i > 0 {
-			numRange, distinctRange = randNumRangeAndDistinctRange(rng)
-		}
+// tableKey identifies a table in the maps of the statistics and foreign key
+// mutators. Tables without an explicit schema are assumed to be in the public
+// schema, so that t and public.t have the same key, while s1.t and s2.t don't.
+// Tables without an explicit database have an empty db.
+type tableKey struct {
+	db, schema, table tree.Name
+}
 
-		h.Buckets = append(h.Buckets, stats.HistogramData_Bucket{
-			NumEq:         randNonNegInt(rng),
-			NumRange:      numRange,
-			DistinctRange: distinctRange,
-			UpperBound:    encodedUpperBounds[i],
-		})
+func makeTableKey(tn *tree.TableName) tableKey {
+	k := tableKey{schema: tree.PublicSchemaName, table: tn.ObjectName}
+	if tn.ExplicitCatalog {
+		k.db = tn.CatalogName
 	}
+	if tn.ExplicitSchema {
+		k.schema = tn.SchemaName
+	}
+	return k
+}
 
-	return h
+// foreignKeyRef is a foreign key reference from the columns of one table to
+// the columns of another table. toCols is empty if the primary key of the
+// referenced table is referenced.
+type foreignKeyRef struct {
+	fromTable, toTable tableKey
+	fromCols, toCols   tree.NameList
 }
 
-// encodeInvertedIndexHistogramUpperBounds returns a slice of byte-encoded
-// inverted index keys that are created from val.
-func encodeInvertedIndexHistogramUpperBounds(colType *types.T, val tree.Datum) (encs [][]byte) {
-	var keys [][]byte
-	var err error
-	switch colType.Family() {
-	case types.GeometryFamily:
-		tempIdx := descpb.IndexDescriptor{
-			GeoConfig: *geoindex.DefaultGeometryIndexConfig(),
-		}
-		keys, err = rowenc.EncodeGeoInvertedIndexTableKeys(val, nil, &tempIdx)
-	case types.GeographyFamily:
-		tempIdx := descpb.IndexDescriptor{
-			GeoConfig: *geoindex.DefaultGeographyIndexConfig(),
+// collectForeignKeys returns the foreign key references that are defined in
+// the CREATE TABLE and ALTER TABLE statements in stmts.
+func collectForeignKeys(stmts []tree.Statement) []foreignKeyRef {
+	var fks []foreignKeyRef
+	addFK := func(fromTable tableKey, def *tree.ForeignKeyConstraintTableDef) {
+		fks = append(fks, foreignKeyRef{
+			fromTable: fromTable,
+			toTable:   makeTableKey(&def.Table),
+			fromCols:  def.FromCols,
+			toCols:    def.ToCols,
+		})
+	}
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *tree.CreateTable:
+			for _, def := range stmt.Defs {
+				switch def := def.(type) {
+				case *tree.ColumnTableDef:
+					if def.References.Table != nil {
+						ref := foreignKeyRef{
+							fromTable: makeTableKey(&stmt.Table),
+							toTable:   makeTableKey(def.References.Table),
+							fromCols:  tree.NameList{def.Name},
+						}
+						if def.References.Col != "" {
+							ref.toCols = tree.NameList{def.References.Col}
+						}
+						fks = append(fks, ref)
+					}
+				case *tree.ForeignKeyConstraintTableDef:
+					addFK(makeTableKey(&stmt.Table), def)
+				}
+			}
+		case *tree.AlterTable:
+			for _, cmd := range stmt.Cmds {
+				if add, ok := cmd.(*tree.AlterTableAddConstraint); ok {
+					if fk, ok := add.ConstraintDef.(*tree.ForeignKeyConstraintTableDef); ok {
+						tn := stmt.Table.ToTableName()
+						addFK(makeTableKey(&tn), fk)
+					}
+				}
+			}
 		}
-		keys, err = rowenc.EncodeGeoInvertedIndexTableKeys(val, nil, &tempIdx)
-	default:
-		keys, err = rowenc.EncodeInvertedIndexTableKeys(val, nil, descpb.EmptyArraysInInvertedIndexesVersion)
 	}
+	return fks
+}
 
-	if err != nil {
-		panic(err)
+// makeColumnStatsConsistent regenerates the null and distinct counts of the
+// single-column statistics of the given table so that the null and distinct
+// counts add up to at most the row count, unique columns have a distinct value
+// for every non-null row, the columns referenced by foreign keys have no NULLs,
+// and the distinct counts of the referencing columns of foreign keys are at
+// most the row counts of the referenced tables.
+func makeColumnStatsConsistent(
+	rng *rand.Rand,
+	table tableKey,
+	rowCount int64,
+	cols map[tree.Name]*tree.ColumnTableDef,
+	colStats map[tree.Name]*stats.JSONStatistic,
+	uniqueCols map[tree.Name]bool,
+	fks []foreignKeyRef,
+	rowCounts map[tableKey]int64,
+) {
+	referenced := map[tree.Name]bool{}
+	distinctBounds := map[tree.Name]int64{}
+	for _, fk := range fks {
+		if fk.toTable == table {
+			for _, col := range fk.toCols {
+				referenced[col] = true
+			}
+		}
+		if fk.fromTable == table {
+			parentRows, ok := rowCounts[fk.toTable]
+			if !ok {
+				// The referenced table is not created by these statements.
+				continue
+			}
+			for _, col := range fk.fromCols {
+				if bound, ok := distinctBounds[col]; !ok || parentRows < bound {
+					distinctBounds[col] = parentRows
+				}
+			}
+		}
 	}
-
-	var da rowenc.DatumAlloc
-	for i := range keys {
-		// Each key much be a byte-encoded datum so that it can be
-		// decoded in JSONStatistic.SetHistogram.
-		enc, err := rowenc.EncodeTableKey(nil, da.NewDBytes(tree.DBytes(keys[i])), encoding.Ascending)
-		if err != nil {
-			panic(err)
+	// The map is iterated in the order of the column definitions to keep the
+	// generated statistics deterministic for a given seed.
+	for _, name := range sortedColumnNames(cols) {
+		def, stat := cols[name], colStats[name]
+		var nullCount, distinctCount int64
+		if rowCount > 0 && def.Nullable.Nullability != tree.NotNull && !referenced[name] {
+			nullCount = rng.Int63n(rowCount)
 		}
-		encs = append(encs, enc)
+		if rows := rowCount - nullCount; rows > 0 {
+			if uniqueCols[name] {
+				distinctCount = rows
+			} else {
+				distinctCount = 1 + rng.Int63n(rows)
+			}
+		}
+		if bound, ok := distinctBounds[name]; ok && distinctCount > bound {
+			distinctCount = bound
+		}
+		stat.NullCount = uint64(nullCount)
+		stat.DistinctCount = uint64(distinctCount)
 	}
-	return encs
 }
 
-// randNumRangeAndDistinctRange returns two random numbers to be used for
-// NumRange and DistinctRange fields of a histogram bucket.
-func randNumRangeAndDistinctRange(rng *rand.Rand) (numRange int64, distinctRange float64) {
-	numRange = randNonNegInt(rng)
-	// distinctRange should be <= numRange.
-	switch rng.Intn(3) {
-	case 0:
-		distinctRange = 0
-	case 1:
-		distinctRange = float64(numRange)
-	default:
-		distinctRange = rng.Float64() * float64(numRange)
+// sortedColumnNames returns the names of the given columns in sorted order.
+func sortedColumnNames(cols map[tree.Name]*tree.ColumnTableDef) []tree.Name {
+	names := make([]tree.Name, 0, len(cols))
+	for name := range cols {
+		names = append(names, name)
 	}
-	return numRange, distinctRange
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
 }
 
-// foreignKeyMutator is a MultiStatementMutation implementation which adds
-// foreign key references between existing columns.
-func foreignKeyMutator(
-	rng *rand.Rand, stmts []tree.Statement,
-) (mutated []tree.Statement, changed bool) {
-	// Find columns in the tables.
-	cols := map[tree.TableName][]*tree.ColumnTableDef{}
-	byName := map[tree.TableName]*tree.CreateTable{}
-
-	// Keep track of referencing columns since we have a limitation that a
-	// column can only be used by one FK.
-	usedCols := map[tree.TableName]map[tree.Name]bool{}
-
-	// Keep track of table dependencies to prevent circular dependencies.
-	dependsOn := map[tree.TableName]map[tree.TableName]bool{}
-
-	var tables []*tree.CreateTable
-	for _, stmt := range stmts {
-		table, ok := stmt.(*tree.CreateTable)
-		if !ok {
-			continue
+// makeHistogramConsistent sets the counts of the buckets of h so that they add
+// up to numRows rows and at most distinctCount distinct values. It returns the
+// number of distinct values in the histogram, which is less than distinctCount
+// only if the buckets cannot hold that many distinct values.
+func makeHistogramConsistent(
+	rng *rand.Rand, h *stats.HistogramData, numRows, distinctCount int64,
+) int64 {
+	n := len(h.Buckets)
+	if n == 0 {
+		return distinctCount
+	}
+	for i := range h.Buckets {
+		h.Buckets[i].NumEq = 0
+		h.Buckets[i].NumRange = 0
+		h.Buckets[i].DistinctRange = 0
+	}
+	if numRows <= 0 || distinctCount <= 0 {
+		return 0
+	}
+	if distinctCount > numRows {
+		distinctCount = numRows
+	}
+	// Every distinct value needs at least one row. The upper bounds of the
+	// first min(n, distinctCount) buckets get one value each, and the remaining
+	// distinct values are spread over the ranges between the upper bounds (the
+	// first bucket has no range).
+	numEqBuckets := n
+	if int64(numEqBuckets) > distinctCount {
+		numEqBuckets = int(distinctCount)
+	}
+	for i := 0; i < numEqBuckets; i++ {
+		h.Buckets[i].NumEq = 1
+	}
+	distinctCount -= int64(numEqBuckets)
+	if n > 1 {
+		for i, d := range randSplit(rng, distinctCount, n-1) {
+			h.Buckets[i+1].NumRange = d
+			h.Buckets[i+1].DistinctRange = float64(d)
 		}
-		tables = append(tables, table)
-		byName[table.Table] = table
-		usedCols[table.Table] = map[tree.Name]bool{}
-		dependsOn[table.Table] = map[tree.TableName]bool{}
-		for _, def := range table.Defs {
-			switch def := def.(type) {
-			case *tree.ColumnTableDef:
-				cols[table.Table] = append(cols[table.Table], def)
-			}
+	}
+	// Spread the remaining rows over the values that are already present.
+	var eqBuckets, rangeBuckets []int
+	var distinct int64
+	for i := range h.Buckets {
+		if h.Buckets[i].NumEq > 0 {
+			eqBuckets = append(eqBuckets, i)
+			distinct++
+		}
+		if h.Buckets[i].NumRange > 0 {
+			rangeBuckets = append(rangeBuckets, i)
+			distinct += h.Buckets[i].NumRange
 		}
 	}
-	if len(tables) == 0 {
-		return stmts, false
+	extra := randSplit(rng, numRows-distinct, len(eqBuckets)+len(rangeBuckets))
+	for i, b := range eqBuckets {
+		h.Buckets[b].NumEq += extra[i]
+	}
+	for i, b := range rangeBuckets {
+		h.Buckets[b].NumRange += extra[len(eqBuckets)+i]
 	}
+	return distinct
+}
 
-	toNames := func(cols []*tree.ColumnTableDef) tree.NameList {
-		names := make(tree.NameList, len(cols))
-		for i, c := range cols {
-			names[i] = c.Name
+// randSplit returns n random non-negative numbers that add up to total.
+func randSplit(rng *rand.Rand, total int64, n int) []int64 {
+	parts := make([]int64, n)
+	if n == 0 || total <= 0 {
+		return parts
+	}
+	weights := make([]float64, n)
+	var sum float64
+	for i := range weights {
+		weights[i] = rng.Float64()
+		sum += weights[i]
+	}
+	var assigned int64
+	for i := range parts {
+		if sum == 0 {
+			break
 		}
-		return names
+		remaining := total - assigned
+		if p := float64(total) * (weights[i] / sum); p < float64(remaining) {
+			parts[i] = int64(p)
+		} else {
+			parts[i] = remaining
+		}
+		assigned += parts[i]
 	}
+	parts[rng.Intn(n)] += total - assigned
+	return parts
+}
 
-	// We cannot mutate the table definitions themselves because 1) we
-	// don't know the order of dependencies (i.e., table 1 could reference
-	// table 4 which doesn't exist yet) and relatedly 2) we don't prevent
-	// circular dependencies. Instead, add new ALTER TABLE commands to the
-	// end of a list of statements.
-
-	// Create some FKs.
-	for rng.Intn(2) == 0 {
-		// Choose a random table.
-		table := tables[rng.Intn(len(tables))]
-		// Choose a random column subset.
-		var fkCols []*tree.ColumnTableDef
-		for _, c := range cols[table.Table] {
-			if c.Computed.Computed {
-				// We don't support FK references from computed columns (#46672).
+// randMultiColumnStats returns statistics for each prefix of at least two
+// columns of the given indexes. The counts are random and at most rowCount,
+// or, in the consistent mode, they are bounded by the single-column
+// statistics in colStats.
+func randMultiColumnStats(
+	rng *rand.Rand,
+	rowCount int64,
+	cols map[tree.Name]*tree.ColumnTableDef,
+	colStats map[tree.Name]*stats.JSONStatistic,
+	indexes [][]tree.Name,
+	mode statsMode,
+) []stats.JSONStatistic {
+	var res []stats.JSONStatistic
+	seen := map[string]bool{}
+	for _, index := range indexes {
+		var names []string
+		nullable := true
+		// maxNulls, minDistinct and maxDistinct are the bounds of the counts
+		// of the prefix implied by its single-column statistics. Note that a
+		// row is counted as NULL by the multi-column statistics only if all
+		// of the columns are NULL (see sampler.go), so the null count of the
+		// prefix is at most the smallest null count of its columns.
+		var minDistinct uint64
+		maxNulls, maxDistinct := uint64(math.MaxUint64), uint64(1)
+		for _, col := range index {
+			def := cols[col]
+			if def == nil {
+				// The index refers to a column that is not defined.
+				break
+			}
+			names = append(names, col.String())
+			nullable = nullable && def.Nullable.Nullability != tree.NotNull
+			colStat := colStats[col]
+			if colStat.NullCount < maxNulls {
+				maxNulls = colStat.NullCount
+			}
+			if colStat.DistinctCount > minDistinct {
+				minDistinct = colStat.DistinctCount
+			}
+			if maxDistinct < uint64(rowCount) {
+				if d := colStat.DistinctCount; d != 0 && maxDistinct > math.MaxUint64/d {
+					maxDistinct = math.MaxUint64
+				} else {
+					maxDistinct *= d
+				}
+			}
+			if len(names) < 2 {
 				continue
 			}
-			if usedCols[table.Table][c.Name] {
+			key := strings.Join(names, ",")
+			if seen[key] {
 				continue
 			}
-			fkCols = append(fkCols, c)
+			seen[key] = true
+			var nullCount, distinctCount uint64
+			switch {
+			case mode == consistentStats:
+				if !nullable {
+					maxNulls = 0
+				}
+				nullCount, distinctCount = consistentMultiColumnCounts(
+					rng, uint64(rowCount), maxNulls, minDistinct, maxDistinct,
+				)
+			case rowCount > 0:
+				if nullable {
+					nullCount = uint64(rng.Int63n(rowCount))
+				}
+				distinctCount = uint64(rng.Int63n(rowCount))
+			}
+			res = append(res, stats.JSONStatistic{
+				Name:          "__auto__",
+				CreatedAt:     "2000-01-01 00:00:00+00:00",
+				RowCount:      uint64(rowCount),
+				Columns:       append([]string(nil), names...),
+				DistinctCount: distinctCount,
+				NullCount:     nullCount,
+			})
+		}
+	}
+	return res
+}
+
+// consistentMultiColumnCounts returns random null and distinct counts for a
+// set of columns within the given bounds implied by the single-column
+// statistics. The counts add up to at most rowCount.
+func consistentMultiColumnCounts(
+	rng *rand.Rand, rowCount, maxNulls, minDistinct, maxDistinct uint64,
+) (nullCount, distinctCount uint64) {
+	if maxNulls > rowCount {
+		maxNulls = rowCount
+	}
+	nullCount = randUint64Between(rng, 0, maxNulls)
+	if rows := rowCount - nullCount; maxDistinct > rows {
+		maxDistinct = rows
+	}
+	if minDistinct > maxDistinct {
+		minDistinct = maxDistinct
+	}
+	distinctCount = randUint64Between(rng, minDistinct, maxDistinct)
+	return nullCount, distinctCount
+}
+
+// randUint64Between returns a random number in the interval [lo, hi].
+func randUint64Between(rng *rand.Rand, lo, hi uint64) uint64 {
+	if hi-lo >= math.MaxInt64 {
+		return lo + uint64(rng.Int63())
+	}
+	return lo + uint64(rng.Int63n(int64(hi-lo+1)))
+}
+
+// kvOptionChoice is an option in an allow-list of statement options along with
+// its possible values. An empty value means the option is used without a
+// value.
+type kvOptionChoice struct {
+	key    tree.Name
+	values []string
+}
+
+// changefeedOptions is the allow-list of options for sinkless changefeeds
+// created by changefeedExportMutator.
+var changefeedOptions = []kvOptionChoice{
+	{"updated", []string{""}},
+	{"resolved", []string{"", "1s", "10ms"}},
+	{"diff", []string{""}},
+	{"key_in_value", []string{""}},
+	{"full_table_name", []string{""}},
+	{"schema_change_events", []string{"default", "column_changes"}},
+	{"schema_change_policy", []string{"backfill", "nobackfill", "stop"}},
+	{"initial_scan", []string{""}},
+	{"no_initial_scan", []string{""}},
+}
+
+// exportOptions is the allow-list of options for the EXPORT statements created
+// by changefeedExportMutator.
+var exportOptions = []kvOptionChoice{
+	{"delimiter", []string{"|", "\t"}},
+	{"nullas", []string{"NULL", "\\N"}},
+	{"chunk_rows", []string{"1", "10", "1000"}},
+	{"compression", []string{"gzip"}},
+}
+
+// sessionSettings is the allow-list of session settings changed by
+// sessionSettingMutator. Values that can make statements fail, like
+// vectorize = experimental_always, are excluded.
+var sessionSettings = []kvOptionChoice{
+	{"vectorize", []string{"on", "off"}},
+	{"distsql", []string{"off", "on", "auto"}},
+	{"experimental_distsql_planning", []string{"off", "on"}},
+	{"disable_partially_distributed_plans", []string{"off", "on"}},
+	{"reorder_joins_limit", []string{"0", "2", "8"}},
+	{"enable_zigzag_join", []string{"off", "on"}},
+	{"optimizer_use_histograms", []string{"off", "on"}},
+	{"optimizer_use_multicol_stats", []string{"off", "on"}},
+	{"locality_optimized_partitioned_index_scan", []string{"off", "on"}},
+	{"prefer_lookup_joins_for_fks", []string{"off", "on"}},
+	{"enable_implicit_select_for_update", []string{"off", "on"}},
+	{"enable_insert_fast_path", []string{"off", "on"}},
+}
+
+// postgresSessionSettings are the session settings that also exist in
+// Postgres. SET statements for all other settings are removed by
+// PostgresMutator.
+var postgresSessionSettings = map[string]bool{
+	"application_name":   true,
+	"bytea_output":       true,
+	"client_encoding":    true,
+	"datestyle":          true,
+	"extra_float_digits": true,
+	"search_path":        true,
+	"statement_timeout":  true,
+	"timezone":           true,
+}
+
+func sessionSettingMutator(
+	rng *rand.Rand, stmts []tree.Statement,
+) (mutated []tree.Statement, changed bool) {
+	opts := randKVOptions(rng, sessionSettings)
+	if len(opts) == 0 {
+		return stmts, false
+	}
+	mutated = make([]tree.Statement, 0, len(opts)+len(stmts))
+	for _, opt := range opts {
+		mutated = append(mutated, &tree.SetVar{Name: string(opt.Key), Values: tree.Exprs{opt.Value}})
+	}
+	return append(mutated, stmts...), true
+}
+
+func changefeedExportMutator(
+	rng *rand.Rand, stmts []tree.Statement,
+) (mutated []tree.Statement, changed bool) {
+	var addRangefeedSetting bool
+	for _, stmt := range stmts {
+		create, ok := stmt.(*tree.CreateTable)
+		if !ok || create.As() {
+			continue
+		}
+		switch rng.Intn(3) {
+		case 0:
+			// Don't add any statements for this table.
+		case 1:
+			// Changefeeds don't support tables with multiple column families.
+			families := 0
+			for _, def := range create.Defs {
+				if _, ok := def.(*tree.FamilyTableDef); ok {
+					families++
+				}
+			}
+			if families > 1 {
+				continue
+			}
+			table := create.Table
+			stmts = append(stmts, &tree.CreateChangefeed{
+				Targets: tree.TargetList{Tables: tree.TablePatterns{&table}},
+				Options: randChangefeedOptions(rng),
+			})
+			addRangefeedSetting = true
+			changed = true
+		case 2:
+			table := create.Table
+			stmts = append(stmts, &tree.Export{
+				Query: &tree.Select{
+					Select: &tree.SelectClause{
+						Exprs: tree.SelectExprs{tree.StarSelectExpr()},
+						From:  tree.From{Tables: tree.TableExprs{&table}},
+					},
+				},
+				FileFormat: "CSV",
+				File:       tree.NewStrVal(fmt.Sprintf("nodelocal://0/export/%s", table.Table())),
+				Options:    randKVOptions(rng, exportOptions),
+			})
+			changed = true
+		}
+	}
+	if addRangefeedSetting {
+		// Changefeeds require rangefeeds to be enabled.
+		stmts = append([]tree.Statement{&tree.SetClusterSetting{
+			Name:  "kv.rangefeed.enabled",
+			Value: tree.DBoolTrue,
+		}}, stmts...)
+	}
+	return stmts, changed
+}
+
+// randChangefeedOptions returns a random subset of changefeedOptions.
+func randChangefeedOptions(rng *rand.Rand) tree.KVOptions {
+	opts := randKVOptions(rng, changefeedOptions)
+	// initial_scan and no_initial_scan are mutually exclusive.
+	var hasInitialScan bool
+	for i := 0; i < len(opts); i++ {
+		switch opts[i].Key {
+		case "initial_scan":
+			hasInitialScan = true
+		case "no_initial_scan":
+			if hasInitialScan {
+				opts = append(opts[:i], opts[i+1:]...)
+				i--
+			}
+		}
+	}
+	return opts
+}
+
+// randKVOptions returns a random subset of the given options with random
+// values, or nil if the subset is empty.
+func randKVOptions(rng *rand.Rand, allowed []kvOptionChoice) tree.KVOptions {
+	var opts tree.KVOptions
+	for _, choice := range allowed {
+		if rng.Intn(3) != 0 {
+			continue
+		}
+		opt := tree.KVOption{Key: choice.key}
+		if v := choice.values[rng.Intn(len(choice.values))]; v != "" {
+			opt.Value = tree.NewStrVal(v)
+		}
+		opts = append(opts, opt)
+	}
+	return opts
+}
+
+// collectEnumLabels returns the labels of the enum types created by the
+// CREATE TYPE statements in stmts, keyed by the SQL string of the type name,
+// which matches the SQL string of the column types that refer to them.
+func collectEnumLabels(stmts []tree.Statement) map[string]tree.EnumValueList {
+	labels := map[string]tree.EnumValueList{}
+	for _, stmt := range stmts {
+		if create, ok := stmt.(*tree.CreateType); ok && create.Variety == tree.Enum {
+			labels[create.TypeName.SQLString()] = create.EnumLabels
+		}
+	}
+	return labels
+}
+
+// collectEnumTypes returns a catalog of the enum types created by the CREATE
+// TYPE statements in stmts, which resolves the column types that refer to
+// them.
+func collectEnumTypes(stmts []tree.Statement) *rowenc.RandTypeCatalog {
+	catalog := rowenc.NewRandTypeCatalog()
+	for _, stmt := range stmts {
+		if create, ok := stmt.(*tree.CreateType); ok && create.Variety == tree.Enum {
+			labels := make([]string, len(create.EnumLabels))
+			for i, label := range create.EnumLabels {
+				labels[i] = string(label)
+			}
+			catalog.AddEnum(create.TypeName.Schema(), create.TypeName.Object(), labels)
+		}
+	}
+	return catalog
+}
+
+// histogramBound is the upper bound of a random histogram bucket.
+type histogramBound struct {
+	// encoded is the key encoding of the upper bound, which determines the
+	// order of the buckets.
+	encoded []byte
+	// datum is the upper bound.
+	datum tree.Datum
+}
+
+// randHistogram generates a histogram for the given type with random histogram
+// buckets, whose counts are generated according to mode. If colType is
+// inverted indexable then the histogram bucket upper bounds are byte-encoded
+// inverted index keys. The upper bounds of the buckets are returned formatted
+// so that they can be parsed as values of the type of the histogram. They are
+// formatted from the generated datums rather than decoded from their key
+// encoding, which is not possible for collated strings and tuples.
+func randHistogram(
+	rng *rand.Rand, colType *types.T, mode statsMode,
+) (_ stats.HistogramData, upperBounds []string) {
+	histogramColType := colType
+	if colinfo.ColumnTypeIsInvertedIndexable(colType) {
+		histogramColType = types.Bytes
+	}
+	h := stats.HistogramData{
+		ColumnType: histogramColType,
+	}
+
+	// Generate random values for histogram bucket upper bounds. The upper
+	// bounds of geospatial histograms are shapes in the same area, so that
+	// their inverted index keys share cells like the ones of real data.
+	var shapeOpts *geogen.RandomShapeOptions
+	switch colType.Family() {
+	case types.GeometryFamily, types.GeographyFamily:
+		opts := randGeoShapeOptions(rng, colType)
+		shapeOpts = &opts
+	}
+	var bounds []histogramBound
+	var da rowenc.DatumAlloc
+	for i, numDatums := 0, rng.Intn(10); i < numDatums; i++ {
+		var upper tree.Datum
+		if shapeOpts != nil {
+			upper = randGeoDatum(rng, colType, *shapeOpts)
+		} else {
+			upper = rowenc.RandDatum(rng, colType, false /* nullOk */)
+		}
+		if colinfo.ColumnTypeIsInvertedIndexable(colType) {
+			for _, enc := range encodeInvertedIndexHistogramUpperBounds(colType, upper) {
+				d, _, err := rowenc.DecodeTableKey(&da, types.Bytes, enc, encoding.Ascending)
+				if err != nil {
+					panic(err)
+				}
+				bounds = append(bounds, histogramBound{encoded: enc, datum: d})
+			}
+		} else {
+			enc, err := rowenc.EncodeTableKey(nil, upper, encoding.Ascending)
+			if err != nil {
+				panic(err)
+			}
+			bounds = append(bounds, histogramBound{encoded: enc, datum: upper})
+		}
+	}
+
+	// Return early if there are no upper-bounds.
+	if len(bounds) == 0 {
+		return h, nil
+	}
+
+	// Sort the upper-bounds by their encodings.
+	sort.Slice(bounds, func(i, j int) bool {
+		return bytes.Compare(bounds[i].encoded, bounds[j].encoded) < 0
+	})
+
+	// Remove duplicates.
+	dedupIdx := 1
+	for i := 1; i < len(bounds); i++ {
+		if !bytes.Equal(bounds[i].encoded, bounds[i-1].encoded) {
+			bounds[dedupIdx] = bounds[i]
+			dedupIdx++
+		}
+	}
+	bounds = bounds[:dedupIdx]
+
+	fmtFlags := tree.FmtExport
+	if colType.Family() == types.TupleFamily {
+		// FmtExport is not suitable for tuples.
+		fmtFlags = tree.FmtParsable
+	}
+	encodedUpperBounds := make([][]byte, len(bounds))
+	upperBounds = make([]string, len(bounds))
+	for i := range bounds {
+		encodedUpperBounds[i] = bounds[i].encoded
+		upperBounds[i] = tree.AsStringWithFlags(bounds[i].datum, fmtFlags)
+	}
+	h.Buckets = randHistogramBuckets(rng, encodedUpperBounds, mode)
+	return h, upperBounds
+}
+
+// randGeoShapeOptions returns the options of the shapes of a random histogram
+// of the geospatial type colType. The shapes are within a random area that
+// covers a tenth of the bounds of the default inverted index config of the
+// type in each dimension.
+func randGeoShapeOptions(rng *rand.Rand, colType *types.T) geogen.RandomShapeOptions {
+	minX, maxX, minY, maxY := -180.0, 180.0, -90.0, 90.0
+	if colType.Family() == types.GeometryFamily {
+		cfg := geoindex.DefaultGeometryIndexConfig().S2Geometry
+		minX, maxX, minY, maxY = cfg.MinX, cfg.MaxX, cfg.MinY, cfg.MaxY
+	}
+	width, height := (maxX-minX)/10, (maxY-minY)/10
+	minX = geogen.RandomCoord(rng, minX, maxX-width)
+	minY = geogen.RandomCoord(rng, minY, maxY-height)
+	return geogen.MakeRandomShapeOptions(
+		geogen.MakeRandomGeomBoundsForXY(minX, minX+width, minY, minY+height),
+	)
+}
+
+// randGeoDatum returns a random datum of the geospatial type colType, whose
+// shape is generated with the given options.
+func randGeoDatum(rng *rand.Rand, colType *types.T, opts geogen.RandomShapeOptions) tree.Datum {
+	gm, err := colType.GeoMetadata()
+	if err != nil {
+		panic(err)
+	}
+	if colType.Family() == types.GeographyFamily {
+		srid := gm.SRID
+		if srid == 0 {
+			srid = geopb.DefaultGeographySRID
+		}
+		return tree.NewDGeography(geogen.RandomGeographyWithOptions(rng, srid, opts))
+	}
+	return tree.NewDGeometry(geogen.RandomGeometryWithOptions(rng, gm.SRID, opts))
+}
+
+// randEnumHistogram generates a histogram for an enum type with the given
+// labels, with buckets for a random subset of the labels. The type of the
+// histogram is not set, since the enum type is not known until the statements
+// are executed. The labels are returned as the upper bounds of the buckets.
+func randEnumHistogram(
+	rng *rand.Rand, labels tree.EnumValueList, mode statsMode,
+) (_ stats.HistogramData, upperBounds []string) {
+	// Enum values are ordered by the order of the labels in the type
+	// definition, so the labels are chosen in order.
+	var encodedUpperBounds [][]byte
+	for i, label := range labels {
+		if rng.Intn(2) == 0 {
+			continue
+		}
+		encodedUpperBounds = append(encodedUpperBounds, encoding.EncodeUvarintAscending(nil, uint64(i)))
+		upperBounds = append(upperBounds, string(label))
+	}
+	return stats.HistogramData{Buckets: randHistogramBuckets(rng, encodedUpperBounds, mode)}, upperBounds
+}
+
+// randHistogramBuckets returns histogram buckets with the given sorted and
+// deduplicated encoded upper bounds and random counts generated according to
+// mode.
+func randHistogramBuckets(
+	rng *rand.Rand, encodedUpperBounds [][]byte, mode statsMode,
+) []stats.HistogramData_Bucket {
+	var buckets []stats.HistogramData_Bucket
+	for i := range encodedUpperBounds {
+		// The first bucket must have NumRange = 0, and thus DistinctRange = 0
+		// as well.
+		var numRange int64
+		var distinctRange float64
+		if i > 0 {
+			numRange, distinctRange = randNumRangeAndDistinctRange(rng, mode)
+		}
+
+		buckets = append(buckets, stats.HistogramData_Bucket{
+			NumEq:         mode.randCount(rng),
+			NumRange:      numRange,
+			DistinctRange: distinctRange,
+			UpperBound:    encodedUpperBounds[i],
+		})
+	}
+	return buckets
+}
+
+// setHistogram sets the histogram of stat to the buckets of h, whose upper
+// bounds are formatted in upperBounds, and whose type is colTypeName. Unlike
+// JSONStatistic.SetHistogram, it does not decode the upper bounds from their
+// key encoding, so it can be used for histograms on collated strings, enums and
+// tuples.
+func setHistogram(
+	stat *stats.JSONStatistic, colTypeName string, h *stats.HistogramData, upperBounds []string,
+) {
+	stat.HistogramColumnType = colTypeName
+	stat.HistogramBuckets = make([]stats.JSONHistoBucket, len(h.Buckets))
+	for i := range h.Buckets {
+		b := &h.Buckets[i]
+		stat.HistogramBuckets[i] = stats.JSONHistoBucket{
+			NumEq:         b.NumEq,
+			NumRange:      b.NumRange,
+			DistinctRange: b.DistinctRange,
+			UpperBound:    upperBounds[i],
+		}
+	}
+}
+
+// encodeInvertedIndexHistogramUpperBounds returns a slice of byte-encoded
+// inverted index keys that are created from val.
+func encodeInvertedIndexHistogramUpperBounds(colType *types.T, val tree.Datum) (encs [][]byte) {
+	var keys [][]byte
+	var err error
+	switch colType.Family() {
+	case types.GeometryFamily:
+		tempIdx := descpb.IndexDescriptor{
+			GeoConfig: *geoindex.DefaultGeometryIndexConfig(),
+		}
+		keys, err = rowenc.EncodeGeoInvertedIndexTableKeys(val, nil, &tempIdx)
+	case types.GeographyFamily:
+		tempIdx := descpb.IndexDescriptor{
+			GeoConfig: *geoindex.DefaultGeographyIndexConfig(),
+		}
+		keys, err = rowenc.EncodeGeoInvertedIndexTableKeys(val, nil, &tempIdx)
+	default:
+		keys, err = rowenc.EncodeInvertedIndexTableKeys(val, nil, descpb.EmptyArraysInInvertedIndexesVersion)
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	var da rowenc.DatumAlloc
+	for i := range keys {
+		// Each key much be a byte-encoded datum so that it can be
+		// decoded in JSONStatistic.SetHistogram.
+		enc, err := rowenc.EncodeTableKey(nil, da.NewDBytes(tree.DBytes(keys[i])), encoding.Ascending)
+		if err != nil {
+			panic(err)
+		}
+		encs = append(encs, enc)
+	}
+	return encs
+}
+
+// randNumRangeAndDistinctRange returns two random numbers to be used for
+// NumRange and DistinctRange fields of a histogram bucket.
+func randNumRangeAndDistinctRange(
+	rng *rand.Rand, mode statsMode,
+) (numRange int64, distinctRange float64) {
+	numRange = mode.randCount(rng)
+	// distinctRange should be <= numRange.
+	switch rng.Intn(3) {
+	case 0:
+		distinctRange = 0
+	case 1:
+		distinctRange = float64(numRange)
+	default:
+		distinctRange = rng.Float64() * float64(numRange)
+	}
+	return numRange, distinctRange
+}
+
+// ForeignKeyMutatorOptions configures the foreign keys added by a mutator
+// returned by MakeForeignKeyMutator. The zero value results in the behavior of
+// ForeignKeyMutator.
+type ForeignKeyMutatorOptions struct {
+	// RandomMatch, if set, makes the mutator choose between MATCH SIMPLE and
+	// MATCH FULL at random. Otherwise, MATCH SIMPLE is always used.
+	RandomMatch bool `yaml:"random_match"`
+	// MatchPartial, if set together with RandomMatch, makes the mutator also
+	// choose MATCH PARTIAL. It is not supported yet (#20305), so the
+	// statements that use it are expected to fail.
+	MatchPartial bool `yaml:"match_partial"`
+	// CrossDatabase, if set, allows the mutator to add foreign keys between
+	// tables in different databases, which requires the
+	// sql.cross_db_fks.enabled cluster setting. Foreign keys between tables
+	// in different schemas of the same database are always allowed.
+	CrossDatabase bool `yaml:"cross_database"`
+	// SelfReference, if set, allows the mutator to add foreign keys from a
+	// table to other columns of the same table, like a parent_id column
+	// referencing an id column. ForeignKeyInsertOrder describes the order in
+	// which rows have to be inserted into such tables.
+	SelfReference bool `yaml:"self_reference"`
+}
+
+// MakeForeignKeyMutator returns a SchemaAwareMutation which adds ALTER TABLE
+// ADD FOREIGN KEY statements configured by opts.
+func MakeForeignKeyMutator(opts ForeignKeyMutatorOptions) SchemaAwareMutation {
+	return func(
+		rng *rand.Rand, schema *Schema, stmts []tree.Statement,
+	) (mutated []tree.Statement, changed bool) {
+		return foreignKeyMutator(rng, schema, stmts, opts)
+	}
+}
+
+// foreignKeyMutator is a SchemaAwareMutation implementation which adds
+// foreign key references between existing columns.
+func foreignKeyMutator(
+	rng *rand.Rand, schema *Schema, stmts []tree.Statement, opts ForeignKeyMutatorOptions,
+) (mutated []tree.Statement, changed bool) {
+	tables := schema.Tables()
+	if len(tables) == 0 {
+		return stmts, false
+	}
+
+	// Keep track of referencing columns since we have a limitation that a
+	// column can only be used by one FK.
+	usedCols := map[tableKey]map[tree.Name]bool{}
+
+	// Keep track of table dependencies to prevent circular dependencies.
+	dependsOn := map[tableKey]map[tableKey]bool{}
+
+	for _, table := range tables {
+		usedCols[table.key] = map[tree.Name]bool{}
+		dependsOn[table.key] = map[tableKey]bool{}
+	}
+
+	toNames := func(cols []*tree.ColumnTableDef) tree.NameList {
+		names := make(tree.NameList, len(cols))
+		for i, c := range cols {
+			names[i] = c.Name
+		}
+		return names
+	}
+
+	// We cannot mutate the table definitions themselves because 1) we
+	// don't know the order of dependencies (i.e., table 1 could reference
+	// table 4 which doesn't exist yet) and relatedly 2) we don't prevent
+	// circular dependencies. Instead, add new ALTER TABLE commands to the
+	// end of a list of statements.
+
+	// Create some FKs.
+	for rng.Intn(2) == 0 {
+		// Choose a random table.
+		table := tables[rng.Intn(len(tables))]
+		key := table.key
+		// Choose a random column subset.
+		var fkCols []*tree.ColumnTableDef
+		for _, c := range table.Columns {
+			if c.Computed.Computed {
+				// We don't support FK references from computed columns (#46672).
+				continue
+			}
+			if usedCols[key][c.Name] {
+				continue
+			}
+			fkCols = append(fkCols, c)
 		}
 		if len(fkCols) == 0 {
 			continue
@@ -480,165 +1510,733 @@ func foreignKeyMutator(
 		for len(fkCols) > i && rng.Intn(2) == 0 {
 			i++
 		}
-		fkCols = fkCols[:i]
+		fkCols = fkCols[:i]
+
+		// Check if a table has the needed column types.
+	LoopTable:
+		for _, ref := range tables {
+			refTable, refCols := ref.key, ref.Columns
+			if refTable.db != key.db && !opts.CrossDatabase {
+				continue
+			}
+			selfRef := refTable == key
+			if selfRef {
+				// Self references are opt-in, see
+				// ForeignKeyInsertOrder for how to populate
+				// such tables. The FK columns cannot reference
+				// themselves.
+				if !opts.SelfReference {
+					continue
+				}
+				refCols = excludeColumns(refCols, fkCols)
+			}
+			if len(refCols) < len(fkCols) {
+				continue
+			}
+
+			if !selfRef {
+				// Prevent circular references because generating
+				// valid INSERTs could become impossible or
+				// difficult algorithmically. Find all transitive
+				// dependencies of refTable and make sure none of
+				// them are table.
+				stack := []tableKey{refTable}
+				for i := 0; i < len(stack); i++ {
+					curTable := stack[i]
+					if curTable == key {
+						// table was trying to add a dependency
+						// to refTable, but refTable already
+						// depends on table (directly or
+						// indirectly).
+						continue LoopTable
+					}
+					for t := range dependsOn[curTable] {
+						stack = append(stack, t)
+					}
+				}
+			}
+
+			// Prefer an existing primary key or unique index whose
+			// columns can be matched with the FK columns, so that
+			// no unneeded unique index is created.
+			var usingCols []*tree.ColumnTableDef
+			for _, unique := range uniqueColumnSets(ref.Create, refCols) {
+				if len(unique) != len(fkCols) {
+					continue
+				}
+				if usingCols = matchReferencedColumns(fkCols, unique); usingCols != nil {
+					break
+				}
+			}
+			if usingCols == nil {
+				// Otherwise check if the table has some columns
+				// that are needed types, and create a unique
+				// index on them.
+				if usingCols = matchReferencedColumns(fkCols, refCols); usingCols == nil {
+					continue
+				}
+				refColumns := make(tree.IndexElemList, len(usingCols))
+				for i, c := range usingCols {
+					refColumns[i].Column = c.Name
+				}
+				ref.Create.Defs = append(ref.Create.Defs, &tree.UniqueConstraintTableDef{
+					IndexTableDef: tree.IndexTableDef{
+						Columns: refColumns,
+					},
+				})
+			}
+
+			// Found a suitable table.
+			for _, c := range fkCols {
+				usedCols[key][c.Name] = true
+			}
+			if !selfRef {
+				dependsOn[key][refTable] = true
+			}
+
+			match := tree.MatchSimple
+			if opts.RandomMatch {
+				match = randMatch(rng, opts.MatchPartial)
+			}
+			var actions tree.ReferenceActions
+			if rng.Intn(2) == 0 {
+				actions.Delete = randAction(rng, table.Create, fkCols)
+			}
+			if rng.Intn(2) == 0 {
+				actions.Update = randAction(rng, table.Create, fkCols)
+			}
+			stmts = append(stmts, &tree.AlterTable{
+				Table: table.Create.Table.ToUnresolvedObjectName(),
+				Cmds: tree.AlterTableCmds{&tree.AlterTableAddConstraint{
+					ConstraintDef: &tree.ForeignKeyConstraintTableDef{
+						Table:    ref.Create.Table,
+						FromCols: toNames(fkCols),
+						ToCols:   toNames(usingCols),
+						Actions:  actions,
+						Match:    match,
+					},
+				}},
+			})
+			changed = true
+			break
+		}
+	}
+
+	return stmts, changed
+}
+
+// matchReferencedColumns returns columns of refCols that can be referenced by
+// fkCols, in the order of fkCols. In order to not use columns multiple times,
+// it keeps track of available columns. It returns nil if some FK column has
+// no matching referenced column.
+func matchReferencedColumns(
+	fkCols, refCols []*tree.ColumnTableDef,
+) []*tree.ColumnTableDef {
+	if len(refCols) < len(fkCols) {
+		return nil
+	}
+	availCols := append([]*tree.ColumnTableDef(nil), refCols...)
+	usingCols := make([]*tree.ColumnTableDef, 0, len(fkCols))
+	for _, fkCol := range fkCols {
+		found := false
+		for refI, refCol := range availCols {
+			if refCol.Computed.Virtual {
+				// We don't support FK references to virtual columns (#51296).
+				continue
+			}
+			fkColType := tree.MustBeStaticallyKnownType(fkCol.Type)
+			refColType := tree.MustBeStaticallyKnownType(refCol.Type)
+			if fkColType.Equivalent(refColType) && colinfo.ColumnTypeIsIndexable(refColType) {
+				usingCols = append(usingCols, refCol)
+				availCols = append(availCols[:refI], availCols[refI+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+	return usingCols
+}
+
+// uniqueColumnSets returns the sets of columns of table that are guaranteed
+// to be unique by its primary key or by one of its unique indexes, and that
+// can therefore back a foreign key reference. cols are the column definitions
+// of table. Partial, inverted, sharded and expression-based unique indexes, as
+// well as unique constraints without an index, are ignored.
+func uniqueColumnSets(
+	table *tree.CreateTable, cols []*tree.ColumnTableDef,
+) [][]*tree.ColumnTableDef {
+	byName := make(map[tree.Name]*tree.ColumnTableDef, len(cols))
+	for _, c := range cols {
+		byName[c.Name] = c
+	}
+	var sets [][]*tree.ColumnTableDef
+	for _, def := range table.Defs {
+		switch def := def.(type) {
+		case *tree.ColumnTableDef:
+			if def.PrimaryKey.IsPrimaryKey || def.Unique.IsUnique {
+				sets = append(sets, []*tree.ColumnTableDef{def})
+			}
+		case *tree.UniqueConstraintTableDef:
+			if def.WithoutIndex || def.Predicate != nil || def.Inverted || def.Sharded != nil {
+				continue
+			}
+			set := make([]*tree.ColumnTableDef, 0, len(def.Columns))
+			for _, elem := range def.Columns {
+				c, ok := byName[elem.Column]
+				if elem.Expr != nil || !ok {
+					break
+				}
+				set = append(set, c)
+			}
+			if len(set) == len(def.Columns) {
+				sets = append(sets, set)
+			}
+		}
+	}
+	return sets
+}
+
+// excludeColumns returns the columns of cols that are not in exclude.
+func excludeColumns(cols, exclude []*tree.ColumnTableDef) []*tree.ColumnTableDef {
+	var res []*tree.ColumnTableDef
+Loop:
+	for _, c := range cols {
+		for _, e := range exclude {
+			if c.Name == e.Name {
+				continue Loop
+			}
+		}
+		res = append(res, c)
+	}
+	return res
+}
+
+// InsertOrderHint describes the order in which rows have to be inserted into
+// a table so that its foreign keys are satisfied.
+type InsertOrderHint struct {
+	// Table is the name of the table, as in its CREATE TABLE statement.
+	Table tree.TableName
+	// DependsOn are the other tables referenced by Table. They have to be
+	// populated before Table.
+	DependsOn []tree.TableName
+	// SelfReferences are the foreign keys from Table to itself. A row has to
+	// be inserted after (or in the same statement as) the row it references,
+	// or with NULLs in the referencing columns.
+	SelfReferences []SelfReference
+}
+
+// SelfReference is a foreign key from the FromCols of a table to the ToCols of
+// the same table. ToCols is empty if the primary key is referenced.
+type SelfReference struct {
+	FromCols, ToCols tree.NameList
+}
+
+// ForeignKeyInsertOrder returns a hint for every table created by a CREATE
+// TABLE statement in stmts, in an order in which the tables can be populated
+// without violating the foreign keys defined by stmts. Tables that are part of
+// a reference cycle between multiple tables cannot be ordered; they are
+// returned last, in the order of their CREATE TABLE statements.
+func ForeignKeyInsertOrder(stmts []tree.Statement) []InsertOrderHint {
+	var tables []tableKey
+	hints := map[tableKey]*InsertOrderHint{}
+	dependsOn := map[tableKey][]tableKey{}
+	for _, stmt := range stmts {
+		if create, ok := stmt.(*tree.CreateTable); ok {
+			key := makeTableKey(&create.Table)
+			if _, ok := hints[key]; !ok {
+				tables = append(tables, key)
+				hints[key] = &InsertOrderHint{Table: create.Table}
+			}
+		}
+	}
+	for _, fk := range collectForeignKeys(stmts) {
+		hint, ok := hints[fk.fromTable]
+		if !ok {
+			continue
+		}
+		if fk.fromTable == fk.toTable {
+			hint.SelfReferences = append(hint.SelfReferences, SelfReference{
+				FromCols: fk.fromCols,
+				ToCols:   fk.toCols,
+			})
+			continue
+		}
+		toHint, ok := hints[fk.toTable]
+		if !ok {
+			// The referenced table is not created by these statements.
+			continue
+		}
+		found := false
+		for _, t := range dependsOn[fk.fromTable] {
+			found = found || t == fk.toTable
+		}
+		if !found {
+			dependsOn[fk.fromTable] = append(dependsOn[fk.fromTable], fk.toTable)
+			hint.DependsOn = append(hint.DependsOn, toHint.Table)
+		}
+	}
 
-		// Check if a table has the needed column types.
+	res := make([]InsertOrderHint, 0, len(tables))
+	added := map[tableKey]bool{}
+	for len(res) < len(tables) {
+		progress := false
 	LoopTable:
-		for refTable, refCols := range cols {
-			// Prevent circular and self references because
-			// generating valid INSERTs could become impossible or
-			// difficult algorithmically.
-			if refTable == table.Table || len(refCols) < len(fkCols) {
+		for _, key := range tables {
+			if added[key] {
 				continue
 			}
+			for _, t := range dependsOn[key] {
+				if !added[t] {
+					continue LoopTable
+				}
+			}
+			res = append(res, *hints[key])
+			added[key] = true
+			progress = true
+		}
+		if !progress {
+			for _, key := range tables {
+				if !added[key] {
+					res = append(res, *hints[key])
+				}
+			}
+			break
+		}
+	}
+	return res
+}
 
-			{
-				// To prevent circular references, find all transitive
-				// dependencies of refTable and make sure none of them
-				// are table.
-				stack := []tree.TableName{refTable}
-				for i := 0; i < len(stack); i++ {
-					curTable := stack[i]
-					if curTable == table.Table {
-						// table was trying to add a dependency
-						// to refTable, but refTable already
-						// depends on table (directly or
-						// indirectly).
-						continue LoopTable
-					}
-					for t := range dependsOn[curTable] {
-						stack = append(stack, t)
-					}
+// randMatch returns a random match type for a foreign key. MATCH PARTIAL is
+// only returned if allowPartial is set.
+func randMatch(rng *rand.Rand, allowPartial bool) tree.CompositeKeyMatchMethod {
+	matches := []tree.CompositeKeyMatchMethod{tree.MatchSimple, tree.MatchFull}
+	if allowPartial {
+		matches = append(matches, tree.MatchPartial)
+	}
+	return matches[rng.Intn(len(matches))]
+}
+
+// randAction returns a random action of a foreign key from the columns cols
+// of table that is valid according to isValidReferenceAction.
+func randAction(
+	rng *rand.Rand, table *tree.CreateTable, cols []*tree.ColumnTableDef,
+) tree.ReferenceAction {
+	const highestAction = tree.Cascade
+	for {
+		action := tree.ReferenceAction(rng.Intn(int(highestAction + 1)))
+		if isValidReferenceAction(action, table, cols) {
+			return action
+		}
+	}
+}
+
+// isValidReferenceAction returns whether action can be used by a foreign key
+// from the columns cols of table: SET NULL requires nullable columns, SET
+// DEFAULT requires columns that have a non-NULL default value or are
+// nullable, and computed columns are only allowed with NO ACTION and
+// RESTRICT. Primary key columns are not nullable.
+func isValidReferenceAction(
+	action tree.ReferenceAction, table *tree.CreateTable, cols []*tree.ColumnTableDef,
+) bool {
+	if action == tree.NoAction || action == tree.Restrict {
+		return true
+	}
+	pk := primaryKeyColumns(table)
+	for _, col := range cols {
+		if col.IsComputed() {
+			return false
+		}
+		notNull := col.Nullable.Nullability == tree.NotNull || pk[col.Name]
+		switch action {
+		case tree.SetNull:
+			if notNull {
+				return false
+			}
+		case tree.SetDefault:
+			if notNull && (col.DefaultExpr.Expr == nil || col.DefaultExpr.Expr == tree.DNull) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// primaryKeyColumns returns the names of the primary key columns of table.
+func primaryKeyColumns(table *tree.CreateTable) map[tree.Name]bool {
+	pk := map[tree.Name]bool{}
+	for _, def := range table.Defs {
+		switch def := def.(type) {
+		case *tree.ColumnTableDef:
+			if def.PrimaryKey.IsPrimaryKey {
+				pk[def.Name] = true
+			}
+		case *tree.UniqueConstraintTableDef:
+			if def.PrimaryKey {
+				for _, elem := range def.Columns {
+					pk[elem.Column] = true
 				}
 			}
+		}
+	}
+	return pk
+}
 
-			// We found a table with enough columns. Check if it
-			// has some columns that are needed types. In order
-			// to not use columns multiple times, keep track of
-			// available columns.
-			availCols := append([]*tree.ColumnTableDef(nil), refCols...)
-			var usingCols []*tree.ColumnTableDef
-			for len(availCols) > 0 && len(usingCols) < len(fkCols) {
-				fkCol := fkCols[len(usingCols)]
-				found := false
-				for refI, refCol := range availCols {
-					if refCol.Computed.Virtual {
-						// We don't support FK references to virtual columns (#51296).
-						continue
-					}
-					fkColType := tree.MustBeStaticallyKnownType(fkCol.Type)
-					refColType := tree.MustBeStaticallyKnownType(refCol.Type)
-					if fkColType.Equivalent(refColType) && colinfo.ColumnTypeIsIndexable(refColType) {
-						usingCols = append(usingCols, refCol)
-						availCols = append(availCols[:refI], availCols[refI+1:]...)
-						found = true
-						break
+// typeChangePinnedColumns returns the names of the columns whose types cannot
+// be changed by a mutator, because they are referenced by computed columns or
+// foreign keys. Column names are tracked across all tables, which is
+// conservative but simple.
+func typeChangePinnedColumns(stmts []tree.Statement) map[tree.Name]bool {
+	pinned := map[tree.Name]bool{}
+	pinExpr := func(expr tree.Expr) {
+		_, _ = tree.SimpleVisit(expr, func(expr tree.Expr) (bool, tree.Expr, error) {
+			switch expr := expr.(type) {
+			case *tree.UnresolvedName:
+				pinned[tree.Name(expr.Parts[0])] = true
+			case *tree.ColumnItem:
+				pinned[expr.ColumnName] = true
+			}
+			return true, expr, nil
+		})
+	}
+	pinFK := func(fk *tree.ForeignKeyConstraintTableDef) {
+		for _, c := range fk.FromCols {
+			pinned[c] = true
+		}
+		for _, c := range fk.ToCols {
+			pinned[c] = true
+		}
+	}
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *tree.CreateTable:
+			for _, def := range stmt.Defs {
+				switch def := def.(type) {
+				case *tree.ColumnTableDef:
+					if def.IsComputed() {
+						pinned[def.Name] = true
+						pinExpr(def.Computed.Expr)
 					}
-				}
-				if !found {
-					continue LoopTable
+				case *tree.ForeignKeyConstraintTableDef:
+					pinFK(def)
 				}
 			}
-			// If we didn't find enough columns, try another table.
-			if len(usingCols) != len(fkCols) {
-				continue
+		case *tree.AlterTable:
+			for _, cmd := range stmt.Cmds {
+				if add, ok := cmd.(*tree.AlterTableAddConstraint); ok {
+					if fk, ok := add.ConstraintDef.(*tree.ForeignKeyConstraintTableDef); ok {
+						pinFK(fk)
+					}
+				}
 			}
+		}
+	}
+	return pinned
+}
 
-			// Found a suitable table.
-			// TODO(mjibson): prevent the creation of unneeded
-			// unique indexes. One may already exist with the
-			// correct prefix.
-			ref := byName[refTable]
-			refColumns := make(tree.IndexElemList, len(usingCols))
-			for i, c := range usingCols {
-				refColumns[i].Column = c.Name
+// fixupIndexPredicates calls fix for every comparison between a column and a
+// datum or a string constant in the partial index predicates of stmts,
+// replacing the datum with the returned expression. If fix returns nil, the
+// comparison is left unchanged.
+func fixupIndexPredicates(
+	stmts []tree.Statement, fix func(col tree.Name, d tree.Datum) tree.Expr,
+) {
+	fixPredicate := func(pred tree.Expr) tree.Expr {
+		if pred == nil {
+			return nil
+		}
+		newPred, _ := tree.SimpleVisit(pred, func(expr tree.Expr) (bool, tree.Expr, error) {
+			cmp, ok := expr.(*tree.ComparisonExpr)
+			if !ok {
+				return true, expr, nil
 			}
-			for _, c := range fkCols {
-				usedCols[table.Table][c.Name] = true
+			var colName tree.Name
+			switch left := cmp.Left.(type) {
+			case *tree.ColumnItem:
+				colName = left.ColumnName
+			case *tree.UnresolvedName:
+				colName = tree.Name(left.Parts[0])
+			default:
+				return true, expr, nil
 			}
-			dependsOn[table.Table][ref.Table] = true
-			ref.Defs = append(ref.Defs, &tree.UniqueConstraintTableDef{
-				IndexTableDef: tree.IndexTableDef{
-					Columns: refColumns,
-				},
-			})
+			var d tree.Datum
+			switch right := cmp.Right.(type) {
+			case tree.Datum:
+				d = right
+			case *tree.StrVal:
+				// The string constants of parsed statements are not type
+				// checked, so they are passed to fix as strings.
+				d = tree.NewDString(right.RawString())
+			default:
+				return true, expr, nil
+			}
+			right := fix(colName, d)
+			if right == nil {
+				return true, expr, nil
+			}
+			newCmp := *cmp
+			newCmp.Right = right
+			return false, &newCmp, nil
+		})
+		return newPred
+	}
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *tree.CreateTable:
+			for _, def := range stmt.Defs {
+				switch def := def.(type) {
+				case *tree.IndexTableDef:
+					def.Predicate = fixPredicate(def.Predicate)
+				case *tree.UniqueConstraintTableDef:
+					def.Predicate = fixPredicate(def.Predicate)
+				}
+			}
+		case *tree.CreateIndex:
+			stmt.Predicate = fixPredicate(stmt.Predicate)
+		}
+	}
+}
 
-			match := tree.MatchSimple
-			// TODO(mjibson): Set match once #42498 is fixed.
-			var actions tree.ReferenceActions
-			if rng.Intn(2) == 0 {
-				actions.Delete = randAction(rng, table)
+// collationLocales are the locales used by collatedStringMutator.
+var collationLocales = [...]string{"da", "de", "en", "en_US", "es", "fr", "sv", "tr", "ja", "zh"}
+
+// collatedStringMutator is a MultiStatementMutation implementation which
+// changes the type of random STRING columns in CREATE TABLE statements to
+// collated strings. Partial index predicates that compare a retyped column to
+// a string constant are fixed up to compare against a collated string with
+// the same locale.
+func collatedStringMutator(
+	rng *rand.Rand, stmts []tree.Statement,
+) (mutated []tree.Statement, changed bool) {
+	pinned := typeChangePinnedColumns(stmts)
+
+	// Retype some STRING columns, keeping track of the locale chosen for each.
+	locales := map[tree.Name]string{}
+	for _, stmt := range stmts {
+		create, ok := stmt.(*tree.CreateTable)
+		if !ok {
+			continue
+		}
+		for _, def := range create.Defs {
+			col, ok := def.(*tree.ColumnTableDef)
+			if !ok || pinned[col.Name] || col.DefaultExpr.Expr != nil {
+				continue
 			}
+			colType := tree.MustBeStaticallyKnownType(col.Type)
+			if colType.Family() != types.StringFamily {
+				continue
+			}
+			// Retype 50% of the eligible columns.
 			if rng.Intn(2) == 0 {
-				actions.Update = randAction(rng, table)
+				continue
 			}
-			stmts = append(stmts, &tree.AlterTable{
-				Table: table.Table.ToUnresolvedObjectName(),
-				Cmds: tree.AlterTableCmds{&tree.AlterTableAddConstraint{
-					ConstraintDef: &tree.ForeignKeyConstraintTableDef{
-						Table:    ref.Table,
-						FromCols: toNames(fkCols),
-						ToCols:   toNames(usingCols),
-						Actions:  actions,
-						Match:    match,
-					},
-				}},
-			})
+			locale := collationLocales[rng.Intn(len(collationLocales))]
+			col.Type = types.MakeCollatedString(colType, locale)
+			locales[col.Name] = locale
 			changed = true
-			break
 		}
 	}
+	if !changed {
+		return stmts, false
+	}
 
-	return stmts, changed
+	// Fix up partial index predicates that reference retyped columns.
+	var env tree.CollationEnvironment
+	fixupIndexPredicates(stmts, func(col tree.Name, d tree.Datum) tree.Expr {
+		locale, ok := locales[col]
+		if !ok {
+			return nil
+		}
+		str, ok := d.(*tree.DString)
+		if !ok {
+			return nil
+		}
+		collated, err := tree.NewDCollatedString(string(*str), locale, &env)
+		if err != nil {
+			panic(err)
+		}
+		return collated
+	})
+	return stmts, true
 }
 
-func randAction(rng *rand.Rand, table *tree.CreateTable) tree.ReferenceAction {
-	const highestAction = tree.Cascade
-	// Find a valid action. Depending on the random action chosen, we have
-	// to verify some validity conditions.
-Loop:
-	for {
-		action := tree.ReferenceAction(rng.Intn(int(highestAction + 1)))
-		for _, def := range table.Defs {
-			col, ok := def.(*tree.ColumnTableDef)
-			if !ok {
-				continue
-			}
-			switch action {
-			case tree.SetNull:
-				if col.Nullable.Nullability == tree.NotNull {
-					continue Loop
+// enumMutator is a MultiStatementMutation implementation which adds random
+// CREATE TYPE ... AS ENUM statements and changes the type of random STRING
+// columns in CREATE TABLE statements to those enums. Partial index predicates
+// that compare a retyped column to a constant are fixed up to compare against
+// a random label of the enum.
+//
+// Since the retyped columns no longer have statically known types, this
+// mutator should be applied after any mutators that inspect column types.
+func enumMutator(
+	rng *rand.Rand, stmts []tree.Statement,
+) (mutated []tree.Statement, changed bool) {
+	pinned := typeChangePinnedColumns(stmts)
+
+	var candidates []*tree.ColumnTableDef
+	seen := map[*tree.ColumnTableDef]bool{}
+	typeNames := map[string]bool{}
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *tree.CreateType:
+			typeNames[stmt.TypeName.String()] = true
+		case *tree.CreateTable:
+			for _, def := range stmt.Defs {
+				col, ok := def.(*tree.ColumnTableDef)
+				if !ok || seen[col] || pinned[col.Name] || col.DefaultExpr.Expr != nil {
+					continue
 				}
-			case tree.SetDefault:
-				if col.DefaultExpr.Expr == nil && col.Nullable.Nullability == tree.NotNull {
-					continue Loop
+				// Interleaved tables share column definitions with their parents,
+				// so make sure each definition is only considered once.
+				seen[col] = true
+				if typ, ok := col.Type.(*types.T); ok && typ.Family() == types.StringFamily {
+					candidates = append(candidates, col)
 				}
 			}
 		}
-		return action
+	}
+	if len(candidates) == 0 {
+		return stmts, false
+	}
+
+	// Create up to 3 enum types.
+	var enums []*tree.CreateType
+	for i, n := 0, rng.Intn(3)+1; i < n; i++ {
+		name := fmt.Sprintf("rand_enum%d", i)
+		for j := 0; typeNames[name]; j++ {
+			name = fmt.Sprintf("rand_enum%d_%d", i, j)
+		}
+		typeNames[name] = true
+		enums = append(enums, rowenc.RandCreateType(rng, name, "abcdefghijklmnopqrstuvwxyz").(*tree.CreateType))
+	}
+
+	// Retype 50% of the candidate columns.
+	retyped := map[tree.Name]*tree.CreateType{}
+	for _, col := range candidates {
+		if rng.Intn(2) == 0 {
+			continue
+		}
+		enum := enums[rng.Intn(len(enums))]
+		col.Type = enum.TypeName
+		retyped[col.Name] = enum
+	}
+	if len(retyped) == 0 {
+		return stmts, false
+	}
+
+	fixupIndexPredicates(stmts, func(col tree.Name, d tree.Datum) tree.Expr {
+		enum, ok := retyped[col]
+		if !ok {
+			return nil
+		}
+		label := enum.EnumLabels[rng.Intn(len(enum.EnumLabels))]
+		return tree.NewStrVal(string(label))
+	})
+
+	// The types must be created before the tables that use them.
+	mutated = make([]tree.Statement, 0, len(enums)+len(stmts))
+	for _, enum := range enums {
+		mutated = append(mutated, enum)
+	}
+	mutated = append(mutated, stmts...)
+	return mutated, true
+}
+
+// MakeMultiRegionMutator returns a MultiStatementMutation which makes the
+// given database multi-region using the given regions (the first of which
+// becomes the primary region) and changes the locality of random tables in
+// CREATE TABLE statements to GLOBAL, REGIONAL BY TABLE, or REGIONAL BY ROW. The
+// regions must be available in the cluster the statements are executed on.
+func MakeMultiRegionMutator(dbName string, regions []string) MultiStatementMutation {
+	return func(rng *rand.Rand, stmts []tree.Statement) (mutated []tree.Statement, changed bool) {
+		return multiRegionMutator(rng, stmts, tree.Name(dbName), regions)
 	}
 }
 
-var postgresMutatorAtIndex = regexp.MustCompile(`@[\[\]\w]+`)
+func multiRegionMutator(
+	rng *rand.Rand, stmts []tree.Statement, dbName tree.Name, regions []string,
+) (mutated []tree.Statement, changed bool) {
+	if len(regions) == 0 {
+		return stmts, false
+	}
+
+	// Temporary, interleaved, and partitioned tables cannot be made
+	// multi-region, and neither can tables that other tables are interleaved
+	// into.
+	interleaveParents := map[tree.TableName]bool{}
+	for _, stmt := range stmts {
+		if create, ok := stmt.(*tree.CreateTable); ok && create.Interleave != nil {
+			interleaveParents[create.Interleave.Parent] = true
+		}
+	}
+	for _, stmt := range stmts {
+		create, ok := stmt.(*tree.CreateTable)
+		if !ok || create.Locality != nil || create.Persistence.IsTemporary() ||
+			create.Interleave != nil || create.PartitionByTable != nil || interleaveParents[create.Table] {
+			continue
+		}
+		// Change the locality of 50% of the tables.
+		if rng.Intn(2) == 0 {
+			continue
+		}
+		switch rng.Intn(3) {
+		case 0:
+			create.Locality = &tree.Locality{LocalityLevel: tree.LocalityLevelGlobal}
+		case 1:
+			create.Locality = &tree.Locality{LocalityLevel: tree.LocalityLevelTable}
+			// Home the table in a non-primary region 50% of the time.
+			if len(regions) > 1 && rng.Intn(2) == 0 {
+				create.Locality.TableRegion = tree.Name(regions[1+rng.Intn(len(regions)-1)])
+			}
+		default:
+			create.Locality = &tree.Locality{LocalityLevel: tree.LocalityLevelRow}
+		}
+		changed = true
+	}
+
+	// The database must be made multi-region before any of the tables are
+	// created. Some of the time do so even if no table locality was changed,
+	// so that the implicit REGIONAL BY TABLE locality is covered too.
+	if !changed && rng.Intn(2) == 0 {
+		return stmts, false
+	}
+	mutated = make([]tree.Statement, 0, len(regions)+len(stmts))
+	mutated = append(mutated, &tree.AlterDatabasePrimaryRegion{
+		Name:          dbName,
+		PrimaryRegion: tree.Name(regions[0]),
+	})
+	for _, region := range regions[1:] {
+		mutated = append(mutated, &tree.AlterDatabaseAddRegion{
+			Name:   dbName,
+			Region: tree.Name(region),
+		})
+	}
+	mutated = append(mutated, stmts...)
+	return mutated, true
+}
 
+// postgresMutator applies postgresStatementMutator to the statements in q and
+// serializes them in the Postgres dialect. q is returned unchanged if it can't
+// be parsed.
 func postgresMutator(rng *rand.Rand, q string) string {
-	q, _ = ApplyString(rng, q, postgresStatementMutator)
+	parsed, err := parser.Parse(q)
+	if err != nil {
+		return q
+	}
+	stmts := make([]tree.Statement, len(parsed))
+	for i, p := range parsed {
+		stmts[i] = p.AST
+	}
+	stmts, _ = postgresStatementMutator(rng, stmts)
 
-	for from, to := range map[string]string{
-		":::":     "::",
-		"STRING":  "TEXT",
-		"BYTES":   "BYTEA",
-		"FLOAT4":  "FLOAT8",
-		"INT2":    "INT8",
-		"INT4":    "INT8",
-		"STORING": "INCLUDE",
-		" AS (":   " GENERATED ALWAYS AS (",
-		",)":      ")",
-	} {
-		q = strings.Replace(q, from, to, -1)
+	var sb strings.Builder
+	for _, s := range stmts {
+		sb.WriteString(tree.AsStringWithFlags(s, tree.FmtSerializable|tree.FmtPostgres))
+		sb.WriteString(";\n")
 	}
-	q = postgresMutatorAtIndex.ReplaceAllString(q, "")
-	return q
+	return sb.String()
 }
 
 // postgresStatementMutator removes cockroach-only things from CREATE TABLE and
@@ -646,8 +2244,14 @@ func postgresMutator(rng *rand.Rand, q string) string {
 var postgresStatementMutator MultiStatementMutation = func(rng *rand.Rand, stmts []tree.Statement) (mutated []tree.Statement, changed bool) {
 	for _, stmt := range stmts {
 		switch stmt := stmt.(type) {
-		case *tree.SetClusterSetting, *tree.SetVar:
+		case *tree.SetClusterSetting:
+			changed = true
 			continue
+		case *tree.SetVar:
+			if !postgresSessionSettings[strings.ToLower(stmt.Name)] {
+				changed = true
+				continue
+			}
 		case *tree.CreateTable:
 			if stmt.Interleave != nil {
 				stmt.Interleave = nil
@@ -713,6 +2317,91 @@ var postgresStatementMutator MultiStatementMutation = func(rng *rand.Rand, stmts
 	return mutated, changed
 }
 
+// mysqlMutator applies mysqlStatementMutator to the statements in q and
+// serializes them in the MySQL dialect. q is returned unchanged if it can't be
+// parsed.
+func mysqlMutator(rng *rand.Rand, q string) string {
+	parsed, err := parser.Parse(q)
+	if err != nil {
+		return q
+	}
+	stmts := make([]tree.Statement, len(parsed))
+	for i, p := range parsed {
+		stmts[i] = p.AST
+	}
+	stmts, _ = mysqlStatementMutator(rng, stmts)
+
+	var sb strings.Builder
+	for _, s := range stmts {
+		// Note that FmtParsable is not used since MySQL doesn't support type
+		// annotations of datums.
+		sb.WriteString(tree.AsStringWithFlags(s, tree.FmtMySQL))
+		sb.WriteString(";\n")
+	}
+	return sb.String()
+}
+
+// mysqlStatementMutator removes cockroach-only things from CREATE TABLE,
+// CREATE INDEX, and ALTER TABLE. Type annotations and casts are handled when
+// the statements are formatted with tree.FmtMySQL.
+var mysqlStatementMutator MultiStatementMutation = func(rng *rand.Rand, stmts []tree.Statement) (mutated []tree.Statement, changed bool) {
+	// Start with the features that Postgres doesn't support either.
+	stmts, changed = postgresStatementMutator(rng, stmts)
+
+	fixIndex := func(def *tree.IndexTableDef) {
+		if def.Storing != nil || def.Predicate != nil || def.Sharded != nil {
+			def.Storing = nil
+			def.Predicate = nil
+			def.Sharded = nil
+			changed = true
+		}
+	}
+
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *tree.CreateTable:
+			if stmt.Locality != nil {
+				stmt.Locality = nil
+				changed = true
+			}
+			for i := 0; i < len(stmt.Defs); i++ {
+				switch def := stmt.Defs[i].(type) {
+				case *tree.ColumnTableDef:
+					if def.PrimaryKey.Sharded {
+						def.PrimaryKey.Sharded = false
+						def.PrimaryKey.ShardBuckets = nil
+						changed = true
+					}
+				case *tree.IndexTableDef:
+					if def.Inverted {
+						// MySQL doesn't have inverted indexes.
+						stmt.Defs = append(stmt.Defs[:i], stmt.Defs[i+1:]...)
+						i--
+						changed = true
+						continue
+					}
+					fixIndex(def)
+				case *tree.UniqueConstraintTableDef:
+					fixIndex(&def.IndexTableDef)
+				}
+			}
+		case *tree.CreateIndex:
+			if stmt.Inverted {
+				changed = true
+				continue
+			}
+			if stmt.Storing != nil || stmt.Predicate != nil || stmt.Sharded != nil {
+				stmt.Storing = nil
+				stmt.Predicate = nil
+				stmt.Sharded = nil
+				changed = true
+			}
+		}
+		mutated = append(mutated, stmt)
+	}
+	return mutated, changed
+}
+
 func postgresCreateTableMutator(
 	rng *rand.Rand, stmts []tree.Statement,
 ) (mutated []tree.Statement, changed bool) {
@@ -754,10 +2443,25 @@ func postgresCreateTableMutator(
 						break
 					}
 					def.Columns = newCols
-					// TODO(rafi): Postgres supports inverted indexes with a different
-					// syntax than Cockroach. Maybe we could add it later.
-					// The syntax is `CREATE INDEX name ON table USING gin(column)`.
-					if !def.Inverted {
+					if def.Inverted {
+						// Postgres supports inverted indexes on JSONB and array columns
+						// as GIN indexes, which PostgresMutator converts the CREATE
+						// INVERTED INDEX statement into. Without the btree_gin extension,
+						// GIN indexes can't have prefix columns, so only the inverted
+						// column (which is the last one) is kept. Inverted indexes on
+						// other types (i.e. spatial types) are dropped.
+						invertedCol := newCols[len(newCols)-1]
+						switch colTypes[string(invertedCol.Column)].Family() {
+						case types.JsonFamily, types.ArrayFamily:
+							mutated = append(mutated, &tree.CreateIndex{
+								Name:     def.Name,
+								Table:    stmt.Table,
+								Inverted: true,
+								Columns:  tree.IndexElemList{invertedCol},
+							})
+						}
+						changed = true
+					} else {
 						mutated = append(mutated, &tree.CreateIndex{
 							Name:     def.Name,
 							Table:    stmt.Table,
@@ -11,12 +11,53 @@
 package mutations
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
 	"strings"
 	"testing"
 
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/stats"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/cockroachdb/errors"
 )
 
+func TestApplyStringStrict(t *testing.T) {
+	rng, _ := randutil.NewPseudoRand()
+	q := `
+		CREATE TABLE t (a INT8);;
+		SELECT 'a;b' FROM t;
+		SELEC a FROM t;
+		SELECT a FROM t;
+	`
+	_, _, err := ApplyStringStrict(rng, q)
+	var parseErr *StatementParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected a StatementParseError, found %v", err)
+	}
+	if parseErr.Index != 2 || parseErr.SQL != "SELEC a FROM t;" {
+		t.Fatalf("unexpected statement %d: %s", parseErr.Index, parseErr.SQL)
+	}
+	// ApplyString returns the input unchanged.
+	if output, changed := ApplyString(rng, q); changed || output != q {
+		t.Fatalf("expected unchanged input, found %s", output)
+	}
+
+	output, _, err := ApplyStringStrict(rng, "SELECT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output != "SELECT 1" {
+		t.Fatalf("unexpected output: %s", output)
+	}
+}
+
 func TestPostgresMutator(t *testing.T) {
 	q := `
 		CREATE TABLE t (s STRING FAMILY fam1, b BYTES, FAMILY fam2 (b), PRIMARY KEY (s ASC, b DESC), INDEX (s) STORING (b))
@@ -51,4 +92,856 @@ func TestPostgresMutator(t *testing.T) {
 			t.Fatalf("unexpected: %s", mutated)
 		}
 	}
+	{
+		// Inverted indexes on JSONB and array columns are converted into GIN
+		// indexes, and other inverted indexes are dropped.
+		q := `
+			CREATE TABLE t (i INT, j JSONB, a INT[], g GEOMETRY,
+				INVERTED INDEX (j), INVERTED INDEX idx (i, a), INVERTED INDEX (g));
+		`
+		mutated, changed := ApplyString(rng, q, PostgresCreateTableMutator, PostgresMutator)
+		if !changed {
+			t.Fatal("expected changed")
+		}
+		mutated = strings.TrimSpace(mutated)
+		expect := "CREATE TABLE t (i INT8, j JSONB, a INT8[], g GEOMETRY);\n" +
+			"CREATE INDEX ON t USING gin (j);\n" +
+			"CREATE INDEX idx ON t USING gin (a);"
+		if mutated != expect {
+			t.Fatalf("unexpected: %s", mutated)
+		}
+	}
+	{
+		// String literals and CTEs are not rewritten.
+		q := `WITH x AS (SELECT 'a:::STRING,)' AS s FROM t@idx WHERE b::INT2 = 1:::INT4) SELECT s FROM x`
+		mutated, changed := ApplyString(rng, q, PostgresMutator)
+		if !changed {
+			t.Fatal("expected changed")
+		}
+		mutated = strings.TrimSpace(mutated)
+		expect := `WITH x AS (SELECT 'a:::STRING,)' AS s FROM t WHERE b::INT8 = 1::INT8) SELECT s FROM x;`
+		if mutated != expect {
+			t.Fatalf("unexpected: %s", mutated)
+		}
+	}
+}
+
+func TestSessionSettingMutator(t *testing.T) {
+	q := `
+		SET extra_float_digits = 3;
+		SELECT 1;
+	`
+	rng, _ := randutil.NewPseudoRand()
+	for i := 0; i < 100; i++ {
+		mutated, changed := ApplyString(rng, q, SessionSettingMutator)
+		if !changed {
+			continue
+		}
+		parsed, err := parser.Parse(mutated)
+		if err != nil {
+			t.Fatalf("error parsing %s: %v", mutated, err)
+		}
+		if len(parsed) <= 2 {
+			t.Fatalf("expected SET statements: %s", mutated)
+		}
+		for _, p := range parsed[:len(parsed)-2] {
+			if _, ok := p.AST.(*tree.SetVar); !ok {
+				t.Fatalf("expected SET statements before the original statements: %s", mutated)
+			}
+		}
+		// Only the SET statements of settings that exist in Postgres are kept
+		// by PostgresMutator.
+		mutated = strings.TrimSpace(postgresMutator(rng, mutated))
+		expect := "SET extra_float_digits = 3;\nSELECT 1;"
+		if mutated != expect {
+			t.Fatalf("unexpected: %s", mutated)
+		}
+		return
+	}
+	t.Fatal("expected a change")
+}
+
+func TestCollatedStringMutator(t *testing.T) {
+	q := `
+		CREATE TABLE t (s STRING, c STRING AS (lower(s)) STORED, INDEX (s) WHERE s > 'foo');
+	`
+
+	rng, _ := randutil.NewPseudoRand()
+	for i := 0; i < 100; i++ {
+		// s is referenced by a computed column, so it should never be retyped.
+		if mutated, changed := ApplyString(rng, q, CollatedStringMutator); changed {
+			t.Fatalf("unexpected change: %s", mutated)
+		}
+	}
+
+	q = `
+		CREATE TABLE t (s STRING, INDEX (s) WHERE s > 'foo');
+	`
+	for i := 0; i < 100; i++ {
+		mutated, changed := ApplyString(rng, q, CollatedStringMutator)
+		if !changed {
+			continue
+		}
+		if !strings.Contains(mutated, "s STRING COLLATE ") {
+			t.Fatalf("expected collated column: %s", mutated)
+		}
+		if !strings.Contains(mutated, "WHERE s > 'foo' COLLATE ") {
+			t.Fatalf("expected collated predicate: %s", mutated)
+		}
+		return
+	}
+	t.Fatal("expected a change")
+}
+
+func TestFixupIndexPredicates(t *testing.T) {
+	q := `CREATE TABLE t (s STRING, INDEX (s) WHERE s > 'foo');
+CREATE INDEX ON t (s) WHERE s = 'bar'`
+	parsed, err := parser.Parse(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmts := []tree.Statement{parsed[0].AST, parsed[1].AST}
+	var fixed []string
+	fixupIndexPredicates(stmts, func(col tree.Name, d tree.Datum) tree.Expr {
+		str, ok := d.(*tree.DString)
+		if !ok {
+			t.Fatalf("expected a string datum, found %T", d)
+		}
+		fixed = append(fixed, col.String()+":"+string(*str))
+		return tree.NewStrVal("baz")
+	})
+	if expected := []string{"s:foo", "s:bar"}; strings.Join(fixed, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected %v to be fixed up, found %v", expected, fixed)
+	}
+	for _, stmt := range stmts {
+		if sql := tree.AsString(stmt); !strings.Contains(sql, "'baz'") {
+			t.Fatalf("expected fixed up predicate: %s", sql)
+		}
+	}
+}
+
+func TestEnumMutator(t *testing.T) {
+	q := `CREATE TABLE t (s STRING, INDEX (s) WHERE s > 'foo');`
+
+	rng, _ := randutil.NewPseudoRand()
+	for i := 0; i < 100; i++ {
+		mutated, changed := ApplyString(rng, q, EnumMutator)
+		if !changed {
+			continue
+		}
+		if !strings.HasPrefix(mutated, "CREATE TYPE rand_enum") {
+			t.Fatalf("expected CREATE TYPE first: %s", mutated)
+		}
+		if !strings.Contains(mutated, "s rand_enum") {
+			t.Fatalf("expected enum column: %s", mutated)
+		}
+		if strings.Contains(mutated, "'foo'") {
+			t.Fatalf("expected predicate to be fixed up: %s", mutated)
+		}
+		return
+	}
+	t.Fatal("expected a change")
+}
+
+func TestForeignKeyMutatorReusesUniqueIndexes(t *testing.T) {
+	rng, _ := randutil.NewPseudoRand()
+
+	// Every column is unique, so no new unique index should be needed.
+	q := `
+		CREATE TABLE p (a INT8 PRIMARY KEY, b STRING, UNIQUE INDEX (b));
+		CREATE TABLE c (x INT8 PRIMARY KEY, y STRING UNIQUE);
+	`
+	found := false
+	for i := 0; i < 100; i++ {
+		mutated, changed := ApplyString(rng, q, ForeignKeyMutator)
+		if !changed {
+			continue
+		}
+		found = true
+		if strings.Count(mutated, "UNIQUE") != 2 {
+			t.Fatalf("unexpected unique index: %s", mutated)
+		}
+	}
+	if !found {
+		t.Fatal("expected a change")
+	}
+
+	// No column of p is unique, so references to p need a new unique index.
+	q = `
+		CREATE TABLE p (a INT8, b INT8);
+		CREATE TABLE c (x INT8 PRIMARY KEY);
+	`
+	for i := 0; i < 100; i++ {
+		mutated, changed := ApplyString(rng, q, ForeignKeyMutator)
+		if !changed || !strings.Contains(mutated, "REFERENCES p") {
+			continue
+		}
+		if !strings.Contains(mutated, "UNIQUE (") {
+			t.Fatalf("expected a unique index: %s", mutated)
+		}
+		return
+	}
+	t.Fatal("expected a reference to p")
+}
+
+func TestForeignKeyMutatorMatch(t *testing.T) {
+	q := `
+		CREATE TABLE p (a INT8 PRIMARY KEY, b INT8, UNIQUE INDEX (a, b));
+		CREATE TABLE c (x INT8 PRIMARY KEY, y INT8, z INT8);
+	`
+	rng, _ := randutil.NewPseudoRand()
+	for i := 0; i < 100; i++ {
+		mutated, _ := ApplyString(rng, q, ForeignKeyMutator)
+		if strings.Contains(mutated, "MATCH") {
+			t.Fatalf("unexpected match type: %s", mutated)
+		}
+	}
+
+	for _, tc := range []struct {
+		opts     ForeignKeyMutatorOptions
+		expected []string
+	}{
+		{
+			opts:     ForeignKeyMutatorOptions{RandomMatch: true},
+			expected: []string{"MATCH FULL"},
+		},
+		{
+			opts:     ForeignKeyMutatorOptions{RandomMatch: true, MatchPartial: true},
+			expected: []string{"MATCH FULL", "MATCH PARTIAL"},
+		},
+	} {
+		mutator := MakeForeignKeyMutator(tc.opts)
+		found := map[string]bool{}
+		for i := 0; i < 1000 && len(found) < len(tc.expected); i++ {
+			mutated, _ := ApplyString(rng, q, mutator)
+			if !tc.opts.MatchPartial && strings.Contains(mutated, "MATCH PARTIAL") {
+				t.Fatalf("unexpected MATCH PARTIAL: %s", mutated)
+			}
+			for _, e := range tc.expected {
+				if strings.Contains(mutated, e) {
+					found[e] = true
+				}
+			}
+		}
+		for _, e := range tc.expected {
+			if !found[e] {
+				t.Errorf("%+v: expected %s", tc.opts, e)
+			}
+		}
+	}
+}
+
+func TestForeignKeyMutatorSelfReference(t *testing.T) {
+	q := `CREATE TABLE t (id INT8 PRIMARY KEY, parent_id INT8);`
+	rng, _ := randutil.NewPseudoRand()
+	for i := 0; i < 100; i++ {
+		if mutated, changed := ApplyString(rng, q, ForeignKeyMutator); changed {
+			t.Fatalf("unexpected change: %s", mutated)
+		}
+	}
+
+	mutator := MakeForeignKeyMutator(ForeignKeyMutatorOptions{SelfReference: true})
+	for i := 0; i < 100; i++ {
+		mutated, changed := ApplyString(rng, q, mutator)
+		if !changed {
+			continue
+		}
+		if !strings.Contains(mutated, "REFERENCES t") {
+			t.Fatalf("expected a self reference: %s", mutated)
+		}
+		stmts, err := parser.Parse(mutated)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hints := ForeignKeyInsertOrder(stmtsFromParsed(stmts))
+		if len(hints) != 1 || len(hints[0].SelfReferences) == 0 {
+			t.Fatalf("expected a self reference hint for %s, found %+v", mutated, hints)
+		}
+		for _, ref := range hints[0].SelfReferences {
+			for _, from := range ref.FromCols {
+				for _, to := range ref.ToCols {
+					if from == to {
+						t.Fatalf("column %s references itself: %s", from, mutated)
+					}
+				}
+			}
+		}
+		return
+	}
+	t.Fatal("expected a change")
+}
+
+func TestForeignKeyMutatorQualifiedNames(t *testing.T) {
+	rng, _ := randutil.NewPseudoRand()
+
+	// t and public.t are the same table, so there is nothing to reference.
+	q := `
+		CREATE TABLE t (a INT8 PRIMARY KEY, b INT8);
+		CREATE TABLE public.t (a INT8 PRIMARY KEY, b INT8);
+	`
+	for i := 0; i < 100; i++ {
+		if mutated, changed := ApplyString(rng, q, ForeignKeyMutator); changed {
+			t.Fatalf("unexpected change: %s", mutated)
+		}
+	}
+
+	// Tables in different schemas can reference each other.
+	q = `
+		CREATE TABLE s1.t (a INT8, b STRING, PRIMARY KEY (a, b));
+		CREATE TABLE s2.t (a INT8, b STRING, PRIMARY KEY (a, b));
+	`
+	found := false
+	for i := 0; i < 100 && !found; i++ {
+		mutated, _ := ApplyString(rng, q, ForeignKeyMutator)
+		found = strings.Contains(mutated, "ALTER TABLE s1.t ADD CONSTRAINT") &&
+			strings.Contains(mutated, "REFERENCES s2.t")
+	}
+	if !found {
+		t.Fatal("expected a cross-schema reference")
+	}
+
+	// Tables in different databases can only reference each other with the
+	// CrossDatabase option.
+	q = `
+		CREATE TABLE d1.public.t (a INT8 PRIMARY KEY, b INT8);
+		CREATE TABLE d2.public.t (a INT8 PRIMARY KEY, b INT8);
+	`
+	for i := 0; i < 100; i++ {
+		if mutated, changed := ApplyString(rng, q, ForeignKeyMutator); changed {
+			t.Fatalf("unexpected change: %s", mutated)
+		}
+	}
+	mutator := MakeForeignKeyMutator(ForeignKeyMutatorOptions{CrossDatabase: true})
+	for i := 0; i < 100; i++ {
+		if _, changed := ApplyString(rng, q, mutator); changed {
+			return
+		}
+	}
+	t.Fatal("expected a cross-database reference")
+}
+
+func TestForeignKeyInsertOrder(t *testing.T) {
+	q := `
+		CREATE TABLE c (x INT8 PRIMARY KEY, p INT8 REFERENCES p (a));
+		CREATE TABLE p (a INT8 PRIMARY KEY, parent INT8 REFERENCES p (a));
+		CREATE TABLE q (y INT8);
+		ALTER TABLE q ADD CONSTRAINT fk FOREIGN KEY (y) REFERENCES c (x);
+		ALTER TABLE q ADD CONSTRAINT ext FOREIGN KEY (y) REFERENCES ext (z);
+		CREATE TABLE s.p (a INT8 PRIMARY KEY);
+		CREATE TABLE r (z INT8 REFERENCES s.p (a));
+	`
+	stmts, err := parser.Parse(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hints := ForeignKeyInsertOrder(stmtsFromParsed(stmts))
+	var order []string
+	for _, h := range hints {
+		deps := make([]string, len(h.DependsOn))
+		for i := range h.DependsOn {
+			deps[i] = h.DependsOn[i].String()
+		}
+		order = append(order, fmt.Sprintf("%s %v %+v", h.Table.String(), deps, h.SelfReferences))
+	}
+	expected := []string{
+		"p [] [{FromCols:[parent] ToCols:[a]}]",
+		"s.p [] []",
+		"r [s.p] []",
+		"c [p] []",
+		"q [c] []",
+	}
+	if a, e := strings.Join(order, "\n"), strings.Join(expected, "\n"); a != e {
+		t.Fatalf("expected:\n%s\nfound:\n%s", e, a)
+	}
+}
+
+func TestStatisticsMutator(t *testing.T) {
+	q := `CREATE TABLE t (a INT, b INT, c INT, d INT, e INT, INDEX (a, b) STORING (e), UNIQUE (c, d));`
+
+	rng, _ := randutil.NewPseudoRand()
+	sawHistogram := map[string]bool{}
+	sawMultiColumn := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		parsed, err := parser.Parse(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stmts, changed := statisticsMutator(rng, []tree.Statement{parsed[0].AST}, randomStats, false /* history */)
+		if !changed {
+			continue
+		}
+		inject := stmts[1].(*tree.AlterTable).Cmds[0].(*tree.AlterTableInjectStats)
+		var jsonStats []stats.JSONStatistic
+		if err := json.Unmarshal([]byte(inject.Stats.(*tree.DJSON).JSON.String()), &jsonStats); err != nil {
+			t.Fatal(err)
+		}
+		for _, stat := range jsonStats {
+			if len(stat.Columns) > 1 {
+				sawMultiColumn[strings.Join(stat.Columns, ",")] = true
+				if stat.HistogramColumnType != "" {
+					t.Fatalf("expected no multi-column histograms: %s", inject.Stats)
+				}
+				continue
+			}
+			if stat.HistogramColumnType == "" {
+				continue
+			}
+			col := stat.Columns[0]
+			if col == "e" {
+				t.Fatalf("expected no histogram on stored column: %s", inject.Stats)
+			}
+			sawHistogram[col] = true
+		}
+	}
+	// Suffix columns of indexes and unique constraints get histograms too.
+	for _, col := range []string{"a", "b", "c", "d"} {
+		if !sawHistogram[col] {
+			t.Errorf("expected a histogram on column %s", col)
+		}
+	}
+	// Multi-column statistics are made for prefixes of indexes.
+	if len(sawMultiColumn) != 2 || !sawMultiColumn["a,b"] || !sawMultiColumn["c,d"] {
+		t.Errorf("expected multi-column statistics on (a, b) and (c, d), found %v", sawMultiColumn)
+	}
+}
+
+func TestStatisticsMutatorUserDefinedTypes(t *testing.T) {
+	q := `CREATE TYPE e AS ENUM ('a', 'b', 'c', 'd');
+CREATE TABLE t (x e PRIMARY KEY, s STRING COLLATE de, INDEX (s));`
+
+	var env tree.CollationEnvironment
+	rng, _ := randutil.NewPseudoRand()
+	for i := 0; i < 100; i++ {
+		parsed, err := parser.Parse(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stmts, _ := statisticsMutator(
+			rng, []tree.Statement{parsed[0].AST, parsed[1].AST}, randomStats, false, /* history */
+		)
+		inject := stmts[2].(*tree.AlterTable).Cmds[0].(*tree.AlterTableInjectStats)
+		var jsonStats []stats.JSONStatistic
+		if err := json.Unmarshal([]byte(inject.Stats.(*tree.DJSON).JSON.String()), &jsonStats); err != nil {
+			t.Fatal(err)
+		}
+		for _, stat := range jsonStats {
+			if len(stat.HistogramBuckets) == 0 {
+				continue
+			}
+			switch stat.Columns[0] {
+			case "x":
+				// The upper bounds must be labels of the enum, in order.
+				if stat.HistogramColumnType != "e" {
+					t.Fatalf("unexpected histogram type %s", stat.HistogramColumnType)
+				}
+				var prev string
+				for _, b := range stat.HistogramBuckets {
+					if b.UpperBound <= prev || !strings.Contains("abcd", b.UpperBound) {
+						t.Fatalf("unexpected enum upper bound %s after %s", b.UpperBound, prev)
+					}
+					prev = b.UpperBound
+				}
+			case "s":
+				// The upper bounds must be collated strings ordered by their
+				// collation keys.
+				var prev []byte
+				for _, b := range stat.HistogramBuckets {
+					expr, err := parser.ParseExpr(b.UpperBound)
+					if err != nil {
+						t.Fatal(err)
+					}
+					collate, ok := expr.(*tree.CollateExpr)
+					if !ok || collate.Locale != "de" {
+						t.Fatalf("unexpected collated string upper bound %s", b.UpperBound)
+					}
+					d, err := tree.NewDCollatedString(
+						collate.Expr.(*tree.StrVal).RawString(), collate.Locale, &env,
+					)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if prev != nil && bytes.Compare(d.Key, prev) <= 0 {
+						t.Fatalf("collated string upper bound %s is out of order", b.UpperBound)
+					}
+					prev = d.Key
+				}
+			}
+		}
+	}
+
+	// Tuple histograms must not panic, and their upper bounds must be valid
+	// expressions.
+	h, upperBounds := randHistogram(rng, types.MakeTuple([]*types.T{types.Int, types.String}), randomStats)
+	if len(h.Buckets) != len(upperBounds) {
+		t.Fatalf("expected %d upper bounds, found %d", len(h.Buckets), len(upperBounds))
+	}
+	for _, upper := range upperBounds {
+		if _, err := parser.ParseExpr(upper); err != nil {
+			t.Fatalf("error parsing tuple upper bound %s: %v", upper, err)
+		}
+	}
+}
+
+func TestConsistentStatisticsMutator(t *testing.T) {
+	q := `CREATE TABLE p (a INT PRIMARY KEY, b INT UNIQUE, c STRING, INDEX (c, b));
+CREATE TABLE c (x INT REFERENCES p (b), y INT, z STRING, INDEX (x, y), INDEX (z));`
+
+	rng, _ := randutil.NewPseudoRand()
+	for i := 0; i < 100; i++ {
+		parsed, err := parser.Parse(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stmts, _ := statisticsMutator(rng, []tree.Statement{parsed[0].AST, parsed[1].AST}, consistentStats, false /* history */)
+		tableStats := map[string][]stats.JSONStatistic{}
+		for _, stmt := range stmts {
+			alter, ok := stmt.(*tree.AlterTable)
+			if !ok {
+				continue
+			}
+			inject := alter.Cmds[0].(*tree.AlterTableInjectStats)
+			var jsonStats []stats.JSONStatistic
+			if err := json.Unmarshal([]byte(inject.Stats.(*tree.DJSON).JSON.String()), &jsonStats); err != nil {
+				t.Fatal(err)
+			}
+			tableStats[alter.Table.String()] = jsonStats
+		}
+
+		for table, jsonStats := range tableStats {
+			colNulls := map[string]uint64{}
+			for _, stat := range jsonStats {
+				if len(stat.Columns) == 1 {
+					colNulls[stat.Columns[0]] = stat.NullCount
+				}
+			}
+			for _, stat := range jsonStats {
+				cols := strings.Join(stat.Columns, ",")
+				// A row is NULL in multi-column statistics only if all of the
+				// columns are NULL.
+				for _, col := range stat.Columns {
+					if n, ok := colNulls[col]; ok && stat.NullCount > n {
+						t.Fatalf("%s(%s): null count %d exceeds null count %d of column %s",
+							table, cols, stat.NullCount, n, col)
+					}
+				}
+				if stat.NullCount+stat.DistinctCount > stat.RowCount {
+					t.Fatalf("%s(%s): null count %d and distinct count %d exceed row count %d",
+						table, cols, stat.NullCount, stat.DistinctCount, stat.RowCount)
+				}
+				if len(stat.HistogramBuckets) == 0 {
+					continue
+				}
+				var numRows uint64
+				var distinct float64
+				for _, b := range stat.HistogramBuckets {
+					numRows += uint64(b.NumEq + b.NumRange)
+					distinct += b.DistinctRange
+					if b.NumEq > 0 {
+						distinct++
+					}
+				}
+				if numRows != stat.RowCount-stat.NullCount {
+					t.Fatalf("%s(%s): histogram has %d rows, expected %d",
+						table, cols, numRows, stat.RowCount-stat.NullCount)
+				}
+				// DistinctRange is a float, so allow for rounding errors.
+				if expected := float64(stat.DistinctCount); math.Abs(distinct-expected) > 1e-9*expected {
+					t.Fatalf("%s(%s): histogram has %f distinct values, expected %d",
+						table, cols, distinct, stat.DistinctCount)
+				}
+			}
+		}
+
+		// The referenced column has no NULLs, and the referencing column has
+		// at most as many distinct values as the referenced table has rows.
+		var parentRows uint64
+		for _, stat := range tableStats["p"] {
+			parentRows = stat.RowCount
+			if len(stat.Columns) == 1 && stat.Columns[0] == "b" && stat.NullCount != 0 {
+				t.Fatalf("expected no NULLs in referenced column: %+v", stat)
+			}
+		}
+		for _, stat := range tableStats["c"] {
+			if len(stat.Columns) == 1 && stat.Columns[0] == "x" && stat.DistinctCount > parentRows {
+				t.Fatalf("expected at most %d distinct values in referencing column: %+v", parentRows, stat)
+			}
+		}
+	}
+}
+
+func TestStatisticsHistoryMutator(t *testing.T) {
+	q := `CREATE TABLE t (a INT PRIMARY KEY, b INT, c STRING, INDEX (b, c))`
+
+	rng, _ := randutil.NewPseudoRand()
+	for i := 0; i < 100; i++ {
+		parsed, err := parser.ParseOne(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stmts, changed := statisticsMutator(rng, []tree.Statement{parsed.AST}, randomStats, true /* history */)
+		if !changed {
+			t.Fatal("expected changed")
+		}
+		inject := stmts[1].(*tree.AlterTable).Cmds[0].(*tree.AlterTableInjectStats)
+		var jsonStats []stats.JSONStatistic
+		if err := json.Unmarshal([]byte(inject.Stats.(*tree.DJSON).JSON.String()), &jsonStats); err != nil {
+			t.Fatal(err)
+		}
+
+		// Every column set must have one statistic per collection, with
+		// distinct creation times, and the latest collection must be the
+		// first one generated.
+		createdAt := map[string]map[string]bool{}
+		for _, stat := range jsonStats {
+			cols := strings.Join(stat.Columns, ",")
+			if createdAt[cols] == nil {
+				createdAt[cols] = map[string]bool{}
+				if stat.CreatedAt != "2000-01-01 00:00:00+00:00" {
+					t.Fatalf("%s: expected the latest statistic first, found %s", cols, stat.CreatedAt)
+				}
+			}
+			if createdAt[cols][stat.CreatedAt] {
+				t.Fatalf("%s: duplicate creation time %s", cols, stat.CreatedAt)
+			}
+			createdAt[cols][stat.CreatedAt] = true
+		}
+		numCollections := len(createdAt["a"])
+		if numCollections < 1 || numCollections > maxStatsHistory+1 {
+			t.Fatalf("unexpected number of collections %d", numCollections)
+		}
+		for cols, times := range createdAt {
+			if len(times) != numCollections {
+				t.Fatalf("%s: expected %d collections, found %d", cols, numCollections, len(times))
+			}
+		}
+	}
+}
+
+func TestDataStatisticsMutator(t *testing.T) {
+	q := `CREATE TABLE t (a INT PRIMARY KEY, b STRING, c INT AS (a + 1) STORED, d INT DEFAULT 5, INDEX (b, a));
+INSERT INTO t (a, b) VALUES (1, 'x'), (2, 'x'), (3, NULL);
+INSERT INTO t VALUES (4, 'y', 7);
+CREATE TABLE u (x INT);
+INSERT INTO u VALUES (1);
+DELETE FROM u WHERE x = 1;`
+
+	parsed, err := parser.Parse(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmts := make([]tree.Statement, len(parsed))
+	for i := range parsed {
+		stmts[i] = parsed[i].AST
+	}
+	mutator := MakeDataStatisticsMutator(map[string][]tree.Datums{
+		"t": {{tree.NewDInt(5), tree.NewDString("z"), tree.DNull, tree.DNull}},
+	})
+	rng, _ := randutil.NewPseudoRand()
+	stmts, changed := mutator(rng, stmts)
+	if !changed {
+		t.Fatal("expected changed")
+	}
+
+	var injected []string
+	actual := map[string]stats.JSONStatistic{}
+	for _, stmt := range stmts[len(parsed):] {
+		alter := stmt.(*tree.AlterTable)
+		injected = append(injected, alter.Table.String())
+		inject := alter.Cmds[0].(*tree.AlterTableInjectStats)
+		var jsonStats []stats.JSONStatistic
+		if err := json.Unmarshal([]byte(inject.Stats.(*tree.DJSON).JSON.String()), &jsonStats); err != nil {
+			t.Fatal(err)
+		}
+		for _, stat := range jsonStats {
+			actual[strings.Join(stat.Columns, ",")] = stat
+		}
+	}
+	// Table u is modified by a DELETE, so its data is not known.
+	if len(injected) != 1 || injected[0] != "t" {
+		t.Fatalf("expected statistics for table t only, found %v", injected)
+	}
+
+	expected := map[string]struct {
+		distinctCount, nullCount uint64
+		histogram                bool
+	}{
+		"a":   {distinctCount: 5, histogram: true},
+		"b":   {distinctCount: 4, nullCount: 1, histogram: true},
+		"b,a": {distinctCount: 5},
+	}
+	if len(actual) != len(expected) {
+		t.Fatalf("expected statistics for %d column sets, found %d: %+v", len(expected), len(actual), actual)
+	}
+	for cols, e := range expected {
+		stat, ok := actual[cols]
+		if !ok {
+			t.Fatalf("expected statistics for %s", cols)
+		}
+		if stat.RowCount != 5 || stat.DistinctCount != e.distinctCount || stat.NullCount != e.nullCount {
+			t.Fatalf("%s: unexpected counts: %+v", cols, stat)
+		}
+		if !e.histogram {
+			continue
+		}
+		var numRows int64
+		for _, b := range stat.HistogramBuckets {
+			numRows += b.NumEq + b.NumRange
+		}
+		if numRows != int64(stat.RowCount-stat.NullCount) {
+			t.Fatalf("%s: histogram has %d rows, expected %d", cols, numRows, stat.RowCount-stat.NullCount)
+		}
+	}
+}
+
+func TestChangefeedExportMutator(t *testing.T) {
+	q := `CREATE TABLE t (i INT PRIMARY KEY, s STRING);
+CREATE TABLE f (i INT PRIMARY KEY, s STRING, FAMILY (i), FAMILY (s));`
+
+	rng, _ := randutil.NewPseudoRand()
+	var sawChangefeed, sawExport bool
+	for i := 0; i < 100; i++ {
+		mutated, changed := ApplyString(rng, q, ChangefeedExportMutator)
+		if !changed {
+			continue
+		}
+		parsed, err := parser.Parse(mutated)
+		if err != nil {
+			t.Fatalf("%s: %v", mutated, err)
+		}
+		var hasChangefeed bool
+		for _, p := range parsed {
+			switch stmt := p.AST.(type) {
+			case *tree.CreateChangefeed:
+				hasChangefeed = true
+				if stmt.SinkURI != nil {
+					t.Fatalf("expected sinkless changefeed: %s", mutated)
+				}
+				if name := stmt.Targets.Tables[0].String(); name != "t" {
+					t.Fatalf("expected no changefeed on table %s with multiple families: %s", name, mutated)
+				}
+				opts := map[tree.Name]bool{}
+				for _, opt := range stmt.Options {
+					opts[opt.Key] = true
+				}
+				if opts["initial_scan"] && opts["no_initial_scan"] {
+					t.Fatalf("expected mutually exclusive options: %s", mutated)
+				}
+			case *tree.Export:
+				sawExport = true
+				if stmt.FileFormat != "CSV" {
+					t.Fatalf("expected CSV export: %s", mutated)
+				}
+			}
+		}
+		if hasChangefeed {
+			sawChangefeed = true
+			if !strings.HasPrefix(mutated, "SET CLUSTER SETTING \"kv.rangefeed.enabled\" = true;\n") {
+				t.Fatalf("expected rangefeeds to be enabled first: %s", mutated)
+			}
+		}
+	}
+	if !sawChangefeed || !sawExport {
+		t.Fatalf("expected changefeeds and exports, saw changefeed: %t, saw export: %t",
+			sawChangefeed, sawExport)
+	}
+}
+
+func TestMultiRegionMutator(t *testing.T) {
+	q := `CREATE TABLE p (i INT PRIMARY KEY);
+CREATE TABLE c (i INT, j INT, PRIMARY KEY (i, j)) INTERLEAVE IN PARENT p (i);
+CREATE TABLE t (i INT PRIMARY KEY);`
+
+	mutator := MakeMultiRegionMutator("defaultdb", []string{"us-east1", "us-west1"})
+	rng, _ := randutil.NewPseudoRand()
+	for i := 0; i < 100; i++ {
+		mutated, changed := ApplyString(rng, q, mutator)
+		if !changed {
+			continue
+		}
+		if !strings.HasPrefix(mutated,
+			"ALTER DATABASE defaultdb PRIMARY REGION \"us-east1\";\n"+
+				"ALTER DATABASE defaultdb ADD REGION \"us-west1\";\n") {
+			t.Fatalf("expected database to be made multi-region first: %s", mutated)
+		}
+		if strings.Count(mutated, "LOCALITY") > 1 {
+			t.Fatalf("expected only non-interleaved tables to change locality: %s", mutated)
+		}
+	}
+}
+
+func TestMySQLMutator(t *testing.T) {
+	q := `
+		CREATE TABLE t (
+			i SERIAL8 PRIMARY KEY,
+			s STRING FAMILY fam1,
+			b BYTES DEFAULT 'x':::BYTES,
+			j JSONB,
+			FAMILY fam2 (b, j),
+			INDEX (s) STORING (b) WHERE s > 'a',
+			INVERTED INDEX (j)
+		);
+		CREATE INDEX ON t (b) STORING (s);
+		SELECT i::STRING FROM t@primary;
+		SET CLUSTER SETTING "sql.stats.automatic_collection.enabled" = false;
+	`
+
+	rng, _ := randutil.NewPseudoRand()
+	mutated, changed := ApplyString(rng, q, MySQLMutator)
+	if !changed {
+		t.Fatal("expected changed")
+	}
+	mutated = strings.TrimSpace(mutated)
+	expect := "CREATE TABLE t (i BIGINT AUTO_INCREMENT PRIMARY KEY, s TEXT, b BLOB DEFAULT 'x', j JSON, INDEX (s));\n" +
+		"CREATE INDEX ON t (b);\n" +
+		"SELECT CAST(i AS CHAR) FROM t;"
+	if mutated != expect {
+		t.Fatalf("unexpected: %s", mutated)
+	}
+
+	// String literals and CTEs are not rewritten, and identifiers are quoted
+	// with backticks.
+	q = `WITH x AS (SELECT 'a AS (b)::STRING' AS "my col" FROM t) SELECT "my col"::INT2 FROM x`
+	mutated, changed = ApplyString(rng, q, MySQLMutator)
+	if !changed {
+		t.Fatal("expected changed")
+	}
+	mutated = strings.TrimSpace(mutated)
+	expect = "WITH x AS (SELECT 'a AS (b)::STRING' AS `my col` FROM t) SELECT CAST(`my col` AS SIGNED) FROM x;"
+	if mutated != expect {
+		t.Fatalf("unexpected: %s", mutated)
+	}
+}
+
+func TestForVersion(t *testing.T) {
+	mutators := []rowenc.Mutator{
+		StatisticsMutator,
+		ForeignKeyMutator,
+		PartialIndexMutator,
+		EnumMutator,
+		PostgresMutator,
+	}
+
+	older := ForVersion(clusterversion.ByKey(clusterversion.V20_2), mutators...)
+	if len(older) != len(mutators)-1 {
+		t.Fatalf("expected %d mutators, found %d", len(mutators)-1, len(older))
+	}
+	for _, m := range older {
+		if vm, ok := m.(VersionedMutator); ok && vm.MinVersion == clusterversion.Start21_1 {
+			t.Fatalf("unexpected mutator with minimum version %s", vm.MinVersion)
+		}
+	}
+
+	newer := ForVersion(clusterversion.ByKey(clusterversion.Start21_1), mutators...)
+	if len(newer) != len(mutators) {
+		t.Fatalf("expected %d mutators, found %d", len(mutators), len(newer))
+	}
+}
+
+// stmtsFromParsed returns the ASTs of the parsed statements.
+func stmtsFromParsed(parsed parser.Statements) []tree.Statement {
+	stmts := make([]tree.Statement, len(parsed))
+	for i, p := range parsed {
+		stmts[i] = p.AST
+	}
+	return stmts
 }
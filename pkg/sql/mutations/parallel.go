@@ -0,0 +1,158 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"math/rand"
+	"sync"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+)
+
+// ApplyParallel is like Apply, but it partitions stmts into groups of
+// independent statements, which don't refer to the objects created by the
+// statements of other groups, and runs the mutators on the groups on up to
+// parallelism goroutines. Every group is mutated with a random number
+// generator of its own, seeded with a seed drawn from rng, so the result only
+// depends on rng and not on the scheduling of the goroutines. The mutated
+// groups are returned in the order of their first statements.
+//
+// Since the mutators only see the statements of a group, mutators that add
+// statements for the whole batch, like SessionSettingMutator and
+// MultiRegionMutator, must be run with Apply. If stmts contain statements
+// that affect all following statements, like SET and BEGIN, the mutators are
+// run by Apply as well.
+func ApplyParallel(
+	rng *rand.Rand, parallelism int, stmts []tree.Statement, mutators ...rowenc.Mutator,
+) (mutated []tree.Statement, changed bool) {
+	groups := independentStatementGroups(stmts)
+	if len(groups) <= 1 || parallelism <= 1 {
+		return Apply(rng, stmts, mutators...)
+	}
+
+	type result struct {
+		stmts   []tree.Statement
+		changed bool
+	}
+	results := make([]result, len(groups))
+	seeds := make([]int64, len(groups))
+	for i := range seeds {
+		seeds[i] = rng.Int63()
+	}
+	work := make(chan int, len(groups))
+	for i := range groups {
+		work <- i
+	}
+	close(work)
+	var wg sync.WaitGroup
+	if parallelism > len(groups) {
+		parallelism = len(groups)
+	}
+	for w := 0; w < parallelism; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				r := &results[i]
+				r.stmts, r.changed = Apply(rand.New(rand.NewSource(seeds[i])), groups[i], mutators...)
+			}
+		}()
+	}
+	wg.Wait()
+
+	mutated = make([]tree.Statement, 0, len(stmts))
+	for _, r := range results {
+		mutated = append(mutated, r.stmts...)
+		changed = changed || r.changed
+	}
+	return mutated, changed
+}
+
+// independentStatementGroups partitions stmts into groups of statements that
+// refer to the same objects created by stmts, in their original order. It
+// returns a single group if a statement affects all following statements.
+// References are found by comparing the tokens of the statements with the
+// names of the objects, which is conservative: statements that mention the
+// name of an object in another way, like an alias or a string, are grouped
+// with the statements of the object.
+func independentStatementGroups(stmts []tree.Statement) [][]tree.Statement {
+	names := map[string]bool{}
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *tree.SetVar, *tree.SetClusterSetting, *tree.SetTransaction,
+			*tree.BeginTransaction, *tree.CommitTransaction, *tree.RollbackTransaction,
+			*tree.Savepoint, *tree.ReleaseSavepoint, *tree.RollbackToSavepoint:
+			return [][]tree.Statement{stmts}
+		case *tree.CreateTable:
+			names[string(stmt.Table.ObjectName)] = true
+		case *tree.CreateView:
+			names[string(stmt.Name.ObjectName)] = true
+		case *tree.CreateSequence:
+			names[string(stmt.Name.ObjectName)] = true
+		case *tree.CreateType:
+			names[stmt.TypeName.Object()] = true
+		case *tree.CreateDatabase:
+			names[string(stmt.Name)] = true
+		case *tree.CreateSchema:
+			names[string(stmt.Schema.SchemaName)] = true
+		}
+	}
+
+	// parent is the union-find forest of the statements.
+	parent := make([]int, len(stmts))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	// owner contains the first statement that refers to every name.
+	owner := map[string]int{}
+	for i, stmt := range stmts {
+		tokens, _ := parser.Tokens(tree.Serialize(stmt))
+		for _, tok := range tokens {
+			if !names[tok.Str] {
+				continue
+			}
+			if j, ok := owner[tok.Str]; ok {
+				// Join the groups, keeping the earlier statement as the root
+				// so that the groups are ordered by their first statements.
+				a, b := find(i), find(j)
+				if a > b {
+					a, b = b, a
+				}
+				parent[b] = a
+			} else {
+				owner[tok.Str] = i
+			}
+		}
+	}
+
+	var groups [][]tree.Statement
+	index := map[int]int{}
+	for i, stmt := range stmts {
+		root := find(i)
+		g, ok := index[root]
+		if !ok {
+			g = len(groups)
+			index[root] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], stmt)
+	}
+	return groups
+}
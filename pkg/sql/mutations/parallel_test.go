@@ -0,0 +1,106 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+)
+
+func TestIndependentStatementGroups(t *testing.T) {
+	for _, tc := range []struct {
+		q        string
+		expected [][]string
+	}{
+		{
+			q: `
+				CREATE TABLE a (k INT8 PRIMARY KEY);
+				CREATE TABLE b (k INT8 PRIMARY KEY);
+				INSERT INTO a VALUES (1);
+				CREATE TABLE c (k INT8 PRIMARY KEY REFERENCES b (k));
+				SELECT 1;
+				SELECT * FROM c;
+			`,
+			expected: [][]string{
+				{"CREATE TABLE a (k INT8 PRIMARY KEY)", "INSERT INTO a VALUES (1)"},
+				{"CREATE TABLE b (k INT8 PRIMARY KEY)", "CREATE TABLE c (k INT8 PRIMARY KEY REFERENCES b (k))", "SELECT * FROM c"},
+				{"SELECT 1"},
+			},
+		},
+		{
+			// SET affects all statements.
+			q: `
+				CREATE TABLE a (k INT8 PRIMARY KEY);
+				SET vectorize = off;
+				CREATE TABLE b (k INT8 PRIMARY KEY);
+			`,
+			expected: [][]string{
+				{"CREATE TABLE a (k INT8 PRIMARY KEY)", "SET vectorize = off", "CREATE TABLE b (k INT8 PRIMARY KEY)"},
+			},
+		},
+	} {
+		parsed, err := parser.Parse(tc.q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		groups := independentStatementGroups(stmtsFromParsed(parsed))
+		var actual [][]string
+		for _, g := range groups {
+			var sqls []string
+			for _, stmt := range g {
+				sqls = append(sqls, tree.AsString(stmt))
+			}
+			actual = append(actual, sqls)
+		}
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("expected %v, found %v", tc.expected, actual)
+		}
+	}
+}
+
+func TestApplyParallel(t *testing.T) {
+	q := `
+		CREATE TABLE a (k INT8 PRIMARY KEY, v STRING);
+		CREATE TABLE b (k INT8 PRIMARY KEY, v STRING);
+		CREATE TABLE c (k INT8 PRIMARY KEY, v STRING);
+		CREATE TABLE d (k INT8 PRIMARY KEY, v STRING);
+		INSERT INTO a VALUES (1, 'a');
+	`
+	mutators := []rowenc.Mutator{ColumnFamilyMutator, StatisticsMutator, IndexStoringMutator}
+	var expected []string
+	for i := 0; i < 10; i++ {
+		// The result only depends on the seed, not on the parallelism.
+		parallelism := 2 + i%4
+		// The mutators modify the statements, so they are parsed again.
+		parsed, err := parser.Parse(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stmts := stmtsFromParsed(parsed)
+		mutated, _ := ApplyParallel(rand.New(rand.NewSource(1)), parallelism, stmts, mutators...)
+		sqls := serializeStatements(mutated)
+		for _, sql := range sqls {
+			if _, err := parser.ParseOne(sql); err != nil {
+				t.Fatalf("error parsing %s: %v", sql, err)
+			}
+		}
+		if expected == nil {
+			expected = sqls
+		} else if !reflect.DeepEqual(expected, sqls) {
+			t.Fatalf("expected %v, found %v", expected, sqls)
+		}
+	}
+}
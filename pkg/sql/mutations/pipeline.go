@@ -0,0 +1,232 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"io/ioutil"
+	"math/rand"
+
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// PipelineConfig is the declarative configuration of a mutator pipeline. It
+// is loaded from YAML or JSON by LoadPipeline, for example:
+//
+//   mutators:
+//   - name: ColumnFamilyMutator
+//   - name: ForeignKeyMutator
+//     probability: 0.5
+//     options:
+//       random_match: true
+//       self_reference: true
+//   - name: PostgresMutator
+//
+type PipelineConfig struct {
+	Mutators []MutatorConfig `yaml:"mutators"`
+}
+
+// MutatorConfig configures a mutator of a pipeline.
+type MutatorConfig struct {
+	// Name is the name of the mutator, which is the name of the exported
+	// variable of this package that holds it, like ForeignKeyMutator.
+	Name string `yaml:"name"`
+	// Probability is the probability with which the mutator runs. If it is
+	// not set, the mutator always runs.
+	Probability *float64 `yaml:"probability"`
+	// Options are the options of mutators that take options, like
+	// ForeignKeyMutator, MultiRegionMutator, RegexpRewriteMutator and
+	// ZoneConfigMutator.
+	Options map[string]interface{} `yaml:"options"`
+}
+
+// MultiRegionMutatorOptions are the options of the MultiRegionMutator of a
+// pipeline, which is built by MakeMultiRegionMutator.
+type MultiRegionMutatorOptions struct {
+	Database string   `yaml:"database"`
+	Regions  []string `yaml:"regions"`
+}
+
+// ZoneConfigMutatorOptions are the options of the ZoneConfigMutator of a
+// pipeline, which is built by MakeZoneConfigMutator. Localities are in the
+// format of the --locality flag, like region=us-east1,zone=us-east1-b.
+type ZoneConfigMutatorOptions struct {
+	Localities []string `yaml:"localities"`
+}
+
+// mutatorConstructors contains the mutators that can be configured by name.
+// decode decodes the options of the configuration into its argument; mutators
+// without options only check that there are none.
+var mutatorConstructors = map[string]func(decode func(interface{}) error) (rowenc.Mutator, error){
+	"ForeignKeyMutator": func(decode func(interface{}) error) (rowenc.Mutator, error) {
+		var opts ForeignKeyMutatorOptions
+		if err := decode(&opts); err != nil {
+			return nil, err
+		}
+		return VersionedMutator{MakeForeignKeyMutator(opts), clusterversion.V20_2}, nil
+	},
+	"MultiRegionMutator": func(decode func(interface{}) error) (rowenc.Mutator, error) {
+		var opts MultiRegionMutatorOptions
+		if err := decode(&opts); err != nil {
+			return nil, err
+		}
+		if opts.Database == "" || len(opts.Regions) == 0 {
+			return nil, errors.New("database and regions are required")
+		}
+		return MakeMultiRegionMutator(opts.Database, opts.Regions), nil
+	},
+	"RegexpRewriteMutator": func(decode func(interface{}) error) (rowenc.Mutator, error) {
+		var opts RegexpRewriteMutatorOptions
+		if err := decode(&opts); err != nil {
+			return nil, err
+		}
+		if len(opts.Rules) == 0 {
+			return nil, errors.New("rules are required")
+		}
+		return MakeRegexpRewriteMutator(opts.Rules...)
+	},
+	"ZoneConfigMutator": func(decode func(interface{}) error) (rowenc.Mutator, error) {
+		var opts ZoneConfigMutatorOptions
+		if err := decode(&opts); err != nil {
+			return nil, err
+		}
+		localities := make([]roachpb.Locality, len(opts.Localities))
+		for i, l := range opts.Localities {
+			if err := localities[i].Set(l); err != nil {
+				return nil, err
+			}
+		}
+		return MakeZoneConfigMutator(localities), nil
+	},
+}
+
+// namedMutators contains the mutators without options that can be configured
+// by name.
+var namedMutators = map[string]rowenc.Mutator{
+	"StatisticsMutator":           StatisticsMutator,
+	"ExtremeStatisticsMutator":    ExtremeStatisticsMutator,
+	"ConsistentStatisticsMutator": ConsistentStatisticsMutator,
+	"DataStatisticsMutator":       DataStatisticsMutator,
+	"StatisticsHistoryMutator":    StatisticsHistoryMutator,
+	"ColumnFamilyMutator":         ColumnFamilyMutator,
+	"StorageColumnFamilyMutator":  StorageColumnFamilyMutator,
+	"ColumnOrderMutator":          ColumnOrderMutator,
+	"IndexStoringMutator":         IndexStoringMutator,
+	"PartialIndexMutator":         PartialIndexMutator,
+	"CollatedStringMutator":       CollatedStringMutator,
+	"EnumMutator":                 EnumMutator,
+	"IdentifierMutator":           IdentifierMutator,
+	"PostgresMutator":             PostgresMutator,
+	"PostgresCreateTableMutator":  PostgresCreateTableMutator,
+	"MySQLMutator":                MySQLMutator,
+	"ChangefeedExportMutator":     ChangefeedExportMutator,
+	"SessionSettingMutator":       SessionSettingMutator,
+	"UpsertMutator":               UpsertMutator,
+	"ReturningMutator":            ReturningMutator,
+	"InsertBatchMutator":          InsertBatchMutator,
+	"PredicateReorderMutator":     PredicateReorderMutator,
+	"RedundantCastMutator":        RedundantCastMutator,
+	"InToExistsMutator":           InToExistsMutator,
+	"CTEFilterMutator":            CTEFilterMutator,
+	"RenameMutator":               RenameMutator,
+	"AlterColumnTypeMutator":      AlterColumnTypeMutator,
+	"ConstraintInterplayMutator":  ConstraintInterplayMutator,
+}
+
+// Pipeline is a list of mutators built from a PipelineConfig.
+type Pipeline struct {
+	steps []pipelineStep
+}
+
+type pipelineStep struct {
+	mutator     rowenc.Mutator
+	probability float64
+}
+
+// LoadPipeline builds the pipeline configured by data, which is a
+// PipelineConfig in YAML or JSON.
+func LoadPipeline(data []byte) (*Pipeline, error) {
+	var config PipelineConfig
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return nil, errors.Wrap(err, "invalid mutator pipeline config")
+	}
+	return MakePipeline(config)
+}
+
+// LoadPipelineFile builds the pipeline configured by the YAML or JSON file at
+// path.
+func LoadPipelineFile(path string) (*Pipeline, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return LoadPipeline(data)
+}
+
+// MakePipeline builds the pipeline configured by config.
+func MakePipeline(config PipelineConfig) (*Pipeline, error) {
+	p := &Pipeline{steps: make([]pipelineStep, 0, len(config.Mutators))}
+	for i, mc := range config.Mutators {
+		m, err := makeConfiguredMutator(mc)
+		if err != nil {
+			return nil, errors.Wrapf(err, "mutator %d (%s)", i, mc.Name)
+		}
+		step := pipelineStep{mutator: m, probability: 1}
+		if mc.Probability != nil {
+			if *mc.Probability < 0 || *mc.Probability > 1 {
+				return nil, errors.Newf("mutator %d (%s): probability %v is not between 0 and 1",
+					i, mc.Name, *mc.Probability)
+			}
+			step.probability = *mc.Probability
+		}
+		p.steps = append(p.steps, step)
+	}
+	return p, nil
+}
+
+// makeConfiguredMutator returns the mutator configured by mc.
+func makeConfiguredMutator(mc MutatorConfig) (rowenc.Mutator, error) {
+	if m, ok := namedMutators[mc.Name]; ok {
+		if len(mc.Options) > 0 {
+			return nil, errors.New("mutator has no options")
+		}
+		return m, nil
+	}
+	constructor, ok := mutatorConstructors[mc.Name]
+	if !ok {
+		return nil, errors.New("unknown mutator")
+	}
+	return constructor(func(opts interface{}) error {
+		// The options are decoded by encoding them again, so that the
+		// option structs can use the same yaml tags as the configuration.
+		data, err := yaml.Marshal(mc.Options)
+		if err != nil {
+			return err
+		}
+		return errors.Wrap(yaml.UnmarshalStrict(data, opts), "invalid options")
+	})
+}
+
+// Mutators returns the mutators of the pipeline in their configured order.
+// Every mutator is included with its configured probability, so the result
+// differs between calls.
+func (p *Pipeline) Mutators(rng *rand.Rand) []rowenc.Mutator {
+	res := make([]rowenc.Mutator, 0, len(p.steps))
+	for _, step := range p.steps {
+		if step.probability >= 1 || rng.Float64() < step.probability {
+			res = append(res, step.mutator)
+		}
+	}
+	return res
+}
@@ -0,0 +1,72 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+func TestLoadPipeline(t *testing.T) {
+	const yamlConfig = `
+mutators:
+- name: ColumnFamilyMutator
+- name: ForeignKeyMutator
+  probability: 0.5
+  options:
+    random_match: true
+    self_reference: true
+- name: ReturningMutator
+  probability: 0
+`
+	p, err := LoadPipeline([]byte(yamlConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rng, _ := randutil.NewPseudoRand()
+	counts := map[int]int{}
+	for i := 0; i < 1000; i++ {
+		mutators := p.Mutators(rng)
+		counts[len(mutators)]++
+		if mutatorName(mutators[0]) != mutatorName(ColumnFamilyMutator) {
+			t.Fatalf("expected ColumnFamilyMutator first, found %s", mutatorName(mutators[0]))
+		}
+	}
+	// ForeignKeyMutator is included about half of the time, and
+	// ReturningMutator never.
+	if counts[1] == 0 || counts[2] == 0 || counts[3] != 0 {
+		t.Fatalf("unexpected numbers of mutators: %v", counts)
+	}
+
+	// JSON is a subset of YAML.
+	const jsonConfig = `{"mutators": [{"name": "MultiRegionMutator", "options": {"database": "d", "regions": ["r1", "r2"]}}]}`
+	if _, err := LoadPipeline([]byte(jsonConfig)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		config string
+		err    string
+	}{
+		{`mutators: [{name: Unknown}]`, "unknown mutator"},
+		{`mutators: [{name: ReturningMutator, options: {a: 1}}]`, "mutator has no options"},
+		{`mutators: [{name: ForeignKeyMutator, options: {unknown_option: true}}]`, "invalid options"},
+		{`mutators: [{name: MultiRegionMutator}]`, "database and regions are required"},
+		{`mutators: [{name: ReturningMutator, probability: 2}]`, "is not between 0 and 1"},
+		{`mutator: []`, "invalid mutator pipeline config"},
+	} {
+		if _, err := LoadPipeline([]byte(tc.config)); err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: expected error %q, found %v", tc.config, tc.err, err)
+		}
+	}
+}
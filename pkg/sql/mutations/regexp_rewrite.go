@@ -0,0 +1,83 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"math/rand"
+	"regexp"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+)
+
+// RegexpRewriteRule is a rule of a RegexpRewriteMutator.
+type RegexpRewriteRule struct {
+	// Pattern is a regular expression in the syntax of the regexp package.
+	Pattern string `yaml:"pattern"`
+	// Replacement replaces the matches of Pattern. It can refer to the
+	// submatches of Pattern like regexp.Regexp.ReplaceAllString, for example
+	// with $1.
+	Replacement string `yaml:"replacement"`
+}
+
+// RegexpRewriteMutator is a StringMutator that rewrites the SQL string of the
+// statements with an ordered list of rules. It lets tests adjust the output to
+// other dialects without changes to this package. Since it works on strings, it must run after the
+// mutators that work on statements, which ApplyString takes care of.
+type RegexpRewriteMutator struct {
+	rules []regexpRewrite
+}
+
+type regexpRewrite struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+var _ StringMutator = (*RegexpRewriteMutator)(nil)
+
+// RegexpRewriteMutatorOptions are the options of the RegexpRewriteMutator of
+// a pipeline, which is built by MakeRegexpRewriteMutator.
+type RegexpRewriteMutatorOptions struct {
+	Rules []RegexpRewriteRule `yaml:"rules"`
+}
+
+// MakeRegexpRewriteMutator returns a RegexpRewriteMutator that applies rules
+// in the given order, so more specific rules must come first. An error is
+// returned if a pattern cannot be compiled.
+func MakeRegexpRewriteMutator(rules ...RegexpRewriteRule) (*RegexpRewriteMutator, error) {
+	m := &RegexpRewriteMutator{rules: make([]regexpRewrite, len(rules))}
+	for i, r := range rules {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "rule %d", i)
+		}
+		m.rules[i] = regexpRewrite{re: re, replacement: r.Replacement}
+	}
+	return m, nil
+}
+
+// Mutate implements the Mutator interface.
+func (m *RegexpRewriteMutator) Mutate(
+	rng *rand.Rand, stmts []tree.Statement,
+) (mutated []tree.Statement, changed bool) {
+	panic("can only be used with MutateString")
+}
+
+// MutateString implements the StringMutator interface.
+func (m *RegexpRewriteMutator) MutateString(
+	rng *rand.Rand, q string,
+) (mutated string, changed bool) {
+	mutated = q
+	for _, r := range m.rules {
+		mutated = r.re.ReplaceAllString(mutated, r.replacement)
+	}
+	return mutated, mutated != q
+}
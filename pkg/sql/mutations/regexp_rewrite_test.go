@@ -0,0 +1,50 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+func TestRegexpRewriteMutator(t *testing.T) {
+	m, err := MakeRegexpRewriteMutator(
+		RegexpRewriteRule{Pattern: `\bINT8\b`, Replacement: "BIGINT"},
+		RegexpRewriteRule{Pattern: `\bBIGINT\b`, Replacement: "INTEGER"},
+		RegexpRewriteRule{Pattern: `"(\w+)"`, Replacement: "`$1`"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rng, _ := randutil.NewPseudoRand()
+
+	// The rules are applied in order, after PostgresMutator serialized the
+	// statements.
+	q := `CREATE TABLE "my table" (k INT8 PRIMARY KEY);`
+	const expected = "CREATE TABLE \"my table\" (k INTEGER PRIMARY KEY);\n"
+	mutated, changed := ApplyString(rng, q, PostgresMutator, m)
+	if !changed || mutated != expected {
+		t.Fatalf("expected %q, found %q", expected, mutated)
+	}
+	mutated, changed = ApplyString(rng, `CREATE TABLE "t" (k INT8)`, m)
+	const expectedQuoted = "CREATE TABLE `t` (k INTEGER)"
+	if !changed || mutated != expectedQuoted {
+		t.Fatalf("expected %q, found %q", expectedQuoted, mutated)
+	}
+	if mutated, changed := ApplyString(rng, `SELECT 1`, m); changed {
+		t.Fatalf("unexpected change: %s", mutated)
+	}
+
+	if _, err := MakeRegexpRewriteMutator(RegexpRewriteRule{Pattern: `(`}); err == nil {
+		t.Fatal("expected error for invalid pattern")
+	}
+}
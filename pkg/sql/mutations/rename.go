@@ -0,0 +1,230 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/sql/lex"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+)
+
+// RenameMutator inserts ALTER TABLE ... RENAME TO and ALTER TABLE ... RENAME
+// COLUMN statements at random positions after the CREATE TABLE statements of
+// the renamed tables, and renames the references to the renamed objects in
+// the statements that follow, so that name resolution is exercised with
+// renames interleaved with other statements. Only objects whose names are
+// unique among the objects created by the statements are renamed, and no
+// renames are inserted after statements that could prevent them, like
+// CREATE VIEW and DROP TABLE.
+var RenameMutator = VersionedMutator{MultiStatementMutation(renameMutator), clusterversion.V20_2}
+
+// renamedSuffix is appended to the names of the objects renamed by
+// RenameMutator.
+const renamedSuffix = "_renamed"
+
+// renameCandidate is a table, or a column of a table, that can be renamed by
+// RenameMutator.
+type renameCandidate struct {
+	// create is the index of the CREATE TABLE statement of the table.
+	create int
+	table  *tree.CreateTable
+	// column is the name of the renamed column, and empty if the table is
+	// renamed.
+	column tree.Name
+}
+
+func renameMutator(
+	rng *rand.Rand, stmts []tree.Statement,
+) (mutated []tree.Statement, changed bool) {
+	counts := createdNameCounts(stmts)
+	used := usedIdentifiers(stmts)
+	var candidates []renameCandidate
+	for i, stmt := range stmts {
+		create, ok := stmt.(*tree.CreateTable)
+		if !ok {
+			continue
+		}
+		if name := create.Table.ObjectName; counts[name] == 1 && isRenamableIdentifier(string(name)) {
+			candidates = append(candidates, renameCandidate{create: i, table: create})
+		}
+		for _, def := range create.Defs {
+			if col, ok := def.(*tree.ColumnTableDef); ok &&
+				counts[col.Name] == 1 && isRenamableIdentifier(string(col.Name)) {
+				candidates = append(candidates, renameCandidate{create: i, table: create, column: col.Name})
+			}
+		}
+	}
+
+	for n := 1 + rng.Intn(2); n > 0 && len(candidates) > 0; n-- {
+		c := candidates[rng.Intn(len(candidates))]
+		// Every table is renamed at most once, so that the renames of its
+		// columns don't need to refer to it by its new name.
+		remaining := candidates[:0]
+		for _, other := range candidates {
+			if other.create != c.create {
+				remaining = append(remaining, other)
+			}
+		}
+		candidates = remaining
+
+		// The rename can be inserted anywhere after the CREATE TABLE
+		// statement, up to the first statement that could prevent it.
+		end := c.create + 1
+		for end < len(stmts) && !preventsRename(stmts[end]) {
+			end++
+		}
+		pos := c.create + 1 + rng.Intn(end-c.create)
+
+		old := c.table.Table.ObjectName
+		if c.column != "" {
+			old = c.column
+		}
+		renamed := tree.Name(string(old) + renamedSuffix)
+		for j := 1; used[string(renamed)]; j++ {
+			renamed = tree.Name(fmt.Sprintf("%s%s_%d", old, renamedSuffix, j))
+		}
+		used[string(renamed)] = true
+
+		var rename tree.Statement
+		if c.column != "" {
+			rename = &tree.AlterTable{
+				Table: c.table.Table.ToUnresolvedObjectName(),
+				Cmds: tree.AlterTableCmds{
+					&tree.AlterTableRenameColumn{Column: old, NewName: renamed},
+				},
+			}
+		} else {
+			newName := c.table.Table
+			newName.ObjectName = renamed
+			rename = &tree.RenameTable{
+				Name:    c.table.Table.ToUnresolvedObjectName(),
+				NewName: newName.ToUnresolvedObjectName(),
+			}
+		}
+
+		renames := map[string]string{string(old): string(renamed)}
+		mutated = make([]tree.Statement, 0, len(stmts)+1)
+		mutated = append(mutated, stmts[:pos]...)
+		mutated = append(mutated, rename)
+		for _, stmt := range stmts[pos:] {
+			mutated = append(mutated, renameStatement(stmt, renames))
+		}
+		// The CREATE TABLE statements after the rename moved.
+		for j := range candidates {
+			if candidates[j].create > pos {
+				candidates[j].create++
+			}
+		}
+		stmts = mutated
+		changed = true
+	}
+	return stmts, changed
+}
+
+// renameStatement returns stmt with the identifiers that have a new name in
+// renames renamed, including the columns of injected statistics. stmt is
+// returned if no identifiers are renamed.
+func renameStatement(stmt tree.Statement, renames map[string]string) tree.Statement {
+	sql := tree.Serialize(stmt)
+	renamed := renameIdentifiers(sql, renames)
+	if renamed == sql {
+		return stmt
+	}
+	parsed, err := parser.ParseOne(renamed)
+	if err != nil {
+		// Should not happen, the renamed identifiers are quoted.
+		panic(err)
+	}
+	if alter, ok := parsed.AST.(*tree.AlterTable); ok {
+		for _, cmd := range alter.Cmds {
+			if inject, ok := cmd.(*tree.AlterTableInjectStats); ok {
+				renameStatisticsColumns(inject, renames)
+			}
+		}
+	}
+	return parsed.AST
+}
+
+// preventsRename returns whether stmt can prevent the objects created before
+// it from being renamed after it: views depend on the names of the objects
+// they refer to, and dropped tables and columns cannot be renamed.
+func preventsRename(stmt tree.Statement) bool {
+	switch stmt := stmt.(type) {
+	case *tree.CreateView, *tree.DropTable, *tree.DropView:
+		return true
+	case *tree.AlterTable:
+		for _, cmd := range stmt.Cmds {
+			if _, ok := cmd.(*tree.AlterTableDropColumn); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// createdNameCounts returns the number of objects created by stmts with each
+// name, counting tables, views, sequences, columns, indexes and constraints.
+func createdNameCounts(stmts []tree.Statement) map[tree.Name]int {
+	counts := map[tree.Name]int{}
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *tree.CreateTable:
+			counts[stmt.Table.ObjectName]++
+			for _, def := range stmt.Defs {
+				switch def := def.(type) {
+				case *tree.ColumnTableDef:
+					counts[def.Name]++
+				case *tree.IndexTableDef:
+					counts[def.Name]++
+				case *tree.UniqueConstraintTableDef:
+					counts[def.Name]++
+				case *tree.CheckConstraintTableDef:
+					counts[def.Name]++
+				case *tree.ForeignKeyConstraintTableDef:
+					counts[def.Name]++
+				case *tree.FamilyTableDef:
+					counts[def.Name]++
+				}
+			}
+		case *tree.CreateIndex:
+			counts[stmt.Name]++
+		case *tree.CreateView:
+			counts[stmt.Name.ObjectName]++
+		case *tree.CreateSequence:
+			counts[stmt.Name.ObjectName]++
+		case *tree.AlterTable:
+			for _, cmd := range stmt.Cmds {
+				if add, ok := cmd.(*tree.AlterTableAddColumn); ok {
+					counts[add.ColumnDef.Name]++
+				}
+			}
+		}
+	}
+	return counts
+}
+
+// usedIdentifiers returns the identifiers used by stmts.
+func usedIdentifiers(stmts []tree.Statement) map[string]bool {
+	used := map[string]bool{}
+	for _, stmt := range stmts {
+		tokens, _ := parser.Tokens(tree.Serialize(stmt))
+		for _, tok := range tokens {
+			if tok.TokenID == lex.IDENT {
+				used[tok.Str] = true
+			}
+		}
+	}
+	return used
+}
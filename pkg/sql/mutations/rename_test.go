@@ -0,0 +1,88 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/lex"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+func TestRenameMutator(t *testing.T) {
+	q := `
+		CREATE TABLE tab (k INT8 PRIMARY KEY, v STRING, w INT8 AS (k + 1) STORED);
+		INSERT INTO tab (k, v) VALUES (1, 'v');
+		CREATE TABLE other (a INT8 PRIMARY KEY, k INT8 REFERENCES tab (k));
+		UPDATE tab SET v = 'x' WHERE k = 1;
+		SELECT v, w FROM tab;
+		CREATE VIEW vw AS SELECT v FROM tab;
+		SELECT * FROM tab;
+	`
+	rng, _ := randutil.NewPseudoRand()
+	found := map[string]bool{}
+	for i := 0; i < 1000 && len(found) < 2; i++ {
+		mutated, changed := ApplyString(rng, q, RenameMutator)
+		if !changed {
+			t.Fatalf("expected change")
+		}
+		parsed, err := parser.Parse(mutated)
+		if err != nil {
+			t.Fatalf("error parsing %s: %v", mutated, err)
+		}
+		// renamed contains the renamed objects, and the names they were
+		// renamed to.
+		renamed := map[string]string{}
+		view := false
+		for _, p := range parsed {
+			var from, to tree.Name
+			switch stmt := p.AST.(type) {
+			case *tree.RenameTable:
+				from, to = tree.Name(stmt.Name.Object()), tree.Name(stmt.NewName.Object())
+				found["table"] = true
+			case *tree.AlterTable:
+				if rename, ok := stmt.Cmds[0].(*tree.AlterTableRenameColumn); ok {
+					from, to = rename.Column, rename.NewName
+					found["column"] = true
+				}
+			case *tree.CreateView:
+				view = true
+			}
+			if from != "" {
+				if view {
+					t.Fatalf("unexpected rename after CREATE VIEW: %s", mutated)
+				}
+				// k is not unique, so it cannot be renamed.
+				if from == "k" {
+					t.Fatalf("unexpected rename of k: %s", mutated)
+				}
+				renamed[string(from)] = string(to)
+				continue
+			}
+			// The statements before a rename use the old name, and the
+			// statements after it the new name.
+			tokens, _ := parser.Tokens(p.SQL)
+			for _, tok := range tokens {
+				if tok.TokenID != lex.IDENT {
+					continue
+				}
+				if _, ok := renamed[tok.Str]; ok {
+					t.Fatalf("unexpected reference to renamed %s in %s: %s", tok.Str, p.SQL, mutated)
+				}
+			}
+		}
+	}
+	if len(found) != 2 {
+		t.Fatalf("expected table and column renames, found %v", found)
+	}
+}
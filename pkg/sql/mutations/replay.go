@@ -0,0 +1,93 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+)
+
+// replayTokenVersion is the prefix of replay tokens. It has to be changed
+// when the format of the tokens changes.
+const replayTokenVersion = "v1"
+
+// ApplyRecorded is like Apply, but every mutator is run with a random number
+// generator of its own, seeded with a seed drawn from rng. It returns a replay
+// token that records the order of the mutators and their seeds, which
+// ApplyReplay uses to repeat the mutations. Since the mutators don't share a
+// random number generator, a mutator that draws more or fewer random numbers
+// after a code change only changes its own decisions, and not the ones of the
+// mutators that run after it.
+func ApplyRecorded(
+	rng *rand.Rand, stmts []tree.Statement, mutators ...rowenc.Mutator,
+) (mutated []tree.Statement, changed bool, token string) {
+	steps := make([]string, 0, len(mutators)+1)
+	steps = append(steps, replayTokenVersion)
+	for _, m := range mutators {
+		seed := rng.Int63()
+		var mc bool
+		stmts, mc = Apply(rand.New(rand.NewSource(seed)), stmts, m)
+		changed = changed || mc
+		steps = append(steps, fmt.Sprintf("%s=%d", mutatorName(m), seed))
+	}
+	return stmts, changed, strings.Join(steps, ",")
+}
+
+// ApplyReplay repeats the mutations recorded by ApplyRecorded in token. The
+// mutators are looked up by name in the given mutators, which can be a
+// superset of the recorded ones and be in any order. An error is returned if
+// a recorded mutator is missing or if several mutators have its name.
+func ApplyReplay(
+	token string, stmts []tree.Statement, mutators ...rowenc.Mutator,
+) (mutated []tree.Statement, changed bool, err error) {
+	byName := make(map[string]rowenc.Mutator, len(mutators))
+	ambiguous := map[string]bool{}
+	for _, m := range mutators {
+		name := mutatorName(m)
+		if _, ok := byName[name]; ok {
+			ambiguous[name] = true
+		}
+		byName[name] = m
+	}
+
+	steps := strings.Split(token, ",")
+	if steps[0] != replayTokenVersion {
+		return nil, false, errors.Newf("unsupported replay token version: %q", steps[0])
+	}
+	for _, step := range steps[1:] {
+		i := strings.LastIndexByte(step, '=')
+		if i < 0 {
+			return nil, false, errors.Newf("invalid replay token step: %q", step)
+		}
+		name := step[:i]
+		seed, err := strconv.ParseInt(step[i+1:], 10, 64)
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "invalid seed of mutator %s", name)
+		}
+		m, ok := byName[name]
+		if !ok {
+			return nil, false, errors.Newf("unknown mutator %s", name)
+		}
+		if ambiguous[name] {
+			return nil, false, errors.Newf("ambiguous mutator %s", name)
+		}
+		var mc bool
+		stmts, mc = Apply(rand.New(rand.NewSource(seed)), stmts, m)
+		changed = changed || mc
+	}
+	return stmts, changed, nil
+}
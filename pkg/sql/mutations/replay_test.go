@@ -0,0 +1,61 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+func TestApplyReplay(t *testing.T) {
+	q := `
+		CREATE TABLE t (a INT8 PRIMARY KEY, b INT8, c STRING);
+		CREATE TABLE u (x INT8 PRIMARY KEY, y INT8, z STRING);
+		INSERT INTO t VALUES (1, 2, 'c');
+	`
+	serialize := func(stmts []tree.Statement) string {
+		return strings.Join(serializeStatements(stmts), ";\n")
+	}
+	parse := func() []tree.Statement {
+		parsed, err := parser.Parse(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return stmtsFromParsed(parsed)
+	}
+
+	rng, _ := randutil.NewPseudoRand()
+	for i := 0; i < 10; i++ {
+		recorded, _, token := ApplyRecorded(rng, parse(),
+			ColumnFamilyMutator, ForeignKeyMutator, StatisticsMutator, UpsertMutator)
+		// The mutators are looked up by name, so their order and additional
+		// mutators don't matter.
+		replayed, _, err := ApplyReplay(token, parse(),
+			UpsertMutator, ReturningMutator, StatisticsMutator, ForeignKeyMutator, ColumnFamilyMutator)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if serialize(replayed) != serialize(recorded) {
+			t.Fatalf("replay of %s differs:\n%s\nexpected:\n%s", token, serialize(replayed), serialize(recorded))
+		}
+
+		if _, _, err := ApplyReplay(token, parse(), ColumnFamilyMutator); err == nil {
+			t.Fatalf("expected an error for missing mutators in %s", token)
+		}
+	}
+	if _, _, err := ApplyReplay("v0", parse()); err == nil {
+		t.Fatal("expected an error for an unsupported version")
+	}
+}
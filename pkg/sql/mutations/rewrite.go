@@ -0,0 +1,312 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"math/rand"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+// The query rewrite mutators change SELECT statements into equivalent forms,
+// so that metamorphic tests can verify that the results of the statements
+// are the same before and after the mutation. Since the order in which the
+// operands of AND and OR expressions are evaluated is not defined, the
+// rewritten statements can return errors in different cases than the
+// original statements.
+var (
+	// PredicateReorderMutator swaps the operands of random AND and OR
+	// expressions in SELECT statements.
+	PredicateReorderMutator = VersionedMutator{StatementMutator(predicateReorderMutator), clusterversion.V20_2}
+
+	// RedundantCastMutator wraps random boolean expressions in SELECT
+	// statements in redundant parentheses and casts to BOOL.
+	RedundantCastMutator = VersionedMutator{StatementMutator(redundantCastMutator), clusterversion.V20_2}
+
+	// InToExistsMutator rewrites random x IN (subquery) filters of SELECT
+	// statements, which are planned as semi-joins, into correlated EXISTS
+	// subqueries, which the optimizer has to decorrelate into joins.
+	InToExistsMutator = VersionedMutator{StatementMutator(inToExistsMutator), clusterversion.V20_2}
+
+	// CTEFilterMutator moves the filter of random SELECT statements over a
+	// single table into a CTE that scans the table.
+	CTEFilterMutator = VersionedMutator{StatementMutator(cteFilterMutator), clusterversion.V20_2}
+
+	// QueryRewriteMutators contains all semantics-preserving query rewrite
+	// mutators.
+	QueryRewriteMutators = []rowenc.Mutator{
+		PredicateReorderMutator,
+		RedundantCastMutator,
+		InToExistsMutator,
+		CTEFilterMutator,
+	}
+)
+
+const (
+	// inToExistsAlias and inToExistsCol are the names of the data source and
+	// the column of the subqueries rewritten by InToExistsMutator.
+	inToExistsAlias = "mut_in"
+	inToExistsCol   = "mut_in_col"
+	// cteFilterName is the name of the CTE added by CTEFilterMutator.
+	cteFilterName = "mut_filtered"
+)
+
+// walkSelect calls fn for every expression in sel, including the expressions
+// in its subqueries and CTEs, and replaces sel in place with the result.
+func walkSelect(sel *tree.Select, fn tree.SimpleVisitFn) {
+	// There is no exported walker for statements, so sel is walked as part
+	// of a subquery expression.
+	sub := &tree.Subquery{Select: &tree.ParenSelect{Select: sel}}
+	newExpr, err := tree.SimpleVisit(sub, fn)
+	if err != nil {
+		// Should not happen, fn does not return errors.
+		panic(err)
+	}
+	*sel = *newExpr.(*tree.Subquery).Select.(*tree.ParenSelect).Select
+}
+
+// forEachSelectClause calls fn for the SELECT clauses that produce the rows of
+// sel, including the ones of set operations and CTEs, but not the ones of
+// subqueries.
+func forEachSelectClause(sel *tree.Select, fn func(*tree.SelectClause)) {
+	if sel.With != nil {
+		for _, cte := range sel.With.CTEList {
+			if cteSel, ok := cte.Stmt.(*tree.Select); ok {
+				forEachSelectClause(cteSel, fn)
+			}
+		}
+	}
+	switch s := sel.Select.(type) {
+	case *tree.SelectClause:
+		fn(s)
+	case *tree.ParenSelect:
+		forEachSelectClause(s.Select, fn)
+	case *tree.UnionClause:
+		forEachSelectClause(s.Left, fn)
+		forEachSelectClause(s.Right, fn)
+	}
+}
+
+func predicateReorderMutator(rng *rand.Rand, stmt tree.Statement) (changed bool) {
+	sel, ok := stmt.(*tree.Select)
+	if !ok {
+		return false
+	}
+	walkSelect(sel, func(expr tree.Expr) (recurse bool, newExpr tree.Expr, err error) {
+		if rng.Intn(2) == 0 {
+			return true, expr, nil
+		}
+		switch e := expr.(type) {
+		case *tree.AndExpr:
+			changed = true
+			return true, &tree.AndExpr{Left: e.Right, Right: e.Left}, nil
+		case *tree.OrExpr:
+			changed = true
+			return true, &tree.OrExpr{Left: e.Right, Right: e.Left}, nil
+		}
+		return true, expr, nil
+	})
+	return changed
+}
+
+func redundantCastMutator(rng *rand.Rand, stmt tree.Statement) (changed bool) {
+	sel, ok := stmt.(*tree.Select)
+	if !ok {
+		return false
+	}
+	// Keep track of the wrapped expressions, so that they are not wrapped
+	// again when the walk recurses into the wrapping expressions.
+	wrapped := map[tree.Expr]bool{}
+	walkSelect(sel, func(expr tree.Expr) (recurse bool, newExpr tree.Expr, err error) {
+		switch expr.(type) {
+		case *tree.ComparisonExpr, *tree.AndExpr, *tree.OrExpr, *tree.NotExpr:
+		default:
+			return true, expr, nil
+		}
+		if wrapped[expr] || rng.Intn(3) == 0 {
+			return true, expr, nil
+		}
+		wrapped[expr] = true
+		changed = true
+		paren := &tree.ParenExpr{Expr: expr}
+		if rng.Intn(2) == 0 {
+			return true, paren, nil
+		}
+		return true, &tree.CastExpr{Expr: paren, Type: types.Bool, SyntaxMode: tree.CastShort}, nil
+	})
+	return changed
+}
+
+func inToExistsMutator(rng *rand.Rand, stmt tree.Statement) (changed bool) {
+	sel, ok := stmt.(*tree.Select)
+	if !ok {
+		return false
+	}
+	forEachSelectClause(sel, func(clause *tree.SelectClause) {
+		if clause.Where == nil {
+			return
+		}
+		// Only the conjuncts at the top of the filter are rewritten,
+		// because IN and EXISTS differ when the subquery has no matching
+		// row and the left side or the subquery contain NULLs, which
+		// matters under NOT and OR.
+		var rewrite func(expr tree.Expr) tree.Expr
+		rewrite = func(expr tree.Expr) tree.Expr {
+			switch e := expr.(type) {
+			case *tree.AndExpr:
+				return &tree.AndExpr{Left: rewrite(e.Left), Right: rewrite(e.Right)}
+			case *tree.ParenExpr:
+				return &tree.ParenExpr{Expr: rewrite(e.Expr)}
+			case *tree.ComparisonExpr:
+				if e.Operator != tree.In || rng.Intn(2) == 0 {
+					return expr
+				}
+				if exists, ok := inToExists(e); ok {
+					changed = true
+					return exists
+				}
+			}
+			return expr
+		}
+		clause.Where.Expr = rewrite(clause.Where.Expr)
+	})
+	return changed
+}
+
+// inToExists rewrites the filter x IN (SELECT y FROM ...) into the filter
+// EXISTS (SELECT 1 FROM (SELECT y FROM ...) AS mut_in (mut_in_col) WHERE
+// mut_in.mut_in_col = x). It returns false if the IN expression does not have
+// that form.
+func inToExists(in *tree.ComparisonExpr) (tree.Expr, bool) {
+	if _, ok := in.Left.(*tree.Tuple); ok {
+		return nil, false
+	}
+	sub, ok := in.Right.(*tree.Subquery)
+	if !ok {
+		return nil, false
+	}
+	// Only subqueries with a single column are rewritten, so that the
+	// column can be aliased.
+	paren, ok := sub.Select.(*tree.ParenSelect)
+	if !ok {
+		return nil, false
+	}
+	clause, ok := paren.Select.Select.(*tree.SelectClause)
+	if !ok || len(clause.Exprs) != 1 {
+		return nil, false
+	}
+	switch clause.Exprs[0].Expr.(type) {
+	case tree.UnqualifiedStar, *tree.AllColumnsSelector:
+		return nil, false
+	}
+	if n, ok := clause.Exprs[0].Expr.(*tree.UnresolvedName); ok && n.Star {
+		return nil, false
+	}
+
+	alias := tree.MakeUnqualifiedTableName(inToExistsAlias)
+	exists := &tree.SelectClause{
+		Exprs: tree.SelectExprs{{Expr: tree.NewDInt(1)}},
+		From: tree.From{Tables: tree.TableExprs{&tree.AliasedTableExpr{
+			Expr: &tree.Subquery{Select: paren},
+			As:   tree.AliasClause{Alias: inToExistsAlias, Cols: tree.NameList{inToExistsCol}},
+		}}},
+		Where: tree.NewWhere(tree.AstWhere, &tree.ComparisonExpr{
+			Operator: tree.EQ,
+			Left:     tree.NewColumnItem(&alias, inToExistsCol),
+			Right:    in.Left,
+		}),
+	}
+	return &tree.Subquery{
+		Select: &tree.ParenSelect{Select: &tree.Select{Select: exists}},
+		Exists: true,
+	}, true
+}
+
+func cteFilterMutator(rng *rand.Rand, stmt tree.Statement) (changed bool) {
+	sel, ok := stmt.(*tree.Select)
+	if !ok || len(sel.Locking) > 0 || rng.Intn(2) == 0 {
+		return false
+	}
+	if sel.With != nil && sel.With.Recursive {
+		return false
+	}
+	clause, ok := sel.Select.(*tree.SelectClause)
+	if !ok || clause.Where == nil || len(clause.From.Tables) != 1 || clause.From.AsOf.Expr != nil {
+		return false
+	}
+	for _, o := range sel.OrderBy {
+		if o.OrderType != tree.OrderByColumn {
+			// ORDER BY PRIMARY KEY and ORDER BY INDEX need the table.
+			return false
+		}
+	}
+	table := clause.From.Tables[0]
+	if aliased, ok := table.(*tree.AliasedTableExpr); ok {
+		if aliased.IndexFlags != nil || aliased.Ordinality || aliased.Lateral || aliased.As.Cols != nil {
+			return false
+		}
+		table = aliased.Expr
+	}
+	tn, ok := table.(*tree.TableName)
+	if !ok {
+		return false
+	}
+	name := tn.ObjectName
+	if aliased, ok := clause.From.Tables[0].(*tree.AliasedTableExpr); ok && aliased.As.Alias != "" {
+		name = aliased.As.Alias
+	}
+	if !cteFilterCanRename(sel) {
+		return false
+	}
+
+	cte := &tree.CTE{
+		Name: tree.AliasClause{Alias: cteFilterName},
+		Stmt: &tree.Select{Select: &tree.SelectClause{
+			Exprs: tree.SelectExprs{tree.StarSelectExpr()},
+			From:  tree.From{Tables: tree.TableExprs{clause.From.Tables[0]}},
+			Where: clause.Where,
+		}},
+	}
+	cteName := tree.MakeUnqualifiedTableName(cteFilterName)
+	clause.From.Tables[0] = &tree.AliasedTableExpr{
+		Expr: &cteName,
+		As:   tree.AliasClause{Alias: name},
+	}
+	clause.Where = nil
+	if sel.With == nil {
+		sel.With = &tree.With{}
+	}
+	sel.With.CTEList = append(sel.With.CTEList, cte)
+	return true
+}
+
+// cteFilterCanRename returns whether the columns referenced by sel stay valid
+// when its table is replaced by a CTE with the same alias. That is not the case
+// for columns qualified with a schema or database, and for hidden columns
+// like rowid, which are not produced by SELECT * in the CTE.
+func cteFilterCanRename(sel *tree.Select) bool {
+	ok := true
+	sub := &tree.Subquery{Select: &tree.ParenSelect{Select: sel}}
+	_, _ = tree.SimpleVisit(sub, func(expr tree.Expr) (recurse bool, newExpr tree.Expr, err error) {
+		if n, isName := expr.(*tree.UnresolvedName); isName {
+			col := strings.ToLower(n.Parts[0])
+			if n.NumParts > 2 || strings.HasPrefix(col, "rowid") || strings.HasPrefix(col, "crdb_internal") {
+				ok = false
+			}
+		}
+		return ok, expr, nil
+	})
+	return ok
+}
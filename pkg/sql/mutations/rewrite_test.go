@@ -0,0 +1,117 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+func TestQueryRewriteMutators(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mutator  rowenc.Mutator
+		query    string
+		expected []string
+	}{
+		{
+			name:    "predicate reorder",
+			mutator: PredicateReorderMutator,
+			query:   `SELECT a FROM t WHERE a > 1 AND (b < 2 OR c = 3)`,
+			expected: []string{
+				"SELECT a FROM t WHERE ((b < 2) OR (c = 3)) AND (a > 1)",
+				"SELECT a FROM t WHERE (a > 1) AND ((c = 3) OR (b < 2))",
+			},
+		},
+		{
+			name:    "redundant cast",
+			mutator: RedundantCastMutator,
+			query:   `SELECT a FROM t WHERE a > 1`,
+			expected: []string{
+				"SELECT a FROM t WHERE (a > 1)",
+				"SELECT a FROM t WHERE (a > 1)::BOOL",
+			},
+		},
+		{
+			name:    "in to exists",
+			mutator: InToExistsMutator,
+			query:   `SELECT a FROM t WHERE a IN (SELECT b FROM u WHERE c > 1) AND a > 0`,
+			expected: []string{
+				"SELECT a FROM t WHERE EXISTS (SELECT 1:::INT8 FROM (SELECT b FROM u WHERE c > 1) AS mut_in (mut_in_col) WHERE mut_in.mut_in_col = a) AND (a > 0)",
+			},
+		},
+		{
+			name:    "cte filter",
+			mutator: CTEFilterMutator,
+			query:   `SELECT t.a, count(*) FROM t WHERE b > 1 GROUP BY t.a ORDER BY t.a`,
+			expected: []string{
+				"WITH mut_filtered AS (SELECT * FROM t WHERE b > 1) SELECT t.a, count(*) FROM mut_filtered AS t GROUP BY t.a ORDER BY t.a",
+			},
+		},
+	}
+	rng, _ := randutil.NewPseudoRand()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			found := map[string]bool{}
+			for i := 0; i < 1000 && len(found) < len(tc.expected); i++ {
+				mutated, changed := ApplyString(rng, tc.query, tc.mutator)
+				if !changed {
+					continue
+				}
+				if _, err := parser.Parse(mutated); err != nil {
+					t.Fatalf("error parsing %s: %v", mutated, err)
+				}
+				for _, e := range tc.expected {
+					if strings.TrimSuffix(mutated, ";\n") == e {
+						found[e] = true
+					}
+				}
+			}
+			for _, e := range tc.expected {
+				if !found[e] {
+					t.Errorf("expected mutation: %s", e)
+				}
+			}
+		})
+	}
+}
+
+func TestQueryRewriteMutatorsUnchanged(t *testing.T) {
+	testCases := []struct {
+		mutator rowenc.Mutator
+		query   string
+	}{
+		// IN under NOT or OR cannot be rewritten.
+		{InToExistsMutator, `SELECT a FROM t WHERE NOT (a IN (SELECT b FROM u))`},
+		{InToExistsMutator, `SELECT a FROM t WHERE a IN (SELECT b FROM u) OR a > 0`},
+		{InToExistsMutator, `SELECT a FROM t WHERE (a, b) IN (SELECT b, c FROM u)`},
+		{InToExistsMutator, `SELECT a FROM t WHERE a IN (SELECT * FROM u)`},
+		// Hidden and qualified columns, and index hints, need the table.
+		{CTEFilterMutator, `SELECT rowid FROM t WHERE a > 1`},
+		{CTEFilterMutator, `SELECT public.t.a FROM t WHERE a > 1`},
+		{CTEFilterMutator, `SELECT a FROM t@idx WHERE a > 1`},
+		{CTEFilterMutator, `SELECT a FROM t WHERE a > 1 ORDER BY PRIMARY KEY t`},
+		{CTEFilterMutator, `SELECT a FROM t, u WHERE a > 1`},
+		{CTEFilterMutator, `SELECT a FROM t WHERE a > 1 FOR UPDATE`},
+	}
+	rng, _ := randutil.NewPseudoRand()
+	for _, tc := range testCases {
+		for i := 0; i < 100; i++ {
+			if mutated, changed := ApplyString(rng, tc.query, tc.mutator); changed {
+				t.Fatalf("unexpected change of %s: %s", tc.query, mutated)
+			}
+		}
+	}
+}
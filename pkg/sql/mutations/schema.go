@@ -0,0 +1,147 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"math/rand"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+)
+
+// SchemaAwareMutator is a Mutator that uses the tables and types created by
+// the statements it mutates. Apply passes the same Schema to consecutive
+// SchemaAwareMutators until one of the mutators changes the statements.
+type SchemaAwareMutator interface {
+	rowenc.Mutator
+	MutateWithSchema(
+		rng *rand.Rand, schema *Schema, stmts []tree.Statement,
+	) (mutated []tree.Statement, changed bool)
+}
+
+// SchemaAwareMutation defines a func that can return a list of new and/or
+// mutated statements, given the schema created by the statements.
+type SchemaAwareMutation func(
+	rng *rand.Rand, schema *Schema, stmts []tree.Statement,
+) (mutated []tree.Statement, changed bool)
+
+// Mutate implements the Mutator interface.
+func (sm SchemaAwareMutation) Mutate(
+	rng *rand.Rand, stmts []tree.Statement,
+) (mutated []tree.Statement, changed bool) {
+	return sm(rng, MakeSchema(stmts), stmts)
+}
+
+// MutateWithSchema implements the SchemaAwareMutator interface.
+func (sm SchemaAwareMutation) MutateWithSchema(
+	rng *rand.Rand, schema *Schema, stmts []tree.Statement,
+) (mutated []tree.Statement, changed bool) {
+	return sm(rng, schema, stmts)
+}
+
+// Schema is a catalog of the tables and types created by a list of
+// statements.
+type Schema struct {
+	tables []*SchemaTable
+	byKey  map[tableKey]*SchemaTable
+	// enumLabels contains the labels of the enum types, keyed by the SQL
+	// string of the type name.
+	enumLabels map[string]tree.EnumValueList
+}
+
+// SchemaTable is a table of a Schema.
+type SchemaTable struct {
+	// Create is the statement that created the table.
+	Create *tree.CreateTable
+	// Columns are the columns of the table, including the ones added by
+	// ALTER TABLE statements, in the order in which they were added.
+	Columns []*tree.ColumnTableDef
+
+	key tableKey
+}
+
+// MakeSchema returns the schema created by stmts. The same table can be
+// named with different qualifications by the statements. If a table is
+// created more than once, only the first statement is used, since the
+// others fail.
+func MakeSchema(stmts []tree.Statement) *Schema {
+	s := &Schema{
+		byKey:      map[tableKey]*SchemaTable{},
+		enumLabels: collectEnumLabels(stmts),
+	}
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *tree.CreateTable:
+			key := makeTableKey(&stmt.Table)
+			if _, ok := s.byKey[key]; ok {
+				continue
+			}
+			table := &SchemaTable{Create: stmt, key: key}
+			for _, def := range stmt.Defs {
+				if col, ok := def.(*tree.ColumnTableDef); ok {
+					table.Columns = append(table.Columns, col)
+				}
+			}
+			s.tables = append(s.tables, table)
+			s.byKey[key] = table
+
+		case *tree.AlterTable:
+			tn := stmt.Table.ToTableName()
+			table, ok := s.byKey[makeTableKey(&tn)]
+			if !ok {
+				continue
+			}
+			for _, cmd := range stmt.Cmds {
+				switch cmd := cmd.(type) {
+				case *tree.AlterTableAddColumn:
+					table.Columns = append(table.Columns, cmd.ColumnDef)
+				case *tree.AlterTableDropColumn:
+					for i, col := range table.Columns {
+						if col.Name == cmd.Column {
+							table.Columns = append(table.Columns[:i:i], table.Columns[i+1:]...)
+							break
+						}
+					}
+				}
+			}
+		}
+	}
+	return s
+}
+
+// Tables returns the tables of the schema in the order in which they were
+// created.
+func (s *Schema) Tables() []*SchemaTable {
+	return s.tables
+}
+
+// Table returns the table with the given name.
+func (s *Schema) Table(tn *tree.TableName) (*SchemaTable, bool) {
+	table, ok := s.byKey[makeTableKey(tn)]
+	return table, ok
+}
+
+// EnumLabels returns the labels of the enum type typ, if it was created by a
+// CREATE TYPE statement of the schema.
+func (s *Schema) EnumLabels(typ tree.ResolvableTypeReference) (tree.EnumValueList, bool) {
+	labels, ok := s.enumLabels[typ.SQLString()]
+	return labels, ok
+}
+
+// Column returns the column of the table with the given name.
+func (t *SchemaTable) Column(name tree.Name) (*tree.ColumnTableDef, bool) {
+	for _, col := range t.Columns {
+		if col.Name == name {
+			return col, true
+		}
+	}
+	return nil, false
+}
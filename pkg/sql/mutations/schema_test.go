@@ -0,0 +1,105 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+func TestMakeSchema(t *testing.T) {
+	q := `
+		CREATE TYPE e AS ENUM ('x', 'y');
+		CREATE TABLE t (a INT8, b e);
+		CREATE TABLE public.t (c INT8);
+		CREATE TABLE s.u (d INT8);
+		ALTER TABLE t ADD COLUMN f INT8;
+		ALTER TABLE t ADD COLUMN g INT8;
+		ALTER TABLE public.t DROP COLUMN f;
+	`
+	parsed, err := parser.Parse(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := MakeSchema(stmtsFromParsed(parsed))
+
+	var names []string
+	for _, table := range schema.Tables() {
+		names = append(names, table.Create.Table.String())
+	}
+	if len(names) != 2 || names[0] != "t" || names[1] != "s.u" {
+		t.Fatalf("unexpected tables: %v", names)
+	}
+
+	tn := tree.MakeTableNameWithSchema("", "public", "t")
+	table, ok := schema.Table(&tn)
+	if !ok {
+		t.Fatal("expected table public.t")
+	}
+	var cols tree.NameList
+	for _, col := range table.Columns {
+		cols = append(cols, col.Name)
+	}
+	if s := tree.AsString(&cols); s != "a, b, g" {
+		t.Fatalf("unexpected columns: %s", s)
+	}
+	col, ok := table.Column("b")
+	if !ok {
+		t.Fatal("expected column b")
+	}
+	labels, ok := schema.EnumLabels(col.Type)
+	if !ok || len(labels) != 2 {
+		t.Fatalf("expected enum labels of column b, found %v", labels)
+	}
+	if _, ok := table.Column("f"); ok {
+		t.Fatal("unexpected dropped column f")
+	}
+}
+
+func TestApplySchemaAwareMutators(t *testing.T) {
+	q := `CREATE TABLE t (a INT8)`
+	parsed, err := parser.Parse(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first two mutators see the same schema. The second one adds a
+	// table, so the third one sees a new schema that contains it.
+	var schemas []*Schema
+	record := SchemaAwareMutation(func(
+		rng *rand.Rand, schema *Schema, stmts []tree.Statement,
+	) (mutated []tree.Statement, changed bool) {
+		schemas = append(schemas, schema)
+		return stmts, false
+	})
+	addTable := SchemaAwareMutation(func(
+		rng *rand.Rand, schema *Schema, stmts []tree.Statement,
+	) (mutated []tree.Statement, changed bool) {
+		schemas = append(schemas, schema)
+		u := tree.MakeUnqualifiedTableName("u")
+		return append(stmts, &tree.CreateTable{Table: u}), true
+	})
+	rng, _ := randutil.NewPseudoRand()
+	_, changed := Apply(rng, stmtsFromParsed(parsed), record, addTable, record)
+	if !changed {
+		t.Fatal("expected changed")
+	}
+	if len(schemas) != 3 || schemas[0] != schemas[1] || schemas[1] == schemas[2] {
+		t.Fatalf("expected the schema to be rebuilt after the change")
+	}
+	if len(schemas[1].Tables()) != 1 || len(schemas[2].Tables()) != 2 {
+		t.Fatalf("expected the new table in the rebuilt schema")
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"context"
+	gosql "database/sql"
+	"fmt"
+	"math/rand"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+)
+
+// validationDatabase is the database in which ApplyAndValidate executes the
+// statements. It is dropped and created again for every execution.
+const validationDatabase = "mutations_validation"
+
+// ValidationError is returned by ApplyAndValidate when a statement fails to
+// execute.
+type ValidationError struct {
+	// Mutator is the name of the mutator whose output failed to execute. It
+	// is empty if the input statements failed to execute.
+	Mutator string
+	// Index is the index of the statement that failed.
+	Index int
+	// SQL is the text of the statement that failed.
+	SQL   string
+	cause error
+}
+
+func (e *ValidationError) Error() string {
+	if e.Mutator == "" {
+		return fmt.Sprintf("input statement %d: %s: %v", e.Index, e.SQL, e.cause)
+	}
+	return fmt.Sprintf("mutator %s: statement %d: %s: %v", e.Mutator, e.Index, e.SQL, e.cause)
+}
+
+func (e *ValidationError) Unwrap() error { return e.cause }
+
+// ApplyAndValidate is like Apply, but it executes the statements on conn
+// before the mutators run and after every mutator that changed them, so that
+// a mutator that produces invalid statements is caught and named. It is meant
+// for tests of mutators, with conn being a connection to a single-node test
+// server. The statements are executed in a database of their own, which is
+// created again for every execution, and conn is left using it.
+//
+// If the input statements fail to execute, a *ValidationError without a
+// mutator is returned. If the output of a mutator fails to execute, a
+// *ValidationError naming it is returned together with the statements
+// mutated by the mutators before it, which are valid. StringMutators cannot
+// be validated since they produce SQL for other databases.
+func ApplyAndValidate(
+	ctx context.Context,
+	conn *gosql.Conn,
+	rng *rand.Rand,
+	stmts []tree.Statement,
+	mutators ...rowenc.Mutator,
+) (mutated []tree.Statement, changed bool, err error) {
+	if err := executeStatements(ctx, conn, stmts); err != nil {
+		return nil, false, err
+	}
+	for _, m := range mutators {
+		if _, ok := m.(StringMutator); ok {
+			return nil, false, errors.AssertionFailedf("cannot validate StringMutator %s", mutatorName(m))
+		}
+		// Apply may modify the statements in place, so they are copied in
+		// case the mutation has to be discarded.
+		before := serializeStatements(stmts)
+		ms, mc := Apply(rng, stmts, m)
+		if !mc {
+			continue
+		}
+		if err := executeStatements(ctx, conn, ms); err != nil {
+			var verr *ValidationError
+			if errors.As(err, &verr) {
+				verr.Mutator = mutatorName(m)
+			}
+			valid, perr := parseStatements(before)
+			if perr != nil {
+				return nil, false, errors.CombineErrors(err, perr)
+			}
+			return valid, changed, err
+		}
+		stmts, changed = ms, true
+	}
+	return stmts, changed, nil
+}
+
+// executeStatements executes stmts on conn in a new validationDatabase. An
+// error that is returned by a statement is wrapped in a *ValidationError.
+func executeStatements(ctx context.Context, conn *gosql.Conn, stmts []tree.Statement) error {
+	for _, setup := range []string{
+		"DROP DATABASE IF EXISTS " + validationDatabase + " CASCADE",
+		"CREATE DATABASE " + validationDatabase,
+		"SET database = " + validationDatabase,
+	} {
+		if _, err := conn.ExecContext(ctx, setup); err != nil {
+			return errors.Wrap(err, "setting up validation database")
+		}
+	}
+	for i, stmt := range stmts {
+		sql := tree.Serialize(stmt)
+		if _, err := conn.ExecContext(ctx, sql); err != nil {
+			return &ValidationError{Index: i, SQL: sql, cause: err}
+		}
+	}
+	return nil
+}
+
+// parseStatements parses the SQL strings returned by serializeStatements.
+func parseStatements(sqls []string) ([]tree.Statement, error) {
+	stmts := make([]tree.Statement, len(sqls))
+	for i, sql := range sqls {
+		stmt, err := parser.ParseOne(sql)
+		if err != nil {
+			return nil, err
+		}
+		stmts[i] = stmt.AST
+	}
+	return stmts, nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations_test
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/sql/mutations"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/cockroachdb/errors"
+)
+
+func TestApplyAndValidate(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	parse := func(q string) []tree.Statement {
+		parsed, err := parser.Parse(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stmts := make([]tree.Statement, len(parsed))
+		for i, p := range parsed {
+			stmts[i] = p.AST
+		}
+		return stmts
+	}
+	const q = `
+		CREATE TABLE t (k INT8 PRIMARY KEY, i INT8, s STRING);
+		INSERT INTO t VALUES (1, 2, 'a');
+		SELECT k FROM t WHERE i > 0 AND s = 'a';
+	`
+	rng, _ := randutil.NewPseudoRand()
+
+	// invalidMutator makes the INSERT statements insert into a table that
+	// doesn't exist.
+	invalidMutator := mutations.StatementMutator(func(rng *rand.Rand, stmt tree.Statement) bool {
+		ins, ok := stmt.(*tree.Insert)
+		if !ok {
+			return false
+		}
+		ins.Table = tree.NewUnqualifiedTableName("missing")
+		return true
+	})
+
+	mutated, _, err := mutations.ApplyAndValidate(ctx, conn, rng, parse(q),
+		mutations.ColumnFamilyMutator, mutations.PredicateReorderMutator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mutated) != 3 {
+		t.Fatalf("expected 3 statements, found %d", len(mutated))
+	}
+
+	mutated, _, err = mutations.ApplyAndValidate(ctx, conn, rng, parse(q),
+		mutations.ColumnFamilyMutator, invalidMutator)
+	var verr *mutations.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected a validation error, found %v", err)
+	}
+	if verr.Index != 1 || !strings.Contains(verr.Mutator, "TestApplyAndValidate") {
+		t.Fatalf("expected statement 1 of the invalid mutator to fail, found %v", verr)
+	}
+	// The statements mutated before the invalid mutator are returned.
+	if len(mutated) != 3 || !strings.HasPrefix(tree.Serialize(mutated[1]), "INSERT INTO t") {
+		t.Fatalf("unexpected statements: %v", mutated)
+	}
+
+	// Invalid input is reported without a mutator.
+	_, _, err = mutations.ApplyAndValidate(ctx, conn, rng, parse(`SELECT * FROM missing`))
+	if !errors.As(err, &verr) || verr.Mutator != "" || verr.Index != 0 {
+		t.Fatalf("expected a validation error of the input, found %v", err)
+	}
+}
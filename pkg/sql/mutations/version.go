@@ -0,0 +1,44 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+)
+
+// VersionedMutator is a Mutator that is tagged with the minimum cluster
+// version whose syntax it emits.
+type VersionedMutator struct {
+	rowenc.Mutator
+	// MinVersion is the minimum cluster version that supports all the
+	// statements emitted by the mutator.
+	MinVersion clusterversion.Key
+}
+
+// ForVersion returns the mutators that only emit syntax that is supported by
+// clusters at version v. Mutators that are not VersionedMutators are assumed
+// to be supported by all versions.
+//
+// It is used by mixed-version tests to generate schemas that are valid on the
+// older binary before the upgrade, while all mutators can be used once the
+// upgrade is finalized.
+func ForVersion(v roachpb.Version, mutators ...rowenc.Mutator) []rowenc.Mutator {
+	res := make([]rowenc.Mutator, 0, len(mutators))
+	for _, m := range mutators {
+		if vm, ok := m.(VersionedMutator); ok && v.Less(clusterversion.ByKey(vm.MinVersion)) {
+			continue
+		}
+		res = append(res, m)
+	}
+	return res
+}
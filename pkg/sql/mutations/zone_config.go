@@ -0,0 +1,165 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+)
+
+// ZoneConfigMutator adds ALTER TABLE ... CONFIGURE ZONE and ALTER INDEX ...
+// CONFIGURE ZONE statements after the statements that create random tables
+// and named indexes. Since it doesn't know the localities of the nodes of the
+// cluster, it only adds prohibited constraints and lease preferences, which
+// don't have to match any node. Use MakeZoneConfigMutator to add required
+// ones as well.
+var ZoneConfigMutator = MakeZoneConfigMutator(nil)
+
+// zoneConfigLocalityKeys are the keys of the locality tiers used in the
+// prohibited constraints added by ZoneConfigMutator.
+var zoneConfigLocalityKeys = []string{"region", "zone", "dc", "rack"}
+
+// MakeZoneConfigMutator returns a mutator like ZoneConfigMutator that also
+// adds required constraints and lease preferences, which are tiers of the
+// given localities. The localities must be the ones of nodes of the cluster,
+// since required constraints that don't match any node are rejected.
+func MakeZoneConfigMutator(localities []roachpb.Locality) rowenc.Mutator {
+	var tiers []roachpb.Tier
+	for _, l := range localities {
+		tiers = append(tiers, l.Tiers...)
+	}
+	return VersionedMutator{
+		MultiStatementMutation(func(rng *rand.Rand, stmts []tree.Statement) (mutated []tree.Statement, changed bool) {
+			return zoneConfigMutator(rng, stmts, tiers)
+		}),
+		clusterversion.V20_2,
+	}
+}
+
+func zoneConfigMutator(
+	rng *rand.Rand, stmts []tree.Statement, tiers []roachpb.Tier,
+) (mutated []tree.Statement, changed bool) {
+	for _, stmt := range stmts {
+		mutated = append(mutated, stmt)
+		var targets []tree.TableIndexName
+		switch stmt := stmt.(type) {
+		case *tree.CreateTable:
+			if stmt.Persistence.IsTemporary() {
+				continue
+			}
+			targets = append(targets, tree.TableIndexName{Table: stmt.Table})
+			for _, def := range stmt.Defs {
+				var name tree.Name
+				switch def := def.(type) {
+				case *tree.IndexTableDef:
+					name = def.Name
+				case *tree.UniqueConstraintTableDef:
+					if !def.PrimaryKey && !def.WithoutIndex {
+						name = def.Name
+					}
+				}
+				if name != "" {
+					targets = append(targets, tree.TableIndexName{
+						Table: stmt.Table, Index: tree.UnrestrictedName(name),
+					})
+				}
+			}
+		case *tree.CreateIndex:
+			if stmt.Name != "" {
+				targets = append(targets, tree.TableIndexName{
+					Table: stmt.Table, Index: tree.UnrestrictedName(stmt.Name),
+				})
+			}
+		}
+		for _, target := range targets {
+			if rng.Intn(4) != 0 {
+				continue
+			}
+			mutated = append(mutated, &tree.SetZoneConfig{
+				ZoneSpecifier: tree.ZoneSpecifier{TableOrIndex: target},
+				Options:       randZoneConfigOptions(rng, tiers),
+			})
+			changed = true
+		}
+	}
+	return mutated, changed
+}
+
+// randZoneConfigOptions returns a random, non-empty list of zone config
+// options. Required constraints and lease preferences use the given tiers.
+func randZoneConfigOptions(rng *rand.Rand, tiers []roachpb.Tier) tree.KVOptions {
+	var opts tree.KVOptions
+	for len(opts) == 0 {
+		if rng.Intn(2) == 0 {
+			numReplicas := []int{1, 3, 5}[rng.Intn(3)]
+			opts = append(opts, tree.KVOption{
+				Key: "num_replicas", Value: tree.NewDInt(tree.DInt(numReplicas)),
+			})
+		}
+		if rng.Intn(2) == 0 {
+			opts = append(opts, tree.KVOption{
+				Key: "gc.ttlseconds", Value: tree.NewDInt(tree.DInt(600 + rng.Intn(90000))),
+			})
+		}
+		if rng.Intn(2) == 0 {
+			constraints := randZoneConstraints(rng, tiers)
+			opts = append(opts, tree.KVOption{
+				Key: "constraints", Value: tree.NewStrVal("[" + strings.Join(constraints, ", ") + "]"),
+			})
+		}
+		if rng.Intn(3) == 0 {
+			// Every lease preference is a list of constraints, in the order
+			// of preference.
+			var prefs []string
+			for i := 1 + rng.Intn(2); i > 0; i-- {
+				prefs = append(prefs, "["+strings.Join(randZoneConstraints(rng, tiers), ", ")+"]")
+			}
+			opts = append(opts, tree.KVOption{
+				Key: "lease_preferences", Value: tree.NewStrVal("[" + strings.Join(prefs, ", ") + "]"),
+			})
+		}
+	}
+	return opts
+}
+
+// randZoneConstraints returns a random, non-empty list of constraints that
+// apply to all replicas. The required constraints are the given tiers, and
+// the prohibited ones are random tiers and attributes.
+func randZoneConstraints(rng *rand.Rand, tiers []roachpb.Tier) []string {
+	var constraints []string
+	seen := map[string]bool{}
+	add := func(c string) {
+		if !seen[c] {
+			seen[c] = true
+			constraints = append(constraints, c)
+		}
+	}
+	if len(tiers) > 0 && rng.Intn(2) == 0 {
+		tier := tiers[rng.Intn(len(tiers))]
+		add(fmt.Sprintf("+%s=%s", tier.Key, tier.Value))
+	}
+	for len(constraints) == 0 || rng.Intn(2) == 0 {
+		if rng.Intn(3) == 0 {
+			// An attribute of nodes or stores.
+			add(fmt.Sprintf("-mut_attr%d", rng.Intn(3)))
+			continue
+		}
+		key := zoneConfigLocalityKeys[rng.Intn(len(zoneConfigLocalityKeys))]
+		add(fmt.Sprintf("-%s=mut-%s-%d", key, key, rng.Intn(3)))
+	}
+	return constraints
+}
@@ -0,0 +1,84 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package mutations
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+func TestZoneConfigMutator(t *testing.T) {
+	q := `
+		CREATE TABLE t (k INT8 PRIMARY KEY, i INT8, s STRING, INDEX idx (i), UNIQUE INDEX (s));
+		CREATE INDEX idx2 ON t (s);
+		CREATE TEMP TABLE tmp (k INT8 PRIMARY KEY);
+	`
+	var locality roachpb.Locality
+	if err := locality.Set("region=us-east1,zone=us-east1-b"); err != nil {
+		t.Fatal(err)
+	}
+	rng, _ := randutil.NewPseudoRand()
+	for _, tc := range []struct {
+		mutator  rowenc.Mutator
+		required bool
+	}{
+		{ZoneConfigMutator, false},
+		{MakeZoneConfigMutator([]roachpb.Locality{locality}), true},
+	} {
+		targets := map[string]bool{}
+		foundRequired := false
+		for i := 0; i < 1000; i++ {
+			mutated, changed := ApplyString(rng, q, tc.mutator)
+			if !changed {
+				continue
+			}
+			parsed, err := parser.Parse(mutated)
+			if err != nil {
+				t.Fatalf("error parsing %s: %v", mutated, err)
+			}
+			for _, p := range parsed {
+				zone, ok := p.AST.(*tree.SetZoneConfig)
+				if !ok {
+					continue
+				}
+				targets[tree.AsString(&zone.ZoneSpecifier)] = true
+				for _, opt := range zone.Options {
+					s := tree.AsString(opt.Value)
+					if strings.Contains(s, "+") {
+						if !strings.Contains(s, "+region=us-east1") && !strings.Contains(s, "+zone=us-east1-b") {
+							t.Fatalf("unexpected required constraint: %s", mutated)
+						}
+						foundRequired = true
+					}
+				}
+			}
+		}
+		// The temporary table and the unnamed index are not configured.
+		expected := map[string]bool{"TABLE t": true, "INDEX t@idx": true, "INDEX t@idx2": true}
+		if len(targets) != len(expected) {
+			t.Fatalf("expected zone configs of %v, found %v", expected, targets)
+		}
+		for target := range targets {
+			if !expected[target] {
+				t.Fatalf("unexpected zone config of %s", target)
+			}
+		}
+		if foundRequired != tc.required {
+			t.Fatalf("expected required constraints: %t, found: %t", tc.required, foundRequired)
+		}
+	}
+}
@@ -11,6 +11,7 @@
 package cat
 
 import (
+	"math"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
@@ -200,6 +201,50 @@ type HistogramBucket struct {
 	UpperBound tree.Datum
 }
 
+// Statistics can be injected by users or generated randomly by tests, so their
+// counts (row, distinct and null counts as well as histogram bucket counts) can
+// be absurdly large or even negative. The functions below define how these
+// counts are consumed: negative counts are treated as zero, and sums and
+// conversions saturate at the largest representable count instead of
+// overflowing.
+
+// StatCount converts a count that is stored as a signed integer into an
+// unsigned count. Negative counts are treated as zero.
+func StatCount(count int64) uint64 {
+	if count < 0 {
+		return 0
+	}
+	return uint64(count)
+}
+
+// HistogramBucketCount converts a histogram bucket count that is stored as a
+// signed integer into the float used by HistogramBucket. Negative counts are
+// treated as zero.
+func HistogramBucketCount(count int64) float64 {
+	return float64(StatCount(count))
+}
+
+// AddStatCounts returns a+b, saturating at math.MaxUint64.
+func AddStatCounts(a, b uint64) uint64 {
+	if sum := a + b; sum >= a {
+		return sum
+	}
+	return math.MaxUint64
+}
+
+// EstimatedCount converts a row count estimated by the optimizer into an
+// unsigned count. Negative and NaN estimates are treated as zero, and
+// estimates that don't fit into a uint64 saturate at math.MaxUint64.
+func EstimatedCount(estimate float64) uint64 {
+	if !(estimate > 0) {
+		return 0
+	}
+	if estimate >= math.MaxUint64 {
+		return math.MaxUint64
+	}
+	return uint64(estimate)
+}
+
 // ForeignKeyConstraint represents a foreign key constraint. A foreign key
 // constraint has an origin (or referencing) side and a referenced side. For
 // example:
@@ -357,7 +357,7 @@ func (e *emitter) emitNodeAttributes(n *Node) error {
 
 		// Show the estimated row count (except Values, where it is redundant).
 		if n.op != valuesOp {
-			count := uint64(math.Round(s.RowCount))
+			count := cat.EstimatedCount(math.Round(s.RowCount))
 			if s.TableStatsAvailable {
 				if n.op == scanOp && s.TableStatsRowCount != 0 {
 					percentage := s.RowCount / float64(s.TableStatsRowCount) * 100
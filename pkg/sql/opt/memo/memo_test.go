@@ -13,9 +13,11 @@ package memo_test
 import (
 	"context"
 	"fmt"
+	"math"
 	"testing"
 
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/mutations"
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/memo"
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/norm"
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/optbuilder"
@@ -26,6 +28,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 	"github.com/cockroachdb/datadriven"
 )
 
@@ -331,6 +334,56 @@ func TestStatsAvailable(t *testing.T) {
 	testAvailable(o.Memo().RootExpr().(memo.RelExpr))
 }
 
+// TestExtremeStats verifies that statistics with counts close to the maximum
+// int64 value never cause panics or negative, NaN or infinite estimates.
+func TestExtremeStats(t *testing.T) {
+	evalCtx := tree.MakeTestingEvalContext(cluster.MakeTestingClusterSettings())
+	rng, _ := randutil.NewPseudoRand()
+
+	const schema = `
+CREATE TABLE a (x INT PRIMARY KEY, y INT, s STRING, d DATE, INDEX (y, s), INDEX (d));
+CREATE TABLE b (x INT, y INT, s STRING, f FLOAT, INDEX (x), UNIQUE (s, y));
+`
+	queries := []string{
+		"SELECT * FROM a WHERE x > 10",
+		"SELECT * FROM a WHERE y = 5 AND s < 'foo'",
+		"SELECT * FROM a WHERE d BETWEEN '2000-01-01' AND '2020-01-01'",
+		"SELECT * FROM b WHERE f > 1.5 OR x IS NULL",
+		"SELECT y, count(*) FROM a GROUP BY y",
+		"SELECT DISTINCT s, y FROM b",
+		"SELECT * FROM a JOIN b ON a.x = b.x",
+		"SELECT * FROM a LEFT JOIN b ON a.y = b.y WHERE b.s > 'bar'",
+		"SELECT * FROM a FULL JOIN b ON a.s = b.s",
+		"SELECT x FROM a UNION ALL SELECT x FROM b",
+		"SELECT x FROM a EXCEPT SELECT x FROM b",
+		"SELECT * FROM a, b, a AS c, b AS d WHERE a.x = d.y",
+	}
+
+	for i := 0; i < 10; i++ {
+		mutated, _ := mutations.ApplyString(rng, schema, mutations.ExtremeStatisticsMutator)
+		catalog := testcat.New()
+		if err := catalog.ExecuteMultipleDDL(mutated); err != nil {
+			t.Fatalf("%s: %v", mutated, err)
+		}
+
+		var o xform.Optimizer
+		for _, query := range queries {
+			opttestutils.BuildQuery(t, &o, catalog, &evalCtx, query)
+			expr, err := o.Optimize()
+			if err != nil {
+				t.Fatalf("%s: %v", query, err)
+			}
+			traverseExpr(expr.(memo.RelExpr), func(e memo.RelExpr) {
+				rowCount := e.Relational().Stats.RowCount
+				if math.IsNaN(rowCount) || math.IsInf(rowCount, 0) || rowCount < 0 {
+					t.Fatalf("%s: invalid row count %f for %s\nwith stats:\n%s",
+						query, rowCount, e.Op(), mutated)
+				}
+			})
+		}
+	}
+}
+
 // traverseExpr is a helper function to recursively traverse a relational
 // expression and apply a function to the root as well as each relational
 // child.
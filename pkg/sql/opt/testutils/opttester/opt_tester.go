@@ -14,7 +14,6 @@ import (
 	"bytes"
 	"context"
 	gosql "database/sql"
-	"encoding/json"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -348,6 +347,13 @@ func New(catalog cat.Catalog, sql string) *OptTester {
 //    check-size will result in a test error if the rule application or memo
 //    group count exceeds the corresponding limit.
 //
+//  - check-plan-shape [flags]
+//
+//    Fully optimizes the given query and checks the resulting plan for
+//    structural properties that are expected to hold, like the absence of
+//    full scans when a covering index can be constrained. Outputs one line
+//    per violation, or "ok" if there are none. See CheckPlanShape.
+//
 // Supported flags:
 //
 //  - format: controls the formatting of expressions for build, opt, and
@@ -632,6 +638,13 @@ func (ot *OptTester) RunCommand(tb testing.TB, d *datadriven.TestData) string {
 		}
 		return result
 
+	case "check-plan-shape":
+		violations, err := ot.CheckPlanShape()
+		if err != nil {
+			d.Fatalf(tb, "%+v", err)
+		}
+		return FormatPlanShapeViolations(violations)
+
 	default:
 		d.Fatalf(tb, "unsupported command: %s", d.Cmd)
 		return ""
@@ -1573,16 +1586,11 @@ func (ot *OptTester) injectStats(name tree.TableName, jsonStats []stats.JSONStat
 		return fmt.Errorf("injectStats can only be used with TestCatalog")
 	}
 
-	encoded, err := json.Marshal(jsonStats)
-	if err != nil {
-		return err
-	}
-	alterStmt := fmt.Sprintf("ALTER TABLE %s INJECT STATISTICS '%s'", name.String(), encoded)
-	stmt, err := parser.ParseOne(alterStmt)
+	alter, err := stats.MakeAlterTableInjectStats(name.ToUnresolvedObjectName(), jsonStats)
 	if err != nil {
 		return err
 	}
-	catalog.AlterTable(stmt.AST.(*tree.AlterTable))
+	catalog.AlterTable(alter)
 	return nil
 }
 
@@ -0,0 +1,200 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opttester
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/opt"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/cat"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/memo"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/props/physical"
+)
+
+// PlanShapeViolation describes a structural property that was expected to hold
+// for an optimized plan, but did not. Violations are soft failures: the plan
+// still produces correct results, but it is likely that the optimizer missed a
+// better plan. They are intended to be collected by randomized tests and
+// reported for optimizer triage, rather than failing the test.
+type PlanShapeViolation struct {
+	// Check is the name of the check that was violated.
+	Check string
+	// Details describes the violation.
+	Details string
+}
+
+func (v PlanShapeViolation) String() string {
+	return fmt.Sprintf("%s: %s", v.Check, v.Details)
+}
+
+const (
+	// fullScanWithCoveringIndex is violated when an unconstrained scan is
+	// filtered on the leading key column of an index which covers all of the
+	// columns needed from the scan.
+	fullScanWithCoveringIndex = "full-scan-with-covering-index"
+
+	// sortWithProvidedOrdering is violated when a sort is planned even though
+	// its input already provides the required ordering, or when the input is
+	// an unconstrained scan and a covering index provides the ordering.
+	sortWithProvidedOrdering = "sort-with-provided-ordering"
+)
+
+// CheckPlanShape fully optimizes the SQL query and checks the resulting plan
+// for the structural properties described by fullScanWithCoveringIndex and
+// sortWithProvidedOrdering. It is intended to be used with a catalog built
+// from randomly generated (and mutated) schemas with injected statistics.
+func (ot *OptTester) CheckPlanShape() ([]PlanShapeViolation, error) {
+	e, err := ot.Optimize()
+	if err != nil {
+		return nil, err
+	}
+	rel, ok := e.(memo.RelExpr)
+	if !ok {
+		return nil, nil
+	}
+	c := planShapeChecker{md: rel.Memo().Metadata()}
+	c.check(e)
+	return c.violations, nil
+}
+
+// FormatPlanShapeViolations returns a string with one violation per line, or
+// "ok" if there are no violations.
+func FormatPlanShapeViolations(violations []PlanShapeViolation) string {
+	if len(violations) == 0 {
+		return "ok\n"
+	}
+	var sb strings.Builder
+	for _, v := range violations {
+		sb.WriteString(v.String())
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+type planShapeChecker struct {
+	md         *opt.Metadata
+	violations []PlanShapeViolation
+}
+
+func (c *planShapeChecker) check(e opt.Expr) {
+	switch t := e.(type) {
+	case *memo.SelectExpr:
+		if scan, ok := t.Input.(*memo.ScanExpr); ok {
+			c.checkFilteredScan(scan, t.Filters)
+		}
+
+	case *memo.SortExpr:
+		c.checkSort(t)
+	}
+
+	for i, n := 0, e.ChildCount(); i < n; i++ {
+		c.check(e.Child(i))
+	}
+}
+
+// checkFilteredScan checks that an unconstrained scan with filters on top of it
+// could not have been replaced with a constrained scan over a covering index.
+func (c *planShapeChecker) checkFilteredScan(scan *memo.ScanExpr, filters memo.FiltersExpr) {
+	if !scan.IsUnfiltered(c.md) {
+		return
+	}
+	var constrainedCols opt.ColSet
+	for i := range filters {
+		if cs := filters[i].ScalarProps().Constraints; cs != nil {
+			constrainedCols.UnionWith(cs.ExtractCols())
+		}
+	}
+	if constrainedCols.Empty() {
+		return
+	}
+	tm := c.md.TableMeta(scan.Table)
+	for ord, n := 0, tm.Table.IndexCount(); ord < n; ord++ {
+		index := tm.Table.Index(ord)
+		if !c.isCoveringIndex(tm, index, scan.Cols) {
+			continue
+		}
+		leadingCol := tm.MetaID.ColumnID(index.Column(0).Ordinal())
+		if constrainedCols.Contains(leadingCol) {
+			c.violations = append(c.violations, PlanShapeViolation{
+				Check: fullScanWithCoveringIndex,
+				Details: fmt.Sprintf(
+					"full scan of %s@%s, but index %s is constrained by the filters and covers the scan",
+					tm.Alias.Table(), tm.Table.Index(scan.Index).Name(), index.Name(),
+				),
+			})
+			return
+		}
+	}
+}
+
+// checkSort checks that a sort is not planned when its input already provides
+// the required ordering, or when the input is an unconstrained scan and there
+// is a covering index which provides the required ordering.
+func (c *planShapeChecker) checkSort(sort *memo.SortExpr) {
+	required := &sort.RequiredPhysical().Ordering
+	if required.Any() {
+		return
+	}
+	var provided physical.OrderingChoice
+	provided.FromOrdering(sort.Input.ProvidedPhysical().Ordering)
+	if !provided.Any() && provided.Implies(required) {
+		c.violations = append(c.violations, PlanShapeViolation{
+			Check:   sortWithProvidedOrdering,
+			Details: fmt.Sprintf("sort on %s, but the input already provides it", required),
+		})
+		return
+	}
+
+	scan, ok := sort.Input.(*memo.ScanExpr)
+	if !ok || !scan.IsUnfiltered(c.md) {
+		return
+	}
+	tm := c.md.TableMeta(scan.Table)
+	for ord, n := 0, tm.Table.IndexCount(); ord < n; ord++ {
+		if ord == scan.Index {
+			continue
+		}
+		index := tm.Table.Index(ord)
+		if !c.isCoveringIndex(tm, index, scan.Cols) {
+			continue
+		}
+		var indexOrdering physical.OrderingChoice
+		for i, n := 0, index.KeyColumnCount(); i < n; i++ {
+			col := index.Column(i)
+			indexOrdering.AppendCol(tm.MetaID.ColumnID(col.Ordinal()), col.Descending)
+		}
+		if indexOrdering.Implies(required) {
+			c.violations = append(c.violations, PlanShapeViolation{
+				Check: sortWithProvidedOrdering,
+				Details: fmt.Sprintf(
+					"sort on %s over a full scan of %s@%s, but index %s provides the ordering",
+					required, tm.Alias.Table(), tm.Table.Index(scan.Index).Name(), index.Name(),
+				),
+			})
+			return
+		}
+	}
+}
+
+// isCoveringIndex returns true if the given index is a non-inverted,
+// non-partial index which contains all of the given columns.
+func (c *planShapeChecker) isCoveringIndex(
+	tm *opt.TableMeta, index cat.Index, cols opt.ColSet,
+) bool {
+	if index.IsInverted() {
+		return false
+	}
+	if _, isPartial := index.Predicate(); isPartial {
+		return false
+	}
+	return cols.SubsetOf(tm.IndexColumns(index.Ordinal()))
+}
@@ -1017,8 +1017,8 @@ func (ts *TableStat) Histogram() []cat.HistogramBucket {
 			panic(err)
 		}
 		histogram[i] = cat.HistogramBucket{
-			NumEq:         float64(bucket.NumEq),
-			NumRange:      float64(bucket.NumRange),
+			NumEq:         cat.HistogramBucketCount(bucket.NumEq),
+			NumRange:      cat.HistogramBucketCount(bucket.NumRange),
 			DistinctRange: bucket.DistinctRange,
 			UpperBound:    datum,
 		}
@@ -1000,7 +1000,7 @@ func Tokens(sql string) (tokens []TokenString, ok bool) {
 		if lval.id == 0 {
 			break
 		}
-		tokens = append(tokens, TokenString{TokenID: lval.id, Str: lval.str})
+		tokens = append(tokens, TokenString{TokenID: lval.id, Str: lval.str, Pos: lval.pos})
 	}
 	return tokens, true
 }
@@ -1009,6 +1009,8 @@ func Tokens(sql string) (tokens []TokenString, ok bool) {
 type TokenString struct {
 	TokenID int32
 	Str     string
+	// Pos is the byte offset of the start of the token in the input.
+	Pos int32
 }
 
 // LastLexicalToken returns the last lexical token. If the string has no lexical
@@ -942,6 +942,11 @@ func (p *PhysicalPlan) GenerateFlowSpecs() map[roachpb.NodeID]*execinfrapb.FlowS
 // plans.
 func (p *PhysicalPlan) SetRowEstimates(left, right *PhysicalPlan) {
 	p.TotalEstimatedScannedRows = left.TotalEstimatedScannedRows + right.TotalEstimatedScannedRows
+	if p.TotalEstimatedScannedRows < left.TotalEstimatedScannedRows {
+		// The estimates can be absurdly large, so saturate instead of
+		// overflowing.
+		p.TotalEstimatedScannedRows = math.MaxUint64
+	}
 }
 
 // MergePlans is used when merging two plans into a new plan. All plans must
@@ -14,6 +14,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
@@ -76,19 +77,10 @@ func (r *rowSourceToPlanNode) Next(params runParams) (bool, error) {
 		r.row, p = r.source.Next()
 
 		if p != nil {
-			if p.Err != nil {
-				return false, p.Err
-			}
-			if r.forwarder != nil {
-				r.forwarder.forwardMetadata(p)
-				continue
-			}
-			if p.TraceData != nil {
-				// We drop trace metadata since we have no reasonable way to propagate
-				// it in local SQL execution.
-				continue
+			if err := r.processMetadata(p); err != nil {
+				return false, err
 			}
-			return false, fmt.Errorf("unexpected producer metadata: %+v", p)
+			continue
 		}
 
 		if r.row == nil {
@@ -109,6 +101,41 @@ func (r *rowSourceToPlanNode) Next(params runParams) (bool, error) {
 	}
 }
 
+// nextBatch is like Next, but it returns the next batch of the wrapped
+// RowSource, which must be a batchRowSource. nil is returned once the source
+// is exhausted.
+func (r *rowSourceToPlanNode) nextBatch() (coldata.Batch, error) {
+	source := r.source.(batchRowSource)
+	for {
+		batch, p := source.NextBatch()
+		if p != nil {
+			if err := r.processMetadata(p); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return batch, nil
+	}
+}
+
+// processMetadata handles a piece of metadata received from the wrapped
+// RowSource.
+func (r *rowSourceToPlanNode) processMetadata(p *execinfrapb.ProducerMetadata) error {
+	if p.Err != nil {
+		return p.Err
+	}
+	if r.forwarder != nil {
+		r.forwarder.forwardMetadata(p)
+		return nil
+	}
+	if p.TraceData != nil {
+		// We drop trace metadata since we have no reasonable way to propagate
+		// it in local SQL execution.
+		return nil
+	}
+	return fmt.Errorf("unexpected producer metadata: %+v", p)
+}
+
 func (r *rowSourceToPlanNode) Values() tree.Datums {
 	return r.datumRow
 }
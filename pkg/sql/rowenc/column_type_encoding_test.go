@@ -12,7 +12,11 @@ package rowenc
 
 import (
 	"bytes"
+	"flag"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
@@ -21,6 +25,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/util/encoding"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/leanovate/gopter"
@@ -156,33 +161,8 @@ func TestEncodeTableKey(t *testing.T) {
 	))
 
 	generateAndCompareDatums := func(datums []tree.Datum, dir encoding.Direction) string {
-		d1 := datums[0]
-		d2 := datums[1]
-		b1, err := EncodeTableKey(nil, d1, dir)
-		if err != nil {
-			return "error: " + err.Error()
-		}
-		b2, err := EncodeTableKey(nil, d2, dir)
-		if err != nil {
-			return "error: " + err.Error()
-		}
-
-		expectedCmp := d1.Compare(ctx, d2)
-		cmp := bytes.Compare(b1, b2)
-
-		if expectedCmp == 0 {
-			if cmp != 0 {
-				return fmt.Sprintf("equal inputs produced inequal outputs: \n%v\n%v", b1, b2)
-			}
-			// If the inputs are equal and so are the outputs, no more checking to do.
-			return ""
-		}
-
-		cmpsMatch := expectedCmp == cmp
-		dirIsAscending := dir == encoding.Ascending
-
-		if cmpsMatch != dirIsAscending {
-			return fmt.Sprintf("non-order preserving encoding: \n%v\n%v", b1, b2)
+		if err := CheckTableKeyOrdering(ctx, datums[0], datums[1], dir); err != nil {
+			return err.Error()
 		}
 		return ""
 	}
@@ -233,9 +213,124 @@ func TestEncodeTableKey(t *testing.T) {
 		genEncodingDirection(),
 	))
 
+	// Check that keys are also stable: the decoded datums must encode to the
+	// same keys.
+	properties.Property("stable", prop.ForAll(
+		func(d tree.Datum, dir encoding.Direction) string {
+			if _, err := CheckTableKeyRoundTrip(ctx, a, d, dir); err != nil {
+				return err.Error()
+			}
+			return ""
+		},
+		genRandomArrayType().
+			SuchThat(hasKeyEncoding).
+			FlatMap(genArrayDatumWithType, reflect.TypeOf((*tree.Datum)(nil)).Elem()),
+		genEncodingDirection(),
+	))
+
 	properties.TestingRun(t)
 }
 
+func TestCheckTableKeyOrdering(t *testing.T) {
+	ctx := tree.NewTestingEvalContext(cluster.MakeTestingClusterSettings())
+	for _, tc := range []struct {
+		d1, d2 tree.Datum
+	}{
+		{tree.NewDInt(1), tree.NewDInt(2)},
+		{tree.NewDInt(2), tree.NewDInt(2)},
+		{tree.NewDInt(-1), tree.NewDInt(-2)},
+		{tree.DNull, tree.NewDInt(0)},
+		{tree.NewDString("a"), tree.NewDString("ab")},
+		{tree.NewDString("b"), tree.NewDString("ab")},
+	} {
+		for _, dir := range []encoding.Direction{encoding.Ascending, encoding.Descending} {
+			require.NoError(t, CheckTableKeyOrdering(ctx, tc.d1, tc.d2, dir))
+			require.NoError(t, CheckTableKeyOrdering(ctx, tc.d2, tc.d1, dir))
+		}
+	}
+}
+
+func TestEncodeInvertedIndexTableKeysProperties(t *testing.T) {
+	a := &DatumAlloc{}
+	ctx := tree.NewTestingEvalContext(cluster.MakeTestingClusterSettings())
+	parameters := gopter.DefaultTestParameters()
+	parameters.MinSuccessfulTests = 1000
+	properties := gopter.NewProperties(parameters)
+	checkKeys := func(d tree.Datum) string {
+		if _, err := CheckInvertedIndexTableKeys(ctx, a, d, []byte("prefix")); err != nil {
+			return err.Error()
+		}
+		return ""
+	}
+	properties.Property("arrays", prop.ForAll(
+		checkKeys,
+		genRandomArrayType().
+			SuchThat(hasKeyEncoding).
+			FlatMap(genArrayDatumWithType, reflect.TypeOf((*tree.Datum)(nil)).Elem()),
+	))
+	properties.Property("json", prop.ForAll(
+		checkKeys,
+		genDatumWithType(types.Jsonb),
+	))
+	properties.TestingRun(t)
+}
+
+var rewriteGoldenDatums = flag.Bool(
+	"rewrite-golden-datums", false, "rewrite testdata/golden_datums from RandGoldenDatums",
+)
+
+// goldenDatumTypes are the types of the datums in testdata/golden_datums.
+var goldenDatumTypes = append([]*types.T{
+	types.MakeArray(types.String),
+	types.MakeTuple([]*types.T{types.Int, types.String}),
+	types.MakeCollatedString(types.String, "en"),
+}, types.Scalar...)
+
+// TestEncodeGoldenDatums checks that the datums of testdata/golden_datums
+// round trip through the value and key encodings. The datums are snapshotted
+// so that the coverage of the test doesn't change when the random datum
+// generators change; run with -rewrite-golden-datums to snapshot them again.
+func TestEncodeGoldenDatums(t *testing.T) {
+	path := testutils.TestDataPath(t, "golden_datums")
+	if *rewriteGoldenDatums {
+		var buf bytes.Buffer
+		datums := RandGoldenDatums(1 /* seed */, goldenDatumTypes, 20 /* numDatums */)
+		require.NoError(t, WriteGoldenDatums(&buf, goldenDatumTypes, datums))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, buf.Bytes(), 0644))
+	}
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	datums, err := ReadGoldenDatums(f, goldenDatumTypes)
+	require.NoError(t, err)
+
+	a := &DatumAlloc{}
+	ctx := tree.NewTestingEvalContext(cluster.MakeTestingClusterSettings())
+	for i, typ := range goldenDatumTypes {
+		for _, d := range datums[i] {
+			b, err := EncodeTableValue(nil, 0, d, nil)
+			require.NoError(t, err)
+			newD, rest, err := DecodeTableValue(a, typ, b)
+			require.NoError(t, err)
+			require.Empty(t, rest)
+			require.Zero(t, newD.Compare(ctx, d), "%s: %s != %s", typ, newD, d)
+
+			if !hasKeyEncoding(typ) {
+				continue
+			}
+			for _, dir := range []encoding.Direction{encoding.Ascending, encoding.Descending} {
+				b, err := EncodeTableKey(nil, d, dir)
+				require.NoError(t, err)
+				newD, rest, err := DecodeTableKey(a, typ, b, dir)
+				require.NoError(t, err)
+				require.Empty(t, rest)
+				require.Zero(t, newD.Compare(ctx, d), "%s: %s != %s", typ, newD, d)
+			}
+		}
+	}
+}
+
 func TestSkipTableKey(t *testing.T) {
 	parameters := gopter.DefaultTestParameters()
 	parameters.MinSuccessfulTests = 10000
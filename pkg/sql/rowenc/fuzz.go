@@ -0,0 +1,81 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// +build gofuzz
+
+package rowenc
+
+import (
+	"encoding/binary"
+	"math/rand"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+)
+
+var (
+	fuzzEvalCtx = tree.NewTestingEvalContext(cluster.MakeTestingClusterSettings())
+
+	// fuzzKeyTypes are the types of the datums generated by FuzzTableKey,
+	// whose keys round trip.
+	fuzzKeyTypes = []*types.T{
+		types.Bool, types.Int, types.Float, types.String, types.Bytes, types.Date,
+		types.Timestamp, types.TimestampTZ, types.Interval, types.Uuid, types.INet,
+		types.Time, types.TimeTZ, types.VarBit, types.Oid,
+		types.IntArray, types.StringArray,
+	}
+)
+
+// FuzzTableKey generates two random datums of one of fuzzKeyTypes from a
+// random number generator seeded with the first 8 bytes of data, and checks
+// that their keys round trip and are ordered like the datums in a random
+// direction. The datums are generated rather than decoded from data, since
+// arbitrary bytes can decode to datums that don't round trip, like
+// non-canonical keys.
+func FuzzTableKey(data []byte) int {
+	if len(data) < 8 {
+		return 0
+	}
+	rng := rand.New(rand.NewSource(int64(binary.BigEndian.Uint64(data))))
+	typ := fuzzKeyTypes[rng.Intn(len(fuzzKeyTypes))]
+	dir := encoding.Ascending
+	if rng.Intn(2) == 1 {
+		dir = encoding.Descending
+	}
+	d1 := RandDatum(rng, typ, true /* nullOk */)
+	d2 := RandDatum(rng, typ, true /* nullOk */)
+	var a DatumAlloc
+	for _, d := range []tree.Datum{d1, d2} {
+		if _, err := CheckTableKeyRoundTrip(fuzzEvalCtx, &a, d, dir); err != nil {
+			panic(err)
+		}
+	}
+	if err := CheckTableKeyOrdering(fuzzEvalCtx, d1, d2, dir); err != nil {
+		panic(err)
+	}
+	return 1
+}
+
+// FuzzInvertedIndexTableKeys parses data as a JSON document and checks its
+// inverted index keys.
+func FuzzInvertedIndexTableKeys(data []byte) int {
+	j, err := json.ParseJSON(string(data))
+	if err != nil {
+		return 0
+	}
+	var a DatumAlloc
+	if _, err := CheckInvertedIndexTableKeys(fuzzEvalCtx, &a, tree.NewDJSON(j), nil /* inKey */); err != nil {
+		panic(err)
+	}
+	return 1
+}
@@ -11,9 +11,13 @@
 package rowenc
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	gosql "database/sql"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"math"
 	"math/big"
 	"math/bits"
@@ -33,10 +37,13 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/enum"
+	"github.com/cockroachdb/cockroach/pkg/sql/oidext"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/bitarray"
 	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
 	"github.com/cockroachdb/cockroach/pkg/util/ipaddr"
 	"github.com/cockroachdb/cockroach/pkg/util/json"
 	"github.com/cockroachdb/cockroach/pkg/util/randutil"
@@ -71,12 +78,23 @@ func RandDatum(rng *rand.Rand, typ *types.T, nullOk bool) tree.Datum {
 // Note that if typ.Family is UNKNOWN, the datum will always be
 // DNull, regardless of the null flag.
 func RandDatumWithNullChance(rng *rand.Rand, typ *types.T, nullChance int) tree.Datum {
+	return RandDatumWithInterestingChance(rng, typ, nullChance, 10 /* interestingChance */)
+}
+
+// RandDatumWithInterestingChance is like RandDatumWithNullChance, but
+// interestingChance is the chance of returning an interesting datum, see
+// RandInterestingDatum, expressed as a fraction denominator like nullChance. A
+// interestingChance of 0 means that only interesting datums that are random
+// datums by chance will be returned.
+func RandDatumWithInterestingChance(
+	rng *rand.Rand, typ *types.T, nullChance int, interestingChance int,
+) tree.Datum {
 	if nullChance != 0 && rng.Intn(nullChance) == 0 {
 		return tree.DNull
 	}
 	// Sometimes pick from a predetermined list of known interesting datums.
-	if rng.Intn(10) == 0 {
-		if special := randInterestingDatum(rng, typ); special != nil {
+	if interestingChance != 0 && rng.Intn(interestingChance) == 0 {
+		if special := RandInterestingDatum(rng, typ); special != nil {
 			return special
 		}
 	}
@@ -262,6 +280,152 @@ func RandDatumWithNullChance(rng *rand.Rand, typ *types.T, nullChance int) tree.
 	}
 }
 
+// RandTypeCatalog is a catalog of hydrated user-defined types for the
+// generation of random datums, which resolves the types by their names and
+// OIDs. It implements tree.TypeReferenceResolver, so that it can resolve the
+// types of the columns of tree.CreateTable statements that refer to
+// user-defined types, like the ones generated by tests.
+type RandTypeCatalog struct {
+	// byName contains the types by their unqualified and their
+	// schema-qualified names.
+	byName map[string]*types.T
+	byOID  map[oid.Oid]*types.T
+	// nextOID is the OID of the next type added by AddEnum.
+	nextOID oid.Oid
+}
+
+var _ tree.TypeReferenceResolver = &RandTypeCatalog{}
+
+// NewRandTypeCatalog returns an empty RandTypeCatalog.
+func NewRandTypeCatalog() *RandTypeCatalog {
+	return &RandTypeCatalog{
+		byName:  map[string]*types.T{},
+		byOID:   map[oid.Oid]*types.T{},
+		nextOID: oidext.CockroachPredefinedOIDMax + 1,
+	}
+}
+
+// AddType adds the hydrated user-defined type typ to the catalog. If a type
+// with the same unqualified name is in several schemas, the unqualified name
+// resolves to the first one.
+func (c *RandTypeCatalog) AddType(typ *types.T) {
+	c.byOID[typ.Oid()] = typ
+	if name := typ.TypeMeta.Name; name != nil {
+		if _, ok := c.byName[name.Name]; !ok {
+			c.byName[name.Name] = typ
+		}
+		schema := name.Schema
+		if schema == "" {
+			schema = tree.PublicSchema
+		}
+		c.byName[schema+"."+name.Name] = typ
+	}
+	if typ.Oid() >= c.nextOID {
+		c.nextOID = typ.Oid() + 1
+	}
+}
+
+// AddEnum adds an ENUM type in the given schema, which is public if it is
+// empty, with the given name and labels to the catalog, and returns it. The
+// type gets a new OID.
+func (c *RandTypeCatalog) AddEnum(schema, name string, labels []string) *types.T {
+	if schema == "" {
+		schema = tree.PublicSchema
+	}
+	// Every user-defined type has an array type with the next OID.
+	typ := types.MakeEnum(c.nextOID, c.nextOID+1)
+	typ.TypeMeta = types.UserDefinedTypeMetadata{
+		Name: &types.UserDefinedTypeName{Schema: schema, Name: name},
+		EnumData: &types.EnumMetadata{
+			LogicalRepresentations:  labels,
+			PhysicalRepresentations: enum.GenerateNEvenlySpacedBytes(len(labels)),
+			IsMemberReadOnly:        make([]bool, len(labels)),
+		},
+	}
+	c.AddType(typ)
+	c.nextOID += 2
+	return typ
+}
+
+// ResolveType implements the tree.TypeReferenceResolver interface.
+func (c *RandTypeCatalog) ResolveType(
+	_ context.Context, name *tree.UnresolvedObjectName,
+) (*types.T, error) {
+	key := name.Object()
+	if name.HasExplicitSchema() {
+		key = name.Schema() + "." + key
+	}
+	if typ, ok := c.byName[key]; ok {
+		return typ, nil
+	}
+	return nil, errors.Newf("type %q does not exist", name)
+}
+
+// ResolveTypeByOID implements the tree.TypeReferenceResolver interface.
+func (c *RandTypeCatalog) ResolveTypeByOID(_ context.Context, id oid.Oid) (*types.T, error) {
+	if typ, ok := c.byOID[id]; ok {
+		return typ, nil
+	}
+	return nil, errors.Newf("type with OID %d does not exist", id)
+}
+
+// RandDatumWithTypeResolver is like RandDatumWithNullChance, but the type ref
+// is resolved by resolver first, like the types of columns of
+// tree.CreateTable statements, which can refer to user-defined types by name.
+// User-defined types that are not hydrated, including the elements of arrays
+// and tuples, are resolved by their OIDs, so that ENUM values can be
+// generated.
+func RandDatumWithTypeResolver(
+	ctx context.Context,
+	rng *rand.Rand,
+	ref tree.ResolvableTypeReference,
+	resolver tree.TypeReferenceResolver,
+	nullChance int,
+) (tree.Datum, error) {
+	typ, err := tree.ResolveType(ctx, ref, resolver)
+	if err != nil {
+		return nil, err
+	}
+	if typ, err = hydrateRandType(ctx, typ, resolver); err != nil {
+		return nil, err
+	}
+	return RandDatumWithNullChance(rng, typ, nullChance), nil
+}
+
+// hydrateRandType returns typ with the user-defined types in it that are not
+// hydrated replaced by the ones resolved by resolver.
+func hydrateRandType(
+	ctx context.Context, typ *types.T, resolver tree.TypeReferenceResolver,
+) (*types.T, error) {
+	switch typ.Family() {
+	case types.ArrayFamily:
+		contents, err := hydrateRandType(ctx, typ.ArrayContents(), resolver)
+		if err != nil || contents == typ.ArrayContents() {
+			return typ, err
+		}
+		return types.MakeArray(contents), nil
+	case types.TupleFamily:
+		contents := make([]*types.T, len(typ.TupleContents()))
+		changed := false
+		for i, t := range typ.TupleContents() {
+			var err error
+			if contents[i], err = hydrateRandType(ctx, t, resolver); err != nil {
+				return nil, err
+			}
+			changed = changed || contents[i] != t
+		}
+		if !changed {
+			return typ, nil
+		}
+		return types.MakeLabeledTuple(contents, typ.TupleLabels()), nil
+	case types.EnumFamily:
+		if typ.TypeMeta.EnumData == nil && resolver != nil {
+			return resolver.ResolveTypeByOID(ctx, typ.Oid())
+		}
+	}
+	return typ, nil
+}
+
 // RandArray generates a random DArray where the contents have nullChance
 // of being null.
 func RandArray(rng *rand.Rand, typ *types.T, nullChance int) tree.Datum {
@@ -510,9 +674,9 @@ var (
 			return res
 		}(),
 		types.TimestampTZFamily: func() []tree.Datum {
-			res := make([]tree.Datum, len(randTimestampSpecials))
-			for i, t := range randTimestampSpecials {
-				res[i] = tree.MustMakeDTimestampTZ(t, time.Microsecond)
+			var res []tree.Datum
+			for _, t := range append(randTimestampSpecials, randTimestampTZSpecials...) {
+				res = append(res, tree.MustMakeDTimestampTZ(t, time.Microsecond))
 			}
 			return res
 		}(),
@@ -602,6 +766,7 @@ var (
 			tree.NewDString(`'`),
 			tree.NewDString("\x00"),
 			tree.NewDString("\u2603"), // unicode snowman
+			tree.NewDString(strings.Repeat("X", randInterestingStringLength)),
 		},
 		types.BytesFamily: {
 			tree.NewDBytes(""),
@@ -611,6 +776,7 @@ var (
 			tree.NewDBytes("\x00"),
 			tree.NewDBytes("\u2603"), // unicode snowman
 			tree.NewDBytes("\xFF"),   // invalid utf-8 sequence, but a valid bytes
+			tree.NewDBytes(tree.DBytes(strings.Repeat("\xFF", randInterestingStringLength))),
 		},
 		types.OidFamily: {
 			tree.NewDOid(0),
@@ -627,8 +793,13 @@ var (
 			var res []tree.Datum
 			for _, s := range []string{
 				`{}`,
+				`[]`,
 				`1`,
+				`null`,
 				`{"test": "json"}`,
+				// Deeply nested JSON.
+				strings.Repeat(`{"a": [`, randInterestingJSONDepth) + `1` +
+					strings.Repeat(`]}`, randInterestingJSONDepth),
 			} {
 				d, err := tree.ParseDJSON(s)
 				if err != nil {
@@ -654,13 +825,32 @@ var (
 		}(),
 	}
 	randTimestampSpecials = []time.Time{
-		{},
+		{}, // 0001-01-01 00:00:00
 		time.Date(-2000, time.January, 1, 0, 0, 0, 0, time.UTC),
 		time.Date(3000, time.January, 1, 0, 0, 0, 0, time.UTC),
 		// NOTE(otan): we cannot support this as it does not work with colexec in tests.
 		tree.MinSupportedTime,
 		tree.MaxSupportedTime,
 	}
+	// randTimestampTZSpecials are the instants around the DST transitions of
+	// America/New_York in 2021, which are only interesting for TIMESTAMPTZ.
+	randTimestampTZSpecials = []time.Time{
+		// The clocks were set forward from 02:00 EST to 03:00 EDT.
+		time.Date(2021, time.March, 14, 6, 59, 59, 999999000, time.UTC),
+		time.Date(2021, time.March, 14, 7, 0, 0, 0, time.UTC),
+		// The clocks were set back from 02:00 EDT to 01:00 EST.
+		time.Date(2021, time.November, 7, 5, 59, 59, 999999000, time.UTC),
+		time.Date(2021, time.November, 7, 6, 0, 0, 0, time.UTC),
+	}
+)
+
+const (
+	// randInterestingStringLength is the length of the very long strings and
+	// bytes in randInterestingDatums.
+	randInterestingStringLength = 10000
+	// randInterestingJSONDepth is the nesting depth of the deeply nested JSON
+	// in randInterestingDatums.
+	randInterestingJSONDepth = 100
 )
 
 var (
@@ -719,11 +909,32 @@ func init() {
 	})
 }
 
-// randInterestingDatum returns an interesting Datum of type typ.
+// RandInterestingDatum returns an interesting Datum of type typ, which is an
+// edge case like the minimum and maximum values of the type, NaN, infinities,
+// empty and very long strings, timestamps at the limits of the supported
+// range and at DST transitions, empty arrays and deeply nested JSON.
 // If there are no such Datums for a scalar type, it panics. Otherwise,
 // it returns nil if there are no such Datums. Note that it pays attention
-// to the width of the requested type for Int and Float type families.
+// to the width of the requested type for Int, Float and String type
+// families.
+func RandInterestingDatum(rng *rand.Rand, typ *types.T) tree.Datum {
+	return randInterestingDatum(rng, typ)
+}
+
+// randInterestingDatum returns an interesting Datum of type typ, see
+// RandInterestingDatum.
 func randInterestingDatum(rng *rand.Rand, typ *types.T) tree.Datum {
+	if typ.Family() == types.ArrayFamily {
+		// Arrays are empty or contain a NULL, which are interesting regardless
+		// of the type of their elements.
+		arr := tree.NewDArray(typ.ArrayContents())
+		if rng.Intn(2) == 0 {
+			if err := arr.Append(tree.DNull); err != nil {
+				panic(err)
+			}
+		}
+		return arr
+	}
 	specials, ok := randInterestingDatums[typ.Family()]
 	if !ok || len(specials) == 0 {
 		for _, sc := range types.Scalar {
@@ -768,12 +979,272 @@ func randInterestingDatum(rng *rand.Rand, typ *types.T) tree.Datum {
 			return special
 		}
 		return &tree.DBitArray{BitArray: special.(*tree.DBitArray).ToWidth(uint(typ.Width()))}
+	case types.StringFamily:
+		// Truncate the string to the width of CHAR and VARCHAR types.
+		if width := int(typ.Width()); width > 0 {
+			if str := []rune(string(tree.MustBeDString(special))); len(str) > width {
+				return tree.NewDString(string(str[:width]))
+			}
+		}
+		return special
 
 	default:
 		return special
 	}
 }
 
+// RandOverflowBoundaryDatum returns a random Datum of the numeric type typ
+// which is close to a boundary at which arithmetic on it is likely to overflow:
+// the minimum and maximum values of an int of the type's width, the square
+// roots of those (for multiplication), the largest finite floats, and decimals
+// with exponents close to the limits of tree.DecimalCtx. Small values are
+// returned some of the time too, so that operations which straddle the
+// boundary are generated. It returns nil for non-numeric types.
+func RandOverflowBoundaryDatum(rng *rand.Rand, typ *types.T) tree.Datum {
+	// offset moves the boundary value a small amount towards zero.
+	offset := rng.Int63n(4)
+	switch typ.Family() {
+	case types.IntFamily:
+		width := typ.Width()
+		if width == 0 {
+			width = 64
+		}
+		max := int64(1)<<(width-1) - 1
+		min := -max - 1
+		sqrt := int64(math.Sqrt(float64(max)))
+		var i int64
+		switch rng.Intn(5) {
+		case 0:
+			i = max - offset
+		case 1:
+			i = min + offset
+		case 2:
+			i = sqrt + offset
+		case 3:
+			i = -sqrt - offset
+		default:
+			i = offset - 2
+		}
+		return tree.NewDInt(tree.DInt(i))
+
+	case types.FloatFamily:
+		max := math.MaxFloat64
+		if typ.Width() == 32 {
+			max = math.MaxFloat32
+		}
+		var f float64
+		switch rng.Intn(5) {
+		case 0:
+			f = max / (1 + float64(offset)*1e-15)
+		case 1:
+			f = -max / (1 + float64(offset)*1e-15)
+		case 2:
+			f = math.Sqrt(max) * (1 + float64(offset))
+		case 3:
+			f = math.SmallestNonzeroFloat64 * float64(offset+1)
+		default:
+			f = float64(offset - 2)
+		}
+		return tree.NewDFloat(tree.DFloat(f))
+
+	case types.DecimalFamily:
+		// Use a coefficient with a few digits so that the adjusted exponent is
+		// close to the exponent limits.
+		coeff := rng.Int63n(999) + 1
+		if rng.Intn(2) == 0 {
+			coeff = -coeff
+		}
+		var exp int32
+		switch rng.Intn(4) {
+		case 0:
+			exp = tree.DecimalCtx.MaxExponent - 3 - int32(offset)
+		case 1:
+			exp = tree.DecimalCtx.MinExponent + int32(offset)
+		case 2:
+			exp = tree.DecimalCtx.MaxExponent/2 - int32(offset)
+		default:
+			exp = 0
+		}
+		d := &tree.DDecimal{}
+		d.SetFinite(coeff, exp)
+		return d
+
+	default:
+		return nil
+	}
+}
+
+// DatumDistributionKind is the kind of a DatumDistribution.
+type DatumDistributionKind int
+
+const (
+	// UniformDistribution generates datums like RandDatum.
+	UniformDistribution DatumDistributionKind = iota
+	// ZipfianDistribution generates a fixed set of distinct datums, with the
+	// frequencies of a Zipfian distribution: the i-th datum is generated
+	// with a probability proportional to 1/(i+1)^Skew.
+	ZipfianDistribution
+	// SequentialDistribution generates increasing datums, which are the
+	// values Start, Start+1, Start+2, ... converted to the type. Types that
+	// can't be converted from integers are generated like
+	// UniformDistribution.
+	SequentialDistribution
+	// BoundaryDistribution generates datums close to the boundary values of
+	// the type, like its minimum and maximum values, see
+	// RandOverflowBoundaryDatum. Types without boundary values are generated
+	// like UniformDistribution.
+	BoundaryDistribution
+)
+
+// DatumDistribution is the distribution of the datums of a column generated
+// by a DatumGenerator. The zero value is the uniform distribution without
+// NULLs.
+type DatumDistribution struct {
+	Kind DatumDistributionKind
+	// NullProbability is the probability with which DNull is generated,
+	// independently of Kind.
+	NullProbability float64
+	// Cardinality is the number of distinct datums of a ZipfianDistribution.
+	// If it is 0, 100 datums are used.
+	Cardinality int
+	// Skew is the exponent of a ZipfianDistribution, which must be greater
+	// than 1. If it is 0, 1.1 is used.
+	Skew float64
+	// Start is the first value of a SequentialDistribution.
+	Start int64
+}
+
+// DatumGenerator generates random datums of a type with a DatumDistribution.
+// Use a DatumGenerator per column to generate rows whose columns have
+// different distributions, see RandDatumsFromGenerators.
+type DatumGenerator struct {
+	rng  *rand.Rand
+	typ  *types.T
+	dist DatumDistribution
+	// zipf and values generate the datums of a ZipfianDistribution.
+	zipf   *rand.Zipf
+	values []tree.Datum
+	// next is the next value of a SequentialDistribution.
+	next int64
+}
+
+// MakeDatumGenerator returns a DatumGenerator of datums of type typ with the
+// distribution dist, which uses rng.
+func MakeDatumGenerator(rng *rand.Rand, typ *types.T, dist DatumDistribution) *DatumGenerator {
+	g := &DatumGenerator{rng: rng, typ: typ, dist: dist, next: dist.Start}
+	if dist.Kind == ZipfianDistribution {
+		cardinality, skew := dist.Cardinality, dist.Skew
+		if cardinality == 0 {
+			cardinality = 100
+		}
+		if skew == 0 {
+			skew = 1.1
+		}
+		if cardinality < 1 || skew <= 1 {
+			panic(errors.AssertionFailedf(
+				"invalid zipfian distribution with cardinality %d and skew %v", cardinality, skew))
+		}
+		g.zipf = rand.NewZipf(rng, skew, 1, uint64(cardinality-1))
+		g.values = make([]tree.Datum, cardinality)
+		for i := range g.values {
+			if d := datumFromInt(typ, int64(i)); d != nil {
+				g.values[i] = d
+			} else {
+				// The values don't have to be distinct, but they usually
+				// are for types that can't be converted from integers.
+				g.values[i] = RandDatumWithNullChance(rng, typ, 0)
+			}
+		}
+	}
+	return g
+}
+
+// Next returns the next random datum.
+func (g *DatumGenerator) Next() tree.Datum {
+	if g.dist.NullProbability > 0 && g.rng.Float64() < g.dist.NullProbability {
+		return tree.DNull
+	}
+	switch g.dist.Kind {
+	case ZipfianDistribution:
+		return g.values[g.zipf.Uint64()]
+	case SequentialDistribution:
+		if d := datumFromInt(g.typ, g.next); d != nil {
+			g.next++
+			return d
+		}
+	case BoundaryDistribution:
+		if g.rng.Intn(2) == 0 {
+			if d := RandOverflowBoundaryDatum(g.rng, g.typ); d != nil {
+				return d
+			}
+		}
+		if d := randInterestingDatum(g.rng, g.typ); d != nil {
+			return d
+		}
+	}
+	return RandDatumWithNullChance(g.rng, g.typ, 0)
+}
+
+// RandDatumsFromGenerators returns a row with the next datum of every
+// generator.
+func RandDatumsFromGenerators(gens []*DatumGenerator) tree.Datums {
+	row := make(tree.Datums, len(gens))
+	for i, g := range gens {
+		row[i] = g.Next()
+	}
+	return row
+}
+
+// datumFromInt converts i to a datum of type typ, so that larger values of i
+// result in larger datums. It returns nil if the type can't be converted from
+// integers.
+func datumFromInt(typ *types.T, i int64) tree.Datum {
+	switch typ.Family() {
+	case types.IntFamily:
+		switch typ.Width() {
+		case 32:
+			i = int64(int32(i))
+		case 16:
+			i = int64(int16(i))
+		}
+		return tree.NewDInt(tree.DInt(i))
+	case types.FloatFamily:
+		return tree.NewDFloat(tree.DFloat(i))
+	case types.DecimalFamily:
+		if typ.Precision() > 0 {
+			return nil
+		}
+		d := &tree.DDecimal{}
+		d.SetFinite(i, 0)
+		return d
+	case types.StringFamily:
+		if typ.Width() > 0 {
+			return nil
+		}
+		return tree.NewDString(fmt.Sprintf("%020d", i))
+	case types.BytesFamily:
+		return tree.NewDBytes(tree.DBytes(fmt.Sprintf("%020d", i)))
+	case types.DateFamily:
+		date, err := pgdate.MakeDateFromPGEpoch(int32(i))
+		if err != nil {
+			return nil
+		}
+		return tree.NewDDate(date)
+	case types.TimestampFamily:
+		return tree.MustMakeDTimestamp(timeutil.Unix(946684800+i, 0), time.Microsecond)
+	case types.TimestampTZFamily:
+		return tree.MustMakeDTimestampTZ(timeutil.Unix(946684800+i, 0), time.Microsecond)
+	case types.IntervalFamily:
+		return &tree.DInterval{Duration: duration.MakeDuration(i*1e9, 0, 0)}
+	case types.UuidFamily:
+		return tree.NewDUuid(tree.DUuid{UUID: uuid.FromUint128(uint128.FromInts(0, uint64(i)))})
+	case types.OidFamily:
+		return tree.NewDOid(tree.DInt(i))
+	default:
+		return nil
+	}
+}
+
 // RandCollationLocale returns a random element of collationLocales.
 func RandCollationLocale(rng *rand.Rand) *string {
 	return &collationLocales[rng.Intn(len(collationLocales))]
@@ -995,6 +1466,276 @@ func RandEncDatumRowsOfTypes(rng *rand.Rand, numRows int, types []*types.T) EncD
 	return vals
 }
 
+// RandSortedEncDatumRowsOfTypes is like RandEncDatumRowsOfTypes, but the rows
+// are sorted in ascending order by their first prefixLen columns, with NULLs
+// first, like the input of operators that require an ordering, such as merge
+// joins. The order of rows with equal prefixes is random.
+func RandSortedEncDatumRowsOfTypes(
+	rng *rand.Rand, numRows int, types []*types.T, prefixLen int, evalCtx *tree.EvalContext,
+) EncDatumRows {
+	if prefixLen > len(types) {
+		panic(errors.AssertionFailedf("prefix of %d columns of %d columns", prefixLen, len(types)))
+	}
+	vals := RandEncDatumRowsOfTypes(rng, numRows, types)
+	sort.SliceStable(vals, func(i, j int) bool {
+		for col := 0; col < prefixLen; col++ {
+			// The rows are generated from datums, so they don't have to be
+			// decoded.
+			if c := vals[i][col].Datum.Compare(evalCtx, vals[j][col].Datum); c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+	return vals
+}
+
+// RandClusteredEncDatumRowsOfTypes is like RandEncDatumRowsOfTypes, but the
+// rows are clustered into runs of between 1 and maxRunLength identical rows,
+// like the input of operators that benefit from duplicates, such as streaming
+// distincts. The rows of a run don't share memory.
+func RandClusteredEncDatumRowsOfTypes(
+	rng *rand.Rand, numRows int, types []*types.T, maxRunLength int,
+) EncDatumRows {
+	if maxRunLength < 1 {
+		panic(errors.AssertionFailedf("invalid maximum run length: %d", maxRunLength))
+	}
+	vals := make(EncDatumRows, 0, numRows)
+	for len(vals) < numRows {
+		row := RandEncDatumRowOfTypes(rng, types)
+		for n := 1 + rng.Intn(maxRunLength); n > 0 && len(vals) < numRows; n-- {
+			vals = append(vals, row.Copy())
+		}
+	}
+	return vals
+}
+
+// RandGoldenDatums returns numDatums random non-NULL datums of every type of
+// typs, generated with a random number generator seeded with seed.
+func RandGoldenDatums(seed int64, typs []*types.T, numDatums int) []tree.Datums {
+	rng := rand.New(rand.NewSource(seed))
+	datums := make([]tree.Datums, len(typs))
+	for i, typ := range typs {
+		datums[i] = make(tree.Datums, numDatums)
+		for j := range datums[i] {
+			datums[i][j] = RandDatum(rng, typ, false /* nullOk */)
+		}
+	}
+	return datums
+}
+
+// WriteGoldenDatums writes datums, which contains the datums of every type of
+// typs, to w, so that they can be replayed by ReadGoldenDatums. This allows
+// tests to snapshot the datums generated by RandGoldenDatums into a golden
+// file, so that their coverage doesn't change silently when the random datum
+// generators change. Every datum is written as its value encoding, followed
+// by its quoted string representation, which is only informational:
+//
+//   type INT8
+//   0a2302 "17"
+//
+func WriteGoldenDatums(w io.Writer, typs []*types.T, datums []tree.Datums) error {
+	var enc, scratch []byte
+	for i, typ := range typs {
+		if _, err := fmt.Fprintf(w, "type %s\n", typ.SQLString()); err != nil {
+			return err
+		}
+		for _, d := range datums[i] {
+			var err error
+			enc, err = EncodeTableValue(enc[:0], descpb.ColumnID(encoding.NoColumnID), d, scratch)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(w, "%x %q\n", enc, d.String()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ReadGoldenDatums reads the datums of every type of typs written by
+// WriteGoldenDatums from r. The types must have distinct SQL strings. Datums
+// of other types are skipped, and it is an error if r doesn't contain one of
+// the types.
+func ReadGoldenDatums(r io.Reader, typs []*types.T) ([]tree.Datums, error) {
+	index := make(map[string]int, len(typs))
+	for i, typ := range typs {
+		index[typ.SQLString()] = i
+	}
+	datums := make([]tree.Datums, len(typs))
+	found := make([]bool, len(typs))
+	var a DatumAlloc
+	// cur is the index of the type of the current datums, or -1 if the
+	// datums are skipped.
+	cur := -1
+	scanner := bufio.NewScanner(r)
+	// Random strings and JSON documents can be long.
+	scanner.Buffer(nil, 1<<24)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if typ := strings.TrimPrefix(text, "type "); typ != text {
+			var ok bool
+			if cur, ok = index[typ]; ok {
+				found[cur] = true
+			} else {
+				cur = -1
+			}
+			continue
+		}
+		if cur == -1 {
+			continue
+		}
+		fields := strings.SplitN(text, " ", 2)
+		enc, err := hex.DecodeString(fields[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", line)
+		}
+		d, rest, err := DecodeTableValue(&a, typs[cur], enc)
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", line)
+		}
+		if len(rest) > 0 {
+			return nil, errors.Newf("line %d: %d leftover bytes", line, len(rest))
+		}
+		datums[cur] = append(datums[cur], d)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for i := range typs {
+		if !found[i] {
+			return nil, errors.Newf("no datums of type %s", typs[i].SQLString())
+		}
+	}
+	return datums, nil
+}
+
+// CheckTableKeyRoundTrip checks that d round trips through EncodeTableKey and
+// DecodeTableKey in the direction dir: the decoded datum must be equal to d,
+// and it must be encoded to the same key again. The keys of the type of d
+// must be decodable. It returns the key of d.
+//
+// It is meant to be run on random datums by property-based tests and
+// fuzzers.
+func CheckTableKeyRoundTrip(
+	evalCtx *tree.EvalContext, a *DatumAlloc, d tree.Datum, dir encoding.Direction,
+) ([]byte, error) {
+	key, err := EncodeTableKey(nil, d, dir)
+	if err != nil {
+		return nil, err
+	}
+	decoded, rest, err := DecodeTableKey(a, d.ResolvedType(), key, dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "decoding key %x of %s", key, d)
+	}
+	if len(rest) > 0 {
+		return nil, errors.Newf("%d leftover bytes decoding key %x of %s", len(rest), key, d)
+	}
+	if decoded.Compare(evalCtx, d) != 0 {
+		return nil, errors.Newf("key %x of %s decoded to %s", key, d, decoded)
+	}
+	reencoded, err := EncodeTableKey(nil, decoded, dir)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(key, reencoded) {
+		return nil, errors.Newf("key %x of %s decoded to %s, which encoded to %x",
+			key, d, decoded, reencoded)
+	}
+	return key, nil
+}
+
+// CheckTableKeyOrdering checks that the keys of d1 and d2 encoded by
+// EncodeTableKey in the direction dir are ordered like the datums.
+func CheckTableKeyOrdering(
+	evalCtx *tree.EvalContext, d1, d2 tree.Datum, dir encoding.Direction,
+) error {
+	k1, err := EncodeTableKey(nil, d1, dir)
+	if err != nil {
+		return err
+	}
+	k2, err := EncodeTableKey(nil, d2, dir)
+	if err != nil {
+		return err
+	}
+	expected := d1.Compare(evalCtx, d2)
+	if dir == encoding.Descending {
+		expected = -expected
+	}
+	if cmp := bytes.Compare(k1, k2); (cmp < 0) != (expected < 0) || (cmp == 0) != (expected == 0) {
+		return errors.Newf("keys %x and %x of %s and %s are not ordered like the datums in direction %d",
+			k1, k2, d1, d2, dir)
+	}
+	return nil
+}
+
+// CheckInvertedIndexTableKeys checks the keys of d encoded by
+// EncodeInvertedIndexTableKeys with the prefix inKey: the encoding must be
+// deterministic, and every key must start with inKey. The keys of a non-empty
+// array must also be unique and sorted, and decode to elements of the array
+// in the same order, so the keys of the elements of the array must be
+// decodable. It returns the keys of d.
+func CheckInvertedIndexTableKeys(
+	evalCtx *tree.EvalContext, a *DatumAlloc, d tree.Datum, inKey []byte,
+) ([][]byte, error) {
+	version := descpb.EmptyArraysInInvertedIndexesVersion
+	keys, err := EncodeInvertedIndexTableKeys(d, inKey, version)
+	if err != nil {
+		return nil, err
+	}
+	again, err := EncodeInvertedIndexTableKeys(d, inKey, version)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) != len(again) {
+		return nil, errors.Newf("%s encoded to %d and %d inverted keys", d, len(keys), len(again))
+	}
+	for i := range keys {
+		if !bytes.Equal(keys[i], again[i]) {
+			return nil, errors.Newf("inverted key %d of %s encoded to %x and %x", i, d, keys[i], again[i])
+		}
+		if !bytes.HasPrefix(keys[i], inKey) {
+			return nil, errors.Newf("inverted key %x of %s doesn't start with %x", keys[i], d, inKey)
+		}
+	}
+
+	arr, ok := tree.UnwrapDatum(evalCtx, d).(*tree.DArray)
+	if !ok || arr.Len() == 0 {
+		return keys, nil
+	}
+	var prev tree.Datum
+	for i, key := range keys {
+		if i > 0 && bytes.Compare(keys[i-1], key) >= 0 {
+			return nil, errors.Newf("inverted keys %x and %x of %s are not sorted and unique",
+				keys[i-1], key, d)
+		}
+		elem, rest, err := DecodeTableKey(a, arr.ParamTyp, key[len(inKey):], encoding.Ascending)
+		if err != nil {
+			return nil, errors.Wrapf(err, "decoding inverted key %x of %s", key, d)
+		}
+		if len(rest) > 0 {
+			return nil, errors.Newf("%d leftover bytes decoding inverted key %x of %s", len(rest), key, d)
+		}
+		if prev != nil && prev.Compare(evalCtx, elem) >= 0 {
+			return nil, errors.Newf("inverted keys of %s decoded to %s and %s out of order", d, prev, elem)
+		}
+		found := false
+		for _, e := range arr.Array {
+			if e.Compare(evalCtx, elem) == 0 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, errors.Newf("inverted key %x of %s decoded to %s, which is not an element",
+				key, d, elem)
+		}
+		prev = elem
+	}
+	return keys, nil
+}
+
 // TestingMakePrimaryIndexKey creates a key prefix that corresponds to
 // a table row (in the primary index); it is intended for tests.
 //
@@ -1080,13 +1821,131 @@ func MakeSchemaName(
 	}
 }
 
+// RandCreateTableOptions configures the random table definitions created by
+// RandCreateTableWithOptions and RandCreateTablesWithOptions, so that tests
+// can target specific features. Use DefaultRandCreateTableOptions to get the
+// options used by RandCreateTable.
+type RandCreateTableOptions struct {
+	// MaxColumns is the maximum number of columns of a table, which is at
+	// least 1. Tables that are interleaved into other tables also have the
+	// primary key columns of the parent table.
+	MaxColumns int
+	// TypeFamilies are the families of the types of the non-computed columns.
+	// If it is empty, all column types are used. The types of computed
+	// columns are the types of the columns they are computed from, or STRING.
+	TypeFamilies []types.Family
+	// UseComputedColumnProbability makes ComputedColumnProbability determine
+	// the number of computed columns. If it is false, a random number of up to
+	// half of the columns are computed, which is the distribution (and the
+	// sequence of random draws) used by RandCreateTable, so that existing
+	// seeds keep reproducing the same tables.
+	UseComputedColumnProbability bool
+	// ComputedColumnProbability is the probability with which every column
+	// but the first one is a computed column if UseComputedColumnProbability
+	// is true. Tables that are interleaved into other tables have no computed
+	// columns.
+	ComputedColumnProbability float64
+	// MinIndexes and MaxIndexes are the bounds of the number of secondary
+	// indexes of a table. Fewer indexes are created if there are no valid
+	// index columns.
+	MinIndexes, MaxIndexes int
+	// PartialIndexes allows secondary indexes to be partial indexes.
+	PartialIndexes bool
+}
+
+// DefaultRandCreateTableOptions returns the options used by RandCreateTable.
+func DefaultRandCreateTableOptions() RandCreateTableOptions {
+	return RandCreateTableOptions{
+		MaxColumns: 19,
+		MinIndexes: 0,
+		MaxIndexes: 9,
+	}
+}
+
+// validate panics if the options are invalid.
+func (opts *RandCreateTableOptions) validate() {
+	if opts.MaxColumns < 1 {
+		panic(errors.AssertionFailedf("invalid maximum number of columns: %d", opts.MaxColumns))
+	}
+	if opts.ComputedColumnProbability < 0 || opts.ComputedColumnProbability > 1 {
+		panic(errors.AssertionFailedf(
+			"computed column probability %v is not between 0 and 1", opts.ComputedColumnProbability))
+	}
+	if opts.MinIndexes < 0 || opts.MaxIndexes < opts.MinIndexes {
+		panic(errors.AssertionFailedf(
+			"invalid range of the number of indexes: [%d, %d]", opts.MinIndexes, opts.MaxIndexes))
+	}
+	if len(opts.columnTypes()) == 0 {
+		panic(errors.AssertionFailedf("no column types in families %v", opts.TypeFamilies))
+	}
+}
+
+// columnTypes returns the seed types of the allowed type families.
+func (opts *RandCreateTableOptions) columnTypes() []*types.T {
+	if len(opts.TypeFamilies) == 0 {
+		return SeedTypes
+	}
+	var typs []*types.T
+	for _, typ := range SeedTypes {
+		for _, fam := range opts.TypeFamilies {
+			if typ.Family() == fam {
+				typs = append(typs, typ)
+				break
+			}
+		}
+	}
+	return typs
+}
+
+// randColumnType returns a random legal column type of the allowed type
+// families.
+func (opts *RandCreateTableOptions) randColumnType(rng *rand.Rand) *types.T {
+	if len(opts.TypeFamilies) == 0 {
+		return RandColumnType(rng)
+	}
+	typs := opts.columnTypes()
+	for {
+		typ := RandTypeFromSlice(rng, typs)
+		switch typ.Oid() {
+		case oid.T_int2vector, oid.T_oidvector:
+			continue
+		}
+		if err := colinfo.ValidateColumnDefType(typ); err == nil {
+			return typ
+		}
+	}
+}
+
+// hasIndexableColumnType returns whether the allowed type families contain an
+// indexable column type, which interleaved tables need.
+func (opts *RandCreateTableOptions) hasIndexableColumnType() bool {
+	if len(opts.TypeFamilies) == 0 {
+		return true
+	}
+	for _, typ := range opts.columnTypes() {
+		if colinfo.ColumnTypeIsIndexable(typ) && colinfo.ValidateColumnDefType(typ) == nil {
+			return true
+		}
+	}
+	return false
+}
+
 // RandCreateTables creates random table definitions.
 func RandCreateTables(
 	rng *rand.Rand, prefix string, num int, mutators ...Mutator,
+) []tree.Statement {
+	return RandCreateTablesWithOptions(rng, prefix, num, DefaultRandCreateTableOptions(), mutators...)
+}
+
+// RandCreateTablesWithOptions creates random table definitions configured by
+// opts.
+func RandCreateTablesWithOptions(
+	rng *rand.Rand, prefix string, num int, opts RandCreateTableOptions, mutators ...Mutator,
 ) []tree.Statement {
 	if num < 1 {
 		panic("at least one table required")
 	}
+	opts.validate()
 
 	// Make some random tables.
 	tables := make([]tree.Statement, num)
@@ -1097,7 +1956,7 @@ func RandCreateTables(
 		if i > 0 && rng.Intn(2) == 0 {
 			interleave = tables[rng.Intn(i)].(*tree.CreateTable)
 		}
-		t := RandCreateTableWithInterleave(rng, prefix, i+1, interleave, nil)
+		t := randCreateTable(rng, prefix, i+1, interleave, nil, opts)
 		tables[i] = t
 	}
 
@@ -1113,6 +1972,15 @@ func RandCreateTable(rng *rand.Rand, prefix string, tableIdx int) *tree.CreateTa
 	return RandCreateTableWithInterleave(rng, prefix, tableIdx, nil, nil)
 }
 
+// RandCreateTableWithOptions creates a random CreateTable definition
+// configured by opts.
+func RandCreateTableWithOptions(
+	rng *rand.Rand, prefix string, tableIdx int, opts RandCreateTableOptions,
+) *tree.CreateTable {
+	opts.validate()
+	return randCreateTable(rng, prefix, tableIdx, nil, nil, opts)
+}
+
 // RandCreateTableWithColumnIndexNumberGenerator creates a random CreateTable definition
 // using the passed function to generate column index numbers for column names.
 func RandCreateTableWithColumnIndexNumberGenerator(
@@ -1130,8 +1998,29 @@ func RandCreateTableWithInterleave(
 	interleaveInto *tree.CreateTable,
 	generateColumnIndexNumber func() int64,
 ) *tree.CreateTable {
+	return randCreateTable(
+		rng, prefix, tableIdx, interleaveInto, generateColumnIndexNumber, DefaultRandCreateTableOptions(),
+	)
+}
+
+// randCreateTable creates a random CreateTable definition configured by opts,
+// interleaved into the given other CreateTable definition. The options must
+// be valid.
+func randCreateTable(
+	rng *rand.Rand,
+	prefix string,
+	tableIdx int,
+	interleaveInto *tree.CreateTable,
+	generateColumnIndexNumber func() int64,
+	opts RandCreateTableOptions,
+) *tree.CreateTable {
+	if interleaveInto != nil && !opts.hasIndexableColumnType() {
+		// The primary key of the interleaved table can't be extended.
+		interleaveInto = nil
+	}
+
 	// columnDefs contains the list of Columns we'll add to our table.
-	nColumns := randutil.RandIntInRange(rng, 1, 20)
+	nColumns := randutil.RandIntInRange(rng, 1, opts.MaxColumns+1)
 	columnDefs := make([]*tree.ColumnTableDef, 0, nColumns)
 	// defs contains the list of Columns and other attributes (indexes, column
 	// families, etc) we'll add to our table.
@@ -1180,7 +2069,7 @@ func RandCreateTableWithInterleave(
 			// Loop until we generate an indexable column type.
 			var extraCol *tree.ColumnTableDef
 			for {
-				extraCol = randColumnTableDef(rng, tableIdx, colIdx(i+prefixLength))
+				extraCol = randColumnTableDef(rng, tableIdx, colIdx(i+prefixLength), &opts)
 				extraColType := tree.MustBeStaticallyKnownType(extraCol.Type)
 				if colinfo.ColumnTypeIsIndexable(extraColType) {
 					break
@@ -1216,10 +2105,21 @@ func RandCreateTableWithInterleave(
 		}
 	} else {
 		// Make new defs from scratch.
-		nComputedColumns := randutil.RandIntInRange(rng, 0, (nColumns+1)/2)
+		var nComputedColumns int
+		if opts.UseComputedColumnProbability {
+			// The first column is never computed, so that the computed
+			// columns have a column to be computed from.
+			for i := 1; i < nColumns; i++ {
+				if rng.Float64() < opts.ComputedColumnProbability {
+					nComputedColumns++
+				}
+			}
+		} else {
+			nComputedColumns = randutil.RandIntInRange(rng, 0, (nColumns+1)/2)
+		}
 		nNormalColumns := nColumns - nComputedColumns
 		for i := 0; i < nNormalColumns; i++ {
-			columnDef := randColumnTableDef(rng, tableIdx, colIdx(i))
+			columnDef := randColumnTableDef(rng, tableIdx, colIdx(i), &opts)
 			columnDefs = append(columnDefs, columnDef)
 			defs = append(defs, columnDef)
 		}
@@ -1249,14 +2149,14 @@ func RandCreateTableWithInterleave(
 		// Make defs for computed columns.
 		normalColDefs := columnDefs
 		for i := nNormalColumns; i < nColumns; i++ {
-			columnDef := randComputedColumnTableDef(rng, normalColDefs, tableIdx, colIdx(i))
+			columnDef := randComputedColumnTableDef(rng, normalColDefs, tableIdx, colIdx(i), &opts)
 			columnDefs = append(columnDefs, columnDef)
 			defs = append(defs, columnDef)
 		}
 	}
 
 	// Make indexes.
-	nIdxs := rng.Intn(10)
+	nIdxs := randutil.RandIntInRange(rng, opts.MinIndexes, opts.MaxIndexes+1)
 	for i := 0; i < nIdxs; i++ {
 		indexDef, ok := randIndexTableDefFromCols(rng, columnDefs)
 		if !ok {
@@ -1285,6 +2185,11 @@ func RandCreateTableWithInterleave(
 		ColumnFamilyMutator(rng, ret)
 	}
 
+	// Maybe make some partial indexes.
+	if opts.PartialIndexes {
+		PartialIndexMutator(rng, []tree.Statement{ret})
+	}
+
 	// Maybe add some storing columns.
 	res, _ := IndexStoringMutator(rng, []tree.Statement{ret})
 	return res[0].(*tree.CreateTable)
@@ -1563,13 +2468,16 @@ RefColsLoop:
 }
 
 // randColumnTableDef produces a random ColumnTableDef for a non-computed
-// column, with a random type and nullability.
-func randColumnTableDef(rand *rand.Rand, tableIdx int, colIdx int) *tree.ColumnTableDef {
+// column, with a random type of the families allowed by opts and a random
+// nullability.
+func randColumnTableDef(
+	rand *rand.Rand, tableIdx int, colIdx int, opts *RandCreateTableOptions,
+) *tree.ColumnTableDef {
 	columnDef := &tree.ColumnTableDef{
 		// We make a unique name for all columns by prefixing them with the table
 		// index to make it easier to reference columns from different tables.
 		Name: tree.Name(fmt.Sprintf("col%d_%d", tableIdx, colIdx)),
-		Type: RandColumnType(rand),
+		Type: opts.randColumnType(rand),
 	}
 	columnDef.Nullable.Nullability = tree.Nullability(rand.Intn(int(tree.SilentNull) + 1))
 	return columnDef
@@ -1579,9 +2487,13 @@ func randColumnTableDef(rand *rand.Rand, tableIdx int, colIdx int) *tree.ColumnT
 // column (either STORED or VIRTUAL). The computed expressions refer to columns
 // in normalColDefs.
 func randComputedColumnTableDef(
-	rng *rand.Rand, normalColDefs []*tree.ColumnTableDef, tableIdx int, colIdx int,
+	rng *rand.Rand,
+	normalColDefs []*tree.ColumnTableDef,
+	tableIdx int,
+	colIdx int,
+	opts *RandCreateTableOptions,
 ) *tree.ColumnTableDef {
-	newDef := randColumnTableDef(rng, tableIdx, colIdx)
+	newDef := randColumnTableDef(rng, tableIdx, colIdx, opts)
 	newDef.Computed.Computed = true
 	newDef.Computed.Virtual = (rng.Intn(2) == 0)
 
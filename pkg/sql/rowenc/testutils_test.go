@@ -0,0 +1,286 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package rowenc
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+func TestRandCreateTableWithOptions(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	rng, _ := randutil.NewPseudoRand()
+	opts := RandCreateTableOptions{
+		MaxColumns:   4,
+		TypeFamilies: []types.Family{types.IntFamily, types.StringFamily},
+		// No computed columns are created.
+		UseComputedColumnProbability: true,
+		MinIndexes:                   2,
+		MaxIndexes:                   3,
+	}
+	for i := 0; i < 100; i++ {
+		stmts := RandCreateTablesWithOptions(rng, "t", 3, opts)
+		for _, stmt := range stmts {
+			create := stmt.(*tree.CreateTable)
+			// Interleaved tables also have the primary key columns of their
+			// parents.
+			maxColumns := opts.MaxColumns * len(stmts)
+			var cols, idxs int
+			for _, def := range create.Defs {
+				switch def := def.(type) {
+				case *tree.ColumnTableDef:
+					cols++
+					if def.IsComputed() {
+						t.Errorf("unexpected computed column in %s", tree.AsString(create))
+					}
+					typ := tree.MustBeStaticallyKnownType(def.Type)
+					if fam := typ.Family(); fam != types.IntFamily && fam != types.StringFamily {
+						t.Errorf("unexpected type %s in %s", typ, tree.AsString(create))
+					}
+				case *tree.IndexTableDef:
+					idxs++
+					if def.Predicate != nil {
+						t.Errorf("unexpected partial index in %s", tree.AsString(create))
+					}
+				case *tree.UniqueConstraintTableDef:
+					if !def.PrimaryKey {
+						idxs++
+						if def.Predicate != nil {
+							t.Errorf("unexpected partial index in %s", tree.AsString(create))
+						}
+					}
+				}
+			}
+			if cols < 1 || cols > maxColumns {
+				t.Errorf("expected between 1 and %d columns in %s", maxColumns, tree.AsString(create))
+			}
+			if idxs > opts.MaxIndexes {
+				t.Errorf("expected at most %d indexes in %s", opts.MaxIndexes, tree.AsString(create))
+			}
+		}
+	}
+}
+
+func TestDatumGenerator(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	rng, _ := randutil.NewPseudoRand()
+	evalCtx := tree.NewTestingEvalContext(cluster.MakeTestingClusterSettings())
+	defer evalCtx.Stop(context.Background())
+	const n = 1000
+
+	t.Run("zipfian", func(t *testing.T) {
+		g := MakeDatumGenerator(rng, types.Int, DatumDistribution{
+			Kind: ZipfianDistribution, Cardinality: 10, Skew: 2,
+		})
+		counts := map[int64]int{}
+		for i := 0; i < n; i++ {
+			d := tree.MustBeDInt(g.Next())
+			if d < 0 || d >= 10 {
+				t.Fatalf("unexpected value %d", d)
+			}
+			counts[int64(d)]++
+		}
+		if counts[0] <= counts[9] {
+			t.Errorf("expected 0 to be more frequent than 9, found %v", counts)
+		}
+	})
+
+	t.Run("sequential", func(t *testing.T) {
+		for _, typ := range []*types.T{types.Int, types.String, types.Date, types.Timestamp, types.Uuid} {
+			g := MakeDatumGenerator(rng, typ, DatumDistribution{Kind: SequentialDistribution, Start: 5})
+			prev := g.Next()
+			for i := 0; i < n; i++ {
+				d := g.Next()
+				if prev.Compare(evalCtx, d) >= 0 {
+					t.Fatalf("expected %s < %s", prev, d)
+				}
+				prev = d
+			}
+		}
+	})
+
+	t.Run("nulls", func(t *testing.T) {
+		g := MakeDatumGenerator(rng, types.String, DatumDistribution{
+			Kind: BoundaryDistribution, NullProbability: 0.9,
+		})
+		nulls := 0
+		for i := 0; i < n; i++ {
+			if g.Next() == tree.DNull {
+				nulls++
+			}
+		}
+		if nulls < n/2 {
+			t.Errorf("expected mostly NULLs, found %d out of %d", nulls, n)
+		}
+	})
+}
+
+func TestRandInterestingDatum(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	rng, _ := randutil.NewPseudoRand()
+	for _, typ := range []*types.T{
+		types.Int, types.Int2, types.Float, types.Decimal, types.String, types.MakeChar(1),
+		types.MakeVarChar(3), types.Bytes, types.Date, types.Timestamp, types.TimestampTZ,
+		types.Jsonb, types.IntArray, types.StringArray,
+	} {
+		for i := 0; i < 100; i++ {
+			d := RandDatumWithInterestingChance(rng, typ, 0 /* nullChance */, 1 /* interestingChance */)
+			if d == tree.DNull {
+				t.Fatalf("unexpected NULL of type %s", typ)
+			}
+			if !d.ResolvedType().Equivalent(typ) {
+				t.Fatalf("expected datum of type %s, found %s", typ, d.ResolvedType())
+			}
+			switch typ.Family() {
+			case types.StringFamily:
+				if width := int(typ.Width()); width > 0 && len([]rune(string(tree.MustBeDString(d)))) > width {
+					t.Fatalf("expected datum of at most %d characters, found %s", width, d)
+				}
+			case types.ArrayFamily:
+				if arr := tree.MustBeDArray(d); arr.Len() > 1 || (arr.Len() == 1 && arr.Array[0] != tree.DNull) {
+					t.Fatalf("expected empty array or array of NULL, found %s", d)
+				}
+			}
+		}
+	}
+}
+
+func TestRandDatumWithTypeResolver(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	rng, _ := randutil.NewPseudoRand()
+	catalog := NewRandTypeCatalog()
+	typ := catalog.AddEnum("sc", "e", []string{"a", "b", "c"})
+	// An enum type that is not hydrated, like the ones of decoded
+	// descriptors.
+	unhydrated := types.MakeEnum(typ.Oid(), typ.UserDefinedArrayOID())
+
+	for _, tc := range []struct {
+		ref      tree.ResolvableTypeReference
+		expected *types.T
+	}{
+		{ref: &tree.UnresolvedObjectName{NumParts: 1, Parts: [3]string{"e"}}, expected: typ},
+		{ref: &tree.UnresolvedObjectName{NumParts: 2, Parts: [3]string{"e", "sc"}}, expected: typ},
+		{ref: &tree.OIDTypeReference{OID: typ.Oid()}, expected: typ},
+		{ref: unhydrated, expected: typ},
+		{ref: types.MakeArray(unhydrated), expected: types.MakeArray(typ)},
+		{ref: types.Int, expected: types.Int},
+	} {
+		for i := 0; i < 10; i++ {
+			d, err := RandDatumWithTypeResolver(ctx, rng, tc.ref, catalog, 0 /* nullChance */)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !d.ResolvedType().Equivalent(tc.expected) {
+				t.Fatalf("expected datum of type %s, found %s", tc.expected, d.ResolvedType())
+			}
+		}
+	}
+
+	unknown := &tree.UnresolvedObjectName{NumParts: 1, Parts: [3]string{"unknown"}}
+	if _, err := RandDatumWithTypeResolver(ctx, rng, unknown, catalog, 0 /* nullChance */); err == nil {
+		t.Fatal("expected error for unknown type")
+	}
+}
+
+func TestRandSortedAndClusteredEncDatumRows(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	rng, _ := randutil.NewPseudoRand()
+	evalCtx := tree.NewTestingEvalContext(cluster.MakeTestingClusterSettings())
+	defer evalCtx.Stop(context.Background())
+	typs := []*types.T{types.Int, types.String, types.Bool}
+
+	const numRows = 100
+	rows := RandSortedEncDatumRowsOfTypes(rng, numRows, typs, 2, evalCtx)
+	if len(rows) != numRows {
+		t.Fatalf("expected %d rows, got %d", numRows, len(rows))
+	}
+	for i := 1; i < len(rows); i++ {
+		for col := 0; col < 2; col++ {
+			c := rows[i-1][col].Datum.Compare(evalCtx, rows[i][col].Datum)
+			if c < 0 {
+				break
+			}
+			if c > 0 {
+				t.Fatalf("rows %d and %d are not sorted: %s, %s", i-1, i, rows[i-1], rows[i])
+			}
+		}
+	}
+
+	const maxRunLength = 5
+	rows = RandClusteredEncDatumRowsOfTypes(rng, numRows, typs, maxRunLength)
+	if len(rows) != numRows {
+		t.Fatalf("expected %d rows, got %d", numRows, len(rows))
+	}
+	var runs int
+	for i := range rows {
+		if i == 0 || rows[i].String(typs) != rows[i-1].String(typs) {
+			runs++
+		} else if &rows[i][0] == &rows[i-1][0] {
+			t.Fatalf("rows %d and %d share memory", i-1, i)
+		}
+	}
+	// Random rows can be equal to the rows of the previous run, so runs can
+	// be merged, but there must be fewer runs than rows.
+	if runs >= numRows {
+		t.Fatalf("expected runs of duplicates, got %d runs of %d rows", runs, numRows)
+	}
+}
+
+func TestGoldenDatums(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	evalCtx := tree.NewTestingEvalContext(cluster.MakeTestingClusterSettings())
+	defer evalCtx.Stop(context.Background())
+	typs := goldenDatumTypes
+
+	const seed, numDatums = 1, 10
+	datums := RandGoldenDatums(seed, typs, numDatums)
+	var buf bytes.Buffer
+	if err := WriteGoldenDatums(&buf, typs, datums); err != nil {
+		t.Fatal(err)
+	}
+	for i, again := range RandGoldenDatums(seed, typs, numDatums) {
+		for j, d := range again {
+			if d.Compare(evalCtx, datums[i][j]) != 0 {
+				t.Fatalf("golden datums are not deterministic: %s, %s", datums[i][j], d)
+			}
+		}
+	}
+
+	// Read the datums of a subset of the types in a different order.
+	subset := []*types.T{typs[2], typs[0]}
+	read, err := ReadGoldenDatums(&buf, subset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, j := range []int{2, 0} {
+		if len(read[i]) != numDatums {
+			t.Fatalf("expected %d datums of type %s, got %d", numDatums, typs[j], len(read[i]))
+		}
+		for k, d := range read[i] {
+			if d.Compare(evalCtx, datums[j][k]) != 0 {
+				t.Fatalf("expected %s, got %s", datums[j][k], d)
+			}
+		}
+	}
+
+	if _, err := ReadGoldenDatums(strings.NewReader("type INT8\n"), []*types.T{types.Bool}); err == nil {
+		t.Fatal("expected an error for a missing type")
+	}
+}
@@ -2360,7 +2360,8 @@ may increase either contention or retry errors, or both.`,
 				"Compatible elements: millennium, century, decade, year, isoyear,\n" +
 				"quarter, month, week, dayofweek, isodow, dayofyear, julian,\n" +
 				"hour, minute, second, millisecond, microsecond, epoch",
-			Volatility: tree.VolatilityImmutable,
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.ExtractStringTimestamp,
 		},
 		tree.Overload{
 			Types:      tree.ArgTypes{{"element", types.String}, {"input", types.Interval}},
@@ -2527,7 +2528,8 @@ may increase either contention or retry errors, or both.`,
 				"significant than `element` to zero (or one, for day and month)\n\n" +
 				"Compatible elements: millennium, century, decade, year, quarter, month,\n" +
 				"week, day, hour, minute, second, millisecond, microsecond.",
-			Volatility: tree.VolatilityImmutable,
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.DateTruncStringTimestamp,
 		},
 		tree.Overload{
 			Types:      tree.ArgTypes{{"element", types.String}, {"input", types.Date}},
@@ -2577,7 +2579,8 @@ may increase either contention or retry errors, or both.`,
 				"significant than `element` to zero (or one, for day and month)\n\n" +
 				"Compatible elements: millennium, century, decade, year, quarter, month,\n" +
 				"week, day, hour, minute, second, millisecond, microsecond.",
-			Volatility: tree.VolatilityStable,
+			Volatility:            tree.VolatilityStable,
+			SpecializedVecBuiltin: tree.DateTruncStringTimestampTZ,
 		},
 	),
 
@@ -6684,24 +6687,6 @@ func extractTimeSpanFromTimeOfDay(t timeofday.TimeOfDay, timeSpan string) (tree.
 	}
 }
 
-// dateToJulianDay is based on the date2j function in PostgreSQL 10.5.
-func dateToJulianDay(year int, month int, day int) int {
-	if month > 2 {
-		month++
-		year += 4800
-	} else {
-		month += 13
-		year += 4799
-	}
-
-	century := year / 100
-	jd := year*365 - 32167
-	jd += year/4 - century + century/4
-	jd += 7834*month/256 + day
-
-	return jd
-}
-
 func extractTimeSpanFromTimestampTZ(
 	ctx *tree.EvalContext, fromTime time.Time, timeSpan string,
 ) (tree.Datum, error) {
@@ -6772,99 +6757,11 @@ func extractTimeSpanFromInterval(
 func extractTimeSpanFromTimestamp(
 	_ *tree.EvalContext, fromTime time.Time, timeSpan string,
 ) (tree.Datum, error) {
-	switch timeSpan {
-	case "millennia", "millennium", "millenniums":
-		year := fromTime.Year()
-		if year > 0 {
-			return tree.NewDFloat(tree.DFloat((year + 999) / 1000)), nil
-		}
-		return tree.NewDFloat(tree.DFloat(-((999 - (year - 1)) / 1000))), nil
-
-	case "centuries", "century":
-		year := fromTime.Year()
-		if year > 0 {
-			return tree.NewDFloat(tree.DFloat((year + 99) / 100)), nil
-		}
-		return tree.NewDFloat(tree.DFloat(-((99 - (year - 1)) / 100))), nil
-
-	case "decade", "decades":
-		year := fromTime.Year()
-		if year >= 0 {
-			return tree.NewDFloat(tree.DFloat(year / 10)), nil
-		}
-		return tree.NewDFloat(tree.DFloat(-((8 - (year - 1)) / 10))), nil
-
-	case "year", "years":
-		return tree.NewDFloat(tree.DFloat(fromTime.Year())), nil
-
-	case "isoyear":
-		year, _ := fromTime.ISOWeek()
-		return tree.NewDFloat(tree.DFloat(year)), nil
-
-	case "quarter":
-		return tree.NewDFloat(tree.DFloat((fromTime.Month()-1)/3 + 1)), nil
-
-	case "month", "months":
-		return tree.NewDFloat(tree.DFloat(fromTime.Month())), nil
-
-	case "week", "weeks":
-		_, week := fromTime.ISOWeek()
-		return tree.NewDFloat(tree.DFloat(week)), nil
-
-	case "day", "days":
-		return tree.NewDFloat(tree.DFloat(fromTime.Day())), nil
-
-	case "dayofweek", "dow":
-		return tree.NewDFloat(tree.DFloat(fromTime.Weekday())), nil
-
-	case "isodow":
-		day := fromTime.Weekday()
-		if day == 0 {
-			return tree.NewDFloat(tree.DFloat(7)), nil
-		}
-		return tree.NewDFloat(tree.DFloat(day)), nil
-
-	case "dayofyear", "doy":
-		return tree.NewDFloat(tree.DFloat(fromTime.YearDay())), nil
-
-	case "julian":
-		julianDay := float64(dateToJulianDay(fromTime.Year(), int(fromTime.Month()), fromTime.Day())) +
-			(float64(fromTime.Hour()*duration.SecsPerHour+fromTime.Minute()*duration.SecsPerMinute+fromTime.Second())+
-				float64(fromTime.Nanosecond())/float64(time.Second))/duration.SecsPerDay
-		return tree.NewDFloat(tree.DFloat(julianDay)), nil
-
-	case "hour", "hours":
-		return tree.NewDFloat(tree.DFloat(fromTime.Hour())), nil
-
-	case "minute", "minutes":
-		return tree.NewDFloat(tree.DFloat(fromTime.Minute())), nil
-
-	case "second", "seconds":
-		return tree.NewDFloat(tree.DFloat(float64(fromTime.Second()) + float64(fromTime.Nanosecond())/float64(time.Second))), nil
-
-	case "millisecond", "milliseconds":
-		// This a PG extension not supported in MySQL.
-		return tree.NewDFloat(
-			tree.DFloat(
-				float64(fromTime.Second()*duration.MillisPerSec) + float64(fromTime.Nanosecond())/
-					float64(time.Millisecond),
-			),
-		), nil
-
-	case "microsecond", "microseconds":
-		return tree.NewDFloat(
-			tree.DFloat(
-				float64(fromTime.Second()*duration.MillisPerSec*duration.MicrosPerMilli) + float64(fromTime.Nanosecond())/
-					float64(time.Microsecond),
-			),
-		), nil
-
-	case "epoch":
-		return tree.NewDFloat(tree.DFloat(float64(fromTime.UnixNano()) / float64(time.Second))), nil
-
-	default:
-		return nil, pgerror.Newf(pgcode.InvalidParameterValue, "unsupported timespan: %s", timeSpan)
+	res, err := tree.ExtractTimeSpanFromTimestamp(fromTime, timeSpan)
+	if err != nil {
+		return nil, err
 	}
+	return tree.NewDFloat(tree.DFloat(res)), nil
 }
 
 func truncateTime(fromTime *tree.DTime, timeSpan string) (*tree.DTime, error) {
@@ -7019,94 +6916,10 @@ func decodeEscape(input string) ([]byte, error) {
 }
 
 func truncateTimestamp(fromTime time.Time, timeSpan string) (*tree.DTimestampTZ, error) {
-	year := fromTime.Year()
-	month := fromTime.Month()
-	day := fromTime.Day()
-	hour := fromTime.Hour()
-	min := fromTime.Minute()
-	sec := fromTime.Second()
-	nsec := fromTime.Nanosecond()
-	loc := fromTime.Location()
-
-	monthTrunc := time.January
-	dayTrunc := 1
-	hourTrunc := 0
-	minTrunc := 0
-	secTrunc := 0
-	nsecTrunc := 0
-
-	switch timeSpan {
-	case "millennia", "millennium", "millenniums":
-		if year > 0 {
-			year = ((year+999)/1000)*1000 - 999
-		} else {
-			year = -((999-(year-1))/1000)*1000 + 1
-		}
-		month, day, hour, min, sec, nsec = monthTrunc, dayTrunc, hourTrunc, minTrunc, secTrunc, nsecTrunc
-
-	case "centuries", "century":
-		if year > 0 {
-			year = ((year+99)/100)*100 - 99
-		} else {
-			year = -((99-(year-1))/100)*100 + 1
-		}
-		month, day, hour, min, sec, nsec = monthTrunc, dayTrunc, hourTrunc, minTrunc, secTrunc, nsecTrunc
-
-	case "decade", "decades":
-		if year >= 0 {
-			year = (year / 10) * 10
-		} else {
-			year = -((8 - (year - 1)) / 10) * 10
-		}
-		month, day, hour, min, sec, nsec = monthTrunc, dayTrunc, hourTrunc, minTrunc, secTrunc, nsecTrunc
-
-	case "year", "years":
-		month, day, hour, min, sec, nsec = monthTrunc, dayTrunc, hourTrunc, minTrunc, secTrunc, nsecTrunc
-
-	case "quarter":
-		firstMonthInQuarter := ((month-1)/3)*3 + 1
-		month, day, hour, min, sec, nsec = firstMonthInQuarter, dayTrunc, hourTrunc, minTrunc, secTrunc, nsecTrunc
-
-	case "month", "months":
-		day, hour, min, sec, nsec = dayTrunc, hourTrunc, minTrunc, secTrunc, nsecTrunc
-
-	case "week", "weeks":
-		// Subtract (day of week * nanoseconds per day) to get Sunday, then add a day to get Monday.
-		previousMonday := fromTime.Add(-1 * time.Hour * 24 * time.Duration(fromTime.Weekday()-1))
-		if fromTime.Weekday() == time.Sunday {
-			// The math above does not work for Sunday, as it roll forward to the next Monday.
-			// As such, subtract six days instead.
-			previousMonday = fromTime.Add(-6 * time.Hour * 24)
-		}
-		year, month, day = previousMonday.Year(), previousMonday.Month(), previousMonday.Day()
-		hour, min, sec, nsec = hourTrunc, minTrunc, secTrunc, nsecTrunc
-
-	case "day", "days":
-		hour, min, sec, nsec = hourTrunc, minTrunc, secTrunc, nsecTrunc
-
-	case "hour", "hours":
-		min, sec, nsec = minTrunc, secTrunc, nsecTrunc
-
-	case "minute", "minutes":
-		sec, nsec = secTrunc, nsecTrunc
-
-	case "second", "seconds":
-		nsec = nsecTrunc
-
-	case "millisecond", "milliseconds":
-		// This a PG extension not supported in MySQL.
-		milliseconds := (nsec / int(time.Millisecond)) * int(time.Millisecond)
-		nsec = milliseconds
-
-	case "microsecond", "microseconds":
-		microseconds := (nsec / int(time.Microsecond)) * int(time.Microsecond)
-		nsec = microseconds
-
-	default:
-		return nil, pgerror.Newf(pgcode.InvalidParameterValue, "unsupported timespan: %s", timeSpan)
+	toTime, err := tree.TruncateTimestamp(fromTime, timeSpan)
+	if err != nil {
+		return nil, err
 	}
-
-	toTime := time.Date(year, month, day, hour, min, sec, nsec, loc)
 	return tree.MakeDTimestampTZ(toTime, time.Microsecond)
 }
 
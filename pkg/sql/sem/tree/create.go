@@ -201,7 +201,7 @@ func (node *CreateIndex) Format(ctx *FmtCtx) {
 	if node.Unique {
 		ctx.WriteString("UNIQUE ")
 	}
-	if node.Inverted && !ctx.HasFlags(FmtPGCatalog) {
+	if node.Inverted && !ctx.flags.HasAnyFlags(FmtPGCatalog|FmtPostgres) {
 		ctx.WriteString("INVERTED ")
 	}
 	ctx.WriteString("INDEX ")
@@ -224,6 +224,9 @@ func (node *CreateIndex) Format(ctx *FmtCtx) {
 		} else {
 			ctx.WriteString(" btree")
 		}
+	} else if node.Inverted && ctx.HasFlags(FmtPostgres) {
+		// Postgres uses GIN indexes for inverted indexes.
+		ctx.WriteString(" USING gin")
 	}
 	ctx.WriteString(" (")
 	ctx.FormatNode(&node.Columns)
@@ -232,9 +235,7 @@ func (node *CreateIndex) Format(ctx *FmtCtx) {
 		ctx.FormatNode(node.Sharded)
 	}
 	if len(node.Storing) > 0 {
-		ctx.WriteString(" STORING (")
-		ctx.FormatNode(&node.Storing)
-		ctx.WriteByte(')')
+		ctx.formatStoring(&node.Storing)
 	}
 	if node.Interleave != nil {
 		ctx.FormatNode(node.Interleave)
@@ -592,7 +593,18 @@ func (node *ColumnTableDef) Format(ctx *FmtCtx) {
 	// TABLE ... AS query.
 	if node.Type != nil {
 		ctx.WriteByte(' ')
-		ctx.WriteString(node.columnTypeString())
+		typ := node.columnTypeString()
+		if t, ok := GetStaticallyKnownType(node.Type); ok {
+			if ctx.HasFlags(FmtPostgres) && !node.IsSerial {
+				typ = postgresTypeSQLString(t)
+			} else if ctx.HasFlags(FmtMySQL) {
+				typ = mysqlTypeSQLString(t)
+				if node.IsSerial {
+					typ += " AUTO_INCREMENT"
+				}
+			}
+		}
+		ctx.WriteString(typ)
 	}
 
 	if node.Nullable.Nullability != SilentNull && node.Nullable.ConstraintName != "" {
@@ -662,6 +674,9 @@ func (node *ColumnTableDef) Format(ctx *FmtCtx) {
 		ctx.FormatNode(&node.References.Actions)
 	}
 	if node.IsComputed() {
+		if ctx.flags.HasAnyFlags(FmtPostgres | FmtMySQL) {
+			ctx.WriteString(" GENERATED ALWAYS")
+		}
 		ctx.WriteString(" AS (")
 		ctx.FormatNode(node.Computed.Expr)
 		if node.Computed.Virtual {
@@ -787,6 +802,18 @@ type ColumnFamilyConstraint struct {
 	IfNotExists bool
 }
 
+// formatStoring formats the STORING clause of an index. Postgres calls it
+// INCLUDE.
+func (ctx *FmtCtx) formatStoring(storing *NameList) {
+	if ctx.HasFlags(FmtPostgres) {
+		ctx.WriteString(" INCLUDE (")
+	} else {
+		ctx.WriteString(" STORING (")
+	}
+	ctx.FormatNode(storing)
+	ctx.WriteByte(')')
+}
+
 // IndexTableDef represents an index definition within a CREATE TABLE
 // statement.
 type IndexTableDef struct {
@@ -818,9 +845,7 @@ func (node *IndexTableDef) Format(ctx *FmtCtx) {
 		ctx.FormatNode(node.Sharded)
 	}
 	if node.Storing != nil {
-		ctx.WriteString(" STORING (")
-		ctx.FormatNode(&node.Storing)
-		ctx.WriteByte(')')
+		ctx.formatStoring(&node.Storing)
 	}
 	if node.Interleave != nil {
 		ctx.FormatNode(node.Interleave)
@@ -890,9 +915,7 @@ func (node *UniqueConstraintTableDef) Format(ctx *FmtCtx) {
 		ctx.FormatNode(node.Sharded)
 	}
 	if node.Storing != nil {
-		ctx.WriteString(" STORING (")
-		ctx.FormatNode(&node.Storing)
-		ctx.WriteByte(')')
+		ctx.formatStoring(&node.Storing)
 	}
 	if node.Interleave != nil {
 		ctx.FormatNode(node.Interleave)
@@ -3519,17 +3519,17 @@ func (d *DTuple) Format(ctx *FmtCtx) {
 			if tupleContents[i].Family() != types.UnknownFamily {
 				nullType := tupleContents[i]
 				if ctx.HasFlags(fmtDisambiguateDatumTypes) {
-					ctx.WriteString(":::")
+					ctx.WriteString(ctx.typeAnnotationOperator())
 					ctx.FormatTypeReference(nullType)
 				} else {
 					ctx.WriteString("::")
-					ctx.WriteString(nullType.SQLString())
+					ctx.WriteString(ctx.typeSQLString(nullType))
 				}
 			}
 		}
 		comma = ", "
 	}
-	if len(d.D) == 1 {
+	if len(d.D) == 1 && !ctx.flags.HasAnyFlags(FmtPostgres|FmtMySQL) {
 		// Ensure the pretty-printed 1-value tuple is not ambiguous with
 		// the equivalent value enclosed in grouping parentheses.
 		ctx.WriteByte(',')
@@ -2949,6 +2949,20 @@ func ConvertLikeToRegexp(
 	return re, nil
 }
 
+// ConvertSimilarToRegexp compiles the specified SIMILAR TO pattern (with the
+// default escape character) as an equivalent regular expression.
+func ConvertSimilarToRegexp(ctx *EvalContext, pattern string) (*regexp.Regexp, error) {
+	key := similarToKey{s: pattern, escape: '\\'}
+	return ctx.ReCache.GetRegexp(key)
+}
+
+// CompileRegexp compiles the specified POSIX regular expression as used by the
+// ~ (or ~* if caseInsensitive is true) operator.
+func CompileRegexp(ctx *EvalContext, pattern string, caseInsensitive bool) (*regexp.Regexp, error) {
+	key := regexpKey{s: pattern, caseInsensitive: caseInsensitive}
+	return ctx.ReCache.GetRegexp(key)
+}
+
 func matchLike(ctx *EvalContext, left, right Datum, caseInsensitive bool) (Datum, error) {
 	if left == DNull || right == DNull {
 		return DNull, nil
@@ -202,11 +202,7 @@ func TestEval(t *testing.T) {
 				0, /* processorID */
 				result.Op,
 				[]*types.T{typedExpr.ResolvedType()},
-				nil, /* output */
-				nil, /* getStats */
-				result.MetadataSources,
-				nil, /* toClose */
-				nil, /* cancelFlow */
+				colexec.WithMetadataSources(result.MetadataSources),
 			)
 			require.NoError(t, err)
 
@@ -111,6 +111,7 @@ const (
 	ExplainFlagJSON
 	ExplainFlagStages
 	ExplainFlagDeps
+	ExplainFlagDOT
 	numExplainFlags = iota
 )
 
@@ -122,6 +123,7 @@ var explainFlagStrings = [...]string{
 	ExplainFlagJSON:    "JSON",
 	ExplainFlagStages:  "STAGES",
 	ExplainFlagDeps:    "DEPS",
+	ExplainFlagDOT:     "DOT",
 }
 
 var explainFlagStringMap = func() map[string]ExplainFlag {
@@ -247,13 +249,21 @@ func MakeExplain(options []string, stmt Statement) (Statement, error) {
 		opts.Mode = ExplainPlan
 	}
 	if opts.Flags[ExplainFlagJSON] {
-		if opts.Mode != ExplainDistSQL {
-			return nil, pgerror.Newf(pgcode.Syntax, "the JSON flag can only be used with DISTSQL")
+		if opts.Mode != ExplainDistSQL && opts.Mode != ExplainVec {
+			return nil, pgerror.Newf(pgcode.Syntax, "the JSON flag can only be used with DISTSQL or VEC")
 		}
 		if analyze {
 			return nil, pgerror.Newf(pgcode.Syntax, "the JSON flag cannot be used with ANALYZE")
 		}
 	}
+	if opts.Flags[ExplainFlagDOT] {
+		if opts.Mode != ExplainVec {
+			return nil, pgerror.Newf(pgcode.Syntax, "the DOT flag can only be used with VEC")
+		}
+		if opts.Flags[ExplainFlagJSON] {
+			return nil, pgerror.Newf(pgcode.Syntax, "the JSON and DOT flags cannot be used together")
+		}
+	}
 
 	if analyze {
 		if opts.Mode != ExplainDistSQL && opts.Mode != ExplainDebug && opts.Mode != ExplainPlan {
@@ -923,9 +923,10 @@ func (node *Tuple) Format(ctx *FmtCtx) {
 	}
 	ctx.WriteByte('(')
 	ctx.FormatNode(&node.Exprs)
-	if len(node.Exprs) == 1 {
+	if len(node.Exprs) == 1 && !ctx.flags.HasAnyFlags(FmtPostgres|FmtMySQL) {
 		// Ensure the pretty-printed 1-value tuple is not ambiguous with
-		// the equivalent value enclosed in grouping parentheses.
+		// the equivalent value enclosed in grouping parentheses. Postgres
+		// and MySQL don't support this syntax.
 		ctx.WriteByte(',')
 	}
 	ctx.WriteByte(')')
@@ -962,8 +963,8 @@ func (node *Array) Format(ctx *FmtCtx) {
 	// UNKNOWN[], since that's not a valid annotation.
 	if ctx.HasFlags(FmtParsable) && node.typ != nil {
 		if node.typ.ArrayContents().Family() != types.UnknownFamily {
-			ctx.WriteString(":::")
-			ctx.Buffer.WriteString(node.typ.SQLString())
+			ctx.WriteString(ctx.typeAnnotationOperator())
+			ctx.Buffer.WriteString(ctx.typeSQLString(node.typ))
 		}
 	}
 }
@@ -982,8 +983,8 @@ func (node *ArrayFlatten) Format(ctx *FmtCtx) {
 	if ctx.HasFlags(FmtParsable) {
 		if t, ok := node.Subquery.(*DTuple); ok {
 			if len(t.D) == 0 {
-				ctx.WriteString(":::")
-				ctx.Buffer.WriteString(node.typ.SQLString())
+				ctx.WriteString(ctx.typeAnnotationOperator())
+				ctx.Buffer.WriteString(ctx.typeSQLString(node.typ))
 			}
 		}
 	}
@@ -1461,8 +1462,8 @@ func (node *FuncExpr) Format(ctx *FmtCtx) {
 			// TODO(jordan,knz): clean this up. AmbiguousReturnType should be set only
 			// when we should and can put an annotation here. #28579
 			if node.typ.Family() != types.TupleFamily {
-				ctx.WriteString(":::")
-				ctx.Buffer.WriteString(node.typ.SQLString())
+				ctx.WriteString(ctx.typeAnnotationOperator())
+				ctx.Buffer.WriteString(ctx.typeSQLString(node.typ))
 			}
 		}
 	}
@@ -1557,6 +1558,19 @@ type CastExpr struct {
 
 // Format implements the NodeFormatter interface.
 func (node *CastExpr) Format(ctx *FmtCtx) {
+	if ctx.HasFlags(FmtMySQL) {
+		// MySQL only supports the CAST syntax with a few target types.
+		ctx.WriteString("CAST(")
+		ctx.FormatNode(node.Expr)
+		ctx.WriteString(" AS ")
+		if typ, ok := GetStaticallyKnownType(node.Type); ok {
+			ctx.WriteString(mysqlCastTypeSQLString(typ))
+		} else {
+			ctx.FormatTypeReference(node.Type)
+		}
+		ctx.WriteByte(')')
+		return
+	}
 	switch node.SyntaxMode {
 	case CastPrepend:
 		// This is a special case for things like INTERVAL '1s'. These only work
@@ -1646,19 +1660,25 @@ type AnnotateTypeExpr struct {
 
 // Format implements the NodeFormatter interface.
 func (node *AnnotateTypeExpr) Format(ctx *FmtCtx) {
-	switch node.SyntaxMode {
-	case AnnotateShort:
-		exprFmtWithParen(ctx, node.Expr)
-		ctx.WriteString(":::")
-		ctx.FormatTypeReference(node.Type)
-
-	default:
-		ctx.WriteString("ANNOTATE_TYPE(")
+	if ctx.HasFlags(FmtMySQL) {
+		// MySQL doesn't support type annotations, so only the expression is
+		// formatted.
 		ctx.FormatNode(node.Expr)
-		ctx.WriteString(", ")
+		return
+	}
+	// Postgres doesn't support ANNOTATE_TYPE, so the short form (formatted as
+	// a cast) is always used under FmtPostgres.
+	if node.SyntaxMode == AnnotateShort || ctx.HasFlags(FmtPostgres) {
+		exprFmtWithParen(ctx, node.Expr)
+		ctx.WriteString(ctx.typeAnnotationOperator())
 		ctx.FormatTypeReference(node.Type)
-		ctx.WriteByte(')')
+		return
 	}
+	ctx.WriteString("ANNOTATE_TYPE(")
+	ctx.FormatNode(node.Expr)
+	ctx.WriteString(", ")
+	ctx.FormatTypeReference(node.Type)
+	ctx.WriteByte(')')
 }
 
 // TypedInnerExpr returns the AnnotateTypeExpr's inner expression as a TypedExpr.
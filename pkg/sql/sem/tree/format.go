@@ -137,6 +137,27 @@ const (
 	// rather than string literals. For example, the bytes \x40ab will be formatted
 	// as x'40ab' rather than '\x40ab'.
 	fmtFormatByteLiterals
+
+	// FmtPostgres instructs the pretty-printer to produce a representation in
+	// the Postgres dialect of SQL, as far as the Postgres equivalents exist.
+	// Specifically, type annotations are formatted as casts, type names are
+	// replaced with the names of their closest Postgres equivalents, index
+	// hints are omitted, STORING is formatted as INCLUDE, computed columns are
+	// formatted as generated columns, inverted indexes are formatted as GIN
+	// indexes, and 1-value tuples are formatted without a trailing comma. It is
+	// used to run the same statements against CockroachDB and Postgres in
+	// randomized tests.
+	FmtPostgres
+
+	// FmtMySQL instructs the pretty-printer to produce a representation in the
+	// MySQL dialect of SQL, as far as the MySQL equivalents exist.
+	// Specifically, type annotations are omitted, casts are formatted as CAST
+	// expressions with the MySQL cast types, type names are replaced with the
+	// names of their closest MySQL equivalents, identifiers are quoted with
+	// backticks, index hints are omitted, computed columns are formatted as
+	// generated columns, and 1-value tuples are formatted without a trailing
+	// comma. Like FmtPostgres, it is used in randomized tests.
+	FmtMySQL
 )
 
 // Composite/derived flag definitions follow.
@@ -388,7 +409,7 @@ func (ctx *FmtCtx) FormatNode(n NodeFormatter) {
 		}
 		if typ != nil {
 			if f.HasFlags(fmtDisambiguateDatumTypes) {
-				ctx.WriteString(":::")
+				ctx.WriteString(ctx.typeAnnotationOperator())
 				ctx.FormatTypeReference(typ)
 			} else if f.HasFlags(FmtPGCatalog) && !typ.IsNumeric() {
 				ctx.WriteString("::")
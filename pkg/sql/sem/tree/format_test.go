@@ -134,6 +134,26 @@ func TestFormatStatement(t *testing.T) {
 		{`RESTORE FROM 'a' WITH into_db='foo', skip_missing_foreign_keys`,
 			tree.FmtHideConstants | tree.FmtAnonymize,
 			`RESTORE FROM _ WITH into_db=_, skip_missing_foreign_keys`},
+
+		// Test the Postgres dialect.
+		{`CREATE TABLE t (s STRING, c INT4 AS (length(s)) STORED, INDEX (s) STORING (c))`,
+			tree.FmtPostgres,
+			`CREATE TABLE t (s TEXT, c INT8 GENERATED ALWAYS AS (length(s)) STORED, INDEX (s) INCLUDE (c))`},
+		{`CREATE INVERTED INDEX i ON t (j)`, tree.FmtPostgres,
+			`CREATE INDEX i ON t USING gin (j)`},
+		{`SELECT 'a:::STRING', x:::INT2, (1,) FROM t@idx`, tree.FmtPostgres,
+			`SELECT 'a:::STRING', x::INT8, (1) FROM t`},
+		{`DROP INDEX t@idx`, tree.FmtPostgres,
+			`DROP INDEX idx`},
+
+		// Test the MySQL dialect.
+		{`CREATE TABLE t (i SERIAL4, s STRING, c INT2 AS (length(s)) STORED)`,
+			tree.FmtMySQL,
+			`CREATE TABLE t (i INT AUTO_INCREMENT, s TEXT, c SMALLINT GENERATED ALWAYS AS (length(s)) STORED)`},
+		{`SELECT 'a:::STRING', x:::INT2, y::BYTES, (1,) FROM t@idx`, tree.FmtMySQL,
+			`SELECT 'a:::STRING', x, CAST(y AS BINARY), (1) FROM t`},
+		{`SELECT "my col" FROM "my table"`, tree.FmtMySQL,
+			"SELECT `my col` FROM `my table`"},
 	}
 
 	for i, test := range testData {
@@ -303,6 +323,8 @@ func TestFormatExpr(t *testing.T) {
 
 		{`(123:::INT, 123:::DECIMAL)`, tree.FmtCheckEquivalence,
 			`(123:::INT8, 123:::DECIMAL)`},
+		{`(123:::INT2, 'a':::STRING)`, tree.FmtCheckEquivalence | tree.FmtPostgres,
+			`(123::INT8, 'a'::TEXT)`},
 
 		{`(1, COALESCE(NULL, 123), ARRAY[45.6])`, tree.FmtHideConstants,
 			`(_, COALESCE(_, _), ARRAY[_])`},
@@ -11,6 +11,9 @@
 package tree
 
 import (
+	"bytes"
+	"strings"
+
 	"github.com/cockroachdb/cockroach/pkg/sql/lexbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
@@ -33,11 +36,32 @@ func (n *Name) Format(ctx *FmtCtx) {
 	f := ctx.flags
 	if f.HasFlags(FmtAnonymize) && !isArityIndicatorString(string(*n)) {
 		ctx.WriteByte('_')
+	} else if f.HasFlags(FmtMySQL) {
+		encodeMySQLIdent(&ctx.Buffer, string(*n), f.EncodeFlags(), lexbase.EncodeRestrictedSQLIdent)
 	} else {
 		lexbase.EncodeRestrictedSQLIdent(&ctx.Buffer, string(*n), f.EncodeFlags())
 	}
 }
 
+// encodeMySQLIdent writes the identifier s to buf, quoting it with backticks
+// like MySQL does if encode would quote it with double quotes.
+func encodeMySQLIdent(
+	buf *bytes.Buffer,
+	s string,
+	flags lexbase.EncodeFlags,
+	encode func(*bytes.Buffer, string, lexbase.EncodeFlags),
+) {
+	var ident bytes.Buffer
+	encode(&ident, s, flags)
+	if ident.Len() == 0 || ident.Bytes()[0] != '"' {
+		buf.Write(ident.Bytes())
+		return
+	}
+	buf.WriteByte('`')
+	buf.WriteString(strings.Replace(s, "`", "``", -1))
+	buf.WriteByte('`')
+}
+
 // NameStringP escapes an identifier stored in a heap string to a SQL
 // identifier, avoiding a heap allocation.
 func NameStringP(s *string) string {
@@ -88,6 +112,8 @@ func (u *UnrestrictedName) Format(ctx *FmtCtx) {
 	f := ctx.flags
 	if f.HasFlags(FmtAnonymize) {
 		ctx.WriteByte('_')
+	} else if f.HasFlags(FmtMySQL) {
+		encodeMySQLIdent(&ctx.Buffer, string(*u), f.EncodeFlags(), lexbase.EncodeUnrestrictedSQLIdent)
 	} else {
 		lexbase.EncodeUnrestrictedSQLIdent(&ctx.Buffer, string(*u), f.EncodeFlags())
 	}
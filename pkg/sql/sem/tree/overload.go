@@ -38,6 +38,9 @@ type SpecializedVectorizedBuiltin int
 // Keep this list alphabetized so that it is easy to manage.
 const (
 	_ SpecializedVectorizedBuiltin = iota
+	DateTruncStringTimestamp
+	DateTruncStringTimestampTZ
+	ExtractStringTimestamp
 	SubstringStringIntInt
 )
 
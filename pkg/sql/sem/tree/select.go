@@ -408,7 +408,8 @@ func (node *AliasedTableExpr) Format(ctx *FmtCtx) {
 		ctx.WriteString("LATERAL ")
 	}
 	ctx.FormatNode(node.Expr)
-	if node.IndexFlags != nil {
+	// Postgres and MySQL don't support index hints.
+	if node.IndexFlags != nil && !ctx.flags.HasAnyFlags(FmtPostgres|FmtMySQL) {
 		ctx.FormatNode(node.IndexFlags)
 	}
 	if node.Ordinality {
@@ -167,7 +167,9 @@ func (n *TableIndexName) Format(ctx *FmtCtx) {
 		return
 	}
 
-	if n.Table.ObjectName != "" {
+	// Postgres index names are unique within a schema and can't be qualified
+	// with a table name.
+	if n.Table.ObjectName != "" && !ctx.HasFlags(FmtPostgres) {
 		// The table is specified.
 		ctx.FormatNode(&n.Table)
 		ctx.WriteByte('@')
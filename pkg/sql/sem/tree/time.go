@@ -13,6 +13,9 @@ package tree
 import (
 	"time"
 
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
 	"github.com/cockroachdb/errors"
 )
 
@@ -38,3 +41,205 @@ func TimeFamilyPrecisionToRoundDuration(precision int32) time.Duration {
 	}
 	panic(errors.Newf("unsupported precision: %d", precision))
 }
+
+// ExtractTimeSpanFromTimestamp returns the given element (as accepted by the
+// extract builtin) of fromTime.
+func ExtractTimeSpanFromTimestamp(fromTime time.Time, timeSpan string) (float64, error) {
+	switch timeSpan {
+	case "millennia", "millennium", "millenniums":
+		year := fromTime.Year()
+		if year > 0 {
+			return float64((year + 999) / 1000), nil
+		}
+		return float64(-((999 - (year - 1)) / 1000)), nil
+
+	case "centuries", "century":
+		year := fromTime.Year()
+		if year > 0 {
+			return float64((year + 99) / 100), nil
+		}
+		return float64(-((99 - (year - 1)) / 100)), nil
+
+	case "decade", "decades":
+		year := fromTime.Year()
+		if year >= 0 {
+			return float64(year / 10), nil
+		}
+		return float64(-((8 - (year - 1)) / 10)), nil
+
+	case "year", "years":
+		return float64(fromTime.Year()), nil
+
+	case "isoyear":
+		year, _ := fromTime.ISOWeek()
+		return float64(year), nil
+
+	case "quarter":
+		return float64((fromTime.Month()-1)/3 + 1), nil
+
+	case "month", "months":
+		return float64(fromTime.Month()), nil
+
+	case "week", "weeks":
+		_, week := fromTime.ISOWeek()
+		return float64(week), nil
+
+	case "day", "days":
+		return float64(fromTime.Day()), nil
+
+	case "dayofweek", "dow":
+		return float64(fromTime.Weekday()), nil
+
+	case "isodow":
+		day := fromTime.Weekday()
+		if day == 0 {
+			return 7, nil
+		}
+		return float64(day), nil
+
+	case "dayofyear", "doy":
+		return float64(fromTime.YearDay()), nil
+
+	case "julian":
+		julianDay := float64(dateToJulianDay(fromTime.Year(), int(fromTime.Month()), fromTime.Day())) +
+			(float64(fromTime.Hour()*duration.SecsPerHour+fromTime.Minute()*duration.SecsPerMinute+fromTime.Second())+
+				float64(fromTime.Nanosecond())/float64(time.Second))/duration.SecsPerDay
+		return julianDay, nil
+
+	case "hour", "hours":
+		return float64(fromTime.Hour()), nil
+
+	case "minute", "minutes":
+		return float64(fromTime.Minute()), nil
+
+	case "second", "seconds":
+		return float64(fromTime.Second()) + float64(fromTime.Nanosecond())/float64(time.Second), nil
+
+	case "millisecond", "milliseconds":
+		// This a PG extension not supported in MySQL.
+		return float64(fromTime.Second()*duration.MillisPerSec) +
+			float64(fromTime.Nanosecond())/float64(time.Millisecond), nil
+
+	case "microsecond", "microseconds":
+		return float64(fromTime.Second()*duration.MillisPerSec*duration.MicrosPerMilli) +
+			float64(fromTime.Nanosecond())/float64(time.Microsecond), nil
+
+	case "epoch":
+		return float64(fromTime.UnixNano()) / float64(time.Second), nil
+
+	default:
+		return 0, pgerror.Newf(pgcode.InvalidParameterValue, "unsupported timespan: %s", timeSpan)
+	}
+}
+
+// dateToJulianDay is based on the date2j function in PostgreSQL 10.5.
+func dateToJulianDay(year int, month int, day int) int {
+	if month > 2 {
+		month++
+		year += 4800
+	} else {
+		month += 13
+		year += 4799
+	}
+
+	century := year / 100
+	jd := year*365 - 32167
+	jd += year/4 - century + century/4
+	jd += 7834*month/256 + day
+
+	return jd
+}
+
+// TruncateTimestamp truncates fromTime to the precision of the given element
+// (as accepted by the date_trunc builtin). Note that the result is not rounded
+// to any precision.
+func TruncateTimestamp(fromTime time.Time, timeSpan string) (time.Time, error) {
+	year := fromTime.Year()
+	month := fromTime.Month()
+	day := fromTime.Day()
+	hour := fromTime.Hour()
+	min := fromTime.Minute()
+	sec := fromTime.Second()
+	nsec := fromTime.Nanosecond()
+	loc := fromTime.Location()
+
+	monthTrunc := time.January
+	dayTrunc := 1
+	hourTrunc := 0
+	minTrunc := 0
+	secTrunc := 0
+	nsecTrunc := 0
+
+	switch timeSpan {
+	case "millennia", "millennium", "millenniums":
+		if year > 0 {
+			year = ((year+999)/1000)*1000 - 999
+		} else {
+			year = -((999-(year-1))/1000)*1000 + 1
+		}
+		month, day, hour, min, sec, nsec = monthTrunc, dayTrunc, hourTrunc, minTrunc, secTrunc, nsecTrunc
+
+	case "centuries", "century":
+		if year > 0 {
+			year = ((year+99)/100)*100 - 99
+		} else {
+			year = -((99-(year-1))/100)*100 + 1
+		}
+		month, day, hour, min, sec, nsec = monthTrunc, dayTrunc, hourTrunc, minTrunc, secTrunc, nsecTrunc
+
+	case "decade", "decades":
+		if year >= 0 {
+			year = (year / 10) * 10
+		} else {
+			year = -((8 - (year - 1)) / 10) * 10
+		}
+		month, day, hour, min, sec, nsec = monthTrunc, dayTrunc, hourTrunc, minTrunc, secTrunc, nsecTrunc
+
+	case "year", "years":
+		month, day, hour, min, sec, nsec = monthTrunc, dayTrunc, hourTrunc, minTrunc, secTrunc, nsecTrunc
+
+	case "quarter":
+		firstMonthInQuarter := ((month-1)/3)*3 + 1
+		month, day, hour, min, sec, nsec = firstMonthInQuarter, dayTrunc, hourTrunc, minTrunc, secTrunc, nsecTrunc
+
+	case "month", "months":
+		day, hour, min, sec, nsec = dayTrunc, hourTrunc, minTrunc, secTrunc, nsecTrunc
+
+	case "week", "weeks":
+		// Subtract (day of week * nanoseconds per day) to get Sunday, then add a day to get Monday.
+		previousMonday := fromTime.Add(-1 * time.Hour * 24 * time.Duration(fromTime.Weekday()-1))
+		if fromTime.Weekday() == time.Sunday {
+			// The math above does not work for Sunday, as it roll forward to the next Monday.
+			// As such, subtract six days instead.
+			previousMonday = fromTime.Add(-6 * time.Hour * 24)
+		}
+		year, month, day = previousMonday.Year(), previousMonday.Month(), previousMonday.Day()
+		hour, min, sec, nsec = hourTrunc, minTrunc, secTrunc, nsecTrunc
+
+	case "day", "days":
+		hour, min, sec, nsec = hourTrunc, minTrunc, secTrunc, nsecTrunc
+
+	case "hour", "hours":
+		min, sec, nsec = minTrunc, secTrunc, nsecTrunc
+
+	case "minute", "minutes":
+		sec, nsec = secTrunc, nsecTrunc
+
+	case "second", "seconds":
+		nsec = nsecTrunc
+
+	case "millisecond", "milliseconds":
+		// This a PG extension not supported in MySQL.
+		milliseconds := (nsec / int(time.Millisecond)) * int(time.Millisecond)
+		nsec = milliseconds
+
+	case "microsecond", "microseconds":
+		microseconds := (nsec / int(time.Microsecond)) * int(time.Microsecond)
+		nsec = microseconds
+
+	default:
+		return time.Time{}, pgerror.Newf(pgcode.InvalidParameterValue, "unsupported timespan: %s", timeSpan)
+	}
+
+	return time.Date(year, month, day, hour, min, sec, nsec, loc), nil
+}
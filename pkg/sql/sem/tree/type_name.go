@@ -13,6 +13,7 @@ package tree
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
@@ -194,7 +195,7 @@ func (ctx *FmtCtx) FormatTypeReference(ref ResolvableTypeReference) {
 				return
 			}
 		}
-		ctx.WriteString(t.SQLString())
+		ctx.WriteString(ctx.typeSQLString(t))
 
 	case *OIDTypeReference:
 		if ctx.indexedTypeFormatter != nil {
@@ -212,6 +213,121 @@ func (ctx *FmtCtx) FormatTypeReference(ref ResolvableTypeReference) {
 	}
 }
 
+// typeSQLString returns the SQL name of typ. Under FmtPostgres and FmtMySQL,
+// it is the name of the closest equivalent of typ in the dialect.
+func (ctx *FmtCtx) typeSQLString(typ *types.T) string {
+	if ctx.HasFlags(FmtPostgres) {
+		return postgresTypeSQLString(typ)
+	}
+	if ctx.HasFlags(FmtMySQL) {
+		return mysqlTypeSQLString(typ)
+	}
+	return typ.SQLString()
+}
+
+// postgresTypeSQLString returns the name of the closest Postgres equivalent of
+// typ.
+func postgresTypeSQLString(typ *types.T) string {
+	switch typ.Family() {
+	case types.IntFamily:
+		// Arithmetic on INT2 and INT4 values produces INT8 values in
+		// CockroachDB, so INT8 is used to get the same overflow behavior.
+		return "INT8"
+	case types.FloatFamily:
+		// Similarly, arithmetic on FLOAT4 values produces FLOAT8 values.
+		return "FLOAT8"
+	case types.StringFamily:
+		if typ.Oid() == oid.T_text {
+			if typ.Width() > 0 {
+				return fmt.Sprintf("VARCHAR(%d)", typ.Width())
+			}
+			return "TEXT"
+		}
+	case types.CollatedStringFamily:
+		if typ.Oid() == oid.T_text {
+			return "TEXT" + strings.TrimPrefix(typ.SQLString(), "STRING")
+		}
+	case types.BytesFamily:
+		return "BYTEA"
+	case types.ArrayFamily:
+		switch typ.Oid() {
+		case oid.T_oidvector, oid.T_int2vector:
+		default:
+			contents := typ.ArrayContents()
+			if contents.Family() != types.CollatedStringFamily {
+				return postgresTypeSQLString(contents) + "[]"
+			}
+			// The COLLATE clause of a collated string array follows the
+			// brackets.
+			if contents.Oid() == oid.T_text {
+				return "TEXT" + strings.TrimPrefix(typ.SQLString(), "STRING")
+			}
+		}
+	}
+	return typ.SQLString()
+}
+
+// mysqlTypeSQLString returns the name of the closest MySQL equivalent of typ
+// to be used as a column type.
+func mysqlTypeSQLString(typ *types.T) string {
+	switch typ.Family() {
+	case types.IntFamily:
+		switch typ.Width() {
+		case 16:
+			return "SMALLINT"
+		case 32:
+			return "INT"
+		}
+		return "BIGINT"
+	case types.FloatFamily:
+		if typ.Width() == 32 {
+			return "FLOAT"
+		}
+		return "DOUBLE"
+	case types.StringFamily:
+		if typ.Oid() == oid.T_text {
+			if typ.Width() > 0 {
+				return fmt.Sprintf("VARCHAR(%d)", typ.Width())
+			}
+			return "TEXT"
+		}
+	case types.BytesFamily:
+		return "BLOB"
+	case types.TimestampTZFamily:
+		return "TIMESTAMP"
+	case types.JsonFamily:
+		return "JSON"
+	}
+	return typ.SQLString()
+}
+
+// mysqlCastTypeSQLString returns the name of the closest MySQL equivalent of
+// typ to be used as the target type of a CAST expression. MySQL only supports
+// a few types there.
+func mysqlCastTypeSQLString(typ *types.T) string {
+	switch typ.Family() {
+	case types.IntFamily:
+		return "SIGNED"
+	case types.StringFamily:
+		return "CHAR"
+	case types.BytesFamily:
+		return "BINARY"
+	case types.TimestampFamily, types.TimestampTZFamily:
+		return "DATETIME"
+	}
+	return mysqlTypeSQLString(typ)
+}
+
+// typeAnnotationOperator returns the operator used to annotate the type of an
+// expression. Postgres doesn't support type annotations, so a cast is used
+// under FmtPostgres.
+func (ctx *FmtCtx) typeAnnotationOperator() string {
+	if ctx.HasFlags(FmtPostgres) {
+		return "::"
+	}
+	return ":::"
+}
+
 // GetStaticallyKnownType possibly promotes a ResolvableTypeReference into a
 // *types.T if the reference is a statically known type. It is only safe to
 // access the returned type if ok is true.
@@ -30,6 +30,26 @@ func VecModeCounter(mode string) telemetry.Counter {
 	return telemetry.GetCounter(fmt.Sprintf("sql.exec.vectorized-setting.%s", mode))
 }
 
+// VecFlowNotSupportedCounter is to be incremented whenever a flow falls back
+// to the row execution engine because the vectorized engine doesn't support
+// it.
+var VecFlowNotSupportedCounter = telemetry.GetCounterOnce("sql.exec.vectorized.flow-not-supported")
+
+// VecWrappedProcessorCounter is to be incremented every time a row execution
+// processor with the given core is wrapped into a vectorized flow.
+func VecWrappedProcessorCounter(core string) telemetry.Counter {
+	return telemetry.GetCounter(fmt.Sprintf("sql.exec.vectorized.wrapped-processor.%s", core))
+}
+
+// VecInternalErrorCounter is to be incremented whenever an internal error
+// (i.e. the vectorized engine was in an unexpected state) is returned by a
+// vectorized flow.
+var VecInternalErrorCounter = telemetry.GetCounterOnce("sql.exec.vectorized.internal-error")
+
+// VecOutOfMemoryErrorCounter is to be incremented whenever a vectorized flow
+// returns an error because a memory budget has been exceeded.
+var VecOutOfMemoryErrorCounter = telemetry.GetCounterOnce("sql.exec.vectorized.out-of-memory-error")
+
 // CascadesLimitReached is to be incremented whenever the limit of foreign key
 // cascade for a single query is exceeded.
 var CascadesLimitReached = telemetry.GetCounterOnce("sql.exec.cascade-limit-reached")
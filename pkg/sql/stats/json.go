@@ -12,8 +12,11 @@ package stats
 
 import (
 	"context"
+	"encoding/json"
 	fmt "fmt"
+	"time"
 
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
@@ -155,3 +158,55 @@ func (js *JSONStatistic) GetHistogram(
 	}
 	return h, nil
 }
+
+// MakeJSONStatistics converts the given table statistics into their JSON
+// representation. colNames is used to look up the names of the columns
+// referenced by each statistic.
+func MakeJSONStatistics(
+	tableStats []*TableStatistic, colNames map[descpb.ColumnID]string,
+) ([]JSONStatistic, error) {
+	jsonStats := make([]JSONStatistic, len(tableStats))
+	for i, s := range tableStats {
+		js := &jsonStats[i]
+		js.Name = s.Name
+		js.CreatedAt = tree.AsStringWithFlags(
+			tree.MustMakeDTimestamp(s.CreatedAt, time.Microsecond), tree.FmtBareStrings,
+		)
+		js.Columns = make([]string, len(s.ColumnIDs))
+		for j, colID := range s.ColumnIDs {
+			name, ok := colNames[colID]
+			if !ok {
+				return nil, errors.AssertionFailedf("no name for column %d", colID)
+			}
+			js.Columns[j] = name
+		}
+		js.RowCount = s.RowCount
+		js.DistinctCount = s.DistinctCount
+		js.NullCount = s.NullCount
+		if s.HistogramData != nil && len(s.HistogramData.Buckets) > 0 {
+			if err := js.SetHistogram(s.HistogramData); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return jsonStats, nil
+}
+
+// MakeAlterTableInjectStats returns an ALTER TABLE ... INJECT STATISTICS
+// statement which injects jsonStats into the given table.
+func MakeAlterTableInjectStats(
+	table *tree.UnresolvedObjectName, jsonStats []JSONStatistic,
+) (*tree.AlterTable, error) {
+	b, err := json.Marshal(jsonStats)
+	if err != nil {
+		return nil, err
+	}
+	j, err := tree.ParseDJSON(string(b))
+	if err != nil {
+		return nil, err
+	}
+	return &tree.AlterTable{
+		Table: table,
+		Cmds:  tree.AlterTableCmds{&tree.AlterTableInjectStats{Stats: j}},
+	}, nil
+}
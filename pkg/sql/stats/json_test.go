@@ -0,0 +1,72 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package stats
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+func TestMakeAlterTableInjectStats(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	tableStats := []*TableStatistic{{
+		TableStatisticProto: TableStatisticProto{
+			Name:          AutoStatsName,
+			ColumnIDs:     []descpb.ColumnID{1, 2},
+			CreatedAt:     time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+			RowCount:      100,
+			DistinctCount: 10,
+			NullCount:     1,
+		},
+	}}
+	colNames := map[descpb.ColumnID]string{1: "a", 2: "b"}
+
+	jsonStats, err := MakeJSONStatistics(tableStats, colNames)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jsonStats) != 1 {
+		t.Fatalf("expected 1 statistic, got %d", len(jsonStats))
+	}
+	js := jsonStats[0]
+	if js.CreatedAt != "2000-01-01 00:00:00" {
+		t.Errorf("unexpected created_at: %s", js.CreatedAt)
+	}
+	if len(js.Columns) != 2 || js.Columns[0] != "a" || js.Columns[1] != "b" {
+		t.Errorf("unexpected columns: %v", js.Columns)
+	}
+
+	tn := tree.MakeUnqualifiedTableName("t")
+	alter, err := MakeAlterTableInjectStats(tn.ToUnresolvedObjectName(), jsonStats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := tree.AsString(alter)
+	if !strings.HasPrefix(s, "ALTER TABLE t INJECT STATISTICS '") {
+		t.Errorf("unexpected statement: %s", s)
+	}
+	if !strings.Contains(s, `"row_count": 100`) {
+		t.Errorf("expected row count in statement: %s", s)
+	}
+
+	// A statistic referencing an unknown column is an error.
+	if _, err := MakeJSONStatistics(tableStats, map[descpb.ColumnID]string{1: "a"}); err == nil {
+		t.Error("expected error for unknown column")
+	}
+}
@@ -468,9 +468,9 @@ func (sc *TableStatisticsCache) parseStats(
 			TableID:       descpb.ID((int32)(*datums[tableIDIndex].(*tree.DInt))),
 			StatisticID:   (uint64)(*datums[statisticsIDIndex].(*tree.DInt)),
 			CreatedAt:     datums[createdAtIndex].(*tree.DTimestamp).Time,
-			RowCount:      (uint64)(*datums[rowCountIndex].(*tree.DInt)),
-			DistinctCount: (uint64)(*datums[distinctCountIndex].(*tree.DInt)),
-			NullCount:     (uint64)(*datums[nullCountIndex].(*tree.DInt)),
+			RowCount:      cat.StatCount(int64(*datums[rowCountIndex].(*tree.DInt))),
+			DistinctCount: cat.StatCount(int64(*datums[distinctCountIndex].(*tree.DInt))),
+			NullCount:     cat.StatCount(int64(*datums[nullCountIndex].(*tree.DInt))),
 		},
 	}
 	columnIDs := datums[columnIDsIndex].(*tree.DArray)
@@ -543,8 +543,8 @@ func (sc *TableStatisticsCache) parseStats(
 				return nil, err
 			}
 			res.Histogram[i] = cat.HistogramBucket{
-				NumEq:         float64(bucket.NumEq),
-				NumRange:      float64(bucket.NumRange),
+				NumEq:         cat.HistogramBucketCount(bucket.NumEq),
+				NumRange:      cat.HistogramBucketCount(bucket.NumRange),
 				DistinctRange: bucket.DistinctRange,
 				UpperBound:    datum,
 			}
@@ -88,3 +88,15 @@ func MulHalfPositiveWithOverflow(a, b int64) (r int64, ok bool) {
 	}
 	return a * b, true
 }
+
+// MulWithOverflow returns a*b. If ok is false, a*b overflowed.
+func MulWithOverflow(a, b int64) (r int64, ok bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	r = a * b
+	if (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) || r/b != a {
+		return 0, false
+	}
+	return r, true
+}
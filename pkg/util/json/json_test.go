@@ -1853,6 +1853,59 @@ func TestJSONRandomRoundTrip(t *testing.T) {
 	}
 }
 
+func TestRandomSchema(t *testing.T) {
+	rng := rand.New(rand.NewSource(timeutil.Now().Unix()))
+	for i := 0; i < 20; i++ {
+		schema := MakeRandomSchema(20, rng)
+		// pathTypes contains the type of the values at every path of the
+		// documents, where the elements of arrays are at the path of the
+		// array.
+		pathTypes := map[string]Type{}
+		var check func(path string, j JSON)
+		check = func(path string, j JSON) {
+			typ := j.Type()
+			switch typ {
+			case NullJSONType:
+				return
+			case FalseJSONType:
+				typ = TrueJSONType
+			}
+			if expected, ok := pathTypes[path]; ok && expected != typ {
+				t.Fatalf("expected type %v at %s, found %v", expected, path, typ)
+			}
+			pathTypes[path] = typ
+			switch typ {
+			case ArrayJSONType:
+				for idx := 0; idx < j.Len(); idx++ {
+					elem, err := j.FetchValIdx(idx)
+					if err != nil {
+						t.Fatal(err)
+					}
+					check(path+"[]", elem)
+				}
+			case ObjectJSONType:
+				it, err := j.ObjectIter()
+				if err != nil {
+					t.Fatal(err)
+				}
+				for it.Next() {
+					check(path+"."+strconv.Quote(it.Key()), it.Value())
+				}
+			}
+		}
+		for j := 0; j < 100; j++ {
+			doc, err := schema.Generate(rng)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if doc.Type() != ObjectJSONType {
+				t.Fatalf("expected object, found %s", doc)
+			}
+			check("", doc)
+		}
+	}
+}
+
 func TestJSONContains(t *testing.T) {
 	cases := map[string][]struct {
 		other    string
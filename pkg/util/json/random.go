@@ -90,3 +90,122 @@ func doRandomJSON(complexity int, rng *rand.Rand) interface{} {
 		return encoded
 	}
 }
+
+// RandomSchema is a random schema of JSON documents, which is a template for
+// the documents generated by its Generate method. All documents have the same
+// structure of nested objects and arrays, the objects have the same keys, and
+// the values at the same paths have the same types, like the rows of a table
+// that stores JSON documents of an application.
+type RandomSchema struct {
+	root *randomSchemaNode
+}
+
+type randomSchemaKind int
+
+const (
+	randomSchemaString randomSchemaKind = iota
+	randomSchemaNumber
+	randomSchemaBool
+	randomSchemaObject
+	randomSchemaArray
+)
+
+// randomSchemaNode is the schema of a value of a document.
+type randomSchemaNode struct {
+	kind randomSchemaKind
+	// fields are the fields of objects.
+	fields []randomSchemaField
+	// elem is the schema of the elements of arrays.
+	elem *randomSchemaNode
+}
+
+// randomSchemaField is the schema of a field of an object.
+type randomSchemaField struct {
+	key   string
+	value *randomSchemaNode
+	// optional fields are missing from some documents.
+	optional bool
+}
+
+// MakeRandomSchema generates a random schema of JSON documents. The documents
+// are objects with about complexity values.
+func MakeRandomSchema(complexity int, rng *rand.Rand) *RandomSchema {
+	if complexity < 1 {
+		complexity = 1
+	}
+	return &RandomSchema{root: makeRandomSchemaObject(complexity, rng)}
+}
+
+func makeRandomSchemaNode(complexity int, rng *rand.Rand) *randomSchemaNode {
+	if complexity <= 1 || rng.Intn(10) == 0 {
+		return &randomSchemaNode{kind: randomSchemaKind(rng.Intn(int(randomSchemaBool) + 1))}
+	}
+	if rng.Intn(3) == 0 {
+		// The elements of arrays are the values that are repeated, so they
+		// get all of the complexity.
+		return &randomSchemaNode{kind: randomSchemaArray, elem: makeRandomSchemaNode(complexity-1, rng)}
+	}
+	return makeRandomSchemaObject(complexity, rng)
+}
+
+func makeRandomSchemaObject(complexity int, rng *rand.Rand) *randomSchemaNode {
+	node := &randomSchemaNode{kind: randomSchemaObject}
+	keys := make(map[string]bool)
+	for complexity > 0 {
+		amount := 1 + rng.Intn(complexity)
+		complexity -= amount
+		key := randomJSONString(rng).(string)
+		if keys[key] {
+			continue
+		}
+		keys[key] = true
+		node.fields = append(node.fields, randomSchemaField{
+			key:      key,
+			value:    makeRandomSchemaNode(amount, rng),
+			optional: rng.Intn(4) == 0,
+		})
+	}
+	return node
+}
+
+// Generate generates a random JSON document that conforms to the schema.
+// Optional fields of objects are missing some of the time, and values are
+// sometimes null.
+func (s *RandomSchema) Generate(rng *rand.Rand) (JSON, error) {
+	return MakeJSON(s.root.generate(rng))
+}
+
+func (n *randomSchemaNode) generate(rng *rand.Rand) interface{} {
+	switch n.kind {
+	case randomSchemaString:
+		if rng.Intn(10) == 0 {
+			return nil
+		}
+		return randomJSONString(rng)
+	case randomSchemaNumber:
+		if rng.Intn(10) == 0 {
+			return nil
+		}
+		return randomJSONNumber(rng)
+	case randomSchemaBool:
+		if rng.Intn(10) == 0 {
+			return nil
+		}
+		return rng.Intn(2) == 0
+	case randomSchemaArray:
+		result := make([]interface{}, rng.Intn(4))
+		for i := range result {
+			result[i] = n.elem.generate(rng)
+		}
+		return result
+	default:
+		result := make(map[string]interface{}, len(n.fields))
+		for _, f := range n.fields {
+			if f.optional && rng.Intn(2) == 0 {
+				continue
+			}
+			result[f.key] = f.value.generate(rng)
+		}
+		return result
+	}
+}
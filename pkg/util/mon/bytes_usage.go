@@ -216,7 +216,8 @@ type BytesMonitor struct {
 	// hit constraints on the owner monitor. This is useful to limit allocations
 	// when an owner monitor has a larger capacity than wanted but should still
 	// keep track of allocations made through this monitor. Note that child
-	// monitors are affected by this limit.
+	// monitors are affected by this limit. The limit can be updated via
+	// SetLimit, so it must only be accessed while holding mu.
 	limit int64
 
 	// poolAllocationSize specifies the allocation unit for requests to the
@@ -457,6 +458,32 @@ func (mm *BytesMonitor) Resource() Resource {
 	return mm.resource
 }
 
+// Name returns the name of the monitor.
+func (mm *BytesMonitor) Name() string {
+	return mm.name
+}
+
+// Limit returns the limit of the monitor local to this monitor (math.MaxInt64
+// if the monitor doesn't have a local limit).
+func (mm *BytesMonitor) Limit() int64 {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	return mm.limit
+}
+
+// SetLimit updates the limit local to this monitor. A non-positive limit
+// removes the local limit. Note that lowering the limit below the number of
+// bytes currently allocated doesn't release any of them; it only makes the
+// further reservations fail.
+func (mm *BytesMonitor) SetLimit(limit int64) {
+	if limit <= 0 {
+		limit = math.MaxInt64
+	}
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	mm.limit = limit
+}
+
 // BoundAccount tracks the cumulated allocations for one client of a pool or
 // monitor. BytesMonitor has an account to its pool; BytesMonitor clients have
 // an account to the monitor. This allows each client to release all the bytes